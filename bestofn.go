@@ -0,0 +1,173 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/eval"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Sample is one sampled response of BestOfN.
+type Sample struct {
+	// Response is the sampled response, or nil if its request failed.
+	Response *types.CompletionResponse
+
+	// Score is the scorer's verdict on the response.
+	Score eval.Score
+
+	// Error is why the request or the scorer failed.
+	Error error
+}
+
+// BestOfNResult is the outcome of BestOfN.
+type BestOfNResult struct {
+	// Best is the highest-scoring response.
+	Best *types.CompletionResponse
+
+	// Score is Best's score.
+	Score eval.Score
+
+	// Samples are all sampled responses, in the order they were sampled,
+	// including Best and the failed ones.
+	Samples []Sample
+
+	// Usage is the total usage of the samples. The scorer's own requests,
+	// such as a judge's, are not included.
+	Usage types.Usage
+}
+
+// BestOfN samples n responses to req and returns the one scorer rates
+// highest, with every sample attached. Providers with FeatureCandidates
+// return the samples in one call (see CompletionRequest.N); others get n
+// concurrent calls, which WithRequestCoalescing leaves separate. Each call
+// has its own RequestID: the caller's with a "-1", "-2", ... suffix if it
+// set one. Sampling is only diverse at a non-zero temperature.
+//
+// Any eval.Scorer works, including an LLM judge made with eval.Judge. With
+// a nil scorer, samples are rated by self-consistency: the share of the
+// samples that gave the same answer, ignoring case and whitespace, so
+// the most common answer wins. Ties go to the earliest sample. BestOfN
+// fails only if every request or every score fails.
+func (r *Router) BestOfN(ctx context.Context, req *types.CompletionRequest, n int, scorer eval.Scorer) (*BestOfNResult, error) {
+	if n < 1 {
+		return nil, errors.ErrInvalidRequest("BestOfN needs n of at least 1")
+	}
+
+	result := &BestOfNResult{}
+	if n > 1 && r.SupportsFeature(req.Provider, types.FeatureCandidates) {
+		sampled := *req
+		sampled.N = n
+		resp, err := r.Complete(ctx, &sampled)
+		if err != nil {
+			return nil, err
+		}
+		alternatives := resp.Alternatives
+		resp.Alternatives = nil
+		result.Samples = append(result.Samples, Sample{Response: resp})
+		for i := range alternatives {
+			alt := &alternatives[i]
+			alt.RequestID = resp.RequestID
+			result.Samples = append(result.Samples, Sample{Response: alt})
+		}
+		result.Usage = resp.Usage
+	} else {
+		reqs := make([]*types.CompletionRequest, n)
+		for i := range reqs {
+			sample := *req
+			if req.RequestID != "" {
+				sample.RequestID = fmt.Sprintf("%s-%d", req.RequestID, i+1)
+			}
+			reqs[i] = &sample
+		}
+		responses, err := r.CompleteAll(withoutCoalescing(ctx), reqs, WithFanOutConcurrency(n))
+		var fanErr *FanOutError
+		if err != nil && !stderrors.As(err, &fanErr) {
+			return nil, err
+		}
+		for i, resp := range responses {
+			c := Sample{Response: resp}
+			if fanErr != nil {
+				c.Error = fanErr.Errors[i]
+			}
+			if resp != nil {
+				result.Usage.Add(resp.Usage)
+			}
+			result.Samples = append(result.Samples, c)
+		}
+		if fanErr != nil && len(fanErr.Failed()) == n {
+			return nil, fanErr.Errors[0]
+		}
+	}
+
+	scoreSamples(ctx, req, result.Samples, scorer)
+
+	best := -1
+	for i, c := range result.Samples {
+		if c.Error == nil && (best < 0 || c.Score.Value > result.Samples[best].Score.Value) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return nil, result.Samples[0].Error
+	}
+	result.Best = result.Samples[best].Response
+	result.Score = result.Samples[best].Score
+	return result, nil
+}
+
+// scoreSamples scores the successful samples concurrently, by
+// self-consistency if scorer is nil.
+func scoreSamples(ctx context.Context, req *types.CompletionRequest, samples []Sample, scorer eval.Scorer) {
+	if scorer == nil {
+		scoreConsistency(samples)
+		return
+	}
+
+	c := &eval.Case{Request: *req}
+	var wg sync.WaitGroup
+	for i := range samples {
+		if samples[i].Response == nil {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			samples[i].Score, samples[i].Error = scorer.Score(ctx, c, samples[i].Response)
+		}()
+	}
+	wg.Wait()
+}
+
+// scoreConsistency scores each sample by the share of samples with
+// the same answer. A majority passes.
+func scoreConsistency(samples []Sample) {
+	answers := make(map[string]int)
+	for _, c := range samples {
+		if c.Response != nil {
+			answers[normalizeAnswer(c.Response.Text())]++
+		}
+	}
+	for i, c := range samples {
+		if c.Response == nil {
+			continue
+		}
+		agree := answers[normalizeAnswer(c.Response.Text())]
+		value := float64(agree) / float64(len(samples))
+		samples[i].Score = eval.Score{
+			Scorer: "consistency",
+			Value:  value,
+			Pass:   value > 0.5,
+			Reason: fmt.Sprintf("%d of %d samples gave this answer", agree, len(samples)),
+		}
+	}
+}
+
+// normalizeAnswer lowercases text and collapses its whitespace.
+func normalizeAnswer(text string) string {
+	return strings.Join(strings.Fields(strings.ToLower(text)), " ")
+}
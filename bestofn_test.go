@@ -0,0 +1,143 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/eval"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestBestOfN_Candidates(t *testing.T) {
+	var calls atomic.Int32
+	var n int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var body struct {
+			N int `json:"n"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		n = body.N
+		fmt.Fprint(w, `{"id":"1","model":"gpt-4o","choices":[
+			{"index":0,"message":{"role":"assistant","content":"Paris."},"finish_reason":"stop"},
+			{"index":1,"message":{"role":"assistant","content":"Lyon"},"finish_reason":"stop"},
+			{"index":2,"message":{"role":"assistant","content":" paris. "},"finish_reason":"stop"}
+		],"usage":{"prompt_tokens":10,"completion_tokens":6,"total_tokens":16}}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.BestOfN(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Capital of France?")},
+	}, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if calls.Load() != 1 || n != 3 {
+		t.Errorf("calls = %d with n = %d, want one call with n = 3", calls.Load(), n)
+	}
+	if len(result.Samples) != 3 || result.Best.Text() != "Paris." {
+		t.Fatalf("best = %q of %d samples", result.Best.Text(), len(result.Samples))
+	}
+	if result.Score.Scorer != "consistency" || result.Score.Value < 0.66 || !result.Score.Pass {
+		t.Errorf("score = %+v", result.Score)
+	}
+	if s := result.Samples[1].Score; s.Pass || s.Value > 0.34 {
+		t.Errorf("minority score = %+v", s)
+	}
+	if result.Best.Alternatives != nil || result.Usage.TotalTokens != 16 {
+		t.Errorf("alternatives = %v, usage = %+v", result.Best.Alternatives, result.Usage)
+	}
+}
+
+// lengthScorer prefers longer answers.
+type lengthScorer struct{}
+
+func (lengthScorer) Name() string { return "length" }
+func (lengthScorer) Score(_ context.Context, _ *eval.Case, resp *types.CompletionResponse) (eval.Score, error) {
+	return eval.Score{Scorer: "length", Value: float64(len(resp.Text())) / 10, Pass: true}, nil
+}
+
+func TestBestOfN_ConcurrentCalls(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["n"] != nil {
+			t.Errorf("n = %v, want none", body["n"])
+		}
+		text := []string{"a", "abc", "ab"}[calls.Add(1)-1]
+		fmt.Fprintf(w, `{"id":"1","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}],"usage":{"prompt_tokens":5,"completion_tokens":1,"total_tokens":6}}`, text)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithDeepSeek("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.BestOfN(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderDeepSeek,
+		Model:    "deepseek-chat",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	}, 3, lengthScorer{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 || len(result.Samples) != 3 {
+		t.Fatalf("calls = %d, samples = %d", calls.Load(), len(result.Samples))
+	}
+	if result.Best.Text() != "abc" || result.Score.Value != 0.3 {
+		t.Errorf("best = %q with %+v", result.Best.Text(), result.Score)
+	}
+	if result.Usage.TotalTokens != 18 {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+}
+
+func TestBestOfN_Coalescing(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		text := fmt.Sprint("answer ", calls.Add(1))
+		fmt.Fprintf(w, `{"id":"1","model":"deepseek-chat","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, text)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithDeepSeek("key", provider.WithBaseURL(srv.URL)), WithRequestCoalescing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := r.BestOfN(context.Background(), &types.CompletionRequest{
+		Provider:  types.ProviderDeepSeek,
+		Model:     "deepseek-chat",
+		RequestID: "req",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	}, 3, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want one per sample", calls.Load())
+	}
+	ids := make(map[string]bool)
+	for _, s := range result.Samples {
+		ids[s.Response.RequestID] = true
+	}
+	if !ids["req-1"] || !ids["req-2"] || !ids["req-3"] {
+		t.Errorf("request IDs = %v, want one per sample", ids)
+	}
+	if result.Score.Value > 0.34 {
+		t.Errorf("score = %+v, want disagreeing samples", result.Score)
+	}
+}
@@ -0,0 +1,151 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	// messageOverheadTokens is the estimated cost of a message's role and
+	// separators.
+	messageOverheadTokens = 4
+
+	// imageTokens is the estimated cost of an image, roughly a 1024x1024
+	// image on most providers.
+	imageTokens = 1000
+)
+
+// EstimateTokenBreakdown estimates the input tokens of req by part of the
+// prompt from the size of each part, at about four characters per token.
+// It does not call the provider.
+func EstimateTokenBreakdown(req *types.CompletionRequest) *types.TokenBreakdown {
+	b := &types.TokenBreakdown{Messages: make([]int, len(req.Messages)), Estimated: true}
+	for i, msg := range req.Messages {
+		b.Messages[i] = messageOverheadTokens + estimateBlocks(msg.Content)
+	}
+	for _, tool := range req.Tools {
+		params, _ := json.Marshal(tool.Parameters)
+		b.Tools += estimateText(tool.Name) + estimateText(tool.Description) + estimateText(string(params))
+	}
+	for _, doc := range req.Documents {
+		b.Documents += estimateText(doc.Title) + estimateText(doc.Text)
+		for k, v := range doc.Fields {
+			b.Documents += estimateText(k) + estimateText(v)
+		}
+	}
+	sumSections(b, req)
+	return b
+}
+
+// TokenBreakdown returns the input tokens of req by part of the prompt. On
+// providers with FeatureTokenCounting the total is the provider's count and
+// the parts are scaled to it; elsewhere the breakdown is estimated as by
+// EstimateTokenBreakdown. Model aliases are resolved as by Complete.
+func (r *Router) TokenBreakdown(ctx context.Context, req *types.CompletionRequest) (*types.TokenBreakdown, error) {
+	req, err := r.resolveAlias(req)
+	if err != nil {
+		return nil, err
+	}
+	p, err := r.providerFor(req)
+	if err != nil {
+		return nil, err
+	}
+
+	b := EstimateTokenBreakdown(req)
+	if _, ok := p.(provider.TokenCounter); !ok || !p.SupportsFeature(types.FeatureTokenCounting) {
+		return b, nil
+	}
+	total, err := r.CountTokens(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	calibrateBreakdown(b, req, total)
+	return b, nil
+}
+
+// withTokenBreakdown sets the token breakdown of a response to a request
+// that asks for it, scaled to the input tokens the provider reported.
+func withTokenBreakdown(req *types.CompletionRequest, resp *types.CompletionResponse) {
+	if !req.IncludeTokenBreakdown || resp == nil {
+		return
+	}
+	b := EstimateTokenBreakdown(req)
+	calibrateBreakdown(b, req, resp.Usage.InputTokens)
+	resp.TokenBreakdown = b
+}
+
+// calibrateBreakdown scales an estimated breakdown so its parts sum to the
+// provider's total. A total of zero leaves the estimate.
+func calibrateBreakdown(b *types.TokenBreakdown, req *types.CompletionRequest, total int) {
+	if total <= 0 || b.Total <= 0 {
+		return
+	}
+	scale := float64(total) / float64(b.Total)
+	parts := make([]*int, 0, len(b.Messages)+2)
+	for i := range b.Messages {
+		parts = append(parts, &b.Messages[i])
+	}
+	parts = append(parts, &b.Tools, &b.Documents)
+
+	sum := 0
+	largest := parts[0]
+	for _, n := range parts {
+		*n = int(math.Round(float64(*n) * scale))
+		sum += *n
+		if *n > *largest {
+			largest = n
+		}
+	}
+	// Rounding leaves a few tokens over or under; the largest part absorbs
+	// them least visibly.
+	*largest += total - sum
+
+	sumSections(b, req)
+	b.Estimated = false
+}
+
+// sumSections sets the system, history, and total counts of b from its
+// messages, tools, and documents.
+func sumSections(b *types.TokenBreakdown, req *types.CompletionRequest) {
+	b.System, b.History = 0, 0
+	for i, msg := range req.Messages {
+		if msg.Role == types.RoleSystem {
+			b.System += b.Messages[i]
+		} else {
+			b.History += b.Messages[i]
+		}
+	}
+	b.Total = b.System + b.History + b.Tools + b.Documents
+}
+
+// estimateBlocks estimates the tokens of content blocks.
+func estimateBlocks(blocks []types.ContentBlock) int {
+	n := 0
+	for _, block := range blocks {
+		switch block.Type {
+		case types.ContentTypeImage:
+			n += imageTokens
+		case types.ContentTypeToolUse:
+			input, _ := json.Marshal(block.ToolInput)
+			n += estimateText(block.ToolName) + estimateText(string(input))
+		case types.ContentTypeToolResult:
+			if len(block.ToolResultContent) > 0 {
+				n += estimateBlocks(block.ToolResultContent)
+			} else {
+				n += estimateText(block.Text)
+			}
+		default:
+			n += estimateText(block.Text)
+		}
+	}
+	return n
+}
+
+// estimateText estimates the tokens of text at four characters per token.
+func estimateText(text string) int {
+	return (len(text) + 3) / 4
+}
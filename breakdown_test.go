@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func breakdownRequest(p types.Provider, model string) *types.CompletionRequest {
+	return &types.CompletionRequest{
+		Provider: p,
+		Model:    model,
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleSystem, strings.Repeat("Be helpful. ", 100)),
+			types.NewTextMessage(types.RoleUser, "What is in the report?"),
+		},
+		Tools:     []types.Tool{{Name: "search", Description: "Search the web", Parameters: types.JSONSchema{Type: "object"}}},
+		Documents: []types.Document{{Title: "Report", Text: strings.Repeat("Revenue grew. ", 200)}},
+	}
+}
+
+func TestEstimateTokenBreakdown(t *testing.T) {
+	b := EstimateTokenBreakdown(breakdownRequest(types.ProviderOpenAI, "gpt-4o"))
+	if !b.Estimated || len(b.Messages) != 2 {
+		t.Fatalf("breakdown = %+v", b)
+	}
+	if b.System != b.Messages[0] || b.History != b.Messages[1] || b.System != 4+300 {
+		t.Errorf("system %d, history %d, messages %v", b.System, b.History, b.Messages)
+	}
+	if b.Documents != 2+700 || b.Tools == 0 {
+		t.Errorf("documents %d, tools %d", b.Documents, b.Tools)
+	}
+	if b.Total != b.System+b.History+b.Tools+b.Documents {
+		t.Errorf("total %d is not the sum of the parts", b.Total)
+	}
+}
+
+func TestComplete_TokenBreakdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"c1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Growth."},"finish_reason":"stop"}],"usage":{"prompt_tokens":1500,"completion_tokens":2,"total_tokens":1502}}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := breakdownRequest(types.ProviderOpenAI, "gpt-4o")
+	req.Documents = nil // OpenAI takes no documents
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.TokenBreakdown != nil {
+		t.Error("breakdown set without being requested")
+	}
+
+	req.IncludeTokenBreakdown = true
+	resp, err = r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := resp.TokenBreakdown
+	if b == nil || b.Estimated || b.Total != 1500 {
+		t.Fatalf("breakdown = %+v, want the provider's total", b)
+	}
+	if b.System+b.History+b.Tools+b.Documents != 1500 {
+		t.Errorf("parts %+v do not sum to the total", b)
+	}
+	if b.Documents != 0 || b.System <= b.History {
+		t.Errorf("parts %+v lost their proportions", b)
+	}
+}
+
+func TestTokenBreakdown(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"input_tokens":2000}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)), WithOpenAI("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	b, err := r.TokenBreakdown(context.Background(), breakdownRequest(types.ProviderAnthropic, "claude-haiku-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Estimated || b.Total != 2000 || b.System+b.History+b.Tools+b.Documents != 2000 {
+		t.Errorf("anthropic breakdown = %+v, want parts of the counted total", b)
+	}
+
+	b, err = r.TokenBreakdown(context.Background(), breakdownRequest(types.ProviderOpenAI, "gpt-4o"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Estimated {
+		t.Errorf("openai breakdown = %+v, want an estimate", b)
+	}
+}
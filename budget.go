@@ -0,0 +1,229 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// budgetOutputTokens is the output assumed when estimating the cost of a
+// request without MaxTokens.
+const budgetOutputTokens = 4096
+
+// BudgetLimits configures spend enforcement. Costs are estimated in USD from
+// the list prices in the models catalog; requests for uncataloged models are
+// free as far as the budget is concerned.
+type BudgetLimits struct {
+	// Limit is the maximum spend per key in each window. Zero disables it.
+	Limit float64
+
+	// Window is the period after which spend resets. Zero never resets.
+	Window time.Duration
+
+	// PerRequest is the maximum estimated cost of a single request. Zero
+	// disables it.
+	PerRequest float64
+
+	// Downgrade maps a model or model alias to a cheaper one to use instead
+	// of rejecting a request that would exceed a limit. Aliases are resolved
+	// as usual; other models stay on the request's provider.
+	Downgrade map[string]string
+
+	// Key scopes spend, e.g. by API key. The default is the request's
+	// TenantID, so each tenant (and requests without one) has its own budget.
+	Key func(req *types.CompletionRequest) string
+}
+
+// WithBudget enforces spend limits. Before each request the router estimates
+// its cost from the prompt length and MaxTokens; a request that would exceed
+// a limit is downgraded if possible and otherwise fails with a
+// budget_exceeded error. Completed requests and streams are charged their
+// actual usage, and failed ones nothing; a stream closed before it ends is
+// charged its estimate.
+func WithBudget(limits BudgetLimits) Option {
+	return func(r *Router) {
+		r.budget = &budget{limits: limits, windows: make(map[string]*budgetWindow)}
+	}
+}
+
+// Spend returns the estimated spend in USD for a budget key in the current
+// window.
+func (r *Router) Spend(key string) float64 {
+	if r.budget == nil {
+		return 0
+	}
+	r.budget.mu.Lock()
+	defer r.budget.mu.Unlock()
+	return r.budget.windowLocked(key).spent
+}
+
+type budget struct {
+	limits BudgetLimits
+
+	mu      sync.Mutex
+	windows map[string]*budgetWindow
+}
+
+type budgetWindow struct {
+	start time.Time
+	spent float64
+}
+
+// reservation is the estimated cost held against a budget while a request is
+// in flight.
+type reservation struct {
+	key      string
+	window   *budgetWindow
+	estimate float64
+	provider types.Provider
+	model    string
+}
+
+// admit reserves the estimated cost of routed, the resolved form of req. If
+// the request does not fit, it follows the Downgrade chain from req.Model and
+// returns the first cheaper request that fits. A nil budget admits everything.
+func (b *budget) admit(r *Router, req, routed *types.CompletionRequest) (*types.CompletionRequest, *reservation, error) {
+	if b == nil {
+		return routed, nil, nil
+	}
+
+	key := req.TenantID
+	if b.limits.Key != nil {
+		key = b.limits.Key(req)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	w := b.windowLocked(key)
+	estimate := estimateCost(routed)
+	reason := b.exceedsLocked(w, estimate)
+	if reason == "" {
+		return routed, b.reserveLocked(key, w, routed, estimate), nil
+	}
+
+	seen := map[string]bool{req.Model: true}
+	for from := req.Model; ; {
+		to, ok := b.limits.Downgrade[from]
+		if !ok || seen[to] {
+			break
+		}
+		seen[to] = true
+		from = to
+
+		downgraded := *req
+		downgraded.Model = to
+		if r.isAlias(to) {
+			downgraded.Provider = ""
+		} else {
+			downgraded.Provider = routed.Provider
+		}
+		candidate, err := r.resolveAlias(&downgraded)
+		if err != nil {
+			continue
+		}
+		estimate := estimateCost(candidate)
+		if b.exceedsLocked(w, estimate) == "" {
+			return candidate, b.reserveLocked(key, w, candidate, estimate), nil
+		}
+	}
+
+	if key != "" {
+		reason = fmt.Sprintf("%s for %q", reason, key)
+	}
+	return nil, nil, errors.ErrBudgetExceeded(reason).WithProvider(routed.Provider)
+}
+
+// settle replaces a reservation's estimate with the actual cost of the
+// response, or releases it if the request failed.
+func (b *budget) settle(res *reservation, resp *types.CompletionResponse, err error) {
+	if b == nil || res == nil {
+		return
+	}
+
+	actual := 0.0
+	if err == nil && resp != nil {
+		actual = models.Cost(res.provider, res.model, resp.Usage)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if w := b.windowLocked(res.key); w == res.window {
+		w.spent += actual - res.estimate
+	} else {
+		// The window rolled over while the request was in flight.
+		w.spent += actual
+	}
+}
+
+// budgetStream settles a stream's reservation when the stream ends.
+type budgetStream struct {
+	types.StreamReader
+	budget *budget
+	res    *reservation
+	once   sync.Once
+}
+
+func (s *budgetStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	switch {
+	case err != nil:
+		s.once.Do(func() { s.budget.settle(s.res, nil, err) })
+	case event == nil:
+		s.once.Do(func() { s.budget.settle(s.res, s.StreamReader.Response(), nil) })
+	case event.Type == types.StreamEventDone && event.Usage != nil:
+		s.once.Do(func() { s.budget.settle(s.res, &types.CompletionResponse{Usage: *event.Usage}, nil) })
+	}
+	return event, err
+}
+
+func (b *budget) exceedsLocked(w *budgetWindow, estimate float64) string {
+	if b.limits.PerRequest > 0 && estimate > b.limits.PerRequest {
+		return fmt.Sprintf("estimated cost $%.4f exceeds the per-request limit of $%.4f", estimate, b.limits.PerRequest)
+	}
+	if b.limits.Limit > 0 && w.spent+estimate > b.limits.Limit {
+		return fmt.Sprintf("estimated cost $%.4f would exceed the remaining budget of $%.4f", estimate, max(0, b.limits.Limit-w.spent))
+	}
+	return ""
+}
+
+func (b *budget) reserveLocked(key string, w *budgetWindow, req *types.CompletionRequest, estimate float64) *reservation {
+	w.spent += estimate
+	return &reservation{key: key, window: w, estimate: estimate, provider: req.Provider, model: req.Model}
+}
+
+// windowLocked returns the current window for a key, starting a new one if
+// the previous window has ended.
+func (b *budget) windowLocked(key string) *budgetWindow {
+	w := b.windows[key]
+	if w == nil || (b.limits.Window > 0 && time.Since(w.start) >= b.limits.Window) {
+		w = &budgetWindow{start: time.Now()}
+		b.windows[key] = w
+	}
+	return w
+}
+
+// estimateCost estimates the list price of a request, assuming four
+// characters per input token and MaxTokens of output.
+func estimateCost(req *types.CompletionRequest) float64 {
+	info, ok := models.Lookup(req.Provider, req.Model)
+	if !ok {
+		return 0
+	}
+
+	chars := 0
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			chars += len(block.Text)
+		}
+	}
+	output := budgetOutputTokens
+	if req.MaxTokens != nil {
+		output = *req.MaxTokens
+	}
+	return info.Cost(types.Usage{InputTokens: chars / 4, OutputTokens: output})
+}
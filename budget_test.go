@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// newBudgetRouter returns a router whose Anthropic provider reports 1000
+// input and 200 output tokens per request, and records the models requested.
+func newBudgetRouter(t *testing.T, limits BudgetLimits) (*Router, *[]string) {
+	t.Helper()
+	var requested []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		requested = append(requested, body.Model)
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":%q,"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1000,"output_tokens":200}}`, body.Model)
+	}))
+	t.Cleanup(srv.Close)
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)), WithBudget(limits))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r, &requested
+}
+
+func budgetRequest(model string) *types.CompletionRequest {
+	maxTokens := 100
+	return &types.CompletionRequest{
+		Provider:  types.ProviderAnthropic,
+		Model:     model,
+		MaxTokens: &maxTokens,
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	}
+}
+
+func TestBudget_Limit(t *testing.T) {
+	r, _ := newBudgetRouter(t, BudgetLimits{Limit: 0.005})
+	ctx := context.Background()
+
+	if _, err := r.Complete(ctx, budgetRequest("claude-sonnet-4-5")); err != nil {
+		t.Fatal(err)
+	}
+	// 1000 input tokens at $3/MTok plus 200 output tokens at $15/MTok.
+	if spend := r.Spend(""); math.Abs(spend-0.006) > 1e-9 {
+		t.Errorf("spend = %v, want 0.006", spend)
+	}
+
+	_, err := r.Complete(ctx, budgetRequest("claude-sonnet-4-5"))
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeBudgetExceeded {
+		t.Fatalf("err = %v, want budget exceeded", err)
+	}
+}
+
+func TestBudget_Downgrade(t *testing.T) {
+	r, requested := newBudgetRouter(t, BudgetLimits{
+		PerRequest: 0.001,
+		Downgrade:  map[string]string{"claude-opus-4-5": "claude-sonnet-4-5", "claude-sonnet-4-5": "claude-haiku-4-5"},
+	})
+
+	resp, err := r.Complete(context.Background(), budgetRequest("claude-opus-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Model != "claude-haiku-4-5" || len(*requested) != 1 || (*requested)[0] != "claude-haiku-4-5" {
+		t.Errorf("requested %v, want a single downgraded request to claude-haiku-4-5", *requested)
+	}
+}
+
+func TestBudget_Stream(t *testing.T) {
+	fail := true
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail {
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"type":"error","error":{"type":"invalid_request_error","message":"bad"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-sonnet-4-5\",\"usage\":{\"input_tokens\":1000}}}\n\n"+
+			"event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":200}}\n\n"+
+			"event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	}))
+	defer srv.Close()
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)), WithBudget(BudgetLimits{Limit: 1}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, err := r.Stream(ctx, budgetRequest("claude-sonnet-4-5")); err == nil {
+		t.Fatal("expected the stream to fail")
+	}
+	unsupported := budgetRequest("claude-sonnet-4-5")
+	unsupported.N = 2
+	if _, err := r.Stream(ctx, unsupported); err == nil {
+		t.Fatal("expected N > 1 to fail")
+	}
+	if spend := r.Spend(""); spend != 0 {
+		t.Errorf("spend = %v after failed streams, want 0", spend)
+	}
+
+	fail = false
+	stream, err := r.Stream(ctx, budgetRequest("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+	}
+	// Charged its usage, not the estimate.
+	if spend := r.Spend(""); math.Abs(spend-0.006) > 1e-9 {
+		t.Errorf("spend = %v, want 0.006", spend)
+	}
+}
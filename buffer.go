@@ -0,0 +1,180 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// OverflowPolicy controls what a buffered stream does when its buffer is
+// full.
+type OverflowPolicy string
+
+const (
+	// OverflowBlock stops reading the provider until the consumer catches
+	// up, as an unbuffered stream would.
+	OverflowBlock OverflowPolicy = "block"
+
+	// OverflowCoalesce merges consecutive text deltas of the same content
+	// block into one event, so no text is lost, and otherwise blocks.
+	OverflowCoalesce OverflowPolicy = "coalesce"
+
+	// OverflowError fails the stream with a server_error and closes the
+	// provider connection.
+	OverflowError OverflowPolicy = "error"
+)
+
+// defaultBufferSize is used when BufferOptions.Size is zero.
+const defaultBufferSize = 256
+
+// BufferOptions configures Buffer.
+type BufferOptions struct {
+	// Size is the maximum number of buffered events. Zero means 256.
+	Size int
+
+	// Overflow is applied when the buffer is full. Empty means OverflowBlock.
+	Overflow OverflowPolicy
+}
+
+// Buffer returns a stream that reads stream in a background goroutine, so a
+// slow consumer does not stall the provider connection and trigger
+// provider-side timeouts. Up to opts.Size events are held for the consumer.
+// Closing the returned stream closes stream.
+func Buffer(stream types.StreamReader, opts BufferOptions) types.StreamReader {
+	if opts.Size <= 0 {
+		opts.Size = defaultBufferSize
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowBlock
+	}
+	b := &bufferedStream{upstream: stream, opts: opts}
+	b.cond = sync.NewCond(&b.mu)
+	go b.read()
+	return b
+}
+
+type bufferedStream struct {
+	upstream types.StreamReader
+	opts     BufferOptions
+
+	closeOnce sync.Once
+	closeErr  error
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	events []*types.StreamEvent
+	done   bool
+	err    error
+	closed bool
+}
+
+// read moves events from the upstream stream into the buffer.
+func (b *bufferedStream) read() {
+	for {
+		event, err := b.upstream.Next()
+
+		b.mu.Lock()
+		if event == nil || err != nil {
+			b.done, b.err = true, err
+			b.cond.Broadcast()
+			b.mu.Unlock()
+			return
+		}
+		if !b.push(event) {
+			b.mu.Unlock()
+			b.closeUpstream()
+			return
+		}
+		b.cond.Broadcast()
+		b.mu.Unlock()
+	}
+}
+
+// push adds an event to the buffer, applying the overflow policy. It reports
+// whether reading should continue. b.mu must be held.
+func (b *bufferedStream) push(event *types.StreamEvent) bool {
+	if len(b.events) >= b.opts.Size && b.opts.Overflow == OverflowCoalesce && b.coalesce(event) {
+		return true
+	}
+	if len(b.events) >= b.opts.Size && b.opts.Overflow == OverflowError {
+		b.done = true
+		b.err = errors.NewError(errors.ErrCodeServerError, fmt.Sprintf("stream buffer overflow: consumer is %d events behind", len(b.events)))
+		b.cond.Broadcast()
+		return false
+	}
+	for len(b.events) >= b.opts.Size && !b.closed {
+		b.cond.Wait()
+	}
+	if b.closed {
+		return false
+	}
+	b.events = append(b.events, event)
+	return true
+}
+
+// coalesce merges a text delta into the last buffered event if that is a
+// text delta of the same content block.
+func (b *bufferedStream) coalesce(event *types.StreamEvent) bool {
+	last := b.events[len(b.events)-1]
+	if !isTextDelta(event) || !isTextDelta(last) || last.Index != event.Index {
+		return false
+	}
+	merged := *last
+	delta := *last.Delta
+	delta.Text += event.Delta.Text
+	merged.Delta = &delta
+	b.events[len(b.events)-1] = &merged
+	return true
+}
+
+func isTextDelta(event *types.StreamEvent) bool {
+	return event.Type == types.StreamEventContentDelta && event.Delta != nil && event.Delta.Type == types.ContentTypeText
+}
+
+func (b *bufferedStream) Next() (*types.StreamEvent, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for len(b.events) == 0 && !b.done && !b.closed {
+		b.cond.Wait()
+	}
+	if len(b.events) == 0 {
+		return nil, b.err
+	}
+	event := b.events[0]
+	b.events = b.events[1:]
+	b.cond.Broadcast()
+	return event, nil
+}
+
+func (b *bufferedStream) Close() error {
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return nil
+	}
+	b.closed = true
+	b.cond.Broadcast()
+	b.mu.Unlock()
+	return b.closeUpstream()
+}
+
+// closeUpstream closes the provider stream once, whether the consumer or an
+// overflow closes it first.
+func (b *bufferedStream) closeUpstream() error {
+	b.closeOnce.Do(func() {
+		b.closeErr = b.upstream.Close()
+	})
+	return b.closeErr
+}
+
+func (b *bufferedStream) Response() *types.CompletionResponse {
+	b.mu.Lock()
+	done := b.done
+	b.mu.Unlock()
+	if !done {
+		return nil
+	}
+	return b.upstream.Response()
+}
@@ -0,0 +1,70 @@
+package router
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// waitDrained waits until a buffered stream has read all of its upstream.
+func waitDrained(t *testing.T, stream types.StreamReader) {
+	t.Helper()
+	b := stream.(*bufferedStream)
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		b.mu.Lock()
+		done := b.done
+		b.mu.Unlock()
+		if done {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("upstream was not read ahead")
+}
+
+func TestBuffer_ReadsAhead(t *testing.T) {
+	upstream := &scriptedStream{events: []*types.StreamEvent{textDelta("a"), textDelta("b"), textDelta("c")}}
+	stream := Buffer(upstream, BufferOptions{})
+	waitDrained(t, stream)
+
+	if text, err := readText(t, stream); text != "abc" || err != nil {
+		t.Errorf("read %q, %v", text, err)
+	}
+}
+
+func TestBuffer_Coalesce(t *testing.T) {
+	upstream := &scriptedStream{events: []*types.StreamEvent{
+		textDelta("a"), textDelta("b"), textDelta("c"),
+	}}
+	stream := Buffer(upstream, BufferOptions{Size: 1, Overflow: OverflowCoalesce})
+	waitDrained(t, stream)
+
+	event, _ := stream.Next()
+	if event == nil || event.Delta.Text != "abc" {
+		t.Fatalf("event = %+v, want the merged delta", event)
+	}
+	if event, err := stream.Next(); event != nil || err != nil {
+		t.Errorf("expected the end of the stream, got %v, %v", event, err)
+	}
+}
+
+func TestBuffer_OverflowError(t *testing.T) {
+	upstream := &closeCountingStream{scriptedStream: scriptedStream{events: []*types.StreamEvent{
+		{Type: types.StreamEventStart}, textDelta("a"), textDelta("b"),
+	}}}
+	stream := Buffer(upstream, BufferOptions{Size: 1, Overflow: OverflowError})
+	waitDrained(t, stream)
+
+	if event, err := stream.Next(); event == nil || err != nil {
+		t.Fatalf("expected the buffered event first, got %v, %v", event, err)
+	}
+	if _, err := stream.Next(); err == nil {
+		t.Error("expected an overflow error")
+	}
+	stream.Close()
+	if upstream.closes != 1 {
+		t.Errorf("upstream closed %d times, want 1", upstream.closes)
+	}
+}
@@ -0,0 +1,251 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// RawExchange is one HTTP exchange between a provider client and its API, as
+// sent and received on the wire, with credentials redacted.
+type RawExchange struct {
+	Provider types.Provider `json:"provider"`
+	Method   string         `json:"method"`
+
+	// URL is the request URL with API key query parameters redacted.
+	URL string `json:"url"`
+
+	// Header is the request header with credential headers redacted.
+	Header http.Header `json:"header,omitempty"`
+
+	// Request is the request body. Bodies that are not JSON, such as file
+	// uploads, are stored as a JSON string.
+	Request json.RawMessage `json:"request,omitempty"`
+
+	// StatusCode is the response status, or zero if the request failed.
+	StatusCode int `json:"status_code,omitempty"`
+
+	// Response is the response body as far as the client read it. Event
+	// streams are stored as a JSON string.
+	Response json.RawMessage `json:"response,omitempty"`
+
+	// Error is the transport error if the request failed.
+	Error string `json:"error,omitempty"`
+
+	Duration time.Duration `json:"duration"`
+}
+
+// RawSink receives captured exchanges. It may be called concurrently.
+type RawSink func(RawExchange)
+
+// Redacted replaces credential values in captured exchanges.
+const Redacted = errors.Redacted
+
+// credentialHeaders are redacted from captured request headers.
+var credentialHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key", "X-Amz-Security-Token"}
+
+// credentialQuery are query parameters redacted from captured URLs.
+var credentialQuery = []string{"key", "api_key"}
+
+// WithRawCapture sends every HTTP exchange of the built-in providers to sink,
+// including batch and model listing calls, and sets CompletionResponse.Raw on
+// Complete responses to the provider's response body. Use it to debug
+// transformer bugs against live APIs; it buffers every body in memory.
+//
+// A streamed exchange is sent to sink when the stream is closed.
+func WithRawCapture(sink RawSink) Option {
+	return func(r *Router) {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		installed := r.config.RawCapture != nil
+		r.config.RawCapture = sink
+		if installed {
+			return
+		}
+		for name, f := range r.factories {
+			f.build = r.capturing(name, f.build)
+			r.installLocked(name, f.build(f.opts...))
+		}
+	}
+}
+
+// capturing wraps a provider build function so its clients send their HTTP
+// traffic through a capturing transport.
+func (r *Router) capturing(name types.Provider, build func(opts ...provider.Option) provider.Provider) func(opts ...provider.Option) provider.Provider {
+	return func(opts ...provider.Option) provider.Provider {
+		cfg := provider.DefaultConfig()
+		provider.ApplyOptions(cfg, opts...)
+
+		client := *provider.NewHTTPClient(cfg)
+		client.Transport = &captureTransport{provider: name, base: client.Transport, router: r}
+
+		return build(append(opts[:len(opts):len(opts)], provider.WithHTTPClient(&client))...)
+	}
+}
+
+// rawCallKey is the context key of a Complete call's rawCall.
+type rawCallKey struct{}
+
+// rawCall collects the last response body of one Complete call.
+type rawCall struct {
+	mu   sync.Mutex
+	body json.RawMessage
+}
+
+func withRawCall(ctx context.Context) (context.Context, *rawCall) {
+	call := &rawCall{}
+	return context.WithValue(ctx, rawCallKey{}, call), call
+}
+
+func (c *rawCall) response() json.RawMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.body
+}
+
+// captureTransport records exchanges and hands them to the router's sink.
+type captureTransport struct {
+	provider types.Provider
+	base     http.RoundTripper
+	router   *Router
+}
+
+// CloseIdleConnections closes the idle connections of the base transport.
+func (t *captureTransport) CloseIdleConnections() {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	provider.CloseIdleConnections(base)
+}
+
+func (t *captureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ex := RawExchange{
+		Provider: t.provider,
+		Method:   req.Method,
+		URL:      redactURL(req.URL),
+		Header:   redactHeader(req.Header),
+	}
+
+	if req.Body != nil && req.Body != http.NoBody {
+		body, err := io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+		ex.Request = rawJSON(body)
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+	}
+
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	start := time.Now()
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		ex.Error = err.Error()
+		ex.Duration = time.Since(start)
+		t.emit(req.Context(), ex)
+		return nil, err
+	}
+
+	ex.StatusCode = resp.StatusCode
+	resp.Body = &captureBody{ReadCloser: resp.Body, done: func(body []byte) {
+		ex.Response = rawJSON(body)
+		ex.Duration = time.Since(start)
+		t.emit(req.Context(), ex)
+	}}
+	return resp, nil
+}
+
+func (t *captureTransport) emit(ctx context.Context, ex RawExchange) {
+	if call, ok := ctx.Value(rawCallKey{}).(*rawCall); ok && ex.Response != nil {
+		call.mu.Lock()
+		call.body = ex.Response
+		call.mu.Unlock()
+	}
+	t.router.mu.RLock()
+	sink := t.router.config.RawCapture
+	t.router.mu.RUnlock()
+	if sink != nil {
+		sink(ex)
+	}
+}
+
+// captureBody copies a response body as it is read and reports it once, at
+// EOF or Close.
+type captureBody struct {
+	io.ReadCloser
+	buf  bytes.Buffer
+	once sync.Once
+	done func([]byte)
+}
+
+func (b *captureBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.buf.Write(p[:n])
+	if err == io.EOF {
+		b.finish()
+	}
+	return n, err
+}
+
+func (b *captureBody) Close() error {
+	b.finish()
+	return b.ReadCloser.Close()
+}
+
+func (b *captureBody) finish() {
+	b.once.Do(func() { b.done(bytes.Clone(b.buf.Bytes())) })
+}
+
+// rawJSON returns body as JSON, quoting it as a string if it is not JSON.
+func rawJSON(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	if json.Valid(body) {
+		return body
+	}
+	quoted, _ := json.Marshal(string(body))
+	return quoted
+}
+
+func redactURL(u *url.URL) string {
+	redactedURL := *u
+	q := redactedURL.Query()
+	changed := false
+	for _, key := range credentialQuery {
+		if q.Has(key) {
+			q.Set(key, Redacted)
+			changed = true
+		}
+	}
+	if changed {
+		redactedURL.RawQuery = q.Encode()
+	}
+	return redactedURL.String()
+}
+
+func redactHeader(h http.Header) http.Header {
+	header := h.Clone()
+	for _, key := range credentialHeaders {
+		if header.Get(key) != "" {
+			header.Set(key, Redacted)
+		}
+	}
+	return header
+}
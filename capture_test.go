@@ -0,0 +1,83 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const chatCompletionBody = `{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`
+
+func TestRawCapture_Complete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(chatCompletionBody))
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var exchanges []RawExchange
+	r, err := New(
+		WithOpenAI("sk-secret", provider.WithBaseURL(server.URL)),
+		WithRawCapture(func(ex RawExchange) {
+			mu.Lock()
+			defer mu.Unlock()
+			exchanges = append(exchanges, ex)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := r.Complete(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(resp.Raw) != chatCompletionBody {
+		t.Errorf("Raw = %s, want the response body", resp.Raw)
+	}
+
+	if len(exchanges) != 1 {
+		t.Fatalf("got %d exchanges, want 1", len(exchanges))
+	}
+	ex := exchanges[0]
+	if ex.Provider != types.ProviderOpenAI || ex.StatusCode != http.StatusOK || string(ex.Response) != chatCompletionBody {
+		t.Errorf("exchange = %+v", ex)
+	}
+	if got := ex.Header.Get("Authorization"); got != Redacted {
+		t.Errorf("Authorization = %q, want redacted", got)
+	}
+	var body map[string]any
+	if err := json.Unmarshal(ex.Request, &body); err != nil || body["model"] != "gpt-4o" {
+		t.Errorf("request = %s, want the wire request", ex.Request)
+	}
+}
+
+func TestRawCapture_QuotesNonJSONBodies(t *testing.T) {
+	if got := string(rawJSON([]byte("data: {}\n\n"))); got != `"data: {}\n\n"` {
+		t.Errorf("rawJSON = %s", got)
+	}
+	if got := rawJSON(nil); got != nil {
+		t.Errorf("rawJSON(nil) = %s, want nil", got)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	u, _ := url.Parse("https://example.com/v1beta/models/gemini:generateContent?alt=sse&key=secret")
+	got := redactURL(u)
+	if strings.Contains(got, "secret") || !strings.Contains(got, "alt=sse") {
+		t.Errorf("redactURL = %q", got)
+	}
+}
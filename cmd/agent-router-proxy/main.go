@@ -0,0 +1,113 @@
+// Command agent-router-proxy serves an OpenAI-compatible API backed by the agent router.
+//
+// Providers are enabled by their usual environment variables:
+//
+//	OPENAI_API_KEY, ANTHROPIC_API_KEY, GOOGLE_API_KEY, DEEPSEEK_API_KEY, COHERE_API_KEY,
+//	OPENROUTER_API_KEY,
+//	VERTEX_PROJECT_ID (+ VERTEX_LOCATION, and VERTEX_ACCESS_TOKEN or VERTEX_API_KEY instead of
+//	Application Default Credentials)
+//
+// Clients pick a provider by model name ("gpt-4o-mini", "claude-haiku-4-5", "gemini-2.0-flash")
+// or explicitly with a prefix ("anthropic/claude-haiku-4-5"). Other "vendor/model" names, as
+// OpenRouter names models, go to OpenRouter. Set PROXY_AUTH_TOKEN to require clients to present
+// it as their API key. Any of these, and PROXY_ADDR, may be set in a .env file in the working
+// directory.
+//
+// Usage:
+//
+//	agent-router-proxy -addr :8080
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"os"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/proxy"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	addr := parseArgs(os.Args[1:])
+
+	r, handler, err := newHandler(os.Getenv)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	log.Printf("agent-router-proxy listening on %s (providers: %v)", addr, r.Providers())
+	log.Fatal(http.ListenAndServe(addr, handler))
+}
+
+// parseArgs loads the .env file, then parses the command line and returns
+// the listen address. Loading first lets .env set flag defaults such as
+// PROXY_ADDR.
+func parseArgs(args []string) string {
+	if err := godotenv.Load(); err != nil {
+		log.Println("No .env file found, relying on environment variables")
+	}
+
+	flags := flag.NewFlagSet("agent-router-proxy", flag.ExitOnError)
+	addr := flags.String("addr", envOr("PROXY_ADDR", ":8080"), "listen address")
+	flags.Parse(args)
+	return *addr
+}
+
+// newHandler creates the router for the providers configured in the
+// environment, read with getenv, and the proxy handler serving it.
+func newHandler(getenv func(string) string) (*router.Router, http.Handler, error) {
+	var opts []router.Option
+	if key := getenv("OPENAI_API_KEY"); key != "" {
+		opts = append(opts, router.WithOpenAI(key))
+	}
+	if key := getenv("ANTHROPIC_API_KEY"); key != "" {
+		opts = append(opts, router.WithAnthropic(key))
+	}
+	if key := getenv("GOOGLE_API_KEY"); key != "" {
+		opts = append(opts, router.WithGoogle(key))
+	}
+	if key := getenv("DEEPSEEK_API_KEY"); key != "" {
+		opts = append(opts, router.WithDeepSeek(key))
+	}
+	if key := getenv("COHERE_API_KEY"); key != "" {
+		opts = append(opts, router.WithCohere(key))
+	}
+	if key := getenv("OPENROUTER_API_KEY"); key != "" {
+		opts = append(opts, router.WithOpenRouter(key))
+	}
+	if projectID := getenv("VERTEX_PROJECT_ID"); projectID != "" {
+		var vertexOpts []provider.Option
+		if token := getenv("VERTEX_ACCESS_TOKEN"); token != "" {
+			vertexOpts = append(vertexOpts, provider.WithAccessToken(token))
+		}
+		if key := getenv("VERTEX_API_KEY"); key != "" {
+			vertexOpts = append(vertexOpts, provider.WithAPIKey(key))
+		}
+		location := getenv("VERTEX_LOCATION")
+		if location == "" {
+			location = "global"
+		}
+		opts = append(opts, router.WithVertex(projectID, location, vertexOpts...))
+	}
+
+	r, err := router.New(opts...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var handlerOpts []proxy.Option
+	if token := getenv("PROXY_AUTH_TOKEN"); token != "" {
+		handlerOpts = append(handlerOpts, proxy.WithAuthToken(token))
+	}
+	return r, proxy.NewHandler(r, handlerOpts...), nil
+}
+
+func envOr(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestParseArgs_DotEnv(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, ".env"), []byte("PROXY_ADDR=:9090\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+	t.Setenv("PROXY_ADDR", "")
+	os.Unsetenv("PROXY_ADDR")
+
+	if addr := parseArgs(nil); addr != ":9090" {
+		t.Errorf("addr = %q, want PROXY_ADDR from .env", addr)
+	}
+	if addr := parseArgs([]string{"-addr", ":7070"}); addr != ":7070" {
+		t.Errorf("addr = %q, want the flag", addr)
+	}
+}
+
+func TestNewHandler(t *testing.T) {
+	env := map[string]string{
+		"ANTHROPIC_API_KEY": "sk-ant",
+		"VERTEX_PROJECT_ID": "my-project",
+		"VERTEX_API_KEY":    "vertex-key",
+		"PROXY_AUTH_TOKEN":  "secret",
+	}
+	r, handler, err := newHandler(func(key string) string { return env[key] })
+	if err != nil {
+		t.Fatal(err)
+	}
+	providers := r.Providers()
+	slices.Sort(providers)
+	if want := []types.Provider{types.ProviderAnthropic, types.ProviderVertex}; !slices.Equal(providers, want) {
+		t.Errorf("providers = %v, want %v", providers, want)
+	}
+
+	for _, tt := range []struct {
+		auth string
+		want int
+	}{
+		{"", http.StatusUnauthorized},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Bearer secret", http.StatusOK},
+	} {
+		req := httptest.NewRequest("GET", "/v1/models", nil)
+		if tt.auth != "" {
+			req.Header.Set("Authorization", tt.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tt.want {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.auth, rec.Code, tt.want)
+		}
+	}
+
+	if _, _, err := newHandler(func(string) string { return "" }); err == nil {
+		t.Error("expected an error without providers")
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WithRequestCoalescing makes concurrent identical Complete calls share one
+// upstream request. Calls are identical when their requests are equal apart
+// from RequestID: same provider, model, messages, tenant, and parameters.
+// Each caller gets its own copy of the response, with its own RequestID;
+// errors are shared and carry the RequestID of the call that was sent.
+//
+// The shared request is only cancelled when every caller waiting for it has
+// given up, so one caller's cancelled context does not fail the others.
+// Streams are never coalesced.
+func WithRequestCoalescing() Option {
+	return func(r *Router) {
+		r.coalescer = &coalescer{calls: make(map[string]*coalescedCall)}
+	}
+}
+
+// coalescer tracks in-flight Complete calls by request key.
+type coalescer struct {
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is one upstream request and the callers waiting for it.
+type coalescedCall struct {
+	done    chan struct{}
+	resp    *types.CompletionResponse
+	err     error
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// noCoalescingKey marks a context whose Complete calls are each sent, even
+// if identical.
+type noCoalescingKey struct{}
+
+// withoutCoalescing returns a context whose Complete calls are never
+// coalesced, for requests that are identical on purpose, like BestOfN's
+// samples.
+func withoutCoalescing(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noCoalescingKey{}, true)
+}
+
+// do returns the result of complete(req), sharing it with concurrent calls
+// for an identical request.
+func (c *coalescer) do(ctx context.Context, req *types.CompletionRequest, complete func(context.Context, *types.CompletionRequest) (*types.CompletionResponse, error)) (*types.CompletionResponse, error) {
+	key, ok := coalesceKey(req)
+	if !ok || ctx.Value(noCoalescingKey{}) != nil {
+		return complete(ctx, req)
+	}
+
+	c.mu.Lock()
+	call, inFlight := c.calls[key]
+	if !inFlight {
+		callCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		call = &coalescedCall{done: make(chan struct{}), cancel: cancel}
+		c.calls[key] = call
+		go func() {
+			defer cancel()
+			call.resp, call.err = complete(callCtx, req)
+			// Tag the shared error before the callers see it, so they do
+			// not each write its details.
+			call.err = tagRequestID(call.err, req.RequestID)
+			c.mu.Lock()
+			c.forget(key, call)
+			c.mu.Unlock()
+			close(call.done)
+		}()
+	}
+	call.waiters++
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		if call.err != nil {
+			return nil, call.err
+		}
+		return cloneResponse(call.resp), nil
+	case <-ctx.Done():
+		c.mu.Lock()
+		call.waiters--
+		if call.waiters == 0 {
+			// Later identical calls start afresh rather than join the
+			// cancelled one.
+			c.forget(key, call)
+			call.cancel()
+		}
+		c.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// forget removes call from the in-flight calls if it is still registered
+// under key. The caller must hold c.mu.
+func (c *coalescer) forget(key string, call *coalescedCall) {
+	if c.calls[key] == call {
+		delete(c.calls, key)
+	}
+}
+
+// coalesceKey identifies requests that can share an upstream call. It
+// reports false for requests that cannot be encoded.
+func coalesceKey(req *types.CompletionRequest) (string, bool) {
+	clone := *req
+	clone.RequestID = ""
+	data, err := json.Marshal(&clone)
+	if err != nil {
+		return "", false
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), true
+}
+
+// cloneResponse copies resp so callers sharing it can modify their copy.
+func cloneResponse(resp *types.CompletionResponse) *types.CompletionResponse {
+	clone := *resp
+	clone.Content = slices.Clone(resp.Content)
+	clone.ToolCalls = slices.Clone(resp.ToolCalls)
+	clone.Citations = slices.Clone(resp.Citations)
+	clone.Metadata = maps.Clone(resp.Metadata)
+	return &clone
+}
@@ -0,0 +1,95 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestWithRequestCoalescing(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)), WithRequestCoalescing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	newReq := func(text string) *types.CompletionRequest {
+		return &types.CompletionRequest{
+			Provider: types.ProviderOpenAI,
+			Model:    "gpt-4o",
+			Messages: []types.Message{types.NewTextMessage(types.RoleUser, text)},
+		}
+	}
+
+	// A caller that gives up does not cancel the call for the others.
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancelledErr := make(chan error, 1)
+	go func() {
+		_, err := r.Complete(cancelled, newReq("Hi"))
+		cancelledErr <- err
+	}()
+
+	var wg sync.WaitGroup
+	resps := make([]*types.CompletionResponse, 4)
+	for i := range resps {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			text := "Hi"
+			if i == 3 {
+				text = "Hello"
+			}
+			resp, err := r.Complete(context.Background(), newReq(text))
+			if err != nil {
+				t.Error(err)
+			}
+			resps[i] = resp
+		}()
+	}
+
+	for waiters(r.coalescer) < 5 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-cancelledErr; err != context.Canceled {
+		t.Errorf("cancelled caller: %v", err)
+	}
+	close(release)
+	wg.Wait()
+
+	if n := calls.Load(); n != 2 {
+		t.Errorf("upstream calls = %d, want one per distinct request", n)
+	}
+	if resps[0] == nil || resps[1] == nil || resps[0] == resps[1] || resps[0].RequestID == resps[1].RequestID {
+		t.Fatalf("coalesced callers should get their own copies: %+v, %+v", resps[0], resps[1])
+	}
+	if resps[0].Text() != "Hi" {
+		t.Errorf("text = %q", resps[0].Text())
+	}
+}
+
+// waiters returns the number of callers waiting on in-flight calls.
+func waiters(c *coalescer) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	n := 0
+	for _, call := range c.calls {
+		n += call.waiters
+	}
+	return n
+}
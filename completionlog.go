@@ -0,0 +1,108 @@
+package router
+
+import (
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Completion is one Complete or Stream call as the caller saw it, for
+// transcripts and offline evaluation.
+type Completion struct {
+	RequestID string `json:"request_id"`
+
+	// Request is the request as the caller sent it, before aliases were
+	// resolved and defaults applied.
+	Request *types.CompletionRequest `json:"request"`
+
+	// Response is the response, or for a stream the accumulated response.
+	// It is nil if the call failed or the stream was closed early.
+	Response *types.CompletionResponse `json:"response,omitempty"`
+
+	// Error is the failure, if any.
+	Error string `json:"error,omitempty"`
+
+	// Stream reports whether the call was a Stream call.
+	Stream bool `json:"stream,omitempty"`
+
+	// Cost is the cost in USD the provider billed, or the list price from
+	// the models catalog.
+	Cost float64 `json:"cost,omitempty"`
+
+	// Start is when the call was made. Duration runs until the response
+	// arrived, or for a stream until it ended or was closed.
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+}
+
+// CompletionSink receives completed calls. It may be called concurrently.
+type CompletionSink func(Completion)
+
+// WithCompletionLog sends every Complete and Stream call, with its response
+// or error, to sink once the call is done. Use it to keep transcripts; the
+// transcript package writes them as JSON lines and exports them as
+// fine-tuning data.
+func WithCompletionLog(sink CompletionSink) Option {
+	return func(r *Router) {
+		r.config.CompletionLog = sink
+	}
+}
+
+// logCompletion sends a finished call to the completion log.
+func (r *Router) logCompletion(req *types.CompletionRequest, resp *types.CompletionResponse, err error, stream bool, start time.Time) {
+	c := Completion{
+		RequestID: req.RequestID,
+		Request:   req,
+		Response:  resp,
+		Stream:    stream,
+		Start:     start,
+		Duration:  time.Since(start),
+	}
+	if err != nil {
+		c.Error = err.Error()
+	}
+	if resp != nil {
+		c.Cost = models.Cost(resp.Provider, resp.Model, resp.Usage)
+	}
+	r.config.CompletionLog(c)
+}
+
+// loggedStream sends a stream to the completion log when it ends or is
+// closed.
+type loggedStream struct {
+	types.StreamReader
+	router *Router
+	req    *types.CompletionRequest
+	start  time.Time
+	once   sync.Once
+}
+
+func (s *loggedStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	switch {
+	case err != nil:
+		s.log(err)
+	case event == nil:
+		s.log(nil)
+	case event.Type == types.StreamEventError:
+		s.log(event.Error)
+	}
+	return event, err
+}
+
+func (s *loggedStream) Close() error {
+	s.log(nil)
+	return s.StreamReader.Close()
+}
+
+func (s *loggedStream) log(err error) {
+	s.once.Do(func() {
+		var resp *types.CompletionResponse
+		if err == nil {
+			resp = s.StreamReader.Response()
+		}
+		s.router.logCompletion(s.req, resp, err, true, s.start)
+	})
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCompletionLog(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{
+		{
+			events: []*types.StreamEvent{{Type: types.StreamEventStart}, textDelta("Hi")},
+			resp:   &types.CompletionResponse{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5", Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: "Hi"}}},
+		},
+		{events: []*types.StreamEvent{textDelta("Hel")}, err: io.ErrUnexpectedEOF},
+	}}
+	var logged []Completion
+	r := newTestRouter(t, fake, WithCompletionLog(func(c Completion) { logged = append(logged, c) }))
+	req := &types.CompletionRequest{
+		Provider:  types.ProviderAnthropic,
+		Model:     "claude-haiku-4-5",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+		RequestID: "req_1",
+	}
+
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		stream, err := r.Stream(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for {
+			event, err := stream.Next()
+			if event == nil || err != nil {
+				break
+			}
+		}
+		stream.Close()
+	}
+	if _, err := r.Complete(context.Background(), &types.CompletionRequest{Provider: "missing", Model: "m"}); err == nil {
+		t.Fatal("expected an error for an unknown provider")
+	}
+
+	if len(logged) != 4 {
+		t.Fatalf("logged %d completions, want 4", len(logged))
+	}
+	if c := logged[0]; c.RequestID != "req_1" || c.Stream || c.Response == nil || c.Request != req || c.Start.IsZero() {
+		t.Errorf("complete = %+v", c)
+	}
+	if c := logged[1]; !c.Stream || c.Response.Text() != "Hi" || c.Response.RequestID != "req_1" || c.Error != "" {
+		t.Errorf("stream = %+v", c)
+	}
+	if c := logged[2]; !c.Stream || c.Response != nil || c.Error == "" {
+		t.Errorf("failed stream = %+v, want the error and no response", c)
+	}
+	if c := logged[3]; c.Response != nil || c.Error == "" || c.RequestID == "" {
+		t.Errorf("failed complete = %+v, want the error with a generated request ID", c)
+	}
+}
@@ -0,0 +1,33 @@
+package router
+
+import (
+	"github.com/Chloe199719/agent-router/pkg/compress"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WithPromptCompression runs a prompt compression pipeline on every Complete
+// and Stream call, after the request guards, and reports the savings in
+// CompletionResponse.Compression. The requests of its stages, such as
+// compress.LLM's, are not compressed.
+//
+//	p := compress.New(compress.Dedupe(), compress.Minify())
+//	r, err := router.New(router.WithOpenAI(key), router.WithPromptCompression(p))
+func WithPromptCompression(p *compress.Pipeline) Option {
+	return func(r *Router) {
+		r.compress = p
+	}
+}
+
+// compressedStream sets the compression stats on the stream's response.
+type compressedStream struct {
+	types.StreamReader
+	stats *types.CompressionStats
+}
+
+func (s *compressedStream) Response() *types.CompletionResponse {
+	resp := s.StreamReader.Response()
+	if resp != nil {
+		resp.Compression = s.stats
+	}
+	return resp
+}
@@ -0,0 +1,53 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/compress"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestPromptCompression(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{{
+		events: []*types.StreamEvent{textDelta("ok")},
+		resp:   &types.CompletionResponse{Provider: types.ProviderAnthropic},
+	}}}
+	r := newTestRouter(t, fake, WithPromptCompression(compress.New(compress.Minify())))
+	req := &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello    **world**\n\n\n\n")},
+	}
+
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Compression == nil || resp.Compression.SavedTokens <= 0 {
+		t.Errorf("Compression = %+v, want savings", resp.Compression)
+	}
+
+	stream, err := r.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+	}
+	if got := fake.requests[0].Messages[0].Content[0].Text; got != "Hello world" {
+		t.Errorf("sent %q, want the minified prompt", got)
+	}
+	if req.Messages[0].Content[0].Text != "Hello    **world**\n\n\n\n" {
+		t.Error("the caller's request was modified")
+	}
+	if c := stream.Response().Compression; c == nil || c.Stages["minify"] != c.SavedTokens {
+		t.Errorf("stream Compression = %+v, want the minify savings", c)
+	}
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// TestRouter_ConcurrentUse shares one router across goroutines that send
+// requests while providers are re-keyed and replaced. Run with -race.
+func TestRouter_ConcurrentUse(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+			io.WriteString(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}],"usage":{"prompt_tokens":1,"completion_tokens":1,"total_tokens":2}}`)
+	}))
+	defer srv.Close()
+
+	openAI := func(key string) Option {
+		return WithOpenAI(key, provider.WithBaseURL(srv.URL), provider.WithTransport(provider.TransportConfig{MaxIdleConnsPerHost: 16}))
+	}
+	r, err := New(openAI("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	}
+
+	ctx := context.Background()
+	errs := make(chan error, 64)
+	var wg sync.WaitGroup
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 5 {
+				if i%2 == 0 {
+					_, err := r.Complete(ctx, req)
+					if err != nil {
+						errs <- err
+					}
+					continue
+				}
+				stream, err := r.Stream(ctx, req)
+				if err != nil {
+					errs <- err
+					continue
+				}
+				for {
+					event, err := stream.Next()
+					if err != nil {
+						errs <- err
+					}
+					if err != nil || event == nil || event.Type == types.StreamEventDone {
+						break
+					}
+				}
+				stream.Close()
+			}
+		}()
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 5 {
+			r.UpdateAPIKey(types.ProviderOpenAI, fmt.Sprintf("key-%d", i))
+			r.AddProvider(openAI("key"))
+			r.Metrics()
+		}
+	}()
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,149 @@
+package router
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// imageMaxEdge is the longest image edge, in pixels, providers use without
+// downscaling the image themselves. Larger images cost more input tokens or
+// upload time for no gain.
+var imageMaxEdge = map[types.Provider]int{
+	types.ProviderOpenAI:    2048,
+	types.ProviderAnthropic: 1568,
+	types.ProviderGoogle:    3072,
+	types.ProviderVertex:    3072,
+}
+
+// defaultImageMaxEdge applies to providers without a known edge limit.
+const defaultImageMaxEdge = 2048
+
+// maxDownscaleInput caps how much of an image URL is downloaded when
+// downscaling, since the original may exceed the provider's limit.
+const maxDownscaleInput = 50 << 20
+
+// jpegQualities are tried in order when re-encoding an image to fit the
+// byte limit.
+var jpegQualities = []int{85, 70, 55}
+
+// downscale shrinks an image to fit maxEdge and maxBytes. It returns the new
+// data and media type, or ok false if the image already fits or cannot be
+// decoded (WebP and HEIC images are left to the provider).
+func downscale(data []byte, mediaType string, maxEdge int, maxBytes int64) (out []byte, outType string, ok bool) {
+	var decode func([]byte) (image.Image, error)
+	switch mediaType {
+	case "image/jpeg":
+		decode = func(b []byte) (image.Image, error) { return jpeg.Decode(bytes.NewReader(b)) }
+	case "image/png":
+		decode = func(b []byte) (image.Image, error) { return png.Decode(bytes.NewReader(b)) }
+	case "image/gif":
+		decode = func(b []byte) (image.Image, error) { return gif.Decode(bytes.NewReader(b)) }
+	default:
+		return nil, "", false
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", false
+	}
+	if max(cfg.Width, cfg.Height) <= maxEdge && int64(len(data)) <= maxBytes {
+		return nil, "", false
+	}
+	img, err := decode(data)
+	if err != nil {
+		return nil, "", false
+	}
+
+	w, h := fitEdge(cfg.Width, cfg.Height, maxEdge)
+	for {
+		resized := resize(img, w, h)
+
+		// Keep the original format if it fits; PNG keeps transparency.
+		var buf bytes.Buffer
+		if mediaType == "image/jpeg" {
+			jpeg.Encode(&buf, resized, &jpeg.Options{Quality: jpegQualities[0]})
+		} else {
+			png.Encode(&buf, resized)
+		}
+		if int64(buf.Len()) <= maxBytes {
+			if mediaType == "image/gif" {
+				return buf.Bytes(), "image/png", true
+			}
+			return buf.Bytes(), mediaType, true
+		}
+
+		// Otherwise trade quality for size as JPEG.
+		flat := flatten(resized)
+		for _, q := range jpegQualities {
+			buf.Reset()
+			jpeg.Encode(&buf, flat, &jpeg.Options{Quality: q})
+			if int64(buf.Len()) <= maxBytes {
+				return buf.Bytes(), "image/jpeg", true
+			}
+		}
+
+		if w <= 64 || h <= 64 {
+			return buf.Bytes(), "image/jpeg", true
+		}
+		w, h = w*3/4, h*3/4
+	}
+}
+
+// fitEdge scales w and h so the longer edge is at most maxEdge, keeping the
+// aspect ratio.
+func fitEdge(w, h, maxEdge int) (int, int) {
+	if w <= maxEdge && h <= maxEdge {
+		return w, h
+	}
+	if w >= h {
+		return maxEdge, max(1, h*maxEdge/w)
+	}
+	return max(1, w*maxEdge/h), maxEdge
+}
+
+// resize scales src to w×h by averaging the source pixels that cover each
+// destination pixel, which suits the large reductions downscaling makes.
+func resize(src image.Image, w, h int) *image.NRGBA {
+	b := src.Bounds()
+	if b.Dx() == w && b.Dy() == h {
+		dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+		return dst
+	}
+
+	dst := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		y0 := b.Min.Y + y*b.Dy()/h
+		y1 := max(y0+1, b.Min.Y+(y+1)*b.Dy()/h)
+		for x := 0; x < w; x++ {
+			x0 := b.Min.X + x*b.Dx()/w
+			x1 := max(x0+1, b.Min.X+(x+1)*b.Dx()/w)
+
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(r / n), G: uint16(g / n), B: uint16(bl / n), A: uint16(a / n)})
+		}
+	}
+	return dst
+}
+
+// flatten draws img over white, since JPEG has no transparency.
+func flatten(img image.Image) *image.RGBA {
+	dst := image.NewRGBA(img.Bounds())
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), img, img.Bounds().Min, draw.Over)
+	return dst
+}
@@ -0,0 +1,115 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"image"
+	"image/color"
+	"image/png"
+	"math/rand"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func encodePNG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// noiseImage is an image that compresses poorly.
+func noiseImage(w, h int) image.Image {
+	rng := rand.New(rand.NewSource(1))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for i := range img.Pix {
+		img.Pix[i] = byte(rng.Intn(256))
+	}
+	return img
+}
+
+func decodedImage(t *testing.T, block types.ContentBlock) image.Config {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(block.ImageBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cfg
+}
+
+func TestDownscale_MaxEdge(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3136, 200))
+	for x := 0; x < 3136; x++ {
+		img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+	}
+
+	fake := &recordingProvider{name: types.ProviderAnthropic}
+	r := newTestRouter(t, fake, WithImagePreflight(ImagePreflight{Downscale: true}))
+
+	block := types.ContentBlock{ImageBase64: base64.StdEncoding.EncodeToString(encodePNG(t, img)), MediaType: "image/png"}
+	if _, err := r.Complete(context.Background(), imageRequest(types.ProviderAnthropic, block)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fake.requests[0].Messages[0].Content[1]
+	if got.MediaType != "image/png" {
+		t.Errorf("media type = %q, want png kept", got.MediaType)
+	}
+	if cfg := decodedImage(t, got); cfg.Width != 1568 || cfg.Height != 100 {
+		t.Errorf("size = %dx%d, want 1568x100", cfg.Width, cfg.Height)
+	}
+}
+
+func TestDownscale_MaxBytes(t *testing.T) {
+	data := encodePNG(t, noiseImage(400, 400))
+
+	fake := &recordingProvider{name: types.ProviderOpenAI}
+	r := newTestRouter(t, fake, WithImagePreflight(ImagePreflight{Downscale: true, MaxBytes: 40 << 10}))
+
+	block := types.ContentBlock{ImageBase64: base64.StdEncoding.EncodeToString(data), MediaType: "image/png"}
+	if _, err := r.Complete(context.Background(), imageRequest(types.ProviderOpenAI, block)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fake.requests[0].Messages[0].Content[1]
+	size := base64.StdEncoding.DecodedLen(len(got.ImageBase64))
+	if got.MediaType != "image/jpeg" || size > 40<<10 {
+		t.Errorf("got %s of %d bytes, want jpeg within 40 KB", got.MediaType, size)
+	}
+	decodedImage(t, got)
+}
+
+func TestDownscale_LeavesSmallImages(t *testing.T) {
+	data := encodePNG(t, image.NewNRGBA(image.Rect(0, 0, 100, 100)))
+	if _, _, ok := downscale(data, "image/png", 1568, 5<<20); ok {
+		t.Error("expected small image to be left alone")
+	}
+	if _, _, ok := downscale([]byte("RIFF....WEBP"), "image/webp", 1568, 5); ok {
+		t.Error("expected undecodable image to be left alone")
+	}
+}
+
+func TestFitEdge(t *testing.T) {
+	tests := []struct {
+		w, h, edge   int
+		wantW, wantH int
+	}{
+		{100, 50, 200, 100, 50},
+		{4000, 3000, 2000, 2000, 1500},
+		{3000, 4000, 2000, 1500, 2000},
+		{10000, 1, 100, 100, 1},
+	}
+	for _, tt := range tests {
+		if w, h := fitEdge(tt.w, tt.h, tt.edge); w != tt.wantW || h != tt.wantH {
+			t.Errorf("fitEdge(%d, %d, %d) = %d, %d, want %d, %d", tt.w, tt.h, tt.edge, w, h, tt.wantW, tt.wantH)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Defaults of EmbedBatch.
+const (
+	defaultEmbedConcurrency = 4
+	defaultEmbedBackoff     = time.Second
+)
+
+// Embed embeds the request's inputs in a single provider call. Use
+// EmbedBatch for inputs beyond the provider's per-call limits.
+func (r *Router) Embed(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	if err := r.inflight.enter(); err != nil {
+		return nil, err
+	}
+	defer r.inflight.leave()
+
+	embedder, err := r.getEmbedder(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	resp, err := embedder.Embed(ctx, req)
+	r.metrics.Record(req.Provider, req.Model, time.Since(start), err)
+	if err != nil {
+		return nil, timeoutError(ctx, req.Provider, err)
+	}
+	return resp, nil
+}
+
+// EmbedOption configures EmbedBatch.
+type EmbedOption func(*embedConfig)
+
+type embedConfig struct {
+	batchSize   int
+	concurrency int
+	maxRetries  int
+	backoff     time.Duration
+	onProgress  func(done, total int)
+}
+
+// WithEmbedBatchSize caps the number of inputs per provider call below the
+// provider's own limit.
+func WithEmbedBatchSize(n int) EmbedOption {
+	return func(c *embedConfig) {
+		c.batchSize = n
+	}
+}
+
+// WithEmbedConcurrency sets how many provider calls run at once. The
+// default is 4.
+func WithEmbedConcurrency(n int) EmbedOption {
+	return func(c *embedConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithEmbedRetries retries calls that fail with a retryable error (rate
+// limits, server errors, timeouts) up to n times, waiting backoff times the
+// attempt number between tries.
+func WithEmbedRetries(n int, backoff time.Duration) EmbedOption {
+	return func(c *embedConfig) {
+		c.maxRetries = n
+		c.backoff = backoff
+	}
+}
+
+// WithEmbedProgress registers a callback invoked with the number of inputs
+// embedded so far after each provider call. Calls are serialized.
+func WithEmbedProgress(fn func(done, total int)) EmbedOption {
+	return func(c *embedConfig) {
+		c.onProgress = fn
+	}
+}
+
+// EmbedBatch embeds a corpus of any size. The inputs are split into batches
+// within the provider's EmbeddingLimits, counting four characters per token,
+// and the batches are embedded concurrently. A rate limit or overload error
+// pauses every batch for the backoff, or the provider's Retry-After if
+// longer, before retrying, so the router backs off as a whole instead of
+// each call hitting the limit again.
+//
+// The response's Embeddings are aligned with req.Input and its Usage is the
+// total of all calls. If a batch still fails after its retries, the
+// remaining batches are cancelled and the error is returned.
+func (r *Router) EmbedBatch(ctx context.Context, req *types.EmbeddingRequest, opts ...EmbedOption) (*types.EmbeddingResponse, error) {
+	if err := r.inflight.enter(); err != nil {
+		return nil, err
+	}
+	defer r.inflight.leave()
+
+	embedder, err := r.getEmbedder(req.Provider)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := &embedConfig{concurrency: defaultEmbedConcurrency, backoff: defaultEmbedBackoff}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.concurrency = max(cfg.concurrency, 1)
+
+	limits := embedder.EmbeddingLimits()
+	if cfg.batchSize > 0 && (limits.MaxInputs == 0 || cfg.batchSize < limits.MaxInputs) {
+		limits.MaxInputs = cfg.batchSize
+	}
+	batches := embeddingBatches(req.Input, limits)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &types.EmbeddingResponse{
+		Provider:   req.Provider,
+		Model:      req.Model,
+		Embeddings: make([][]float64, len(req.Input)),
+	}
+	run := &embedRun{r: r, embedder: embedder, cfg: cfg, result: result, total: len(req.Input)}
+
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for _, b := range batches {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func(b embeddingBatch) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			batchReq := *req
+			batchReq.Input = req.Input[b.start:b.end]
+			if err := run.embed(ctx, &batchReq, b.start); err != nil {
+				cancel()
+			}
+		}(b)
+	}
+	wg.Wait()
+
+	if run.err != nil {
+		return nil, run.err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// getEmbedder returns the named provider if it can embed.
+func (r *Router) getEmbedder(name types.Provider) (provider.Embedder, error) {
+	p, err := r.getProvider(name)
+	if err != nil {
+		return nil, err
+	}
+	embedder, ok := p.(provider.Embedder)
+	if !ok {
+		return nil, errors.ErrUnsupportedFeature(name, types.FeatureEmbeddings)
+	}
+	return embedder, nil
+}
+
+// embeddingBatch is the input range [start, end) of one provider call.
+type embeddingBatch struct {
+	start, end int
+}
+
+// embeddingBatches splits inputs into consecutive batches within limits. An
+// input over the token limit on its own gets a batch of its own, for the
+// provider to reject or truncate.
+func embeddingBatches(inputs []string, limits provider.EmbeddingLimits) []embeddingBatch {
+	var batches []embeddingBatch
+	start, tokens := 0, 0
+	for i, input := range inputs {
+		n := len(input)/4 + 1
+		full := limits.MaxInputs > 0 && i-start >= limits.MaxInputs
+		over := limits.MaxTokens > 0 && tokens+n > limits.MaxTokens
+		if i > start && (full || over) {
+			batches = append(batches, embeddingBatch{start, i})
+			start, tokens = i, 0
+		}
+		tokens += n
+	}
+	if start < len(inputs) {
+		batches = append(batches, embeddingBatch{start, len(inputs)})
+	}
+	return batches
+}
+
+// embedRun is the shared state of an EmbedBatch call.
+type embedRun struct {
+	r        *Router
+	embedder provider.Embedder
+	cfg      *embedConfig
+	result   *types.EmbeddingResponse
+	total    int
+
+	mu         sync.Mutex
+	done       int
+	err        error
+	pauseUntil time.Time
+}
+
+// embed embeds one batch, retrying per the config, and stores its vectors
+// at offset.
+func (run *embedRun) embed(ctx context.Context, req *types.EmbeddingRequest, offset int) error {
+	for attempt := 0; ; attempt++ {
+		if err := run.waitPause(ctx); err != nil {
+			return run.fail(err)
+		}
+
+		resp, err := run.r.Embed(ctx, req)
+		if err == nil {
+			run.store(resp, offset)
+			return nil
+		}
+		if attempt >= run.cfg.maxRetries || !errors.IsRetryable(err) || ctx.Err() != nil {
+			return run.fail(err)
+		}
+
+		wait := max(run.cfg.backoff*time.Duration(attempt+1), errors.RetryAfter(err))
+		var rerr *errors.RouterError
+		if stderrors.As(err, &rerr) && (rerr.Code == errors.ErrCodeRateLimit || rerr.Code == errors.ErrCodeOverloaded) {
+			run.pause(wait)
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return run.fail(err)
+		case <-time.After(wait):
+		}
+	}
+}
+
+// pause holds back every batch of the run for d.
+func (run *embedRun) pause(d time.Duration) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if until := time.Now().Add(d); until.After(run.pauseUntil) {
+		run.pauseUntil = until
+	}
+}
+
+// waitPause waits out a pause after a rate limit.
+func (run *embedRun) waitPause(ctx context.Context) error {
+	run.mu.Lock()
+	wait := time.Until(run.pauseUntil)
+	run.mu.Unlock()
+	if wait <= 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(wait):
+		return nil
+	}
+}
+
+// store records a batch's vectors and usage and reports progress.
+func (run *embedRun) store(resp *types.EmbeddingResponse, offset int) {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+
+	copy(run.result.Embeddings[offset:], resp.Embeddings)
+	if resp.Model != "" {
+		run.result.Model = resp.Model
+	}
+	run.result.Usage.Add(resp.Usage)
+
+	run.done += len(resp.Embeddings)
+	if run.cfg.onProgress != nil {
+		run.cfg.onProgress(run.done, run.total)
+	}
+}
+
+// fail records the first error of the run.
+func (run *embedRun) fail(err error) error {
+	run.mu.Lock()
+	defer run.mu.Unlock()
+	if run.err == nil {
+		run.err = err
+	}
+	return err
+}
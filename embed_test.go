@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestEmbedBatch_ChunksAndAligns(t *testing.T) {
+	var calls, rateLimited atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Input []string `json:"input"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Input) > 3 {
+			t.Errorf("batch of %d inputs, want at most 3", len(body.Input))
+		}
+		if calls.Add(1) == 2 && rateLimited.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"slow down","type":"rate_limit"}}`)
+			return
+		}
+
+		// Vectors hold the input's number, listed in reverse order.
+		var data []string
+		for i := len(body.Input) - 1; i >= 0; i-- {
+			n := strings.TrimPrefix(body.Input[i], "text ")
+			data = append(data, fmt.Sprintf(`{"index":%d,"embedding":[%s]}`, i, n))
+		}
+		fmt.Fprintf(w, `{"model":"text-embedding-3-small","data":[%s],"usage":{"prompt_tokens":%d,"total_tokens":%d}}`,
+			strings.Join(data, ","), len(body.Input), len(body.Input))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inputs := make([]string, 10)
+	for i := range inputs {
+		inputs[i] = "text " + strconv.Itoa(i)
+	}
+	var mu sync.Mutex
+	var progress []int
+	resp, err := r.EmbedBatch(context.Background(), &types.EmbeddingRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "text-embedding-3-small",
+		Input:    inputs,
+	},
+		WithEmbedBatchSize(3),
+		WithEmbedConcurrency(2),
+		WithEmbedRetries(2, time.Millisecond),
+		WithEmbedProgress(func(done, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, done)
+			if total != 10 {
+				t.Errorf("total = %d", total)
+			}
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, vec := range resp.Embeddings {
+		if len(vec) != 1 || vec[0] != float64(i) {
+			t.Errorf("embedding %d = %v", i, vec)
+		}
+	}
+	if resp.Usage.InputTokens != 10 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+	if calls.Load() != 5 {
+		t.Errorf("calls = %d, want 4 batches and a retry", calls.Load())
+	}
+	if len(progress) != 4 || progress[3] != 10 {
+		t.Errorf("progress = %v", progress)
+	}
+}
+
+func TestEmbedBatch_Unsupported(t *testing.T) {
+	r, err := New(WithAnthropic("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.EmbedBatch(context.Background(), &types.EmbeddingRequest{Provider: types.ProviderAnthropic, Input: []string{"a"}})
+	if err == nil {
+		t.Fatal("expected unsupported feature error")
+	}
+}
+
+func TestEmbeddingBatches_TokenLimit(t *testing.T) {
+	inputs := []string{strings.Repeat("a", 396), strings.Repeat("b", 396), "c", strings.Repeat("d", 1000)}
+	batches := embeddingBatches(inputs, provider.EmbeddingLimits{MaxInputs: 10, MaxTokens: 200})
+	want := []embeddingBatch{{0, 2}, {2, 3}, {3, 4}}
+	if fmt.Sprint(batches) != fmt.Sprint(want) {
+		t.Errorf("batches = %v, want %v", batches, want)
+	}
+}
@@ -0,0 +1,192 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Defaults of CompleteAll.
+const (
+	defaultFanOutConcurrency = 4
+	defaultFanOutBackoff     = time.Second
+)
+
+// FanOutOption configures CompleteAll.
+type FanOutOption func(*fanOutConfig)
+
+type fanOutConfig struct {
+	concurrency int
+	maxRetries  int
+	backoff     time.Duration
+	failFast    bool
+	onProgress  func(done, total int)
+}
+
+// WithFanOutConcurrency sets how many completions run at once. The default
+// is 4.
+func WithFanOutConcurrency(n int) FanOutOption {
+	return func(c *fanOutConfig) {
+		c.concurrency = n
+	}
+}
+
+// WithFanOutRetries retries completions that fail with a retryable error
+// (rate limits, overloads, server errors, timeouts) up to n times, waiting
+// backoff times the attempt number between tries, or the provider's
+// Retry-After if longer.
+func WithFanOutRetries(n int, backoff time.Duration) FanOutOption {
+	return func(c *fanOutConfig) {
+		c.maxRetries = n
+		c.backoff = backoff
+	}
+}
+
+// WithFanOutFailFast cancels the remaining completions as soon as one fails
+// after its retries.
+func WithFanOutFailFast() FanOutOption {
+	return func(c *fanOutConfig) {
+		c.failFast = true
+	}
+}
+
+// WithFanOutProgress registers a callback invoked with the number of
+// finished completions, successful or not, after each one. Calls are
+// serialized.
+func WithFanOutProgress(fn func(done, total int)) FanOutOption {
+	return func(c *fanOutConfig) {
+		c.onProgress = fn
+	}
+}
+
+// FanOutError reports the completions of a CompleteAll call that failed.
+type FanOutError struct {
+	// Errors is aligned with the requests: nil for those that succeeded.
+	Errors []error
+}
+
+func (e *FanOutError) Error() string {
+	failed := e.Failed()
+	if len(failed) == 0 {
+		return "no completions failed"
+	}
+	return fmt.Sprintf("%d of %d completions failed; request %d: %v", len(failed), len(e.Errors), failed[0], e.Errors[failed[0]])
+}
+
+// Unwrap returns the errors of the failed completions, for errors.Is and
+// errors.As.
+func (e *FanOutError) Unwrap() []error {
+	var errs []error
+	for _, err := range e.Errors {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// Failed returns the indexes of the requests that failed.
+func (e *FanOutError) Failed() []int {
+	var failed []int
+	for i, err := range e.Errors {
+		if err != nil {
+			failed = append(failed, i)
+		}
+	}
+	return failed
+}
+
+// CompleteAll runs many completions concurrently and returns their
+// responses in the order of reqs. It is a lightweight sibling of the batch
+// API for latency-sensitive fan-out: each request goes through Complete, so
+// fallbacks, budgets, and metrics apply, with a worker pool capping the
+// calls in flight.
+//
+// If any completion fails after its retries, CompleteAll returns the
+// responses that succeeded, nil for the others, with a *FanOutError holding
+// each request's error. With WithFanOutFailFast, the completions not yet
+// finished are cancelled and fail with the context's error.
+func (r *Router) CompleteAll(ctx context.Context, reqs []*types.CompletionRequest, opts ...FanOutOption) ([]*types.CompletionResponse, error) {
+	cfg := &fanOutConfig{concurrency: defaultFanOutConcurrency, backoff: defaultFanOutBackoff}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	cfg.concurrency = max(cfg.concurrency, 1)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	responses := make([]*types.CompletionResponse, len(reqs))
+	errs := make([]error, len(reqs))
+	var (
+		mu     sync.Mutex
+		done   int
+		failed bool
+	)
+	finish := func(i int, resp *types.CompletionResponse, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		responses[i], errs[i] = resp, err
+		if err != nil {
+			failed = true
+			if cfg.failFast {
+				cancel()
+			}
+		}
+		done++
+		if cfg.onProgress != nil {
+			cfg.onProgress(done, len(reqs))
+		}
+	}
+
+	sem := make(chan struct{}, cfg.concurrency)
+	var wg sync.WaitGroup
+	for i, req := range reqs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if err := ctx.Err(); err != nil {
+			finish(i, nil, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := r.completeWithRetry(ctx, req, cfg)
+			finish(i, resp, err)
+		}()
+	}
+	wg.Wait()
+
+	if failed {
+		return responses, &FanOutError{Errors: errs}
+	}
+	return responses, nil
+}
+
+// completeWithRetry calls Complete, retrying retryable errors per cfg.
+func (r *Router) completeWithRetry(ctx context.Context, req *types.CompletionRequest, cfg *fanOutConfig) (*types.CompletionResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := r.Complete(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= cfg.maxRetries || !errors.IsRetryable(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(max(cfg.backoff*time.Duration(attempt+1), errors.RetryAfter(err))):
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// echoServer answers chat completions with the last user message. "flaky"
+// is rate limited once and "bad" is always rejected.
+func echoServer(t *testing.T, inFlight, maxInFlight *atomic.Int32) *httptest.Server {
+	var flaky atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+
+		var body struct {
+			Messages []struct {
+				Content string `json:"content"`
+			} `json:"messages"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		text := body.Messages[len(body.Messages)-1].Content
+		switch {
+		case text == "bad":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `{"error":{"message":"bad request","type":"invalid_request_error"}}`)
+			return
+		case text == "flaky" && flaky.Add(1) == 1:
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprint(w, `{"error":{"message":"slow down","type":"rate_limit"}}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id":"1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":%q},"finish_reason":"stop"}]}`, text)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func fanOutRequests(texts ...string) []*types.CompletionRequest {
+	reqs := make([]*types.CompletionRequest, len(texts))
+	for i, text := range texts {
+		reqs[i] = &types.CompletionRequest{
+			Provider: types.ProviderOpenAI,
+			Model:    "gpt-4o",
+			Messages: []types.Message{types.NewTextMessage(types.RoleUser, text)},
+		}
+	}
+	return reqs
+}
+
+func TestCompleteAll(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := echoServer(t, &inFlight, &maxInFlight)
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mu sync.Mutex
+	var progress []int
+	responses, err := r.CompleteAll(context.Background(), fanOutRequests("a", "flaky", "bad", "b", "c", "d"),
+		WithFanOutConcurrency(2),
+		WithFanOutRetries(1, time.Millisecond),
+		WithFanOutProgress(func(done, total int) {
+			mu.Lock()
+			defer mu.Unlock()
+			progress = append(progress, done)
+		}),
+	)
+
+	var fanErr *FanOutError
+	if !stderrors.As(err, &fanErr) {
+		t.Fatalf("err = %v, want a FanOutError", err)
+	}
+	if !slices.Equal(fanErr.Failed(), []int{2}) {
+		t.Errorf("failed = %v, want [2]", fanErr.Failed())
+	}
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		t.Errorf("err = %v, want it to wrap the invalid request", err)
+	}
+
+	for i, want := range []string{"a", "flaky", "", "b", "c", "d"} {
+		if want == "" {
+			if responses[i] != nil {
+				t.Errorf("response %d = %v, want nil", i, responses[i])
+			}
+			continue
+		}
+		if responses[i] == nil || responses[i].Text() != want {
+			t.Errorf("response %d = %v, want %q", i, responses[i], want)
+		}
+	}
+	if got := maxInFlight.Load(); got > 2 {
+		t.Errorf("max in flight = %d, want at most 2", got)
+	}
+	if !slices.Equal(progress, []int{1, 2, 3, 4, 5, 6}) {
+		t.Errorf("progress = %v", progress)
+	}
+}
+
+func TestCompleteAll_FailFast(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	srv := echoServer(t, &inFlight, &maxInFlight)
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	responses, err := r.CompleteAll(context.Background(), fanOutRequests("bad", "a", "b", "c"),
+		WithFanOutConcurrency(1),
+		WithFanOutFailFast(),
+	)
+	var fanErr *FanOutError
+	if !stderrors.As(err, &fanErr) || !slices.Equal(fanErr.Failed(), []int{0, 1, 2, 3}) {
+		t.Fatalf("err = %v, want every request to fail", err)
+	}
+	if !stderrors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want the remaining requests cancelled", err)
+	}
+	if slices.ContainsFunc(responses, func(resp *types.CompletionResponse) bool { return resp != nil }) {
+		t.Errorf("responses = %v, want none", responses)
+	}
+}
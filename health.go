@@ -0,0 +1,59 @@
+package router
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WithPingModel makes Ping check a provider with a 1-token completion to
+// model instead of its default check, for credentials that may not list
+// models or may only use some of them.
+func WithPingModel(providerName types.Provider, model string) Option {
+	return func(r *Router) {
+		if r.config.PingModels == nil {
+			r.config.PingModels = make(map[types.Provider]string)
+		}
+		r.config.PingModels[providerName] = model
+	}
+}
+
+// Ping checks that a provider is reachable and accepts the router's
+// credentials, for readiness probes and health checks. It makes a minimal
+// authenticated call: a model listing where the provider has one, otherwise
+// a 1-token completion (see provider.Ping and WithPingModel). The call
+// bypasses metrics, budgets, and tenant usage.
+func (r *Router) Ping(ctx context.Context, providerName types.Provider) error {
+	p, err := r.getProvider(providerName)
+	if err != nil {
+		return err
+	}
+	if model, ok := r.config.PingModels[providerName]; ok {
+		return provider.PingCompletion(ctx, p, model)
+	}
+	return provider.Ping(ctx, p)
+}
+
+// PingAll pings every configured provider concurrently and returns each
+// one's error, nil for the healthy ones.
+func (r *Router) PingAll(ctx context.Context) map[types.Provider]error {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[types.Provider]error)
+	)
+	for _, name := range r.Providers() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := r.Ping(ctx, name)
+			mu.Lock()
+			results[name] = err
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,48 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// listingProvider lists its models, failing with err.
+type listingProvider struct {
+	fakeProvider
+	err   error
+	lists int
+}
+
+func (p *listingProvider) Name() types.Provider { return types.ProviderOpenAI }
+func (p *listingProvider) ListModels(context.Context) ([]provider.ModelInfo, error) {
+	p.lists++
+	return nil, p.err
+}
+
+func TestPing(t *testing.T) {
+	lister := &listingProvider{err: errors.ErrInvalidAPIKey(types.ProviderOpenAI)}
+	fake := &fakeProvider{}
+	r := newTestRouter(t, fake, WithPingModel(types.ProviderAnthropic, "claude-3-5-haiku-20241022"), func(r *Router) {
+		r.register(types.ProviderOpenAI, func(...provider.Option) provider.Provider { return lister }, nil)
+	})
+
+	results := r.PingAll(context.Background())
+	if len(results) != 2 || results[types.ProviderAnthropic] != nil {
+		t.Fatalf("results = %v", results)
+	}
+	var rerr *errors.RouterError
+	if !stderrors.As(results[types.ProviderOpenAI], &rerr) || rerr.Code != errors.ErrCodeInvalidAPIKey {
+		t.Errorf("openai err = %v, want invalid API key", results[types.ProviderOpenAI])
+	}
+	if lister.lists != 1 {
+		t.Errorf("lists = %d, want 1", lister.lists)
+	}
+
+	if err := r.Ping(context.Background(), types.ProviderGoogle); !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeProviderUnavailable {
+		t.Errorf("unconfigured err = %v, want provider unavailable", err)
+	}
+}
@@ -0,0 +1,240 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// defaultHedgeDelay is the hedge delay for a primary without recorded
+// latencies.
+const defaultHedgeDelay = 2 * time.Second
+
+// HedgeOption configures Hedge and HedgeStream.
+type HedgeOption func(*hedgeConfig)
+
+type hedgeConfig struct {
+	delay time.Duration
+}
+
+// WithHedgeDelay sets how long the primary gets before the hedge request is
+// sent. The default is the primary's p95 latency recorded in Metrics, time
+// to first token for HedgeStream, or 2 seconds until one is recorded.
+func WithHedgeDelay(d time.Duration) HedgeOption {
+	return func(c *hedgeConfig) {
+		c.delay = d
+	}
+}
+
+// Hedge sends req to its provider and model, the primary, and if no
+// response arrives within the hedge delay, sends it to backup as well and
+// returns whichever succeeds first, cancelling the other. Unlike Race, the
+// backup is only paid for on the slow tail of requests.
+//
+//	resp, err := r.Hedge(ctx, req,
+//		router.ModelTarget{Provider: types.ProviderGoogle, Model: "gemini-2.5-flash"},
+//		router.WithHedgeDelay(800*time.Millisecond),
+//	)
+//
+// A primary that fails before the hedge delay fails the call, as the backup
+// is for latency, not errors. Hedges and backup wins are recorded in the
+// primary's ModelStats. If both requests fail, their errors are joined.
+func (r *Router) Hedge(ctx context.Context, req *types.CompletionRequest, backup ModelTarget, opts ...HedgeOption) (*types.CompletionResponse, error) {
+	delay := r.hedgeDelay(req, opts, false)
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(errRaceLost)
+
+	type result struct {
+		i    int
+		resp *types.CompletionResponse
+		err  error
+	}
+	results := make(chan result, 2)
+	send := func(i int, req *types.CompletionRequest) {
+		go func() {
+			resp, err := r.Complete(ctx, req)
+			results <- result{i, resp, err}
+		}()
+	}
+	send(0, req)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var errs [2]error
+	hedged := false
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			send(1, hedgeRequest(req, backup))
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if hedged {
+					r.metrics.RecordHedge(req.Provider, req.Model, res.i == 1)
+				}
+				return res.resp, nil
+			}
+			errs[res.i] = res.err
+			if !hedged {
+				return nil, res.err
+			}
+		}
+	}
+	r.metrics.RecordHedge(req.Provider, req.Model, false)
+	return nil, stderrors.Join(errs[:]...)
+}
+
+// HedgeStream is Hedge for streams: if the primary's stream produces no
+// token within the hedge delay, a stream from backup is started as well,
+// and the first to produce a token is returned while the other is closed.
+// The events the winner sent before its first token, such as its start
+// event, are replayed.
+func (r *Router) HedgeStream(ctx context.Context, req *types.CompletionRequest, backup ModelTarget, opts ...HedgeOption) (types.StreamReader, error) {
+	delay := r.hedgeDelay(req, opts, true)
+
+	results := make(chan *hedgedStream, 2)
+	var cancels [2]context.CancelCauseFunc
+	start := func(i int, req *types.CompletionRequest) {
+		ctx, cancel := context.WithCancelCause(ctx)
+		cancels[i] = cancel
+		go func() {
+			results <- r.firstToken(ctx, cancel, i, req)
+		}()
+	}
+	start(0, req)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var errs [2]error
+	hedged := false
+	for pending := 1; pending > 0; {
+		select {
+		case <-timer.C:
+			hedged = true
+			pending++
+			start(1, hedgeRequest(req, backup))
+		case res := <-results:
+			pending--
+			if res.err == nil {
+				if hedged {
+					r.metrics.RecordHedge(req.Provider, req.Model, res.i == 1)
+				}
+				if pending > 0 {
+					cancels[1-res.i](errRaceLost)
+					go closeLoser(results)
+				}
+				return res, nil
+			}
+			errs[res.i] = res.err
+			if !hedged {
+				return nil, res.err
+			}
+		}
+	}
+	r.metrics.RecordHedge(req.Provider, req.Model, false)
+	return nil, stderrors.Join(errs[:]...)
+}
+
+// hedgeDelay returns the configured hedge delay or the primary's recorded
+// p95 latency.
+func (r *Router) hedgeDelay(req *types.CompletionRequest, opts []HedgeOption, stream bool) time.Duration {
+	cfg := &hedgeConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.delay > 0 {
+		return cfg.delay
+	}
+
+	stats, _ := r.metrics.Stats(req.Provider, req.Model)
+	p95 := stats.P95
+	if stream {
+		p95 = stats.TTFTP95
+	}
+	if p95 > 0 {
+		return p95
+	}
+	return defaultHedgeDelay
+}
+
+// hedgeRequest returns a copy of req for backup.
+func hedgeRequest(req *types.CompletionRequest, backup ModelTarget) *types.CompletionRequest {
+	hedge := *req
+	hedge.Provider = backup.Provider
+	hedge.Model = backup.Model
+	return &hedge
+}
+
+// firstToken starts a stream for req and reads it up to its first token or
+// its end. The stream owns cancel, which ends its context when it is closed.
+func (r *Router) firstToken(ctx context.Context, cancel context.CancelCauseFunc, i int, req *types.CompletionRequest) *hedgedStream {
+	stream, err := r.Stream(ctx, req)
+	if err != nil {
+		cancel(nil)
+		return &hedgedStream{i: i, err: err}
+	}
+
+	s := &hedgedStream{StreamReader: stream, i: i, cancel: cancel}
+	for {
+		event, err := stream.Next()
+		if err == nil && event != nil && event.Type == types.StreamEventError {
+			err = event.Error
+		}
+		if err != nil {
+			s.Close()
+			return &hedgedStream{i: i, err: err}
+		}
+		if event == nil {
+			s.done = true
+			return s
+		}
+		s.events = append(s.events, event)
+		if isToken(event) {
+			return s
+		}
+	}
+}
+
+// closeLoser closes the stream of the request that lost a HedgeStream once
+// it has started.
+func closeLoser(results <-chan *hedgedStream) {
+	if res := <-results; res.err == nil {
+		res.Close()
+	}
+}
+
+// hedgedStream is a stream started by HedgeStream, with the events read
+// while waiting for its first token.
+type hedgedStream struct {
+	types.StreamReader
+	i      int
+	err    error
+	events []*types.StreamEvent
+	done   bool
+	cancel context.CancelCauseFunc
+}
+
+func (s *hedgedStream) Next() (*types.StreamEvent, error) {
+	if len(s.events) > 0 {
+		event := s.events[0]
+		s.events = s.events[1:]
+		return event, nil
+	}
+	if s.done {
+		return nil, nil
+	}
+	return s.StreamReader.Next()
+}
+
+func (s *hedgedStream) Close() error {
+	err := s.StreamReader.Close()
+	s.cancel(nil)
+	return err
+}
@@ -0,0 +1,132 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// newHedgeRouter returns a router whose Anthropic provider stalls and whose
+// OpenAI provider answers "backup", streamed or not.
+func newHedgeRouter(t *testing.T) *Router {
+	t.Helper()
+	stalling := newStallingServer(t)
+	t.Cleanup(stalling.Close)
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if !body.Stream {
+			fmt.Fprint(w, `{"id":"1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"backup"},"finish_reason":"stop"}]}`)
+			return
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"backup\"},\"finish_reason\":\"stop\"}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	t.Cleanup(fast.Close)
+
+	r, err := New(
+		WithAnthropic("key", provider.WithBaseURL(stalling.URL)),
+		WithOpenAI("key", provider.WithBaseURL(fast.URL)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func hedgeRequests() (*types.CompletionRequest, ModelTarget) {
+	req := &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	}
+	return req, ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o"}
+}
+
+func TestHedge(t *testing.T) {
+	r := newHedgeRouter(t)
+	req, backup := hedgeRequests()
+
+	resp, err := r.Hedge(context.Background(), req, backup, WithHedgeDelay(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != types.ProviderOpenAI || resp.Text() != "backup" {
+		t.Errorf("got %s %q, want the backup response", resp.Provider, resp.Text())
+	}
+
+	// Wait for the primary to return before checking its metrics.
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats, _ := r.Metrics().Stats(types.ProviderAnthropic, "claude-haiku-4-5")
+	if stats.Hedges != 1 || stats.HedgeWins != 1 || stats.Errors != 0 {
+		t.Errorf("stats = %+v, want 1 hedge won by the backup and no errors", stats)
+	}
+}
+
+func TestHedge_PrimaryInTime(t *testing.T) {
+	r := newHedgeRouter(t)
+	primary, _ := hedgeRequests()
+	req := hedgeRequest(primary, ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o"})
+	backup := ModelTarget{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"}
+
+	resp, err := r.Hedge(context.Background(), req, backup, WithHedgeDelay(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != types.ProviderOpenAI {
+		t.Errorf("got %s, want the primary's response", resp.Provider)
+	}
+	if stats, _ := r.Metrics().Stats(types.ProviderOpenAI, "gpt-4o"); stats.Hedges != 0 {
+		t.Errorf("recorded %d hedges, want 0", stats.Hedges)
+	}
+}
+
+func TestHedgeStream(t *testing.T) {
+	r := newHedgeRouter(t)
+	req, backup := hedgeRequests()
+
+	// The primary sends its start event but no token.
+	stream, err := r.HedgeStream(context.Background(), req, backup, WithHedgeDelay(20*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventContentDelta {
+			text += event.Delta.Text
+		}
+	}
+	if resp := stream.Response(); text != "backup" || resp.Provider != types.ProviderOpenAI {
+		t.Errorf("streamed %q from %s, want the backup stream", text, resp.Provider)
+	}
+	stream.Close()
+
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	stats, _ := r.Metrics().Stats(types.ProviderAnthropic, "claude-haiku-4-5")
+	if stats.Hedges != 1 || stats.HedgeWins != 1 || stats.StreamErrors != 0 {
+		t.Errorf("stats = %+v, want 1 hedge won by the backup and no stream errors", stats)
+	}
+}
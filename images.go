@@ -0,0 +1,266 @@
+package router
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// ImagePreflight configures checks of image inputs before a request is sent.
+// Image URLs are downloaded; every image's media type is sniffed from its
+// bytes and checked against what the provider accepts, and its size against
+// the provider's limit. Images that fail are rejected with an invalid_request
+// error instead of a less helpful provider error.
+type ImagePreflight struct {
+	// MaxBytes overrides the provider's per-image size limit.
+	MaxBytes int64
+
+	// InlineURLs sends downloaded URL images as base64 data for every
+	// provider. Without it, only providers that cannot fetch URLs get
+	// inline data (Google's Gemini API); others are sent the URL.
+	InlineURLs bool
+
+	// Downscale shrinks JPEG, PNG, and GIF images that exceed the
+	// provider's size limit or longest-edge limit instead of rejecting
+	// them, re-encoding them as JPEG if needed to fit. Downscaled images
+	// are sent inline.
+	Downscale bool
+
+	// MaxEdge overrides the provider's longest-edge limit, in pixels, for
+	// Downscale.
+	MaxEdge int
+
+	// HTTPClient downloads image URLs. Nil uses http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+// imageLimits are the per-image size limits of providers' APIs.
+var imageLimits = map[types.Provider]int64{
+	types.ProviderOpenAI:    20 << 20,
+	types.ProviderAnthropic: 5 << 20,
+	types.ProviderGoogle:    20 << 20,
+	types.ProviderVertex:    7 << 20,
+}
+
+// defaultImageLimit applies to providers without a known limit.
+const defaultImageLimit = 20 << 20
+
+// imageTypes are the image media types providers accept.
+var imageTypes = map[types.Provider][]string{
+	types.ProviderOpenAI:    {"image/jpeg", "image/png", "image/gif", "image/webp"},
+	types.ProviderAnthropic: {"image/jpeg", "image/png", "image/gif", "image/webp"},
+	types.ProviderGoogle:    {"image/jpeg", "image/png", "image/webp", "image/heic", "image/heif"},
+	types.ProviderVertex:    {"image/jpeg", "image/png", "image/webp", "image/heic", "image/heif"},
+}
+
+// WithImagePreflight checks image inputs before requests are sent, and
+// converts image URLs to inline data for providers that only accept it.
+func WithImagePreflight(cfg ImagePreflight) Option {
+	return func(r *Router) {
+		r.config.ImagePreflight = &cfg
+	}
+}
+
+// preflightImages applies the image preflight to req. It returns req itself
+// if it has no images, and otherwise a copy with checked images; the
+// caller's messages are not modified.
+func (r *Router) preflightImages(ctx context.Context, name types.Provider, req *types.CompletionRequest) (*types.CompletionRequest, error) {
+	cfg := r.config.ImagePreflight
+	if cfg == nil || !hasImages(req.Messages) {
+		return req, nil
+	}
+
+	pf := &imageChecker{cfg: cfg, provider: name, limit: imageLimits[name], maxEdge: imageMaxEdge[name], types: imageTypes[name]}
+	if cfg.MaxBytes > 0 {
+		pf.limit = cfg.MaxBytes
+	} else if pf.limit == 0 {
+		pf.limit = defaultImageLimit
+	}
+	if cfg.MaxEdge > 0 {
+		pf.maxEdge = cfg.MaxEdge
+	} else if pf.maxEdge == 0 {
+		pf.maxEdge = defaultImageMaxEdge
+	}
+	if pf.client = cfg.HTTPClient; pf.client == nil {
+		pf.client = http.DefaultClient
+	}
+
+	checked := *req
+	checked.Messages = make([]types.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		content, err := pf.checkBlocks(ctx, msg.Content)
+		if err != nil {
+			return nil, err
+		}
+		checked.Messages[i] = types.Message{Role: msg.Role, Content: content}
+	}
+	return &checked, nil
+}
+
+func hasImages(messages []types.Message) bool {
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == types.ContentTypeImage || len(block.ToolResultContent) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+type imageChecker struct {
+	cfg      *ImagePreflight
+	provider types.Provider
+	client   *http.Client
+	limit    int64
+	maxEdge  int
+	types    []string
+}
+
+// checkBlocks returns a copy of blocks with images checked, including those
+// inside tool results.
+func (c *imageChecker) checkBlocks(ctx context.Context, blocks []types.ContentBlock) ([]types.ContentBlock, error) {
+	result := make([]types.ContentBlock, len(blocks))
+	for i, block := range blocks {
+		var err error
+		switch {
+		case block.Type == types.ContentTypeImage:
+			block, err = c.checkImage(ctx, block)
+		case len(block.ToolResultContent) > 0:
+			block.ToolResultContent, err = c.checkBlocks(ctx, block.ToolResultContent)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result[i] = block
+	}
+	return result, nil
+}
+
+// checkImage checks one image block, downloading or decoding its data.
+func (c *imageChecker) checkImage(ctx context.Context, block types.ContentBlock) (types.ContentBlock, error) {
+	var data []byte
+	inline := true
+	switch {
+	case block.ImageBase64 != "":
+		decoded, err := base64.StdEncoding.DecodeString(block.ImageBase64)
+		if err != nil {
+			return block, errors.ErrInvalidRequest("image preflight: invalid base64 image data").WithCause(err)
+		}
+		data = decoded
+
+	case strings.HasPrefix(block.ImageURL, "data:"):
+		mediaType, decoded, err := parseDataURL(block.ImageURL)
+		if err != nil {
+			return block, errors.ErrInvalidRequest("image preflight: invalid data URL").WithCause(err)
+		}
+		if block.MediaType == "" {
+			block.MediaType = mediaType
+		}
+		data = decoded
+
+	case strings.HasPrefix(block.ImageURL, "http://") || strings.HasPrefix(block.ImageURL, "https://"):
+		if c.provider == types.ProviderGoogle && strings.HasPrefix(block.ImageURL, "https://generativelanguage.googleapis.com/") {
+			return block, nil // a Files API URI
+		}
+		downloaded, err := c.download(ctx, block.ImageURL)
+		if err != nil {
+			return block, err
+		}
+		data = downloaded
+		inline = c.cfg.InlineURLs || c.provider == types.ProviderGoogle
+
+	default:
+		// Provider-specific URIs such as gs:// are passed through.
+		return block, nil
+	}
+
+	mediaType := sniffImageType(data)
+	if mediaType == "" {
+		mediaType = block.MediaType
+	}
+	if mediaType == "" {
+		return block, errors.ErrInvalidRequest("image preflight: unrecognized image format").WithProvider(c.provider)
+	}
+
+	if c.cfg.Downscale {
+		if out, outType, ok := downscale(data, mediaType, c.maxEdge, c.limit); ok {
+			data, mediaType, inline = out, outType, true
+		}
+	}
+	if int64(len(data)) > c.limit {
+		return block, errors.ErrInvalidRequest(fmt.Sprintf("image preflight: image is %d bytes, over the %d byte limit of %s", len(data), c.limit, c.provider)).WithProvider(c.provider)
+	}
+	if len(c.types) > 0 && !slices.Contains(c.types, mediaType) {
+		return block, errors.ErrInvalidRequest(fmt.Sprintf("image preflight: %s does not accept %q images", c.provider, mediaType)).WithProvider(c.provider)
+	}
+	block.MediaType = mediaType
+
+	if inline {
+		block.ImageURL = ""
+		block.ImageBase64 = base64.StdEncoding.EncodeToString(data)
+	}
+	return block, nil
+}
+
+// download fetches an image URL, reading at most one byte over the limit, or
+// over maxDownscaleInput when downscaling.
+func (c *imageChecker) download(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("image preflight: invalid image URL").WithCause(err)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("image preflight: failed to download " + url).WithCause(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("image preflight: downloading %s returned status %d", url, resp.StatusCode))
+	}
+	limit := c.limit
+	if c.cfg.Downscale {
+		limit = max(limit, maxDownscaleInput)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("image preflight: failed to download " + url).WithCause(err)
+	}
+	return data, nil
+}
+
+// parseDataURL decodes a base64 data URL.
+func parseDataURL(url string) (string, []byte, error) {
+	header, payload, ok := strings.Cut(strings.TrimPrefix(url, "data:"), ",")
+	if !ok || !strings.HasSuffix(header, ";base64") {
+		return "", nil, fmt.Errorf("expected a base64 data URL")
+	}
+	mediaType, _, _ := mime.ParseMediaType(strings.TrimSuffix(header, ";base64"))
+	data, err := base64.StdEncoding.DecodeString(payload)
+	return mediaType, data, err
+}
+
+// sniffImageType returns the media type of image data, or "" if it is not
+// recognized.
+func sniffImageType(data []byte) string {
+	if len(data) >= 12 && string(data[4:8]) == "ftyp" {
+		switch string(data[8:12]) {
+		case "heic", "heix", "heim", "heis":
+			return "image/heic"
+		case "mif1", "msf1":
+			return "image/heif"
+		}
+	}
+	if t := http.DetectContentType(data); strings.HasPrefix(t, "image/") {
+		return t
+	}
+	return ""
+}
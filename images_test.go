@@ -0,0 +1,135 @@
+package router
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	stderrors "errors"
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// recordingProvider records Complete requests under a given name.
+type recordingProvider struct {
+	fakeProvider
+	name     types.Provider
+	requests []*types.CompletionRequest
+}
+
+func (f *recordingProvider) Name() types.Provider { return f.name }
+func (f *recordingProvider) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	f.requests = append(f.requests, req)
+	return &types.CompletionResponse{Provider: f.name, Model: req.Model}, nil
+}
+
+func pngBytes(t *testing.T) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 2, 2))); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func imageRequest(p types.Provider, block types.ContentBlock) *types.CompletionRequest {
+	block.Type = types.ContentTypeImage
+	return &types.CompletionRequest{
+		Provider: p,
+		Model:    "m",
+		Messages: []types.Message{{Role: types.RoleUser, Content: []types.ContentBlock{
+			{Type: types.ContentTypeText, Text: "Describe this"},
+			block,
+		}}},
+	}
+}
+
+func TestImagePreflight_InlinesURLsForGoogle(t *testing.T) {
+	data := pngBytes(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(data)
+	}))
+	defer srv.Close()
+
+	fake := &recordingProvider{name: types.ProviderGoogle}
+	r := newTestRouter(t, fake, WithImagePreflight(ImagePreflight{}))
+
+	req := imageRequest(types.ProviderGoogle, types.ContentBlock{ImageURL: srv.URL + "/cat.png"})
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	got := fake.requests[0].Messages[0].Content[1]
+	if got.ImageURL != "" || got.ImageBase64 != base64.StdEncoding.EncodeToString(data) || got.MediaType != "image/png" {
+		t.Errorf("image = %+v, want inline png", got)
+	}
+	if req.Messages[0].Content[1].ImageURL == "" {
+		t.Error("caller's request was modified")
+	}
+}
+
+func TestImagePreflight_KeepsURLsForAnthropic(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write(pngBytes(t))
+	}))
+	defer srv.Close()
+
+	fake := &recordingProvider{name: types.ProviderAnthropic}
+	r := newTestRouter(t, fake, WithImagePreflight(ImagePreflight{}))
+
+	if _, err := r.Complete(context.Background(), imageRequest(types.ProviderAnthropic, types.ContentBlock{ImageURL: srv.URL})); err != nil {
+		t.Fatal(err)
+	}
+	got := fake.requests[0].Messages[0].Content[1]
+	if got.ImageURL != srv.URL || got.ImageBase64 != "" || got.MediaType != "image/png" {
+		t.Errorf("image = %+v, want URL kept with media type", got)
+	}
+}
+
+func TestImagePreflight_Rejects(t *testing.T) {
+	data := pngBytes(t)
+	tests := []struct {
+		name  string
+		cfg   ImagePreflight
+		block types.ContentBlock
+	}{
+		{"too large", ImagePreflight{MaxBytes: 10}, types.ContentBlock{ImageBase64: base64.StdEncoding.EncodeToString(data)}},
+		{"not an image", ImagePreflight{}, types.ContentBlock{ImageBase64: base64.StdEncoding.EncodeToString([]byte("hello"))}},
+		{"unsupported type", ImagePreflight{}, types.ContentBlock{ImageBase64: base64.StdEncoding.EncodeToString([]byte("BM\x00\x00\x00\x00\x00\x00\x00\x00"))}},
+		{"invalid base64", ImagePreflight{}, types.ContentBlock{ImageBase64: "%%%"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &recordingProvider{name: types.ProviderAnthropic}
+			r := newTestRouter(t, fake, WithImagePreflight(tt.cfg))
+
+			_, err := r.Complete(context.Background(), imageRequest(types.ProviderAnthropic, tt.block))
+			var rerr *errors.RouterError
+			if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+				t.Fatalf("err = %v, want invalid_request", err)
+			}
+			if len(fake.requests) != 0 {
+				t.Error("request was sent")
+			}
+		})
+	}
+}
+
+func TestImagePreflight_CorrectsMediaType(t *testing.T) {
+	fake := &recordingProvider{name: types.ProviderOpenAI}
+	r := newTestRouter(t, fake, WithImagePreflight(ImagePreflight{}))
+
+	block := types.ContentBlock{ImageURL: "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(pngBytes(t))}
+	if _, err := r.Complete(context.Background(), imageRequest(types.ProviderOpenAI, block)); err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.requests[0].Messages[0].Content[1]; got.MediaType != "image/png" || got.ImageBase64 == "" {
+		t.Errorf("image = %+v, want inline png", got)
+	}
+}
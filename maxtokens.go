@@ -0,0 +1,41 @@
+package router
+
+import (
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WithDefaultMaxTokens sets the MaxTokens sent for requests that leave it
+// unset. A model's catalog DefaultMaxTokens takes precedence, and either is
+// capped at the model's output limit. Zero leaves the choice to each
+// provider.
+func WithDefaultMaxTokens(n int) Option {
+	return func(r *Router) {
+		r.config.DefaultMaxTokens = n
+	}
+}
+
+// applyDefaultMaxTokens returns req with the default MaxTokens filled in, or
+// req itself if it sets MaxTokens or there is no default for the model.
+func (r *Router) applyDefaultMaxTokens(p provider.Provider, req *types.CompletionRequest) *types.CompletionRequest {
+	if req.MaxTokens != nil {
+		return req
+	}
+
+	n := r.config.DefaultMaxTokens
+	info, known := models.Lookup(p.Name(), req.Model)
+	if known && info.DefaultMaxTokens > 0 {
+		n = info.DefaultMaxTokens
+	}
+	if known && info.MaxOutputTokens > 0 && n > info.MaxOutputTokens {
+		n = info.MaxOutputTokens
+	}
+	if n <= 0 {
+		return req
+	}
+
+	clone := *req
+	clone.MaxTokens = &n
+	return &clone
+}
@@ -0,0 +1,97 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/Chloe199719/agent-router/pkg/mcp"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// maxMCPRounds bounds the model turns in completeWithMCP.
+const maxMCPRounds = 10
+
+// completeWithMCP serves a request with MCP servers on a provider without a
+// native MCP connector: it offers the servers' tools alongside the request's
+// own, executes the model's MCP tool calls, and continues the conversation
+// until the model answers or calls a tool the caller must run. Usage covers
+// every model turn.
+func (r *Router) completeWithMCP(ctx context.Context, p provider.Provider, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	conv := *req
+	conv.MCPServers = nil
+	conv.Tools = append([]types.Tool(nil), req.Tools...)
+	conv.Messages = append([]types.Message(nil), req.Messages...)
+
+	owners := make(map[string]*mcp.Client)
+	for _, t := range req.Tools {
+		owners[t.Name] = nil // the caller's tools take precedence
+	}
+	for _, server := range req.MCPServers {
+		client := mcp.NewClient(server, nil)
+		defer client.Close(context.WithoutCancel(ctx))
+
+		tools, err := client.ListTools(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for _, t := range tools {
+			if _, taken := owners[t.Name]; taken {
+				continue
+			}
+			tool, err := t.ToTool()
+			if err != nil {
+				return nil, err
+			}
+			owners[t.Name] = client
+			conv.Tools = append(conv.Tools, tool)
+		}
+	}
+
+	var usage types.Usage
+	for round := 1; ; round++ {
+		resp, err := p.Complete(ctx, &conv)
+		if err != nil {
+			return nil, err
+		}
+		usage.Add(resp.Usage)
+		resp.Usage = usage
+
+		if !resp.HasToolCalls() || round == maxMCPRounds || !allOwned(resp.ToolCalls, owners) {
+			return resp, nil
+		}
+
+		conv.Messages = append(conv.Messages, types.Message{Role: types.RoleAssistant, Content: resp.Content})
+		for _, tc := range resp.ToolCalls {
+			conv.Messages = append(conv.Messages, callMCPTool(ctx, owners[tc.Name], tc))
+		}
+	}
+}
+
+// callMCPTool runs a tool call and returns its result message. Failures are
+// reported to the model as error results.
+func callMCPTool(ctx context.Context, client *mcp.Client, tc types.ToolCall) types.Message {
+	input := tc.Input
+	if s, ok := input.(string); ok {
+		// Unparseable streamed input is kept as a raw string.
+		var v any
+		if json.Unmarshal([]byte(s), &v) == nil {
+			input = v
+		}
+	}
+	result, err := client.CallTool(ctx, tc.Name, input)
+	if err != nil {
+		return types.NewToolResultMessage(tc.ID, err.Error(), true)
+	}
+	return types.NewToolResultMessage(tc.ID, result.Text(), result.IsError)
+}
+
+// allOwned reports whether every call is to an MCP tool.
+func allOwned(calls []types.ToolCall, owners map[string]*mcp.Client) bool {
+	for _, tc := range calls {
+		if owners[tc.Name] == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// toolCallingProvider calls the "lookup" tool once, then answers with the
+// tool result it was given. It has no native MCP support.
+type toolCallingProvider struct {
+	fakeProvider
+	requests []*types.CompletionRequest
+}
+
+func (f *toolCallingProvider) Name() types.Provider { return types.ProviderOpenAI }
+func (f *toolCallingProvider) SupportsFeature(feature types.Feature) bool {
+	return feature != types.FeatureMCP
+}
+func (f *toolCallingProvider) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	f.requests = append(f.requests, req)
+	usage := types.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15}
+
+	last := req.Messages[len(req.Messages)-1]
+	if last.Role == types.RoleTool {
+		return &types.CompletionResponse{
+			Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: "answer: " + last.Content[0].Text}},
+			Usage:   usage,
+		}, nil
+	}
+	call := types.ToolCall{ID: "call_1", Name: "lookup", Input: map[string]any{"key": "color"}}
+	return &types.CompletionResponse{
+		Content:   []types.ContentBlock{{Type: types.ContentTypeToolUse, ToolUseID: call.ID, ToolName: call.Name, ToolInput: call.Input}},
+		ToolCalls: []types.ToolCall{call},
+		Usage:     usage,
+	}, nil
+}
+
+func TestComplete_LocalMCP(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     int64  `json:"id"`
+			Method string `json:"method"`
+			Params struct {
+				Arguments map[string]string `json:"arguments"`
+			} `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		switch req.Method {
+		case "initialize":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{}}`, req.ID)
+		case "tools/list":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"tools":[{"name":"lookup","inputSchema":{"type":"object","properties":{"key":{"type":"string"}}}}]}}`, req.ID)
+		case "tools/call":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"content":[{"type":"text","text":"%s is blue"}]}}`, req.ID, req.Params.Arguments["key"])
+		default:
+			w.WriteHeader(http.StatusAccepted)
+		}
+	}))
+	defer srv.Close()
+
+	fake := &toolCallingProvider{}
+	r := newTestRouter(t, fake)
+
+	req := &types.CompletionRequest{
+		Provider:   types.ProviderOpenAI,
+		Model:      "gpt-4o",
+		Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "What color?")},
+		MCPServers: []types.MCPServer{{Name: "kv", URL: srv.URL}},
+	}
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp.Text() != "answer: color is blue" {
+		t.Errorf("text = %q", resp.Text())
+	}
+	if resp.Usage.TotalTokens != 30 {
+		t.Errorf("usage = %+v, want both turns", resp.Usage)
+	}
+	if len(fake.requests) != 2 || len(fake.requests[0].Tools) != 1 || fake.requests[0].MCPServers != nil {
+		t.Errorf("provider saw %d requests; first had tools %v and MCP servers %v", len(fake.requests), fake.requests[0].Tools, fake.requests[0].MCPServers)
+	}
+	if len(req.Messages) != 1 || len(req.Tools) != 0 {
+		t.Error("Complete modified the caller's request")
+	}
+}
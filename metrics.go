@@ -0,0 +1,282 @@
+package router
+
+import (
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// latencyWindow is the number of recent latencies kept per model.
+const latencyWindow = 128
+
+// Metrics records request outcomes and latencies per provider and model. The
+// router records every Complete call and the time to first token of every
+// Stream call; routing strategies read it to rank candidates. It is safe for concurrent use.
+type Metrics struct {
+	mu     sync.Mutex
+	models map[metricsKey]*modelMetrics
+}
+
+type metricsKey struct {
+	provider types.Provider
+	model    string
+}
+
+type modelMetrics struct {
+	requests  int64
+	errors    int64
+	latencies []time.Duration // ring buffer of the last latencyWindow successes
+	next      int
+
+	streams      int64
+	streamErrors int64
+	ttfts        []time.Duration // ring buffer of the last latencyWindow streams
+	nextTTFT     int
+
+	acceptedPrediction int64
+	rejectedPrediction int64
+
+	hedges    int64
+	hedgeWins int64
+}
+
+// ModelStats summarizes the recorded requests for a provider and model.
+// Latency percentiles cover the most recent successful requests.
+type ModelStats struct {
+	Requests int64
+	Errors   int64
+	P50      time.Duration
+	P95      time.Duration
+
+	// Streams and StreamErrors count Stream calls that ran to completion or
+	// failed. TTFT percentiles cover the most recent successful streams.
+	Streams      int64
+	StreamErrors int64
+	TTFTP50      time.Duration
+	TTFTP95      time.Duration
+
+	// AcceptedPredictionTokens and RejectedPredictionTokens total the
+	// predicted output tokens of all requests with a Prediction. Accepted
+	// tokens are output the model did not have to generate one at a time,
+	// so their share of the total shows how much a prediction saves.
+	AcceptedPredictionTokens int64
+	RejectedPredictionTokens int64
+
+	// Hedges counts Hedge and HedgeStream calls with this model as the
+	// primary that launched a hedge request, and HedgeWins those the backup
+	// won. A high share of wins suggests making the backup the primary.
+	Hedges    int64
+	HedgeWins int64
+}
+
+func newMetrics() *Metrics {
+	return &Metrics{models: make(map[metricsKey]*modelMetrics)}
+}
+
+// Record adds a request outcome. Latencies are only recorded for successful
+// requests.
+func (m *Metrics) Record(providerName types.Provider, model string, latency time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := metricsKey{providerName, model}
+	mm := m.modelLocked(key)
+
+	mm.requests++
+	if err != nil {
+		mm.errors++
+		return
+	}
+	mm.latencies, mm.next = addSample(mm.latencies, mm.next, latency)
+}
+
+// RecordStream adds the outcome of a stream. Time to first token is only
+// recorded for successful streams.
+func (m *Metrics) RecordStream(providerName types.Provider, model string, stats types.StreamStats, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm := m.modelLocked(metricsKey{providerName, model})
+	mm.streams++
+	if err != nil {
+		mm.streamErrors++
+		return
+	}
+	mm.ttfts, mm.nextTTFT = addSample(mm.ttfts, mm.nextTTFT, stats.TimeToFirstToken)
+}
+
+// RecordPrediction adds the predicted output tokens of a successful request.
+func (m *Metrics) RecordPrediction(providerName types.Provider, model string, usage types.Usage) {
+	if usage.AcceptedPredictionTokens == 0 && usage.RejectedPredictionTokens == 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm := m.modelLocked(metricsKey{providerName, model})
+	mm.acceptedPrediction += int64(usage.AcceptedPredictionTokens)
+	mm.rejectedPrediction += int64(usage.RejectedPredictionTokens)
+}
+
+// RecordHedge adds a hedge request launched for a primary provider and
+// model, and whether the backup won.
+func (m *Metrics) RecordHedge(providerName types.Provider, model string, won bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mm := m.modelLocked(metricsKey{providerName, model})
+	mm.hedges++
+	if won {
+		mm.hedgeWins++
+	}
+}
+
+func (m *Metrics) modelLocked(key metricsKey) *modelMetrics {
+	mm := m.models[key]
+	if mm == nil {
+		mm = &modelMetrics{}
+		m.models[key] = mm
+	}
+	return mm
+}
+
+// addSample adds d to a ring buffer of latencyWindow samples and returns the
+// buffer and its next write position.
+func addSample(samples []time.Duration, next int, d time.Duration) ([]time.Duration, int) {
+	if len(samples) < latencyWindow {
+		return append(samples, d), next
+	}
+	samples[next] = d
+	return samples, (next + 1) % latencyWindow
+}
+
+// Stats returns the recorded stats for a provider and model. The second
+// result is false if no successful Complete call has been recorded; stream
+// and prediction stats are filled in either way.
+func (m *Metrics) Stats(providerName types.Provider, model string) (ModelStats, bool) {
+	m.mu.Lock()
+	mm := m.models[metricsKey{providerName, model}]
+	if mm == nil {
+		m.mu.Unlock()
+		return ModelStats{}, false
+	}
+	stats := ModelStats{
+		Requests:                 mm.requests,
+		Errors:                   mm.errors,
+		Streams:                  mm.streams,
+		StreamErrors:             mm.streamErrors,
+		AcceptedPredictionTokens: mm.acceptedPrediction,
+		RejectedPredictionTokens: mm.rejectedPrediction,
+		Hedges:                   mm.hedges,
+		HedgeWins:                mm.hedgeWins,
+	}
+	latencies := slices.Clone(mm.latencies)
+	ttfts := slices.Clone(mm.ttfts)
+	m.mu.Unlock()
+
+	if len(ttfts) > 0 {
+		slices.Sort(ttfts)
+		stats.TTFTP50 = percentile(ttfts, 0.50)
+		stats.TTFTP95 = percentile(ttfts, 0.95)
+	}
+	if len(latencies) == 0 {
+		return stats, false
+	}
+	slices.Sort(latencies)
+	stats.P50 = percentile(latencies, 0.50)
+	stats.P95 = percentile(latencies, 0.95)
+	return stats, true
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies.
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(math.Ceil(float64(len(sorted))*p)) - 1
+	return sorted[max(0, min(i, len(sorted)-1))]
+}
+
+// Metrics returns the router's request metrics.
+func (r *Router) Metrics() *Metrics {
+	return r.metrics
+}
+
+// statsStream measures a stream for StreamStats and records it in Metrics
+// when it ends.
+type statsStream struct {
+	types.StreamReader
+	ctx      context.Context
+	metrics  *Metrics
+	provider types.Provider
+	model    string
+	start    time.Time
+
+	mu    sync.Mutex
+	stats types.StreamStats
+	done  bool
+}
+
+func newStatsStream(ctx context.Context, stream types.StreamReader, metrics *Metrics, providerName types.Provider, model string, start time.Time) *statsStream {
+	return &statsStream{StreamReader: stream, ctx: ctx, metrics: metrics, provider: providerName, model: model, start: start}
+}
+
+func (s *statsStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.done {
+		return event, err
+	}
+
+	failure := err
+	if failure == nil && event != nil && event.Type == types.StreamEventError {
+		failure = event.Error
+	}
+	switch {
+	case failure != nil && lostRace(s.ctx):
+		s.done = true
+	case failure != nil || event == nil:
+		s.done = true
+		s.stats.Duration = time.Since(s.start)
+		s.metrics.RecordStream(s.provider, s.model, s.stats, failure)
+		if failure == nil {
+			if resp := s.StreamReader.Response(); resp != nil {
+				s.metrics.RecordPrediction(s.provider, s.model, resp.Usage)
+			}
+		}
+	case isToken(event):
+		if s.stats.TimeToFirstToken == 0 {
+			s.stats.TimeToFirstToken = time.Since(s.start)
+		}
+		if event.Type != types.StreamEventToolCallStart {
+			s.stats.Deltas++
+		}
+	}
+	return event, err
+}
+
+// Response returns the accumulated response with its StreamStats.
+func (s *statsStream) Response() *types.CompletionResponse {
+	resp := s.StreamReader.Response()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if resp != nil && s.done {
+		stats := s.stats
+		resp.StreamStats = &stats
+	}
+	return resp
+}
+
+// isToken reports whether event carries generated output, the first of
+// which marks the time to first token.
+func isToken(event *types.StreamEvent) bool {
+	switch event.Type {
+	case types.StreamEventContentDelta, types.StreamEventThinkingDelta, types.StreamEventAudioDelta,
+		types.StreamEventToolCallStart, types.StreamEventToolCallDelta:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,121 @@
+package router
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// defaultModelCacheTTL is how long ListModels results are reused.
+const defaultModelCacheTTL = time.Hour
+
+// WithModelCacheTTL sets how long ListModels caches a provider's model list.
+// Zero disables caching.
+func WithModelCacheTTL(d time.Duration) Option {
+	return func(r *Router) {
+		r.config.ModelCacheTTL = d
+	}
+}
+
+// modelCache holds model lists fetched from provider APIs.
+type modelCache struct {
+	mu      sync.Mutex
+	entries map[types.Provider]modelCacheEntry
+}
+
+type modelCacheEntry struct {
+	models  []provider.ModelInfo
+	fetched time.Time
+}
+
+func newModelCache() *modelCache {
+	return &modelCache{entries: make(map[types.Provider]modelCacheEntry)}
+}
+
+// get returns a cached list younger than ttl.
+func (c *modelCache) get(name types.Provider, ttl time.Duration) ([]provider.ModelInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[name]
+	if !ok || time.Since(entry.fetched) >= ttl {
+		return nil, false
+	}
+	return entry.models, true
+}
+
+func (c *modelCache) put(name types.Provider, models []provider.ModelInfo) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[name] = modelCacheEntry{models: models, fetched: time.Now()}
+}
+
+func (c *modelCache) invalidate(name types.Provider) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, name)
+}
+
+// ListModels fetches the models a provider currently serves, with whatever
+// metadata its API reports (context window, modalities, deprecation).
+// Limits and deprecation the API omits are filled in from the models
+// catalog. Results are cached for Config.ModelCacheTTL. Providers without a
+// model listing API return their built-in Models list.
+//
+// The returned slice is shared with the cache and must not be modified.
+func (r *Router) ListModels(ctx context.Context, providerName types.Provider) ([]provider.ModelInfo, error) {
+	p, err := r.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	ttl := r.config.ModelCacheTTL
+	if ttl > 0 {
+		if models, ok := r.models.get(providerName, ttl); ok {
+			return models, nil
+		}
+	}
+
+	var models []provider.ModelInfo
+	if lister, ok := p.(provider.ModelLister); ok {
+		models, err = lister.ListModels(ctx)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, id := range p.Models() {
+			models = append(models, provider.ModelInfo{ID: id, Provider: providerName})
+		}
+	}
+	for i := range models {
+		enrichModelInfo(&models[i])
+	}
+	slices.SortFunc(models, func(a, b provider.ModelInfo) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+
+	if ttl > 0 {
+		r.models.put(providerName, models)
+	}
+	return models, nil
+}
+
+// enrichModelInfo fills fields the provider did not report from the catalog.
+func enrichModelInfo(m *provider.ModelInfo) {
+	info, ok := models.Lookup(m.Provider, m.ID)
+	if !ok {
+		return
+	}
+	if m.ContextWindow == 0 {
+		m.ContextWindow = info.ContextWindow
+	}
+	if m.MaxOutputTokens == 0 {
+		m.MaxOutputTokens = info.OutputLimit()
+	}
+	m.Deprecated = m.Deprecated || info.Deprecated
+}
@@ -0,0 +1,135 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestListModels_CachesAndInvalidates(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if r.URL.Query().Get("pageToken") == "" {
+			fmt.Fprint(w, `{"models":[
+				{"name":"models/gemini-2.5-flash","displayName":"Gemini 2.5 Flash","inputTokenLimit":1048576,"outputTokenLimit":65536,"supportedGenerationMethods":["generateContent","countTokens"]},
+				{"name":"models/text-embedding-004","supportedGenerationMethods":["embedContent"]}
+			],"nextPageToken":"p2"}`)
+			return
+		}
+		fmt.Fprint(w, `{"models":[{"name":"models/gemini-2.0-flash","supportedGenerationMethods":["generateContent"]}]}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithGoogle("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	models, err := r.ListModels(context.Background(), types.ProviderGoogle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].ID != "gemini-2.0-flash" || models[1].ContextWindow != 1048576 {
+		t.Errorf("unexpected models: %+v", models)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected both pages to be fetched, got %d requests", calls.Load())
+	}
+
+	if _, err := r.ListModels(context.Background(), types.ProviderGoogle); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 2 {
+		t.Errorf("expected cached result, got %d requests", calls.Load())
+	}
+
+	// A new key may see different models.
+	if err := r.UpdateAPIKey(types.ProviderGoogle, "key2"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.ListModels(context.Background(), types.ProviderGoogle); err != nil {
+		t.Fatal(err)
+	}
+	if calls.Load() != 4 {
+		t.Errorf("expected refetch after key change, got %d requests", calls.Load())
+	}
+}
+
+func TestCheckFeatureSupport_ModelCatalog(t *testing.T) {
+	r := newTestRouter(t, &fakeProvider{})
+
+	// claude-sonnet-4 has no native structured output, unlike the provider.
+	_, err := r.Complete(context.Background(), &types.CompletionRequest{
+		Provider:       types.ProviderAnthropic,
+		Model:          "claude-sonnet-4-20250514",
+		Messages:       []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+		ResponseFormat: &types.ResponseFormat{Type: "json_schema"},
+	})
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeUnsupportedFeature {
+		t.Errorf("expected unsupported feature error, got %v", err)
+	}
+
+	_, err = r.Complete(context.Background(), &types.CompletionRequest{
+		Provider:  types.ProviderAnthropic,
+		Model:     "claude-3-5-haiku-20241022",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+		MaxTokens: types.Ptr(20000),
+	})
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		t.Errorf("expected invalid request for max_tokens over the model limit, got %v", err)
+	}
+
+	// claude-3-7-sonnet goes up to 128k with the extended output beta.
+	_, err = r.Complete(context.Background(), &types.CompletionRequest{
+		Provider:  types.ProviderAnthropic,
+		Model:     "claude-3-7-sonnet-20250219",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+		MaxTokens: types.Ptr(128000),
+	})
+	if err != nil {
+		t.Errorf("expected max_tokens within the extended output limit to pass, got %v", err)
+	}
+}
+
+func TestDefaultMaxTokens(t *testing.T) {
+	fake := &recordingProvider{name: types.ProviderAnthropic}
+	r := newTestRouter(t, fake, WithDefaultMaxTokens(16000))
+
+	tests := []struct {
+		model     string
+		maxTokens *int
+		want      int
+	}{
+		{"custom-model", nil, 16000},
+		{"claude-3-5-haiku-20241022", nil, 8192}, // capped at the model's limit
+		{"claude-sonnet-4-20250514", types.Ptr(100), 100},
+	}
+	for _, tt := range tests {
+		req := &types.CompletionRequest{
+			Provider:  types.ProviderAnthropic,
+			Model:     tt.model,
+			Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+			MaxTokens: tt.maxTokens,
+		}
+		if _, err := r.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+		got := fake.requests[len(fake.requests)-1].MaxTokens
+		if got == nil || *got != tt.want {
+			t.Errorf("%s: MaxTokens = %v, want %d", tt.model, got, tt.want)
+		}
+		if tt.maxTokens == nil && req.MaxTokens != nil {
+			t.Errorf("%s: caller's request was modified", tt.model)
+		}
+	}
+}
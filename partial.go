@@ -0,0 +1,68 @@
+package router
+
+import (
+	"encoding/json"
+	"fmt"
+	"iter"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// StreamPartial reads the text of a structured output stream and yields a
+// progressively more complete T each time more of the JSON value arrives.
+// Intermediate values are best-effort: strings may be cut short and fields
+// missing. The last value is decoded from the whole text (with
+// schema.Repair), and a stream that does not produce valid JSON for T ends
+// with an error. The caller still closes the stream.
+func StreamPartial[T any](stream types.StreamReader) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		var text strings.Builder
+		last := ""
+
+		for {
+			event, err := stream.Next()
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+			if event == nil {
+				break
+			}
+			if event.Type == types.StreamEventError {
+				yield(zero, event.Error)
+				return
+			}
+			if event.Type != types.StreamEventContentDelta || event.Delta == nil || event.Delta.Text == "" {
+				continue
+			}
+
+			text.WriteString(event.Delta.Text)
+			completed, ok := schema.CompletePartial(text.String())
+			if !ok || completed == last {
+				continue
+			}
+			var v T
+			if json.Unmarshal([]byte(completed), &v) != nil {
+				continue // a partial value that does not fit T yet
+			}
+			last = completed
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		final := schema.Repair(text.String())
+		var v T
+		if err := json.Unmarshal([]byte(final), &v); err != nil {
+			yield(zero, errors.NewError(errors.ErrCodeServerError, fmt.Sprintf("structured output stream is not valid JSON: %v", err)).WithCause(err))
+			return
+		}
+		if completed, _ := schema.CompletePartial(final); completed != last {
+			yield(v, nil)
+		}
+	}
+}
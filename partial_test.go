@@ -0,0 +1,51 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestStreamPartial(t *testing.T) {
+	type person struct {
+		Name string   `json:"name"`
+		Tags []string `json:"tags"`
+	}
+	stream := &scriptedStream{events: []*types.StreamEvent{
+		{Type: types.StreamEventStart},
+		textDelta(`{"na`),
+		textDelta(`me": "An`),
+		textDelta(`n", "tags": ["a`),
+		textDelta(`"`),
+		textDelta(`]}`),
+		{Type: types.StreamEventDone},
+	}}
+
+	var got []person
+	for v, err := range StreamPartial[person](stream) {
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, v)
+	}
+
+	want := []person{{}, {Name: "An"}, {Name: "Ann", Tags: []string{"a"}}}
+	if len(got) != len(want) {
+		t.Fatalf("got %d values %+v, want %+v", len(got), got, want)
+	}
+	for i := range want {
+		if got[i].Name != want[i].Name || len(got[i].Tags) != len(want[i].Tags) {
+			t.Errorf("value %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestStreamPartial_InvalidJSON(t *testing.T) {
+	stream := &scriptedStream{events: []*types.StreamEvent{textDelta(`{"n": 1`)}}
+	var err error
+	for _, err = range StreamPartial[map[string]int](stream) {
+	}
+	if err == nil {
+		t.Error("expected an error for a truncated stream")
+	}
+}
@@ -0,0 +1,358 @@
+// Package agent runs tool-calling conversations to completion.
+//
+// An Agent sends a request, executes the Go functions registered for the
+// tools the model calls, feeds the results back, and repeats until the model
+// answers without calling a tool:
+//
+//	a := agent.New(r,
+//		agent.WithTool(weatherTool, getWeather),
+//		agent.WithToolTimeout(10*time.Second),
+//	)
+//	result, err := a.Run(ctx, req)
+//	fmt.Println(result.Response.Text())
+//
+// Runs are bounded by a turn limit, repeated-call detection, and optional
+// token and cost budgets; RunResult.StopReason says which ended the run.
+//
+// When a response contains several tool calls they run concurrently, up to
+// the agent's parallelism limit. Results are returned to the model in the
+// order of the calls.
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// DefaultMaxParallel is the number of tool calls run at once by default.
+const DefaultMaxParallel = 8
+
+// ToolFunc executes a tool call. input is the call's arguments as JSON. The
+// returned string is sent to the model as the tool result; an error is sent
+// as an error result.
+type ToolFunc func(ctx context.Context, input json.RawMessage) (string, error)
+
+// ContentToolFunc is a ToolFunc that returns structured content, such as a
+// screenshot with a caption. The content may hold text and image blocks.
+type ContentToolFunc func(ctx context.Context, input json.RawMessage) ([]types.ContentBlock, error)
+
+// Agent runs requests with a set of Go tools. It is safe for concurrent use.
+type Agent struct {
+	client      types.Completer
+	tools       []types.Tool
+	funcs       map[string]ContentToolFunc
+	maxParallel int
+	toolTimeout time.Duration
+	approve     ApprovalFunc
+	maxTurns    int
+	maxRepeats  int
+	tokenBudget int
+	costBudget  float64
+	streaming   bool
+}
+
+// Option configures an agent.
+type Option func(*Agent)
+
+// WithTool registers a tool and the function that executes it. The tool is
+// added to every request the agent runs.
+func WithTool(tool types.Tool, fn ToolFunc) Option {
+	return WithContentTool(tool, func(ctx context.Context, input json.RawMessage) ([]types.ContentBlock, error) {
+		text, err := fn(ctx, input)
+		if err != nil {
+			return nil, err
+		}
+		return []types.ContentBlock{{Type: types.ContentTypeText, Text: text}}, nil
+	})
+}
+
+// WithContentTool registers a tool whose function returns structured
+// content.
+func WithContentTool(tool types.Tool, fn ContentToolFunc) Option {
+	return func(a *Agent) {
+		if _, ok := a.funcs[tool.Name]; !ok {
+			a.tools = append(a.tools, tool)
+		}
+		a.funcs[tool.Name] = fn
+	}
+}
+
+// WithMaxParallel sets how many tool calls from one response run at once.
+// Values below one are treated as one.
+func WithMaxParallel(n int) Option {
+	return func(a *Agent) {
+		a.maxParallel = max(n, 1)
+	}
+}
+
+// WithSequentialTools runs tool calls one at a time, in order. Use it for
+// tools that share state.
+func WithSequentialTools() Option {
+	return WithMaxParallel(1)
+}
+
+// WithToolTimeout limits how long each tool call may run. A call that times
+// out is reported to the model as an error result. Zero means no limit.
+func WithToolTimeout(d time.Duration) Option {
+	return func(a *Agent) {
+		a.toolTimeout = d
+	}
+}
+
+// Decision is the outcome of a tool approval.
+type Decision struct {
+	// Deny skips the call. The model receives Reason as an error result.
+	Deny   bool
+	Reason string
+
+	// Input, if non-nil, replaces the call's input.
+	Input any
+}
+
+// Approve allows a call as the model made it.
+func Approve() Decision {
+	return Decision{}
+}
+
+// ApproveWithInput allows a call with different input.
+func ApproveWithInput(input any) Decision {
+	return Decision{Input: input}
+}
+
+// Deny rejects a call, telling the model why.
+func Deny(reason string) Decision {
+	return Decision{Deny: true, Reason: reason}
+}
+
+// ApprovalFunc decides whether a tool call may run. An error ends the run.
+type ApprovalFunc func(ctx context.Context, call types.ToolCall) (Decision, error)
+
+// WithToolApproval checks every tool call with fn before it runs, for tools
+// with side effects such as sending email. Calls are checked one at a time,
+// in order, before any of a response's calls run.
+func WithToolApproval(fn ApprovalFunc) Option {
+	return func(a *Agent) {
+		a.approve = fn
+	}
+}
+
+// New creates an agent that sends requests through client.
+func New(client types.Completer, opts ...Option) *Agent {
+	a := &Agent{
+		client:      client,
+		funcs:       make(map[string]ContentToolFunc),
+		maxParallel: DefaultMaxParallel,
+		maxTurns:    DefaultMaxTurns,
+		maxRepeats:  DefaultMaxRepeats,
+	}
+	for _, opt := range opts {
+		opt(a)
+	}
+	return a
+}
+
+// Run sends req and executes the model's tool calls until the model answers
+// without calling a tool or a limit ends the run; the result explains which.
+// req is not modified.
+func (a *Agent) Run(ctx context.Context, req *types.CompletionRequest) (*RunResult, error) {
+	return a.run(ctx, req, func(Event) {})
+}
+
+// run implements Run, reporting progress to emit. emit must be safe for
+// concurrent use.
+func (a *Agent) run(ctx context.Context, req *types.CompletionRequest, emit func(Event)) (*RunResult, error) {
+	conv := *req
+	conv.Messages = append([]types.Message(nil), req.Messages...)
+	conv.Tools = append([]types.Tool(nil), req.Tools...)
+	for _, t := range a.tools {
+		if !hasTool(conv.Tools, t.Name) {
+			conv.Tools = append(conv.Tools, t)
+		}
+	}
+
+	result := &RunResult{}
+	seen := make(map[string]int)
+	var usage types.Usage
+	for {
+		resp, err := a.complete(ctx, &conv, result.Turns+1, emit)
+		if err != nil {
+			return nil, err
+		}
+		result.Turns++
+		result.Cost += turnCost(&conv, resp, resp.Usage)
+		usage.Add(resp.Usage)
+		resp.Usage = usage
+
+		result.Response = resp
+		result.Messages = append(conv.Messages, types.Message{Role: types.RoleAssistant, Content: resp.Content})
+		result.StopReason = a.stop(result, resp, seen)
+		if result.StopReason == "" {
+			results, err := a.execute(ctx, result.Turns, resp.ToolCalls, emit)
+			if err != nil {
+				return nil, err
+			}
+			conv.Messages = append(result.Messages, results...)
+		}
+
+		emit(Event{Type: EventTurnEnd, Turn: result.Turns, Response: resp})
+		if result.StopReason != "" {
+			return result, nil
+		}
+	}
+}
+
+// stop returns why the run should end after a response, or "" to continue.
+// It records the response's tool calls in seen.
+func (a *Agent) stop(result *RunResult, resp *types.CompletionResponse, seen map[string]int) StopReason {
+	switch {
+	case !resp.HasToolCalls():
+		return StopAnswered
+	case !a.handles(resp.ToolCalls):
+		return StopUnhandledTool
+	case result.Turns >= a.maxTurns:
+		return StopMaxTurns
+	case a.tokenBudget > 0 && resp.Usage.InputTokens+resp.Usage.OutputTokens >= a.tokenBudget:
+		return StopTokenBudget
+	case a.costBudget > 0 && result.Cost >= a.costBudget:
+		return StopCostBudget
+	}
+
+	repeated := false
+	for _, tc := range resp.ToolCalls {
+		key := callKey(tc)
+		if a.maxRepeats > 0 && seen[key] >= a.maxRepeats {
+			repeated = true
+		}
+		seen[key]++
+	}
+	if repeated {
+		return StopRepeatedCall
+	}
+	return ""
+}
+
+// handles reports whether the agent has a function for every call.
+func (a *Agent) handles(calls []types.ToolCall) bool {
+	for _, tc := range calls {
+		if a.funcs[tc.Name] == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// execute runs the approved calls with bounded parallelism and returns the
+// result messages in call order.
+func (a *Agent) execute(ctx context.Context, turn int, calls []types.ToolCall, emit func(Event)) ([]types.Message, error) {
+	calls = slices.Clone(calls)
+	results := make([]types.Message, len(calls))
+	approved := make([]bool, len(calls))
+	for i := range calls {
+		if a.approve == nil {
+			approved[i] = true
+			continue
+		}
+		d, err := a.approve(ctx, calls[i])
+		if err != nil {
+			return nil, err
+		}
+		if d.Deny {
+			reason := d.Reason
+			if reason == "" {
+				reason = fmt.Sprintf("tool %s was not approved", calls[i].Name)
+			}
+			results[i] = types.NewToolResultMessage(calls[i].ID, reason, true)
+			emit(Event{Type: EventToolResult, Turn: turn, ToolCall: &calls[i], ToolResult: &results[i].Content[0]})
+			continue
+		}
+		if d.Input != nil {
+			calls[i].Input = d.Input
+		}
+		approved[i] = true
+	}
+
+	sem := make(chan struct{}, a.maxParallel)
+	var wg sync.WaitGroup
+	for i, tc := range calls {
+		if !approved[i] {
+			continue
+		}
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			emit(Event{Type: EventToolCallStart, Turn: turn, ToolCall: &tc})
+			results[i] = a.call(ctx, tc)
+			emit(Event{Type: EventToolResult, Turn: turn, ToolCall: &tc, ToolResult: &results[i].Content[0]})
+		}()
+	}
+	wg.Wait()
+	return results, nil
+}
+
+// call runs one tool call and returns its result message. Errors, timeouts,
+// and panics are reported to the model as error results.
+func (a *Agent) call(ctx context.Context, tc types.ToolCall) (msg types.Message) {
+	if a.toolTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, a.toolTimeout)
+		defer cancel()
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			msg = types.NewToolResultMessage(tc.ID, fmt.Sprintf("tool %s panicked: %v", tc.Name, p), true)
+		}
+	}()
+
+	input, err := toolInput(tc.Input)
+	if err != nil {
+		return types.NewToolResultMessage(tc.ID, fmt.Sprintf("invalid input for tool %s: %v", tc.Name, err), true)
+	}
+	content, err := a.funcs[tc.Name](ctx, input)
+	if err != nil {
+		if stderrors.Is(err, context.DeadlineExceeded) && ctx.Err() != nil {
+			return types.NewToolResultMessage(tc.ID, fmt.Sprintf("tool %s timed out after %s", tc.Name, a.toolTimeout), true)
+		}
+		return types.NewToolResultMessage(tc.ID, err.Error(), true)
+	}
+	if len(content) == 1 && content[0].Type == types.ContentTypeText {
+		return types.NewToolResultMessage(tc.ID, content[0].Text, false)
+	}
+	return types.NewToolResultContentMessage(tc.ID, content, false)
+}
+
+// toolInput encodes a call's input as JSON. Streamed calls carry their input
+// as a JSON string, which is used as is.
+func toolInput(input any) (json.RawMessage, error) {
+	switch v := input.(type) {
+	case nil:
+		return json.RawMessage("{}"), nil
+	case string:
+		if !json.Valid([]byte(v)) {
+			return nil, fmt.Errorf("malformed JSON %q", v)
+		}
+		return json.RawMessage(v), nil
+	case json.RawMessage:
+		return v, nil
+	}
+	return json.Marshal(input)
+}
+
+func hasTool(tools []types.Tool, name string) bool {
+	for _, t := range tools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
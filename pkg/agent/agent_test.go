@@ -0,0 +1,315 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// scriptedCompleter returns its responses in order and records the requests.
+type scriptedCompleter struct {
+	responses []*types.CompletionResponse
+	requests  []*types.CompletionRequest
+}
+
+func (c *scriptedCompleter) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	clone := *req
+	clone.Messages = append([]types.Message(nil), req.Messages...)
+	c.requests = append(c.requests, &clone)
+	resp := c.responses[0]
+	c.responses = c.responses[1:]
+	return resp, nil
+}
+
+func toolCalls(calls ...types.ToolCall) *types.CompletionResponse {
+	resp := &types.CompletionResponse{ToolCalls: calls, Usage: types.Usage{TotalTokens: 10}}
+	for _, tc := range calls {
+		resp.Content = append(resp.Content, types.ContentBlock{Type: types.ContentTypeToolUse, ToolUseID: tc.ID, ToolName: tc.Name, ToolInput: tc.Input})
+	}
+	return resp
+}
+
+func answer(text string) *types.CompletionResponse {
+	return &types.CompletionResponse{
+		Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: text}},
+		Usage:   types.Usage{TotalTokens: 5},
+	}
+}
+
+var echoTool = types.Tool{Name: "echo", Parameters: types.JSONSchema{Type: "object"}}
+
+func TestRun_ParallelTools(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(
+			types.ToolCall{ID: "a", Name: "echo", Input: map[string]any{"text": "first", "delay": 30}},
+			types.ToolCall{ID: "b", Name: "echo", Input: `{"text":"second","delay":0}`},
+			types.ToolCall{ID: "c", Name: "echo", Input: map[string]any{"text": "third", "delay": 10}},
+		),
+		answer("done"),
+	}}
+
+	var running, peak atomic.Int32
+	echo := func(_ context.Context, input json.RawMessage) (string, error) {
+		n := running.Add(1)
+		defer running.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		var args struct {
+			Text  string `json:"text"`
+			Delay int    `json:"delay"`
+		}
+		if err := json.Unmarshal(input, &args); err != nil {
+			return "", err
+		}
+		time.Sleep(time.Duration(args.Delay) * time.Millisecond)
+		return args.Text, nil
+	}
+
+	a := New(client, WithTool(echoTool, echo), WithMaxParallel(2))
+	req := &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")}}
+	result, err := a.Run(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if resp := result.Response; resp.Text() != "done" || resp.Usage.TotalTokens != 15 {
+		t.Errorf("got %q with usage %+v", resp.Text(), resp.Usage)
+	}
+	if result.StopReason != StopAnswered || result.Turns != 2 || len(result.Messages) != 6 {
+		t.Errorf("stopped with %s after %d turns and %d messages", result.StopReason, result.Turns, len(result.Messages))
+	}
+	if p := peak.Load(); p != 2 {
+		t.Errorf("peak concurrency = %d, want 2", p)
+	}
+	if len(req.Messages) != 1 || len(req.Tools) != 0 {
+		t.Error("Run modified the caller's request")
+	}
+
+	sent := client.requests[1]
+	if len(sent.Tools) != 1 || sent.Tools[0].Name != "echo" {
+		t.Errorf("tools = %+v", sent.Tools)
+	}
+	results := sent.Messages[2:]
+	want := []struct{ id, text string }{{"a", "first"}, {"b", "second"}, {"c", "third"}}
+	if len(results) != len(want) {
+		t.Fatalf("got %d tool results, want %d", len(results), len(want))
+	}
+	for i, w := range want {
+		block := results[i].Content[0]
+		if block.ToolResultID != w.id || block.Text != w.text || block.IsError {
+			t.Errorf("result %d = %+v, want %s: %s", i, block, w.id, w.text)
+		}
+	}
+}
+
+func TestRun_SequentialTools(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(
+			types.ToolCall{ID: "a", Name: "append", Input: map[string]any{"v": "x"}},
+			types.ToolCall{ID: "b", Name: "append", Input: map[string]any{"v": "y"}},
+		),
+		answer("done"),
+	}}
+
+	var mu sync.Mutex
+	var order []string
+	appendTool := types.Tool{Name: "append", Parameters: types.JSONSchema{Type: "object"}}
+	fn := func(_ context.Context, input json.RawMessage) (string, error) {
+		var args struct{ V string }
+		json.Unmarshal(input, &args)
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, args.V)
+		return "ok", nil
+	}
+
+	a := New(client, WithTool(appendTool, fn), WithSequentialTools())
+	if _, err := a.Run(context.Background(), &types.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	if len(order) != 2 || order[0] != "x" || order[1] != "y" {
+		t.Errorf("order = %v", order)
+	}
+}
+
+func TestRun_ToolTimeout(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(types.ToolCall{ID: "a", Name: "slow"}),
+		answer("done"),
+	}}
+	slow := func(ctx context.Context, _ json.RawMessage) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}
+
+	a := New(client, WithTool(types.Tool{Name: "slow"}, slow), WithToolTimeout(10*time.Millisecond))
+	if _, err := a.Run(context.Background(), &types.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	block := client.requests[1].Messages[1].Content[0]
+	if !block.IsError || block.Text != "tool slow timed out after 10ms" {
+		t.Errorf("result = %+v", block)
+	}
+}
+
+func TestRun_UnknownTool(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(types.ToolCall{ID: "a", Name: "client_side"}),
+	}}
+
+	a := New(client, WithTool(echoTool, func(context.Context, json.RawMessage) (string, error) { return "", nil }))
+	result, err := a.Run(context.Background(), &types.CompletionRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.StopReason != StopUnhandledTool {
+		t.Errorf("stop reason = %s", result.StopReason)
+	}
+	if calls := result.Response.ToolCalls; len(calls) != 1 || calls[0].Name != "client_side" {
+		t.Errorf("expected the unhandled call to be returned, got %+v", calls)
+	}
+}
+
+func TestRun_ToolApproval(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(
+			types.ToolCall{ID: "a", Name: "echo", Input: map[string]any{"text": "send to boss"}},
+			types.ToolCall{ID: "b", Name: "echo", Input: map[string]any{"text": "send to all"}},
+			types.ToolCall{ID: "c", Name: "echo", Input: map[string]any{"text": "draft"}},
+		),
+		answer("done"),
+	}}
+	echo := func(_ context.Context, input json.RawMessage) (string, error) {
+		var args struct{ Text string }
+		err := json.Unmarshal(input, &args)
+		return args.Text, err
+	}
+
+	var checked []string
+	approval := func(_ context.Context, tc types.ToolCall) (Decision, error) {
+		checked = append(checked, tc.ID)
+		switch tc.ID {
+		case "a":
+			return ApproveWithInput(map[string]any{"text": "send to boss (cc me)"}), nil
+		case "b":
+			return Deny("sending to everyone is not allowed"), nil
+		}
+		return Approve(), nil
+	}
+
+	a := New(client, WithTool(echoTool, echo), WithToolApproval(approval))
+	if _, err := a.Run(context.Background(), &types.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(checked) != 3 || checked[0] != "a" || checked[1] != "b" || checked[2] != "c" {
+		t.Errorf("checked = %v", checked)
+	}
+	want := []struct {
+		text    string
+		isError bool
+	}{
+		{"send to boss (cc me)", false},
+		{"sending to everyone is not allowed", true},
+		{"draft", false},
+	}
+	for i, w := range want {
+		block := client.requests[1].Messages[1+i].Content[0]
+		if block.Text != w.text || block.IsError != w.isError {
+			t.Errorf("result %d = %+v, want %q (error %v)", i, block, w.text, w.isError)
+		}
+	}
+}
+
+func TestRun_ToolApprovalError(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(types.ToolCall{ID: "a", Name: "echo"}),
+	}}
+	ran := false
+	a := New(client,
+		WithTool(echoTool, func(context.Context, json.RawMessage) (string, error) { ran = true; return "", nil }),
+		WithToolApproval(func(context.Context, types.ToolCall) (Decision, error) {
+			return Decision{}, context.Canceled
+		}),
+	)
+	if _, err := a.Run(context.Background(), &types.CompletionRequest{}); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if ran {
+		t.Error("tool ran without approval")
+	}
+}
+
+func TestRun_ContentTool(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{
+		toolCalls(types.ToolCall{ID: "a", Name: "screenshot"}),
+		answer("done"),
+	}}
+	screenshot := func(context.Context, json.RawMessage) ([]types.ContentBlock, error) {
+		return []types.ContentBlock{
+			{Type: types.ContentTypeText, Text: "home page"},
+			{Type: types.ContentTypeImage, ImageBase64: "iVBORw0KGgo=", MediaType: "image/png"},
+		}, nil
+	}
+
+	a := New(client, WithContentTool(types.Tool{Name: "screenshot"}, screenshot))
+	if _, err := a.Run(context.Background(), &types.CompletionRequest{}); err != nil {
+		t.Fatal(err)
+	}
+	block := client.requests[1].Messages[1].Content[0]
+	if block.Text != "home page" || len(block.ToolResultContent) != 2 || block.ToolResultContent[1].Type != types.ContentTypeImage {
+		t.Errorf("result = %+v", block)
+	}
+}
+
+func TestRun_Limits(t *testing.T) {
+	loop := func(n int, input func(i int) any) *scriptedCompleter {
+		c := &scriptedCompleter{}
+		for i := range n {
+			resp := toolCalls(types.ToolCall{ID: fmt.Sprint(i), Name: "echo", Input: input(i)})
+			resp.Provider, resp.Model = types.ProviderOpenAI, "gpt-4o"
+			resp.Usage = types.Usage{InputTokens: 1000, OutputTokens: 100, TotalTokens: 1100}
+			c.responses = append(c.responses, resp)
+		}
+		return c
+	}
+	same := func(int) any { return map[string]any{"q": "x"} }
+	distinct := func(i int) any { return map[string]any{"q": i} }
+	echo := WithTool(echoTool, func(context.Context, json.RawMessage) (string, error) { return "", nil })
+
+	tests := []struct {
+		name   string
+		client *scriptedCompleter
+		opts   []Option
+		reason StopReason
+		turns  int
+	}{
+		{"max turns", loop(10, distinct), []Option{WithMaxTurns(3)}, StopMaxTurns, 3},
+		{"repeated call", loop(10, same), nil, StopRepeatedCall, DefaultMaxRepeats + 1},
+		{"repeats disabled", loop(10, same), []Option{WithMaxRepeats(0), WithMaxTurns(5)}, StopMaxTurns, 5},
+		{"token budget", loop(10, distinct), []Option{WithTokenBudget(3000)}, StopTokenBudget, 3},
+		{"cost budget", loop(10, distinct), []Option{WithCostBudget(0.005)}, StopCostBudget, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := New(tt.client, append(tt.opts, echo)...)
+			result, err := a.Run(context.Background(), &types.CompletionRequest{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.StopReason != tt.reason || result.Turns != tt.turns {
+				t.Errorf("stopped with %s after %d turns, want %s after %d", result.StopReason, result.Turns, tt.reason, tt.turns)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package agent
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// EventType identifies an agent run event.
+type EventType string
+
+const (
+	EventModelDelta    EventType = "model_delta"     // Text from the model
+	EventToolCallStart EventType = "tool_call_start" // A tool call started running
+	EventToolResult    EventType = "tool_result"     // A tool call finished or was denied
+	EventTurnEnd       EventType = "turn_end"        // A model turn and its tool calls finished
+	EventDone          EventType = "done"            // The run finished
+	EventError         EventType = "error"           // The run failed
+)
+
+// Event reports the progress of a run.
+type Event struct {
+	// Type of this event
+	Type EventType
+
+	// Turn is the model turn the event belongs to, starting at 1.
+	Turn int
+
+	// Text is the model's text (for model_delta events).
+	Text string
+
+	// ToolCall is the call (for tool_call_start and tool_result events).
+	ToolCall *types.ToolCall
+
+	// ToolResult is the call's result block (for tool_result events).
+	ToolResult *types.ContentBlock
+
+	// Response is the turn's model response (for turn_end events).
+	Response *types.CompletionResponse
+
+	// Result is the outcome of the run (for done events).
+	Result *RunResult
+
+	// Err is the failure (for error events).
+	Err error
+}
+
+// RunEvents runs req like Run, reporting progress on the returned channel
+// so a UI can render it live. The last event is EventDone or EventError,
+// after which the channel is closed. Tool events for calls that run in
+// parallel arrive in the order they happen.
+//
+// The caller must drain the channel or cancel ctx; a run whose events are
+// not received blocks until ctx is done.
+func (a *Agent) RunEvents(ctx context.Context, req *types.CompletionRequest) <-chan Event {
+	events := make(chan Event, 16)
+	emit := func(e Event) {
+		select {
+		case events <- e:
+		case <-ctx.Done():
+		}
+	}
+
+	go func() {
+		defer close(events)
+		result, err := a.run(ctx, req, emit)
+		if err != nil {
+			emit(Event{Type: EventError, Err: err})
+			return
+		}
+		emit(Event{Type: EventDone, Turn: result.Turns, Result: result})
+	}()
+	return events
+}
@@ -0,0 +1,175 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestRunEvents(t *testing.T) {
+	first := toolCalls(types.ToolCall{ID: "a", Name: "echo", Input: map[string]any{"text": "hi"}})
+	first.Content = append([]types.ContentBlock{{Type: types.ContentTypeText, Text: "Let me check."}}, first.Content...)
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{first, answer("done")}}
+	echo := func(_ context.Context, input json.RawMessage) (string, error) {
+		var args struct{ Text string }
+		err := json.Unmarshal(input, &args)
+		return args.Text, err
+	}
+
+	a := New(client, WithTool(echoTool, echo))
+	var got []EventType
+	var last Event
+	for e := range a.RunEvents(context.Background(), &types.CompletionRequest{}) {
+		got = append(got, e.Type)
+		last = e
+		switch e.Type {
+		case EventModelDelta:
+			if e.Turn == 1 && e.Text != "Let me check." {
+				t.Errorf("turn 1 text = %q", e.Text)
+			}
+		case EventToolResult:
+			if e.ToolCall.ID != "a" || e.ToolResult.Text != "hi" {
+				t.Errorf("tool result = %+v for %+v", e.ToolResult, e.ToolCall)
+			}
+		}
+	}
+
+	want := []EventType{
+		EventModelDelta, EventToolCallStart, EventToolResult, EventTurnEnd,
+		EventModelDelta, EventTurnEnd, EventDone,
+	}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("events = %v, want %v", got, want)
+		}
+	}
+	if last.Result == nil || last.Result.StopReason != StopAnswered || last.Result.Response.Text() != "done" {
+		t.Errorf("done event = %+v", last)
+	}
+}
+
+type failingCompleter struct{ err error }
+
+func (c failingCompleter) Complete(context.Context, *types.CompletionRequest) (*types.CompletionResponse, error) {
+	return nil, c.err
+}
+
+func TestRunEvents_Error(t *testing.T) {
+	want := errors.New("provider down")
+	var events []Event
+	for e := range New(failingCompleter{want}).RunEvents(context.Background(), &types.CompletionRequest{}) {
+		events = append(events, e)
+	}
+	if len(events) != 1 || events[0].Type != EventError || events[0].Err != want {
+		t.Errorf("events = %+v", events)
+	}
+}
+
+// scriptedStreamer streams its responses in order, as text deltas followed
+// by the tool calls.
+type scriptedStreamer struct {
+	scriptedCompleter
+}
+
+func (c *scriptedStreamer) Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	resp, err := c.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var events []*types.StreamEvent
+	for _, block := range resp.Content {
+		if block.Type == types.ContentTypeText {
+			for _, word := range strings.SplitAfter(block.Text, " ") {
+				events = append(events, &types.StreamEvent{Type: types.StreamEventContentDelta, Delta: &types.ContentBlock{Type: types.ContentTypeText, Text: word}})
+			}
+		}
+	}
+	for i, tc := range resp.ToolCalls {
+		events = append(events,
+			&types.StreamEvent{Type: types.StreamEventToolCallStart, ToolCall: &types.ToolCall{ID: tc.ID, Name: tc.Name}, Index: i},
+			&types.StreamEvent{Type: types.StreamEventToolCallEnd, ToolCall: &tc, Index: i},
+		)
+	}
+	return &sliceStream{events: events, resp: resp}, nil
+}
+
+type sliceStream struct {
+	events []*types.StreamEvent
+	resp   *types.CompletionResponse
+}
+
+func (s *sliceStream) Next() (*types.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return nil, nil
+	}
+	event := s.events[0]
+	s.events = s.events[1:]
+	return event, nil
+}
+
+func (s *sliceStream) Close() error { return nil }
+
+func (s *sliceStream) Response() *types.CompletionResponse {
+	if len(s.events) > 0 {
+		return nil
+	}
+	return s.resp
+}
+
+func TestRunEvents_Streaming(t *testing.T) {
+	first := toolCalls(types.ToolCall{ID: "a", Name: "echo", Input: map[string]any{"text": "hi"}})
+	first.Content = append([]types.ContentBlock{{Type: types.ContentTypeText, Text: "Let me check."}}, first.Content...)
+	client := &scriptedStreamer{scriptedCompleter{responses: []*types.CompletionResponse{first, answer("It says hi.")}}}
+	echo := func(_ context.Context, input json.RawMessage) (string, error) {
+		var args struct{ Text string }
+		err := json.Unmarshal(input, &args)
+		return args.Text, err
+	}
+
+	a := New(client, WithTool(echoTool, echo), WithStreaming())
+	var deltas []string
+	var got []EventType
+	var last Event
+	for e := range a.RunEvents(context.Background(), &types.CompletionRequest{}) {
+		got = append(got, e.Type)
+		last = e
+		if e.Type == EventModelDelta {
+			deltas = append(deltas, fmt.Sprintf("%d:%s", e.Turn, e.Text))
+		}
+	}
+
+	want := []EventType{
+		EventModelDelta, EventModelDelta, EventModelDelta, EventToolCallStart, EventToolResult, EventTurnEnd,
+		EventModelDelta, EventModelDelta, EventModelDelta, EventTurnEnd, EventDone,
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	wantDeltas := []string{"1:Let ", "1:me ", "1:check.", "2:It ", "2:says ", "2:hi."}
+	if !slices.Equal(deltas, wantDeltas) {
+		t.Errorf("deltas = %q, want %q", deltas, wantDeltas)
+	}
+	if last.Result == nil || last.Result.Turns != 2 || last.Result.Response.Text() != "It says hi." {
+		t.Fatalf("done event = %+v", last)
+	}
+	if len(client.requests) != 2 || len(client.requests[1].Messages) != 2 {
+		t.Errorf("second request = %+v, want the tool call and its result", client.requests[1])
+	}
+}
+
+func TestRun_StreamingWithoutStreamer(t *testing.T) {
+	client := &scriptedCompleter{responses: []*types.CompletionResponse{answer("done")}}
+	_, err := New(client, WithStreaming()).Run(context.Background(), &types.CompletionRequest{})
+	if !errors.Is(err, errNoStreamer) {
+		t.Errorf("err = %v, want errNoStreamer", err)
+	}
+}
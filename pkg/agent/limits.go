@@ -0,0 +1,107 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Default run limits.
+const (
+	DefaultMaxTurns   = 10
+	DefaultMaxRepeats = 3
+)
+
+// StopReason explains why a run ended.
+type StopReason string
+
+const (
+	StopAnswered      StopReason = "answered"       // The model answered without calling a tool
+	StopUnhandledTool StopReason = "unhandled_tool" // The model called a tool the agent has no function for
+	StopMaxTurns      StopReason = "max_turns"      // The run reached its turn limit
+	StopRepeatedCall  StopReason = "repeated_call"  // The model kept making the same tool call
+	StopTokenBudget   StopReason = "token_budget"   // The run used up its token budget
+	StopCostBudget    StopReason = "cost_budget"    // The run used up its cost budget
+)
+
+// RunResult is the outcome of a run.
+type RunResult struct {
+	// Response is the last model response. Usage covers every turn.
+	Response *types.CompletionResponse
+
+	// Messages is the conversation, from the request's messages through the
+	// last response, ready to continue.
+	Messages []types.Message
+
+	// Turns is the number of model turns.
+	Turns int
+
+	// Cost is the estimated list price in USD of all turns, from the models
+	// catalog. Turns on uncataloged models are free.
+	Cost float64
+
+	// StopReason explains why the run ended. Tool calls in the last response
+	// were not executed unless the reason is StopAnswered.
+	StopReason StopReason
+}
+
+// WithMaxTurns limits the model turns in a run. The default is
+// DefaultMaxTurns.
+func WithMaxTurns(n int) Option {
+	return func(a *Agent) {
+		a.maxTurns = max(n, 1)
+	}
+}
+
+// WithMaxRepeats ends a run when the model makes a tool call identical to
+// one it has already made n times, which usually means it is stuck in a
+// loop. The default is DefaultMaxRepeats; zero disables the check.
+func WithMaxRepeats(n int) Option {
+	return func(a *Agent) {
+		a.maxRepeats = max(n, 0)
+	}
+}
+
+// WithTokenBudget ends a run once its turns have used n tokens in total.
+// Zero means no limit.
+func WithTokenBudget(n int) Option {
+	return func(a *Agent) {
+		a.tokenBudget = n
+	}
+}
+
+// WithCostBudget ends a run once its turns have cost usd in total, as billed
+// by the provider or estimated from the models catalog. Zero means no limit.
+func WithCostBudget(usd float64) Option {
+	return func(a *Agent) {
+		a.costBudget = usd
+	}
+}
+
+// turnCost returns the cost of a response, falling back to the request's
+// provider when the response does not name one.
+func turnCost(req *types.CompletionRequest, resp *types.CompletionResponse, usage types.Usage) float64 {
+	provider, model := resp.Provider, resp.Model
+	if provider == "" {
+		provider = req.Provider
+	}
+	if model == "" {
+		model = req.Model
+	}
+	return models.Cost(provider, model, usage)
+}
+
+// callKey identifies a tool call by name and input, for repeat detection.
+func callKey(tc types.ToolCall) string {
+	input, err := toolInput(tc.Input)
+	if err != nil {
+		return tc.Name
+	}
+	// Round-trip to normalize key order and whitespace.
+	var v any
+	if json.Unmarshal(input, &v) == nil {
+		input, _ = json.Marshal(v)
+	}
+	return tc.Name + "\x00" + string(input)
+}
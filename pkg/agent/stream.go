@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Streamer streams completions. *router.Router implements it.
+type Streamer interface {
+	Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error)
+}
+
+// errNoStreamer is returned by streaming runs on a client that cannot
+// stream.
+var errNoStreamer = stderrors.New("agent: streaming requires a client that implements Streamer")
+
+// WithStreaming runs each model turn with Stream instead of Complete. With
+// RunEvents the model's text arrives as EventModelDelta events while it is
+// generated, rather than once per turn. Tool calls are buffered until the
+// model finishes them, then approved and run as usual, and the conversation
+// continues with another streamed turn. The client must implement Streamer.
+func WithStreaming() Option {
+	return func(a *Agent) {
+		a.streaming = true
+	}
+}
+
+// complete runs one model turn, streaming it if the agent streams.
+func (a *Agent) complete(ctx context.Context, req *types.CompletionRequest, turn int, emit func(Event)) (*types.CompletionResponse, error) {
+	if !a.streaming {
+		resp, err := a.client.Complete(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if text := resp.Text(); text != "" {
+			emit(Event{Type: EventModelDelta, Turn: turn, Text: text})
+		}
+		return resp, nil
+	}
+
+	streamer, ok := a.client.(Streamer)
+	if !ok {
+		return nil, errNoStreamer
+	}
+	stream, err := streamer.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventContentDelta:
+			if event.Delta != nil && event.Delta.Text != "" {
+				emit(Event{Type: EventModelDelta, Turn: turn, Text: event.Delta.Text})
+			}
+		case types.StreamEventError:
+			return nil, event.Error
+		}
+	}
+
+	resp := stream.Response()
+	if resp == nil {
+		return nil, stderrors.New("agent: stream ended without a response")
+	}
+	return resp, nil
+}
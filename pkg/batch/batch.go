@@ -3,6 +3,10 @@ package batch
 
 import (
 	"context"
+	"fmt"
+	"iter"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/Chloe199719/agent-router/pkg/errors"
@@ -103,27 +107,97 @@ type ListOptions struct {
 }
 
 // Manager provides a unified interface for batch processing across providers.
+// It is safe for concurrent use.
 type Manager struct {
-	providers map[types.Provider]provider.BatchProvider
+	mu         sync.RWMutex
+	providers  map[types.Provider]provider.BatchProvider
+	completers map[types.Provider]provider.Provider
+	locals     map[string]*localJob
+	localSeq   atomic.Uint64
+
+	// submitted remembers the requests of batches created through this
+	// manager so failed items can be resubmitted with RetryFailed.
+	submitted map[string][]Request
+
+	// store optionally persists submitted batches across restarts.
+	store Store
+
+	// stopped is set and stopping closed by Shutdown; active counts the
+	// calls and local batches it waits for.
+	stopped  bool
+	stopping chan struct{}
+	active   sync.WaitGroup
 }
 
 // NewManager creates a new batch manager.
-func NewManager() *Manager {
-	return &Manager{
-		providers: make(map[types.Provider]provider.BatchProvider),
+func NewManager(opts ...ManagerOption) *Manager {
+	m := &Manager{
+		providers:  make(map[types.Provider]provider.BatchProvider),
+		completers: make(map[types.Provider]provider.Provider),
+		locals:     make(map[string]*localJob),
+		submitted:  make(map[string][]Request),
+		stopping:   make(chan struct{}),
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
 }
 
-// RegisterProvider registers a batch-capable provider.
+// RegisterProvider registers a batch-capable provider, replacing any
+// provider previously registered under the same name.
 func (m *Manager) RegisterProvider(p provider.BatchProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.providers[p.Name()] = p
+	m.completers[p.Name()] = p
+}
+
+// RegisterLocalProvider registers a provider without a native batch API so
+// it can be used with CreateLocal.
+func (m *Manager) RegisterLocalProvider(p provider.Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.completers[p.Name()] = p
+}
+
+// UnregisterProvider removes a provider from the manager.
+func (m *Manager) UnregisterProvider(name types.Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.providers, name)
+	delete(m.completers, name)
+}
+
+// getProvider returns the batch provider registered under the given name.
+func (m *Manager) getProvider(name types.Provider) (provider.BatchProvider, error) {
+	m.mu.RLock()
+	p, ok := m.providers[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, errors.ErrProviderUnavailable(name, "provider not registered or does not support batch")
+	}
+	return p, nil
 }
 
 // Create creates a new batch job.
+//
+// With a Store configured, the batch is persisted after submission; if that
+// fails, the submitted job is returned together with the error.
 func (m *Manager) Create(ctx context.Context, providerName types.Provider, requests []Request) (*Job, error) {
-	p, ok := m.providers[providerName]
-	if !ok {
-		return nil, errors.ErrProviderUnavailable(providerName, "provider not registered or does not support batch")
+	return m.create(ctx, providerName, requests, "")
+}
+
+// create submits a batch, recording which batch it retries (if any).
+func (m *Manager) create(ctx context.Context, providerName types.Provider, requests []Request, retryOf string) (*Job, error) {
+	if err := m.begin(providerName); err != nil {
+		return nil, err
+	}
+	defer m.active.Done()
+
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
 	}
 
 	// Convert to provider batch requests
@@ -140,14 +214,46 @@ func (m *Manager) Create(ctx context.Context, providerName types.Provider, reque
 		return nil, err
 	}
 
-	return convertJob(job), nil
+	m.remember(job.ID, requests)
+	result := convertJob(job)
+	if err := m.saveRecord(ctx, result, requests, retryOf); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// remember records the requests submitted for a batch.
+func (m *Manager) remember(batchID string, requests []Request) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.submitted[batchID] = requests
+}
+
+// Forget drops what the manager keeps in memory about a finished batch: the
+// requests remembered for RetryFailed and, for a local batch, its results.
+// Call it once a batch's results have been fetched, so long-running
+// managers do not accumulate every batch. A record in the Store is kept;
+// remove it with the Store's Delete.
+func (m *Manager) Forget(batchID string) error {
+	if lj := m.localJobByID(batchID); lj != nil && !lj.snapshot().Status.IsDone() {
+		return errors.ErrInvalidRequest(fmt.Sprintf("batch %s is still running", batchID))
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.locals, batchID)
+	delete(m.submitted, batchID)
+	return nil
 }
 
 // Get retrieves the status of a batch job.
 func (m *Manager) Get(ctx context.Context, providerName types.Provider, batchID string) (*Job, error) {
-	p, ok := m.providers[providerName]
-	if !ok {
-		return nil, errors.ErrProviderUnavailable(providerName, "provider not registered or does not support batch")
+	if lj := m.localJobByID(batchID); lj != nil {
+		return lj.snapshot(), nil
+	}
+
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
 	}
 
 	job, err := p.GetBatch(ctx, batchID)
@@ -155,14 +261,22 @@ func (m *Manager) Get(ctx context.Context, providerName types.Provider, batchID
 		return nil, err
 	}
 
-	return convertJob(job), nil
+	result := convertJob(job)
+	if err := m.updateRecordStatus(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
 }
 
 // GetResults retrieves the results of a completed batch job.
 func (m *Manager) GetResults(ctx context.Context, providerName types.Provider, batchID string) ([]Result, error) {
-	p, ok := m.providers[providerName]
-	if !ok {
-		return nil, errors.ErrProviderUnavailable(providerName, "provider not registered or does not support batch")
+	if lj := m.localJobByID(batchID); lj != nil {
+		return lj.localResults()
+	}
+
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
 	}
 
 	results, err := p.GetBatchResults(ctx, batchID)
@@ -173,46 +287,124 @@ func (m *Manager) GetResults(ctx context.Context, providerName types.Provider, b
 	return convertResults(results), nil
 }
 
+// GetResultsIter streams the results of a completed batch job, decoding the
+// provider's output as it is downloaded so large batches need not fit in
+// memory. Providers without streaming support fall back to GetResults.
+func (m *Manager) GetResultsIter(ctx context.Context, providerName types.Provider, batchID string) (iter.Seq2[Result, error], error) {
+	if lj := m.localJobByID(batchID); lj != nil {
+		results, err := lj.localResults()
+		if err != nil {
+			return nil, err
+		}
+		return resultsSeq(results), nil
+	}
+
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	it, ok := p.(provider.BatchResultsIterator)
+	if !ok {
+		results, err := m.GetResults(ctx, providerName, batchID)
+		if err != nil {
+			return nil, err
+		}
+		return resultsSeq(results), nil
+	}
+
+	seq, err := it.GetBatchResultsIter(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(Result, error) bool) {
+		for r, err := range seq {
+			if !yield(convertResult(r), err) {
+				return
+			}
+		}
+	}, nil
+}
+
 // Cancel cancels a batch job.
 func (m *Manager) Cancel(ctx context.Context, providerName types.Provider, batchID string) error {
-	p, ok := m.providers[providerName]
-	if !ok {
-		return errors.ErrProviderUnavailable(providerName, "provider not registered or does not support batch")
+	if lj := m.localJobByID(batchID); lj != nil {
+		lj.cancel()
+		return nil
+	}
+
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return err
 	}
 
 	return p.CancelBatch(ctx, batchID)
 }
 
-// List lists batch jobs for a provider.
+// List lists batch jobs for a provider. Local batches from CreateLocal are not included.
 func (m *Manager) List(ctx context.Context, providerName types.Provider, opts *ListOptions) ([]Job, error) {
-	p, ok := m.providers[providerName]
-	if !ok {
-		return nil, errors.ErrProviderUnavailable(providerName, "provider not registered or does not support batch")
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
 	}
 
-	var listOpts *provider.ListBatchOptions
-	if opts != nil {
-		listOpts = &provider.ListBatchOptions{
-			Limit: opts.Limit,
-			After: opts.After,
-		}
+	jobs, err := p.ListBatches(ctx, opts.toProvider())
+	if err != nil {
+		return nil, err
 	}
 
-	jobs, err := p.ListBatches(ctx, listOpts)
+	return convertJobs(jobs), nil
+}
+
+// ListAll lists every batch job for a provider, following pagination until
+// the provider reports no more pages. opts.Limit, if set, is the page size.
+// Providers that cannot paginate return a single page.
+func (m *Manager) ListAll(ctx context.Context, providerName types.Provider, opts *ListOptions) ([]Job, error) {
+	p, err := m.getProvider(providerName)
 	if err != nil {
 		return nil, err
 	}
 
+	lister, ok := p.(provider.BatchLister)
+	if !ok {
+		return m.List(ctx, providerName, opts)
+	}
+
+	jobs, err := lister.ListAllBatches(ctx, opts.toProvider())
+	if err != nil {
+		return nil, err
+	}
+
+	return convertJobs(jobs), nil
+}
+
+// toProvider converts list options to the provider form.
+func (o *ListOptions) toProvider() *provider.ListBatchOptions {
+	if o == nil {
+		return nil
+	}
+	return &provider.ListBatchOptions{
+		Limit: o.Limit,
+		After: o.After,
+	}
+}
+
+// convertJobs converts provider batch jobs to Jobs.
+func convertJobs(jobs []provider.BatchJob) []Job {
 	result := make([]Job, len(jobs))
 	for i, job := range jobs {
 		result[i] = *convertJob(&job)
 	}
-
-	return result, nil
+	return result
 }
 
 // Wait waits for a batch to complete, polling at the specified interval.
 func (m *Manager) Wait(ctx context.Context, providerName types.Provider, batchID string, pollInterval time.Duration) (*Job, error) {
+	if err := m.begin(providerName); err != nil {
+		return nil, err
+	}
+	defer m.active.Done()
+
 	ticker := time.NewTicker(pollInterval)
 	defer ticker.Stop()
 
@@ -220,6 +412,8 @@ func (m *Manager) Wait(ctx context.Context, providerName types.Provider, batchID
 		select {
 		case <-ctx.Done():
 			return nil, ctx.Err()
+		case <-m.stopping:
+			return nil, errShutdown(providerName)
 		case <-ticker.C:
 			job, err := m.Get(ctx, providerName, batchID)
 			if err != nil {
@@ -266,12 +460,28 @@ func convertJob(j *provider.BatchJob) *Job {
 func convertResults(results []provider.BatchResult) []Result {
 	out := make([]Result, len(results))
 	for i, r := range results {
-		out[i] = Result{
-			CustomID:      r.CustomID,
-			RequestLabels: r.RequestLabels,
-			Response:      r.Response,
-			Error:         r.Error,
-		}
+		out[i] = convertResult(r)
 	}
 	return out
 }
+
+// resultsSeq returns an iterator over results already in memory.
+func resultsSeq(results []Result) iter.Seq2[Result, error] {
+	return func(yield func(Result, error) bool) {
+		for _, r := range results {
+			if !yield(r, nil) {
+				return
+			}
+		}
+	}
+}
+
+// convertResult converts a provider batch result to a Result.
+func convertResult(r provider.BatchResult) Result {
+	return Result{
+		CustomID:      r.CustomID,
+		RequestLabels: r.RequestLabels,
+		Response:      r.Response,
+		Error:         r.Error,
+	}
+}
@@ -0,0 +1,242 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// localIDPrefix marks IDs of batches emulated by CreateLocal.
+const localIDPrefix = "local_batch_"
+
+// LocalOption configures a local batch created with CreateLocal.
+type LocalOption func(*localConfig)
+
+type localConfig struct {
+	maxRetries int
+	backoff    time.Duration
+	onProgress func(Job)
+}
+
+// WithLocalRetries retries requests that fail with a retryable error
+// (rate limits, overloads, server errors, timeouts) up to n times, waiting
+// backoff multiplied by the attempt number between attempts, or the
+// provider's Retry-After if longer.
+func WithLocalRetries(n int, backoff time.Duration) LocalOption {
+	return func(c *localConfig) {
+		c.maxRetries = n
+		c.backoff = backoff
+	}
+}
+
+// WithProgress registers a callback invoked with a snapshot of the job after
+// each request finishes, and once more when the job is done. Calls are
+// serialized, in order, and made from a separate goroutine, so a slow
+// callback does not hold up the requests and may call Get or Wait.
+func WithProgress(fn func(Job)) LocalOption {
+	return func(c *localConfig) {
+		c.onProgress = fn
+	}
+}
+
+// localJob tracks a batch emulated with individual Complete calls.
+type localJob struct {
+	mu          sync.Mutex
+	job         Job
+	results     []Result
+	cancel      context.CancelFunc
+	concurrency int
+
+	// progress queues snapshots for the WithProgress callback. It has room
+	// for one per request and the final one, so sends never block.
+	progress chan Job
+}
+
+// snapshot returns a copy of the job's current state.
+func (l *localJob) snapshot() *Job {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	job := l.job
+	return &job
+}
+
+// CreateLocal emulates a batch job by fanning out Complete calls with at most
+// concurrency requests in flight. It is meant for providers without a native
+// batch API, or when the native queue is too slow; there is no cost discount.
+//
+// The returned job runs in the background until all requests finish or ctx is
+// cancelled. It can be polled, waited on, cancelled, and its results fetched
+// through the usual Get, Wait, Cancel, and GetResults methods.
+func (m *Manager) CreateLocal(ctx context.Context, providerName types.Provider, requests []Request, concurrency int, opts ...LocalOption) (*Job, error) {
+	m.mu.RLock()
+	p, ok := m.completers[providerName]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, errors.ErrProviderUnavailable(providerName, "provider not registered")
+	}
+
+	if len(requests) == 0 {
+		return nil, errors.ErrInvalidRequest("batch must contain at least one request")
+	}
+	seen := make(map[string]bool, len(requests))
+	for _, req := range requests {
+		if seen[req.CustomID] {
+			return nil, errors.ErrInvalidRequest(fmt.Sprintf("duplicate custom ID %q", req.CustomID))
+		}
+		seen[req.CustomID] = true
+	}
+
+	cfg := &localConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	if err := m.begin(providerName); err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	lj := &localJob{
+		job: Job{
+			ID:        fmt.Sprintf("%s%d", localIDPrefix, m.localSeq.Add(1)),
+			Provider:  providerName,
+			Status:    StatusInProgress,
+			CreatedAt: time.Now(),
+			Counts:    Counts{Total: len(requests)},
+			Metadata:  map[string]any{"local": true},
+		},
+		results:     make([]Result, len(requests)),
+		cancel:      cancel,
+		concurrency: concurrency,
+	}
+	if cfg.onProgress != nil {
+		lj.progress = make(chan Job, len(requests)+1)
+	}
+
+	m.mu.Lock()
+	m.locals[lj.job.ID] = lj
+	m.submitted[lj.job.ID] = requests
+	m.mu.Unlock()
+
+	go m.runLocal(ctx, p, lj, requests, concurrency, cfg)
+
+	return lj.snapshot(), nil
+}
+
+// runLocal processes a local batch and marks it done.
+func (m *Manager) runLocal(ctx context.Context, p provider.Provider, lj *localJob, requests []Request, concurrency int, cfg *localConfig) {
+	defer m.active.Done()
+	defer lj.cancel()
+
+	if lj.progress != nil {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for job := range lj.progress {
+				cfg.onProgress(job)
+			}
+		}()
+		defer func() { <-done }()
+	}
+
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for i, req := range requests {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			lj.finish(i, Result{CustomID: req.CustomID, Error: ctx.Err()})
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, req Request) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			resp, err := completeWithRetry(ctx, p, req.Request, cfg)
+			lj.finish(i, Result{CustomID: req.CustomID, Response: resp, Error: err})
+		}(i, req)
+	}
+	wg.Wait()
+
+	lj.mu.Lock()
+	now := time.Now()
+	lj.job.CompletedAt = &now
+	if ctx.Err() != nil && lj.job.Counts.Completed < lj.job.Counts.Total {
+		lj.job.Status = StatusCancelled
+	} else {
+		lj.job.Status = StatusCompleted
+	}
+	if lj.progress != nil {
+		lj.progress <- lj.job
+		close(lj.progress)
+	}
+	lj.mu.Unlock()
+}
+
+// finish records a result and queues a progress snapshot.
+func (l *localJob) finish(i int, result Result) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.results[i] = result
+	if result.Error != nil {
+		l.job.Counts.Failed++
+	} else {
+		l.job.Counts.Completed++
+	}
+
+	if l.progress != nil {
+		l.progress <- l.job
+	}
+}
+
+// completeWithRetry calls Complete, retrying retryable errors per cfg.
+func completeWithRetry(ctx context.Context, p provider.Provider, req *types.CompletionRequest, cfg *localConfig) (*types.CompletionResponse, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := p.Complete(ctx, req)
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= cfg.maxRetries || !errors.IsRetryable(err) {
+			return nil, err
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(max(cfg.backoff*time.Duration(attempt+1), errors.RetryAfter(err))):
+		}
+	}
+}
+
+// localJobByID returns the local job with the given ID, if any.
+func (m *Manager) localJobByID(batchID string) *localJob {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.locals[batchID]
+}
+
+// localResults returns the results of a finished local job.
+func (l *localJob) localResults() ([]Result, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.job.Status.IsDone() {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("batch %s is still %s", l.job.ID, l.job.Status))
+	}
+	results := make([]Result, len(l.results))
+	copy(results, l.results)
+	return results, nil
+}
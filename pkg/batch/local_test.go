@@ -0,0 +1,196 @@
+package batch
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// fakeProvider answers Complete with the request's first message text.
+// Requests whose text is "flaky" fail once with a rate limit; "bad" always fails.
+type fakeProvider struct {
+	inFlight    atomic.Int32
+	maxInFlight atomic.Int32
+
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+func (f *fakeProvider) Name() types.Provider { return types.ProviderOpenAI }
+func (f *fakeProvider) Stream(context.Context, *types.CompletionRequest) (types.StreamReader, error) {
+	return nil, nil
+}
+func (f *fakeProvider) SupportsFeature(types.Feature) bool { return true }
+func (f *fakeProvider) Models() []string                   { return nil }
+
+func (f *fakeProvider) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	n := f.inFlight.Add(1)
+	defer f.inFlight.Add(-1)
+	for {
+		max := f.maxInFlight.Load()
+		if n <= max || f.maxInFlight.CompareAndSwap(max, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	text := req.Messages[0].Content[0].Text
+	f.mu.Lock()
+	f.attempts[text]++
+	attempt := f.attempts[text]
+	f.mu.Unlock()
+
+	switch {
+	case text == "bad":
+		return nil, errors.ErrInvalidRequest("bad request")
+	case text == "flaky" && attempt == 1:
+		return nil, errors.ErrRateLimit(types.ProviderOpenAI, "slow down")
+	}
+	return &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: text}}}, nil
+}
+
+func localRequests(texts ...string) []Request {
+	reqs := make([]Request, len(texts))
+	for i, text := range texts {
+		reqs[i] = Request{
+			CustomID: text + "-id",
+			Request:  &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, text)}},
+		}
+	}
+	return reqs
+}
+
+func TestCreateLocal(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	var progress []Counts
+	var mu sync.Mutex
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI,
+		localRequests("a", "b", "c", "d", "flaky", "bad"), 2,
+		WithLocalRetries(1, time.Millisecond),
+		WithProgress(func(j Job) {
+			mu.Lock()
+			progress = append(progress, j.Counts)
+			mu.Unlock()
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Wait(context.Background(), types.ProviderOpenAI, job.ID, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != StatusCompleted {
+		t.Errorf("expected completed, got %s", done.Status)
+	}
+	if done.Counts != (Counts{Total: 6, Completed: 5, Failed: 1}) {
+		t.Errorf("unexpected counts: %+v", done.Counts)
+	}
+	if got := fake.maxInFlight.Load(); got > 2 {
+		t.Errorf("expected at most 2 concurrent requests, got %d", got)
+	}
+
+	results, err := m.GetResults(context.Background(), types.ProviderOpenAI, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 6 || results[0].CustomID != "a-id" || results[0].Response.Text() != "a" {
+		t.Errorf("expected results in request order, got %+v", results)
+	}
+	if results[4].Error != nil {
+		t.Errorf("expected flaky request to succeed on retry, got %v", results[4].Error)
+	}
+	if results[5].Error == nil {
+		t.Error("expected bad request to fail")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(progress) < 6 {
+		t.Errorf("expected a progress callback per request, got %d", len(progress))
+	}
+}
+
+func TestCreateLocal_ProgressCallsManager(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	var last Job
+	var calls int
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "b", "c"), 3,
+		WithProgress(func(j Job) {
+			// Calls are serialized, so no lock is needed.
+			if _, err := m.Get(context.Background(), types.ProviderOpenAI, j.ID); err != nil {
+				t.Error(err)
+			}
+			calls++
+			last = j
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Wait(context.Background(), types.ProviderOpenAI, job.ID, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 4 || last.Status != StatusCompleted {
+		t.Errorf("%d progress calls ending with %s, want one per request and the final state", calls, last.Status)
+	}
+
+	if err := m.Forget(job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.GetResults(context.Background(), types.ProviderOpenAI, job.ID); err == nil {
+		t.Error("results of a forgotten batch are still kept")
+	}
+	if requests, _ := m.storedRequests(context.Background(), job.ID); requests != nil {
+		t.Error("requests of a forgotten batch are still kept")
+	}
+}
+
+func TestCreateLocal_Cancel(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "b", "c", "d", "e", "f"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Cancel(context.Background(), types.ProviderOpenAI, job.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Wait(context.Background(), types.ProviderOpenAI, job.ID, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != StatusCancelled {
+		t.Errorf("expected cancelled, got %s", done.Status)
+	}
+}
+
+func TestCreateLocal_Validation(t *testing.T) {
+	m := NewManager()
+	if _, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a"), 1); err == nil {
+		t.Error("expected error for unregistered provider")
+	}
+
+	m.RegisterLocalProvider(&fakeProvider{attempts: make(map[string]int)})
+	if _, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "a"), 1); err == nil {
+		t.Error("expected error for duplicate custom IDs")
+	}
+}
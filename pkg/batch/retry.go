@@ -0,0 +1,86 @@
+package batch
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// RetryLink links a batch to the batch that retries its failed requests.
+type RetryLink struct {
+	// OriginalID is the ID of the batch whose failures were retried.
+	OriginalID string `json:"original_id"`
+
+	// Job is the new batch containing only the retried requests.
+	Job *Job `json:"job"`
+
+	// CustomIDs are the requests that were resubmitted.
+	CustomIDs []string `json:"custom_ids"`
+}
+
+// RetryFailed resubmits the failed requests of a finished batch as a new
+// batch. Requests that errored, and requests missing from the results (for
+// example because the batch expired), are retried. Local batches are retried
+// locally with the same concurrency.
+//
+// The original requests must be known to this manager: the batch was created
+// with Create or CreateLocal on it, or is in its Store.
+func (m *Manager) RetryFailed(ctx context.Context, providerName types.Provider, batchID string) (*RetryLink, error) {
+	requests, err := m.storedRequests(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if len(requests) == 0 {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("requests for batch %s are unknown to this manager", batchID))
+	}
+
+	job, err := m.Get(ctx, providerName, batchID)
+	if err != nil {
+		return nil, err
+	}
+	if !job.Status.IsDone() {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("batch %s is still %s", batchID, job.Status))
+	}
+
+	results, err := m.GetResults(ctx, providerName, batchID)
+	if err != nil {
+		return nil, err
+	}
+
+	succeeded := make(map[string]bool, len(results))
+	for _, r := range results {
+		if r.Error == nil && r.Response != nil {
+			succeeded[r.CustomID] = true
+		}
+	}
+
+	var retry []Request
+	var ids []string
+	for _, req := range requests {
+		if !succeeded[req.CustomID] {
+			retry = append(retry, req)
+			ids = append(ids, req.CustomID)
+		}
+	}
+	if len(retry) == 0 {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("batch %s has no failed requests", batchID))
+	}
+
+	var newJob *Job
+	if lj := m.localJobByID(batchID); lj != nil {
+		newJob, err = m.CreateLocal(ctx, providerName, retry, lj.concurrency)
+	} else {
+		newJob, err = m.create(ctx, providerName, retry, batchID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &RetryLink{
+		OriginalID: batchID,
+		Job:        newJob,
+		CustomIDs:  ids,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package batch
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestRetryFailed(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	// Without retries, "flaky" fails on its first attempt.
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "flaky", "b"), 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.Wait(context.Background(), types.ProviderOpenAI, job.ID, 5*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := m.RetryFailed(context.Background(), types.ProviderOpenAI, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.OriginalID != job.ID || len(link.CustomIDs) != 1 || link.CustomIDs[0] != "flaky-id" {
+		t.Errorf("unexpected link: %+v", link)
+	}
+
+	retried, err := m.Wait(context.Background(), types.ProviderOpenAI, link.Job.ID, 5*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retried.Counts != (Counts{Total: 1, Completed: 1}) {
+		t.Errorf("expected retried request to succeed, got %+v", retried.Counts)
+	}
+
+	if _, err := m.RetryFailed(context.Background(), types.ProviderOpenAI, link.Job.ID); err == nil {
+		t.Error("expected error when there is nothing to retry")
+	}
+	if _, err := m.RetryFailed(context.Background(), types.ProviderOpenAI, "unknown"); err == nil {
+		t.Error("expected error for unknown batch")
+	}
+}
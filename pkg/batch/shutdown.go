@@ -0,0 +1,59 @@
+package batch
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Shutdown stops the manager for a graceful shutdown. New batches fail, as
+// do new Wait and Watch calls, and polls in progress stop at their next
+// interval with a provider_unavailable error; the batches
+// themselves keep running at the provider and can be polled again after a
+// restart (see WithStore). Shutdown waits for the calls in progress and the
+// running local batches. If ctx ends first, the local batches are cancelled
+// and ctx's error is returned.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	if !m.stopped {
+		m.stopped = true
+		close(m.stopping)
+	}
+	m.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		m.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		m.mu.RLock()
+		for _, lj := range m.locals {
+			lj.cancel()
+		}
+		m.mu.RUnlock()
+		return ctx.Err()
+	}
+}
+
+// begin registers a call in progress, or fails once the manager is shutting
+// down. The caller must call m.active.Done when it returns.
+func (m *Manager) begin(providerName types.Provider) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.stopped {
+		return errShutdown(providerName)
+	}
+	m.active.Add(1)
+	return nil
+}
+
+// errShutdown is the error of calls made or stopped by Shutdown.
+func errShutdown(providerName types.Provider) error {
+	return errors.ErrProviderUnavailable(providerName, "batch manager is shutting down")
+}
@@ -0,0 +1,253 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Record is the persisted state of a submitted batch.
+type Record struct {
+	// ID is the provider's batch ID.
+	ID string `json:"id"`
+
+	// Provider that is processing the batch.
+	Provider types.Provider `json:"provider"`
+
+	// Status is the last observed status.
+	Status Status `json:"status"`
+
+	// CreatedAt is when the batch was submitted.
+	CreatedAt time.Time `json:"created_at"`
+
+	// UpdatedAt is when the record was last written.
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Requests are the submitted requests, keyed by CustomID on the wire.
+	Requests []Request `json:"requests"`
+
+	// RetryOf is the ID of the batch this one retries, if any.
+	RetryOf string `json:"retry_of,omitempty"`
+}
+
+// Store persists batch records so submitted jobs, their CustomID mappings,
+// and provider associations survive process restarts.
+// Implementations must be safe for concurrent use.
+type Store interface {
+	// Save creates or replaces a record.
+	Save(ctx context.Context, rec *Record) error
+
+	// Get returns the record for a batch, or nil if it is unknown.
+	Get(ctx context.Context, batchID string) (*Record, error)
+
+	// List returns all records, oldest first.
+	List(ctx context.Context) ([]*Record, error)
+
+	// Delete removes a record. Deleting an unknown batch is not an error.
+	Delete(ctx context.Context, batchID string) error
+}
+
+// ManagerOption configures a Manager.
+type ManagerOption func(*Manager)
+
+// WithStore persists submitted batches to s.
+func WithStore(s Store) ManagerOption {
+	return func(m *Manager) {
+		m.store = s
+	}
+}
+
+// Pending returns stored batches that have not reached a terminal state,
+// e.g. to resume watching them after a restart. It returns nil without a store.
+func (m *Manager) Pending(ctx context.Context) ([]*Record, error) {
+	if m.store == nil {
+		return nil, nil
+	}
+
+	records, err := m.store.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := records[:0]
+	for _, rec := range records {
+		if !rec.Status.IsDone() {
+			pending = append(pending, rec)
+		}
+	}
+	return pending, nil
+}
+
+// saveRecord persists a newly submitted batch.
+func (m *Manager) saveRecord(ctx context.Context, job *Job, requests []Request, retryOf string) error {
+	if m.store == nil {
+		return nil
+	}
+	now := time.Now()
+	return m.store.Save(ctx, &Record{
+		ID:        job.ID,
+		Provider:  job.Provider,
+		Status:    job.Status,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Requests:  requests,
+		RetryOf:   retryOf,
+	})
+}
+
+// updateRecordStatus records a status change for a stored batch.
+func (m *Manager) updateRecordStatus(ctx context.Context, job *Job) error {
+	if m.store == nil {
+		return nil
+	}
+	rec, err := m.store.Get(ctx, job.ID)
+	if err != nil || rec == nil || rec.Status == job.Status {
+		return err
+	}
+	rec.Status = job.Status
+	rec.UpdatedAt = time.Now()
+	return m.store.Save(ctx, rec)
+}
+
+// storedRequests returns the requests of a batch from memory or the store.
+func (m *Manager) storedRequests(ctx context.Context, batchID string) ([]Request, error) {
+	m.mu.RLock()
+	requests, ok := m.submitted[batchID]
+	m.mu.RUnlock()
+	if ok || m.store == nil {
+		return requests, nil
+	}
+
+	rec, err := m.store.Get(ctx, batchID)
+	if err != nil || rec == nil {
+		return nil, err
+	}
+	return rec.Requests, nil
+}
+
+// FileStore is a Store that keeps one JSON file per batch in a directory.
+type FileStore struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewFileStore creates a file store in dir, creating the directory if needed.
+func NewFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("batch: failed to create store directory: %w", err)
+	}
+	return &FileStore{dir: dir}, nil
+}
+
+// path returns the file for a batch ID. IDs may contain slashes (Vertex
+// resource names), so they are escaped.
+func (s *FileStore) path(batchID string) string {
+	return filepath.Join(s.dir, url.PathEscape(batchID)+".json")
+}
+
+// Save writes the record atomically.
+func (s *FileStore) Save(ctx context.Context, rec *Record) error {
+	data, err := json.MarshalIndent(rec, "", "  ")
+	if err != nil {
+		return fmt.Errorf("batch: failed to encode record: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp, err := os.CreateTemp(s.dir, ".record-*")
+	if err != nil {
+		return fmt.Errorf("batch: failed to write record: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("batch: failed to write record: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("batch: failed to write record: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), s.path(rec.ID)); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("batch: failed to write record: %w", err)
+	}
+	return nil
+}
+
+// Get reads the record for a batch.
+func (s *FileStore) Get(ctx context.Context, batchID string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read(s.path(batchID))
+}
+
+// List reads all records, oldest first.
+func (s *FileStore) List(ctx context.Context) ([]*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("batch: failed to list records: %w", err)
+	}
+
+	var records []*Record
+	for _, e := range entries {
+		if e.IsDir() || strings.HasPrefix(e.Name(), ".") || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+		rec, err := s.read(filepath.Join(s.dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if rec != nil {
+			records = append(records, rec)
+		}
+	}
+
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, nil
+}
+
+// Delete removes the record for a batch.
+func (s *FileStore) Delete(ctx context.Context, batchID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.path(batchID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("batch: failed to delete record: %w", err)
+	}
+	return nil
+}
+
+// read decodes a record file; a missing file yields nil.
+func (s *FileStore) read(path string) (*Record, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("batch: failed to read record: %w", err)
+	}
+
+	var rec Record
+	if err := json.Unmarshal(data, &rec); err != nil {
+		return nil, fmt.Errorf("batch: failed to decode record %s: %w", filepath.Base(path), err)
+	}
+	return &rec, nil
+}
+
+// Ensure FileStore implements Store
+var _ Store = (*FileStore)(nil)
@@ -0,0 +1,129 @@
+package batch
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// fakeBatchProvider completes every batch immediately; requests with the
+// custom ID "fail" error.
+type fakeBatchProvider struct {
+	fakeProvider
+	batches map[string][]provider.BatchRequest
+}
+
+func (f *fakeBatchProvider) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.BatchJob, error) {
+	id := "batch_" + string(rune('a'+len(f.batches)))
+	f.batches[id] = requests
+	return &provider.BatchJob{ID: id, Provider: types.ProviderOpenAI, Status: provider.BatchStatusInProgress}, nil
+}
+
+func (f *fakeBatchProvider) GetBatch(ctx context.Context, batchID string) (*provider.BatchJob, error) {
+	return &provider.BatchJob{ID: batchID, Provider: types.ProviderOpenAI, Status: provider.BatchStatusCompleted}, nil
+}
+
+func (f *fakeBatchProvider) GetBatchResults(ctx context.Context, batchID string) ([]provider.BatchResult, error) {
+	var results []provider.BatchResult
+	for _, req := range f.batches[batchID] {
+		if req.CustomID == "fail" {
+			results = append(results, provider.BatchResult{CustomID: req.CustomID, Error: errors.ErrServerError(types.ProviderOpenAI, "boom")})
+			continue
+		}
+		results = append(results, provider.BatchResult{CustomID: req.CustomID, Response: &types.CompletionResponse{}})
+	}
+	return results, nil
+}
+
+func (f *fakeBatchProvider) CancelBatch(ctx context.Context, batchID string) error { return nil }
+func (f *fakeBatchProvider) ListBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
+	return nil, nil
+}
+
+func TestFileStore_SurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	fake := &fakeBatchProvider{batches: make(map[string][]provider.BatchRequest)}
+
+	store, err := NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewManager(WithStore(store))
+	m.RegisterProvider(fake)
+
+	job, err := m.Create(ctx, types.ProviderOpenAI, []Request{
+		{CustomID: "ok", Request: &types.CompletionRequest{Model: "gpt-4o-mini"}},
+		{CustomID: "fail", Request: &types.CompletionRequest{Model: "gpt-4o-mini"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A new manager over the same directory sees the in-flight batch.
+	store, err = NewFileStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m = NewManager(WithStore(store))
+	m.RegisterProvider(fake)
+
+	pending, err := m.Pending(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].ID != job.ID || pending[0].Provider != types.ProviderOpenAI {
+		t.Fatalf("expected the submitted batch to be pending, got %+v", pending)
+	}
+	if len(pending[0].Requests) != 2 || pending[0].Requests[1].Request.Model != "gpt-4o-mini" {
+		t.Errorf("expected stored requests, got %+v", pending[0].Requests)
+	}
+
+	// Polling records the terminal status.
+	if _, err := m.Get(ctx, types.ProviderOpenAI, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	if pending, _ := m.Pending(ctx); len(pending) != 0 {
+		t.Errorf("expected no pending batches after completion, got %d", len(pending))
+	}
+
+	// Retrying after the restart uses the stored requests.
+	link, err := m.RetryFailed(ctx, types.ProviderOpenAI, job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(link.CustomIDs) != 1 || link.CustomIDs[0] != "fail" {
+		t.Errorf("unexpected retry link: %+v", link)
+	}
+	rec, err := store.Get(ctx, link.Job.ID)
+	if err != nil || rec == nil || rec.RetryOf != job.ID {
+		t.Errorf("expected retry batch linked to original, got %+v, %v", rec, err)
+	}
+}
+
+func TestFileStore_EscapesIDs(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	id := "projects/p/locations/us-central1/batchPredictionJobs/123"
+	if err := store.Save(ctx, &Record{ID: id, Provider: types.ProviderVertex}); err != nil {
+		t.Fatal(err)
+	}
+	rec, err := store.Get(ctx, id)
+	if err != nil || rec == nil || rec.ID != id {
+		t.Fatalf("expected record, got %+v, %v", rec, err)
+	}
+
+	if err := store.Delete(ctx, id); err != nil {
+		t.Fatal(err)
+	}
+	if rec, _ := store.Get(ctx, id); rec != nil {
+		t.Error("expected record to be deleted")
+	}
+}
@@ -0,0 +1,125 @@
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"iter"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// inputReader returns the named provider if it accepts batch input from a
+// reader.
+func (m *Manager) inputReader(providerName types.Provider) (provider.BatchInputReader, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+	r, ok := p.(provider.BatchInputReader)
+	if !ok {
+		return nil, errors.NewError(errors.ErrCodeUnsupportedFeature, fmt.Sprintf("provider %s does not support batch input from a reader", providerName)).WithProvider(providerName)
+	}
+	return r, nil
+}
+
+// WriteInput writes requests to w in the provider's JSONL batch input
+// format, for CreateFromReader. requests is consumed as it is written, so a
+// batch can be generated to a file without holding it in memory.
+func (m *Manager) WriteInput(providerName types.Provider, w io.Writer, requests iter.Seq[Request]) error {
+	r, err := m.inputReader(providerName)
+	if err != nil {
+		return err
+	}
+	return r.WriteBatchInput(w, func(yield func(provider.BatchRequest) bool) {
+		for req := range requests {
+			if !yield(provider.BatchRequest{CustomID: req.CustomID, Request: req.Request}) {
+				return
+			}
+		}
+	})
+}
+
+// CreateFromReader creates a batch from input in the provider's JSONL batch
+// format, such as a file written by WriteInput, streaming it to the
+// provider. size is the length of the input in bytes, or -1 if it is
+// unknown. OpenAI supports it.
+//
+// The requests are not known to the manager, so the batch cannot be
+// resubmitted with RetryFailed.
+func (m *Manager) CreateFromReader(ctx context.Context, providerName types.Provider, r io.Reader, size int64) (*Job, error) {
+	if err := m.begin(providerName); err != nil {
+		return nil, err
+	}
+	defer m.active.Done()
+
+	ir, err := m.inputReader(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	job, err := ir.CreateBatchFromReader(ctx, r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	result := convertJob(job)
+	if err := m.saveRecord(ctx, result, nil, ""); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// resultLine is a Result as written by WriteResults.
+type resultLine struct {
+	CustomID      string                    `json:"custom_id"`
+	RequestLabels map[string]string         `json:"request_labels,omitempty"`
+	Response      *types.CompletionResponse `json:"response,omitempty"`
+	Error         *errors.RouterError       `json:"error,omitempty"`
+}
+
+// WriteResults writes the results of a completed batch job to w as JSONL,
+// one result per line, streaming them from the provider as GetResultsIter
+// does. A failed result's error is written as an object with a code and
+// message. It returns the number of results written and how many failed.
+func (m *Manager) WriteResults(ctx context.Context, providerName types.Provider, batchID string, w io.Writer) (Counts, error) {
+	var counts Counts
+	seq, err := m.GetResultsIter(ctx, providerName, batchID)
+	if err != nil {
+		return counts, err
+	}
+
+	encoder := json.NewEncoder(w)
+	for result, err := range seq {
+		if err != nil {
+			return counts, err
+		}
+		line := resultLine{
+			CustomID:      result.CustomID,
+			RequestLabels: result.RequestLabels,
+			Response:      result.Response,
+		}
+		if result.Error != nil {
+			var rerr *errors.RouterError
+			if !stderrors.As(result.Error, &rerr) {
+				rerr = errors.ErrServerError(providerName, result.Error.Error())
+			}
+			line.Error = rerr
+		}
+		if err := encoder.Encode(line); err != nil {
+			return counts, err
+		}
+
+		counts.Total++
+		if result.Error != nil {
+			counts.Failed++
+		} else {
+			counts.Completed++
+		}
+	}
+	return counts, nil
+}
@@ -0,0 +1,91 @@
+package batch
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"iter"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// fakeInputProvider reads batch input of one custom ID per line.
+type fakeInputProvider struct {
+	fakeBatchProvider
+}
+
+func (f *fakeInputProvider) WriteBatchInput(w io.Writer, requests iter.Seq[provider.BatchRequest]) error {
+	for req := range requests {
+		if _, err := io.WriteString(w, req.CustomID+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeInputProvider) CreateBatchFromReader(ctx context.Context, r io.Reader, size int64) (*provider.BatchJob, error) {
+	var requests []provider.BatchRequest
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		requests = append(requests, provider.BatchRequest{CustomID: scanner.Text()})
+	}
+	return f.CreateBatch(ctx, requests)
+}
+
+func TestManager_ReaderInputAndWriterResults(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager()
+	m.RegisterProvider(&fakeInputProvider{fakeBatchProvider{batches: make(map[string][]provider.BatchRequest)}})
+
+	var input bytes.Buffer
+	requests := slices.Values([]Request{{CustomID: "a"}, {CustomID: "fail"}, {CustomID: "b"}})
+	if err := m.WriteInput(types.ProviderOpenAI, &input, requests); err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.CreateFromReader(ctx, types.ProviderOpenAI, &input, int64(input.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var output bytes.Buffer
+	counts, err := m.WriteResults(ctx, types.ProviderOpenAI, job.ID, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if counts != (Counts{Total: 3, Completed: 2, Failed: 1}) {
+		t.Errorf("counts = %+v", counts)
+	}
+
+	lines := strings.Split(strings.TrimSpace(output.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("output:\n%s", output.String())
+	}
+	var failed struct {
+		CustomID string             `json:"custom_id"`
+		Error    errors.RouterError `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &failed); err != nil {
+		t.Fatal(err)
+	}
+	if failed.CustomID != "fail" || failed.Error.Code != errors.ErrCodeServerError || failed.Error.Message != "boom" {
+		t.Errorf("failed result line = %s", lines[1])
+	}
+}
+
+func TestManager_CreateFromReaderUnsupported(t *testing.T) {
+	m := NewManager()
+	m.RegisterProvider(&fakeBatchProvider{batches: make(map[string][]provider.BatchRequest)})
+
+	_, err := m.CreateFromReader(context.Background(), types.ProviderOpenAI, strings.NewReader(""), 0)
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeUnsupportedFeature, "")) {
+		t.Errorf("err = %v, want unsupported feature", err)
+	}
+}
@@ -0,0 +1,87 @@
+package batch
+
+import (
+	"context"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	defaultWatchMinInterval = 2 * time.Second
+	defaultWatchMaxInterval = time.Minute
+)
+
+// WatchOption configures Watch.
+type WatchOption func(*watchConfig)
+
+type watchConfig struct {
+	minInterval time.Duration
+	maxInterval time.Duration
+}
+
+// WithPollInterval bounds the adaptive polling interval used by Watch.
+// Polling starts at min, backs off towards max while the job is unchanged,
+// and drops back to min whenever it changes.
+func WithPollInterval(min, max time.Duration) WatchOption {
+	return func(c *watchConfig) {
+		c.minInterval = min
+		c.maxInterval = max
+	}
+}
+
+// Watch polls a batch job until it reaches a terminal state, calling onUpdate
+// with the job whenever its status or counts change (and once for the initial
+// state). It returns the final job.
+func (m *Manager) Watch(ctx context.Context, providerName types.Provider, batchID string, onUpdate func(Job), opts ...WatchOption) (*Job, error) {
+	cfg := &watchConfig{
+		minInterval: defaultWatchMinInterval,
+		maxInterval: defaultWatchMaxInterval,
+	}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.maxInterval < cfg.minInterval {
+		cfg.maxInterval = cfg.minInterval
+	}
+
+	if err := m.begin(providerName); err != nil {
+		return nil, err
+	}
+	defer m.active.Done()
+
+	var last *Job
+	interval := cfg.minInterval
+
+	for {
+		job, err := m.Get(ctx, providerName, batchID)
+		if err != nil {
+			return nil, err
+		}
+
+		if last == nil || job.Status != last.Status || job.Counts != last.Counts {
+			if onUpdate != nil {
+				onUpdate(*job)
+			}
+			interval = cfg.minInterval
+		} else {
+			interval = min(interval*2, cfg.maxInterval)
+		}
+		last = job
+
+		if job.Status.IsDone() {
+			return job, nil
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-m.stopping:
+			timer.Stop()
+			return nil, errShutdown(providerName)
+		case <-timer.C:
+		}
+	}
+}
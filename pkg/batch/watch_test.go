@@ -0,0 +1,75 @@
+package batch
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestWatch(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "b", "c", "d"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var updates []Job
+	final, err := m.Watch(context.Background(), types.ProviderOpenAI, job.ID, func(j Job) {
+		updates = append(updates, j)
+	}, WithPollInterval(time.Millisecond, 4*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if final.Status != StatusCompleted || final.Counts.Completed != 4 {
+		t.Errorf("unexpected final job: %+v", final)
+	}
+	if len(updates) == 0 || updates[len(updates)-1].Status != StatusCompleted {
+		t.Fatalf("expected final update to be completed, got %+v", updates)
+	}
+	for i := 1; i < len(updates); i++ {
+		if updates[i].Status == updates[i-1].Status && updates[i].Counts == updates[i-1].Counts {
+			t.Errorf("update %d did not change the job", i)
+		}
+	}
+}
+
+func TestShutdown(t *testing.T) {
+	fake := &fakeProvider{attempts: make(map[string]int)}
+	m := NewManager()
+	m.RegisterLocalProvider(fake)
+
+	job, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("a", "b", "c", "d"), 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	watched := make(chan error, 1)
+	go func() {
+		_, err := m.Watch(context.Background(), types.ProviderOpenAI, job.ID, nil, WithPollInterval(time.Hour, time.Hour))
+		watched <- err
+	}()
+	time.Sleep(5 * time.Millisecond)
+
+	if err := m.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	var rerr *errors.RouterError
+	if err := <-watched; !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeProviderUnavailable {
+		t.Errorf("watch err = %v, want provider unavailable", err)
+	}
+	// Shutdown waited for the local batch.
+	if got, _ := m.Get(context.Background(), types.ProviderOpenAI, job.ID); got.Status != StatusCompleted {
+		t.Errorf("status = %s, want completed", got.Status)
+	}
+	if _, err := m.CreateLocal(context.Background(), types.ProviderOpenAI, localRequests("e"), 1); err == nil {
+		t.Error("expected CreateLocal to fail after Shutdown")
+	}
+}
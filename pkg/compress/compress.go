@@ -0,0 +1,105 @@
+// Package compress shrinks prompts before they are sent, to save input
+// tokens.
+//
+// A Pipeline runs stages over a copy of a request's messages: Dedupe drops
+// context repeated across messages, Minify strips whitespace and markdown the
+// model does not need, and LLM has a model rewrite long passages tersely.
+// Run it on every request with router.WithPromptCompression:
+//
+//	p := compress.New(compress.Dedupe(), compress.Minify())
+//	r, err := router.New(router.WithOpenAI(key), router.WithPromptCompression(p))
+//
+// Each response then reports the savings in CompletionResponse.Compression.
+// Stages change the text of text blocks and text tool results; other content
+// is sent as is. Token counts are estimated at four characters per token.
+package compress
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Stage is a compression step. It rewrites the request in place, which is a
+// copy owned by the pipeline, and returns what its own requests used, if it
+// sends any.
+type Stage interface {
+	Name() string
+	Compress(ctx context.Context, req *types.CompletionRequest) (types.Usage, error)
+}
+
+// Pipeline runs stages in order. A nil Pipeline passes requests through.
+type Pipeline struct {
+	stages []Stage
+}
+
+// New creates a pipeline that runs the stages in order.
+func New(stages ...Stage) *Pipeline {
+	return &Pipeline{stages: stages}
+}
+
+// Compress runs the stages on a copy of req, so the caller's messages are
+// never modified, and returns the copy with the savings. Requests sent by a
+// stage, such as LLM's, are passed through when they come back through the
+// pipeline, and so are all requests of a nil Pipeline, with nil stats.
+func (p *Pipeline) Compress(ctx context.Context, req *types.CompletionRequest) (*types.CompletionRequest, *types.CompressionStats, error) {
+	if p == nil || ctx.Value(compressingKey{}) != nil {
+		return req, nil, nil
+	}
+
+	compressed := *req
+	compressed.Messages = make([]types.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = append([]types.ContentBlock(nil), msg.Content...)
+		compressed.Messages[i] = msg
+	}
+
+	ctx = context.WithValue(ctx, compressingKey{}, true)
+	stats := &types.CompressionStats{OriginalTokens: EstimateTokens(req), Stages: make(map[string]int)}
+	tokens := stats.OriginalTokens
+	for _, s := range p.stages {
+		usage, err := s.Compress(ctx, &compressed)
+		if err != nil {
+			return nil, nil, fmt.Errorf("compress: %s: %w", s.Name(), err)
+		}
+		after := EstimateTokens(&compressed)
+		stats.Stages[s.Name()] += tokens - after
+		stats.Usage.Add(usage)
+		tokens = after
+	}
+	stats.CompressedTokens = tokens
+	stats.SavedTokens = stats.OriginalTokens - tokens
+	return &compressed, stats, nil
+}
+
+// compressingKey marks the context of a request being compressed, so the
+// requests of its stages are not compressed in turn.
+type compressingKey struct{}
+
+// EstimateTokens estimates the tokens of the compressible text of req's
+// messages at four characters per token.
+func EstimateTokens(req *types.CompletionRequest) int {
+	chars := 0
+	for i := range req.Messages {
+		for _, text := range texts(&req.Messages[i]) {
+			chars += len(*text)
+		}
+	}
+	return (chars + 3) / 4
+}
+
+// texts returns the compressible text of msg: its text blocks and the text
+// of its tool results without structured content.
+func texts(msg *types.Message) []*string {
+	var out []*string
+	for i := range msg.Content {
+		block := &msg.Content[i]
+		switch {
+		case block.Type == types.ContentTypeText,
+			block.Type == types.ContentTypeToolResult && len(block.ToolResultContent) == 0:
+			out = append(out, &block.Text)
+		}
+	}
+	return out
+}
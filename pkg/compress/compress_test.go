@@ -0,0 +1,112 @@
+package compress
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCompress_Dedupe(t *testing.T) {
+	doc := strings.Repeat("The warranty covers parts and labor for two years. ", 3)
+	req := &types.CompletionRequest{Messages: []types.Message{
+		types.NewTextMessage(types.RoleSystem, "Answer from this document:\n\n"+doc),
+		types.NewTextMessage(types.RoleUser, doc+"\n\nDoes it cover labor?"),
+	}}
+
+	compressed, stats, err := New(Dedupe()).Compress(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := compressed.Messages[1].Content[0].Text, duplicateNote+"\n\nDoes it cover labor?"; got != want {
+		t.Errorf("user message = %q, want %q", got, want)
+	}
+	if !strings.Contains(compressed.Messages[0].Content[0].Text, doc) {
+		t.Error("first occurrence was removed")
+	}
+	if !strings.HasPrefix(req.Messages[1].Content[0].Text, doc) {
+		t.Error("Compress modified the caller's request")
+	}
+	if stats.SavedTokens <= 0 || stats.Stages["dedupe"] != stats.SavedTokens || stats.OriginalTokens-stats.CompressedTokens != stats.SavedTokens {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestCompress_Minify(t *testing.T) {
+	text := "# Title  \n\n\n\nSome   **bold**\ttext.<!-- note -->\n  - indented   item\n```\nkeep   this  \n\n\n```\n"
+	req := &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, text)}}
+
+	compressed, _, err := New(Minify()).Compress(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "# Title\n\nSome bold\ttext.\n  - indented item\n```\nkeep   this  \n\n\n```"
+	if got := compressed.Messages[0].Content[0].Text; got != want {
+		t.Errorf("minified = %q, want %q", got, want)
+	}
+}
+
+type fakeCompleter struct {
+	requests []*types.CompletionRequest
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	f.requests = append(f.requests, req)
+	return &types.CompletionResponse{
+		Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: "short"}},
+		Usage:   types.Usage{InputTokens: 50, OutputTokens: 2, TotalTokens: 52},
+	}, nil
+}
+
+func TestCompress_LLM(t *testing.T) {
+	long := strings.Repeat("a long passage ", 20)
+	req := &types.CompletionRequest{Messages: []types.Message{
+		types.NewTextMessage(types.RoleSystem, long),
+		types.NewTextMessage(types.RoleUser, "tiny"),
+		types.NewTextMessage(types.RoleUser, long),
+	}}
+	client := &fakeCompleter{}
+	p := New(LLM(client, types.ProviderOpenAI, "gpt-4o-mini", 10))
+
+	for range 2 {
+		compressed, stats, err := p.Compress(context.Background(), req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := compressed.Messages[0].Content[0].Text; got != "short" {
+			t.Errorf("system message = %q, want the rewrite", got)
+		}
+		if compressed.Messages[2].Content[0].Text != long {
+			t.Error("last message was rewritten")
+		}
+		if stats.Stages["llm"] <= 0 {
+			t.Errorf("stats = %+v, want llm savings", stats)
+		}
+	}
+	if len(client.requests) != 1 {
+		t.Fatalf("sent %d rewrite requests, want 1 with the second from the cache", len(client.requests))
+	}
+	if r := client.requests[0]; r.Model != "gpt-4o-mini" || !strings.HasSuffix(r.Messages[0].Content[0].Text, long) {
+		t.Errorf("rewrite request = %+v", r)
+	}
+}
+
+func TestCompress_Nested(t *testing.T) {
+	req := &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, "a  b")}}
+	p := New(Minify())
+
+	ctx := context.WithValue(context.Background(), compressingKey{}, true)
+	compressed, stats, err := p.Compress(ctx, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if compressed != req || stats != nil {
+		t.Error("a stage's request was compressed")
+	}
+
+	var nilPipeline *Pipeline
+	if compressed, stats, _ := nilPipeline.Compress(context.Background(), req); compressed != req || stats != nil {
+		t.Error("nil pipeline changed the request")
+	}
+}
@@ -0,0 +1,175 @@
+package compress
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	// minDuplicateChars is the length below which repeated paragraphs are
+	// kept: short lines like "Thanks!" are cheaper than the note replacing
+	// them and often repeat on purpose.
+	minDuplicateChars = 100
+
+	// duplicateNote replaces a repeated paragraph.
+	duplicateNote = "[repeated text omitted]"
+
+	// maxCached is the number of rewritten passages LLM remembers.
+	maxCached = 256
+
+	// llmPrompt asks the model to rewrite a passage.
+	llmPrompt = "Rewrite the following text as tersely as possible for another language model to read. Keep every fact, name, number, instruction, and code snippet. Reply with the rewritten text only.\n\n"
+)
+
+type dedupe struct{}
+
+// Dedupe replaces paragraphs of at least 100 characters that already
+// appeared earlier in the request, such as a document pasted into several
+// turns, with a short note. The first occurrence is kept.
+func Dedupe() Stage {
+	return dedupe{}
+}
+
+func (dedupe) Name() string { return "dedupe" }
+
+func (dedupe) Compress(_ context.Context, req *types.CompletionRequest) (types.Usage, error) {
+	seen := make(map[string]bool)
+	for i := range req.Messages {
+		for _, text := range texts(&req.Messages[i]) {
+			paragraphs := strings.Split(*text, "\n\n")
+			changed := false
+			for j, p := range paragraphs {
+				key := strings.TrimSpace(p)
+				if len(key) < minDuplicateChars {
+					continue
+				}
+				if seen[key] {
+					paragraphs[j] = duplicateNote
+					changed = true
+				}
+				seen[key] = true
+			}
+			if changed {
+				*text = strings.Join(paragraphs, "\n\n")
+			}
+		}
+	}
+	return types.Usage{}, nil
+}
+
+var (
+	spaceRun    = regexp.MustCompile(`(\S)[ \t]{2,}`)
+	blankLines  = regexp.MustCompile(`\n{3,}`)
+	bold        = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	htmlComment = regexp.MustCompile(`(?s)<!--.*?-->`)
+)
+
+type minify struct{}
+
+// Minify strips what the model does not need to read: trailing and repeated
+// spaces, runs of blank lines, bold markers, and HTML comments. Leading
+// indentation and fenced code blocks are left as they are.
+func Minify() Stage {
+	return minify{}
+}
+
+func (minify) Name() string { return "minify" }
+
+func (minify) Compress(_ context.Context, req *types.CompletionRequest) (types.Usage, error) {
+	for i := range req.Messages {
+		for _, text := range texts(&req.Messages[i]) {
+			*text = minifyText(*text)
+		}
+	}
+	return types.Usage{}, nil
+}
+
+// minifyText minifies text outside its ``` fences.
+func minifyText(text string) string {
+	parts := strings.Split(text, "```")
+	// Even parts are outside fences.
+	for i := 0; i < len(parts); i += 2 {
+		p := htmlComment.ReplaceAllString(parts[i], "")
+		p = bold.ReplaceAllString(p, "$1")
+		lines := strings.Split(p, "\n")
+		for j, line := range lines {
+			lines[j] = spaceRun.ReplaceAllString(strings.TrimRight(line, " \t"), "$1 ")
+		}
+		parts[i] = blankLines.ReplaceAllString(strings.Join(lines, "\n"), "\n\n")
+	}
+	return strings.TrimSpace(strings.Join(parts, "```"))
+}
+
+type llm struct {
+	client    types.Completer
+	provider  types.Provider
+	model     string
+	minTokens int
+
+	mu     sync.Mutex
+	cached map[string]string
+}
+
+// LLM has a model, typically a small and cheap one, rewrite each text of at
+// least minTokens tersely, keeping its facts. The last message, which
+// usually holds the question, is left as written. Rewrites are cached, so a
+// passage sent with every request, such as a long system prompt, is only
+// rewritten once; a rewrite that is not shorter is discarded. A failed
+// rewrite fails the request.
+func LLM(client types.Completer, provider types.Provider, model string, minTokens int) Stage {
+	return &llm{client: client, provider: provider, model: model, minTokens: minTokens, cached: make(map[string]string)}
+}
+
+func (s *llm) Name() string { return "llm" }
+
+func (s *llm) Compress(ctx context.Context, req *types.CompletionRequest) (types.Usage, error) {
+	var usage types.Usage
+	for i := range len(req.Messages) - 1 {
+		for _, text := range texts(&req.Messages[i]) {
+			if (len(*text)+3)/4 < s.minTokens {
+				continue
+			}
+			rewritten, u, err := s.rewrite(ctx, *text)
+			if err != nil {
+				return usage, err
+			}
+			usage.Add(u)
+			if rewritten != "" && len(rewritten) < len(*text) {
+				*text = rewritten
+			}
+		}
+	}
+	return usage, nil
+}
+
+// rewrite returns the model's rewrite of text, from the cache if it has one.
+func (s *llm) rewrite(ctx context.Context, text string) (string, types.Usage, error) {
+	s.mu.Lock()
+	rewritten, ok := s.cached[text]
+	s.mu.Unlock()
+	if ok {
+		return rewritten, types.Usage{}, nil
+	}
+
+	resp, err := s.client.Complete(ctx, &types.CompletionRequest{
+		Provider: s.provider,
+		Model:    s.model,
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, llmPrompt+text)},
+	})
+	if err != nil {
+		return "", types.Usage{}, err
+	}
+	rewritten = strings.TrimSpace(resp.Text())
+
+	s.mu.Lock()
+	if len(s.cached) >= maxCached {
+		clear(s.cached)
+	}
+	s.cached[text] = rewritten
+	s.mu.Unlock()
+	return rewritten, resp.Usage, nil
+}
@@ -4,6 +4,7 @@ package errors
 import (
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -13,14 +14,20 @@ const (
 	ErrCodeInvalidRequest      = "invalid_request"
 	ErrCodeAuthentication      = "authentication_error"
 	ErrCodeRateLimit           = "rate_limit"
+	ErrCodeOverloaded          = "overloaded"
+	ErrCodeQuotaExceeded       = "quota_exceeded"
 	ErrCodeServerError         = "server_error"
 	ErrCodeUnsupportedFeature  = "unsupported_feature"
 	ErrCodeProviderUnavailable = "provider_unavailable"
 	ErrCodeTimeout             = "timeout"
 	ErrCodeContentFilter       = "content_filter"
+	ErrCodeContentBlocked      = "content_blocked"
+	ErrCodeEmptyResponse       = "empty_response"
 	ErrCodeInvalidAPIKey       = "invalid_api_key"
 	ErrCodeModelNotFound       = "model_not_found"
 	ErrCodeContextLength       = "context_length_exceeded"
+	ErrCodeBudgetExceeded      = "budget_exceeded"
+	ErrCodeGuardrail           = "guardrail_violation"
 )
 
 // RouterError is the base error type for all router errors.
@@ -52,9 +59,9 @@ func (e *RouterError) Error() string {
 		base = fmt.Sprintf("%s: %s", e.Code, e.Message)
 	}
 	if e.Cause != nil {
-		return fmt.Sprintf("%s: %s", base, e.Cause.Error())
+		return Scrub(fmt.Sprintf("%s: %s", base, e.Cause.Error()))
 	}
-	return base
+	return Scrub(base)
 }
 
 func (e *RouterError) Unwrap() error {
@@ -70,11 +77,11 @@ func (e *RouterError) Is(target error) bool {
 	return false
 }
 
-// NewError creates a new RouterError.
+// NewError creates a new RouterError. Credentials in message are scrubbed.
 func NewError(code, message string) *RouterError {
 	return &RouterError{
 		Code:    code,
-		Message: message,
+		Message: Scrub(message),
 	}
 }
 
@@ -96,12 +103,44 @@ func (e *RouterError) WithStatusCode(code int) *RouterError {
 	return e
 }
 
-// WithDetails adds additional details to the error.
+// WithDetails adds additional details to the error. Credentials in string
+// values are scrubbed.
 func (e *RouterError) WithDetails(details map[string]any) *RouterError {
+	for k, v := range details {
+		if s, ok := v.(string); ok {
+			details[k] = Scrub(s)
+		}
+	}
 	e.Details = details
 	return e
 }
 
+// WithRetryAfter records how long the provider asked to wait before
+// retrying in Details["retry_after"]. A zero duration is not recorded.
+func (e *RouterError) WithRetryAfter(d time.Duration) *RouterError {
+	if d <= 0 {
+		return e
+	}
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details["retry_after"] = d
+	return e
+}
+
+// WithRequestID records the router's request ID in Details["request_id"]
+// for correlating the error with logs. An empty ID is not recorded.
+func (e *RouterError) WithRequestID(id string) *RouterError {
+	if id == "" {
+		return e
+	}
+	if e.Details == nil {
+		e.Details = make(map[string]any)
+	}
+	e.Details["request_id"] = id
+	return e
+}
+
 // Common error constructors
 
 // ErrInvalidRequest creates an invalid request error.
@@ -119,6 +158,19 @@ func ErrRateLimit(provider types.Provider, message string) *RouterError {
 	return NewError(ErrCodeRateLimit, message).WithProvider(provider).WithStatusCode(429)
 }
 
+// ErrOverloaded creates an error for a provider that is temporarily over
+// capacity, such as an Anthropic 529. It is retryable.
+func ErrOverloaded(provider types.Provider, message string) *RouterError {
+	return NewError(ErrCodeOverloaded, message).WithProvider(provider).WithStatusCode(529)
+}
+
+// ErrQuotaExceeded creates an error for an account out of credits or over
+// its plan's quota, such as OpenAI's insufficient_quota. Unlike a rate
+// limit, retrying does not help until the account is topped up.
+func ErrQuotaExceeded(provider types.Provider, message string) *RouterError {
+	return NewError(ErrCodeQuotaExceeded, message).WithProvider(provider).WithStatusCode(402)
+}
+
 // ErrServerError creates a server error.
 func ErrServerError(provider types.Provider, message string) *RouterError {
 	return NewError(ErrCodeServerError, message).WithProvider(provider).WithStatusCode(500)
@@ -132,6 +184,15 @@ func ErrUnsupportedFeature(provider types.Provider, feature types.Feature) *Rout
 	).WithProvider(provider)
 }
 
+// ErrModelUnsupportedFeature creates an unsupported feature error for a
+// specific model of a provider that otherwise supports the feature.
+func ErrModelUnsupportedFeature(provider types.Provider, model string, feature types.Feature) *RouterError {
+	return NewError(
+		ErrCodeUnsupportedFeature,
+		fmt.Sprintf("model %s does not support feature: %s", model, feature),
+	).WithProvider(provider)
+}
+
 // ErrProviderUnavailable creates a provider unavailable error.
 func ErrProviderUnavailable(provider types.Provider, message string) *RouterError {
 	return NewError(ErrCodeProviderUnavailable, message).WithProvider(provider)
@@ -142,6 +203,14 @@ func ErrTimeout(provider types.Provider) *RouterError {
 	return NewError(ErrCodeTimeout, "request timed out").WithProvider(provider)
 }
 
+// ErrTimeoutAfter creates a timeout error for a request that ran for
+// elapsed before its deadline passed. Details hold the "elapsed" duration.
+func ErrTimeoutAfter(provider types.Provider, elapsed time.Duration) *RouterError {
+	return NewError(ErrCodeTimeout, fmt.Sprintf("request timed out after %s", elapsed.Round(time.Millisecond))).
+		WithProvider(provider).
+		WithDetails(map[string]any{"elapsed": elapsed})
+}
+
 // ErrInvalidAPIKey creates an invalid API key error.
 func ErrInvalidAPIKey(provider types.Provider) *RouterError {
 	return NewError(ErrCodeInvalidAPIKey, "invalid or missing API key").WithProvider(provider).WithStatusCode(401)
@@ -160,18 +229,81 @@ func ErrContextLength(provider types.Provider, message string) *RouterError {
 	return NewError(ErrCodeContextLength, message).WithProvider(provider).WithStatusCode(400)
 }
 
+// ErrContentBlocked creates an error for a prompt the provider refused to
+// answer, such as a Gemini prompt blocked for safety. Details hold the
+// "block_reason" and the flagged "categories".
+func ErrContentBlocked(provider types.Provider, reason string, categories []string) *RouterError {
+	return NewError(ErrCodeContentBlocked, fmt.Sprintf("prompt blocked: %s", reason)).
+		WithProvider(provider).
+		WithStatusCode(400).
+		WithDetails(map[string]any{"block_reason": reason, "categories": categories})
+}
+
+// ErrContentFilter creates an error for a response the provider stopped
+// under its content policy, such as an OpenAI content_filter finish or a
+// Gemini SAFETY stop. Details hold the provider's
+// "reason", the flagged "categories" where the provider names them, and the
+// "partial_text" generated before the stop.
+func ErrContentFilter(provider types.Provider, reason string, categories []string, partialText string) *RouterError {
+	return NewError(ErrCodeContentFilter, fmt.Sprintf("response blocked by content filter: %s", reason)).
+		WithProvider(provider).
+		WithStatusCode(400).
+		WithDetails(map[string]any{"reason": reason, "categories": categories, "partial_text": partialText})
+}
+
+// ErrEmptyResponse creates an error for a provider response with nothing to
+// return, such as a Gemini response without candidates. It is retryable.
+func ErrEmptyResponse(provider types.Provider, message string) *RouterError {
+	return NewError(ErrCodeEmptyResponse, message).WithProvider(provider).WithStatusCode(502)
+}
+
+// ErrBudgetExceeded creates a budget exceeded error.
+func ErrBudgetExceeded(message string) *RouterError {
+	return NewError(ErrCodeBudgetExceeded, message).WithStatusCode(429)
+}
+
+// ErrGuardrail creates an error for content rejected by a guardrail.
+func ErrGuardrail(guard, reason string) *RouterError {
+	return NewError(ErrCodeGuardrail, fmt.Sprintf("%s: %s", guard, reason)).
+		WithStatusCode(400).
+		WithDetails(map[string]any{"guardrail": guard})
+}
+
 // IsRetryable returns true if the error is potentially retryable.
 func IsRetryable(err error) bool {
 	var rerr *RouterError
 	if errors.As(err, &rerr) {
 		switch rerr.Code {
-		case ErrCodeRateLimit, ErrCodeServerError, ErrCodeTimeout:
+		case ErrCodeRateLimit, ErrCodeOverloaded, ErrCodeServerError, ErrCodeTimeout, ErrCodeEmptyResponse:
 			return true
 		}
 	}
 	return false
 }
 
+// RetryAfter returns how long the provider asked to wait before retrying
+// err, parsed from its Retry-After header, or zero if it did not say.
+func RetryAfter(err error) time.Duration {
+	var rerr *RouterError
+	if errors.As(err, &rerr) {
+		if d, ok := rerr.Details["retry_after"].(time.Duration); ok {
+			return d
+		}
+	}
+	return 0
+}
+
+// RequestID returns the router's request ID recorded on err, or "" if none.
+func RequestID(err error) string {
+	var rerr *RouterError
+	if errors.As(err, &rerr) {
+		if id, ok := rerr.Details["request_id"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
+
 // IsAuthError returns true if the error is an authentication error.
 func IsAuthError(err error) bool {
 	var rerr *RouterError
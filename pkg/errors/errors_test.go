@@ -2,7 +2,9 @@ package errors
 
 import (
 	"errors"
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -198,6 +200,34 @@ func TestErrContextLength(t *testing.T) {
 	}
 }
 
+func TestErrContentBlocked(t *testing.T) {
+	err := ErrContentBlocked(types.ProviderGoogle, "SAFETY", []string{"HARM_CATEGORY_HARASSMENT"})
+
+	if err.Code != ErrCodeContentBlocked {
+		t.Errorf("expected code %q, got %q", ErrCodeContentBlocked, err.Code)
+	}
+
+	if err.Details["block_reason"] != "SAFETY" {
+		t.Errorf("expected block reason in details, got %v", err.Details)
+	}
+
+	if IsRetryable(err) {
+		t.Error("expected content blocked error to not be retryable")
+	}
+}
+
+func TestErrEmptyResponse(t *testing.T) {
+	err := ErrEmptyResponse(types.ProviderGoogle, "response has no candidates")
+
+	if err.Code != ErrCodeEmptyResponse {
+		t.Errorf("expected code %q, got %q", ErrCodeEmptyResponse, err.Code)
+	}
+
+	if err.Provider != types.ProviderGoogle || err.StatusCode != 502 {
+		t.Errorf("unexpected error: %+v", err)
+	}
+}
+
 func TestIsRetryable(t *testing.T) {
 	tests := []struct {
 		err      error
@@ -206,6 +236,9 @@ func TestIsRetryable(t *testing.T) {
 		{ErrRateLimit(types.ProviderOpenAI, "rate limited"), true},
 		{ErrServerError(types.ProviderOpenAI, "server error"), true},
 		{ErrTimeout(types.ProviderOpenAI), true},
+		{ErrEmptyResponse(types.ProviderOpenAI, "response has no choices"), true},
+		{ErrOverloaded(types.ProviderAnthropic, "overloaded"), true},
+		{ErrQuotaExceeded(types.ProviderOpenAI, "insufficient quota"), false},
 		{ErrInvalidRequest("bad input"), false},
 		{ErrAuthentication(types.ProviderOpenAI, "bad auth"), false},
 		{ErrInvalidAPIKey(types.ProviderOpenAI), false},
@@ -220,6 +253,30 @@ func TestIsRetryable(t *testing.T) {
 	}
 }
 
+func TestRequestID(t *testing.T) {
+	err := ErrServerError(types.ProviderOpenAI, "boom").WithRequestID("req_1")
+	if got := RequestID(fmt.Errorf("wrapped: %w", err)); got != "req_1" {
+		t.Errorf("RequestID = %q", got)
+	}
+	if got := RequestID(ErrServerError(types.ProviderOpenAI, "boom").WithRequestID("")); got != "" {
+		t.Errorf("RequestID of untagged error = %q", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	err := ErrRateLimit(types.ProviderOpenAI, "rate limited").WithRetryAfter(20 * time.Second)
+	if got := RetryAfter(fmt.Errorf("wrapped: %w", err)); got != 20*time.Second {
+		t.Errorf("RetryAfter = %v, want 20s", got)
+	}
+
+	if got := RetryAfter(ErrRateLimit(types.ProviderOpenAI, "rate limited").WithRetryAfter(0)); got != 0 {
+		t.Errorf("RetryAfter without a delay = %v, want 0", got)
+	}
+	if got := RetryAfter(errors.New("regular error")); got != 0 {
+		t.Errorf("RetryAfter of a plain error = %v, want 0", got)
+	}
+}
+
 func TestIsAuthError(t *testing.T) {
 	tests := []struct {
 		err      error
@@ -260,3 +317,17 @@ func TestErrorsAs(t *testing.T) {
 		// This will not match because wrappedErr is a regular error
 	}
 }
+
+func TestErrContentFilter(t *testing.T) {
+	err := ErrContentFilter(types.ProviderAnthropic, "refusal", nil, "I can't")
+
+	if err.Code != ErrCodeContentFilter {
+		t.Errorf("expected code %q, got %q", ErrCodeContentFilter, err.Code)
+	}
+	if err.Details["reason"] != "refusal" || err.Details["partial_text"] != "I can't" {
+		t.Errorf("unexpected details: %v", err.Details)
+	}
+	if IsRetryable(err) {
+		t.Error("expected content filter error to not be retryable")
+	}
+}
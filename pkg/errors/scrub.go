@@ -0,0 +1,29 @@
+package errors
+
+import "regexp"
+
+// Redacted replaces credentials removed by Scrub.
+const Redacted = "[REDACTED]"
+
+// secretPatterns match credentials that can end up in error text: API keys
+// in query parameters (the Google client sends ?key=...), bearer tokens,
+// and the key formats of the major providers.
+var secretPatterns = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`(?i)([?&](?:key|api_key|apikey|access_token)=)[^&\s"']+`), "${1}" + Redacted},
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + Redacted},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`), Redacted},
+	{regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), Redacted},
+}
+
+// Scrub returns s with API keys and tokens replaced by [REDACTED]. It is
+// applied to every RouterError's message, string details, and Error text,
+// so errors can be logged or attached to bug reports safely.
+func Scrub(s string) string {
+	for _, p := range secretPatterns {
+		s = p.re.ReplaceAllString(s, p.repl)
+	}
+	return s
+}
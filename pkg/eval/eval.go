@@ -0,0 +1,291 @@
+// Package eval runs prompts against several providers and models and
+// compares how they do.
+//
+// A Case is a request and the scorers that judge its response; a Target is
+// a provider and model to run it on. Run sends every case to every target
+// concurrently and returns a Report:
+//
+//	report, err := eval.Run(ctx, r, cases, []eval.Target{
+//		{Provider: types.ProviderOpenAI, Model: "gpt-4o-mini"},
+//		{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"},
+//	})
+//	report.WriteTable(os.Stdout)
+//
+// Scorers check the response text with Exact, Contains, or Regex, or ask a
+// model to grade it with Judge.
+package eval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// DefaultConcurrency is the number of requests Run sends at once by
+// default.
+const DefaultConcurrency = 4
+
+// Case is a prompt to evaluate.
+type Case struct {
+	// Name identifies the case in reports.
+	Name string `json:"name"`
+
+	// Request is the prompt. Its Provider and Model are replaced by each
+	// target's.
+	Request types.CompletionRequest `json:"request"`
+
+	// Scorers judge the response. A case without scorers passes whenever
+	// the request succeeds.
+	Scorers []Scorer `json:"-"`
+}
+
+// Target is a provider and model to evaluate.
+type Target struct {
+	Provider types.Provider `json:"provider"`
+	Model    string         `json:"model"`
+}
+
+func (t Target) String() string {
+	return string(t.Provider) + "/" + t.Model
+}
+
+// Result is the outcome of one case on one target.
+type Result struct {
+	Case   string `json:"case"`
+	Target Target `json:"target"`
+
+	// Response is the model's response, or nil if the request failed.
+	Response *types.CompletionResponse `json:"response,omitempty"`
+
+	// Error is why the request or a scorer failed.
+	Error string `json:"error,omitempty"`
+
+	// Scores holds one score per scorer, in the case's order.
+	Scores []Score `json:"scores,omitempty"`
+
+	// Latency is how long the request took.
+	Latency time.Duration `json:"latency"`
+
+	// Cost is the cost in USD the provider billed, or the list price from
+	// the models catalog.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Passed reports whether the request succeeded and every scorer passed.
+func (r *Result) Passed() bool {
+	if r.Error != "" {
+		return false
+	}
+	for _, s := range r.Scores {
+		if !s.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// Score is the mean of the result's scores, or zero if it failed. A result
+// without scores that succeeded scores one.
+func (r *Result) Score() float64 {
+	if r.Error != "" {
+		return 0
+	}
+	if len(r.Scores) == 0 {
+		return 1
+	}
+	var sum float64
+	for _, s := range r.Scores {
+		sum += s.Value
+	}
+	return sum / float64(len(r.Scores))
+}
+
+// Option configures Run.
+type Option func(*options)
+
+type options struct {
+	concurrency int
+	progress    func(done, total int)
+}
+
+// WithConcurrency sets how many requests Run sends at once. Values below
+// one are treated as one.
+func WithConcurrency(n int) Option {
+	return func(o *options) {
+		o.concurrency = max(n, 1)
+	}
+}
+
+// WithProgress calls fn after each case finishes on a target, with the
+// number finished so far and the total. It may be called concurrently.
+func WithProgress(fn func(done, total int)) Option {
+	return func(o *options) {
+		o.progress = fn
+	}
+}
+
+// Run sends every case to every target and scores the responses. A failed
+// request or scorer is recorded in its result rather than ending the run;
+// Run only fails if ctx is done before every case has run.
+func Run(ctx context.Context, client types.Completer, cases []Case, targets []Target, opts ...Option) (*Report, error) {
+	o := options{concurrency: DefaultConcurrency}
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	report := &Report{Targets: targets, Results: make([]Result, len(cases)*len(targets))}
+	total := len(report.Results)
+	var mu sync.Mutex
+	done := 0
+
+	sem := make(chan struct{}, o.concurrency)
+	var wg sync.WaitGroup
+	for i := range cases {
+		for j, target := range targets {
+			select {
+			case sem <- struct{}{}:
+			case <-ctx.Done():
+				wg.Wait()
+				return nil, ctx.Err()
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-sem
+					wg.Done()
+				}()
+				report.Results[i*len(targets)+j] = runCase(ctx, client, &cases[i], target)
+				if o.progress != nil {
+					mu.Lock()
+					done++
+					n := done
+					mu.Unlock()
+					o.progress(n, total)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// runCase runs and scores one case on one target.
+func runCase(ctx context.Context, client types.Completer, c *Case, target Target) Result {
+	result := Result{Case: c.Name, Target: target}
+
+	req := c.Request
+	req.Provider = target.Provider
+	req.Model = target.Model
+	start := time.Now()
+	resp, err := client.Complete(ctx, &req)
+	result.Latency = time.Since(start)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	result.Response = resp
+	result.Cost = models.Cost(target.Provider, target.Model, resp.Usage)
+
+	var errs []error
+	for _, scorer := range c.Scorers {
+		score, err := scorer.Score(ctx, c, resp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", scorer.Name(), err))
+			score = Score{Scorer: scorer.Name()}
+		}
+		if score.Scorer == "" {
+			score.Scorer = scorer.Name()
+		}
+		result.Scores = append(result.Scores, score)
+	}
+	if err := errors.Join(errs...); err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Report holds the results of Run, ordered by case and then by target.
+type Report struct {
+	Targets []Target `json:"targets"`
+	Results []Result `json:"results"`
+}
+
+// Summary aggregates a target's results.
+type Summary struct {
+	Target Target `json:"target"`
+
+	// Cases is the number of cases run; Passed and Errors count those that
+	// passed and those that failed with an error.
+	Cases  int `json:"cases"`
+	Passed int `json:"passed"`
+	Errors int `json:"errors"`
+
+	// Score is the mean score over all cases.
+	Score float64 `json:"score"`
+
+	// Latency is the mean latency of the requests that got a response.
+	Latency time.Duration `json:"latency"`
+
+	// Cost is the total cost.
+	Cost float64 `json:"cost"`
+}
+
+// Summaries returns a summary per target, in the order of the targets.
+func (r *Report) Summaries() []Summary {
+	summaries := make([]Summary, len(r.Targets))
+	latencies := make([]time.Duration, len(r.Targets))
+	responses := make([]int, len(r.Targets))
+	for i, t := range r.Targets {
+		summaries[i].Target = t
+	}
+	for k := range r.Results {
+		res := &r.Results[k]
+		i := k % len(r.Targets)
+		s := &summaries[i]
+		s.Cases++
+		s.Score += res.Score()
+		s.Cost += res.Cost
+		if res.Passed() {
+			s.Passed++
+		}
+		if res.Error != "" {
+			s.Errors++
+		}
+		if res.Response != nil {
+			latencies[i] += res.Latency
+			responses[i]++
+		}
+	}
+	for i := range summaries {
+		s := &summaries[i]
+		if s.Cases > 0 {
+			s.Score /= float64(s.Cases)
+		}
+		if responses[i] > 0 {
+			s.Latency = latencies[i] / time.Duration(responses[i])
+		}
+	}
+	return summaries
+}
+
+// WriteTable writes the summaries as an aligned text table, one target per
+// row.
+func (r *Report) WriteTable(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tPASSED\tSCORE\tERRORS\tLATENCY\tCOST")
+	for _, s := range r.Summaries() {
+		fmt.Fprintf(tw, "%s\t%d/%d\t%.2f\t%d\t%s\t$%.4f\n",
+			s.Target, s.Passed, s.Cases, s.Score, s.Errors, s.Latency.Round(time.Millisecond), s.Cost)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,109 @@
+package eval
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// modelCompleter answers each model with a function of the prompt.
+type modelCompleter map[string]func(prompt string) (string, error)
+
+func (m modelCompleter) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	text, err := m[req.Model](lastUserText(req.Messages))
+	if err != nil {
+		return nil, err
+	}
+	return &types.CompletionResponse{
+		Provider: req.Provider,
+		Model:    req.Model,
+		Content:  []types.ContentBlock{{Type: types.ContentTypeText, Text: text}},
+		Usage:    types.Usage{Cost: 0.01},
+	}, nil
+}
+
+func prompt(text string) types.CompletionRequest {
+	return types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, text)}}
+}
+
+func TestRun(t *testing.T) {
+	client := modelCompleter{
+		"good": func(p string) (string, error) {
+			if strings.Contains(p, "capital") {
+				return "Paris", nil
+			}
+			return "4", nil
+		},
+		"bad": func(p string) (string, error) {
+			if strings.Contains(p, "capital") {
+				return "", errors.New("overloaded")
+			}
+			return "five", nil
+		},
+	}
+	cases := []Case{
+		{Name: "capital", Request: prompt("What is the capital of France?"), Scorers: []Scorer{Exact("Paris")}},
+		{Name: "math", Request: prompt("2+2?"), Scorers: []Scorer{Contains("4")}},
+	}
+	targets := []Target{{Provider: types.ProviderOpenAI, Model: "good"}, {Provider: types.ProviderOpenAI, Model: "bad"}}
+
+	var progress atomic.Int32
+	report, err := Run(context.Background(), client, cases, targets, WithConcurrency(2), WithProgress(func(done, total int) {
+		progress.Add(1)
+		if total != 4 {
+			t.Errorf("total = %d, want 4", total)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.Load() != 4 {
+		t.Errorf("progress called %d times, want 4", progress.Load())
+	}
+
+	if len(report.Results) != 4 {
+		t.Fatalf("got %d results, want 4", len(report.Results))
+	}
+	for i, want := range []struct {
+		c, model string
+		pass     bool
+	}{{"capital", "good", true}, {"capital", "bad", false}, {"math", "good", true}, {"math", "bad", false}} {
+		res := report.Results[i]
+		if res.Case != want.c || res.Target.Model != want.model || res.Passed() != want.pass {
+			t.Errorf("result %d = %s on %s passed %v, want %s on %s passed %v", i, res.Case, res.Target.Model, res.Passed(), want.c, want.model, want.pass)
+		}
+	}
+	if res := report.Results[1]; res.Error != "overloaded" || res.Response != nil {
+		t.Errorf("failed request = %+v", res)
+	}
+
+	summaries := report.Summaries()
+	if s := summaries[0]; s.Passed != 2 || s.Score != 1 || s.Errors != 0 || s.Cost != 0.02 {
+		t.Errorf("good summary = %+v", s)
+	}
+	if s := summaries[1]; s.Passed != 0 || s.Score != 0 || s.Errors != 1 || s.Cost != 0.01 {
+		t.Errorf("bad summary = %+v", s)
+	}
+
+	var table strings.Builder
+	if err := report.WriteTable(&table); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(table.String(), "openai/good") || !strings.Contains(table.String(), "2/2") {
+		t.Errorf("table:\n%s", table.String())
+	}
+}
+
+func TestRun_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client := modelCompleter{"m": func(string) (string, error) { return "", nil }}
+	_, err := Run(ctx, client, []Case{{Name: "c"}}, []Target{{Model: "m"}})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
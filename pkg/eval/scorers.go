@@ -0,0 +1,160 @@
+package eval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Score is a scorer's verdict on a response.
+type Score struct {
+	// Scorer is the name of the scorer.
+	Scorer string `json:"scorer"`
+
+	// Value is the score from 0 to 1.
+	Value float64 `json:"value"`
+
+	// Pass reports whether the response meets the scorer's criteria.
+	Pass bool `json:"pass"`
+
+	// Reason explains the score, if the scorer gives one.
+	Reason string `json:"reason,omitempty"`
+}
+
+// Scorer judges a case's response.
+type Scorer interface {
+	Name() string
+	Score(ctx context.Context, c *Case, resp *types.CompletionResponse) (Score, error)
+}
+
+// passFail returns a score of 1 if pass, else 0.
+func passFail(name string, pass bool, reason string) Score {
+	s := Score{Scorer: name, Pass: pass}
+	if pass {
+		s.Value = 1
+	} else {
+		s.Reason = reason
+	}
+	return s
+}
+
+// textScorer scores the response text with a predicate.
+type textScorer struct {
+	name  string
+	check func(text string) (bool, string)
+}
+
+func (s textScorer) Name() string { return s.name }
+
+func (s textScorer) Score(_ context.Context, _ *Case, resp *types.CompletionResponse) (Score, error) {
+	pass, reason := s.check(resp.Text())
+	return passFail(s.name, pass, reason), nil
+}
+
+// Exact passes responses whose text is want, ignoring surrounding
+// whitespace.
+func Exact(want string) Scorer {
+	return textScorer{name: "exact", check: func(text string) (bool, string) {
+		return strings.TrimSpace(text) == strings.TrimSpace(want), fmt.Sprintf("want %q", want)
+	}}
+}
+
+// Contains passes responses whose text contains substr, ignoring case.
+func Contains(substr string) Scorer {
+	return textScorer{name: "contains", check: func(text string) (bool, string) {
+		return strings.Contains(strings.ToLower(text), strings.ToLower(substr)), fmt.Sprintf("missing %q", substr)
+	}}
+}
+
+// Regex passes responses whose text matches re.
+func Regex(re *regexp.Regexp) Scorer {
+	return textScorer{name: "regex", check: func(text string) (bool, string) {
+		return re.MatchString(text), fmt.Sprintf("no match for %s", re)
+	}}
+}
+
+// judgeInstructions is the judge's system prompt.
+const judgeInstructions = `You grade an AI assistant's answer against the given criteria. Reply with a JSON object with three fields: "score", a number from 0 to 1 for how well the answer meets the criteria; "pass", true if it meets them; and "reason", one sentence explaining the grade.`
+
+// judgeSchema is the structured output of the judge.
+var judgeSchema = &types.JSONSchema{
+	Type: "object",
+	Properties: map[string]types.JSONSchema{
+		"score":  {Type: "number"},
+		"pass":   {Type: "boolean"},
+		"reason": {Type: "string"},
+	},
+	Required:             []string{"score", "pass", "reason"},
+	AdditionalProperties: types.Ptr(false),
+}
+
+// judge asks a model to grade responses.
+type judge struct {
+	client   types.Completer
+	target   Target
+	criteria string
+}
+
+// Judge asks the target model to grade responses against criteria written
+// in plain language, such as "cites at least two sources and stays under
+// 100 words". The judge sees the case's last user message and the
+// response, and returns a score from 0 to 1 and a pass verdict with its
+// reason.
+func Judge(client types.Completer, target Target, criteria string) Scorer {
+	return &judge{client: client, target: target, criteria: criteria}
+}
+
+func (j *judge) Name() string { return "judge" }
+
+func (j *judge) Score(ctx context.Context, c *Case, resp *types.CompletionResponse) (Score, error) {
+	prompt := fmt.Sprintf("Criteria:\n%s\n\nQuestion:\n%s\n\nAnswer:\n%s", j.criteria, lastUserText(c.Request.Messages), resp.Text())
+	verdict, err := j.client.Complete(ctx, &types.CompletionRequest{
+		Provider: j.target.Provider,
+		Model:    j.target.Model,
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleSystem, judgeInstructions),
+			types.NewTextMessage(types.RoleUser, prompt),
+		},
+		Temperature: types.Ptr(0.0),
+		ResponseFormat: &types.ResponseFormat{
+			Type:   "json_schema",
+			Name:   "grade",
+			Schema: judgeSchema,
+			Strict: types.Ptr(true),
+		},
+	})
+	if err != nil {
+		return Score{}, err
+	}
+
+	var grade struct {
+		Score  float64 `json:"score"`
+		Pass   bool    `json:"pass"`
+		Reason string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(verdict.Text()), &grade); err != nil {
+		return Score{}, fmt.Errorf("decoding grade: %w", err)
+	}
+	return Score{Scorer: j.Name(), Value: min(max(grade.Score, 0), 1), Pass: grade.Pass, Reason: grade.Reason}, nil
+}
+
+// lastUserText returns the text of the last user message.
+func lastUserText(messages []types.Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != types.RoleUser {
+			continue
+		}
+		var parts []string
+		for _, block := range messages[i].Content {
+			if block.Type == types.ContentTypeText {
+				parts = append(parts, block.Text)
+			}
+		}
+		return strings.Join(parts, "\n")
+	}
+	return ""
+}
@@ -0,0 +1,79 @@
+package eval
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func textResponse(text string) *types.CompletionResponse {
+	return &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: text}}}
+}
+
+func TestTextScorers(t *testing.T) {
+	tests := []struct {
+		scorer Scorer
+		text   string
+		pass   bool
+	}{
+		{Exact("Paris"), " Paris\n", true},
+		{Exact("Paris"), "Paris, France", false},
+		{Contains("paris"), "It is Paris.", true},
+		{Contains("Lyon"), "It is Paris.", false},
+		{Regex(regexp.MustCompile(`^\d+$`)), "42", true},
+		{Regex(regexp.MustCompile(`^\d+$`)), "forty-two", false},
+	}
+	for _, tt := range tests {
+		score, err := tt.scorer.Score(context.Background(), &Case{}, textResponse(tt.text))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if score.Pass != tt.pass || (score.Value == 1) != tt.pass || score.Scorer != tt.scorer.Name() {
+			t.Errorf("%s(%q) = %+v, want pass %v", tt.scorer.Name(), tt.text, score, tt.pass)
+		}
+	}
+}
+
+// judgeCompleter returns a fixed grade and records the judge's request.
+type judgeCompleter struct {
+	grade string
+	req   *types.CompletionRequest
+}
+
+func (c *judgeCompleter) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	c.req = req
+	return textResponse(c.grade), nil
+}
+
+func TestJudge(t *testing.T) {
+	client := &judgeCompleter{grade: `{"score": 0.8, "pass": true, "reason": "Correct and brief."}`}
+	judge := Judge(client, Target{Provider: types.ProviderAnthropic, Model: "claude-sonnet-4-5"}, "names the capital")
+	c := &Case{Request: prompt("What is the capital of France?")}
+
+	score, err := judge.Score(context.Background(), c, textResponse("Paris"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if score.Value != 0.8 || !score.Pass || score.Reason != "Correct and brief." || score.Scorer != "judge" {
+		t.Errorf("score = %+v", score)
+	}
+
+	req := client.req
+	if req.Provider != types.ProviderAnthropic || req.Model != "claude-sonnet-4-5" || req.ResponseFormat == nil {
+		t.Errorf("judge request = %+v", req)
+	}
+	prompt := lastUserText(req.Messages)
+	for _, want := range []string{"names the capital", "capital of France", "Paris"} {
+		if !strings.Contains(prompt, want) {
+			t.Errorf("judge prompt %q is missing %q", prompt, want)
+		}
+	}
+
+	client.grade = "not json"
+	if _, err := judge.Score(context.Background(), c, textResponse("Paris")); err == nil {
+		t.Error("expected an error for an unreadable grade")
+	}
+}
@@ -0,0 +1,174 @@
+// Package finetune provides a unified fine-tuning interface across providers.
+package finetune
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Request describes a fine-tuning job.
+type Request = provider.FineTuneRequest
+
+// Example is a single training conversation.
+type Example = provider.TrainingExample
+
+// Job represents a fine-tuning job.
+type Job = provider.FineTuneJob
+
+// Status represents the status of a fine-tuning job.
+type Status = provider.FineTuneStatus
+
+// Checkpoint is a snapshot recorded during training.
+type Checkpoint = provider.FineTuneCheckpoint
+
+// ListOptions configures job listing.
+type ListOptions = provider.ListFineTuneOptions
+
+const (
+	StatusPending   = provider.FineTuneStatusPending
+	StatusRunning   = provider.FineTuneStatusRunning
+	StatusSucceeded = provider.FineTuneStatusSucceeded
+	StatusFailed    = provider.FineTuneStatusFailed
+	StatusCancelled = provider.FineTuneStatusCancelled
+)
+
+// Manager provides a unified interface for fine-tuning across providers.
+// It is safe for concurrent use.
+type Manager struct {
+	mu        sync.RWMutex
+	providers map[types.Provider]provider.FineTuneProvider
+}
+
+// NewManager creates a new fine-tuning manager.
+func NewManager() *Manager {
+	return &Manager{
+		providers: make(map[types.Provider]provider.FineTuneProvider),
+	}
+}
+
+// RegisterProvider registers a fine-tuning provider, replacing any provider
+// previously registered under the same name.
+func (m *Manager) RegisterProvider(p provider.FineTuneProvider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.providers[p.Name()] = p
+}
+
+// UnregisterProvider removes a provider from the manager.
+func (m *Manager) UnregisterProvider(name types.Provider) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.providers, name)
+}
+
+// getProvider returns the fine-tuning provider registered under the given name.
+func (m *Manager) getProvider(name types.Provider) (provider.FineTuneProvider, error) {
+	m.mu.RLock()
+	p, ok := m.providers[name]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, errors.ErrProviderUnavailable(name, "provider not registered or does not support fine-tuning")
+	}
+	return p, nil
+}
+
+// UploadTrainingFile uploads examples as a training file and returns its ID,
+// for providers that train from files (OpenAI). The ID can be reused across
+// jobs via Request.TrainingFile.
+func (m *Manager) UploadTrainingFile(ctx context.Context, providerName types.Provider, examples []Example) (string, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return "", err
+	}
+
+	uploader, ok := p.(provider.TrainingFileUploader)
+	if !ok {
+		return "", errors.ErrUnsupportedFeature(providerName, "training file upload")
+	}
+	if len(examples) == 0 {
+		return "", errors.ErrInvalidRequest("training file must contain at least one example")
+	}
+
+	return uploader.UploadTrainingFile(ctx, examples)
+}
+
+// CreateJob starts a fine-tuning job.
+func (m *Manager) CreateJob(ctx context.Context, providerName types.Provider, req *Request) (*Job, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	if req == nil || req.Model == "" {
+		return nil, errors.ErrInvalidRequest("model is required")
+	}
+
+	return p.CreateFineTuneJob(ctx, req)
+}
+
+// GetJob retrieves the status of a fine-tuning job.
+func (m *Manager) GetJob(ctx context.Context, providerName types.Provider, jobID string) (*Job, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.GetFineTuneJob(ctx, jobID)
+}
+
+// ListJobs lists fine-tuning jobs for a provider.
+func (m *Manager) ListJobs(ctx context.Context, providerName types.Provider, opts *ListOptions) ([]Job, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ListFineTuneJobs(ctx, opts)
+}
+
+// Cancel stops a running fine-tuning job.
+func (m *Manager) Cancel(ctx context.Context, providerName types.Provider, jobID string) error {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return err
+	}
+
+	return p.CancelFineTuneJob(ctx, jobID)
+}
+
+// ListCheckpoints lists the checkpoints recorded for a job.
+func (m *Manager) ListCheckpoints(ctx context.Context, providerName types.Provider, jobID string) ([]Checkpoint, error) {
+	p, err := m.getProvider(providerName)
+	if err != nil {
+		return nil, err
+	}
+
+	return p.ListFineTuneCheckpoints(ctx, jobID)
+}
+
+// Wait waits for a job to finish, polling at the specified interval.
+func (m *Manager) Wait(ctx context.Context, providerName types.Provider, jobID string, pollInterval time.Duration) (*Job, error) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		job, err := m.GetJob(ctx, providerName, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if job.Status.IsDone() {
+			return job, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
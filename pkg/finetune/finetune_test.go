@@ -0,0 +1,133 @@
+package finetune
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// fakeProvider runs jobs that succeed after three polls and records the
+// jobs it cancelled and the files it was asked to upload.
+type fakeProvider struct {
+	mu        sync.Mutex
+	polls     int
+	cancelled []string
+	uploaded  int
+}
+
+func (f *fakeProvider) Name() types.Provider { return types.ProviderOpenAI }
+func (f *fakeProvider) Complete(context.Context, *types.CompletionRequest) (*types.CompletionResponse, error) {
+	return nil, nil
+}
+func (f *fakeProvider) Stream(context.Context, *types.CompletionRequest) (types.StreamReader, error) {
+	return nil, nil
+}
+func (f *fakeProvider) SupportsFeature(types.Feature) bool { return true }
+func (f *fakeProvider) Models() []string                   { return nil }
+
+func (f *fakeProvider) CreateFineTuneJob(_ context.Context, req *Request) (*Job, error) {
+	return &Job{ID: "ftjob_1", Provider: types.ProviderOpenAI, Model: req.Model, Status: StatusPending}, nil
+}
+
+func (f *fakeProvider) GetFineTuneJob(_ context.Context, jobID string) (*Job, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.polls++
+	job := &Job{ID: jobID, Provider: types.ProviderOpenAI, Status: StatusRunning}
+	if f.polls >= 3 {
+		job.Status = StatusSucceeded
+		job.FineTunedModel = "ft:gpt-4o-mini:org::1"
+	}
+	return job, nil
+}
+
+func (f *fakeProvider) ListFineTuneJobs(context.Context, *ListOptions) ([]Job, error) {
+	return []Job{{ID: "ftjob_1"}}, nil
+}
+
+func (f *fakeProvider) CancelFineTuneJob(_ context.Context, jobID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.cancelled = append(f.cancelled, jobID)
+	return nil
+}
+
+func (f *fakeProvider) ListFineTuneCheckpoints(context.Context, string) ([]Checkpoint, error) {
+	return []Checkpoint{{Step: 10}}, nil
+}
+
+func (f *fakeProvider) UploadTrainingFile(_ context.Context, examples []Example) (string, error) {
+	f.uploaded += len(examples)
+	return "file_1", nil
+}
+
+func TestManager(t *testing.T) {
+	fake := &fakeProvider{}
+	m := NewManager()
+	m.RegisterProvider(fake)
+	ctx := context.Background()
+
+	if _, err := m.CreateJob(ctx, types.ProviderOpenAI, &Request{}); !stderrors.Is(err, errors.NewError(errors.ErrCodeInvalidRequest, "")) {
+		t.Errorf("err = %v, want invalid request without a model", err)
+	}
+	job, err := m.CreateJob(ctx, types.ProviderOpenAI, &Request{Model: "gpt-4o-mini"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := m.Wait(ctx, types.ProviderOpenAI, job.ID, time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != StatusSucceeded || done.FineTunedModel == "" || fake.polls != 3 {
+		t.Errorf("job = %+v after %d polls, want success after 3", done, fake.polls)
+	}
+
+	if err := m.Cancel(ctx, types.ProviderOpenAI, job.ID); err != nil || len(fake.cancelled) != 1 {
+		t.Errorf("cancel: err = %v, cancelled = %v", err, fake.cancelled)
+	}
+	if jobs, err := m.ListJobs(ctx, types.ProviderOpenAI, nil); err != nil || len(jobs) != 1 {
+		t.Errorf("ListJobs = %v, %v", jobs, err)
+	}
+	if checkpoints, err := m.ListCheckpoints(ctx, types.ProviderOpenAI, job.ID); err != nil || len(checkpoints) != 1 {
+		t.Errorf("ListCheckpoints = %v, %v", checkpoints, err)
+	}
+
+	examples := []Example{{Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")}}}
+	if id, err := m.UploadTrainingFile(ctx, types.ProviderOpenAI, examples); err != nil || id != "file_1" || fake.uploaded != 1 {
+		t.Errorf("UploadTrainingFile = %q, %v", id, err)
+	}
+	if _, err := m.UploadTrainingFile(ctx, types.ProviderOpenAI, nil); err == nil {
+		t.Error("expected an error uploading no examples")
+	}
+}
+
+func TestManager_Unregistered(t *testing.T) {
+	m := NewManager()
+	m.RegisterProvider(&fakeProvider{})
+	m.UnregisterProvider(types.ProviderOpenAI)
+
+	_, err := m.GetJob(context.Background(), types.ProviderOpenAI, "ftjob_1")
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeProviderUnavailable, "")) {
+		t.Errorf("err = %v, want provider unavailable", err)
+	}
+}
+
+func TestManager_WaitCancelled(t *testing.T) {
+	m := NewManager()
+	m.RegisterProvider(&fakeProvider{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := m.Wait(ctx, types.ProviderOpenAI, "ftjob_1", time.Hour); err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+}
+
+var _ provider.FineTuneProvider = (*fakeProvider)(nil)
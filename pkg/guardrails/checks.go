@@ -0,0 +1,254 @@
+package guardrails
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// PIIKind is a category of personal data matched by RedactPII.
+type PIIKind string
+
+const (
+	PIIEmail      PIIKind = "EMAIL"
+	PIIPhone      PIIKind = "PHONE"
+	PIISSN        PIIKind = "SSN"
+	PIICreditCard PIIKind = "CREDIT_CARD"
+)
+
+var piiPatterns = map[PIIKind]*regexp.Regexp{
+	PIIEmail:      regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	PIIPhone:      regexp.MustCompile(`(?:\+?\d{1,3}[ .-]?)?\(?\d{3}\)?[ .-]?\d{3}[ .-]?\d{4}\b`),
+	PIISSN:        regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	PIICreditCard: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+}
+
+// piiOrder applies the more specific patterns first.
+var piiOrder = []PIIKind{PIIEmail, PIISSN, PIICreditCard, PIIPhone}
+
+type piiRedactor struct {
+	kinds []PIIKind
+}
+
+// RedactPII replaces personal data in request text, response text, and
+// stream deltas with a placeholder such as "[EMAIL]". With no kinds it
+// redacts all of them. Matching is regex based, and in streams only within a
+// single delta.
+func RedactPII(kinds ...PIIKind) Guard {
+	if len(kinds) == 0 {
+		return &piiRedactor{kinds: piiOrder}
+	}
+	var ordered []PIIKind
+	for _, k := range piiOrder {
+		for _, want := range kinds {
+			if k == want {
+				ordered = append(ordered, k)
+				break
+			}
+		}
+	}
+	return &piiRedactor{kinds: ordered}
+}
+
+func (g *piiRedactor) Name() string { return "pii" }
+
+func (g *piiRedactor) redact(text string) string {
+	for _, k := range g.kinds {
+		text = piiPatterns[k].ReplaceAllString(text, "["+string(k)+"]")
+	}
+	return text
+}
+
+func (g *piiRedactor) CheckRequest(_ context.Context, req *types.CompletionRequest) error {
+	for i := range req.Messages {
+		redactBlocks(req.Messages[i].Content, g.redact)
+	}
+	return nil
+}
+
+func (g *piiRedactor) CheckResponse(_ context.Context, resp *types.CompletionResponse) error {
+	redactBlocks(resp.Content, g.redact)
+	return nil
+}
+
+func (g *piiRedactor) CheckDelta(_ context.Context, delta *types.ContentBlock, _ string) error {
+	delta.Text = g.redact(delta.Text)
+	return nil
+}
+
+// redactBlocks rewrites the text of text and tool result blocks.
+func redactBlocks(blocks []types.ContentBlock, redact func(string) string) {
+	for i := range blocks {
+		switch blocks[i].Type {
+		case types.ContentTypeText, types.ContentTypeToolResult:
+			blocks[i].Text = redact(blocks[i].Text)
+		}
+	}
+}
+
+type maxLength struct {
+	input, output int
+}
+
+// MaxLength rejects requests whose text exceeds input characters and
+// responses or streams whose text exceeds output characters. Zero disables a
+// limit.
+func MaxLength(input, output int) Guard {
+	return &maxLength{input: input, output: output}
+}
+
+func (g *maxLength) Name() string { return "max_length" }
+
+func (g *maxLength) CheckRequest(_ context.Context, req *types.CompletionRequest) error {
+	if g.input <= 0 {
+		return nil
+	}
+	if n := len(requestText(req)); n > g.input {
+		return Reject("input is %d characters, limit is %d", n, g.input)
+	}
+	return nil
+}
+
+func (g *maxLength) CheckResponse(_ context.Context, resp *types.CompletionResponse) error {
+	if g.output > 0 && len(resp.Text()) > g.output {
+		return Reject("output exceeds %d characters", g.output)
+	}
+	return nil
+}
+
+func (g *maxLength) CheckDelta(_ context.Context, _ *types.ContentBlock, text string) error {
+	if g.output > 0 && len(text) > g.output {
+		return Reject("output exceeds %d characters", g.output)
+	}
+	return nil
+}
+
+// Moderator classifies text, returning the categories it is flagged for.
+type Moderator interface {
+	Moderate(ctx context.Context, text string) ([]string, error)
+}
+
+// ModeratorFunc adapts a function to the Moderator interface.
+type ModeratorFunc func(ctx context.Context, text string) ([]string, error)
+
+// Moderate calls f(ctx, text).
+func (f ModeratorFunc) Moderate(ctx context.Context, text string) ([]string, error) {
+	return f(ctx, text)
+}
+
+// OpenAIModerator classifies text with the OpenAI moderations endpoint.
+func OpenAIModerator(c *openai.Client) Moderator {
+	return ModeratorFunc(func(ctx context.Context, text string) ([]string, error) {
+		result, err := c.Moderate(ctx, text)
+		if err != nil {
+			return nil, err
+		}
+		var flagged []string
+		for category, ok := range result.Categories {
+			if ok {
+				flagged = append(flagged, category)
+			}
+		}
+		sort.Strings(flagged)
+		return flagged, nil
+	})
+}
+
+type moderation struct {
+	moderator Moderator
+	blocked   map[string]bool
+}
+
+// Moderation rejects requests and responses that the moderator flags for any
+// of the blocked categories, or for any category if none are given. Streams
+// are checked once complete, via Response.
+func Moderation(m Moderator, blocked ...string) Guard {
+	g := &moderation{moderator: m}
+	if len(blocked) > 0 {
+		g.blocked = make(map[string]bool, len(blocked))
+		for _, c := range blocked {
+			g.blocked[c] = true
+		}
+	}
+	return g
+}
+
+func (g *moderation) Name() string { return "moderation" }
+
+func (g *moderation) CheckRequest(ctx context.Context, req *types.CompletionRequest) error {
+	return g.check(ctx, requestText(req))
+}
+
+func (g *moderation) CheckResponse(ctx context.Context, resp *types.CompletionResponse) error {
+	return g.check(ctx, resp.Text())
+}
+
+func (g *moderation) check(ctx context.Context, text string) error {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+	categories, err := g.moderator.Moderate(ctx, text)
+	if err != nil {
+		return err
+	}
+	for _, c := range categories {
+		if g.blocked == nil || g.blocked[c] {
+			return Reject("content flagged for %s", c)
+		}
+	}
+	return nil
+}
+
+type requestFunc struct {
+	name string
+	fn   func(ctx context.Context, req *types.CompletionRequest) error
+}
+
+// RequestFunc returns a request guard that runs fn. fn may modify the
+// request, or reject it with Reject.
+func RequestFunc(name string, fn func(ctx context.Context, req *types.CompletionRequest) error) Guard {
+	return &requestFunc{name: name, fn: fn}
+}
+
+func (g *requestFunc) Name() string { return g.name }
+
+func (g *requestFunc) CheckRequest(ctx context.Context, req *types.CompletionRequest) error {
+	return g.fn(ctx, req)
+}
+
+type responseFunc struct {
+	name string
+	fn   func(ctx context.Context, resp *types.CompletionResponse) error
+}
+
+// ResponseFunc returns a response guard that runs fn. fn may modify the
+// response, or reject it with Reject.
+func ResponseFunc(name string, fn func(ctx context.Context, resp *types.CompletionResponse) error) Guard {
+	return &responseFunc{name: name, fn: fn}
+}
+
+func (g *responseFunc) Name() string { return g.name }
+
+func (g *responseFunc) CheckResponse(ctx context.Context, resp *types.CompletionResponse) error {
+	return g.fn(ctx, resp)
+}
+
+// requestText joins the text of a request's messages.
+func requestText(req *types.CompletionRequest) string {
+	var b strings.Builder
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			if block.Type == types.ContentTypeText || block.Type == types.ContentTypeToolResult {
+				if b.Len() > 0 {
+					b.WriteByte('\n')
+				}
+				b.WriteString(block.Text)
+			}
+		}
+	}
+	return b.String()
+}
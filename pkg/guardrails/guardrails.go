@@ -0,0 +1,175 @@
+// Package guardrails filters and rewrites completion traffic.
+//
+// A Pipeline runs guards at three hook points: before a request is sent,
+// after a response is received, and on each streamed text delta. A guard
+// implements any of RequestGuard, ResponseGuard, and StreamGuard; it may
+// rewrite what it is given in place or reject it by returning an error.
+//
+//	p := guardrails.New(
+//		guardrails.RedactPII(),
+//		guardrails.MaxLength(20000, 0),
+//	)
+//	r, err := router.New(router.WithOpenAI(key), router.WithGuardrails(p))
+package guardrails
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Guard is a named check. It implements one or more of RequestGuard,
+// ResponseGuard, and StreamGuard.
+type Guard interface {
+	Name() string
+}
+
+// RequestGuard checks a request before it is sent. The request is a copy
+// owned by the pipeline and may be modified.
+type RequestGuard interface {
+	Guard
+	CheckRequest(ctx context.Context, req *types.CompletionRequest) error
+}
+
+// ResponseGuard checks a complete response and may modify it.
+type ResponseGuard interface {
+	Guard
+	CheckResponse(ctx context.Context, resp *types.CompletionResponse) error
+}
+
+// StreamGuard checks each text delta of a stream. text is the text streamed
+// so far, including the delta. The guard may modify the delta.
+type StreamGuard interface {
+	Guard
+	CheckDelta(ctx context.Context, delta *types.ContentBlock, text string) error
+}
+
+// Pipeline runs guards in order. A nil Pipeline passes everything through.
+type Pipeline struct {
+	guards []Guard
+}
+
+// New creates a pipeline that runs the guards in order.
+func New(guards ...Guard) *Pipeline {
+	return &Pipeline{guards: guards}
+}
+
+// Reject returns an error that rejects the checked content with a reason.
+func Reject(format string, args ...any) error {
+	return &rejection{reason: fmt.Sprintf(format, args...)}
+}
+
+type rejection struct {
+	reason string
+}
+
+func (e *rejection) Error() string { return e.reason }
+
+// Request runs the request guards on a copy of req, so the caller's messages
+// are never modified, and returns the copy.
+func (p *Pipeline) Request(ctx context.Context, req *types.CompletionRequest) (*types.CompletionRequest, error) {
+	if p == nil {
+		return req, nil
+	}
+
+	checked := *req
+	checked.Messages = make([]types.Message, len(req.Messages))
+	for i, msg := range req.Messages {
+		msg.Content = append([]types.ContentBlock(nil), msg.Content...)
+		checked.Messages[i] = msg
+	}
+
+	for _, g := range p.guards {
+		if rg, ok := g.(RequestGuard); ok {
+			if err := rg.CheckRequest(ctx, &checked); err != nil {
+				return nil, violation(g, err)
+			}
+		}
+	}
+	return &checked, nil
+}
+
+// Response runs the response guards on resp.
+func (p *Pipeline) Response(ctx context.Context, resp *types.CompletionResponse) error {
+	if p == nil {
+		return nil
+	}
+	for _, g := range p.guards {
+		if rg, ok := g.(ResponseGuard); ok {
+			if err := rg.CheckResponse(ctx, resp); err != nil {
+				return violation(g, err)
+			}
+		}
+	}
+	return nil
+}
+
+// Stream wraps a stream so the stream guards see each text delta and the
+// response guards see the accumulated response.
+func (p *Pipeline) Stream(ctx context.Context, stream types.StreamReader) types.StreamReader {
+	if p == nil {
+		return stream
+	}
+	return &guardedStream{StreamReader: stream, ctx: ctx, pipeline: p}
+}
+
+// violation converts a guard's error into a router error. Rejections get the
+// guardrail code; other errors are returned unchanged.
+func violation(g Guard, err error) error {
+	var r *rejection
+	if !stderrors.As(err, &r) {
+		return err
+	}
+	return errors.ErrGuardrail(g.Name(), r.reason)
+}
+
+// guardedStream applies a pipeline to a stream.
+type guardedStream struct {
+	types.StreamReader
+	ctx      context.Context
+	pipeline *Pipeline
+	text     strings.Builder
+
+	response *types.CompletionResponse
+	checked  bool
+}
+
+func (s *guardedStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	if err != nil || event == nil {
+		return event, err
+	}
+	if event.Type != types.StreamEventContentDelta || event.Delta == nil || event.Delta.Type != types.ContentTypeText {
+		return event, nil
+	}
+
+	s.text.WriteString(event.Delta.Text)
+	for _, g := range s.pipeline.guards {
+		if sg, ok := g.(StreamGuard); ok {
+			if err := sg.CheckDelta(s.ctx, event.Delta, s.text.String()); err != nil {
+				return nil, violation(g, err)
+			}
+		}
+	}
+	return event, nil
+}
+
+// Response returns the accumulated response after the response guards have
+// run on it, or nil if they rejected it.
+func (s *guardedStream) Response() *types.CompletionResponse {
+	if !s.checked {
+		resp := s.StreamReader.Response()
+		if resp == nil {
+			return nil
+		}
+		s.checked = true
+		if s.pipeline.Response(s.ctx, resp) == nil {
+			s.response = resp
+		}
+	}
+	return s.response
+}
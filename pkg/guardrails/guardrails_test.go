@@ -0,0 +1,108 @@
+package guardrails
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestRequest_RedactsCopy(t *testing.T) {
+	req := &types.CompletionRequest{Messages: []types.Message{
+		types.NewTextMessage(types.RoleUser, "Mail jane.doe@example.com or call 555-123-4567, SSN 123-45-6789, card 4111 1111 1111 1111."),
+	}}
+
+	checked, err := New(RedactPII()).Request(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "Mail [EMAIL] or call [PHONE], SSN [SSN], card [CREDIT_CARD]."
+	if got := checked.Messages[0].Content[0].Text; got != want {
+		t.Errorf("redacted = %q, want %q", got, want)
+	}
+	if strings.Contains(checked.Messages[0].Content[0].Text, "@") || !strings.Contains(req.Messages[0].Content[0].Text, "jane.doe@example.com") {
+		t.Error("guard modified the caller's request")
+	}
+}
+
+func TestRequest_Rejects(t *testing.T) {
+	p := New(
+		MaxLength(10, 0),
+		RequestFunc("never", func(context.Context, *types.CompletionRequest) error {
+			t.Error("guard ran after a rejection")
+			return nil
+		}),
+	)
+
+	_, err := p.Request(context.Background(), &types.CompletionRequest{Messages: []types.Message{
+		types.NewTextMessage(types.RoleUser, "this is too long"),
+	}})
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeGuardrail || rerr.Details["guardrail"] != "max_length" {
+		t.Fatalf("err = %v, want max_length guardrail violation", err)
+	}
+}
+
+func TestModeration(t *testing.T) {
+	m := ModeratorFunc(func(_ context.Context, text string) ([]string, error) {
+		if strings.Contains(text, "attack") {
+			return []string{"violence"}, nil
+		}
+		if strings.Contains(text, "buy") {
+			return []string{"commercial"}, nil
+		}
+		return nil, nil
+	})
+	p := New(Moderation(m, "violence"))
+
+	resp := &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: "plan the attack"}}}
+	if err := p.Response(context.Background(), resp); !stderrors.Is(err, errors.NewError(errors.ErrCodeGuardrail, "")) {
+		t.Errorf("err = %v, want guardrail violation", err)
+	}
+
+	resp.Content[0].Text = "buy now"
+	if err := p.Response(context.Background(), resp); err != nil {
+		t.Errorf("unblocked category rejected: %v", err)
+	}
+}
+
+type deltaStream struct {
+	deltas []string
+}
+
+func (s *deltaStream) Next() (*types.StreamEvent, error) {
+	if len(s.deltas) == 0 {
+		return nil, nil
+	}
+	text := s.deltas[0]
+	s.deltas = s.deltas[1:]
+	return &types.StreamEvent{Type: types.StreamEventContentDelta, Delta: &types.ContentBlock{Type: types.ContentTypeText, Text: text}}, nil
+}
+func (s *deltaStream) Close() error                        { return nil }
+func (s *deltaStream) Response() *types.CompletionResponse { return nil }
+
+func TestStream(t *testing.T) {
+	p := New(RedactPII(PIIEmail), MaxLength(0, 40))
+	stream := p.Stream(context.Background(), &deltaStream{deltas: []string{"write to a@b.io ", "then wait ", "and then keep going far too long"}})
+
+	var out []string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			if !stderrors.Is(err, errors.NewError(errors.ErrCodeGuardrail, "")) {
+				t.Fatalf("err = %v, want guardrail violation", err)
+			}
+			break
+		}
+		if event == nil {
+			t.Fatal("stream finished without exceeding the output limit")
+		}
+		out = append(out, event.Delta.Text)
+	}
+	if got := strings.Join(out, ""); got != "write to [EMAIL] then wait " {
+		t.Errorf("streamed %q", got)
+	}
+}
@@ -0,0 +1,300 @@
+// Package history truncates conversations to fit a token limit.
+//
+// Policies drop or shrink whole turns: a user message and the messages that
+// answer it, up to the next user message. An assistant's tool calls and
+// their results are therefore always kept or dropped together, system
+// messages are always kept, and so is the last turn. Truncate applies a
+// policy to a message list; Fit truncates a request to its model's context
+// window from the models catalog:
+//
+//	req, err := history.Fit(req, history.Chain(
+//		history.ElideToolResults(""),
+//		history.SlidingWindow(),
+//	))
+package history
+
+import (
+	"fmt"
+	"slices"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Policy shortens a conversation. Truncate returns messages cut down to
+// limit tokens, as measured by count, or as far as the policy can. It must
+// not modify messages.
+type Policy interface {
+	Truncate(messages []types.Message, limit int, count Counter) []types.Message
+}
+
+// Counter returns the tokens of messages.
+type Counter func(messages []types.Message) int
+
+// EstimateTokens estimates the tokens of messages as
+// router.EstimateTokenBreakdown does.
+func EstimateTokens(messages []types.Message) int {
+	return router.EstimateTokenBreakdown(&types.CompletionRequest{Messages: messages}).Total
+}
+
+// Truncate returns messages cut down by policy to at most limit tokens, as
+// estimated by EstimateTokens. It fails with an ErrCodeContextLength error
+// if the policy cannot make them fit. messages is not modified.
+func Truncate(messages []types.Message, limit int, policy Policy) ([]types.Message, error) {
+	return truncate(messages, limit, policy, "")
+}
+
+// truncate implements Truncate, reporting errors for provider.
+func truncate(messages []types.Message, limit int, policy Policy, provider types.Provider) ([]types.Message, error) {
+	if EstimateTokens(messages) <= limit {
+		return messages, nil
+	}
+	out := policy.Truncate(messages, limit, EstimateTokens)
+	if n := EstimateTokens(out); n > limit {
+		return nil, errors.ErrContextLength(provider, fmt.Sprintf("conversation needs %d tokens after truncation, over the limit of %d", n, limit))
+	}
+	return out, nil
+}
+
+// Fit returns req with its messages truncated by policy to fit the model's
+// context window from the models catalog, leaving room for the tools, the
+// documents, and MaxTokens of output. It returns req itself if it fits. It
+// fails with an ErrCodeInvalidRequest error for models the catalog does not
+// know, and an ErrCodeContextLength error if the policy cannot make the
+// messages fit. req is not modified.
+func Fit(req *types.CompletionRequest, policy Policy) (*types.CompletionRequest, error) {
+	info, ok := models.Lookup(req.Provider, req.Model)
+	if !ok || info.ContextWindow == 0 {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("no context window known for model %s", req.Model)).WithProvider(req.Provider)
+	}
+	b := router.EstimateTokenBreakdown(req)
+	limit := info.ContextWindow - b.Tools - b.Documents
+	if req.MaxTokens != nil {
+		limit -= *req.MaxTokens
+	}
+
+	if EstimateTokens(req.Messages) <= limit {
+		return req, nil
+	}
+	messages, err := truncate(req.Messages, limit, policy, req.Provider)
+	if err != nil {
+		return nil, err
+	}
+	clone := *req
+	clone.Messages = messages
+	return &clone, nil
+}
+
+// turn is a span of messages that is kept or dropped as a whole.
+type turn struct {
+	start, end int
+	system     bool
+}
+
+// turns splits messages into system messages and turns. A turn starts at a
+// user message that is not a tool result and runs to the next one.
+func turns(messages []types.Message) []turn {
+	var out []turn
+	for i, msg := range messages {
+		switch {
+		case msg.Role == types.RoleSystem:
+			out = append(out, turn{start: i, end: i + 1, system: true})
+		case len(out) == 0 || out[len(out)-1].system || startsTurn(msg):
+			out = append(out, turn{start: i, end: i + 1})
+		default:
+			out[len(out)-1].end = i + 1
+		}
+	}
+	return out
+}
+
+// startsTurn reports whether msg is a user message other than tool results.
+func startsTurn(msg types.Message) bool {
+	if msg.Role != types.RoleUser {
+		return false
+	}
+	for _, block := range msg.Content {
+		if block.Type == types.ContentTypeToolResult {
+			return false
+		}
+	}
+	return true
+}
+
+// lastTurn returns the index of the last turn that is not a system message,
+// or -1.
+func lastTurn(ts []turn) int {
+	for i := len(ts) - 1; i >= 0; i-- {
+		if !ts[i].system {
+			return i
+		}
+	}
+	return -1
+}
+
+// keep returns the messages of the turns not dropped.
+func keep(messages []types.Message, ts []turn, dropped []bool) []types.Message {
+	var out []types.Message
+	for i, t := range ts {
+		if !dropped[i] {
+			out = append(out, messages[t.start:t.end]...)
+		}
+	}
+	return out
+}
+
+type slidingWindow struct{}
+
+// SlidingWindow drops the oldest turns until the conversation fits.
+func SlidingWindow() Policy {
+	return slidingWindow{}
+}
+
+func (slidingWindow) Truncate(messages []types.Message, limit int, count Counter) []types.Message {
+	ts := turns(messages)
+	order := make([]int, 0, len(ts))
+	for i := range ts {
+		order = append(order, i)
+	}
+	return dropInOrder(messages, ts, order, limit, count)
+}
+
+// dropInOrder drops turns in order, skipping system messages and the last
+// turn, until the messages fit.
+func dropInOrder(messages []types.Message, ts []turn, order []int, limit int, count Counter) []types.Message {
+	last := lastTurn(ts)
+	dropped := make([]bool, len(ts))
+	out := messages
+	for _, i := range order {
+		if count(out) <= limit {
+			break
+		}
+		if ts[i].system || i == last {
+			continue
+		}
+		dropped[i] = true
+		out = keep(messages, ts, dropped)
+	}
+	return out
+}
+
+// ImportanceFunc scores a turn; lower scores are dropped first. index is
+// the turn's position among the n turns of the conversation, oldest first.
+type ImportanceFunc func(turn []types.Message, index, n int) float64
+
+type importance struct {
+	score ImportanceFunc
+}
+
+// Importance drops the turns with the lowest scores until the conversation
+// fits, oldest first among equal scores. Use it to keep turns that pinned
+// facts or decisions were made in.
+func Importance(score ImportanceFunc) Policy {
+	return importance{score: score}
+}
+
+func (p importance) Truncate(messages []types.Message, limit int, count Counter) []types.Message {
+	ts := turns(messages)
+	var conversation []int
+	for i, t := range ts {
+		if !t.system {
+			conversation = append(conversation, i)
+		}
+	}
+	scores := make(map[int]float64, len(conversation))
+	for k, i := range conversation {
+		scores[i] = p.score(messages[ts[i].start:ts[i].end], k, len(conversation))
+	}
+	order := slices.Clone(conversation)
+	slices.SortStableFunc(order, func(a, b int) int {
+		switch {
+		case scores[a] < scores[b]:
+			return -1
+		case scores[a] > scores[b]:
+			return 1
+		}
+		return 0
+	})
+	return dropInOrder(messages, ts, order, limit, count)
+}
+
+// DefaultElidedResult replaces the content of elided tool results.
+const DefaultElidedResult = "[tool result removed to save space]"
+
+type elideToolResults struct {
+	placeholder string
+}
+
+// ElideToolResults replaces the content of tool results with placeholder,
+// oldest turn first, until the conversation fits. The calls and results
+// stay paired, so the model still sees which tools it called. An empty
+// placeholder uses DefaultElidedResult. The last turn is left intact.
+func ElideToolResults(placeholder string) Policy {
+	if placeholder == "" {
+		placeholder = DefaultElidedResult
+	}
+	return elideToolResults{placeholder: placeholder}
+}
+
+func (p elideToolResults) Truncate(messages []types.Message, limit int, count Counter) []types.Message {
+	ts := turns(messages)
+	last := lastTurn(ts)
+	out := messages
+	cloned := false
+	for i, t := range ts {
+		if count(out) <= limit {
+			break
+		}
+		if t.system || i == last {
+			continue
+		}
+		for j := t.start; j < t.end; j++ {
+			if msg, ok := p.elide(messages[j]); ok {
+				if !cloned {
+					out = slices.Clone(messages)
+					cloned = true
+				}
+				out[j] = msg
+			}
+		}
+	}
+	return out
+}
+
+// elide returns msg with its tool results replaced by the placeholder, and
+// whether it had any.
+func (p elideToolResults) elide(msg types.Message) (types.Message, bool) {
+	elided := false
+	content := slices.Clone(msg.Content)
+	for k, block := range content {
+		if block.Type != types.ContentTypeToolResult {
+			continue
+		}
+		block.Text = p.placeholder
+		block.ToolResultContent = nil
+		content[k] = block
+		elided = true
+	}
+	msg.Content = content
+	return msg, elided
+}
+
+type chain []Policy
+
+// Chain applies policies in order until the conversation fits, such as
+// eliding tool results before dropping turns.
+func Chain(policies ...Policy) Policy {
+	return chain(policies)
+}
+
+func (c chain) Truncate(messages []types.Message, limit int, count Counter) []types.Message {
+	for _, p := range c {
+		if count(messages) <= limit {
+			break
+		}
+		messages = p.Truncate(messages, limit, count)
+	}
+	return messages
+}
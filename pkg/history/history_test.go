@@ -0,0 +1,192 @@
+package history
+
+import (
+	stderrors "errors"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// conversation has a system prompt, a plain turn, a turn with a tool call
+// and a large result, a pinned turn, and a last question.
+func conversation() []types.Message {
+	big := strings.Repeat("data ", 400)
+	return []types.Message{
+		types.NewTextMessage(types.RoleSystem, "Be brief."),
+		types.NewTextMessage(types.RoleUser, "Hi"),
+		types.NewTextMessage(types.RoleAssistant, "Hello!"),
+		types.NewTextMessage(types.RoleUser, "Fetch the data"),
+		{Role: types.RoleAssistant, Content: []types.ContentBlock{{Type: types.ContentTypeToolUse, ToolUseID: "t1", ToolName: "fetch", ToolInput: map[string]any{}}}},
+		types.NewToolResultMessage("t1", big, false),
+		types.NewTextMessage(types.RoleAssistant, "Fetched."),
+		types.NewTextMessage(types.RoleUser, "PINNED: the budget is $5"),
+		types.NewTextMessage(types.RoleAssistant, "Noted."),
+		types.NewTextMessage(types.RoleUser, "Summarize"),
+	}
+}
+
+func texts(messages []types.Message) []string {
+	var out []string
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			switch block.Type {
+			case types.ContentTypeToolUse:
+				out = append(out, "call:"+block.ToolUseID)
+			case types.ContentTypeToolResult:
+				out = append(out, "result:"+block.ToolResultID)
+			default:
+				out = append(out, block.Text)
+			}
+		}
+	}
+	return out
+}
+
+func TestTurns(t *testing.T) {
+	ts := turns(conversation())
+	want := []turn{{0, 1, true}, {1, 3, false}, {3, 7, false}, {7, 9, false}, {9, 10, false}}
+	if len(ts) != len(want) {
+		t.Fatalf("turns = %v, want %v", ts, want)
+	}
+	for i := range want {
+		if ts[i] != want[i] {
+			t.Errorf("turn %d = %v, want %v", i, ts[i], want[i])
+		}
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	messages := conversation()
+	out, err := Truncate(messages, 100, SlidingWindow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(texts(out), "|")
+	if got != "Be brief.|PINNED: the budget is $5|Noted.|Summarize" {
+		t.Errorf("messages = %s", got)
+	}
+	if len(messages) != 10 || texts(messages)[1] != "Hi" {
+		t.Error("the input was modified")
+	}
+}
+
+func TestSlidingWindow_KeepsToolPairs(t *testing.T) {
+	// Only the tool turn's own size matters: dropping it removes the call
+	// and its result together.
+	out, err := Truncate(conversation(), EstimateTokens(conversation())-1, SlidingWindow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(texts(out), "|")
+	if got != "Be brief.|Fetch the data|call:t1|result:t1|Fetched.|PINNED: the budget is $5|Noted.|Summarize" {
+		t.Errorf("messages = %s", got)
+	}
+
+	out, err = Truncate(conversation(), 100, SlidingWindow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range texts(out) {
+		if strings.HasPrefix(s, "call:") || strings.HasPrefix(s, "result:") {
+			t.Errorf("half of a tool pair survived: %v", texts(out))
+		}
+	}
+}
+
+func TestImportance(t *testing.T) {
+	pinned := func(turn []types.Message, index, n int) float64 {
+		if strings.HasPrefix(turn[0].Content[0].Text, "PINNED") {
+			return 1
+		}
+		return 0
+	}
+	out, err := Truncate(conversation(), 100, Importance(pinned))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(texts(out), "|")
+	if got != "Be brief.|PINNED: the budget is $5|Noted.|Summarize" {
+		t.Errorf("messages = %s", got)
+	}
+
+	// Scoring older turns higher drops the newest turns first, but never
+	// the last.
+	oldestFirst := func(turn []types.Message, index, n int) float64 { return float64(n - index) }
+	out, err = Truncate(conversation(), 100, Importance(oldestFirst))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.Join(texts(out), "|"); got != "Be brief.|Hi|Hello!|Summarize" {
+		t.Errorf("messages = %s", got)
+	}
+}
+
+func TestElideToolResults(t *testing.T) {
+	out, err := Truncate(conversation(), 100, ElideToolResults(""))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 10 {
+		t.Fatalf("got %d messages, want all 10", len(out))
+	}
+	if got := out[5].Content[0]; got.ToolResultID != "t1" || got.Text != DefaultElidedResult {
+		t.Errorf("tool result = %+v", got)
+	}
+	if conversation()[5].Content[0].Text == DefaultElidedResult {
+		t.Error("the input was modified")
+	}
+}
+
+func TestChain(t *testing.T) {
+	// Eliding the tool result is not enough on its own, so the first turn
+	// goes too, and the elided tool turn stays.
+	out, err := Truncate(conversation(), 70, Chain(ElideToolResults(""), SlidingWindow()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := strings.Join(texts(out), "|")
+	if got != "Be brief.|Fetch the data|call:t1|result:t1|Fetched.|PINNED: the budget is $5|Noted.|Summarize" {
+		t.Errorf("messages = %s", got)
+	}
+	if got := out[3].Content[0].Text; got != DefaultElidedResult {
+		t.Errorf("tool result = %q, want it elided", got)
+	}
+	if EstimateTokens(out) > 70 {
+		t.Errorf("%d tokens left, over the limit", EstimateTokens(out))
+	}
+}
+
+func TestTruncate_DoesNotFit(t *testing.T) {
+	_, err := Truncate(conversation(), 5, SlidingWindow())
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeContextLength, "")) {
+		t.Errorf("err = %v, want context length exceeded", err)
+	}
+}
+
+func TestFit(t *testing.T) {
+	long := []types.Message{types.NewTextMessage(types.RoleUser, strings.Repeat("word ", 120000))}
+	long = append(long, types.NewTextMessage(types.RoleAssistant, "ok"), types.NewTextMessage(types.RoleUser, "And now?"))
+	req := &types.CompletionRequest{Provider: types.ProviderOpenAI, Model: "gpt-4o", Messages: long, MaxTokens: types.Ptr(1000)}
+
+	out, err := Fit(req, SlidingWindow())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out.Messages) != 1 || out.Messages[0].Content[0].Text != "And now?" {
+		t.Errorf("got %d messages, want only the last", len(out.Messages))
+	}
+	if len(req.Messages) != 3 {
+		t.Error("the request was modified")
+	}
+
+	short := &types.CompletionRequest{Provider: types.ProviderOpenAI, Model: "gpt-4o", Messages: conversation()}
+	if out, err := Fit(short, SlidingWindow()); err != nil || out != short {
+		t.Errorf("Fit = %v, %v, want the request itself", out, err)
+	}
+
+	if _, err := Fit(&types.CompletionRequest{Provider: types.ProviderOpenAI, Model: "unknown-model"}, SlidingWindow()); err == nil {
+		t.Error("expected an error for an uncataloged model")
+	}
+}
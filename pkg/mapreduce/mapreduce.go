@@ -0,0 +1,370 @@
+// Package mapreduce processes documents too long for one request.
+//
+// Run splits a document into chunks that fit the model's context window,
+// sends each chunk with a map prompt concurrently, and combines the answers
+// with a reduce prompt. When the answers are too long to reduce at once,
+// they are reduced in groups, and the groups' answers again, until one is
+// left:
+//
+//	result, err := mapreduce.Run(ctx, r, document, mapreduce.Options{
+//		Request:      &types.CompletionRequest{Provider: types.ProviderOpenAI, Model: "gpt-4o-mini"},
+//		MapPrompt:    "Summarize this section of a contract, listing every obligation.",
+//		ReducePrompt: "Merge these section summaries into one summary of the contract.",
+//	})
+//	fmt.Println(result.Text)
+//
+// Token counts are estimated at four characters per token.
+package mapreduce
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	// DefaultConcurrency is the number of requests Run sends at once by
+	// default.
+	DefaultConcurrency = 4
+
+	// DefaultInputTokens is the input budget of a request to a model
+	// missing from the models catalog.
+	DefaultInputTokens = 8000
+
+	// defaultOutputTokens is the output reserved in the context window when
+	// the template request leaves MaxTokens unset.
+	defaultOutputTokens = 4096
+
+	// defaultReducePrompt combines the answers when Options.ReducePrompt is
+	// empty.
+	defaultReducePrompt = "The following are answers about consecutive parts of one document, separated by ---. Combine them into a single answer about the whole document."
+
+	// separator separates the answers in a reduce request.
+	separator = "\n\n---\n\n"
+)
+
+// Options configure Run.
+type Options struct {
+	// Request is the template of every request: its provider, model, and
+	// parameters. Its messages, such as a system prompt, are sent before
+	// the prompt and text of each request.
+	Request *types.CompletionRequest
+
+	// MapPrompt is sent with each chunk of the document.
+	MapPrompt string
+
+	// ReducePrompt is sent with the answers to combine. Empty asks the
+	// model to combine them into an answer about the whole document.
+	ReducePrompt string
+
+	// ChunkTokens is the size of the chunks. Zero fills the model's
+	// context window from the models catalog, less the prompts and the
+	// request's MaxTokens, or DefaultInputTokens for unknown models.
+	ChunkTokens int
+
+	// ChunkOverlap is how many tokens at the end of a chunk are repeated
+	// at the start of the next, so text cut at a boundary is seen whole.
+	// It is capped at half of ChunkTokens.
+	ChunkOverlap int
+
+	// Concurrency is the number of requests sent at once. Zero means
+	// DefaultConcurrency.
+	Concurrency int
+}
+
+// Result is the outcome of Run.
+type Result struct {
+	// Text is the final answer.
+	Text string
+
+	// Chunks are the pieces the document was split into.
+	Chunks []string
+
+	// Mapped are the map answers, aligned with Chunks.
+	Mapped []string
+
+	// Calls is the number of requests sent, map and reduce.
+	Calls int
+
+	// Usage is the total usage of the requests.
+	Usage types.Usage
+
+	// Cost is the total cost in USD the providers billed, or the list price
+	// from the models catalog.
+	Cost float64
+}
+
+// Run answers the map prompt about each chunk of document and combines the
+// answers with the reduce prompt. A document that fits in one chunk gets
+// the map answer alone. The first request to fail cancels the others, and
+// its error is returned.
+func Run(ctx context.Context, client types.Completer, document string, opts Options) (*Result, error) {
+	if opts.Request == nil || opts.MapPrompt == "" {
+		return nil, errors.ErrInvalidRequest("mapreduce: Request and MapPrompt are required")
+	}
+	if opts.ReducePrompt == "" {
+		opts.ReducePrompt = defaultReducePrompt
+	}
+	if opts.Concurrency <= 0 {
+		opts.Concurrency = DefaultConcurrency
+	}
+
+	chunkTokens := opts.ChunkTokens
+	if chunkTokens <= 0 {
+		chunkTokens = inputBudget(opts.Request, opts.MapPrompt)
+	}
+	reduceTokens := inputBudget(opts.Request, opts.ReducePrompt)
+	if chunkTokens <= 0 || reduceTokens <= 0 {
+		return nil, errors.ErrContextLength(opts.Request.Provider, "mapreduce: the prompts leave no room in the context window")
+	}
+
+	j := &job{client: client, opts: opts, result: &Result{}}
+	j.result.Chunks = Split(document, chunkTokens, opts.ChunkOverlap)
+	if len(j.result.Chunks) == 0 {
+		return nil, errors.ErrInvalidRequest("mapreduce: the document is empty")
+	}
+
+	mapped, err := j.all(ctx, opts.MapPrompt, j.result.Chunks)
+	if err != nil {
+		return nil, err
+	}
+	j.result.Mapped = mapped
+
+	answers := mapped
+	for len(answers) > 1 {
+		// Groups of one need no reducing and are passed through.
+		next := make([]string, 0, len(answers))
+		var inputs []string
+		var at []int
+		for _, group := range groups(answers, reduceTokens) {
+			if len(group) > 1 {
+				inputs = append(inputs, strings.Join(group, separator))
+				at = append(at, len(next))
+			}
+			next = append(next, group[0])
+		}
+		reduced, err := j.all(ctx, opts.ReducePrompt, inputs)
+		if err != nil {
+			return nil, err
+		}
+		for k, i := range at {
+			next[i] = reduced[k]
+		}
+		answers = next
+	}
+	j.result.Text = answers[0]
+	return j.result, nil
+}
+
+// job is the shared state of a Run call.
+type job struct {
+	client types.Completer
+	opts   Options
+
+	mu     sync.Mutex
+	result *Result
+}
+
+// all sends prompt with each of texts, at most Options.Concurrency at once,
+// and returns the answers in order.
+func (j *job) all(ctx context.Context, prompt string, texts []string) ([]string, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	answers := make([]string, len(texts))
+	var (
+		errOnce sync.Once
+		err     error
+	)
+	sem := make(chan struct{}, j.opts.Concurrency)
+	var wg sync.WaitGroup
+	for i, text := range texts {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			answer, callErr := j.complete(ctx, prompt, text)
+			if callErr != nil {
+				errOnce.Do(func() {
+					err = fmt.Errorf("mapreduce: request %d of %d: %w", i+1, len(texts), callErr)
+					cancel()
+				})
+				return
+			}
+			answers[i] = answer
+		}()
+	}
+	wg.Wait()
+
+	if err != nil {
+		return nil, err
+	}
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return nil, ctxErr
+	}
+	return answers, nil
+}
+
+// complete sends one request built from the template and returns its text.
+func (j *job) complete(ctx context.Context, prompt, text string) (string, error) {
+	req := *j.opts.Request
+	req.Messages = append(slices.Clip(req.Messages), types.NewTextMessage(types.RoleUser, prompt+"\n\n"+text))
+	req.Stream = false
+
+	resp, err := j.client.Complete(ctx, &req)
+	if err != nil {
+		return "", err
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.result.Calls++
+	j.result.Usage.Add(resp.Usage)
+	j.result.Cost += models.Cost(resp.Provider, resp.Model, resp.Usage)
+	return resp.Text(), nil
+}
+
+// inputBudget returns the tokens left for the text of a request with
+// prompt: the model's context window less the template's messages, the
+// prompt, and the output.
+func inputBudget(req *types.CompletionRequest, prompt string) int {
+	used := estimateTokens(prompt)
+	for _, msg := range req.Messages {
+		for _, block := range msg.Content {
+			used += estimateTokens(block.Text)
+		}
+	}
+
+	info, ok := models.Lookup(req.Provider, req.Model)
+	if !ok || info.ContextWindow == 0 {
+		return DefaultInputTokens - used
+	}
+	output := defaultOutputTokens
+	if req.MaxTokens != nil {
+		output = *req.MaxTokens
+	} else if limit := info.OutputLimit(); limit > 0 {
+		output = min(output, limit)
+	}
+	return info.ContextWindow - output - used
+}
+
+// groups splits answers into consecutive groups whose joined text fits in
+// budget tokens. Every group but a trailing one holds at least two answers,
+// so each round of reducing makes progress even when the answers are long.
+func groups(answers []string, budget int) [][]string {
+	var out [][]string
+	var group []string
+	tokens := 0
+	for _, answer := range answers {
+		n := estimateTokens(answer + separator)
+		if len(group) >= 2 && tokens+n > budget {
+			out = append(out, group)
+			group, tokens = nil, 0
+		}
+		group = append(group, answer)
+		tokens += n
+	}
+	return append(out, group)
+}
+
+// Split splits text into chunks of at most maxTokens, estimated at four
+// characters per token. It cuts between paragraphs where it can, and
+// otherwise between lines, sentences, or words, and only as a last resort
+// within a word. Each chunk after the first starts with up to overlap
+// tokens from the end of the previous one, cut at a word boundary.
+// Whitespace around chunks is trimmed, and blank text has no chunks.
+func Split(text string, maxTokens, overlap int) []string {
+	maxChars := max(maxTokens, 1) * 4
+	overlapChars := min(max(overlap, 0)*4, maxChars/2)
+
+	var chunks []string
+	for _, chunk := range split(text, maxChars-overlapChars, []string{"\n\n", "\n", ". ", " "}) {
+		if chunk = strings.TrimSpace(chunk); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+	}
+	// Backwards, so each tail comes from a chunk without its own overlap.
+	for i := len(chunks) - 1; i > 0 && overlapChars > 0; i-- {
+		chunks[i] = tail(chunks[i-1], overlapChars) + chunks[i]
+	}
+	return chunks
+}
+
+// split splits text at the first of seps, keeping each separator with the
+// text before it, and packs the parts into chunks of at most maxChars. Parts
+// over maxChars are split at the next separator, or at maxChars when none is
+// left, into chunks of their own.
+func split(text string, maxChars int, seps []string) []string {
+	if len(text) <= maxChars {
+		return []string{text}
+	}
+	if len(seps) == 0 {
+		var out []string
+		for len(text) > maxChars {
+			cut := maxChars
+			for cut > 1 && !utf8.RuneStart(text[cut]) {
+				cut--
+			}
+			out = append(out, text[:cut])
+			text = text[cut:]
+		}
+		return append(out, text)
+	}
+
+	var out []string
+	var b strings.Builder
+	flush := func() {
+		if b.Len() > 0 {
+			out = append(out, b.String())
+			b.Reset()
+		}
+	}
+	for _, part := range strings.SplitAfter(text, seps[0]) {
+		if len(part) > maxChars {
+			flush()
+			out = append(out, split(part, maxChars, seps[1:])...)
+			continue
+		}
+		if b.Len()+len(part) > maxChars {
+			flush()
+		}
+		b.WriteString(part)
+	}
+	flush()
+	return out
+}
+
+// tail returns the whole words at the end of text, with a trailing space,
+// in at most n bytes.
+func tail(text string, n int) string {
+	if len(text) < n {
+		return text + " "
+	}
+	text = text[len(text)-n+1:]
+	i := strings.IndexAny(text, " \t\n")
+	if i < 0 {
+		return ""
+	}
+	return strings.TrimLeft(text[i:], " \t\n") + " "
+}
+
+// estimateTokens estimates the tokens of text at four characters per token.
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
@@ -0,0 +1,139 @@
+package mapreduce
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestSplit(t *testing.T) {
+	text := "First paragraph here.\n\nSecond paragraph is a bit longer than the first.\n\nThird."
+	chunks := Split(text, 8, 0)
+	want := []string{"First paragraph here.", "Second paragraph is a bit", "longer than the first.", "Third."}
+	if strings.Join(chunks, "|") != strings.Join(want, "|") {
+		t.Fatalf("chunks = %q, want %q", chunks, want)
+	}
+	for _, chunk := range chunks {
+		if len(chunk) > 32 {
+			t.Errorf("chunk %q is over 8 tokens", chunk)
+		}
+	}
+
+	if chunks := Split("  \n\n ", 8, 0); len(chunks) != 0 {
+		t.Errorf("blank text: chunks = %q", chunks)
+	}
+	if chunks := Split(strings.Repeat("x", 10), 1, 0); strings.Join(chunks, "|") != "xxxx|xxxx|xx" {
+		t.Errorf("long word: chunks = %q", chunks)
+	}
+}
+
+func TestSplit_Overlap(t *testing.T) {
+	text := "one two three four five six seven eight nine ten"
+	chunks := Split(text, 5, 2)
+	if len(chunks) < 2 {
+		t.Fatalf("chunks = %q", chunks)
+	}
+	for i, chunk := range chunks {
+		if len(chunk) > 20 {
+			t.Errorf("chunk %q is over 5 tokens", chunk)
+		}
+		if i == 0 {
+			continue
+		}
+		prev := strings.Fields(chunks[i-1])
+		if !strings.Contains(chunk, prev[len(prev)-1]+" ") {
+			t.Errorf("chunk %d = %q does not start with the end of %q", i, chunk, chunks[i-1])
+		}
+	}
+}
+
+// fakeCompleter answers map prompts with the chunk's first word and reduce
+// prompts with the words of the answers joined by "+".
+type fakeCompleter struct {
+	mu       sync.Mutex
+	requests []*types.CompletionRequest
+	fail     string
+}
+
+func (f *fakeCompleter) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	f.mu.Lock()
+	f.requests = append(f.requests, req)
+	f.mu.Unlock()
+
+	prompt, text, _ := strings.Cut(req.Messages[len(req.Messages)-1].Content[0].Text, "\n\n")
+	if text == f.fail {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "boom")
+	}
+	answer := strings.Fields(text)[0]
+	if prompt == "reduce" {
+		answer = strings.Join(strings.Split(text, separator), "+")
+	}
+	return &types.CompletionResponse{
+		Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: answer}},
+		Usage:   types.Usage{InputTokens: 10, OutputTokens: 1, TotalTokens: 11},
+	}, nil
+}
+
+func TestRun(t *testing.T) {
+	// A context window that fits three map answers per reduce request.
+	models.Register(models.Info{ID: "mapreduce-test", Provider: types.ProviderOpenAI, ContextWindow: 19, MaxOutputTokens: 10})
+
+	var paragraphs []string
+	for i := range 7 {
+		paragraphs = append(paragraphs, fmt.Sprintf("p%d lorem ipsum", i))
+	}
+	fake := &fakeCompleter{}
+	result, err := Run(context.Background(), fake, strings.Join(paragraphs, "\n\n"), Options{
+		Request: &types.CompletionRequest{
+			Provider:  types.ProviderOpenAI,
+			Model:     "mapreduce-test",
+			MaxTokens: types.Ptr(5),
+			Messages:  []types.Message{types.NewTextMessage(types.RoleSystem, "Be brief.")},
+		},
+		MapPrompt:    "map",
+		ReducePrompt: "reduce",
+		ChunkTokens:  4,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(result.Chunks) != 7 || strings.Join(result.Mapped, " ") != "p0 p1 p2 p3 p4 p5 p6" {
+		t.Fatalf("chunks = %q, mapped = %q", result.Chunks, result.Mapped)
+	}
+	// Three rounds: p0-p2, p3-p5, and p6 alone; then the first two; then all.
+	if want := "p0+p1+p2+p3+p4+p5+p6"; result.Text != want {
+		t.Errorf("text = %q, want %q", result.Text, want)
+	}
+	if result.Calls != 11 || len(fake.requests) != 11 {
+		t.Errorf("calls = %d, requests = %d", result.Calls, len(fake.requests))
+	}
+	if result.Usage.InputTokens != 10*result.Calls {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+	for _, req := range fake.requests {
+		if len(req.Messages) != 2 || req.Messages[0].Role != types.RoleSystem || req.Model != "mapreduce-test" {
+			t.Fatalf("request = %+v", req)
+		}
+	}
+}
+
+func TestRun_Error(t *testing.T) {
+	fake := &fakeCompleter{fail: "bbb"}
+	_, err := Run(context.Background(), fake, "aaa\n\nbbb\n\nccc\n\nddd", Options{
+		Request:     &types.CompletionRequest{Provider: types.ProviderOpenAI, Model: "unknown"},
+		MapPrompt:   "map",
+		ChunkTokens: 2,
+	})
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeServerError || !strings.Contains(err.Error(), "request 2 of 3") {
+		t.Fatalf("err = %v, want the server error of request 2", err)
+	}
+}
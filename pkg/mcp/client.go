@@ -0,0 +1,325 @@
+// Package mcp is a minimal client for remote MCP servers over the streamable
+// HTTP transport. It supports what the router needs to execute MCP tools on
+// behalf of providers without a native MCP connector: listing and calling
+// tools.
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// ProtocolVersion is the MCP protocol revision the client speaks.
+const ProtocolVersion = "2025-03-26"
+
+// Client is a session with one MCP server. It is safe for concurrent use.
+type Client struct {
+	server     types.MCPServer
+	httpClient *http.Client
+	nextID     atomic.Int64
+
+	mu          sync.Mutex
+	sessionID   string
+	initialized bool
+}
+
+// NewClient creates a client for a server. A nil httpClient uses
+// http.DefaultClient.
+func NewClient(server types.MCPServer, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &Client{server: server, httpClient: httpClient}
+}
+
+// Tool is a tool exposed by an MCP server.
+type Tool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+}
+
+// ToTool converts the tool to the unified tool format.
+func (t Tool) ToTool() (types.Tool, error) {
+	tool := types.Tool{Name: t.Name, Description: t.Description}
+	if len(t.InputSchema) > 0 {
+		if err := json.Unmarshal(t.InputSchema, &tool.Parameters); err != nil {
+			return types.Tool{}, fmt.Errorf("tool %s: invalid input schema: %w", t.Name, err)
+		}
+	}
+	if tool.Parameters.Type == "" {
+		tool.Parameters.Type = "object"
+	}
+	return tool, nil
+}
+
+// ToolResult is the result of a tool call.
+type ToolResult struct {
+	Content []Content `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// Content is an item of tool result content.
+type Content struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+// Text joins the text content of the result.
+func (r *ToolResult) Text() string {
+	var parts []string
+	for _, c := range r.Content {
+		if c.Type == "text" {
+			parts = append(parts, c.Text)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// ListTools returns the server's tools, restricted to the server's
+// AllowedTools if set.
+func (c *Client) ListTools(ctx context.Context) ([]Tool, error) {
+	var allowed map[string]bool
+	if len(c.server.AllowedTools) > 0 {
+		allowed = make(map[string]bool, len(c.server.AllowedTools))
+		for _, name := range c.server.AllowedTools {
+			allowed[name] = true
+		}
+	}
+
+	var tools []Tool
+	cursor := ""
+	for {
+		var params map[string]any
+		if cursor != "" {
+			params = map[string]any{"cursor": cursor}
+		}
+		var result struct {
+			Tools      []Tool `json:"tools"`
+			NextCursor string `json:"nextCursor"`
+		}
+		if err := c.call(ctx, "tools/list", params, &result); err != nil {
+			return nil, err
+		}
+		for _, t := range result.Tools {
+			if allowed == nil || allowed[t.Name] {
+				tools = append(tools, t)
+			}
+		}
+		if result.NextCursor == "" || result.NextCursor == cursor {
+			return tools, nil
+		}
+		cursor = result.NextCursor
+	}
+}
+
+// CallTool calls a tool with the given arguments. A tool that fails reports
+// it in the result's IsError; the error return is for protocol failures.
+func (c *Client) CallTool(ctx context.Context, name string, args any) (*ToolResult, error) {
+	if args == nil {
+		args = map[string]any{}
+	}
+	var result ToolResult
+	if err := c.call(ctx, "tools/call", map[string]any{"name": name, "arguments": args}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Close ends the session on the server, if it issued one.
+func (c *Client) Close(ctx context.Context) error {
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.sessionID, c.initialized = "", false
+	c.mu.Unlock()
+	if sessionID == "" {
+		return nil
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.server.URL, nil)
+	if err != nil {
+		return err
+	}
+	c.setHeaders(httpReq, sessionID)
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// initialize performs the initialization handshake once per session.
+func (c *Client) initialize(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.initialized {
+		return nil
+	}
+
+	params := map[string]any{
+		"protocolVersion": ProtocolVersion,
+		"capabilities":    map[string]any{},
+		"clientInfo":      map[string]any{"name": "agent-router", "version": "1.0.0"},
+	}
+	resp, err := c.post(ctx, c.request("initialize", params), "")
+	if err != nil {
+		return err
+	}
+	sessionID := resp.Header.Get("Mcp-Session-Id")
+	var result json.RawMessage
+	err = c.decodeResponse(resp, &result)
+	resp.Body.Close()
+	if err != nil {
+		return err
+	}
+
+	notify, err := c.post(ctx, map[string]any{"jsonrpc": "2.0", "method": "notifications/initialized"}, sessionID)
+	if err != nil {
+		return err
+	}
+	notify.Body.Close()
+
+	c.sessionID = sessionID
+	c.initialized = true
+	return nil
+}
+
+// call sends a request and decodes its result into out.
+func (c *Client) call(ctx context.Context, method string, params, out any) error {
+	if err := c.initialize(ctx); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	sessionID := c.sessionID
+	c.mu.Unlock()
+
+	resp, err := c.post(ctx, c.request(method, params), sessionID)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return c.decodeResponse(resp, out)
+}
+
+func (c *Client) request(method string, params any) map[string]any {
+	req := map[string]any{"jsonrpc": "2.0", "id": c.nextID.Add(1), "method": method}
+	if params != nil {
+		req["params"] = params
+	}
+	return req
+}
+
+func (c *Client) post(ctx context.Context, msg any, sessionID string) (*http.Response, error) {
+	body, err := json.Marshal(msg)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.server.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, c.errorf("failed to create request: %w", err)
+	}
+	c.setHeaders(httpReq, sessionID)
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Accept", "application/json, text/event-stream")
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, c.errorf("request failed: %w", err)
+	}
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		resp.Body.Close()
+		return nil, c.errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
+
+func (c *Client) setHeaders(req *http.Request, sessionID string) {
+	if c.server.AuthorizationToken != "" {
+		req.Header.Set("Authorization", "Bearer "+c.server.AuthorizationToken)
+	}
+	if sessionID != "" {
+		req.Header.Set("Mcp-Session-Id", sessionID)
+		req.Header.Set("MCP-Protocol-Version", ProtocolVersion)
+	}
+}
+
+type rpcResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// decodeResponse reads a JSON-RPC response from a JSON or SSE body.
+func (c *Client) decodeResponse(resp *http.Response, out any) error {
+	var rpc rpcResponse
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "text/event-stream" {
+		found, err := readSSEResponse(resp.Body, &rpc)
+		if err != nil {
+			return c.errorf("failed to read response: %w", err)
+		}
+		if !found {
+			return c.errorf("stream ended without a response")
+		}
+	} else if err := json.NewDecoder(resp.Body).Decode(&rpc); err != nil {
+		return c.errorf("failed to decode response: %w", err)
+	}
+
+	if rpc.Error != nil {
+		return c.errorf("error %d: %s", rpc.Error.Code, rpc.Error.Message)
+	}
+	if err := json.Unmarshal(rpc.Result, out); err != nil {
+		return c.errorf("failed to decode result: %w", err)
+	}
+	return nil
+}
+
+// readSSEResponse returns the first JSON-RPC response in an event stream,
+// skipping the server's requests and notifications.
+func readSSEResponse(r io.Reader, rpc *rpcResponse) (bool, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	var data strings.Builder
+	for {
+		more := scanner.Scan()
+		line := scanner.Text()
+		if more && line != "" {
+			if rest, ok := strings.CutPrefix(line, "data:"); ok {
+				data.WriteString(strings.TrimPrefix(rest, " "))
+			}
+			continue
+		}
+
+		// End of an event.
+		if data.Len() > 0 {
+			*rpc = rpcResponse{}
+			if err := json.Unmarshal([]byte(data.String()), rpc); err == nil && len(rpc.ID) > 0 && (rpc.Result != nil || rpc.Error != nil) {
+				return true, nil
+			}
+			data.Reset()
+		}
+		if !more {
+			return false, scanner.Err()
+		}
+	}
+}
+
+func (c *Client) errorf(format string, args ...any) error {
+	return fmt.Errorf("mcp server %s: %w", c.server.Name, fmt.Errorf(format, args...))
+}
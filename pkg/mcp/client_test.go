@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// newTestServer serves an MCP server with an "add" and a "secret" tool. It
+// answers tools/list with JSON and tools/call with an event stream.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			return
+		}
+		var req struct {
+			ID     int64           `json:"id"`
+			Method string          `json:"method"`
+			Params json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method != "initialize" && r.Header.Get("Mcp-Session-Id") != "s1" {
+			http.Error(w, "missing session", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer tok" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		switch req.Method {
+		case "initialize":
+			w.Header().Set("Mcp-Session-Id", "s1")
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"protocolVersion":%q,"capabilities":{}}}`, req.ID, ProtocolVersion)
+		case "notifications/initialized":
+			w.WriteHeader(http.StatusAccepted)
+		case "tools/list":
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":{"tools":[
+				{"name":"add","description":"Add numbers","inputSchema":{"type":"object","properties":{"a":{"type":"number"},"b":{"type":"number"}},"required":["a","b"]}},
+				{"name":"secret","inputSchema":{"type":"object"}}
+			]}}`, req.ID)
+		case "tools/call":
+			var params struct {
+				Arguments struct{ A, B float64 }
+			}
+			json.Unmarshal(req.Params, &params)
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprint(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"method\":\"notifications/progress\",\"params\":{}}\n\n")
+			fmt.Fprintf(w, "event: message\ndata: {\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":{\"content\":[{\"type\":\"text\",\"text\":\"%g\"}]}}\n\n", req.ID, params.Arguments.A+params.Arguments.B)
+		default:
+			fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"error":{"code":-32601,"message":"method not found"}}`, req.ID)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClient(t *testing.T) {
+	srv := newTestServer(t)
+	c := NewClient(types.MCPServer{Name: "calc", URL: srv.URL, AuthorizationToken: "tok", AllowedTools: []string{"add"}}, nil)
+	ctx := t.Context()
+
+	tools, err := c.ListTools(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tools) != 1 || tools[0].Name != "add" {
+		t.Fatalf("tools = %+v, want only the allowed add tool", tools)
+	}
+	tool, err := tools[0].ToTool()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Parameters.Type != "object" || len(tool.Parameters.Required) != 2 {
+		t.Errorf("parameters = %+v", tool.Parameters)
+	}
+
+	result, err := c.CallTool(ctx, "add", map[string]any{"a": 2, "b": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Text() != "5" || result.IsError {
+		t.Errorf("result = %+v, want 5", result)
+	}
+
+	if err := c.Close(ctx); err != nil {
+		t.Fatal(err)
+	}
+}
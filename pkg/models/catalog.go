@@ -0,0 +1,64 @@
+package models
+
+import "github.com/Chloe199719/agent-router/pkg/types"
+
+// builtin is the shipped catalog. Limits and list prices are taken from the
+// providers' model and pricing pages. Classes are only set on current models
+// whose catalog ID is also an API alias.
+var builtin = []Info{
+	// OpenAI
+	{ID: "gpt-5", Provider: types.ProviderOpenAI, ContextWindow: 400000, MaxOutputTokens: 128000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 1.25, Output: 10}, Class: ClassPowerful},
+	{ID: "gpt-5-mini", Provider: types.ProviderOpenAI, ContextWindow: 400000, MaxOutputTokens: 128000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.25, Output: 2}, Class: ClassFast},
+	{ID: "gpt-5-nano", Provider: types.ProviderOpenAI, ContextWindow: 400000, MaxOutputTokens: 128000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.05, Output: 0.40}, Class: ClassFast},
+	{ID: "gpt-4.1", Provider: types.ProviderOpenAI, ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 2, Output: 8}, Class: ClassBalanced},
+	{ID: "gpt-4.1-mini", Provider: types.ProviderOpenAI, ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.40, Output: 1.60}, Class: ClassFast},
+	{ID: "gpt-4.1-nano", Provider: types.ProviderOpenAI, ContextWindow: 1047576, MaxOutputTokens: 32768, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.10, Output: 0.40}, Class: ClassFast},
+	{ID: "gpt-4o", Provider: types.ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 2.50, Output: 10}, Class: ClassBalanced},
+	{ID: "gpt-4o-mini", Provider: types.ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 16384, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.15, Output: 0.60}, Class: ClassFast},
+	{ID: "gpt-4-turbo", Provider: types.ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 4096, Tools: true, Vision: true, Pricing: Pricing{Input: 10, Output: 30}},
+	{ID: "gpt-4", Provider: types.ProviderOpenAI, ContextWindow: 8192, MaxOutputTokens: 8192, Tools: true, Pricing: Pricing{Input: 30, Output: 60}},
+	{ID: "gpt-3.5-turbo", Provider: types.ProviderOpenAI, ContextWindow: 16385, MaxOutputTokens: 4096, Tools: true, Pricing: Pricing{Input: 0.50, Output: 1.50}},
+	{ID: "o1", Provider: types.ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 15, Output: 60}, Class: ClassPowerful},
+	{ID: "o1-mini", Provider: types.ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 65536, Pricing: Pricing{Input: 1.10, Output: 4.40}, Deprecated: true},
+	{ID: "o1-preview", Provider: types.ProviderOpenAI, ContextWindow: 128000, MaxOutputTokens: 32768, Pricing: Pricing{Input: 15, Output: 60}, Deprecated: true},
+	{ID: "o3", Provider: types.ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 2, Output: 8}, Class: ClassPowerful},
+	{ID: "o3-mini", Provider: types.ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, StructuredOutput: true, Pricing: Pricing{Input: 1.10, Output: 4.40}, Class: ClassBalanced},
+	{ID: "o4-mini", Provider: types.ProviderOpenAI, ContextWindow: 200000, MaxOutputTokens: 100000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 1.10, Output: 4.40}, Class: ClassBalanced},
+
+	// Anthropic. Native structured output (output_config.format) is limited
+	// to the models listed with StructuredOutput.
+	{ID: "claude-opus-4-5", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 5, Output: 25}, Class: ClassPowerful},
+	{ID: "claude-opus-4-1", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 32000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 15, Output: 75}, Class: ClassPowerful},
+	{ID: "claude-opus-4", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 32000, Tools: true, Vision: true, Pricing: Pricing{Input: 15, Output: 75}},
+	{ID: "claude-sonnet-4-5", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 3, Output: 15}, Class: ClassBalanced},
+	{ID: "claude-sonnet-4", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, Pricing: Pricing{Input: 3, Output: 15}},
+	{ID: "claude-haiku-4-5", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 1, Output: 5}, Class: ClassFast},
+	{ID: "claude-3-7-sonnet", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 64000, ExtendedOutputTokens: 128000, Tools: true, Vision: true, Pricing: Pricing{Input: 3, Output: 15}, Deprecated: true},
+	{ID: "claude-3-5-sonnet", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 8192, Tools: true, Vision: true, Pricing: Pricing{Input: 3, Output: 15}, Deprecated: true},
+	{ID: "claude-3-5-haiku", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 8192, Tools: true, Vision: true, Pricing: Pricing{Input: 0.80, Output: 4}},
+	{ID: "claude-3-opus", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 4096, Tools: true, Vision: true, Pricing: Pricing{Input: 15, Output: 75}, Deprecated: true},
+	{ID: "claude-3-sonnet", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 4096, Tools: true, Vision: true, Pricing: Pricing{Input: 3, Output: 15}, Deprecated: true},
+	{ID: "claude-3-haiku", Provider: types.ProviderAnthropic, ContextWindow: 200000, MaxOutputTokens: 4096, Tools: true, Vision: true, Pricing: Pricing{Input: 0.25, Output: 1.25}},
+
+	// Google (also used for Vertex)
+	{ID: "gemini-2.5-pro", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 1.25, Output: 10}, Class: ClassPowerful},
+	{ID: "gemini-2.5-flash", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.30, Output: 2.50}, Class: ClassBalanced},
+	{ID: "gemini-2.5-flash-lite", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 65536, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.10, Output: 0.40}, Class: ClassFast},
+	{ID: "gemini-2.0-flash", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.10, Output: 0.40}, Class: ClassFast},
+	{ID: "gemini-2.0-flash-lite", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.075, Output: 0.30}, Class: ClassFast},
+	{ID: "gemini-1.5-pro", Provider: types.ProviderGoogle, ContextWindow: 2097152, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 1.25, Output: 5}, Deprecated: true},
+	{ID: "gemini-1.5-flash", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.075, Output: 0.30}, Deprecated: true},
+	{ID: "gemini-1.5-flash-8b", Provider: types.ProviderGoogle, ContextWindow: 1048576, MaxOutputTokens: 8192, Tools: true, Vision: true, StructuredOutput: true, Pricing: Pricing{Input: 0.0375, Output: 0.15}, Deprecated: true},
+	{ID: "gemini-1.0-pro", Provider: types.ProviderGoogle, ContextWindow: 30720, MaxOutputTokens: 2048, Tools: true, Pricing: Pricing{Input: 0.50, Output: 1.50}, Deprecated: true},
+
+	// DeepSeek. Both models accept tools; neither accepts images or JSON
+	// schemas.
+	{ID: "deepseek-chat", Provider: types.ProviderDeepSeek, ContextWindow: 128000, MaxOutputTokens: 8192, DefaultMaxTokens: 4096, Tools: true, Pricing: Pricing{Input: 0.28, Output: 0.42}, Class: ClassFast},
+	{ID: "deepseek-reasoner", Provider: types.ProviderDeepSeek, ContextWindow: 128000, MaxOutputTokens: 65536, DefaultMaxTokens: 32768, Tools: true, Pricing: Pricing{Input: 0.28, Output: 0.42}, Class: ClassBalanced},
+
+	// Cohere
+	{ID: "command-a-03-2025", Provider: types.ProviderCohere, ContextWindow: 256000, MaxOutputTokens: 8000, Tools: true, StructuredOutput: true, Pricing: Pricing{Input: 2.50, Output: 10}},
+	{ID: "command-r-plus-08-2024", Provider: types.ProviderCohere, ContextWindow: 128000, MaxOutputTokens: 4000, Tools: true, StructuredOutput: true, Pricing: Pricing{Input: 2.50, Output: 10}},
+	{ID: "command-r-08-2024", Provider: types.ProviderCohere, ContextWindow: 128000, MaxOutputTokens: 4000, Tools: true, StructuredOutput: true, Pricing: Pricing{Input: 0.15, Output: 0.60}},
+	{ID: "command-r7b-12-2024", Provider: types.ProviderCohere, ContextWindow: 128000, MaxOutputTokens: 4000, Tools: true, StructuredOutput: true, Pricing: Pricing{Input: 0.0375, Output: 0.15}},
+}
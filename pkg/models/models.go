@@ -0,0 +1,186 @@
+// Package models is a catalog of model limits, capabilities, and pricing.
+//
+// Entries are keyed by model family: a lookup for a dated snapshot such as
+// "claude-sonnet-4-5-20250929" or "gpt-4o-2024-08-06" matches the longest
+// catalog ID that is a prefix of it, up to a "-" boundary. The data follows
+// the providers' model pages and goes stale; use Register to add or correct
+// entries.
+package models
+
+import (
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Info describes a model family.
+type Info struct {
+	// ID is the model ID or family prefix.
+	ID string `json:"id"`
+
+	// Provider serving the model. Vertex lookups fall back to Google entries.
+	Provider types.Provider `json:"provider"`
+
+	// ContextWindow is the maximum number of input tokens.
+	ContextWindow int `json:"context_window"`
+
+	// MaxOutputTokens is the maximum value accepted for max_tokens.
+	MaxOutputTokens int `json:"max_output_tokens"`
+
+	// ExtendedOutputTokens is the higher max_tokens limit available through
+	// a provider beta, such as Anthropic's output-128k for Claude 3.7
+	// Sonnet, which clients enable for requests above MaxOutputTokens. Zero
+	// if the model has none.
+	ExtendedOutputTokens int `json:"extended_output_tokens,omitempty"`
+
+	// DefaultMaxTokens is the max_tokens the router sends when a request sets
+	// none. Zero defers to the router's default.
+	DefaultMaxTokens int `json:"default_max_tokens,omitempty"`
+
+	// Tools reports function calling support.
+	Tools bool `json:"tools"`
+
+	// Vision reports image input support.
+	Vision bool `json:"vision"`
+
+	// StructuredOutput reports JSON schema constrained output support.
+	StructuredOutput bool `json:"structured_output"`
+
+	// Pricing in USD per million tokens.
+	Pricing Pricing `json:"pricing"`
+
+	// Deprecated is true if the model is retired or scheduled for retirement.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Class is the model's tier. Only entries with a class are used for
+	// capability-based routing, so their IDs must be valid API model names.
+	Class Class `json:"class,omitempty"`
+}
+
+// Class is a model tier, used to pick a model by capability rather than name.
+type Class string
+
+const (
+	ClassFast     Class = "fast"
+	ClassBalanced Class = "balanced"
+	ClassPowerful Class = "powerful"
+)
+
+// Pricing is the list price of a model in USD per million tokens.
+type Pricing struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// Supports reports whether the model supports a feature. Features the
+// catalog does not track are reported as supported.
+func (i Info) Supports(feature types.Feature) bool {
+	switch feature {
+	case types.FeatureTools:
+		return i.Tools
+	case types.FeatureVision:
+		return i.Vision
+	case types.FeatureStructuredOutput:
+		return i.StructuredOutput
+	default:
+		return true
+	}
+}
+
+// OutputLimit returns the effective maximum max_tokens of the model: its
+// extended output limit if it has one, or else MaxOutputTokens.
+func (i Info) OutputLimit() int {
+	return max(i.MaxOutputTokens, i.ExtendedOutputTokens)
+}
+
+// Cost returns the list price of a request in USD.
+func (i Info) Cost(usage types.Usage) float64 {
+	return (float64(usage.InputTokens)*i.Pricing.Input + float64(usage.OutputTokens)*i.Pricing.Output) / 1e6
+}
+
+// Cost returns the cost of a request in USD: the cost the provider billed,
+// when usage reports one, or else the model's list price. It is 0 for models
+// not in the catalog.
+func Cost(provider types.Provider, model string, usage types.Usage) float64 {
+	if usage.Cost > 0 {
+		return usage.Cost
+	}
+	if info, ok := Lookup(provider, model); ok {
+		return info.Cost(usage)
+	}
+	return 0
+}
+
+var (
+	mu      sync.RWMutex
+	catalog = make(map[types.Provider]map[string]Info)
+)
+
+func init() {
+	for _, info := range builtin {
+		Register(info)
+	}
+}
+
+// Register adds or replaces a catalog entry.
+func Register(info Info) {
+	mu.Lock()
+	defer mu.Unlock()
+	if catalog[info.Provider] == nil {
+		catalog[info.Provider] = make(map[string]Info)
+	}
+	catalog[info.Provider][strings.ToLower(info.ID)] = info
+}
+
+// Lookup returns the catalog entry for a model: an exact match, or else the
+// longest entry whose ID is a prefix of the model followed by "-" (so
+// "gpt-4o-2024-08-06" matches "gpt-4o" but "gpt-4.5" does not match "gpt-4").
+func Lookup(provider types.Provider, model string) (Info, bool) {
+	m := strings.ToLower(strings.TrimSpace(model))
+	if m == "" {
+		return Info{}, false
+	}
+	m = strings.TrimPrefix(m, "models/")
+
+	mu.RLock()
+	defer mu.RUnlock()
+
+	if info, ok := lookupLocked(provider, m); ok {
+		return info, true
+	}
+	if provider == types.ProviderVertex {
+		return lookupLocked(types.ProviderGoogle, m)
+	}
+	return Info{}, false
+}
+
+func lookupLocked(provider types.Provider, m string) (Info, bool) {
+	entries := catalog[provider]
+	if info, ok := entries[m]; ok {
+		return info, true
+	}
+
+	var best Info
+	found := false
+	for id, info := range entries {
+		if strings.HasPrefix(m, id+"-") && len(id) > len(best.ID) {
+			best, found = info, true
+		}
+	}
+	return best, found
+}
+
+// All returns every catalog entry for a provider, sorted by ID.
+func All(provider types.Provider) []Info {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	infos := make([]Info, 0, len(catalog[provider]))
+	for _, info := range catalog[provider] {
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
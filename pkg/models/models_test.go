@@ -0,0 +1,83 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		provider types.Provider
+		model    string
+		wantID   string
+	}{
+		{types.ProviderOpenAI, "gpt-4o", "gpt-4o"},
+		{types.ProviderOpenAI, "gpt-4o-2024-08-06", "gpt-4o"},
+		{types.ProviderOpenAI, "gpt-4o-mini-2024-07-18", "gpt-4o-mini"},
+		{types.ProviderOpenAI, "gpt-4.5-preview", ""},
+		{types.ProviderAnthropic, "claude-sonnet-4-5-20250929", "claude-sonnet-4-5"},
+		{types.ProviderAnthropic, "claude-sonnet-4-20250514", "claude-sonnet-4"},
+		{types.ProviderGoogle, "models/gemini-2.5-flash", "gemini-2.5-flash"},
+		{types.ProviderVertex, "gemini-2.5-flash-lite", "gemini-2.5-flash-lite"},
+		{types.ProviderOpenAI, "claude-sonnet-4-5", ""},
+	}
+
+	for _, tt := range tests {
+		info, ok := Lookup(tt.provider, tt.model)
+		if tt.wantID == "" {
+			if ok {
+				t.Errorf("Lookup(%s, %s) = %s, want no match", tt.provider, tt.model, info.ID)
+			}
+			continue
+		}
+		if !ok || info.ID != tt.wantID {
+			t.Errorf("Lookup(%s, %s) = %q, %v, want %q", tt.provider, tt.model, info.ID, ok, tt.wantID)
+		}
+	}
+}
+
+func TestInfo_OutputLimit(t *testing.T) {
+	tests := map[string]int{
+		"claude-3-7-sonnet-20250219": 128000,
+		"claude-sonnet-4-20250514":   64000,
+		"claude-3-5-haiku-20241022":  8192,
+	}
+	for model, want := range tests {
+		info, _ := Lookup(types.ProviderAnthropic, model)
+		if got := info.OutputLimit(); got != want {
+			t.Errorf("OutputLimit(%s) = %d, want %d", model, got, want)
+		}
+	}
+}
+
+func TestRegister_Overrides(t *testing.T) {
+	orig, _ := Lookup(types.ProviderOpenAI, "gpt-4o")
+	defer Register(orig)
+
+	Register(Info{ID: "gpt-4o", Provider: types.ProviderOpenAI, MaxOutputTokens: 1})
+	if info, _ := Lookup(types.ProviderOpenAI, "gpt-4o-2024-11-20"); info.MaxOutputTokens != 1 {
+		t.Errorf("expected registered entry, got %+v", info)
+	}
+}
+
+func TestCost(t *testing.T) {
+	info := Info{Pricing: Pricing{Input: 3, Output: 15}}
+	if got := info.Cost(types.Usage{InputTokens: 1000000, OutputTokens: 100000}); got != 4.5 {
+		t.Errorf("expected 4.5, got %v", got)
+	}
+}
+
+func TestCost_BilledCost(t *testing.T) {
+	usage := types.Usage{InputTokens: 1000000, OutputTokens: 100000}
+	if got := Cost(types.ProviderAnthropic, "claude-sonnet-4-5", usage); got != 4.5 {
+		t.Errorf("expected list price 4.5, got %v", got)
+	}
+	usage.Cost = 3.9
+	if got := Cost(types.ProviderAnthropic, "claude-sonnet-4-5", usage); got != 3.9 {
+		t.Errorf("expected billed cost 3.9, got %v", got)
+	}
+	if got := Cost(types.ProviderOpenRouter, "anthropic/claude-sonnet-4.5", usage); got != 3.9 {
+		t.Errorf("expected billed cost for uncatalogued model, got %v", got)
+	}
+}
@@ -0,0 +1,113 @@
+// Package postprocess cleans up and checks responses before they reach the
+// application.
+//
+// A Pipeline runs processors in order on a response: each can rewrite its
+// text, annotate its Metadata, send follow-up requests, or reject it by
+// returning an error. Pipelines are attached per request, so each call site
+// picks the processing its output needs:
+//
+//	p := postprocess.New(
+//		postprocess.StripCodeFences(),
+//		postprocess.NormalizeWhitespace(),
+//		postprocess.MaxLength(280, 2),
+//	)
+//	resp, err := p.Complete(ctx, r, req)
+package postprocess
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Call is a request and its response, as processors see it.
+type Call struct {
+	// Client sends the follow-up requests of processors like MaxLength. It
+	// may be nil.
+	Client types.Completer
+
+	// Request is the request that produced Response. Processors must not
+	// modify it.
+	Request *types.CompletionRequest
+
+	// Response is the response to process. A processor may modify it in
+	// place or replace it.
+	Response *types.CompletionResponse
+}
+
+// Processor is a post-processing step.
+type Processor interface {
+	Name() string
+	Process(ctx context.Context, call *Call) error
+}
+
+// Pipeline runs processors in order. A nil Pipeline passes responses
+// through.
+type Pipeline struct {
+	processors []Processor
+}
+
+// New creates a pipeline that runs the processors in order.
+func New(processors ...Processor) *Pipeline {
+	return &Pipeline{processors: processors}
+}
+
+// Complete sends req with client and returns the processed response.
+func (p *Pipeline) Complete(ctx context.Context, client types.Completer, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	resp, err := client.Complete(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return p.Process(ctx, client, req, resp)
+}
+
+// Process runs the processors on resp, the response to req, such as the
+// Response of a finished stream. It returns the processed response, which
+// is resp itself unless a processor replaced it. client may be nil if no
+// processor sends requests; MaxLength then truncates without re-prompting.
+func (p *Pipeline) Process(ctx context.Context, client types.Completer, req *types.CompletionRequest, resp *types.CompletionResponse) (*types.CompletionResponse, error) {
+	if p == nil {
+		return resp, nil
+	}
+	call := &Call{Client: client, Request: req, Response: resp}
+	for _, proc := range p.processors {
+		if err := proc.Process(ctx, call); err != nil {
+			return nil, fmt.Errorf("postprocess: %s: %w", proc.Name(), err)
+		}
+	}
+	return call.Response, nil
+}
+
+type processorFunc struct {
+	name string
+	fn   func(ctx context.Context, call *Call) error
+}
+
+// Func returns a processor that calls fn.
+func Func(name string, fn func(ctx context.Context, call *Call) error) Processor {
+	return &processorFunc{name: name, fn: fn}
+}
+
+func (p *processorFunc) Name() string { return p.name }
+
+func (p *processorFunc) Process(ctx context.Context, call *Call) error {
+	return p.fn(ctx, call)
+}
+
+// rewriteText replaces the text of each text block of resp with fn's result.
+func rewriteText(resp *types.CompletionResponse, fn func(string) string) {
+	for i := range resp.Content {
+		if resp.Content[i].Type == types.ContentTypeText {
+			resp.Content[i].Text = fn(resp.Content[i].Text)
+		}
+	}
+}
+
+// setMetadata sets a Metadata entry of resp.
+func setMetadata(resp *types.CompletionResponse, key string, value any) {
+	if resp.Metadata == nil {
+		resp.Metadata = make(map[string]any)
+	}
+	resp.Metadata[key] = value
+}
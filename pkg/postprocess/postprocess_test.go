@@ -0,0 +1,142 @@
+package postprocess
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func textResponse(text string) *types.CompletionResponse {
+	return &types.CompletionResponse{
+		Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: text}},
+		Usage:   types.Usage{InputTokens: 10, OutputTokens: 5, TotalTokens: 15},
+	}
+}
+
+func process(t *testing.T, p Processor, text string) *types.CompletionResponse {
+	t.Helper()
+	resp, err := New(p).Process(context.Background(), nil, &types.CompletionRequest{}, textResponse(text))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp
+}
+
+func TestTextProcessors(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Processor
+		in   string
+		want string
+	}{
+		{"fences", StripCodeFences(), "```json\n{\"a\": 1}\n```\n", `{"a": 1}`},
+		{"fences with prose", StripCodeFences(), "Here:\n```go\nx := 1\n```\nDone.", "Here:\nx := 1\nDone."},
+		{"markdown", StripMarkdown(), "## Steps\n* **Open** the [docs](https://example.com)\n> run `make`\n", "Steps\n- Open the docs\nrun make"},
+		{"markdown keeps snake_case", StripMarkdown(), "use my_var_name", "use my_var_name"},
+		{"whitespace", NormalizeWhitespace(), "\ufeffa\u00a0b  \r\n\r\n\r\n\r\n  c\u200b\n", "a b\n\n  c"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := process(t, tt.p, tt.in).Text(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+type shortener struct {
+	replies  []string
+	requests []*types.CompletionRequest
+}
+
+func (s *shortener) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	s.requests = append(s.requests, req)
+	reply := s.replies[0]
+	s.replies = s.replies[1:]
+	return textResponse(reply), nil
+}
+
+func TestMaxLength(t *testing.T) {
+	long := strings.Repeat("word ", 20)
+	client := &shortener{replies: []string{long, "Short answer."}}
+	req := &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Explain.")}}
+
+	resp, err := New(MaxLength(20, 3)).Complete(context.Background(), client, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "Short answer." {
+		t.Errorf("text = %q, want the shortened answer", resp.Text())
+	}
+	if len(client.requests) != 2 || len(client.requests[1].Messages) != 3 || len(req.Messages) != 1 {
+		t.Fatalf("sent %d requests, want the original and one re-prompt", len(client.requests))
+	}
+	if got := client.requests[1].Messages[2].Content[0].Text; !strings.Contains(got, "at most 20 characters") {
+		t.Errorf("re-prompt = %q", got)
+	}
+	if resp.Usage.TotalTokens != 30 {
+		t.Errorf("usage = %+v, want both requests", resp.Usage)
+	}
+}
+
+func TestMaxLength_Truncates(t *testing.T) {
+	resp := process(t, MaxLength(12, 2), "héllo wörld and more")
+	if got := resp.Text(); got != "héllo wörld" || utf8.RuneCountInString(got) > 12 {
+		t.Errorf("text = %q, want it cut at a word boundary", got)
+	}
+}
+
+func TestDetectRefusal(t *testing.T) {
+	for text, want := range map[string]bool{
+		"I’m sorry, but I can’t help with that.":           true,
+		"I cannot assist with this request. It is unsafe.": true,
+		"Sure! Here is the recipe. I can't help noticing":  false,
+	} {
+		resp := process(t, DetectRefusal(), text)
+		if got := resp.StopReason == types.StopReasonRefusal; got != want || got && resp.Refusal != text {
+			t.Errorf("%q: refusal = %v, want %v", text, got, want)
+		}
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for text, want := range map[string]string{
+		"The answer is that it depends on the weather.":          "en",
+		"La respuesta es que depende del tiempo y de la hora.":   "es",
+		"Die Antwort ist, dass es nicht von dem Wetter abhängt.": "de",
+		"Ответ зависит от погоды.":                               "ru",
+		"答えは天気によります。":                                            "ja",
+		"答案取决于天气。":                                               "zh",
+	} {
+		if got := process(t, DetectLanguage(), text).Metadata[MetadataLanguage]; got != want {
+			t.Errorf("%q: language = %v, want %s", text, got, want)
+		}
+	}
+	if _, ok := process(t, DetectLanguage(), "1234").Metadata[MetadataLanguage]; ok {
+		t.Error("language set for text without words")
+	}
+}
+
+func TestPipeline_Error(t *testing.T) {
+	errTooShort := stderrors.New("too short")
+	p := New(
+		Func("min_length", func(_ context.Context, call *Call) error {
+			if len(call.Response.Text()) < 5 {
+				return errTooShort
+			}
+			return nil
+		}),
+		Func("never", func(context.Context, *Call) error {
+			t.Error("processor ran after an error")
+			return nil
+		}),
+	)
+	_, err := p.Process(context.Background(), nil, &types.CompletionRequest{}, textResponse("hi"))
+	if !stderrors.Is(err, errTooShort) || !strings.Contains(err.Error(), "min_length") {
+		t.Errorf("err = %v, want the processor's error", err)
+	}
+}
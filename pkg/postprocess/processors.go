@@ -0,0 +1,308 @@
+package postprocess
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// MetadataLanguage is the Metadata key of the ISO 639-1 code DetectLanguage
+// found.
+const MetadataLanguage = "language"
+
+var fenceLine = regexp.MustCompile("(?m)^[ \t]*```[^`\n]*\n?")
+
+type stripCodeFences struct{}
+
+// StripCodeFences removes the ``` lines of fenced code blocks, keeping the
+// code, so a model that wraps JSON or code in a fence returns it bare.
+func StripCodeFences() Processor {
+	return stripCodeFences{}
+}
+
+func (stripCodeFences) Name() string { return "strip_code_fences" }
+
+func (stripCodeFences) Process(_ context.Context, call *Call) error {
+	rewriteText(call.Response, func(text string) string {
+		return strings.TrimSpace(fenceLine.ReplaceAllString(text, ""))
+	})
+	return nil
+}
+
+var markdownRules = []struct {
+	re   *regexp.Regexp
+	repl string
+}{
+	{regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`\[([^\]]+)\]\([^)]*\)`), "$1"},
+	{regexp.MustCompile(`(?m)^[ \t]*#{1,6}[ \t]+`), ""},
+	{regexp.MustCompile(`(?m)^[ \t]*>[ \t]?`), ""},
+	{regexp.MustCompile(`(?m)^([ \t]*)[*+][ \t]+`), "$1- "},
+	{regexp.MustCompile(`\*\*([^*\n]+)\*\*`), "$1"},
+	{regexp.MustCompile(`__([^_\n]+)__`), "$1"},
+	{regexp.MustCompile(`\*([^*\n]+)\*`), "$1"},
+	{regexp.MustCompile("`([^`\n]+)`"), "$1"},
+}
+
+type stripMarkdown struct{}
+
+// StripMarkdown turns markdown into plain text, for output that is shown or
+// spoken without rendering: headings, emphasis, quotes, inline code, and
+// code fences lose their markers, links and images become their text, and
+// bullets become dashes.
+func StripMarkdown() Processor {
+	return stripMarkdown{}
+}
+
+func (stripMarkdown) Name() string { return "strip_markdown" }
+
+func (stripMarkdown) Process(_ context.Context, call *Call) error {
+	rewriteText(call.Response, func(text string) string {
+		text = fenceLine.ReplaceAllString(text, "")
+		for _, rule := range markdownRules {
+			text = rule.re.ReplaceAllString(text, rule.repl)
+		}
+		return strings.TrimSpace(text)
+	})
+	return nil
+}
+
+var (
+	trailingSpace = regexp.MustCompile(`(?m)[ \t]+$`)
+	blankLines    = regexp.MustCompile(`\n{3,}`)
+	invisible     = strings.NewReplacer("\r\n", "\n", "\u00a0", " ", "\u200b", "", "\ufeff", "")
+)
+
+type normalizeWhitespace struct{}
+
+// NormalizeWhitespace converts line endings to \n and non-breaking spaces to
+// spaces, drops zero-width characters and trailing spaces, collapses runs
+// of blank lines into one, and trims the text. Indentation is kept.
+func NormalizeWhitespace() Processor {
+	return normalizeWhitespace{}
+}
+
+func (normalizeWhitespace) Name() string { return "normalize_whitespace" }
+
+func (normalizeWhitespace) Process(_ context.Context, call *Call) error {
+	rewriteText(call.Response, func(text string) string {
+		text = trailingSpace.ReplaceAllString(invisible.Replace(text), "")
+		return strings.TrimSpace(blankLines.ReplaceAllString(text, "\n\n"))
+	})
+	return nil
+}
+
+// shortenPrompt asks the model to shorten its answer.
+const shortenPrompt = "Your answer is %d characters long. Rewrite it in at most %d characters, keeping what matters most. Reply with the rewritten answer only."
+
+type maxLength struct {
+	maxChars int
+	retries  int
+}
+
+// MaxLength enforces a limit of maxChars characters on the response text.
+// A longer answer is sent back to the model with a request to shorten it,
+// up to retries times, and cut at a word boundary if it is still too long.
+// The follow-up requests' usage is added to the response's.
+func MaxLength(maxChars, retries int) Processor {
+	return &maxLength{maxChars: maxChars, retries: retries}
+}
+
+func (p *maxLength) Name() string { return "max_length" }
+
+func (p *maxLength) Process(ctx context.Context, call *Call) error {
+	for attempt := 0; attempt < p.retries && call.Client != nil; attempt++ {
+		text := call.Response.Text()
+		n := utf8.RuneCountInString(text)
+		if n <= p.maxChars {
+			return nil
+		}
+
+		req := *call.Request
+		req.Messages = append(slices.Clip(req.Messages),
+			types.NewTextMessage(types.RoleAssistant, text),
+			types.NewTextMessage(types.RoleUser, fmt.Sprintf(shortenPrompt, n, p.maxChars)),
+		)
+		resp, err := call.Client.Complete(ctx, &req)
+		if err != nil {
+			return err
+		}
+		resp.Usage.Add(call.Response.Usage)
+		call.Response = resp
+	}
+
+	if utf8.RuneCountInString(call.Response.Text()) > p.maxChars {
+		text := truncate(call.Response.Text(), p.maxChars)
+		call.Response.Content = slices.DeleteFunc(call.Response.Content, func(b types.ContentBlock) bool {
+			return b.Type == types.ContentTypeText
+		})
+		call.Response.Content = append(call.Response.Content, types.ContentBlock{Type: types.ContentTypeText, Text: text})
+	}
+	return nil
+}
+
+// truncate cuts text to at most n characters, at the last space if there is
+// one in the second half.
+func truncate(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	cut := string(runes[:n])
+	if i := strings.LastIndexAny(cut, " \t\n"); i >= len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimSpace(cut)
+}
+
+// refusalPhrases open a refusal. They are matched in the first sentence.
+var refusalPhrases = []string{
+	"i can't help", "i cannot help", "i can't assist", "i cannot assist",
+	"i can't provide", "i cannot provide", "i can't comply", "i cannot comply",
+	"i'm not able to help", "i am not able to help", "i'm unable to help", "i am unable to help",
+	"i won't be able to help", "i must decline", "i'm sorry, but i can't", "i'm sorry, but i cannot",
+}
+
+type detectRefusal struct{}
+
+// DetectRefusal marks a response whose text opens with a refusal such as "I
+// can't help with that" as one: its StopReason becomes StopReasonRefusal and
+// its Refusal the text, as for the refusals providers report themselves. It
+// catches the refusals of models that decline in plain text, so an
+// application can branch on StopReasonRefusal alone.
+func DetectRefusal() Processor {
+	return detectRefusal{}
+}
+
+func (detectRefusal) Name() string { return "detect_refusal" }
+
+func (detectRefusal) Process(_ context.Context, call *Call) error {
+	resp := call.Response
+	if resp.StopReason != types.StopReasonRefusal && isRefusal(resp.Text()) {
+		resp.StopReason = types.StopReasonRefusal
+		resp.Refusal = strings.TrimSpace(resp.Text())
+	}
+	return nil
+}
+
+// isRefusal reports whether the first sentence of text holds a refusal
+// phrase.
+func isRefusal(text string) bool {
+	text = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(text), "\u2019", "'"))
+	if i := strings.IndexAny(text, ".!?\n"); i >= 0 {
+		text = text[:i+1]
+	}
+	for _, phrase := range refusalPhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// scripts maps writing systems with one common language to its code.
+var scripts = []struct {
+	table *unicode.RangeTable
+	lang  string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Devanagari, "hi"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+}
+
+// stopwords are frequent words of languages written in the Latin script.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "is", "of", "to", "in", "that", "it", "you", "with", "for", "this", "are", "was"},
+	"es": {"el", "la", "los", "las", "de", "que", "y", "en", "es", "por", "para", "con", "una", "del"},
+	"fr": {"le", "la", "les", "des", "et", "est", "que", "une", "dans", "pour", "pas", "sur", "du", "avec"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ein", "eine", "zu", "mit", "den", "ich", "sie", "auf"},
+	"it": {"il", "di", "che", "è", "la", "per", "non", "una", "sono", "con", "gli", "del", "della", "anche"},
+	"pt": {"o", "os", "de", "que", "e", "não", "em", "uma", "para", "com", "do", "da", "é", "são"},
+	"nl": {"de", "het", "een", "en", "van", "is", "niet", "dat", "op", "te", "met", "voor", "zijn", "ik"},
+}
+
+type detectLanguage struct{}
+
+// DetectLanguage sets the response's MetadataLanguage entry to the ISO 639-1
+// code of the language of its text, for checking that a model answered in
+// the language it was asked to. It is a heuristic: languages are told apart
+// by their script, or, in the Latin script, by their most common words, so
+// only English, Spanish, French, German, Italian, Portuguese, and Dutch are
+// recognized there. Nothing is set when the language is not recognized.
+func DetectLanguage() Processor {
+	return detectLanguage{}
+}
+
+func (detectLanguage) Name() string { return "detect_language" }
+
+func (detectLanguage) Process(_ context.Context, call *Call) error {
+	if lang := language(call.Response.Text()); lang != "" {
+		setMetadata(call.Response, MetadataLanguage, lang)
+	}
+	return nil
+}
+
+// language returns the code of the language of text, or "" if it is not
+// recognized.
+func language(text string) string {
+	counts := make(map[string]int)
+	letters := 0
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			counts["ja"] += 2 // Japanese mixes kana with Han.
+		case unicode.Is(unicode.Han, r):
+			counts["zh"]++
+		default:
+			for _, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[s.lang]++
+					break
+				}
+			}
+		}
+	}
+	best, most := "", 0
+	for lang, n := range counts {
+		if n > most || n == most && lang < best {
+			best, most = lang, n
+		}
+	}
+	if most*2 > letters {
+		if best == "zh" && counts["ja"] > 0 {
+			return "ja"
+		}
+		return best
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool { return !unicode.IsLetter(r) })
+	score := make(map[string]int)
+	for _, w := range words {
+		for lang, list := range stopwords {
+			if slices.Contains(list, w) {
+				score[lang]++
+			}
+		}
+	}
+	best, most = "", 0
+	for lang, n := range score {
+		if n > most || n == most && lang < best {
+			best, most = lang, n
+		}
+	}
+	return best
+}
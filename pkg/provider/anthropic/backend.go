@@ -0,0 +1,387 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Claude is also served on Vertex AI and Amazon Bedrock, selected with
+// WithVertex and WithBedrock. Both take the same Messages
+// request and response bodies, with the model in the URL and the API version
+// in the body.
+const (
+	vertexVersion  = "vertex-2023-10-16"
+	bedrockVersion = "bedrock-2023-05-31"
+)
+
+// WithVertex makes the client call Claude on Vertex AI in the given project
+// and region instead of the Anthropic API. Without an access token or token
+// source, it authenticates with Application Default Credentials. For Gemini
+// on Vertex AI, use the vertex provider.
+func WithVertex(projectID, location string) provider.Option {
+	return func(c *provider.Config) {
+		c.Vertex = true
+		c.ProjectID = projectID
+		c.Location = location
+	}
+}
+
+// WithBedrock makes the client call Claude on Amazon Bedrock in the given
+// region. An API key is sent as a Bedrock API key; otherwise requests are
+// signed with AWS credentials (provider.WithAWSCredentials).
+func WithBedrock(region string) provider.Option {
+	return func(c *provider.Config) {
+		c.Bedrock = true
+		c.Location = region
+	}
+}
+
+// hosted reports whether the client calls Claude on Vertex AI or Bedrock.
+func (c *Client) hosted() bool {
+	return c.config.Vertex || c.config.Bedrock
+}
+
+// errAnthropicAPIOnly is returned by operations that only Anthropic's own API
+// supports when the client calls Vertex AI or Bedrock.
+func errAnthropicAPIOnly(operation string) error {
+	return errors.ErrInvalidRequest(operation + " is only available through the Anthropic API, not Vertex AI or Bedrock").WithProvider(types.ProviderAnthropic)
+}
+
+// newMessagesRequest builds a Messages API request for the configured backend.
+func (c *Client) newMessagesRequest(ctx context.Context, anthReq *MessagesRequest) (*http.Request, error) {
+	model, stream := anthReq.Model, anthReq.Stream
+	extended := needsExtendedOutput(model, anthReq.MaxTokens)
+
+	endpoint := c.baseURL + "/v1/messages"
+	switch {
+	case c.config.Vertex:
+		action := "rawPredict"
+		if stream {
+			action = "streamRawPredict"
+		}
+		endpoint = fmt.Sprintf("%s/projects/%s/locations/%s/publishers/anthropic/models/%s:%s",
+			c.baseURL, c.config.ProjectID, c.config.Location, model, action)
+		hostedReq := *anthReq
+		hostedReq.Model = ""
+		hostedReq.ServiceTier = ""
+		hostedReq.AnthropicVersion = vertexVersion
+		anthReq = &hostedReq
+
+	case c.config.Bedrock:
+		action := "invoke"
+		if stream {
+			action = "invoke-with-response-stream"
+		}
+		endpoint = c.baseURL + "/model/" + awsEscape(model) + "/" + action
+		hostedReq := *anthReq
+		hostedReq.Model = ""
+		hostedReq.Stream = false
+		hostedReq.ServiceTier = ""
+		hostedReq.AnthropicVersion = bedrockVersion
+		if extended {
+			hostedReq.AnthropicBeta = []string{extendedOutputBeta}
+		}
+		anthReq = &hostedReq
+	}
+
+	body, err := json.Marshal(anthReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	switch {
+	case c.config.Vertex:
+		httpReq.Header.Set("Content-Type", "application/json")
+		token := c.config.AccessToken
+		if token == "" && c.config.TokenSource != nil {
+			if token, err = c.config.TokenSource.Token(ctx); err != nil {
+				return nil, errors.ErrAuthentication(types.ProviderAnthropic, "failed to get access token").WithCause(err)
+			}
+		}
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+		if extended {
+			httpReq.Header.Set("anthropic-beta", extendedOutputBeta)
+		}
+
+	case c.config.Bedrock:
+		httpReq.Header.Set("Content-Type", "application/json")
+		if c.config.APIKey != "" {
+			httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+		} else if creds := c.config.AWSCredentials; creds != nil && creds.AccessKeyID != "" {
+			signV4(httpReq, body, creds, c.config.Location, "bedrock", time.Now())
+		} else {
+			return nil, errors.ErrAuthentication(types.ProviderAnthropic, "no Bedrock API key or AWS credentials configured")
+		}
+
+	default:
+		c.setHeaders(httpReq)
+		if extended {
+			httpReq.Header.Set("anthropic-beta", betaHeader+","+extendedOutputBeta)
+		}
+	}
+	return httpReq, nil
+}
+
+// awsCredentialsFromEnv reads AWS credentials from the standard environment
+// variables.
+func awsCredentialsFromEnv() *provider.AWSCredentials {
+	return &provider.AWSCredentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+}
+
+// signV4 signs req with AWS Signature Version 4. It signs the host, the
+// x-amz-* headers, and Content-Type.
+func signV4(req *http.Request, body []byte, creds *provider.AWSCredentials, region, service string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	// Services other than S3 sign each path segment encoded twice.
+	segments := strings.Split(req.URL.EscapedPath(), "/")
+	for i, s := range segments {
+		segments[i] = awsEscape(s)
+	}
+	canonicalURI := strings.Join(segments, "/")
+	if canonicalURI == "" {
+		canonicalURI = "/"
+	}
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalURI,
+		req.URL.Query().Encode(),
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+creds.SecretAccessKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// awsEscape percent-encodes every byte except unreserved characters, as
+// SigV4 requires.
+func awsEscape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// eventStreamBody converts a Bedrock response stream, in the binary AWS
+// event stream encoding, to the server-sent events of the Anthropic API so
+// the stream reader can parse it.
+type eventStreamBody struct {
+	body io.ReadCloser
+	buf  bytes.Buffer
+	err  error
+}
+
+func newEventStreamBody(body io.ReadCloser) *eventStreamBody {
+	return &eventStreamBody{body: body}
+}
+
+func (b *eventStreamBody) Read(p []byte) (int, error) {
+	for b.buf.Len() == 0 && b.err == nil {
+		b.err = b.readMessage()
+	}
+	if b.buf.Len() > 0 {
+		return b.buf.Read(p)
+	}
+	return 0, b.err
+}
+
+func (b *eventStreamBody) Close() error {
+	return b.body.Close()
+}
+
+// readMessage decodes one event stream message into buf.
+func (b *eventStreamBody) readMessage() error {
+	var prelude [12]byte
+	if _, err := io.ReadFull(b.body, prelude[:]); err != nil {
+		if err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("bedrock event stream: truncated message")
+		}
+		return err
+	}
+	total := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return fmt.Errorf("bedrock event stream: prelude checksum mismatch")
+	}
+	if total < 16+headersLen || total > 16<<20 {
+		return fmt.Errorf("bedrock event stream: invalid message length %d", total)
+	}
+
+	rest := make([]byte, total-12)
+	if _, err := io.ReadFull(b.body, rest); err != nil {
+		return fmt.Errorf("bedrock event stream: truncated message")
+	}
+	crc := crc32.NewIEEE()
+	crc.Write(prelude[:])
+	crc.Write(rest[:len(rest)-4])
+	if crc.Sum32() != binary.BigEndian.Uint32(rest[len(rest)-4:]) {
+		return fmt.Errorf("bedrock event stream: message checksum mismatch")
+	}
+
+	headers, err := parseEventHeaders(rest[:headersLen])
+	if err != nil {
+		return err
+	}
+	payload := rest[headersLen : len(rest)-4]
+
+	if headers[":message-type"] == "exception" || headers[":message-type"] == "error" {
+		var exc struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(payload, &exc)
+		if exc.Message == "" {
+			exc.Message = headers[":error-message"]
+		}
+		excType := headers[":exception-type"]
+		if excType == "" {
+			excType = headers[":error-code"]
+		}
+		data, _ := json.Marshal(map[string]any{"type": "error", "error": APIError{Type: excType, Message: exc.Message}})
+		fmt.Fprintf(&b.buf, "event: error\ndata: %s\n\n", data)
+		return nil
+	}
+	if headers[":event-type"] != "chunk" {
+		return nil
+	}
+
+	var chunk struct {
+		Bytes []byte `json:"bytes"`
+	}
+	if err := json.Unmarshal(payload, &chunk); err != nil {
+		return fmt.Errorf("bedrock event stream: %w", err)
+	}
+	var event struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(chunk.Bytes, &event); err != nil {
+		return fmt.Errorf("bedrock event stream: %w", err)
+	}
+	var data bytes.Buffer
+	if err := json.Compact(&data, chunk.Bytes); err != nil {
+		return fmt.Errorf("bedrock event stream: %w", err)
+	}
+	fmt.Fprintf(&b.buf, "event: %s\ndata: %s\n\n", event.Type, data.Bytes())
+	return nil
+}
+
+// parseEventHeaders decodes event stream headers, keeping string values.
+func parseEventHeaders(data []byte) (map[string]string, error) {
+	headers := make(map[string]string)
+	for len(data) > 0 {
+		nameLen := int(data[0])
+		if len(data) < 2+nameLen {
+			return nil, fmt.Errorf("bedrock event stream: malformed header")
+		}
+		name := string(data[1 : 1+nameLen])
+		valueType := data[1+nameLen]
+		data = data[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // true, false
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(data) < 2 {
+				return nil, fmt.Errorf("bedrock event stream: malformed header")
+			}
+			size = 2 + int(binary.BigEndian.Uint16(data))
+		default:
+			return nil, fmt.Errorf("bedrock event stream: unknown header type %d", valueType)
+		}
+		if len(data) < size {
+			return nil, fmt.Errorf("bedrock event stream: malformed header")
+		}
+		if valueType == 7 {
+			headers[name] = string(data[2:size])
+		}
+		data = data[size:]
+	}
+	return headers, nil
+}
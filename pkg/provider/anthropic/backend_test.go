@@ -0,0 +1,249 @@
+package anthropic
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	stderrors "errors"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const helloResponse = `{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`
+
+func helloRequest(model string) *types.CompletionRequest {
+	return &types.CompletionRequest{
+		Model:     model,
+		MaxTokens: types.Ptr(64),
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	}
+}
+
+func TestComplete_Vertex(t *testing.T) {
+	var path, auth string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path, auth = r.URL.Path, r.Header.Get("Authorization")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(helloResponse))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithVertex("my-project", "us-east5"),
+		provider.WithAccessToken("ya29.token"),
+		provider.WithBaseURL(server.URL),
+	)
+	resp, err := client.Complete(context.Background(), helloRequest("claude-sonnet-4@20250514"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != "Hi" {
+		t.Errorf("text = %q", resp.Text())
+	}
+
+	if want := "/projects/my-project/locations/us-east5/publishers/anthropic/models/claude-sonnet-4@20250514:rawPredict"; path != want {
+		t.Errorf("path = %q, want %q", path, want)
+	}
+	if auth != "Bearer ya29.token" {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if body["anthropic_version"] != vertexVersion || body["model"] != nil {
+		t.Errorf("body = %v, want version and no model", body)
+	}
+	if client.SupportsFeature(types.FeatureBatch) || client.SupportsFeature(types.FeatureMCP) {
+		t.Error("expected batch and MCP to be unsupported on Vertex AI")
+	}
+}
+
+func TestComplete_Bedrock(t *testing.T) {
+	var rawPath, auth, token string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPath, auth, token = r.URL.EscapedPath(), r.Header.Get("Authorization"), r.Header.Get("X-Amz-Security-Token")
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(helloResponse))
+	}))
+	defer server.Close()
+
+	client := New(
+		WithBedrock("us-west-2"),
+		provider.WithAWSCredentials(provider.AWSCredentials{AccessKeyID: "AKID", SecretAccessKey: "secret", SessionToken: "session"}),
+		provider.WithBaseURL(server.URL),
+	)
+	req := helloRequest("anthropic.claude-sonnet-4-20250514-v1:0")
+	req.ServiceTier = types.ServiceTierPriority
+	if _, err := client.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "/model/anthropic.claude-sonnet-4-20250514-v1%3A0/invoke"; rawPath != want {
+		t.Errorf("path = %q, want %q", rawPath, want)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/us-west-2/bedrock/aws4_request") {
+		t.Errorf("Authorization = %q, want a SigV4 signature", auth)
+	}
+	if token != "session" {
+		t.Errorf("X-Amz-Security-Token = %q", token)
+	}
+	if body["anthropic_version"] != bedrockVersion || body["model"] != nil || body["stream"] != nil || body["service_tier"] != nil {
+		t.Errorf("body = %v, want version and no model, stream, or service tier", body)
+	}
+}
+
+func TestComplete_BedrockError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"message":"Too many requests, please wait before trying again."}`))
+	}))
+	defer server.Close()
+
+	client := New(WithBedrock("us-east-1"), provider.WithAPIKey("bedrock-key"), provider.WithBaseURL(server.URL))
+	_, err := client.Complete(context.Background(), helloRequest("anthropic.claude-sonnet-4-20250514-v1:0"))
+	if err == nil || !strings.Contains(err.Error(), "Too many requests") {
+		t.Errorf("err = %v, want the Bedrock message", err)
+	}
+}
+
+func TestPing_Bedrock(t *testing.T) {
+	var rawPath string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawPath = r.URL.EscapedPath()
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Write([]byte(helloResponse))
+	}))
+	defer server.Close()
+
+	client := New(WithBedrock("us-east-1"), provider.WithAPIKey("bedrock-key"), provider.WithBaseURL(server.URL))
+	if err := provider.Ping(context.Background(), client); err != nil {
+		t.Fatal(err)
+	}
+	if want := "/model/anthropic.claude-3-5-haiku-20241022-v1%3A0/invoke"; rawPath != want {
+		t.Errorf("path = %q, want %q", rawPath, want)
+	}
+	if body["max_tokens"] != float64(1) {
+		t.Errorf("max_tokens = %v, want 1", body["max_tokens"])
+	}
+}
+
+func TestListModels_Hosted(t *testing.T) {
+	for _, opt := range []provider.Option{WithVertex("my-project", "us-east5"), WithBedrock("us-east-1")} {
+		client := New(opt, provider.WithAccessToken("token"), provider.WithAPIKey("key"), provider.WithBaseURL("http://127.0.0.1:0"))
+		_, err := client.ListModels(context.Background())
+		if !stderrors.Is(err, errors.NewError(errors.ErrCodeUnsupportedFeature, "")) {
+			t.Errorf("err = %v, want unsupported feature", err)
+		}
+	}
+}
+
+// eventMessage encodes one AWS event stream message.
+func eventMessage(headers map[string]string, payload []byte) []byte {
+	var h bytes.Buffer
+	for name, value := range headers {
+		h.WriteByte(byte(len(name)))
+		h.WriteString(name)
+		h.WriteByte(7)
+		binary.Write(&h, binary.BigEndian, uint16(len(value)))
+		h.WriteString(value)
+	}
+
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, uint32(16+h.Len()+len(payload)))
+	binary.Write(&msg, binary.BigEndian, uint32(h.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(h.Bytes())
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func chunkMessage(event string) []byte {
+	payload, _ := json.Marshal(map[string][]byte{"bytes": []byte(event)})
+	return eventMessage(map[string]string{":message-type": "event", ":event-type": "chunk", ":content-type": "application/json"}, payload)
+}
+
+func TestStream_Bedrock(t *testing.T) {
+	var stream bytes.Buffer
+	for _, event := range []string{
+		`{"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4"}}`,
+		`{"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`{"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Hi there"}}`,
+		`{"type":"content_block_stop","index":0}`,
+		`{"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":2}}`,
+		`{"type":"message_stop"}`,
+	} {
+		stream.Write(chunkMessage(event))
+	}
+
+	var path string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		w.Header().Set("Content-Type", "application/vnd.amazon.eventstream")
+		w.Write(stream.Bytes())
+	}))
+	defer server.Close()
+
+	client := New(WithBedrock("us-east-1"), provider.WithAPIKey("bedrock-key"), provider.WithBaseURL(server.URL))
+	reader, err := client.Stream(context.Background(), helloRequest("anthropic.claude-sonnet-4-20250514-v1:0"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	for {
+		event, err := reader.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+	}
+
+	if !strings.HasSuffix(path, "/invoke-with-response-stream") {
+		t.Errorf("path = %q", path)
+	}
+	if resp := reader.Response(); resp == nil || resp.Text() != "Hi there" || resp.StopReason != types.StopReasonEnd {
+		t.Errorf("response = %+v", resp)
+	}
+}
+
+func TestEventStreamBody_Exception(t *testing.T) {
+	msg := eventMessage(map[string]string{":message-type": "exception", ":exception-type": "throttlingException"}, []byte(`{"message":"slow down"}`))
+	data, err := io.ReadAll(newEventStreamBody(io.NopCloser(bytes.NewReader(msg))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "event: error\ndata: {\"error\":{\"type\":\"throttlingException\",\"message\":\"slow down\"},\"type\":\"error\"}\n\n"
+	if string(data) != want {
+		t.Errorf("got %q, want %q", data, want)
+	}
+
+	corrupt := bytes.Clone(msg)
+	corrupt[len(corrupt)-1] ^= 0xff
+	if _, err := io.ReadAll(newEventStreamBody(io.NopCloser(bytes.NewReader(corrupt)))); err == nil {
+		t.Error("expected a checksum error")
+	}
+}
+
+// TestSignV4 checks the "get-vanilla" case of the AWS SigV4 test suite.
+func TestSignV4(t *testing.T) {
+	req, _ := http.NewRequest("GET", "https://example.amazonaws.com/", nil)
+	creds := &provider.AWSCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	signV4(req, nil, creds, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
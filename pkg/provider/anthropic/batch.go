@@ -1,11 +1,13 @@
 package anthropic
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
+	"io"
+	"iter"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
@@ -16,11 +18,21 @@ import (
 
 // CreateBatch creates a new batch job.
 func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.BatchJob, error) {
+	if c.hosted() {
+		return nil, errAnthropicAPIOnly("batch")
+	}
 	// Build batch request items
 	items := make([]BatchRequestItem, len(requests))
+	extended := false
 	for i, req := range requests {
 		anthReq := c.transformer.TransformRequest(req.Request)
 		anthReq.Stream = false
+		extended = extended || needsExtendedOutput(anthReq.Model, anthReq.MaxTokens)
+		if c.transformer.PrefillsJSON(req.Request) {
+			// Results are read without their requests, so the prefilled
+			// "{" could not be restored; rely on the instruction alone.
+			anthReq.Messages = anthReq.Messages[:len(anthReq.Messages)-1]
+		}
 		items[i] = BatchRequestItem{
 			CustomID: req.CustomID,
 			Params:   *anthReq,
@@ -40,10 +52,13 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 	}
 
 	c.setHeaders(httpReq)
+	if extended {
+		httpReq.Header.Set("anthropic-beta", betaHeader+","+extendedOutputBeta)
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -68,9 +83,9 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -88,6 +103,16 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 // GetBatchResults retrieves the results of a completed batch job.
 func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provider.BatchResult, error) {
+	seq, err := c.GetBatchResultsIter(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.CollectBatchResults(seq)
+}
+
+// GetBatchResultsIter streams the results of a completed batch job, decoding
+// the results file line by line.
+func (c *Client) GetBatchResultsIter(ctx context.Context, batchID string) (iter.Seq2[provider.BatchResult, error], error) {
 	// First get the batch to get the results URL
 	job, err := c.GetBatch(ctx, batchID)
 	if err != nil {
@@ -99,7 +124,44 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 		return nil, errors.ErrInvalidRequest("batch has no results URL").WithProvider(types.ProviderAnthropic)
 	}
 
-	// Download the results
+	return func(yield func(provider.BatchResult, error) bool) {
+		body, err := c.downloadResults(ctx, resultsURL)
+		if err != nil {
+			yield(provider.BatchResult{}, err)
+			return
+		}
+		defer body.Close()
+
+		for data, err := range provider.JSONLines(body) {
+			if err != nil {
+				yield(provider.BatchResult{}, errors.ErrServerError(types.ProviderAnthropic, "failed to read response").WithCause(err))
+				return
+			}
+
+			var item BatchResultItem
+			if err := json.Unmarshal(data, &item); err != nil {
+				continue
+			}
+
+			result := provider.BatchResult{
+				CustomID: item.CustomID,
+			}
+
+			if item.Result.Type == "succeeded" && item.Result.Message != nil {
+				result.Response = c.transformer.TransformResponse(item.Result.Message)
+			} else if item.Result.Error != nil {
+				result.Error = errors.ErrServerError(types.ProviderAnthropic, item.Result.Error.Message)
+			}
+
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// downloadResults opens the JSONL results of a batch.
+func (c *Client) downloadResults(ctx context.Context, resultsURL string) (io.ReadCloser, error) {
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", resultsURL, nil)
 	if err != nil {
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
@@ -107,40 +169,17 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	// Parse JSONL results
-	var results []provider.BatchResult
-	scanner := bufio.NewScanner(resp.Body)
-
-	for scanner.Scan() {
-		var item BatchResultItem
-		if err := json.Unmarshal(scanner.Bytes(), &item); err != nil {
-			continue
-		}
-
-		result := provider.BatchResult{
-			CustomID: item.CustomID,
-		}
-
-		if item.Result.Type == "succeeded" && item.Result.Message != nil {
-			result.Response = c.transformer.TransformResponse(item.Result.Message)
-		} else if item.Result.Error != nil {
-			result.Error = errors.ErrServerError(types.ProviderAnthropic, item.Result.Error.Message)
-		}
-
-		results = append(results, result)
-	}
-
-	return results, scanner.Err()
+	return resp.Body, nil
 }
 
 // CancelBatch cancels a batch job.
@@ -152,9 +191,9 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -167,38 +206,49 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 // ListBatches lists all batch jobs.
 func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
-	url := c.baseURL + "/v1/messages/batches"
+	jobs, _, err := c.listBatchesPage(ctx, opts)
+	return jobs, err
+}
+
+// ListAllBatches lists every batch job, following the after_id cursor.
+func (c *Client) ListAllBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
+	return provider.ListAllBatches(ctx, opts, c.listBatchesPage)
+}
+
+// listBatchesPage fetches one page of batch jobs and the cursor for the next.
+func (c *Client) listBatchesPage(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, string, error) {
+	if c.hosted() {
+		return nil, "", errAnthropicAPIOnly("batch")
+	}
+	endpoint := c.baseURL + "/v1/messages/batches"
 	if opts != nil {
-		params := "?"
+		params := url.Values{}
 		if opts.Limit > 0 {
-			params += "limit=" + strconv.Itoa(opts.Limit)
+			params.Set("limit", strconv.Itoa(opts.Limit))
 		}
 		if opts.After != "" {
-			if params != "?" {
-				params += "&"
-			}
-			params += "after_id=" + opts.After
+			params.Set("after_id", opts.After)
 		}
-		if params != "?" {
-			url += params
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		return nil, "", errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp)
+		return nil, "", c.handleErrorResponse(resp)
 	}
 
 	var list struct {
@@ -209,7 +259,7 @@ func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOption
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
-		return nil, errors.ErrServerError(types.ProviderAnthropic, "failed to decode response").WithCause(err)
+		return nil, "", errors.ErrServerError(types.ProviderAnthropic, "failed to decode response").WithCause(err)
 	}
 
 	jobs := make([]provider.BatchJob, len(list.Data))
@@ -217,7 +267,12 @@ func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOption
 		jobs[i] = *c.convertBatchJob(&batch)
 	}
 
-	return jobs, nil
+	var next string
+	if list.HasMore {
+		next = list.LastID
+	}
+
+	return jobs, next, nil
 }
 
 // convertBatchJob converts Anthropic batch to provider batch job.
@@ -276,3 +331,9 @@ func (c *Client) convertBatchStatus(status string) provider.BatchStatus {
 
 // Ensure Client implements provider.BatchProvider
 var _ provider.BatchProvider = (*Client)(nil)
+
+// Ensure Client implements provider.BatchResultsIterator
+var _ provider.BatchResultsIterator = (*Client)(nil)
+
+// Ensure Client implements provider.BatchLister
+var _ provider.BatchLister = (*Client)(nil)
@@ -0,0 +1,107 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCreateBatch_StructuredToolsVision(t *testing.T) {
+	var got BatchRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"id":"msgbatch_1","type":"message_batch","processing_status":"in_progress"}`)
+	}))
+	defer server.Close()
+
+	structured := (&types.CompletionRequest{
+		Model: "claude-sonnet-4-5",
+		Messages: []types.Message{{Role: types.RoleUser, Content: []types.ContentBlock{
+			{Type: types.ContentTypeText, Text: "Describe the image."},
+			{Type: types.ContentTypeImage, ImageBase64: "iVBORw0KGgo=", MediaType: "image/png"},
+		}}},
+		Stream: true,
+	}).WithJSONSchema("caption", types.JSONSchema{
+		Type:       "object",
+		Properties: map[string]types.JSONSchema{"caption": {Type: "string"}},
+	})
+	tools := &types.CompletionRequest{
+		Model:      "claude-sonnet-4-5",
+		Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "Look up cats.")},
+		Tools:      []types.Tool{{Name: "lookup", Parameters: types.JSONSchema{Type: "object"}}},
+		ToolChoice: &types.ToolChoice{Type: types.ToolChoiceRequired},
+	}
+
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	_, err := c.CreateBatch(context.Background(), []provider.BatchRequest{
+		{CustomID: "json", Request: structured},
+		{CustomID: "tool", Request: tools},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(got.Requests) != 2 {
+		t.Fatalf("got %d requests, want 2", len(got.Requests))
+	}
+	params := got.Requests[0].Params
+	if params.Stream {
+		t.Error("batch request streams")
+	}
+	if oc := params.OutputConfig; oc == nil || oc.Format == nil || oc.Format.Type != "json_schema" || oc.Format.Schema["type"] != "object" {
+		t.Errorf("output config = %+v", params.OutputConfig)
+	}
+	content, _ := json.Marshal(params.Messages[0].Content)
+	var blocks []map[string]any
+	json.Unmarshal(content, &blocks)
+	if len(blocks) != 2 || blocks[1]["type"] != "image" {
+		t.Errorf("content = %s, want the image", content)
+	}
+
+	params = got.Requests[1].Params
+	if len(params.Tools) != 1 || params.Tools[0].Name != "lookup" || params.ToolChoice == nil || params.ToolChoice.Type != "any" {
+		t.Errorf("tools = %+v, tool choice = %+v", params.Tools, params.ToolChoice)
+	}
+}
+
+func TestGetBatchResults_StructuredAndToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/messages/batches/msgbatch_1":
+			fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"ended","results_url":"http://%s/results"}`, r.Host)
+		case "/results":
+			fmt.Fprintln(w, `{"custom_id":"json","result":{"type":"succeeded","message":{"id":"msg_1","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"text","text":"{\"caption\":\"A cat\"}"}],"stop_reason":"end_turn"}}}`)
+			fmt.Fprintln(w, `{"custom_id":"tool","result":{"type":"succeeded","message":{"id":"msg_2","role":"assistant","model":"claude-sonnet-4-5","content":[{"type":"tool_use","id":"toolu_1","name":"lookup","input":{"q":"cat"}}],"stop_reason":"tool_use"}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	results, err := c.GetBatchResults(context.Background(), "msgbatch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if text := results[0].Response.Text(); text != `{"caption":"A cat"}` {
+		t.Errorf("json text = %q", text)
+	}
+	resp := results[1].Response
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	if input, _ := resp.ToolCalls[0].Input.(map[string]any); resp.ToolCalls[0].ID != "toolu_1" || input["q"] != "cat" || resp.StopReason != types.StopReasonToolUse {
+		t.Errorf("tool call = %+v, stop reason %s", resp.ToolCalls[0], resp.StopReason)
+	}
+}
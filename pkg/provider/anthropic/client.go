@@ -1,17 +1,22 @@
-// Package anthropic provides an Anthropic API client implementation.
+// Package anthropic provides an Anthropic API client implementation. With
+// WithVertex or WithBedrock, the client calls Claude on
+// Vertex AI or Amazon Bedrock instead.
 package anthropic
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
 	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -19,7 +24,21 @@ import (
 const (
 	defaultBaseURL = "https://api.anthropic.com"
 	defaultVersion = "2023-06-01"
-	betaHeader     = "prompt-caching-2024-07-31,output-128k-2025-02-19"
+	betaHeader     = "prompt-caching-2024-07-31,mcp-client-2025-04-04"
+
+	// extendedOutputBeta raises the output limit of Claude 3.7 Sonnet to
+	// 128k tokens. It is only sent with requests that need it.
+	extendedOutputBeta = "output-128k-2025-02-19"
+
+	// standardOutputLimit is the highest max_tokens of any model without
+	// the extended output beta, for models missing from the catalog.
+	standardOutputLimit = 64000
+
+	// longRequestMaxTokens is the max_tokens above which Complete streams
+	// the response. Generating that many tokens can take over ten minutes,
+	// longer than idle connections reliably stay open, so Anthropic
+	// requires streaming for such requests.
+	longRequestMaxTokens = 21333
 )
 
 // Client is an Anthropic API client.
@@ -29,6 +48,10 @@ type Client struct {
 	baseURL     string
 	version     string
 	transformer *Transformer
+
+	// listed holds the models of the last successful ListModels call.
+	mu     sync.Mutex
+	listed []provider.ModelInfo
 }
 
 // New creates a new Anthropic client.
@@ -37,20 +60,26 @@ func New(opts ...provider.Option) *Client {
 	provider.ApplyOptions(cfg, opts...)
 
 	baseURL := defaultBaseURL
+	switch {
+	case cfg.Vertex:
+		baseURL = provider.VertexBaseURL(cfg.Location)
+	case cfg.Bedrock:
+		baseURL = "https://bedrock-runtime." + cfg.Location + ".amazonaws.com"
+	}
 	if cfg.BaseURL != "" {
 		baseURL = cfg.BaseURL
 	}
 
-	httpClient := cfg.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: time.Duration(cfg.Timeout) * time.Second,
-		}
+	if cfg.Vertex && cfg.AccessToken == "" && cfg.TokenSource == nil {
+		cfg.TokenSource = provider.DefaultCredentials(nil)
+	}
+	if cfg.Bedrock && cfg.APIKey == "" && cfg.AWSCredentials == nil {
+		cfg.AWSCredentials = awsCredentialsFromEnv()
 	}
 
 	return &Client{
 		config:      cfg,
-		httpClient:  httpClient,
+		httpClient:  provider.NewHTTPClient(cfg),
 		baseURL:     baseURL,
 		version:     defaultVersion,
 		transformer: NewTransformer(),
@@ -69,48 +98,211 @@ func (c *Client) SupportsFeature(feature types.Feature) bool {
 		types.FeatureStructuredOutput,
 		types.FeatureTools,
 		types.FeatureVision,
-		types.FeatureBatch:
+		types.FeaturePrefill:
 		return true
+	case types.FeatureBatch, types.FeatureMCP, types.FeatureTokenCounting:
+		return !c.hosted()
 	case types.FeatureJSON:
-		return false // Anthropic doesn't have simple JSON mode, only structured output
+		return true // Emulated with a system instruction and a prefilled "{"
 	default:
 		return false
 	}
 }
 
-// Models returns available Anthropic models.
+// builtinModels are the current Claude models, for Models before the model
+// list has been fetched.
+var builtinModels = []string{
+	"claude-opus-4-5-20251101",
+	"claude-sonnet-4-5-20250929",
+	"claude-haiku-4-5-20251001",
+	"claude-opus-4-1-20250805",
+	"claude-opus-4-20250514",
+	"claude-sonnet-4-20250514",
+	"claude-3-haiku-20240307",
+}
+
+// Models returns available Anthropic models: those the API reported the
+// last time ListModels ran, so retired models drop out, or a built-in list
+// of current models before then.
 func (c *Client) Models() []string {
-	return []string{
-		"claude-sonnet-4-20250514",
-		"claude-opus-4-20250514",
-		"claude-3-5-sonnet-20241022",
-		"claude-3-5-haiku-20241022",
-		"claude-3-opus-20240229",
-		"claude-3-sonnet-20240229",
-		"claude-3-haiku-20240307",
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.listed == nil {
+		return slices.Clone(builtinModels)
 	}
+	ids := make([]string, len(c.listed))
+	for i, m := range c.listed {
+		ids[i] = m.ID
+	}
+	return ids
 }
 
-// Complete sends a completion request.
-func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
-	anthReq := c.transformer.TransformRequest(req)
-	anthReq.Stream = false
+// Model returns what the last ListModels call reported about a model, such
+// as its display name and limits.
+func (c *Client) Model(id string) (provider.ModelInfo, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	i := slices.IndexFunc(c.listed, func(m provider.ModelInfo) bool { return m.ID == id })
+	if i < 0 {
+		return provider.ModelInfo{}, false
+	}
+	return c.listed[i], true
+}
+
+// Ping checks the credentials by listing models. On Vertex AI and Bedrock,
+// where the model list is built in, it sends a 1-token completion to Claude
+// 3.5 Haiku instead.
+func (c *Client) Ping(ctx context.Context) error {
+	switch {
+	case c.config.Vertex:
+		return provider.PingCompletion(ctx, c, "claude-3-5-haiku@20241022")
+	case c.config.Bedrock:
+		return provider.PingCompletion(ctx, c, "anthropic.claude-3-5-haiku-20241022-v1:0")
+	}
+	_, err := c.ListModels(ctx)
+	return err
+}
 
-	body, err := json.Marshal(anthReq)
+// ListModels fetches the models available to the API key, following
+// pagination. Every current Claude model accepts text and image input. The
+// API reports no limits, so context windows, output limits, and deprecation
+// come from the models catalog. The result is kept for Models and Model.
+//
+// Vertex AI and Bedrock have no model listing for Claude and name models
+// differently, so there it returns an unsupported feature error.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	if c.hosted() {
+		return nil, errors.ErrUnsupportedFeature(types.ProviderAnthropic, "model listing")
+	}
+
+	var listed []provider.ModelInfo
+	afterID := ""
+	for {
+		endpoint := c.baseURL + "/v1/models?limit=1000"
+		if afterID != "" {
+			endpoint += "&after_id=" + url.QueryEscape(afterID)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		}
+
+		c.setHeaders(httpReq)
+
+		resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := c.handleErrorResponse(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var list ModelList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.ErrServerError(types.ProviderAnthropic, "failed to decode response").WithCause(err)
+		}
+
+		for _, m := range list.Data {
+			info := provider.ModelInfo{
+				ID:              m.ID,
+				Provider:        types.ProviderAnthropic,
+				DisplayName:     m.DisplayName,
+				InputModalities: []string{"text", "image"},
+			}
+			if t, err := time.Parse(time.RFC3339, m.CreatedAt); err == nil {
+				info.CreatedAt = t
+			}
+			if known, ok := models.Lookup(types.ProviderAnthropic, m.ID); ok {
+				info.ContextWindow = known.ContextWindow
+				info.MaxOutputTokens = known.OutputLimit()
+				info.Deprecated = known.Deprecated
+			}
+			listed = append(listed, info)
+		}
+
+		if !list.HasMore || list.LastID == "" {
+			c.mu.Lock()
+			c.listed = listed
+			c.mu.Unlock()
+			return slices.Clone(listed), nil
+		}
+		afterID = list.LastID
+	}
+}
+
+// CountTokens counts the input tokens of a request with the token counting
+// endpoint, without running the model. The count covers everything the
+// request would send, including the system prompt, tools, and the
+// instructions added for JSON output.
+//
+// Vertex AI and Bedrock do not offer the endpoint.
+func (c *Client) CountTokens(ctx context.Context, req *types.CompletionRequest) (int, error) {
+	if c.hosted() {
+		return 0, errors.ErrUnsupportedFeature(types.ProviderAnthropic, types.FeatureTokenCounting)
+	}
+
+	anthReq := c.transformer.TransformRequest(req)
+	body, err := json.Marshal(&CountTokensRequest{
+		Model:      anthReq.Model,
+		Messages:   anthReq.Messages,
+		System:     anthReq.System,
+		Tools:      anthReq.Tools,
+		ToolChoice: anthReq.ToolChoice,
+		Thinking:   anthReq.Thinking,
+		MCPServers: anthReq.MCPServers,
+	})
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+		return 0, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewReader(body))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages/count_tokens", bytes.NewReader(body))
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		return 0, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, c.handleErrorResponse(resp)
+	}
+
+	var count CountTokensResponse
+	if err := json.NewDecoder(resp.Body).Decode(&count); err != nil {
+		return 0, errors.ErrServerError(types.ProviderAnthropic, "failed to decode response").WithCause(err)
+	}
+	return count.InputTokens, nil
+}
+
+// Complete sends a completion request. Requests for more than 21,333 output
+// tokens are streamed and the response is collected, since they may run
+// longer than a non-streaming request can stay open.
+func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	anthReq := c.transformer.TransformRequest(req)
+	anthReq.Stream = false
+	if anthReq.MaxTokens > longRequestMaxTokens {
+		return c.completeStreaming(ctx, req)
+	}
+
+	httpReq, err := c.newMessagesRequest(ctx, anthReq)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderAnthropic)
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -123,7 +315,47 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrServerError(types.ProviderAnthropic, "failed to decode response").WithCause(err)
 	}
 
-	return c.transformer.TransformResponse(&anthResp), nil
+	result := c.transformer.TransformResponse(&anthResp)
+	if c.transformer.PrefillsJSON(req) {
+		prependText(result, jsonPrefill)
+	}
+	result.ProviderMetadata.RequestID = provider.RequestID(resp.Header)
+	return result, nil
+}
+
+// completeStreaming completes a request by streaming it to the end.
+func (c *Client) completeStreaming(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	streamReq := *req
+	streamReq.Stream = true
+	stream, err := c.Stream(ctx, &streamReq)
+	if err != nil {
+		return nil, err
+	}
+	defer stream.Close()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			return nil, err
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventError {
+			return nil, event.Error
+		}
+	}
+
+	return stream.Response(), nil
+}
+
+// needsExtendedOutput reports whether a request for maxTokens output tokens
+// needs the extended output beta.
+func needsExtendedOutput(model string, maxTokens int) bool {
+	if info, ok := models.Lookup(types.ProviderAnthropic, model); ok {
+		return info.ExtendedOutputTokens > 0 && maxTokens > info.MaxOutputTokens
+	}
+	return maxTokens > standardOutputLimit
 }
 
 // Stream sends a streaming completion request.
@@ -131,21 +363,14 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 	anthReq := c.transformer.TransformRequest(req)
 	anthReq.Stream = true
 
-	body, err := json.Marshal(anthReq)
+	httpReq, err := c.newMessagesRequest(ctx, anthReq)
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+		return nil, err
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/messages", bytes.NewReader(body))
-	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
-	}
-
-	c.setHeaders(httpReq)
-
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderAnthropic)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderAnthropic, "request failed").WithCause(err)
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -153,7 +378,16 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	return newStreamReader(resp.Body, c.transformer), nil
+	body := resp.Body
+	if c.config.Bedrock {
+		body = newEventStreamBody(body)
+	}
+	stream := newStreamReader(ctx, body, c.transformer)
+	stream.meta.RequestID = provider.RequestID(resp.Header)
+	if c.transformer.PrefillsJSON(req) {
+		stream.prefix = jsonPrefill
+	}
+	return stream, nil
 }
 
 // setHeaders sets the required headers for Anthropic API requests.
@@ -168,24 +402,46 @@ func (c *Client) setHeaders(req *http.Request) {
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
+	// Bedrock errors are a bare {"message": ...}, and an overloaded edge
+	// may not answer in JSON at all.
+	apiErr := &APIError{Message: string(body)}
 	var errResp ErrorResponse
+	var bedrockErr APIError
 	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-		return c.mapAPIError(errResp.Error, resp.StatusCode)
+		apiErr = errResp.Error
+	} else if err := json.Unmarshal(body, &bedrockErr); err == nil && bedrockErr.Message != "" {
+		apiErr = &bedrockErr
 	}
 
-	return errors.ErrServerError(types.ProviderAnthropic, string(body)).WithStatusCode(resp.StatusCode)
+	rerr := mapAPIError(apiErr, resp.StatusCode)
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
 }
 
-// mapAPIError maps Anthropic API error to RouterError.
-func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
+// mapAPIError maps Anthropic API error to RouterError. Anthropic answers
+// 529 overloaded_error when the API is over capacity, and reports an empty
+// credit balance as a 400.
+func mapAPIError(apiErr *APIError, statusCode int) *errors.RouterError {
+	if apiErr.Type == "overloaded_error" {
+		return errors.ErrOverloaded(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
+	}
+
 	switch statusCode {
 	case http.StatusUnauthorized:
 		return errors.ErrInvalidAPIKey(types.ProviderAnthropic).WithStatusCode(statusCode)
+	case http.StatusPaymentRequired:
+		return errors.ErrQuotaExceeded(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusForbidden:
+		return errors.ErrAuthentication(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusTooManyRequests:
 		return errors.ErrRateLimit(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusNotFound:
 		return errors.ErrModelNotFound(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
+	case 529:
+		return errors.ErrOverloaded(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusBadRequest:
+		if strings.Contains(apiErr.Message, "credit balance") {
+			return errors.ErrQuotaExceeded(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
+		}
 		if strings.Contains(apiErr.Message, "context") || strings.Contains(apiErr.Message, "token") {
 			return errors.ErrContextLength(types.ProviderAnthropic, apiErr.Message).WithStatusCode(statusCode)
 		}
@@ -195,10 +451,24 @@ func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
 	}
 }
 
+// errorTypeStatus maps Anthropic error types to the HTTP status they are
+// returned with, so error events in a stream, which arrives with status 200,
+// are classified like error responses.
+var errorTypeStatus = map[string]int{
+	"invalid_request_error": http.StatusBadRequest,
+	"authentication_error":  http.StatusUnauthorized,
+	"permission_error":      http.StatusForbidden,
+	"not_found_error":       http.StatusNotFound,
+	"request_too_large":     http.StatusRequestEntityTooLarge,
+	"rate_limit_error":      http.StatusTooManyRequests,
+	"api_error":             http.StatusInternalServerError,
+	"overloaded_error":      529,
+}
+
 // streamReader implements types.StreamReader for Anthropic.
 type streamReader struct {
-	reader      *bufio.Reader
-	body        io.ReadCloser
+	lines       *provider.LineReader
+	body        *provider.StreamBody
 	transformer *Transformer
 	response    *types.CompletionResponse
 	done        bool
@@ -208,16 +478,26 @@ type streamReader struct {
 	model         string
 	contentBlocks []types.ContentBlock
 	currentBlock  int
+	texts         map[int]*strings.Builder
+	toolInputs    map[int]*strings.Builder
 	toolCalls     []types.ToolCall
 	usage         *types.Usage
+	inputUsage    Usage // input token counts from message_start
 	stopReason    types.StopReason
+	stopSequence  string
+	prefix        string // prefilled text, added to the first text delta
+	citations     map[int][]Citation
+	meta          types.ProviderMetadata
 }
 
-func newStreamReader(body io.ReadCloser, transformer *Transformer) *streamReader {
+func newStreamReader(ctx context.Context, body io.ReadCloser, transformer *Transformer) *streamReader {
+	streamBody := provider.NewStreamBody(ctx, body)
 	return &streamReader{
-		reader:      bufio.NewReader(body),
-		body:        body,
+		lines:       provider.NewLineReader(streamBody),
+		body:        streamBody,
 		transformer: transformer,
+		texts:       make(map[int]*strings.Builder),
+		toolInputs:  make(map[int]*strings.Builder),
 	}
 }
 
@@ -226,9 +506,12 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 	if s.done {
 		return nil, nil
 	}
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
 
 	for {
-		line, err := s.reader.ReadString('\n')
+		line, err := s.lines.Next()
 		if err != nil {
 			if err == io.EOF {
 				s.done = true
@@ -238,49 +521,68 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 			return nil, err
 		}
 
-		line = strings.TrimSpace(line)
-		if line == "" {
+		// Handle SSE format
+		name, ok := bytes.CutPrefix(line, []byte("event:"))
+		if !ok {
 			continue
 		}
+		// The line is overwritten by the next read.
+		eventType := internEventType(bytes.TrimSpace(name))
 
-		// Handle SSE format
-		if strings.HasPrefix(line, "event: ") {
-			// Read the data line
-			dataLine, err := s.reader.ReadString('\n')
-			if err != nil && err != io.EOF {
-				return nil, err
-			}
-			dataLine = strings.TrimSpace(dataLine)
+		// Read the data line
+		dataLine, err := s.lines.Next()
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		data, ok := provider.SSEData(dataLine)
+		if !ok {
+			continue
+		}
 
-			if !strings.HasPrefix(dataLine, "data: ") {
-				continue
-			}
+		event, done := s.processEvent(eventType, data)
+		if done {
+			s.done = true
+			s.buildResponse()
+		}
+		if event != nil {
+			return event, nil
+		}
+	}
+}
 
-			data := strings.TrimPrefix(dataLine, "data: ")
-			eventType := strings.TrimPrefix(line, "event: ")
+// eventTypes interns the stream's event types, so reading one does not
+// allocate a string.
+var eventTypes = map[string]string{
+	"message_start":       "message_start",
+	"content_block_start": "content_block_start",
+	"content_block_delta": "content_block_delta",
+	"content_block_stop":  "content_block_stop",
+	"message_delta":       "message_delta",
+	"message_stop":        "message_stop",
+	"ping":                "ping",
+	"error":               "error",
+}
 
-			event, done := s.processEvent(eventType, data)
-			if done {
-				s.done = true
-				s.buildResponse()
-			}
-			if event != nil {
-				return event, nil
-			}
-		}
+// internEventType returns the event type named by b.
+func internEventType(b []byte) string {
+	if name, ok := eventTypes[string(b)]; ok {
+		return name
 	}
+	return string(b)
 }
 
 // processEvent processes a stream event.
-func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent, bool) {
+func (s *streamReader) processEvent(eventType string, data []byte) (*types.StreamEvent, bool) {
 	switch eventType {
 	case "message_start":
 		var event struct {
 			Message MessagesResponse `json:"message"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			s.id = event.Message.ID
 			s.model = event.Message.Model
+			s.meta.ServiceTier = event.Message.Usage.ServiceTier
+			s.inputUsage = event.Message.Usage
 			return &types.StreamEvent{
 				Type:       types.StreamEventStart,
 				ResponseID: s.id,
@@ -293,7 +595,7 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 			Index        int          `json:"index"`
 			ContentBlock ContentBlock `json:"content_block"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			s.currentBlock = event.Index
 
 			// Ensure we have enough blocks
@@ -307,17 +609,20 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 					ToolUseID: event.ContentBlock.ID,
 					ToolName:  event.ContentBlock.Name,
 				}
+				s.toolInputs[event.Index] = &strings.Builder{}
 				return &types.StreamEvent{
 					Type: types.StreamEventToolCallStart,
 					ToolCall: &types.ToolCall{
 						ID:   event.ContentBlock.ID,
 						Name: event.ContentBlock.Name,
 					},
+					Index: event.Index,
 				}, false
 			} else {
 				s.contentBlocks[event.Index] = types.ContentBlock{
 					Type: types.ContentTypeText,
 				}
+				s.texts[event.Index] = &strings.Builder{}
 			}
 		}
 
@@ -326,11 +631,15 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 			Index int   `json:"index"`
 			Delta Delta `json:"delta"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			if event.Delta.Text != "" {
 				// Text delta
-				if event.Index < len(s.contentBlocks) {
-					s.contentBlocks[event.Index].Text += event.Delta.Text
+				if s.prefix != "" {
+					event.Delta.Text = s.prefix + event.Delta.Text
+					s.prefix = ""
+				}
+				if buf, ok := s.texts[event.Index]; ok {
+					buf.WriteString(event.Delta.Text)
 				}
 				return &types.StreamEvent{
 					Type: types.StreamEventContentDelta,
@@ -340,8 +649,25 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 					},
 					Index: event.Index,
 				}, false
-			} else if event.Delta.PartialJSON != "" {
-				// Tool input delta
+			} else if event.Delta.Thinking != "" {
+				return &types.StreamEvent{
+					Type: types.StreamEventThinkingDelta,
+					Delta: &types.ContentBlock{
+						Type: types.ContentTypeText,
+						Text: event.Delta.Thinking,
+					},
+					Index: event.Index,
+				}, false
+			} else if event.Delta.Citation != nil {
+				// Citation of the text block, added to the final response
+				if s.citations == nil {
+					s.citations = make(map[int][]Citation)
+				}
+				s.citations[event.Index] = append(s.citations[event.Index], *event.Delta.Citation)
+			} else if buf, ok := s.toolInputs[event.Index]; ok && event.Delta.PartialJSON != "" {
+				// Tool input delta. Input of server-executed tools such as
+				// mcp_tool_use blocks is not reported.
+				buf.WriteString(event.Delta.PartialJSON)
 				return &types.StreamEvent{
 					Type:           types.StreamEventToolCallDelta,
 					ToolInputDelta: event.Delta.PartialJSON,
@@ -354,8 +680,9 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 		var event struct {
 			Index int `json:"index"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			if event.Index < len(s.contentBlocks) && s.contentBlocks[event.Index].Type == types.ContentTypeToolUse {
+				s.contentBlocks[event.Index].ToolInput = s.parseToolInput(event.Index)
 				tc := types.ToolCall{
 					ID:    s.contentBlocks[event.Index].ToolUseID,
 					Name:  s.contentBlocks[event.Index].ToolName,
@@ -365,6 +692,7 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 				return &types.StreamEvent{
 					Type:     types.StreamEventToolCallEnd,
 					ToolCall: &tc,
+					Index:    event.Index,
 				}, false
 			}
 		}
@@ -374,11 +702,15 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 			Delta Delta `json:"delta"`
 			Usage Usage `json:"usage"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			s.stopReason = s.transformer.transformStopReason(event.Delta.StopReason)
+			s.stopSequence = event.Delta.StopSequence
 			if event.Usage.OutputTokens > 0 {
 				s.usage = &types.Usage{
+					InputTokens:  s.inputUsage.InputTokens,
 					OutputTokens: event.Usage.OutputTokens,
+					TotalTokens:  s.inputUsage.InputTokens + event.Usage.OutputTokens,
+					CachedTokens: s.inputUsage.CacheReadInputTokens,
 				}
 			}
 		}
@@ -395,10 +727,10 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 		var event struct {
 			Error APIError `json:"error"`
 		}
-		if err := json.Unmarshal([]byte(data), &event); err == nil {
+		if err := json.Unmarshal(data, &event); err == nil {
 			return &types.StreamEvent{
 				Type:  types.StreamEventError,
-				Error: errors.ErrServerError(types.ProviderAnthropic, event.Error.Message),
+				Error: mapAPIError(&event.Error, errorTypeStatus[event.Error.Type]),
 			}, true
 		}
 	}
@@ -406,24 +738,72 @@ func (s *streamReader) processEvent(eventType, data string) (*types.StreamEvent,
 	return nil, false
 }
 
+// parseToolInput parses the partial_json accumulated for a tool_use block.
+// Tools called without arguments produce no deltas and get an empty object,
+// matching non-streaming responses. Input that fails to parse is returned as
+// the raw string so callers can still inspect it.
+func (s *streamReader) parseToolInput(index int) any {
+	buf, ok := s.toolInputs[index]
+	delete(s.toolInputs, index)
+	if !ok || buf.Len() == 0 {
+		return map[string]any{}
+	}
+
+	var input any
+	if err := json.Unmarshal([]byte(buf.String()), &input); err != nil {
+		return buf.String()
+	}
+	return input
+}
+
 // buildResponse builds the final response from accumulated state.
 func (s *streamReader) buildResponse() {
+	for i, buf := range s.texts {
+		s.contentBlocks[i].Text = buf.String()
+	}
 	s.response = &types.CompletionResponse{
-		ID:         s.id,
-		Provider:   types.ProviderAnthropic,
-		Model:      s.model,
-		Content:    s.contentBlocks,
-		StopReason: s.stopReason,
-		ToolCalls:  s.toolCalls,
-		CreatedAt:  time.Now(),
+		ID:               s.id,
+		Provider:         types.ProviderAnthropic,
+		Model:            s.model,
+		Content:          s.content(),
+		StopReason:       s.stopReason,
+		StopSequence:     s.stopSequence,
+		ToolCalls:        s.toolCalls,
+		CreatedAt:        time.Now(),
+		ProviderMetadata: s.meta,
 	}
 
 	if s.usage != nil {
 		s.response.Usage = *s.usage
 	}
+	s.response.Citations = provider.CollectCitations(s.response.Content)
 }
 
-// Close closes the stream.
+// content returns the accumulated content blocks, with citations after the
+// text blocks they support.
+func (s *streamReader) content() []types.ContentBlock {
+	if len(s.citations) == 0 {
+		return s.contentBlocks
+	}
+	var content []types.ContentBlock
+	offset := 0
+	for i, block := range s.contentBlocks {
+		content = append(content, block)
+		if block.Type != types.ContentTypeText {
+			continue
+		}
+		for _, c := range s.citations[i] {
+			content = append(content, types.ContentBlock{
+				Type:     types.ContentTypeCitation,
+				Citation: transformCitation(c, block.Text, offset),
+			})
+		}
+		offset += len(block.Text)
+	}
+	return content
+}
+
+// Close closes the stream. It is idempotent and safe to call while Next is blocked.
 func (s *streamReader) Close() error {
 	return s.body.Close()
 }
@@ -433,5 +813,19 @@ func (s *streamReader) Response() *types.CompletionResponse {
 	return s.response
 }
 
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
 // Ensure Client implements provider.Provider
 var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
+
+// Ensure Client implements provider.Pinger
+var _ provider.Pinger = (*Client)(nil)
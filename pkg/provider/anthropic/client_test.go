@@ -0,0 +1,551 @@
+package anthropic
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const toolUseStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-haiku-4-5"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"tool_use","id":"toolu_1","name":"get_weather"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"location\": \"Par"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"is\", \"unit\": \"c\"}"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"tool_use","id":"toolu_2","name":"get_time"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":1}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"tool_use"},"usage":{"output_tokens":12}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+
+func TestStreamReader_AccumulatesToolInput(t *testing.T) {
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(toolUseStream)), NewTransformer())
+	defer stream.Close()
+
+	var ends []*types.ToolCall
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventToolCallEnd {
+			ends = append(ends, event.ToolCall)
+		}
+	}
+
+	if len(ends) != 2 {
+		t.Fatalf("expected 2 tool call end events, got %d", len(ends))
+	}
+	input, ok := ends[0].Input.(map[string]any)
+	if !ok || input["location"] != "Paris" || input["unit"] != "c" {
+		t.Errorf("expected parsed tool input, got %#v", ends[0].Input)
+	}
+	if empty, ok := ends[1].Input.(map[string]any); !ok || len(empty) != 0 {
+		t.Errorf("expected empty object for tool without arguments, got %#v", ends[1].Input)
+	}
+
+	resp := stream.Response()
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].Input == nil {
+		t.Fatalf("expected tool calls with input in final response, got %+v", resp.ToolCalls)
+	}
+	if resp.Content[0].ToolInput == nil {
+		t.Error("expected tool input on content block")
+	}
+	if resp.StopReason != types.StopReasonToolUse {
+		t.Errorf("expected tool_use stop reason, got %q", resp.StopReason)
+	}
+}
+
+func TestStreamReader_ErrorEvent(t *testing.T) {
+	tests := []struct {
+		errorType string
+		code      string
+	}{
+		{"overloaded_error", errors.ErrCodeOverloaded},
+		{"rate_limit_error", errors.ErrCodeRateLimit},
+		{"api_error", errors.ErrCodeServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.errorType, func(t *testing.T) {
+			body := `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4","usage":{"input_tokens":3}}}
+
+event: error
+data: {"type":"error","error":{"type":"` + tt.errorType + `","message":"Overloaded"}}
+
+`
+			stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(body)), NewTransformer())
+			defer stream.Close()
+
+			for {
+				event, err := stream.Next()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if event == nil {
+					t.Fatal("stream ended without an error event")
+				}
+				if event.Type != types.StreamEventError {
+					continue
+				}
+				var rerr *errors.RouterError
+				if !stderrors.As(event.Error, &rerr) || rerr.Code != tt.code {
+					t.Errorf("error = %v, want code %s", event.Error, tt.code)
+				}
+				return
+			}
+		})
+	}
+}
+
+func TestStreamReader_Prefix(t *testing.T) {
+	const textStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"\"a\": 1"}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"}"}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(textStream)), NewTransformer())
+	stream.prefix = jsonPrefill
+	defer stream.Close()
+
+	var text string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventContentDelta {
+			text += event.Delta.Text
+		}
+	}
+
+	if text != `{"a": 1}` {
+		t.Errorf("expected prefilled deltas, got %q", text)
+	}
+	if got := stream.Response().Text(); got != `{"a": 1}` {
+		t.Errorf("expected prefilled response text, got %q", got)
+	}
+}
+
+func TestStreamReader_Citations(t *testing.T) {
+	const citationStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-sonnet-4-20250514"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Intro. "}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://example.com","title":"Example","cited_text":"Sky is blue"}}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"The sky is blue."}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(citationStream)), NewTransformer())
+	defer stream.Close()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event == nil {
+			break
+		}
+	}
+
+	resp := stream.Response()
+	if len(resp.Citations) != 1 {
+		t.Fatalf("expected 1 citation, got %d", len(resp.Citations))
+	}
+	c := resp.Citations[0]
+	if c.URL != "https://example.com" || c.CitedText != "Sky is blue" {
+		t.Errorf("unexpected citation: %+v", c)
+	}
+	if got := resp.Text()[c.StartIndex:c.EndIndex]; got != "The sky is blue." {
+		t.Errorf("expected span of the cited block, got %q", got)
+	}
+}
+
+func TestStreamReader_ContextCancellation(t *testing.T) {
+	pr, pw := io.Pipe()
+	defer pw.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream := newStreamReader(ctx, pr, NewTransformer())
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := stream.Next()
+		errCh <- err
+	}()
+
+	cancel()
+
+	select {
+	case err := <-errCh:
+		if err != context.Canceled {
+			t.Errorf("expected context.Canceled, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Next did not unblock after cancellation")
+	}
+
+	if _, err := stream.Next(); err != context.Canceled {
+		t.Errorf("expected context.Canceled on subsequent Next, got %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("unexpected close error: %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Errorf("expected idempotent close, got %v", err)
+	}
+}
+
+func TestStreamReader_Thinking(t *testing.T) {
+	const thinkingStream = `event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"Let me add."}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig"}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"4"}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(thinkingStream)), NewTransformer())
+	defer stream.Close()
+
+	var thinking, text string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventThinkingDelta:
+			thinking += event.Delta.Text
+		case types.StreamEventContentDelta:
+			text += event.Delta.Text
+		}
+	}
+
+	if thinking != "Let me add." || text != "4" {
+		t.Errorf("thinking = %q, text = %q", thinking, text)
+	}
+}
+
+func TestHandleErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		code       string
+		retryAfter time.Duration
+	}{
+		{"overloaded", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, errors.ErrCodeOverloaded, 30 * time.Second},
+		{"overloaded without json", 529, `<html>overloaded</html>`, errors.ErrCodeOverloaded, 30 * time.Second},
+		{"rate limit", 429, `{"type":"error","error":{"type":"rate_limit_error","message":"slow down"}}`, errors.ErrCodeRateLimit, 30 * time.Second},
+		{"permission", 403, `{"type":"error","error":{"type":"permission_error","message":"no access"}}`, errors.ErrCodeAuthentication, 0},
+		{"credit balance", 400, `{"type":"error","error":{"type":"invalid_request_error","message":"Your credit balance is too low to access the Anthropic API."}}`, errors.ErrCodeQuotaExceeded, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter > 0 {
+				header.Set("Retry-After", "30")
+			}
+			err := New(provider.WithAPIKey("key")).handleErrorResponse(&http.Response{
+				StatusCode: tt.status,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			})
+
+			var rerr *errors.RouterError
+			if !stderrors.As(err, &rerr) || rerr.Code != tt.code || rerr.StatusCode != tt.status {
+				t.Fatalf("err = %#v, want code %s", err, tt.code)
+			}
+			if got := errors.RetryAfter(err); got != tt.retryAfter {
+				t.Errorf("retry after = %v, want %v", got, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestComplete_ProviderMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("request-id", "req_011CTest")
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":5,"output_tokens":1,"service_tier":"standard"}}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL)).Complete(context.Background(), helloRequest("claude-haiku-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := types.ProviderMetadata{RequestID: "req_011CTest", ServiceTier: "standard"}
+	if resp.ProviderMetadata != want {
+		t.Errorf("provider metadata = %+v, want %+v", resp.ProviderMetadata, want)
+	}
+}
+
+func TestComplete_Refusal(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-sonnet-4-5","content":[],"stop_reason":"refusal","usage":{"input_tokens":5,"output_tokens":0}}`))
+	}))
+	defer server.Close()
+
+	resp, err := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL)).Complete(context.Background(), helloRequest("claude-sonnet-4-5"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StopReason != types.StopReasonRefusal {
+		t.Errorf("stop reason = %q, want refusal", resp.StopReason)
+	}
+}
+
+// benchmarkStream is a stream of 1000 text deltas.
+var benchmarkStream = func() string {
+	var b strings.Builder
+	b.WriteString("event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-haiku-4-5\"}}\n\n")
+	b.WriteString("event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")
+	for i := range 1000 {
+		fmt.Fprintf(&b, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"token %d \"}}\n\n", i)
+	}
+	b.WriteString("event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")
+	b.WriteString("event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"end_turn\"},\"usage\":{\"output_tokens\":2000}}\n\n")
+	b.WriteString("event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+	return b.String()
+}()
+
+func BenchmarkStreamReader(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkStream)))
+	for b.Loop() {
+		stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(benchmarkStream)), NewTransformer())
+		for {
+			event, err := stream.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if event == nil {
+				break
+			}
+		}
+		stream.Close()
+	}
+}
+
+func TestListModels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("after_id") == "" {
+			w.Write([]byte(`{"data":[{"type":"model","id":"claude-sonnet-4-5-20250929","display_name":"Claude Sonnet 4.5","created_at":"2025-09-29T00:00:00Z"}],"has_more":true,"last_id":"claude-sonnet-4-5-20250929"}`))
+			return
+		}
+		w.Write([]byte(`{"data":[{"type":"model","id":"claude-haiku-4-5-20251001","display_name":"Claude Haiku 4.5","created_at":"2025-10-01T00:00:00Z"}],"has_more":false}`))
+	}))
+	defer server.Close()
+
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	if slices.Contains(c.Models(), "claude-3-sonnet-20240229") {
+		t.Error("built-in models include a retired model")
+	}
+
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(models) != 2 || models[0].DisplayName != "Claude Sonnet 4.5" || models[0].ContextWindow != 200000 || models[0].MaxOutputTokens != 64000 {
+		t.Errorf("models = %+v, want both pages with catalog limits", models)
+	}
+	if got := c.Models(); !slices.Equal(got, []string{"claude-sonnet-4-5-20250929", "claude-haiku-4-5-20251001"}) {
+		t.Errorf("Models = %v, want the listed models", got)
+	}
+	if m, ok := c.Model("claude-haiku-4-5-20251001"); !ok || m.DisplayName != "Claude Haiku 4.5" {
+		t.Errorf("Model = %+v, %v, want the listed model", m, ok)
+	}
+}
+
+func TestCountTokens(t *testing.T) {
+	var got map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("path = %s, want /v1/messages/count_tokens", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		w.Write([]byte(`{"input_tokens":42}`))
+	}))
+	defer server.Close()
+
+	req := helloRequest("claude-haiku-4-5")
+	req.MaxTokens = types.Ptr(100)
+	req.Stream = true
+	req.Messages = append([]types.Message{types.NewTextMessage(types.RoleSystem, "Be brief.")}, req.Messages...)
+
+	n, err := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL)).CountTokens(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 42 {
+		t.Errorf("tokens = %d, want 42", n)
+	}
+	if got["model"] != "claude-haiku-4-5" || got["system"] == nil || got["messages"] == nil {
+		t.Errorf("body = %v, want model, system, and messages", got)
+	}
+	for _, field := range []string{"max_tokens", "stream"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("body has %s: %v", field, got)
+		}
+	}
+}
+
+func TestCountTokens_Hosted(t *testing.T) {
+	c := New(WithBedrock("us-east-1"), provider.WithAPIKey("key"))
+	if c.SupportsFeature(types.FeatureTokenCounting) {
+		t.Error("Bedrock should not support token counting")
+	}
+	_, err := c.CountTokens(context.Background(), helloRequest("claude-haiku-4-5"))
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeUnsupportedFeature, "")) {
+		t.Errorf("err = %v, want unsupported feature", err)
+	}
+}
+
+func TestComplete_ExtendedOutput(t *testing.T) {
+	const textStream = `event: message_start
+data: {"type":"message_start","message":{"id":"msg_1","model":"claude-3-7-sonnet-20250219","usage":{"input_tokens":5}}}
+
+event: content_block_start
+data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}
+
+event: content_block_delta
+data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"A long essay"}}
+
+event: content_block_stop
+data: {"type":"content_block_stop","index":0}
+
+event: message_delta
+data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":3}}
+
+event: message_stop
+data: {"type":"message_stop"}
+
+`
+	var beta string
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		beta = r.Header.Get("anthropic-beta")
+		body = nil
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			w.Write([]byte(textStream))
+			return
+		}
+		w.Write([]byte(helloResponse))
+	}))
+	defer server.Close()
+	client := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+
+	tests := []struct {
+		model     string
+		maxTokens int
+		extended  bool
+		streamed  bool
+		text      string
+	}{
+		{"claude-3-7-sonnet-20250219", 1000, false, false, "Hi"},
+		{"claude-3-7-sonnet-20250219", 32000, false, true, "A long essay"},
+		{"claude-3-7-sonnet-20250219", 128000, true, true, "A long essay"},
+		{"claude-sonnet-4-20250514", 64000, false, true, "A long essay"},
+		{"claude-custom", 100000, true, true, "A long essay"},
+	}
+	for _, tt := range tests {
+		req := helloRequest(tt.model)
+		req.MaxTokens = types.Ptr(tt.maxTokens)
+		resp, err := client.Complete(context.Background(), req)
+		if err != nil {
+			t.Fatalf("%s/%d: %v", tt.model, tt.maxTokens, err)
+		}
+		if got := strings.Contains(beta, extendedOutputBeta); got != tt.extended {
+			t.Errorf("%s/%d: anthropic-beta = %q, want extended output %v", tt.model, tt.maxTokens, beta, tt.extended)
+		}
+		if got := body["stream"] == true; got != tt.streamed {
+			t.Errorf("%s/%d: streamed = %v, want %v", tt.model, tt.maxTokens, got, tt.streamed)
+		}
+		if resp.Text() != tt.text {
+			t.Errorf("%s/%d: text = %q, want %q", tt.model, tt.maxTokens, resp.Text(), tt.text)
+		}
+	}
+}
@@ -4,10 +4,20 @@ import (
 	"strings"
 	"time"
 
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/schema"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
 
+// jsonModeInstruction is added to the system prompt of json requests, which
+// Anthropic has no native mode for.
+const jsonModeInstruction = "Respond only with a single valid JSON object, with no markdown code fences or other text."
+
+// jsonPrefill starts the assistant turn of json requests so the reply is a
+// JSON object. Anthropic omits it from the response; it is added back.
+const jsonPrefill = "{"
+
 // Transformer handles conversion between unified and Anthropic formats.
 type Transformer struct {
 	schemaTranslator *schema.Translator
@@ -20,11 +30,23 @@ func NewTransformer() *Transformer {
 	}
 }
 
+// fallbackMaxTokens is the max_tokens sent, capped at the model's output
+// limit, when a request sets none; the Messages API requires it.
+const fallbackMaxTokens = 8192
+
+// defaultMaxTokens returns the max_tokens for a request without MaxTokens.
+func defaultMaxTokens(model string) int {
+	if info, ok := models.Lookup(types.ProviderAnthropic, model); ok && info.MaxOutputTokens > 0 && info.MaxOutputTokens < fallbackMaxTokens {
+		return info.MaxOutputTokens
+	}
+	return fallbackMaxTokens
+}
+
 // TransformRequest converts a unified request to Anthropic format.
 func (t *Transformer) TransformRequest(req *types.CompletionRequest) *MessagesRequest {
 	anthReq := &MessagesRequest{
 		Model:         req.Model,
-		MaxTokens:     8192, // Default
+		MaxTokens:     defaultMaxTokens(req.Model),
 		Temperature:   req.Temperature,
 		TopP:          req.TopP,
 		TopK:          req.TopK,
@@ -39,15 +61,25 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *MessagesRe
 	// Extract system message and transform other messages
 	messages, system := t.transformMessages(req.Messages)
 	anthReq.Messages = messages
-	if system != "" {
-		anthReq.System = system
-	}
 
 	// Transform response format
 	if req.ResponseFormat != nil {
 		anthReq.OutputConfig = t.transformResponseFormat(req.ResponseFormat)
 	}
 
+	// Emulate JSON mode with an instruction and a prefilled "{"
+	var instruction string
+	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json" {
+		instruction = jsonModeInstruction
+		if t.PrefillsJSON(req) {
+			anthReq.Messages = append(anthReq.Messages, Message{Role: "assistant", Content: jsonPrefill})
+		}
+	}
+
+	if prompt := systemPrompt(system, instruction); prompt != nil {
+		anthReq.System = prompt
+	}
+
 	// Transform tools
 	if len(req.Tools) > 0 {
 		anthReq.Tools = t.transformTools(req.Tools)
@@ -58,10 +90,21 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *MessagesRe
 		anthReq.ToolChoice = t.transformToolChoice(req.ToolChoice)
 	}
 
+	for _, server := range req.MCPServers {
+		anthReq.MCPServers = append(anthReq.MCPServers, t.transformMCPServer(server))
+	}
+
 	if uid := req.Metadata["user_id"]; uid != "" {
 		anthReq.Metadata = &Metadata{UserID: uid}
 	}
 
+	switch req.ServiceTier {
+	case types.ServiceTierAuto, types.ServiceTierPriority:
+		anthReq.ServiceTier = "auto"
+	case types.ServiceTierStandard:
+		anthReq.ServiceTier = "standard_only"
+	}
+
 	if req.Thinking != nil {
 		if th := thinkingToAnthropic(req.Thinking); th != nil {
 			anthReq.Thinking = th
@@ -71,6 +114,52 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *MessagesRe
 	return anthReq
 }
 
+// PrefillsJSON reports whether TransformRequest prefills the assistant turn
+// of req with "{", which the caller must prepend to the response text.
+// Prefill is skipped when it would conflict with the request: with tools,
+// which the model could no longer call; with extended thinking, which does
+// not allow it; and when the conversation already ends with an assistant
+// turn.
+func (t *Transformer) PrefillsJSON(req *types.CompletionRequest) bool {
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json" {
+		return false
+	}
+	if len(req.Tools) > 0 || len(req.MCPServers) > 0 || thinkingToAnthropic(req.Thinking) != nil {
+		return false
+	}
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role != types.RoleSystem {
+			return req.Messages[i].Role != types.RoleAssistant
+		}
+	}
+	return true
+}
+
+// prependText prepends prefix to the first text block of resp.
+func prependText(resp *types.CompletionResponse, prefix string) {
+	for i := range resp.Content {
+		if resp.Content[i].Type == types.ContentTypeText {
+			resp.Content[i].Text = prefix + resp.Content[i].Text
+			return
+		}
+	}
+	resp.Content = append([]types.ContentBlock{{Type: types.ContentTypeText, Text: prefix}}, resp.Content...)
+}
+
+// transformMCPServer converts an MCP server to the MCP connector format.
+func (t *Transformer) transformMCPServer(server types.MCPServer) MCPServer {
+	s := MCPServer{
+		Type:               "url",
+		URL:                server.URL,
+		Name:               server.Name,
+		AuthorizationToken: server.AuthorizationToken,
+	}
+	if len(server.AllowedTools) > 0 {
+		s.ToolConfiguration = &MCPToolConfiguration{AllowedTools: server.AllowedTools}
+	}
+	return s
+}
+
 func thinkingToAnthropic(c *types.ThinkingConfig) *ThinkingRequest {
 	if c == nil {
 		return nil
@@ -88,25 +177,44 @@ func thinkingToAnthropic(c *types.ThinkingConfig) *ThinkingRequest {
 	return nil
 }
 
-// transformMessages converts unified messages to Anthropic format.
-func (t *Transformer) transformMessages(messages []types.Message) ([]Message, string) {
+// transformMessages converts unified messages to Anthropic format. System
+// messages are returned separately, one block per message.
+func (t *Transformer) transformMessages(messages []types.Message) ([]Message, []SystemBlock) {
 	var result []Message
-	var system string
+	var system []SystemBlock
 
-	for _, msg := range messages {
+	messages = provider.PairToolResults(messages)
+	for i, msg := range messages {
 		// Handle system messages
 		if msg.Role == types.RoleSystem {
-			for _, block := range msg.Content {
-				if block.Type == types.ContentTypeText {
-					if system != "" {
-						system += "\n"
+			block := SystemBlock{Type: "text"}
+			for _, b := range msg.Content {
+				if b.Type == types.ContentTypeText {
+					if block.Text != "" {
+						block.Text += "\n"
+					}
+					block.Text += b.Text
+					if b.CacheControl != nil {
+						block.CacheControl = &CacheControl{Type: "ephemeral", TTL: b.CacheControl.TTL}
 					}
-					system += block.Text
 				}
 			}
+			if block.Text != "" {
+				system = append(system, block)
+			}
 			continue
 		}
 
+		// Results of parallel tool calls must all be in the one user
+		// message that follows the assistant turn.
+		if msg.Role == types.RoleTool && i > 0 && messages[i-1].Role == types.RoleTool && len(result) > 0 {
+			last := &result[len(result)-1]
+			if blocks, ok := last.Content.([]ContentBlock); ok {
+				last.Content = append(blocks, t.transformContentBlocks(msg.Content)...)
+				continue
+			}
+		}
+
 		anthMsg := Message{
 			Role: t.mapRole(msg.Role),
 		}
@@ -125,6 +233,56 @@ func (t *Transformer) transformMessages(messages []types.Message) ([]Message, st
 	return result, system
 }
 
+// systemPrompt returns the system field for the system blocks followed by an
+// optional instruction. A single uncached block is sent as a plain string;
+// several blocks, or any block with cache control, are sent as an array so
+// each keeps its own breakpoint. It returns nil if there is no prompt.
+func systemPrompt(blocks []SystemBlock, instruction string) any {
+	cached := false
+	for _, block := range blocks {
+		if block.CacheControl != nil {
+			cached = true
+		}
+	}
+
+	if !cached && len(blocks) <= 1 {
+		var text string
+		if len(blocks) == 1 {
+			text = blocks[0].Text
+		}
+		if instruction != "" {
+			if text != "" {
+				text += "\n\n"
+			}
+			text += instruction
+		}
+		if text == "" {
+			return nil
+		}
+		return text
+	}
+
+	if instruction != "" {
+		blocks = append(blocks, SystemBlock{Type: "text", Text: instruction})
+	}
+	return blocks
+}
+
+// parseDataURL splits a base64 data URL, which Anthropic's url image source
+// does not accept, into its media type and data.
+func parseDataURL(url string) (mediaType, data string, ok bool) {
+	rest, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", false
+	}
+	header, data, ok := strings.Cut(rest, ",")
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !ok || !isBase64 {
+		return "", "", false
+	}
+	return mediaType, data, true
+}
+
 // mapRole maps unified role to Anthropic role.
 func (t *Transformer) mapRole(role types.Role) string {
 	switch role {
@@ -157,6 +315,12 @@ func (t *Transformer) transformContentBlocks(blocks []types.ContentBlock) []Cont
 					MediaType: block.MediaType,
 					Data:      block.ImageBase64,
 				}
+			} else if mediaType, data, ok := parseDataURL(block.ImageURL); ok {
+				cb.Source = &ImageSource{
+					Type:      "base64",
+					MediaType: mediaType,
+					Data:      data,
+				}
 			} else if block.ImageURL != "" {
 				cb.Source = &ImageSource{
 					Type: "url",
@@ -174,10 +338,14 @@ func (t *Transformer) transformContentBlocks(blocks []types.ContentBlock) []Cont
 			})
 
 		case types.ContentTypeToolResult:
+			var content any = block.Text
+			if len(block.ToolResultContent) > 0 {
+				content = t.transformContentBlocks(block.ToolResultContent)
+			}
 			result = append(result, ContentBlock{
 				Type:      "tool_result",
 				ToolUseID: block.ToolResultID,
-				Content:   block.Text,
+				Content:   content,
 				IsError:   block.IsError,
 			})
 		}
@@ -248,27 +416,32 @@ func (t *Transformer) TransformResponse(resp *MessagesResponse) *types.Completio
 	}
 
 	result := &types.CompletionResponse{
-		ID:         resp.ID,
-		Provider:   types.ProviderAnthropic,
-		Model:      resp.Model,
-		Content:    t.transformResponseContent(resp.Content),
-		StopReason: t.transformStopReason(resp.StopReason),
-		ToolCalls:  t.extractToolCalls(resp.Content),
+		ID:           resp.ID,
+		Provider:     types.ProviderAnthropic,
+		Model:        resp.Model,
+		Content:      t.transformResponseContent(resp.Content),
+		StopReason:   t.transformStopReason(resp.StopReason),
+		StopSequence: resp.StopSequence,
+		ToolCalls:    t.extractToolCalls(resp.Content),
 		Usage: types.Usage{
 			InputTokens:  resp.Usage.InputTokens,
 			OutputTokens: resp.Usage.OutputTokens,
 			TotalTokens:  resp.Usage.InputTokens + resp.Usage.OutputTokens,
 			CachedTokens: resp.Usage.CacheReadInputTokens,
 		},
-		CreatedAt: time.Now(),
+		CreatedAt:        time.Now(),
+		ProviderMetadata: types.ProviderMetadata{ServiceTier: resp.Usage.ServiceTier},
 	}
+	result.Citations = provider.CollectCitations(result.Content)
 
 	return result
 }
 
 // transformResponseContent converts Anthropic content blocks to unified format.
+// Citations follow the text block they support.
 func (t *Transformer) transformResponseContent(blocks []ContentBlock) []types.ContentBlock {
 	var result []types.ContentBlock
+	offset := 0
 
 	for _, block := range blocks {
 		switch block.Type {
@@ -277,6 +450,13 @@ func (t *Transformer) transformResponseContent(blocks []ContentBlock) []types.Co
 				Type: types.ContentTypeText,
 				Text: block.Text,
 			})
+			for _, c := range block.Citations {
+				result = append(result, types.ContentBlock{
+					Type:     types.ContentTypeCitation,
+					Citation: transformCitation(c, block.Text, offset),
+				})
+			}
+			offset += len(block.Text)
 		case "tool_use":
 			result = append(result, types.ContentBlock{
 				Type:      types.ContentTypeToolUse,
@@ -290,6 +470,25 @@ func (t *Transformer) transformResponseContent(blocks []ContentBlock) []types.Co
 	return result
 }
 
+// transformCitation converts a citation of the text block at offset in the
+// response text.
+func transformCitation(c Citation, text string, offset int) *types.Citation {
+	title := c.DocumentTitle
+	if title == "" {
+		title = c.Title
+	}
+	return &types.Citation{
+		Type:          c.Type,
+		URL:           c.URL,
+		Title:         title,
+		DocumentIndex: c.DocumentIndex,
+		CitedText:     c.CitedText,
+		Text:          text,
+		StartIndex:    offset,
+		EndIndex:      offset + len(text),
+	}
+}
+
 // extractToolCalls extracts tool calls from Anthropic content blocks.
 func (t *Transformer) extractToolCalls(blocks []ContentBlock) []types.ToolCall {
 	var calls []types.ToolCall
@@ -318,6 +517,8 @@ func (t *Transformer) transformStopReason(reason string) types.StopReason {
 		return types.StopReasonToolUse
 	case "stop_sequence":
 		return types.StopReasonStopSequence
+	case "refusal":
+		return types.StopReasonRefusal
 	default:
 		return types.StopReasonEnd
 	}
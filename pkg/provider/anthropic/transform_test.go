@@ -1,6 +1,8 @@
 package anthropic
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
@@ -36,6 +38,16 @@ func TestTransformRequest_Basic(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_DefaultMaxTokensCapped(t *testing.T) {
+	req := &types.CompletionRequest{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	}
+	if got := NewTransformer().TransformRequest(req).MaxTokens; got != 4096 {
+		t.Errorf("expected max_tokens capped at the model's 4096 limit, got %d", got)
+	}
+}
+
 func TestTransformRequest_WithMaxTokens(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -95,10 +107,83 @@ func TestTransformRequest_MultipleSystemMessages(t *testing.T) {
 
 	result := transformer.TransformRequest(req)
 
-	expected := "Line 1\nLine 2"
+	// Each system message is its own block
+	blocks, ok := result.System.([]SystemBlock)
+	if !ok || len(blocks) != 2 {
+		t.Fatalf("expected 2 system blocks, got %#v", result.System)
+	}
+	if blocks[0].Text != "Line 1" || blocks[1].Text != "Line 2" {
+		t.Errorf("expected system blocks in order, got %+v", blocks)
+	}
+}
+
+func TestTransformRequest_SystemCacheControl(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []types.Message{
+			types.NewCachedSystemMessage("Long reference document"),
+			types.NewTextMessage(types.RoleUser, "Hello"),
+		},
+		ResponseFormat: &types.ResponseFormat{Type: "json"},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	blocks, ok := result.System.([]SystemBlock)
+	if !ok || len(blocks) != 2 {
+		t.Fatalf("expected 2 system blocks, got %#v", result.System)
+	}
+	if blocks[0].CacheControl == nil || blocks[0].CacheControl.Type != "ephemeral" {
+		t.Errorf("expected ephemeral cache control, got %+v", blocks[0].CacheControl)
+	}
+	if blocks[1].Text != jsonModeInstruction || blocks[1].CacheControl != nil {
+		t.Errorf("expected uncached JSON instruction last, got %+v", blocks[1])
+	}
+
+	data, _ := json.Marshal(result)
+	if !strings.Contains(string(data), `"cache_control":{"type":"ephemeral"}`) {
+		t.Errorf("expected cache_control in request JSON, got %s", data)
+	}
+}
+
+func TestTransformRequest_JSONMode(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleSystem, "You are a helpful assistant"),
+			types.NewTextMessage(types.RoleUser, "List three colors"),
+		},
+		ResponseFormat: &types.ResponseFormat{Type: "json"},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	expected := "You are a helpful assistant\n\n" + jsonModeInstruction
 	if result.System != expected {
 		t.Errorf("expected system %q, got %q", expected, result.System)
 	}
+	if result.OutputConfig != nil {
+		t.Error("expected no output config for json mode")
+	}
+	if len(result.Messages) != 2 {
+		t.Fatalf("expected 2 messages, got %d", len(result.Messages))
+	}
+	if last := result.Messages[1]; last.Role != "assistant" || last.Content != "{" {
+		t.Errorf("expected prefilled assistant turn, got %+v", last)
+	}
+
+	// Prefill would stop the model from calling tools.
+	req.Tools = []types.Tool{{Name: "lookup", Parameters: types.JSONSchema{Type: "object"}}}
+	if transformer.PrefillsJSON(req) {
+		t.Error("expected no prefill with tools")
+	}
+	if result := transformer.TransformRequest(req); len(result.Messages) != 1 || result.System != expected {
+		t.Errorf("expected instruction without prefill, got %d messages and system %q", len(result.Messages), result.System)
+	}
 }
 
 func TestTransformRequest_ToolResult(t *testing.T) {
@@ -140,6 +225,75 @@ func TestTransformRequest_ToolResult(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ParallelToolResults(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []types.Message{
+			{
+				Role: types.RoleAssistant,
+				Content: []types.ContentBlock{
+					{Type: types.ContentTypeToolUse, ToolUseID: "toolu_1", ToolName: "get_weather"},
+					{Type: types.ContentTypeToolUse, ToolUseID: "toolu_2", ToolName: "get_time"},
+				},
+			},
+			// One message per result, the second matched by tool name
+			types.NewToolResultMessage("toolu_1", "22C", false),
+			{
+				Role:    types.RoleTool,
+				Content: []types.ContentBlock{{Type: types.ContentTypeToolResult, ToolName: "get_time", Text: "12:00"}},
+			},
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	if len(result.Messages) != 2 {
+		t.Fatalf("expected the results in one user message, got %d messages", len(result.Messages))
+	}
+
+	msg := result.Messages[1]
+	blocks, ok := msg.Content.([]ContentBlock)
+	if msg.Role != "user" || !ok || len(blocks) != 2 {
+		t.Fatalf("unexpected tool result message: %+v", msg)
+	}
+	if blocks[0].ToolUseID != "toolu_1" || blocks[1].ToolUseID != "toolu_2" {
+		t.Errorf("tool_use_ids = %q, %q", blocks[0].ToolUseID, blocks[1].ToolUseID)
+	}
+}
+
+func TestTransformRequest_ToolResultContent(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []types.Message{
+			types.NewToolResultContentMessage("toolu_123", []types.ContentBlock{
+				{Type: types.ContentTypeText, Text: "Screenshot of the page"},
+				{Type: types.ContentTypeImage, ImageBase64: "iVBORw0KGgo=", MediaType: "image/png"},
+			}, false),
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	blocks := result.Messages[0].Content.([]ContentBlock)
+	content, ok := blocks[0].Content.([]ContentBlock)
+	if !ok {
+		t.Fatalf("expected tool result content to be []ContentBlock, got %T", blocks[0].Content)
+	}
+	if len(content) != 2 {
+		t.Fatalf("expected 2 content blocks, got %d", len(content))
+	}
+	if content[0].Type != "text" || content[0].Text != "Screenshot of the page" {
+		t.Errorf("unexpected text block: %+v", content[0])
+	}
+	if content[1].Type != "image" || content[1].Source == nil || content[1].Source.Data != "iVBORw0KGgo=" {
+		t.Errorf("unexpected image block: %+v", content[1])
+	}
+}
+
 func TestTransformRequest_AssistantWithToolUse(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -235,6 +389,34 @@ func TestTransformRequest_Image(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ImageURL(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "claude-sonnet-4-20250514",
+		Messages: []types.Message{
+			{
+				Role: types.RoleUser,
+				Content: []types.ContentBlock{
+					{Type: types.ContentTypeImage, ImageURL: "https://example.com/cat.png"},
+					{Type: types.ContentTypeImage, ImageURL: "data:image/jpeg;base64,abcd"},
+				},
+			},
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	blocks := result.Messages[0].Content.([]ContentBlock)
+	if src := blocks[0].Source; src.Type != "url" || src.URL != "https://example.com/cat.png" {
+		t.Errorf("expected url source, got %+v", src)
+	}
+	// Data URLs are sent inline, since url sources must be http(s)
+	if src := blocks[1].Source; src.Type != "base64" || src.MediaType != "image/jpeg" || src.Data != "abcd" {
+		t.Errorf("expected base64 source from data URL, got %+v", src)
+	}
+}
+
 func TestTransformRequest_Tools(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -264,6 +446,35 @@ func TestTransformRequest_Tools(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_MCPServers(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model:    "claude-sonnet-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+		MCPServers: []types.MCPServer{
+			{Name: "docs", URL: "https://mcp.example.com/sse", AuthorizationToken: "tok", AllowedTools: []string{"search"}},
+			{Name: "open", URL: "https://open.example.com/mcp"},
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	if len(result.MCPServers) != 2 {
+		t.Fatalf("expected 2 MCP servers, got %d", len(result.MCPServers))
+	}
+	docs := result.MCPServers[0]
+	if docs.Type != "url" || docs.Name != "docs" || docs.AuthorizationToken != "tok" {
+		t.Errorf("unexpected server: %+v", docs)
+	}
+	if docs.ToolConfiguration == nil || len(docs.ToolConfiguration.AllowedTools) != 1 {
+		t.Errorf("expected allowed tools, got %+v", docs.ToolConfiguration)
+	}
+	if result.MCPServers[1].ToolConfiguration != nil {
+		t.Error("expected no tool configuration without allowed tools")
+	}
+}
+
 func TestTransformRequest_ToolChoice(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -336,6 +547,27 @@ func TestTransformRequest_MetadataUserID(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ServiceTier(t *testing.T) {
+	transformer := NewTransformer()
+	tests := map[types.ServiceTier]string{
+		"":                        "",
+		types.ServiceTierAuto:     "auto",
+		types.ServiceTierPriority: "auto",
+		types.ServiceTierStandard: "standard_only",
+		types.ServiceTierFlex:     "",
+	}
+	for tier, want := range tests {
+		result := transformer.TransformRequest(&types.CompletionRequest{
+			Model:       "claude-sonnet-4-20250514",
+			Messages:    []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+			ServiceTier: tier,
+		})
+		if result.ServiceTier != want {
+			t.Errorf("tier %q: service_tier = %q, want %q", tier, result.ServiceTier, want)
+		}
+	}
+}
+
 func TestTransformRequest_ThinkingEnabled(t *testing.T) {
 	transformer := NewTransformer()
 	budget := 4096
@@ -435,6 +667,53 @@ func TestTransformResponse(t *testing.T) {
 	}
 }
 
+func TestTransformResponse_StopSequence(t *testing.T) {
+	result := NewTransformer().TransformResponse(&MessagesResponse{
+		Content:      []ContentBlock{{Type: "text", Text: "1, 2, 3"}},
+		StopReason:   "stop_sequence",
+		StopSequence: ", 4",
+	})
+
+	if result.StopReason != types.StopReasonStopSequence || result.StopSequence != ", 4" {
+		t.Errorf("expected stop_sequence %q, got %q %q", ", 4", result.StopReason, result.StopSequence)
+	}
+}
+
+func TestTransformResponse_Citations(t *testing.T) {
+	transformer := NewTransformer()
+
+	resp := &MessagesResponse{
+		ID:    "msg_123",
+		Model: "claude-sonnet-4-20250514",
+		Content: []ContentBlock{
+			{Type: "text", Text: "According to the report, "},
+			{Type: "text", Text: "revenue grew 12%.", Citations: []Citation{{
+				Type:          "char_location",
+				CitedText:     "Revenue grew 12% year over year.",
+				DocumentIndex: 1,
+				DocumentTitle: "Annual Report",
+			}}},
+		},
+		StopReason: "end_turn",
+	}
+
+	result := transformer.TransformResponse(resp)
+
+	if len(result.Content) != 3 || result.Content[2].Type != types.ContentTypeCitation {
+		t.Fatalf("expected citation block after its text, got %+v", result.Content)
+	}
+	if len(result.Citations) != 1 {
+		t.Fatalf("expected 1 citation, got %d", len(result.Citations))
+	}
+	c := result.Citations[0]
+	if c.Title != "Annual Report" || c.DocumentIndex != 1 || c.CitedText != "Revenue grew 12% year over year." {
+		t.Errorf("unexpected citation source: %+v", c)
+	}
+	if got := result.Text()[c.StartIndex:c.EndIndex]; got != "revenue grew 12%." || c.Text != got {
+		t.Errorf("expected span of the cited text block, got %q", got)
+	}
+}
+
 func TestTransformResponse_WithToolUse(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -494,6 +773,7 @@ func TestTransformStopReason(t *testing.T) {
 		{"max_tokens", types.StopReasonMaxTokens},
 		{"tool_use", types.StopReasonToolUse},
 		{"stop_sequence", types.StopReasonStopSequence},
+		{"refusal", types.StopReasonRefusal},
 		{"unknown", types.StopReasonEnd},
 		{"", types.StopReasonEnd},
 	}
@@ -2,7 +2,7 @@ package anthropic
 
 // MessagesRequest is the Anthropic messages API request.
 type MessagesRequest struct {
-	Model         string           `json:"model"`
+	Model         string           `json:"model,omitempty"`
 	Messages      []Message        `json:"messages"`
 	MaxTokens     int              `json:"max_tokens"`
 	System        any              `json:"system,omitempty"` // string or []SystemBlock
@@ -16,6 +16,30 @@ type MessagesRequest struct {
 	Metadata      *Metadata        `json:"metadata,omitempty"`
 	OutputConfig  *OutputConfig    `json:"output_config,omitempty"`
 	Thinking      *ThinkingRequest `json:"thinking,omitempty"`
+	MCPServers    []MCPServer      `json:"mcp_servers,omitempty"`
+	ServiceTier   string           `json:"service_tier,omitempty"` // "auto" | "standard_only"
+
+	// AnthropicVersion replaces the anthropic-version header on Vertex AI
+	// and Bedrock.
+	AnthropicVersion string `json:"anthropic_version,omitempty"`
+
+	// AnthropicBeta replaces the anthropic-beta header on Bedrock.
+	AnthropicBeta []string `json:"anthropic_beta,omitempty"`
+}
+
+// MCPServer is a remote MCP server for the MCP connector.
+// See https://docs.anthropic.com/en/docs/agents-and-tools/mcp-connector
+type MCPServer struct {
+	Type               string                `json:"type"` // "url"
+	URL                string                `json:"url"`
+	Name               string                `json:"name"`
+	AuthorizationToken string                `json:"authorization_token,omitempty"`
+	ToolConfiguration  *MCPToolConfiguration `json:"tool_configuration,omitempty"`
+}
+
+// MCPToolConfiguration restricts the tools of an MCP server.
+type MCPToolConfiguration struct {
+	AllowedTools []string `json:"allowed_tools,omitempty"`
 }
 
 // ThinkingRequest is Anthropic Messages API extended / adaptive thinking.
@@ -51,6 +75,20 @@ type ContentBlock struct {
 	ToolUseID string `json:"tool_use_id,omitempty"`
 	Content   any    `json:"content,omitempty"` // string or []ContentBlock
 	IsError   bool   `json:"is_error,omitempty"`
+
+	// Citations supporting a text block (responses only)
+	Citations []Citation `json:"citations,omitempty"`
+}
+
+// Citation is a citation of a document or web search result.
+// See https://docs.anthropic.com/en/docs/build-with-claude/citations
+type Citation struct {
+	Type          string `json:"type"` // "char_location", "page_location", "content_block_location", "web_search_result_location"
+	CitedText     string `json:"cited_text,omitempty"`
+	DocumentIndex int    `json:"document_index,omitempty"`
+	DocumentTitle string `json:"document_title,omitempty"`
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title,omitempty"`
 }
 
 // ImageSource is the source of an image.
@@ -70,7 +108,8 @@ type SystemBlock struct {
 
 // CacheControl is for prompt caching.
 type CacheControl struct {
-	Type string `json:"type"` // "ephemeral"
+	Type string `json:"type"`          // "ephemeral"
+	TTL  string `json:"ttl,omitempty"` // "5m" or "1h"
 }
 
 // Tool is an Anthropic tool definition.
@@ -117,10 +156,11 @@ type MessagesResponse struct {
 
 // Usage is token usage information.
 type Usage struct {
-	InputTokens              int `json:"input_tokens"`
-	OutputTokens             int `json:"output_tokens"`
-	CacheCreationInputTokens int `json:"cache_creation_input_tokens,omitempty"`
-	CacheReadInputTokens     int `json:"cache_read_input_tokens,omitempty"`
+	InputTokens              int    `json:"input_tokens"`
+	OutputTokens             int    `json:"output_tokens"`
+	CacheCreationInputTokens int    `json:"cache_creation_input_tokens,omitempty"`
+	CacheReadInputTokens     int    `json:"cache_read_input_tokens,omitempty"`
+	ServiceTier              string `json:"service_tier,omitempty"`
 }
 
 // StreamEvent is a streaming event.
@@ -135,11 +175,13 @@ type StreamEvent struct {
 
 // Delta is a streaming delta.
 type Delta struct {
-	Type         string `json:"type,omitempty"`
-	Text         string `json:"text,omitempty"`
-	PartialJSON  string `json:"partial_json,omitempty"`
-	StopReason   string `json:"stop_reason,omitempty"`
-	StopSequence string `json:"stop_sequence,omitempty"`
+	Type         string    `json:"type,omitempty"`
+	Text         string    `json:"text,omitempty"`
+	Thinking     string    `json:"thinking,omitempty"` // for thinking_delta
+	PartialJSON  string    `json:"partial_json,omitempty"`
+	StopReason   string    `json:"stop_reason,omitempty"`
+	StopSequence string    `json:"stop_sequence,omitempty"`
+	Citation     *Citation `json:"citation,omitempty"` // for citations_delta
 }
 
 // ErrorResponse is an Anthropic error response.
@@ -202,3 +244,36 @@ type BatchItemResult struct {
 	Message *MessagesResponse `json:"message,omitempty"`
 	Error   *APIError         `json:"error,omitempty"`
 }
+
+// ModelList is the response from listing models.
+type ModelList struct {
+	Data    []Model `json:"data"`
+	HasMore bool    `json:"has_more"`
+	FirstID string  `json:"first_id"`
+	LastID  string  `json:"last_id"`
+}
+
+// Model is an Anthropic model object.
+type Model struct {
+	Type        string `json:"type"`
+	ID          string `json:"id"`
+	DisplayName string `json:"display_name"`
+	CreatedAt   string `json:"created_at"`
+}
+
+// CountTokensRequest is the body of a token counting request: the parts of
+// a MessagesRequest that make up its input.
+type CountTokensRequest struct {
+	Model      string           `json:"model"`
+	Messages   []Message        `json:"messages"`
+	System     any              `json:"system,omitempty"`
+	Tools      []Tool           `json:"tools,omitempty"`
+	ToolChoice *ToolChoice      `json:"tool_choice,omitempty"`
+	Thinking   *ThinkingRequest `json:"thinking,omitempty"`
+	MCPServers []MCPServer      `json:"mcp_servers,omitempty"`
+}
+
+// CountTokensResponse is the response from counting tokens.
+type CountTokensResponse struct {
+	InputTokens int `json:"input_tokens"`
+}
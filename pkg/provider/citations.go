@@ -0,0 +1,15 @@
+package provider
+
+import "github.com/Chloe199719/agent-router/pkg/types"
+
+// CollectCitations returns the citations of the citation blocks in content,
+// for CompletionResponse.Citations.
+func CollectCitations(content []types.ContentBlock) []types.Citation {
+	var citations []types.Citation
+	for _, block := range content {
+		if block.Type == types.ContentTypeCitation && block.Citation != nil {
+			citations = append(citations, *block.Citation)
+		}
+	}
+	return citations
+}
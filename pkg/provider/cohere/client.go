@@ -0,0 +1,485 @@
+// Package cohere provides a Cohere API client implementation, using the v2
+// chat API.
+//
+// Grounding documents (CompletionRequest.Documents) are sent as Cohere
+// documents, and the citations Cohere returns refer back to them by index.
+// Cohere's tool plan, the model's reasoning before it calls tools, is
+// returned as thinking content.
+package cohere
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	defaultBaseURL = "https://api.cohere.com"
+)
+
+// Client is a Cohere API client.
+type Client struct {
+	config      *provider.Config
+	httpClient  *http.Client
+	baseURL     string
+	transformer *Transformer
+}
+
+// New creates a new Cohere client.
+func New(opts ...provider.Option) *Client {
+	cfg := provider.DefaultConfig()
+	provider.ApplyOptions(cfg, opts...)
+
+	baseURL := defaultBaseURL
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+
+	return &Client{
+		config:      cfg,
+		httpClient:  provider.NewHTTPClient(cfg),
+		baseURL:     baseURL,
+		transformer: NewTransformer(),
+	}
+}
+
+// Name returns the provider name.
+func (c *Client) Name() types.Provider {
+	return types.ProviderCohere
+}
+
+// SupportsFeature checks if Cohere supports a feature.
+func (c *Client) SupportsFeature(feature types.Feature) bool {
+	switch feature {
+	case types.FeatureStreaming,
+		types.FeatureStructuredOutput,
+		types.FeatureTools,
+		types.FeatureJSON,
+		types.FeatureDocuments,
+		types.FeatureSeed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Models returns available Cohere models.
+func (c *Client) Models() []string {
+	return []string{
+		"command-a-03-2025",
+		"command-r-plus-08-2024",
+		"command-r-08-2024",
+		"command-r7b-12-2024",
+	}
+}
+
+// ListModels fetches the chat models available to the API key.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/models?endpoint=chat", nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderCohere)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderCohere, "failed to decode response").WithCause(err)
+	}
+
+	models := make([]provider.ModelInfo, len(list.Models))
+	for i, m := range list.Models {
+		models[i] = provider.ModelInfo{
+			ID:            m.Name,
+			Provider:      types.ProviderCohere,
+			ContextWindow: int(m.ContextLength),
+		}
+	}
+
+	return models, nil
+}
+
+// Complete sends a completion request.
+func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	cReq := c.transformer.TransformRequest(req)
+	cReq.Stream = false
+
+	body, err := json.Marshal(cReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v2/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderCohere)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var cResp ChatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&cResp); err != nil {
+		return nil, errors.ErrServerError(types.ProviderCohere, "failed to decode response").WithCause(err)
+	}
+
+	result := c.transformer.TransformResponse(&cResp, req.Documents)
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderCohere, "response has no message")
+	}
+	if result.StopReason == types.StopReasonContentFilter {
+		return nil, errors.ErrContentFilter(types.ProviderCohere, cResp.FinishReason, nil, result.Text())
+	}
+	result.Model = req.Model
+	result.ProviderMetadata.RequestID = provider.RequestID(resp.Header)
+	return result, nil
+}
+
+// Stream sends a streaming completion request.
+func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	cReq := c.transformer.TransformRequest(req)
+	cReq.Stream = true
+
+	body, err := json.Marshal(cReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v2/chat", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+	httpReq.Header.Set("Accept", "text/event-stream")
+
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderCohere)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	stream := newStreamReader(ctx, resp.Body, c.transformer, req.Model, req.Documents)
+	stream.meta.RequestID = provider.RequestID(resp.Header)
+	return stream, nil
+}
+
+// setHeaders sets the required headers for Cohere API requests.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+}
+
+// handleErrorResponse converts an error response to a RouterError.
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	message := string(body)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Message != "" {
+		message = errResp.Message
+	}
+
+	return c.mapAPIError(message, resp.StatusCode).WithRetryAfter(provider.RetryAfter(resp.Header))
+}
+
+// mapAPIError maps a Cohere API error to RouterError. Cohere reports
+// invalid tokens with status 498.
+func (c *Client) mapAPIError(message string, statusCode int) *errors.RouterError {
+	switch statusCode {
+	case http.StatusUnauthorized, 498:
+		return errors.ErrInvalidAPIKey(types.ProviderCohere).WithStatusCode(statusCode)
+	case http.StatusPaymentRequired:
+		return errors.ErrQuotaExceeded(types.ProviderCohere, message).WithStatusCode(statusCode)
+	case http.StatusForbidden:
+		return errors.ErrAuthentication(types.ProviderCohere, message).WithStatusCode(statusCode)
+	case http.StatusTooManyRequests:
+		return errors.ErrRateLimit(types.ProviderCohere, message).WithStatusCode(statusCode)
+	case http.StatusNotFound:
+		return errors.ErrModelNotFound(types.ProviderCohere, message).WithStatusCode(statusCode)
+	case http.StatusServiceUnavailable:
+		return errors.ErrProviderUnavailable(types.ProviderCohere, message).WithStatusCode(statusCode)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		if strings.Contains(message, "too many tokens") {
+			return errors.ErrContextLength(types.ProviderCohere, message).WithStatusCode(statusCode)
+		}
+		return errors.ErrInvalidRequest(message).WithProvider(types.ProviderCohere).WithStatusCode(statusCode)
+	default:
+		return errors.ErrServerError(types.ProviderCohere, message).WithStatusCode(statusCode)
+	}
+}
+
+// streamReader implements types.StreamReader for Cohere.
+type streamReader struct {
+	reader      *bufio.Reader
+	body        *provider.StreamBody
+	transformer *Transformer
+	documents   []types.Document
+	response    *types.CompletionResponse
+	done        bool
+
+	// Accumulated state
+	id         string
+	model      string
+	toolPlan   strings.Builder
+	content    strings.Builder
+	citations  []*types.Citation
+	toolCalls  []*types.ToolCall
+	toolInputs []*strings.Builder
+	usage      *types.Usage
+	stopReason types.StopReason
+	meta       types.ProviderMetadata
+}
+
+func newStreamReader(ctx context.Context, body io.ReadCloser, transformer *Transformer, model string, documents []types.Document) *streamReader {
+	streamBody := provider.NewStreamBody(ctx, body)
+	return &streamReader{
+		reader:      bufio.NewReader(streamBody),
+		body:        streamBody,
+		transformer: transformer,
+		documents:   documents,
+		model:       model,
+	}
+}
+
+// Next returns the next stream event.
+func (s *streamReader) Next() (*types.StreamEvent, error) {
+	if s.done {
+		return nil, nil
+	}
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := s.reader.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				s.done = true
+				s.buildResponse()
+				return nil, nil
+			}
+			return nil, err
+		}
+
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "data:") {
+			continue
+		}
+
+		var event StreamEvent
+		if err := json.Unmarshal([]byte(strings.TrimSpace(strings.TrimPrefix(line, "data:"))), &event); err != nil {
+			continue
+		}
+
+		if result := s.processEvent(&event); result != nil {
+			return result, nil
+		}
+	}
+}
+
+// processEvent processes a stream event and returns a unified event if
+// applicable.
+func (s *streamReader) processEvent(event *StreamEvent) *types.StreamEvent {
+	var msg *StreamMessage
+	if event.Delta != nil {
+		msg = event.Delta.Message
+	}
+
+	switch event.Type {
+	case "message-start":
+		s.id = event.ID
+		return &types.StreamEvent{Type: types.StreamEventStart, ResponseID: s.id}
+
+	case "content-delta":
+		if msg == nil || msg.Content == nil || msg.Content.Text == "" {
+			return nil
+		}
+		s.content.WriteString(msg.Content.Text)
+		return &types.StreamEvent{
+			Type:  types.StreamEventContentDelta,
+			Delta: &types.ContentBlock{Type: types.ContentTypeText, Text: msg.Content.Text},
+		}
+
+	case "tool-plan-delta":
+		if msg == nil || msg.ToolPlan == "" {
+			return nil
+		}
+		s.toolPlan.WriteString(msg.ToolPlan)
+		return &types.StreamEvent{
+			Type:  types.StreamEventThinkingDelta,
+			Delta: &types.ContentBlock{Type: types.ContentTypeText, Text: msg.ToolPlan},
+		}
+
+	case "tool-call-start":
+		if msg == nil || msg.ToolCalls == nil {
+			return nil
+		}
+		call := &types.ToolCall{ID: msg.ToolCalls.ID, Name: msg.ToolCalls.Function.Name}
+		input := &strings.Builder{}
+		input.WriteString(msg.ToolCalls.Function.Arguments)
+		s.toolCalls = append(s.toolCalls, call)
+		s.toolInputs = append(s.toolInputs, input)
+		return &types.StreamEvent{
+			Type:     types.StreamEventToolCallStart,
+			ToolCall: &types.ToolCall{ID: call.ID, Name: call.Name},
+			Index:    len(s.toolCalls) - 1,
+		}
+
+	case "tool-call-delta":
+		if msg == nil || msg.ToolCalls == nil || len(s.toolInputs) == 0 {
+			return nil
+		}
+		args := msg.ToolCalls.Function.Arguments
+		s.toolInputs[len(s.toolInputs)-1].WriteString(args)
+		return &types.StreamEvent{
+			Type:           types.StreamEventToolCallDelta,
+			ToolInputDelta: args,
+			Index:          len(s.toolInputs) - 1,
+		}
+
+	case "tool-call-end":
+		if len(s.toolCalls) == 0 {
+			return nil
+		}
+		i := len(s.toolCalls) - 1
+		tc := s.toolCalls[i]
+		var input any
+		json.Unmarshal([]byte(s.toolInputs[i].String()), &input)
+		tc.Input = input
+		end := *tc
+		return &types.StreamEvent{
+			Type:     types.StreamEventToolCallEnd,
+			ToolCall: &end,
+			Index:    i,
+		}
+
+	case "citation-start":
+		if msg == nil || msg.Citations == nil {
+			return nil
+		}
+		// Citations arrive after the text they cite.
+		s.citations = append(s.citations, transformCitation(*msg.Citations, s.content.String(), s.documents)...)
+		return nil
+
+	case "message-end":
+		if event.Delta != nil {
+			s.stopReason = s.transformer.TransformStopReason(event.Delta.FinishReason)
+			if event.Delta.Usage != nil {
+				usage := transformUsage(event.Delta.Usage)
+				s.usage = &usage
+			}
+		}
+		s.done = true
+		s.buildResponse()
+		return &types.StreamEvent{
+			Type:       types.StreamEventDone,
+			Usage:      s.usage,
+			StopReason: s.stopReason,
+			ResponseID: s.id,
+		}
+	}
+
+	return nil
+}
+
+// buildResponse builds the final response from accumulated state.
+func (s *streamReader) buildResponse() {
+	var content []types.ContentBlock
+
+	if s.toolPlan.Len() > 0 {
+		content = append(content, types.ContentBlock{Type: types.ContentTypeThinking, Text: s.toolPlan.String()})
+	}
+	if s.content.Len() > 0 {
+		content = append(content, types.ContentBlock{Type: types.ContentTypeText, Text: s.content.String()})
+	}
+	for _, c := range s.citations {
+		content = append(content, types.ContentBlock{Type: types.ContentTypeCitation, Citation: c})
+	}
+
+	var toolCalls []types.ToolCall
+	for i, tc := range s.toolCalls {
+		var input any
+		json.Unmarshal([]byte(s.toolInputs[i].String()), &input)
+		tc.Input = input
+		toolCalls = append(toolCalls, *tc)
+
+		content = append(content, types.ContentBlock{
+			Type:      types.ContentTypeToolUse,
+			ToolUseID: tc.ID,
+			ToolName:  tc.Name,
+			ToolInput: tc.Input,
+		})
+	}
+
+	s.response = &types.CompletionResponse{
+		ID:               s.id,
+		Provider:         types.ProviderCohere,
+		Model:            s.model,
+		Content:          content,
+		StopReason:       s.stopReason,
+		ToolCalls:        toolCalls,
+		CreatedAt:        time.Now(),
+		ProviderMetadata: s.meta,
+	}
+	s.response.Citations = provider.CollectCitations(content)
+
+	if s.usage != nil {
+		s.response.Usage = *s.usage
+	}
+}
+
+// Close closes the stream. It is idempotent and safe to call while Next is blocked.
+func (s *streamReader) Close() error {
+	return s.body.Close()
+}
+
+// Response returns the accumulated response.
+func (s *streamReader) Response() *types.CompletionResponse {
+	return s.response
+}
+
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// Ensure Client implements provider.Provider
+var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
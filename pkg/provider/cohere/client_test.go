@@ -0,0 +1,159 @@
+package cohere
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+var testDocuments = []types.Document{
+	{Title: "Tall penguins", Text: "Emperor penguins are the tallest.", Fields: map[string]string{"url": "https://example.com/tall"}},
+	{ID: "habitats", Title: "Penguin habitats", Text: "Emperor penguins only live in Antarctica."},
+}
+
+func TestComplete_DocumentsAndCitations(t *testing.T) {
+	var sent ChatRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v2/chat" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		fmt.Fprint(w, `{
+			"id": "abc", "finish_reason": "COMPLETE",
+			"message": {"role": "assistant",
+				"content": [{"type": "text", "text": "The tallest penguins are emperor penguins, which live in Antarctica."}],
+				"citations": [
+					{"start": 25, "end": 42, "text": "emperor penguins,", "sources": [{"type": "document", "id": "doc_0"}]},
+					{"start": 57, "end": 67, "text": "Antarctica", "sources": [{"type": "document", "id": "habitats"}]}
+				]},
+			"usage": {"tokens": {"input_tokens": 120, "output_tokens": 14}}
+		}`)
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:     "command-a-03-2025",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Where do the tallest penguins live?")},
+		Documents: testDocuments,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(sent.Documents) != 2 || sent.Documents[0].ID != "doc_0" || sent.Documents[1].Data["title"] != "Penguin habitats" {
+		t.Errorf("documents sent as %+v", sent.Documents)
+	}
+
+	if len(resp.Citations) != 2 {
+		t.Fatalf("expected 2 citations, got %+v", resp.Citations)
+	}
+	first, second := resp.Citations[0], resp.Citations[1]
+	if first.DocumentIndex != 0 || first.URL != "https://example.com/tall" || second.DocumentIndex != 1 || second.Title != "Penguin habitats" {
+		t.Errorf("unexpected citations: %+v", resp.Citations)
+	}
+	if got := resp.Text()[second.StartIndex:second.EndIndex]; got != "Antarctica" {
+		t.Errorf("citation span = %q", got)
+	}
+	if resp.Usage.TotalTokens != 134 || resp.Model != "command-a-03-2025" {
+		t.Errorf("usage = %+v, model = %q", resp.Usage, resp.Model)
+	}
+}
+
+func TestTransformRequest_ToolRoundTrip(t *testing.T) {
+	req := NewTransformer().TransformRequest(&types.CompletionRequest{
+		Model: "command-a-03-2025",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Weather in Paris?"),
+			{Role: types.RoleAssistant, Content: []types.ContentBlock{
+				{Type: types.ContentTypeThinking, Text: "I will look up the weather."},
+				{Type: types.ContentTypeToolUse, ToolUseID: "call_1", ToolName: "get_weather", ToolInput: map[string]any{"location": "Paris"}},
+			}},
+			types.NewToolResultMessage("call_1", `{"temperature": 22}`, false),
+		},
+		Tools:      []types.Tool{{Name: "get_weather", Parameters: types.JSONSchema{Type: "object"}}},
+		ToolChoice: &types.ToolChoice{Type: types.ToolChoiceRequired},
+	})
+
+	assistant := req.Messages[1]
+	if assistant.ToolPlan != "I will look up the weather." || len(assistant.ToolCalls) != 1 || assistant.ToolCalls[0].Function.Arguments != `{"location":"Paris"}` {
+		t.Errorf("unexpected assistant message: %+v", assistant)
+	}
+	if result := req.Messages[2]; result.Role != "tool" || result.ToolCallID != "call_1" {
+		t.Errorf("unexpected tool message: %+v", result)
+	}
+	if req.ToolChoice != "REQUIRED" || req.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("tools = %+v, tool_choice = %q", req.Tools, req.ToolChoice)
+	}
+}
+
+func TestStream(t *testing.T) {
+	events := []string{
+		`{"type":"message-start","id":"abc","delta":{"message":{"role":"assistant"}}}`,
+		`{"type":"tool-plan-delta","delta":{"message":{"tool_plan":"Checking docs."}}}`,
+		`{"type":"content-start","index":0,"delta":{"message":{"content":{"type":"text","text":""}}}}`,
+		`{"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"In "}}}}`,
+		`{"type":"content-delta","index":0,"delta":{"message":{"content":{"text":"Antarctica."}}}}`,
+		`{"type":"content-end","index":0}`,
+		`{"type":"citation-start","index":0,"delta":{"message":{"citations":{"start":3,"end":13,"text":"Antarctica","sources":[{"type":"document","id":"habitats"}]}}}}`,
+		`{"type":"citation-end","index":0}`,
+		`{"type":"message-end","delta":{"finish_reason":"COMPLETE","usage":{"tokens":{"input_tokens":50,"output_tokens":4}}}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, e := range events {
+			var typ struct{ Type string }
+			json.Unmarshal([]byte(e), &typ)
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", typ.Type, e)
+		}
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), &types.CompletionRequest{
+		Model:     "command-a-03-2025",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Where?")},
+		Documents: testDocuments,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var thinking, text strings.Builder
+	var done *types.StreamEvent
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventThinkingDelta:
+			thinking.WriteString(event.Delta.Text)
+		case types.StreamEventContentDelta:
+			text.WriteString(event.Delta.Text)
+		case types.StreamEventDone:
+			done = event
+		}
+	}
+	if thinking.String() != "Checking docs." || text.String() != "In Antarctica." {
+		t.Errorf("thinking = %q, text = %q", thinking.String(), text.String())
+	}
+	if done == nil || done.Usage.OutputTokens != 4 {
+		t.Fatalf("done event = %+v", done)
+	}
+
+	resp := stream.Response()
+	if len(resp.Citations) != 1 || resp.Citations[0].DocumentIndex != 1 || resp.Citations[0].StartIndex != 3 {
+		t.Errorf("citations = %+v", resp.Citations)
+	}
+}
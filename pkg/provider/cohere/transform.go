@@ -0,0 +1,299 @@
+package cohere
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Transformer handles conversion between unified and Cohere formats.
+type Transformer struct{}
+
+// NewTransformer creates a new transformer.
+func NewTransformer() *Transformer {
+	return &Transformer{}
+}
+
+// TransformRequest converts a unified request to Cohere format.
+func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatRequest {
+	cReq := &ChatRequest{
+		Model:         req.Model,
+		Messages:      t.transformMessages(req.Messages),
+		Documents:     transformDocuments(req.Documents),
+		MaxTokens:     req.MaxTokens,
+		Temperature:   req.Temperature,
+		P:             req.TopP,
+		K:             req.TopK,
+		Seed:          req.Seed,
+		StopSequences: req.StopSequences,
+		Stream:        req.Stream,
+	}
+
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json":
+			cReq.ResponseFormat = &ResponseFormat{Type: "json_object"}
+		case "json_schema":
+			cReq.ResponseFormat = &ResponseFormat{Type: "json_object"}
+			if rf.Schema != nil {
+				cReq.ResponseFormat.JSONSchema = rf.Schema.ToMap()
+			}
+		}
+	}
+
+	for _, tool := range req.Tools {
+		cReq.Tools = append(cReq.Tools, Tool{
+			Type: "function",
+			Function: Function{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters.ToMap(),
+			},
+		})
+	}
+
+	// Cohere has no way to name the tool to call, so a specific tool is
+	// required by offering only that one.
+	if tc := req.ToolChoice; tc != nil {
+		switch tc.Type {
+		case types.ToolChoiceRequired:
+			cReq.ToolChoice = "REQUIRED"
+		case types.ToolChoiceNone:
+			cReq.ToolChoice = "NONE"
+		case types.ToolChoiceTool:
+			cReq.ToolChoice = "REQUIRED"
+			for _, tool := range cReq.Tools {
+				if tool.Function.Name == tc.Name {
+					cReq.Tools = []Tool{tool}
+					break
+				}
+			}
+		}
+	}
+
+	return cReq
+}
+
+// documentID returns the ID a request document is sent with.
+func documentID(doc types.Document, index int) string {
+	if doc.ID != "" {
+		return doc.ID
+	}
+	return fmt.Sprintf("doc_%d", index)
+}
+
+// transformDocuments converts grounding documents to Cohere format.
+func transformDocuments(docs []types.Document) []Document {
+	var result []Document
+	for i, doc := range docs {
+		data := make(map[string]string, len(doc.Fields)+2)
+		for k, v := range doc.Fields {
+			data[k] = v
+		}
+		if doc.Title != "" {
+			data["title"] = doc.Title
+		}
+		data["text"] = doc.Text
+		result = append(result, Document{ID: documentID(doc, i), Data: data})
+	}
+	return result
+}
+
+// transformMessages converts unified messages to Cohere format. Each tool
+// result becomes its own tool message.
+func (t *Transformer) transformMessages(messages []types.Message) []Message {
+	messages = provider.PairToolResults(messages)
+	var result []Message
+
+	for _, msg := range messages {
+		switch msg.Role {
+		case types.RoleTool:
+			for _, block := range msg.Content {
+				if block.Type == types.ContentTypeToolResult {
+					result = append(result, Message{
+						Role:       "tool",
+						ToolCallID: block.ToolResultID,
+						Content:    block.Text,
+					})
+				}
+			}
+		case types.RoleAssistant:
+			cMsg := Message{Role: "assistant"}
+			var text strings.Builder
+			for _, block := range msg.Content {
+				switch block.Type {
+				case types.ContentTypeText:
+					text.WriteString(block.Text)
+				case types.ContentTypeThinking:
+					cMsg.ToolPlan += block.Text
+				case types.ContentTypeToolUse:
+					args, _ := json.Marshal(block.ToolInput)
+					cMsg.ToolCalls = append(cMsg.ToolCalls, ToolCall{
+						ID:       block.ToolUseID,
+						Type:     "function",
+						Function: FunctionCall{Name: block.ToolName, Arguments: string(args)},
+					})
+				}
+			}
+			if text.Len() > 0 {
+				cMsg.Content = text.String()
+			}
+			// A tool plan is only accepted with tool calls.
+			if len(cMsg.ToolCalls) == 0 {
+				cMsg.ToolPlan = ""
+			}
+			result = append(result, cMsg)
+		default:
+			var text strings.Builder
+			for _, block := range msg.Content {
+				if block.Type == types.ContentTypeText {
+					text.WriteString(block.Text)
+				}
+			}
+			result = append(result, Message{Role: string(msg.Role), Content: text.String()})
+		}
+	}
+
+	return result
+}
+
+// TransformResponse converts a Cohere response to unified format. The tool
+// plan, Cohere's reasoning before tool calls, becomes a thinking block, and
+// citations follow the text they support. documents are the request's
+// documents, to resolve cited document IDs to indexes.
+func (t *Transformer) TransformResponse(resp *ChatResponse, documents []types.Document) *types.CompletionResponse {
+	if resp == nil || resp.Message == nil {
+		return nil
+	}
+
+	msg := resp.Message
+	var content []types.ContentBlock
+	if msg.ToolPlan != "" {
+		content = append(content, types.ContentBlock{Type: types.ContentTypeThinking, Text: msg.ToolPlan})
+	}
+
+	var text strings.Builder
+	for _, item := range msg.Content {
+		if item.Type == "text" {
+			text.WriteString(item.Text)
+		}
+	}
+	if text.Len() > 0 {
+		content = append(content, types.ContentBlock{Type: types.ContentTypeText, Text: text.String()})
+	}
+	for _, c := range msg.Citations {
+		for _, cite := range transformCitation(c, text.String(), documents) {
+			content = append(content, types.ContentBlock{Type: types.ContentTypeCitation, Citation: cite})
+		}
+	}
+
+	var toolCalls []types.ToolCall
+	for _, tc := range msg.ToolCalls {
+		call := transformToolCall(tc)
+		toolCalls = append(toolCalls, call)
+		content = append(content, types.ContentBlock{
+			Type:      types.ContentTypeToolUse,
+			ToolUseID: call.ID,
+			ToolName:  call.Name,
+			ToolInput: call.Input,
+		})
+	}
+
+	result := &types.CompletionResponse{
+		ID:         resp.ID,
+		Provider:   types.ProviderCohere,
+		Content:    content,
+		StopReason: t.TransformStopReason(resp.FinishReason),
+		ToolCalls:  toolCalls,
+		CreatedAt:  time.Now(),
+	}
+	result.Citations = provider.CollectCitations(result.Content)
+	if resp.Usage != nil {
+		result.Usage = transformUsage(resp.Usage)
+	}
+
+	return result
+}
+
+// transformToolCall converts a Cohere tool call, decoding its arguments.
+func transformToolCall(tc ToolCall) types.ToolCall {
+	var input any
+	json.Unmarshal([]byte(tc.Function.Arguments), &input)
+	return types.ToolCall{ID: tc.ID, Name: tc.Function.Name, Input: input}
+}
+
+// transformCitation converts a citation of the response text into one
+// unified citation per source.
+func transformCitation(c Citation, text string, documents []types.Document) []*types.Citation {
+	var result []*types.Citation
+	for _, src := range c.Sources {
+		cite := &types.Citation{
+			Type:       src.Type,
+			Text:       c.Text,
+			StartIndex: byteOffset(text, c.Start),
+			EndIndex:   byteOffset(text, c.End),
+		}
+		if src.Type == "document" {
+			for i, doc := range documents {
+				if documentID(doc, i) == src.ID {
+					cite.DocumentIndex = i
+					cite.Title = doc.Title
+					cite.URL = doc.Fields["url"]
+					break
+				}
+			}
+		}
+		if cited, ok := src.Document["snippet"].(string); ok {
+			cite.CitedText = cited
+		}
+		result = append(result, cite)
+	}
+	return result
+}
+
+// byteOffset converts a character index in text to a byte offset, clamped to
+// the text.
+func byteOffset(text string, chars int) int {
+	n := 0
+	for i := range text {
+		if n == chars {
+			return i
+		}
+		n++
+	}
+	return len(text)
+}
+
+// transformUsage converts Cohere usage, preferring the model's token counts
+// over billed units.
+func transformUsage(u *Usage) types.Usage {
+	tokens := u.Tokens
+	if tokens == nil {
+		tokens = u.BilledUnits
+	}
+	if tokens == nil {
+		return types.Usage{}
+	}
+	in, out := int(tokens.InputTokens), int(tokens.OutputTokens)
+	return types.Usage{InputTokens: in, OutputTokens: out, TotalTokens: in + out}
+}
+
+// TransformStopReason converts a Cohere finish reason to unified format.
+func (t *Transformer) TransformStopReason(reason string) types.StopReason {
+	switch reason {
+	case "MAX_TOKENS":
+		return types.StopReasonMaxTokens
+	case "STOP_SEQUENCE":
+		return types.StopReasonStopSequence
+	case "TOOL_CALL":
+		return types.StopReasonToolUse
+	case "ERROR_TOXIC":
+		return types.StopReasonContentFilter
+	default:
+		return types.StopReasonEnd
+	}
+}
@@ -0,0 +1,160 @@
+package cohere
+
+// ChatRequest is a Cohere v2 chat request.
+type ChatRequest struct {
+	Model            string          `json:"model"`
+	Messages         []Message       `json:"messages"`
+	Documents        []Document      `json:"documents,omitempty"`
+	Tools            []Tool          `json:"tools,omitempty"`
+	ToolChoice       string          `json:"tool_choice,omitempty"` // "REQUIRED" or "NONE"
+	ResponseFormat   *ResponseFormat `json:"response_format,omitempty"`
+	MaxTokens        *int            `json:"max_tokens,omitempty"`
+	Temperature      *float64        `json:"temperature,omitempty"`
+	P                *float64        `json:"p,omitempty"`
+	K                *int            `json:"k,omitempty"`
+	StopSequences    []string        `json:"stop_sequences,omitempty"`
+	FrequencyPenalty *float64        `json:"frequency_penalty,omitempty"`
+	PresencePenalty  *float64        `json:"presence_penalty,omitempty"`
+	Seed             *int            `json:"seed,omitempty"`
+	Stream           bool            `json:"stream,omitempty"`
+}
+
+// Message is a chat message. Content is a string or []ContentItem.
+type Message struct {
+	Role       string     `json:"role"` // "system", "user", "assistant", or "tool"
+	Content    any        `json:"content,omitempty"`
+	ToolPlan   string     `json:"tool_plan,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ContentItem is an item of message content.
+type ContentItem struct {
+	Type string `json:"type"` // "text"
+	Text string `json:"text"`
+}
+
+// Document is a grounding document. Data holds its fields, such as "title"
+// and "text".
+type Document struct {
+	ID   string            `json:"id,omitempty"`
+	Data map[string]string `json:"data"`
+}
+
+// Tool is a function the model may call.
+type Tool struct {
+	Type     string   `json:"type"` // "function"
+	Function Function `json:"function"`
+}
+
+// Function describes a tool function.
+type Function struct {
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// ToolCall is a tool call by the model.
+type ToolCall struct {
+	ID       string       `json:"id,omitempty"`
+	Type     string       `json:"type,omitempty"`
+	Function FunctionCall `json:"function"`
+}
+
+// FunctionCall is the function and JSON arguments of a tool call.
+type FunctionCall struct {
+	Name      string `json:"name,omitempty"`
+	Arguments string `json:"arguments,omitempty"`
+}
+
+// ResponseFormat configures JSON output, optionally constrained by a schema.
+type ResponseFormat struct {
+	Type       string         `json:"type"` // "text" or "json_object"
+	JSONSchema map[string]any `json:"json_schema,omitempty"`
+}
+
+// ChatResponse is a Cohere v2 chat response.
+type ChatResponse struct {
+	ID           string           `json:"id"`
+	FinishReason string           `json:"finish_reason"`
+	Message      *ResponseMessage `json:"message"`
+	Usage        *Usage           `json:"usage,omitempty"`
+}
+
+// ResponseMessage is the assistant message of a response.
+type ResponseMessage struct {
+	Role      string        `json:"role"`
+	Content   []ContentItem `json:"content,omitempty"`
+	ToolPlan  string        `json:"tool_plan,omitempty"`
+	ToolCalls []ToolCall    `json:"tool_calls,omitempty"`
+	Citations []Citation    `json:"citations,omitempty"`
+}
+
+// Citation attributes the response text between Start and End, which count
+// characters, to its sources.
+type Citation struct {
+	Start   int      `json:"start"`
+	End     int      `json:"end"`
+	Text    string   `json:"text"`
+	Sources []Source `json:"sources"`
+}
+
+// Source is a document or tool result a citation refers to.
+type Source struct {
+	Type     string         `json:"type"` // "document" or "tool"
+	ID       string         `json:"id"`
+	Document map[string]any `json:"document,omitempty"`
+}
+
+// Usage is token usage. Tokens are the model's counts; billed units exclude
+// tokens Cohere does not charge for.
+type Usage struct {
+	BilledUnits *Tokens `json:"billed_units,omitempty"`
+	Tokens      *Tokens `json:"tokens,omitempty"`
+}
+
+// Tokens are input and output token counts.
+type Tokens struct {
+	InputTokens  float64 `json:"input_tokens"`
+	OutputTokens float64 `json:"output_tokens"`
+}
+
+// StreamEvent is a server-sent event of a streaming chat.
+type StreamEvent struct {
+	Type  string       `json:"type"`
+	ID    string       `json:"id,omitempty"`
+	Index int          `json:"index"`
+	Delta *StreamDelta `json:"delta,omitempty"`
+}
+
+// StreamDelta is the payload of a stream event.
+type StreamDelta struct {
+	Message      *StreamMessage `json:"message,omitempty"`
+	FinishReason string         `json:"finish_reason,omitempty"`
+	Usage        *Usage         `json:"usage,omitempty"`
+}
+
+// StreamMessage is the part of the message a stream event adds.
+type StreamMessage struct {
+	Content   *ContentItem `json:"content,omitempty"`
+	ToolPlan  string       `json:"tool_plan,omitempty"`
+	ToolCalls *ToolCall    `json:"tool_calls,omitempty"`
+	Citations *Citation    `json:"citations,omitempty"`
+}
+
+// ErrorResponse is a Cohere error response.
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
+
+// ModelList is the response of the models endpoint.
+type ModelList struct {
+	Models []Model `json:"models"`
+}
+
+// Model is a model available to the API key.
+type Model struct {
+	Name          string   `json:"name"`
+	Endpoints     []string `json:"endpoints"`
+	ContextLength float64  `json:"context_length"`
+}
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ContentDecoder returns a reader of the decompressed content of r.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+var (
+	decodersMu sync.RWMutex
+	decoders   = map[string]ContentDecoder{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) { return gzip.NewReader(r) },
+	}
+)
+
+// RegisterContentDecoder adds a response Content-Encoding that the built-in
+// clients accept and decode. gzip is registered by default; the standard
+// library has no zstd decoder, so register one to accept zstd:
+//
+//	provider.RegisterContentDecoder("zstd", func(r io.Reader) (io.ReadCloser, error) {
+//		d, err := zstd.NewReader(r)
+//		if err != nil {
+//			return nil, err
+//		}
+//		return d.IOReadCloser(), nil
+//	})
+func RegisterContentDecoder(encoding string, decode ContentDecoder) {
+	decodersMu.Lock()
+	defer decodersMu.Unlock()
+	decoders[strings.ToLower(encoding)] = decode
+}
+
+// acceptEncoding returns the Accept-Encoding header listing the registered
+// decoders.
+func acceptEncoding() string {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	return strings.Join(slices.Sorted(maps.Keys(decoders)), ", ")
+}
+
+// contentDecoder returns the registered decoder of a Content-Encoding.
+func contentDecoder(encoding string) (ContentDecoder, bool) {
+	decodersMu.RLock()
+	defer decodersMu.RUnlock()
+	decode, ok := decoders[strings.ToLower(strings.TrimSpace(encoding))]
+	return decode, ok
+}
+
+// WithRequestCompression gzips request bodies of at least minSize bytes and
+// sends them with Content-Encoding: gzip. Only enable it for endpoints that
+// accept compressed request bodies, such as a gateway in front of the
+// provider; the provider APIs themselves may reject them. It has no effect
+// with WithHTTPClient.
+func WithRequestCompression(minSize int) Option {
+	return func(c *Config) {
+		c.RequestCompression = max(minSize, 1)
+	}
+}
+
+// compressionTransport advertises the registered content encodings,
+// decodes compressed responses, and optionally compresses request bodies.
+type compressionTransport struct {
+	base http.RoundTripper
+
+	// minSize is the smallest request body to compress; zero disables
+	// request compression.
+	minSize int
+}
+
+func (t *compressionTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	// Leave requests that negotiate their own encoding alone.
+	if req.Header.Get("Accept-Encoding") != "" {
+		return base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set("Accept-Encoding", acceptEncoding())
+
+	if t.minSize > 0 && req.Body != nil && req.Body != http.NoBody && req.Header.Get("Content-Encoding") == "" {
+		if err := compressBody(req, t.minSize); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if decode, ok := contentDecoder(resp.Header.Get("Content-Encoding")); ok {
+		resp.Body = &decodedBody{body: resp.Body, decode: decode}
+		resp.Header.Del("Content-Encoding")
+		resp.Header.Del("Content-Length")
+		resp.ContentLength = -1
+		resp.Uncompressed = true
+	}
+	return resp, nil
+}
+
+// CloseIdleConnections closes the idle connections of the base transport,
+// so http.Client.CloseIdleConnections reaches it.
+func (t *compressionTransport) CloseIdleConnections() {
+	base := t.base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	CloseIdleConnections(base)
+}
+
+// compressBody gzips req's body in place if it is at least minSize bytes.
+func compressBody(req *http.Request, minSize int) error {
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return err
+	}
+	if len(body) < minSize {
+		req.Body = io.NopCloser(bytes.NewReader(body))
+		req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(body)), nil }
+		return nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	zw.Write(body)
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	compressed := buf.Bytes()
+
+	req.Body = io.NopCloser(bytes.NewReader(compressed))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(compressed)), nil }
+	req.ContentLength = int64(len(compressed))
+	req.Header.Set("Content-Encoding", "gzip")
+	return nil
+}
+
+// decodedBody decodes a compressed response body. The decoder is created on
+// the first read, so an empty body is not an error until it is read.
+type decodedBody struct {
+	body    io.ReadCloser
+	decode  ContentDecoder
+	r       io.ReadCloser
+	initErr error
+}
+
+func (b *decodedBody) Read(p []byte) (int, error) {
+	if b.r == nil && b.initErr == nil {
+		b.r, b.initErr = b.decode(b.body)
+	}
+	if b.initErr != nil {
+		return 0, b.initErr
+	}
+	return b.r.Read(p)
+}
+
+func (b *decodedBody) Close() error {
+	if b.r != nil {
+		b.r.Close()
+	}
+	return b.body.Close()
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestNewHTTPClient_Compression(t *testing.T) {
+	large := strings.Repeat(`{"role":"user","content":"hello"}`, 100)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			t.Errorf("Accept-Encoding = %q", r.Header.Get("Accept-Encoding"))
+		}
+
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			zr, err := gzip.NewReader(r.Body)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body = zr
+		}
+		data, _ := io.ReadAll(body)
+		if (r.Header.Get("Content-Encoding") == "gzip") != (len(data) == len(large)) {
+			t.Errorf("%d byte body sent with Content-Encoding %q", len(data), r.Header.Get("Content-Encoding"))
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		zw := gzip.NewWriter(w)
+		zw.Write(data)
+		zw.Close()
+	}))
+	defer srv.Close()
+
+	cfg := DefaultConfig()
+	ApplyOptions(cfg, WithRequestCompression(1024))
+	client := NewHTTPClient(cfg)
+
+	for _, body := range []string{large, "small"} {
+		resp, err := client.Post(srv.URL, "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(data, []byte(body)) || resp.Header.Get("Content-Encoding") != "" || !resp.Uncompressed {
+			t.Errorf("decoded %d bytes, want %d; Content-Encoding %q", len(data), len(body), resp.Header.Get("Content-Encoding"))
+		}
+	}
+}
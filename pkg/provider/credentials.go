@@ -0,0 +1,255 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// TokenSource supplies OAuth2 access tokens. Implementations must be safe for
+// concurrent use and should cache tokens until they expire.
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+const (
+	googleTokenURL    = "https://oauth2.googleapis.com/token"
+	googleCloudScope  = "https://www.googleapis.com/auth/cloud-platform"
+	metadataTokenPath = "/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// tokenExpiryMargin refreshes tokens this long before they expire.
+	tokenExpiryMargin = time.Minute
+)
+
+// DefaultCredentials returns a TokenSource for Google Application Default
+// Credentials. On first use it looks, in order, for the JSON key file named
+// by GOOGLE_APPLICATION_CREDENTIALS, gcloud's application default credentials
+// ("gcloud auth application-default login"), and the GCE metadata server.
+// Service account and authorized user credential files are supported. A nil
+// client uses http.DefaultClient.
+func DefaultCredentials(client *http.Client) TokenSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &adcTokenSource{client: client}
+}
+
+// adcTokenSource fetches and caches tokens from Application Default
+// Credentials.
+type adcTokenSource struct {
+	client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+// credentialsFile is the subset of a Google credentials JSON file used here.
+type credentialsFile struct {
+	Type string `json:"type"`
+
+	// Service account fields.
+	ClientEmail  string `json:"client_email"`
+	PrivateKey   string `json:"private_key"`
+	PrivateKeyID string `json:"private_key_id"`
+	TokenURI     string `json:"token_uri"`
+
+	// Authorized user fields.
+	ClientID     string `json:"client_id"`
+	ClientSecret string `json:"client_secret"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Token returns a cached token, fetching a new one if it is about to expire.
+func (s *adcTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Add(tokenExpiryMargin).Before(s.expiry) {
+		return s.token, nil
+	}
+
+	tok, err := s.fetch(ctx)
+	if err != nil {
+		return "", fmt.Errorf("application default credentials: %w", err)
+	}
+	s.token = tok.AccessToken
+	s.expiry = time.Now().Add(time.Duration(tok.ExpiresIn) * time.Second)
+	return s.token, nil
+}
+
+func (s *adcTokenSource) fetch(ctx context.Context) (*tokenResponse, error) {
+	path := credentialsPath()
+	if path == "" {
+		return s.fetchMetadata(ctx)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var creds credentialsFile
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+
+	switch creds.Type {
+	case "service_account":
+		assertion, err := signJWT(&creds, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		tokenURL := creds.TokenURI
+		if tokenURL == "" {
+			tokenURL = googleTokenURL
+		}
+		return s.exchange(ctx, tokenURL, url.Values{
+			"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+			"assertion":  {assertion},
+		})
+	case "authorized_user":
+		return s.exchange(ctx, googleTokenURL, url.Values{
+			"grant_type":    {"refresh_token"},
+			"client_id":     {creds.ClientID},
+			"client_secret": {creds.ClientSecret},
+			"refresh_token": {creds.RefreshToken},
+		})
+	default:
+		return nil, fmt.Errorf("unsupported credentials type %q in %s", creds.Type, path)
+	}
+}
+
+// credentialsPath returns the credentials file to use, or "" to fall back to
+// the metadata server.
+func credentialsPath() string {
+	if path := os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"); path != "" {
+		return path
+	}
+
+	var dir string
+	if runtime.GOOS == "windows" {
+		dir = filepath.Join(os.Getenv("APPDATA"), "gcloud")
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dir = filepath.Join(home, ".config", "gcloud")
+	}
+	path := filepath.Join(dir, "application_default_credentials.json")
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return path
+}
+
+// signJWT creates the signed assertion for the service account JWT bearer
+// grant.
+func signJWT(creds *credentialsFile, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(creds.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("service account private key is not PEM encoded")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		if parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes); err != nil {
+			return "", fmt.Errorf("parsing service account private key: %w", err)
+		}
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("service account private key is not an RSA key")
+	}
+
+	aud := creds.TokenURI
+	if aud == "" {
+		aud = googleTokenURL
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": creds.PrivateKeyID})
+	claims, _ := json.Marshal(map[string]any{
+		"iss":   creds.ClientEmail,
+		"scope": googleCloudScope,
+		"aud":   aud,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+
+	enc := base64.RawURLEncoding
+	signed := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return signed + "." + enc.EncodeToString(sig), nil
+}
+
+// exchange posts an OAuth2 token request.
+func (s *adcTokenSource) exchange(ctx context.Context, tokenURL string, form url.Values) (*tokenResponse, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return s.do(req)
+}
+
+// fetchMetadata gets a token for the instance's service account from the GCE
+// metadata server. GCE_METADATA_HOST overrides its address.
+func (s *adcTokenSource) fetchMetadata(ctx context.Context) (*tokenResponse, error) {
+	host := os.Getenv("GCE_METADATA_HOST")
+	if host == "" {
+		host = "metadata.google.internal"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+host+metadataTokenPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+	tok, err := s.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("no credentials file found and the metadata server is unavailable: %w", err)
+	}
+	return tok, nil
+}
+
+func (s *adcTokenSource) do(req *http.Request) (*tokenResponse, error) {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token request returned status %d: %s", resp.StatusCode, body)
+	}
+	var tok tokenResponse
+	if err := json.Unmarshal(body, &tok); err != nil {
+		return nil, fmt.Errorf("decoding token response: %w", err)
+	}
+	if tok.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access token")
+	}
+	return &tok, nil
+}
@@ -0,0 +1,179 @@
+package provider
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCredentials(t *testing.T, creds map[string]string) {
+	t.Helper()
+	data, _ := json.Marshal(creds)
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", path)
+}
+
+// writeServiceAccount writes a service account key file whose token URI is
+// tokenURI and returns its key.
+func writeServiceAccount(t *testing.T, tokenURI string) *rsa.PrivateKey {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, _ := x509.MarshalPKCS8PrivateKey(key)
+	writeCredentials(t, map[string]string{
+		"type":         "service_account",
+		"client_email": "sa@example.iam.gserviceaccount.com",
+		"private_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+		"token_uri":    tokenURI,
+	})
+	return key
+}
+
+func TestDefaultCredentials_ServiceAccount(t *testing.T) {
+	var key *rsa.PrivateKey
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		r.ParseForm()
+		if r.Form.Get("grant_type") != "urn:ietf:params:oauth:grant-type:jwt-bearer" {
+			t.Errorf("grant_type = %q", r.Form.Get("grant_type"))
+		}
+
+		parts := strings.Split(r.Form.Get("assertion"), ".")
+		if len(parts) != 3 {
+			t.Fatalf("assertion has %d parts", len(parts))
+		}
+		sig, _ := base64.RawURLEncoding.DecodeString(parts[2])
+		sum := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+		if err := rsa.VerifyPKCS1v15(&key.PublicKey, crypto.SHA256, sum[:], sig); err != nil {
+			t.Errorf("invalid signature: %v", err)
+		}
+		claims, _ := base64.RawURLEncoding.DecodeString(parts[1])
+		if !strings.Contains(string(claims), `"iss":"sa@example.iam.gserviceaccount.com"`) {
+			t.Errorf("claims = %s", claims)
+		}
+
+		w.Write([]byte(`{"access_token":"ya29.sa","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	key = writeServiceAccount(t, server.URL)
+
+	ts := DefaultCredentials(nil)
+	for range 2 {
+		token, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if token != "ya29.sa" {
+			t.Errorf("token = %q", token)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("got %d token requests, want 1 (cached)", requests)
+	}
+}
+
+func TestDefaultCredentials_MetadataServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" || r.URL.Path != metadataTokenPath {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		w.Write([]byte(`{"access_token":"ya29.gce","expires_in":3600}`))
+	}))
+	defer server.Close()
+
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(server.URL, "http://"))
+
+	token, err := DefaultCredentials(nil).Token(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if token != "ya29.gce" {
+		t.Errorf("token = %q", token)
+	}
+}
+
+func TestDefaultCredentials_UnsupportedType(t *testing.T) {
+	writeCredentials(t, map[string]string{"type": "external_account"})
+
+	_, err := DefaultCredentials(nil).Token(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "external_account") {
+		t.Errorf("err = %v, want unsupported type error", err)
+	}
+}
+
+func TestDefaultCredentials_Refresh(t *testing.T) {
+	// Each response is served once; the first expires within the refresh
+	// margin and the second fails.
+	responses := []struct {
+		status int
+		body   string
+	}{
+		{http.StatusOK, `{"access_token":"ya29.short","expires_in":30}`},
+		{http.StatusServiceUnavailable, `unavailable`},
+		{http.StatusOK, `{"access_token":"ya29.long","expires_in":3600}`},
+		{http.StatusOK, `{"access_token":"ya29.next","expires_in":3600}`},
+	}
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := responses[requests]
+		requests++
+		w.WriteHeader(resp.status)
+		w.Write([]byte(resp.body))
+	}))
+	defer server.Close()
+	writeServiceAccount(t, server.URL)
+
+	ts := DefaultCredentials(nil)
+	token := func() string {
+		t.Helper()
+		token, err := ts.Token(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	if got := token(); got != "ya29.short" {
+		t.Errorf("first token = %q", got)
+	}
+	// The token is about to expire, so it is refreshed. A failed refresh is
+	// reported and not cached.
+	if _, err := ts.Token(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("err = %v, want the failed token request", err)
+	}
+	if got := token(); got != "ya29.long" {
+		t.Errorf("refreshed token = %q", got)
+	}
+	if got := token(); got != "ya29.long" || requests != 3 {
+		t.Errorf("token = %q after %d requests, want the cached token", got, requests)
+	}
+
+	// Once it expires, a new token is fetched.
+	ts.(*adcTokenSource).expiry = time.Now()
+	if got := token(); got != "ya29.next" || requests != 4 {
+		t.Errorf("token = %q after %d requests, want a new token", got, requests)
+	}
+}
@@ -0,0 +1,249 @@
+// Package deepseek provides a DeepSeek API client implementation.
+//
+// DeepSeek's chat completions API follows OpenAI's wire format, with the
+// reasoning of deepseek-reasoner in a separate reasoning_content field of
+// messages and stream deltas. The client returns it as thinking content and
+// thinking stream events.
+package deepseek
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	defaultBaseURL = "https://api.deepseek.com"
+)
+
+// Client is a DeepSeek API client.
+type Client struct {
+	config      *provider.Config
+	httpClient  *http.Client
+	baseURL     string
+	transformer *Transformer
+}
+
+// New creates a new DeepSeek client.
+func New(opts ...provider.Option) *Client {
+	cfg := provider.DefaultConfig()
+	provider.ApplyOptions(cfg, opts...)
+
+	baseURL := defaultBaseURL
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+
+	return &Client{
+		config:      cfg,
+		httpClient:  provider.NewHTTPClient(cfg),
+		baseURL:     baseURL,
+		transformer: NewTransformer(),
+	}
+}
+
+// Name returns the provider name.
+func (c *Client) Name() types.Provider {
+	return types.ProviderDeepSeek
+}
+
+// SupportsFeature checks if DeepSeek supports a feature. It has JSON mode
+// but no JSON schemas, and no image input.
+func (c *Client) SupportsFeature(feature types.Feature) bool {
+	switch feature {
+	case types.FeatureStreaming,
+		types.FeatureTools,
+		types.FeatureJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// Models returns available DeepSeek models.
+func (c *Client) Models() []string {
+	return []string{
+		"deepseek-chat",
+		"deepseek-reasoner",
+	}
+}
+
+// ListModels fetches the models available to the API key.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderDeepSeek)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list openai.ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderDeepSeek, "failed to decode response").WithCause(err)
+	}
+
+	models := make([]provider.ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		models[i] = provider.ModelInfo{
+			ID:       m.ID,
+			Provider: types.ProviderDeepSeek,
+			Metadata: map[string]any{"owned_by": m.OwnedBy},
+		}
+	}
+
+	return models, nil
+}
+
+// Complete sends a completion request.
+func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	dsReq := c.transformer.TransformRequest(req)
+	dsReq.Stream = false
+
+	body, err := json.Marshal(dsReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderDeepSeek)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var dsResp ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&dsResp); err != nil {
+		return nil, errors.ErrServerError(types.ProviderDeepSeek, "failed to decode response").WithCause(err)
+	}
+
+	result := c.transformer.TransformResponse(&dsResp)
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderDeepSeek, "response has no choices")
+	}
+	if result.StopReason == types.StopReasonContentFilter {
+		return nil, errors.ErrContentFilter(types.ProviderDeepSeek, dsResp.Choices[0].FinishReason, nil, result.Text())
+	}
+	result.ProviderMetadata.RequestID = provider.RequestID(resp.Header)
+	return result, nil
+}
+
+// Stream sends a streaming completion request.
+func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	dsReq := c.transformer.TransformRequest(req)
+	dsReq.Stream = true
+	dsReq.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+
+	body, err := json.Marshal(dsReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderDeepSeek)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	return openai.NewStreamReader(ctx, resp.Body, openai.StreamConfig{
+		Provider:       types.ProviderDeepSeek,
+		RequestID:      provider.RequestID(resp.Header),
+		ReasoningField: "reasoning_content",
+		StopReason:     c.transformer.TransformStopReason,
+		Chunk:          transformChunk,
+	}), nil
+}
+
+// setHeaders sets the required headers for DeepSeek API requests.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+}
+
+// handleErrorResponse converts an error response to a RouterError.
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	rerr := errors.ErrServerError(types.ProviderDeepSeek, string(body)).WithStatusCode(resp.StatusCode)
+	var errResp openai.ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		rerr = c.mapAPIError(errResp.Error, resp.StatusCode)
+	}
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
+}
+
+// mapAPIError maps DeepSeek API error to RouterError. DeepSeek answers 402
+// when the balance runs out and 503 when its servers are overloaded.
+func (c *Client) mapAPIError(apiErr *openai.APIError, statusCode int) *errors.RouterError {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return errors.ErrInvalidAPIKey(types.ProviderDeepSeek).WithStatusCode(statusCode)
+	case http.StatusPaymentRequired:
+		return errors.ErrQuotaExceeded(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusTooManyRequests:
+		return errors.ErrRateLimit(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusNotFound:
+		return errors.ErrModelNotFound(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusServiceUnavailable:
+		return errors.ErrOverloaded(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		if strings.Contains(apiErr.Message, "context length") {
+			return errors.ErrContextLength(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+		}
+		return errors.ErrInvalidRequest(apiErr.Message).WithProvider(types.ProviderDeepSeek).WithStatusCode(statusCode)
+	default:
+		return errors.ErrServerError(types.ProviderDeepSeek, apiErr.Message).WithStatusCode(statusCode)
+	}
+}
+
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// Ensure Client implements provider.Provider
+var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
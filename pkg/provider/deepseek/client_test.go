@@ -0,0 +1,117 @@
+package deepseek
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestComplete_Reasoning(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		fmt.Fprint(w, `{
+			"id": "abc", "model": "deepseek-reasoner",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {
+				"role": "assistant", "reasoning_content": "9.11 < 9.8", "content": "9.8 is larger"
+			}}],
+			"usage": {"prompt_tokens": 20, "completion_tokens": 10, "total_tokens": 30,
+				"prompt_cache_hit_tokens": 16, "prompt_cache_miss_tokens": 4,
+				"completion_tokens_details": {"reasoning_tokens": 6}}
+		}`)
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("sk-test"), provider.WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:     "deepseek-reasoner",
+		MaxTokens: types.Ptr(1024),
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Which is larger?"),
+			{Role: types.RoleAssistant, Content: []types.ContentBlock{
+				{Type: types.ContentTypeThinking, Text: "earlier reasoning"},
+				{Type: types.ContentTypeText, Text: "earlier answer"},
+			}},
+			types.NewTextMessage(types.RoleUser, "Are you sure?"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if sent["max_tokens"] != float64(1024) || sent["max_completion_tokens"] != nil {
+		t.Errorf("token limit sent as %v / %v, want max_tokens", sent["max_tokens"], sent["max_completion_tokens"])
+	}
+	if history := sent["messages"].([]any)[1].(map[string]any); history["content"] != "earlier answer" {
+		t.Errorf("assistant history sent as %v, want the answer without its reasoning", history)
+	}
+
+	if resp.Provider != types.ProviderDeepSeek || resp.Content[0].Type != types.ContentTypeThinking {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+	if resp.Thinking() != "9.11 < 9.8" || resp.Text() != "9.8 is larger" {
+		t.Errorf("thinking = %q, text = %q", resp.Thinking(), resp.Text())
+	}
+	if resp.Usage.CachedTokens != 16 || resp.Usage.ReasoningTokens != 6 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestStream_Reasoning(t *testing.T) {
+	chunks := []string{
+		`{"id":"abc","model":"deepseek-reasoner","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Think"}}]}`,
+		`{"id":"abc","choices":[{"index":0,"delta":{"reasoning_content":"ing."}}]}`,
+		`{"id":"abc","choices":[{"index":0,"delta":{"content":"Answer"},"finish_reason":"stop"}]}`,
+		`{"id":"abc","choices":[],"usage":{"prompt_tokens":5,"completion_tokens":7,"total_tokens":12,"prompt_cache_hit_tokens":0}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": keep-alive\n\n")
+		for _, c := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", c)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("sk-test"), provider.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), &types.CompletionRequest{
+		Model:    "deepseek-reasoner",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var thinking, text strings.Builder
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventThinkingDelta:
+			thinking.WriteString(event.Delta.Text)
+		case types.StreamEventContentDelta:
+			text.WriteString(event.Delta.Text)
+		}
+	}
+	if thinking.String() != "Thinking." || text.String() != "Answer" {
+		t.Errorf("thinking = %q, text = %q", thinking.String(), text.String())
+	}
+
+	resp := stream.Response()
+	if resp.Thinking() != "Thinking." || resp.Text() != "Answer" || resp.Usage.TotalTokens != 12 {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
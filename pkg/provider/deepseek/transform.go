@@ -0,0 +1,109 @@
+package deepseek
+
+import (
+	"encoding/json"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Transformer converts between unified types and DeepSeek's API format,
+// using the OpenAI transformer for the parts the formats share.
+type Transformer struct {
+	openai *openai.Transformer
+}
+
+// NewTransformer creates a new DeepSeek transformer.
+func NewTransformer() *Transformer {
+	return &Transformer{openai: openai.NewTransformer()}
+}
+
+// TransformRequest converts a unified request to DeepSeek format.
+func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatCompletionRequest {
+	// DeepSeek rejects reasoning_content in input messages, so the thinking
+	// of earlier turns is not sent back.
+	stripped := *req
+	stripped.Messages = provider.StripThinking(req.Messages)
+	oaiReq := t.openai.TransformRequest(&stripped)
+
+	dsReq := &ChatCompletionRequest{
+		Model:            oaiReq.Model,
+		Messages:         oaiReq.Messages,
+		MaxTokens:        oaiReq.MaxTokens,
+		Temperature:      oaiReq.Temperature,
+		TopP:             oaiReq.TopP,
+		Stop:             oaiReq.Stop,
+		PresencePenalty:  oaiReq.PresencePenalty,
+		FrequencyPenalty: oaiReq.FrequencyPenalty,
+		ResponseFormat:   oaiReq.ResponseFormat,
+		Tools:            oaiReq.Tools,
+		ToolChoice:       oaiReq.ToolChoice,
+	}
+
+	// DeepSeek has JSON mode but no schemas.
+	if dsReq.ResponseFormat != nil && dsReq.ResponseFormat.Type == "json_schema" {
+		dsReq.ResponseFormat = &openai.ResponseFormat{Type: "json_object"}
+	}
+
+	return dsReq
+}
+
+// TransformResponse converts a DeepSeek response to unified format. The
+// reasoning comes first, as a thinking block.
+func (t *Transformer) TransformResponse(resp *ChatCompletionResponse) *types.CompletionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	result := t.openai.TransformResponse(&resp.ChatCompletionResponse)
+	if result == nil {
+		return nil
+	}
+	result.Provider = types.ProviderDeepSeek
+	result.StopReason = t.TransformStopReason(resp.Choices[0].FinishReason)
+
+	if reasoning := resp.Choices[0].Message.ReasoningContent; reasoning != "" {
+		result.Content = append([]types.ContentBlock{{Type: types.ContentTypeThinking, Text: reasoning}}, result.Content...)
+	}
+	if resp.Usage != nil {
+		result.Usage = transformUsage(resp.Usage)
+	}
+
+	return result
+}
+
+// TransformStopReason converts a DeepSeek finish reason to unified format.
+// insufficient_system_resource means the output was cut short, as at the
+// token limit.
+func (t *Transformer) TransformStopReason(reason string) types.StopReason {
+	if reason == "insufficient_system_resource" {
+		return types.StopReasonMaxTokens
+	}
+	return t.openai.TransformStopReason(reason)
+}
+
+// transformUsage converts DeepSeek usage, where cache hits are the cached
+// input tokens.
+func transformUsage(u *Usage) types.Usage {
+	usage := types.Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+		CachedTokens: u.PromptCacheHitTokens,
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.ReasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+// transformChunk decodes the DeepSeek usage of a stream chunk.
+func transformChunk(data []byte) (*types.Usage, map[string]any) {
+	var chunk StreamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil || chunk.Usage == nil {
+		return nil, nil
+	}
+	usage := transformUsage(chunk.Usage)
+	return &usage, nil
+}
@@ -0,0 +1,43 @@
+package deepseek
+
+import "github.com/Chloe199719/agent-router/pkg/provider/openai"
+
+// ChatCompletionRequest is a DeepSeek chat completion request. Messages,
+// tools, and response formats use the OpenAI wire format; the output limit is
+// the older max_tokens parameter.
+type ChatCompletionRequest struct {
+	Model            string                 `json:"model"`
+	Messages         []openai.ChatMessage   `json:"messages"`
+	MaxTokens        *int                   `json:"max_tokens,omitempty"`
+	Temperature      *float64               `json:"temperature,omitempty"`
+	TopP             *float64               `json:"top_p,omitempty"`
+	Stream           bool                   `json:"stream,omitempty"`
+	StreamOptions    *openai.StreamOptions  `json:"stream_options,omitempty"`
+	Stop             []string               `json:"stop,omitempty"`
+	PresencePenalty  *float64               `json:"presence_penalty,omitempty"`
+	FrequencyPenalty *float64               `json:"frequency_penalty,omitempty"`
+	ResponseFormat   *openai.ResponseFormat `json:"response_format,omitempty"`
+	Tools            []openai.Tool          `json:"tools,omitempty"`
+	ToolChoice       any                    `json:"tool_choice,omitempty"`
+}
+
+// ChatCompletionResponse is a DeepSeek chat completion response. The
+// message's reasoning_content holds the reasoning of deepseek-reasoner.
+type ChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// StreamChunk is a streaming chunk. Deltas carry reasoning_content before
+// the answer's content.
+type StreamChunk struct {
+	openai.StreamChunk
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// Usage is token usage with DeepSeek's context cache counts.
+type Usage struct {
+	openai.Usage
+	PromptCacheHitTokens  int `json:"prompt_cache_hit_tokens,omitempty"`
+	PromptCacheMissTokens int `json:"prompt_cache_miss_tokens,omitempty"`
+}
@@ -0,0 +1,27 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Embedder is an optional interface for providers with an embeddings API.
+type Embedder interface {
+	// Embed embeds the request's inputs in a single API call. The inputs
+	// must be within the provider's EmbeddingLimits.
+	Embed(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error)
+
+	// EmbeddingLimits returns how much one Embed call may carry.
+	EmbeddingLimits() EmbeddingLimits
+}
+
+// EmbeddingLimits are the limits of a single embeddings API call. Zero
+// means no limit.
+type EmbeddingLimits struct {
+	// MaxInputs is the maximum number of inputs.
+	MaxInputs int
+
+	// MaxTokens is the maximum total number of input tokens.
+	MaxTokens int
+}
@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// FineTuneProvider is an optional interface for providers that support
+// fine-tuning (OpenAI fine-tuning jobs, Gemini tuned models).
+type FineTuneProvider interface {
+	Provider
+
+	// CreateFineTuneJob starts a fine-tuning job.
+	CreateFineTuneJob(ctx context.Context, req *FineTuneRequest) (*FineTuneJob, error)
+
+	// GetFineTuneJob retrieves the status of a fine-tuning job.
+	GetFineTuneJob(ctx context.Context, jobID string) (*FineTuneJob, error)
+
+	// ListFineTuneJobs lists fine-tuning jobs.
+	ListFineTuneJobs(ctx context.Context, opts *ListFineTuneOptions) ([]FineTuneJob, error)
+
+	// CancelFineTuneJob stops a running fine-tuning job.
+	CancelFineTuneJob(ctx context.Context, jobID string) error
+
+	// ListFineTuneCheckpoints lists the checkpoints recorded for a job.
+	ListFineTuneCheckpoints(ctx context.Context, jobID string) ([]FineTuneCheckpoint, error)
+}
+
+// TrainingFileUploader is an optional interface for fine-tuning providers that
+// take training data as an uploaded file.
+type TrainingFileUploader interface {
+	// UploadTrainingFile uploads examples as a training file and returns its ID.
+	UploadTrainingFile(ctx context.Context, examples []TrainingExample) (string, error)
+}
+
+// FineTuneRequest describes a fine-tuning job.
+type FineTuneRequest struct {
+	// Model is the base model to tune.
+	Model string `json:"model"`
+
+	// TrainingFile is the ID of an uploaded training file.
+	// Either TrainingFile or TrainingData must be set.
+	TrainingFile string `json:"training_file,omitempty"`
+
+	// TrainingData holds training examples. Providers that need a file
+	// upload it automatically; others send the examples inline.
+	TrainingData []TrainingExample `json:"training_data,omitempty"`
+
+	// ValidationFile is the ID of an uploaded validation file (OpenAI).
+	ValidationFile string `json:"validation_file,omitempty"`
+
+	// Suffix is appended to the tuned model's name (OpenAI) or used as its
+	// display name (Google).
+	Suffix string `json:"suffix,omitempty"`
+
+	// Epochs is the number of passes over the training data.
+	Epochs int `json:"epochs,omitempty"`
+
+	// BatchSize is the number of examples per training step.
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// LearningRate is the learning rate multiplier (OpenAI) or the learning
+	// rate (Google).
+	LearningRate float64 `json:"learning_rate,omitempty"`
+}
+
+// TrainingExample is a single conversation to train on. The final assistant
+// message is the expected output.
+type TrainingExample struct {
+	Messages []types.Message `json:"messages"`
+}
+
+// FineTuneJob represents a fine-tuning job.
+type FineTuneJob struct {
+	ID             string         `json:"id"`
+	Provider       types.Provider `json:"provider"`
+	Status         FineTuneStatus `json:"status"`
+	Model          string         `json:"model"`
+	FineTunedModel string         `json:"fine_tuned_model,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	FinishedAt     *time.Time     `json:"finished_at,omitempty"`
+	Error          string         `json:"error,omitempty"`
+	Metadata       map[string]any `json:"metadata,omitempty"`
+}
+
+// FineTuneStatus represents the status of a fine-tuning job.
+type FineTuneStatus string
+
+const (
+	FineTuneStatusPending   FineTuneStatus = "pending"
+	FineTuneStatusRunning   FineTuneStatus = "running"
+	FineTuneStatusSucceeded FineTuneStatus = "succeeded"
+	FineTuneStatusFailed    FineTuneStatus = "failed"
+	FineTuneStatusCancelled FineTuneStatus = "cancelled"
+)
+
+// IsDone returns true if the job is in a terminal state.
+func (s FineTuneStatus) IsDone() bool {
+	switch s {
+	case FineTuneStatusSucceeded, FineTuneStatusFailed, FineTuneStatusCancelled:
+		return true
+	default:
+		return false
+	}
+}
+
+// FineTuneCheckpoint is a snapshot recorded during training.
+type FineTuneCheckpoint struct {
+	ID string `json:"id,omitempty"`
+
+	// Step is the training step the checkpoint was taken at.
+	Step int `json:"step"`
+
+	// FineTunedModel is the model name to use the checkpoint, if it can be
+	// used directly.
+	FineTunedModel string `json:"fine_tuned_model,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+
+	// Metrics holds training metrics such as "train_loss".
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+}
+
+// ListFineTuneOptions configures fine-tuning job listing.
+type ListFineTuneOptions struct {
+	Limit int    `json:"limit,omitempty"`
+	After string `json:"after,omitempty"`
+}
@@ -6,7 +6,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -56,17 +59,19 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 		return nil, errors.ErrInvalidRequest("failed to marshal batch request").WithCause(err)
 	}
 
-	url := c.baseURL + "/models/" + model + ":batchGenerateContent?key=" + c.config.APIKey
+	url := c.baseURL + "/models/" + model + ":batchGenerateContent"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
 	if err != nil {
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -90,17 +95,19 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 		batchName = "batches/" + batchID
 	}
 
-	url := c.baseURL + "/" + batchName + "?key=" + c.config.APIKey
+	url := c.baseURL + "/" + batchName
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -118,6 +125,17 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 // GetBatchResults retrieves the results of a completed batch job.
 func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provider.BatchResult, error) {
+	seq, err := c.GetBatchResultsIter(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.CollectBatchResults(seq)
+}
+
+// GetBatchResultsIter streams the results of a completed batch job. Inline
+// responses are already in memory; file-based responses are decoded line by
+// line as they are downloaded.
+func (c *Client) GetBatchResultsIter(ctx context.Context, batchID string) (iter.Seq2[provider.BatchResult, error], error) {
 	job, err := c.GetBatch(ctx, batchID)
 	if err != nil {
 		return nil, err
@@ -134,17 +152,19 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 		batchName = "batches/" + batchID
 	}
 
-	url := c.baseURL + "/" + batchName + "?key=" + c.config.APIKey
+	url := c.baseURL + "/" + batchName
 	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -159,84 +179,94 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 
 	// Check for inline responses
 	if batchJob.Response != nil && batchJob.Response.InlinedResponses != nil && len(batchJob.Response.InlinedResponses.InlinedResponses) > 0 {
-		return c.convertInlinedResponses(batchJob.Response.InlinedResponses.InlinedResponses), nil
+		inlined := batchJob.Response.InlinedResponses.InlinedResponses
+		return func(yield func(provider.BatchResult, error) bool) {
+			for i := range inlined {
+				if !yield(c.convertInlinedResponse(&inlined[i]), nil) {
+					return
+				}
+			}
+		}, nil
 	}
 
 	// Check for file-based responses
 	if batchJob.Response != nil && batchJob.Response.ResponsesFile != "" {
-		return c.downloadBatchResults(ctx, batchJob.Response.ResponsesFile)
+		return c.streamBatchResults(ctx, batchJob.Response.ResponsesFile), nil
 	}
 
 	return nil, errors.ErrServerError(types.ProviderGoogle, "no results found in batch response")
 }
 
-// downloadBatchResults downloads and parses results from a file.
-func (c *Client) downloadBatchResults(ctx context.Context, fileName string) ([]provider.BatchResult, error) {
-	url := fmt.Sprintf("https://generativelanguage.googleapis.com/download/v1beta/%s:download?alt=media&key=%s", fileName, c.config.APIKey)
+// streamBatchResults downloads a results file and decodes it line by line.
+func (c *Client) streamBatchResults(ctx context.Context, fileName string) iter.Seq2[provider.BatchResult, error] {
+	return func(yield func(provider.BatchResult, error) bool) {
+		body, err := c.downloadBatchResults(ctx, fileName)
+		if err != nil {
+			yield(provider.BatchResult{}, err)
+			return
+		}
+		defer body.Close()
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create download request").WithCause(err)
+		for data, err := range provider.JSONLines(body) {
+			if err != nil {
+				yield(provider.BatchResult{}, errors.ErrServerError(types.ProviderGoogle, "failed to read response").WithCause(err))
+				return
+			}
+
+			var line InlinedResponse
+			if err := json.Unmarshal(data, &line); err != nil {
+				continue
+			}
+
+			if !yield(c.convertInlinedResponse(&line), nil) {
+				return
+			}
+		}
 	}
+}
+
+// downloadBatchResults opens a results file for download.
+func (c *Client) downloadBatchResults(ctx context.Context, fileName string) (io.ReadCloser, error) {
+	url := fmt.Sprintf("https://generativelanguage.googleapis.com/download/v1beta/%s:download?alt=media", fileName)
 
-	resp, err := c.httpClient.Do(httpReq)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "download failed").WithCause(err)
+		return nil, errors.ErrInvalidRequest("failed to create download request").WithCause(err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to read response").WithCause(err)
+		return nil, err
 	}
 
-	// Parse JSONL output
-	var results []provider.BatchResult
-	decoder := json.NewDecoder(bytes.NewReader(content))
-
-	for decoder.More() {
-		var line InlinedResponse
-		if err := decoder.Decode(&line); err != nil {
-			continue
-		}
-
-		result := provider.BatchResult{}
-		if line.Metadata != nil {
-			result.CustomID = line.Metadata.Key
-		}
-
-		if line.Error != nil {
-			result.Error = errors.ErrServerError(types.ProviderGoogle, line.Error.Message)
-		} else if line.Response != nil {
-			result.Response = c.transformer.TransformResponse(line.Response)
-		}
-
-		results = append(results, result)
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.handleErrorResponse(resp)
 	}
 
-	return results, nil
+	return resp.Body, nil
 }
 
-// convertInlinedResponses converts inline responses to provider batch results.
-func (c *Client) convertInlinedResponses(responses []InlinedResponse) []provider.BatchResult {
-	results := make([]provider.BatchResult, len(responses))
-	for i, resp := range responses {
-		results[i] = provider.BatchResult{}
-		if resp.Metadata != nil {
-			results[i].CustomID = resp.Metadata.Key
-		}
+// convertInlinedResponse converts an inline or file response to a provider batch result.
+func (c *Client) convertInlinedResponse(resp *InlinedResponse) provider.BatchResult {
+	result := provider.BatchResult{}
+	if resp.Metadata != nil {
+		result.CustomID = resp.Metadata.Key
+	}
 
-		if resp.Error != nil {
-			results[i].Error = errors.ErrServerError(types.ProviderGoogle, resp.Error.Message)
-		} else if resp.Response != nil {
-			results[i].Response = c.transformer.TransformResponse(resp.Response)
+	if resp.Error != nil {
+		result.Error = errors.ErrServerError(types.ProviderGoogle, resp.Error.Message)
+	} else if resp.Response != nil {
+		result.Response = c.transformer.TransformResponse(resp.Response)
+		if result.Response == nil {
+			result.Error = errors.ErrEmptyResponse(types.ProviderGoogle, "batch result has no candidates")
 		}
 	}
-	return results
+	return result
 }
 
 // CancelBatch cancels a batch job.
@@ -246,17 +276,19 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 		batchName = "batches/" + batchID
 	}
 
-	url := c.baseURL + "/" + batchName + ":cancel?key=" + c.config.APIKey
+	url := c.baseURL + "/" + batchName + ":cancel"
 	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, nil)
 	if err != nil {
 		return errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := c.setHeaders(httpReq); err != nil {
+		return err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -269,37 +301,49 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 // ListBatches lists all batch jobs.
 func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
-	url := c.baseURL + "/batches?key=" + c.config.APIKey
+	jobs, _, err := c.listBatchesPage(ctx, opts)
+	return jobs, err
+}
+
+// ListAllBatches lists every batch job, following page tokens.
+func (c *Client) ListAllBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
+	return provider.ListAllBatches(ctx, opts, c.listBatchesPage)
+}
 
+// listBatchesPage fetches one page of batch jobs and the next page token.
+func (c *Client) listBatchesPage(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, string, error) {
+	params := url.Values{}
 	if opts != nil {
 		if opts.Limit > 0 {
-			url += fmt.Sprintf("&pageSize=%d", opts.Limit)
+			params.Set("pageSize", strconv.Itoa(opts.Limit))
 		}
 		if opts.After != "" {
-			url += "&pageToken=" + opts.After
+			params.Set("pageToken", opts.After)
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/batches?"+params.Encode(), nil)
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		return nil, "", errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, "", err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp)
+		return nil, "", c.handleErrorResponse(resp)
 	}
 
 	var listResp BatchListResponse
 	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
+		return nil, "", errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
 	}
 
 	jobs := make([]provider.BatchJob, len(listResp.Batches))
@@ -307,7 +351,7 @@ func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOption
 		jobs[i] = *c.convertBatchJob(&batch, "")
 	}
 
-	return jobs, nil
+	return jobs, listResp.NextPageToken, nil
 }
 
 // convertBatchJob converts Google batch job to provider batch job.
@@ -380,3 +424,9 @@ func (c *Client) convertBatchStatus(batch *BatchJob) provider.BatchStatus {
 
 // Ensure Client implements provider.BatchProvider
 var _ provider.BatchProvider = (*Client)(nil)
+
+// Ensure Client implements provider.BatchResultsIterator
+var _ provider.BatchResultsIterator = (*Client)(nil)
+
+// Ensure Client implements provider.BatchLister
+var _ provider.BatchLister = (*Client)(nil)
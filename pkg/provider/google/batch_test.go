@@ -0,0 +1,124 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCreateBatch_StructuredToolsVision(t *testing.T) {
+	var got BatchGenerateContentRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/models/gemini-2.5-flash:batchGenerateContent" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Error(err)
+		}
+		fmt.Fprint(w, `{"name":"batches/b1","metadata":{"state":"BATCH_STATE_PENDING"}}`)
+	}))
+	defer server.Close()
+
+	structured := (&types.CompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []types.Message{{Role: types.RoleUser, Content: []types.ContentBlock{
+			{Type: types.ContentTypeText, Text: "Describe the image."},
+			{Type: types.ContentTypeImage, ImageBase64: "iVBORw0KGgo=", MediaType: "image/png"},
+		}}},
+	}).WithJSONSchema("caption", types.JSONSchema{
+		Type:       "object",
+		Properties: map[string]types.JSONSchema{"caption": {Type: "string"}},
+	})
+	tools := &types.CompletionRequest{
+		Model:      "gemini-2.5-flash",
+		Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "Look up cats.")},
+		Tools:      []types.Tool{{Name: "lookup", Parameters: types.JSONSchema{Type: "object"}}},
+		ToolChoice: &types.ToolChoice{Type: types.ToolChoiceRequired},
+	}
+
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	_, err := c.CreateBatch(context.Background(), []provider.BatchRequest{
+		{CustomID: "json", Request: structured},
+		{CustomID: "tool", Request: tools},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := got.Batch.InputConfig.Requests.Requests
+	if len(items) != 2 || items[0].Metadata.Key != "json" || items[1].Metadata.Key != "tool" {
+		t.Fatalf("items = %+v", items)
+	}
+	req := items[0].Request
+	if gc := req.GenerationConfig; gc == nil || gc.ResponseMimeType != "application/json" || gc.ResponseSchema == nil || gc.ResponseSchema.Type != "OBJECT" {
+		t.Errorf("generation config = %+v", req.GenerationConfig)
+	}
+	if parts := req.Contents[0].Parts; len(parts) != 2 || parts[1].InlineData == nil || parts[1].InlineData.MimeType != "image/png" {
+		t.Errorf("parts = %+v, want the image", parts)
+	}
+
+	req = items[1].Request
+	if len(req.Tools) != 1 || len(req.Tools[0].FunctionDeclarations) != 1 || req.Tools[0].FunctionDeclarations[0].Name != "lookup" {
+		t.Errorf("tools = %+v", req.Tools)
+	}
+	if tc := req.ToolConfig; tc == nil || tc.FunctionCallingConfig == nil || tc.FunctionCallingConfig.Mode != "ANY" {
+		t.Errorf("tool config = %+v", req.ToolConfig)
+	}
+}
+
+func TestGetBatchResults_StructuredAndToolCalls(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"name":"batches/b1","done":true,"response":{"inlinedResponses":{"inlinedResponses":[
+			{"metadata":{"key":"json"},"response":{"candidates":[{"content":{"role":"model","parts":[{"text":"{\"caption\":\"A cat\"}"}]},"finishReason":"STOP"}]}},
+			{"metadata":{"key":"tool"},"response":{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"lookup","args":{"q":"cat"}}}]},"finishReason":"STOP"}]}}
+		]}}}`)
+	}))
+	defer server.Close()
+
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	results, err := c.GetBatchResults(context.Background(), "b1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if results[0].CustomID != "json" || results[0].Response.Text() != `{"caption":"A cat"}` {
+		t.Errorf("json result = %s, %q", results[0].CustomID, results[0].Response.Text())
+	}
+	resp := results[1].Response
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	if input, _ := resp.ToolCalls[0].Input.(map[string]any); resp.ToolCalls[0].Name != "lookup" || input["q"] != "cat" {
+		t.Errorf("tool call = %+v", resp.ToolCalls[0])
+	}
+}
+
+type blockingTransport struct{}
+
+func (blockingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	<-req.Context().Done()
+	return nil, req.Context().Err()
+}
+
+func TestDownloadBatchResults_Timeout(t *testing.T) {
+	c := New(provider.WithAPIKey("key"), provider.WithHTTPClient(&http.Client{Transport: blockingTransport{}}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.downloadBatchResults(ctx, "files/batch-output")
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeTimeout, "")) {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strings"
 	"time"
 
@@ -37,16 +39,9 @@ func New(opts ...provider.Option) *Client {
 		baseURL = cfg.BaseURL
 	}
 
-	httpClient := cfg.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: time.Duration(cfg.Timeout) * time.Second,
-		}
-	}
-
 	return &Client{
 		config:      cfg,
-		httpClient:  httpClient,
+		httpClient:  provider.NewHTTPClient(cfg),
 		baseURL:     baseURL,
 		transformer: NewTransformer(),
 	}
@@ -64,7 +59,9 @@ func (c *Client) SupportsFeature(feature types.Feature) bool {
 		types.FeatureStructuredOutput,
 		types.FeatureTools,
 		types.FeatureVision,
-		types.FeatureJSON:
+		types.FeatureJSON,
+		types.FeatureSeed,
+		types.FeatureCandidates:
 		return true
 	case types.FeatureBatch:
 		return true // Via Vertex AI
@@ -85,6 +82,71 @@ func (c *Client) Models() []string {
 	}
 }
 
+// ListModels fetches the models available to the API key, following
+// pagination. Only models that support generateContent are returned.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	var models []provider.ModelInfo
+	pageToken := ""
+	for {
+		params := url.Values{}
+		params.Set("pageSize", "1000")
+		if pageToken != "" {
+			params.Set("pageToken", pageToken)
+		}
+
+		httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models?"+params.Encode(), nil)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		}
+
+		if err := c.setHeaders(httpReq); err != nil {
+			return nil, err
+		}
+
+		resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			err := c.handleErrorResponse(resp)
+			resp.Body.Close()
+			return nil, err
+		}
+
+		var list ModelList
+		err = json.NewDecoder(resp.Body).Decode(&list)
+		resp.Body.Close()
+		if err != nil {
+			return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
+		}
+
+		for _, m := range list.Models {
+			if !slices.Contains(m.SupportedGenerationMethods, "generateContent") {
+				continue
+			}
+			models = append(models, provider.ModelInfo{
+				ID:              strings.TrimPrefix(m.Name, "models/"),
+				Provider:        types.ProviderGoogle,
+				DisplayName:     m.DisplayName,
+				Description:     m.Description,
+				ContextWindow:   m.InputTokenLimit,
+				MaxOutputTokens: m.OutputTokenLimit,
+				Deprecated:      strings.Contains(strings.ToLower(m.Description), "deprecated"),
+				Metadata: map[string]any{
+					"version":                      m.Version,
+					"supported_generation_methods": m.SupportedGenerationMethods,
+				},
+			})
+		}
+
+		if list.NextPageToken == "" {
+			return models, nil
+		}
+		pageToken = list.NextPageToken
+	}
+}
+
 // Complete sends a completion request.
 func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
 	gReq := c.transformer.TransformRequest(req)
@@ -100,11 +162,13 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -117,9 +181,20 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
 	}
 
+	if err := c.transformer.BlockedError(types.ProviderGoogle, gResp.PromptFeedback); err != nil && len(gResp.Candidates) == 0 {
+		return nil, err
+	}
+
 	result := c.transformer.TransformResponse(&gResp)
-	if result != nil {
-		result.Model = req.Model
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderGoogle, "response has no candidates")
+	}
+	if err := c.transformer.FilterError(types.ProviderGoogle, &gResp, result); err != nil {
+		return nil, err
+	}
+	result.Model = req.Model
+	for i := range result.Alternatives {
+		result.Alternatives[i].Model = req.Model
 	}
 	return result, nil
 }
@@ -139,11 +214,13 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderGoogle)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderGoogle, "request failed").WithCause(err)
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -151,7 +228,7 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	return newStreamReader(resp.Body, c.transformer, req.Model), nil
+	return newStreamReader(ctx, resp.Body, c.transformer, req.Model), nil
 }
 
 // buildURL builds the API URL for a given model and streaming flag.
@@ -160,31 +237,62 @@ func (c *Client) buildURL(model string, stream bool) string {
 	if stream {
 		action = "streamGenerateContent"
 	}
-	return c.baseURL + "/models/" + model + ":" + action + "?key=" + c.config.APIKey
+	return c.baseURL + "/models/" + model + ":" + action
 }
 
-// setHeaders sets the required headers for Google API requests.
-func (c *Client) setHeaders(req *http.Request) {
+// setHeaders sets the content type and credentials of Google API requests.
+// An access token or token source is sent as an OAuth2 Bearer token;
+// otherwise the API key goes in the x-goog-api-key header, or in the URL
+// with provider.WithAPIKeyInQuery.
+func (c *Client) setHeaders(req *http.Request) error {
 	req.Header.Set("Content-Type", "application/json")
+
+	token := c.config.AccessToken
+	if token == "" && c.config.TokenSource != nil {
+		t, err := c.config.TokenSource.Token(req.Context())
+		if err != nil {
+			return errors.ErrAuthentication(types.ProviderGoogle, "failed to get access token").WithCause(err)
+		}
+		token = t
+	}
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case c.config.APIKey == "":
+	case c.config.APIKeyInQuery:
+		query := req.URL.Query()
+		query.Set("key", c.config.APIKey)
+		req.URL.RawQuery = query.Encode()
+	default:
+		req.Header.Set("x-goog-api-key", c.config.APIKey)
+	}
+	return nil
 }
 
 // handleErrorResponse converts an error response to a RouterError.
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
+	rerr := errors.ErrServerError(types.ProviderGoogle, string(body)).WithStatusCode(resp.StatusCode)
 	var errResp ErrorResponse
 	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-		return c.mapAPIError(errResp.Error, resp.StatusCode)
+		rerr = c.mapAPIError(errResp.Error, resp.StatusCode)
 	}
-
-	return errors.ErrServerError(types.ProviderGoogle, string(body)).WithStatusCode(resp.StatusCode)
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
 }
 
 // mapAPIError maps Google API error to RouterError.
-func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
+func (c *Client) mapAPIError(apiErr *APIError, statusCode int) *errors.RouterError {
+	if rerr := c.transformer.StatusError(types.ProviderGoogle, apiErr, statusCode); rerr != nil {
+		return rerr
+	}
+
 	switch statusCode {
 	case http.StatusUnauthorized:
 		return errors.ErrInvalidAPIKey(types.ProviderGoogle).WithStatusCode(statusCode)
+	case http.StatusForbidden:
+		return errors.ErrAuthentication(types.ProviderGoogle, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusTooManyRequests:
 		return errors.ErrRateLimit(types.ProviderGoogle, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusNotFound:
@@ -202,13 +310,16 @@ func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
 // streamReader implements types.StreamReader for Google.
 type streamReader struct {
 	decoder      *json.Decoder
-	body         io.ReadCloser
+	body         *provider.StreamBody
 	transformer  *Transformer
 	model        string
 	response     *types.CompletionResponse
 	done         bool
 	arrayStarted bool
 
+	// pending holds events of the last chunk not yet returned by Next.
+	pending []*types.StreamEvent
+
 	// Accumulated state
 	content    []types.ContentBlock
 	thoughtBuf []types.ContentBlock // Gemini thinking parts (thought: true); merged if no visible text
@@ -216,12 +327,17 @@ type streamReader struct {
 	usage      *types.Usage
 	stopReason types.StopReason
 	started    bool
+	safety     []SafetyRating
+	id         string
+	meta       types.ProviderMetadata
+	feedback   *PromptFeedback
 }
 
-func newStreamReader(body io.ReadCloser, transformer *Transformer, model string) *streamReader {
+func newStreamReader(ctx context.Context, body io.ReadCloser, transformer *Transformer, model string) *streamReader {
+	streamBody := provider.NewStreamBody(ctx, body)
 	return &streamReader{
-		decoder:     json.NewDecoder(body),
-		body:        body,
+		decoder:     json.NewDecoder(streamBody),
+		body:        streamBody,
 		transformer: transformer,
 		model:       model,
 	}
@@ -229,9 +345,15 @@ func newStreamReader(body io.ReadCloser, transformer *Transformer, model string)
 
 // Next returns the next stream event.
 func (s *streamReader) Next() (*types.StreamEvent, error) {
+	if len(s.pending) > 0 {
+		return s.pop(), nil
+	}
 	if s.done {
 		return nil, nil
 	}
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
 
 	// Send start event first
 	if !s.started {
@@ -272,12 +394,17 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 			continue
 		}
 
-		event := s.processChunk(&chunk)
-		if event != nil {
-			return event, nil
+		s.processChunk(&chunk)
+		if len(s.pending) > 0 {
+			return s.pop(), nil
 		}
 	}
 
+	// Stop on cancellation rather than reporting a truncated stream as finished
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
+
 	// Array finished
 	s.done = true
 	s.buildResponse()
@@ -288,13 +415,45 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 	}, nil
 }
 
-// processChunk processes a stream chunk and returns an event if applicable.
-func (s *streamReader) processChunk(chunk *StreamChunk) *types.StreamEvent {
-	if len(chunk.Candidates) == 0 {
+// emit queues an event for Next.
+func (s *streamReader) emit(event *types.StreamEvent) {
+	s.pending = append(s.pending, event)
+}
+
+// pop removes and returns the first pending event, or nil if there is none.
+func (s *streamReader) pop() *types.StreamEvent {
+	if len(s.pending) == 0 {
 		return nil
 	}
+	event := s.pending[0]
+	s.pending = s.pending[1:]
+	return event
+}
+
+// processChunk queues the events of a stream chunk.
+func (s *streamReader) processChunk(chunk *StreamChunk) {
+	if chunk.ResponseID != "" {
+		s.id = chunk.ResponseID
+	}
+	if chunk.ModelVersion != "" {
+		s.meta.ModelVersion = chunk.ModelVersion
+	}
+	if chunk.PromptFeedback != nil {
+		s.feedback = chunk.PromptFeedback
+	}
+	if len(chunk.Candidates) == 0 {
+		if err := s.transformer.BlockedError(types.ProviderGoogle, chunk.PromptFeedback); err != nil {
+			s.done = true
+			s.buildResponse()
+			s.emit(&types.StreamEvent{Type: types.StreamEventError, Error: err})
+		}
+		return
+	}
 
 	candidate := chunk.Candidates[0]
+	if len(candidate.SafetyRatings) > 0 {
+		s.safety = candidate.SafetyRatings
+	}
 
 	// Handle finish reason
 	if candidate.FinishReason != "" {
@@ -311,7 +470,7 @@ func (s *streamReader) processChunk(chunk *StreamChunk) *types.StreamEvent {
 	}
 
 	if candidate.Content == nil {
-		return nil
+		return
 	}
 
 	// Process parts
@@ -319,7 +478,14 @@ func (s *streamReader) processChunk(chunk *StreamChunk) *types.StreamEvent {
 		if part.Text != "" {
 			if part.Thought {
 				s.appendThoughtText(part.Text)
-				return nil
+				s.emit(&types.StreamEvent{
+					Type: types.StreamEventThinkingDelta,
+					Delta: &types.ContentBlock{
+						Type: types.ContentTypeText,
+						Text: part.Text,
+					},
+				})
+				continue
 			}
 			s.thoughtBuf = nil
 			// Accumulate visible text
@@ -332,35 +498,47 @@ func (s *streamReader) processChunk(chunk *StreamChunk) *types.StreamEvent {
 				s.content[len(s.content)-1].Text += part.Text
 			}
 
-			return &types.StreamEvent{
+			s.emit(&types.StreamEvent{
 				Type: types.StreamEventContentDelta,
 				Delta: &types.ContentBlock{
 					Type: types.ContentTypeText,
 					Text: part.Text,
 				},
-			}
+			})
+			continue
 		}
 
 		if part.FunctionCall != nil {
 			tc := types.ToolCall{
+				ID:    ToolCallID(part.FunctionCall, len(s.toolCalls)),
 				Name:  part.FunctionCall.Name,
 				Input: part.FunctionCall.Args,
 			}
 			s.toolCalls = append(s.toolCalls, tc)
 			s.content = append(s.content, types.ContentBlock{
 				Type:      types.ContentTypeToolUse,
+				ToolUseID: tc.ID,
 				ToolName:  part.FunctionCall.Name,
 				ToolInput: part.FunctionCall.Args,
 			})
 
-			return &types.StreamEvent{
+			// Calls arrive whole, so each ends as soon as it starts. The
+			// start carries the input too, for consumers that only read
+			// starts.
+			index := len(s.toolCalls) - 1
+			start, end := tc, tc
+			s.emit(&types.StreamEvent{
 				Type:     types.StreamEventToolCallStart,
-				ToolCall: &tc,
-			}
+				ToolCall: &start,
+				Index:    index,
+			})
+			s.emit(&types.StreamEvent{
+				Type:     types.StreamEventToolCallEnd,
+				ToolCall: &end,
+				Index:    index,
+			})
 		}
 	}
-
-	return nil
 }
 
 func (s *streamReader) appendThoughtText(text string) {
@@ -385,20 +563,23 @@ func (s *streamReader) buildResponse() {
 	}
 
 	s.response = &types.CompletionResponse{
-		Provider:   types.ProviderGoogle,
-		Model:      s.model,
-		Content:    content,
-		StopReason: s.stopReason,
-		ToolCalls:  s.toolCalls,
-		CreatedAt:  time.Now(),
+		ID:               s.id,
+		Provider:         types.ProviderGoogle,
+		Model:            s.model,
+		Content:          content,
+		StopReason:       s.stopReason,
+		ToolCalls:        s.toolCalls,
+		CreatedAt:        time.Now(),
+		ProviderMetadata: s.meta,
 	}
 
 	if s.usage != nil {
 		s.response.Usage = *s.usage
 	}
+	s.response.Metadata = s.transformer.SafetyMetadata(s.safety, s.feedback)
 }
 
-// Close closes the stream.
+// Close closes the stream. It is idempotent and safe to call while Next is blocked.
 func (s *streamReader) Close() error {
 	return s.body.Close()
 }
@@ -408,5 +589,16 @@ func (s *streamReader) Response() *types.CompletionResponse {
 	return s.response
 }
 
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
 // Ensure Client implements provider.Provider
 var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
@@ -0,0 +1,286 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+
+func TestComplete_ContentFilter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(GenerateContentResponse{
+			Candidates: []Candidate{{
+				Content:      &Content{Role: "model", Parts: []Part{{Text: "Here is how"}}},
+				FinishReason: "SAFETY",
+				SafetyRatings: []SafetyRating{
+					{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Probability: "HIGH", Blocked: true},
+					{Category: "HARM_CATEGORY_HARASSMENT", Probability: "NEGLIGIBLE"},
+				},
+			}},
+		})
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeContentFilter {
+		t.Fatalf("expected content_filter error, got resp %v, err %v", resp, err)
+	}
+	categories, _ := rerr.Details["categories"].([]string)
+	if rerr.Details["reason"] != "SAFETY" || !slices.Equal(categories, []string{"HARM_CATEGORY_DANGEROUS_CONTENT"}) || rerr.Details["partial_text"] != "Here is how" {
+		t.Errorf("unexpected details: %v", rerr.Details)
+	}
+}
+
+func TestComplete_Credentials(t *testing.T) {
+	tests := []struct {
+		name      string
+		opts      []provider.Option
+		wantKey   string
+		wantAuth  string
+		wantQuery string
+	}{
+		{"header by default", []provider.Option{provider.WithAPIKey("secret")}, "secret", "", ""},
+		{"query opt-in", []provider.Option{provider.WithAPIKey("secret"), provider.WithAPIKeyInQuery()}, "", "", "key=secret"},
+		{"bearer token", []provider.Option{provider.WithAPIKey("secret"), provider.WithTokenSource(staticToken("ya29.token"))}, "", "Bearer ya29.token", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key, auth, query string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				key, auth, query = r.Header.Get("x-goog-api-key"), r.Header.Get("Authorization"), r.URL.RawQuery
+				json.NewEncoder(w).Encode(GenerateContentResponse{
+					Candidates: []Candidate{{Content: &Content{Role: "model", Parts: []Part{{Text: "Hi"}}}, FinishReason: "STOP"}},
+				})
+			}))
+			defer server.Close()
+
+			client := New(append(tt.opts, provider.WithBaseURL(server.URL))...)
+			_, err := client.Complete(context.Background(), &types.CompletionRequest{
+				Model:    "gemini-2.0-flash",
+				Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if key != tt.wantKey || auth != tt.wantAuth || query != tt.wantQuery {
+				t.Errorf("x-goog-api-key = %q, Authorization = %q, query = %q", key, auth, query)
+			}
+		})
+	}
+}
+
+func TestStreamReader_Thoughts(t *testing.T) {
+	chunks := `[{"candidates":[{"content":{"role":"model","parts":[{"text":"Adding.","thought":true}]}}]},
+{"candidates":[{"content":{"role":"model","parts":[{"text":"4"}]},"finishReason":"STOP"}]}]`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(chunks)), NewTransformer(), "gemini-2.5-flash")
+	defer stream.Close()
+
+	var events []types.StreamEventType
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		events = append(events, event.Type)
+	}
+
+	want := []types.StreamEventType{types.StreamEventStart, types.StreamEventThinkingDelta, types.StreamEventContentDelta, types.StreamEventDone}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+	if got := stream.Response().Text(); got != "4" {
+		t.Errorf("text = %q, want the answer without thoughts", got)
+	}
+}
+
+func TestStreamReader_ParallelFunctionCalls(t *testing.T) {
+	chunks := `[{"candidates":[{"content":{"role":"model","parts":[{"functionCall":{"name":"get_weather","args":{"city":"Paris"}}},{"functionCall":{"name":"get_time","args":{}}}]},"finishReason":"STOP"}]}]`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(chunks)), NewTransformer(), "gemini-2.5-flash")
+	defer stream.Close()
+
+	var events []types.StreamEventType
+	var ends []*types.ToolCall
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		events = append(events, event.Type)
+		if event.Type == types.StreamEventToolCallEnd {
+			if event.Index != len(ends) {
+				t.Errorf("end index = %d, want %d", event.Index, len(ends))
+			}
+			ends = append(ends, event.ToolCall)
+		}
+	}
+
+	want := []types.StreamEventType{
+		types.StreamEventStart,
+		types.StreamEventToolCallStart, types.StreamEventToolCallEnd,
+		types.StreamEventToolCallStart, types.StreamEventToolCallEnd,
+		types.StreamEventDone,
+	}
+	if !slices.Equal(events, want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	if input, _ := ends[0].Input.(map[string]any); ends[0].Name != "get_weather" || input["city"] != "Paris" {
+		t.Errorf("first call = %+v, want get_weather with its input", ends[0])
+	}
+	if ends[1].Name != "get_time" {
+		t.Errorf("second call = %+v, want get_time", ends[1])
+	}
+	if got := len(stream.Response().ToolCalls); got != 2 {
+		t.Errorf("response has %d tool calls, want 2", got)
+	}
+}
+
+func TestHandleErrorResponse_Status(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		code       string
+		retryAfter time.Duration
+	}{
+		{
+			"resource exhausted", 429,
+			`{"error":{"code":429,"message":"Resource has been exhausted","status":"RESOURCE_EXHAUSTED","details":[{"@type":"type.googleapis.com/google.rpc.RetryInfo","retryDelay":"33s"}]}}`,
+			errors.ErrCodeRateLimit, 33 * time.Second,
+		},
+		{
+			"daily quota", 429,
+			`{"error":{"code":429,"message":"You exceeded your current quota","status":"RESOURCE_EXHAUSTED","details":[{"@type":"type.googleapis.com/google.rpc.QuotaFailure","violations":[{"quotaId":"GenerateRequestsPerDayPerProjectPerModel-FreeTier"}]}]}}`,
+			errors.ErrCodeQuotaExceeded, 0,
+		},
+		{
+			"permission denied", 403,
+			`{"error":{"code":403,"message":"Permission denied on resource project","status":"PERMISSION_DENIED"}}`,
+			errors.ErrCodeAuthentication, 0,
+		},
+		{
+			"overloaded", 503,
+			`{"error":{"code":503,"message":"The model is overloaded. Please try again later.","status":"UNAVAILABLE"}}`,
+			errors.ErrCodeOverloaded, 0,
+		},
+		{
+			"invalid key", 400,
+			`{"error":{"code":400,"message":"API key not valid. Please pass a valid API key.","status":"INVALID_ARGUMENT","details":[{"@type":"type.googleapis.com/google.rpc.ErrorInfo","reason":"API_KEY_INVALID"}]}}`,
+			errors.ErrCodeInvalidAPIKey, 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := New(provider.WithAPIKey("key")).handleErrorResponse(&http.Response{
+				StatusCode: tt.status,
+				Header:     http.Header{},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			})
+
+			var rerr *errors.RouterError
+			if !stderrors.As(err, &rerr) || rerr.Code != tt.code || rerr.StatusCode != tt.status {
+				t.Fatalf("err = %#v, want code %s", err, tt.code)
+			}
+			if got := errors.RetryAfter(err); got != tt.retryAfter {
+				t.Errorf("retry after = %v, want %v", got, tt.retryAfter)
+			}
+		})
+	}
+}
+
+func TestComplete_TransportErrorScrubsKey(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client := New(provider.WithAPIKey("secret-key"), provider.WithAPIKeyInQuery(), provider.WithBaseURL(server.URL))
+	_, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+	if err == nil {
+		t.Fatal("expected a transport error")
+	}
+	if strings.Contains(err.Error(), "secret-key") || !strings.Contains(err.Error(), "key=[REDACTED]") {
+		t.Errorf("error = %q, want the key redacted", err)
+	}
+}
+
+func TestStream_StructuredOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, ":streamGenerateContent") {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		var req GenerateContentRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if c := req.GenerationConfig; c == nil || c.ResponseMimeType != "application/json" || c.ResponseSchema == nil {
+			t.Errorf("generation config = %+v, want the response schema", c)
+		}
+		var chunks []GenerateContentResponse
+		for _, text := range []string{`{"name":`, `"Ann","age"`, `:42}`} {
+			chunks = append(chunks, GenerateContentResponse{
+				Candidates: []Candidate{{Content: &Content{Role: "model", Parts: []Part{{Text: text}}}}},
+			})
+		}
+		chunks[len(chunks)-1].Candidates[0].FinishReason = "STOP"
+		json.NewEncoder(w).Encode(chunks)
+	}))
+	defer server.Close()
+
+	person := types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"name": {Type: "string"},
+			"age":  {Type: "integer"},
+		},
+		Required: []string{"name", "age"},
+	}
+	req := (&types.CompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Extract: Ann is 42.")},
+	}).WithJSONSchema("person", person)
+
+	client := New(provider.WithAPIKey("g-key"), provider.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for event, err := stream.Next(); event != nil || err != nil; event, err = stream.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text := stream.Response().Text()
+	if err := schema.Validate(&person, []byte(text)); err != nil {
+		t.Fatalf("accumulated text %q does not validate: %v", text, err)
+	}
+}
@@ -0,0 +1,376 @@
+package google
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Tuned model types
+
+// TunedModel is a Gemini tuned model.
+type TunedModel struct {
+	Name        string      `json:"name,omitempty"`
+	DisplayName string      `json:"displayName,omitempty"`
+	BaseModel   string      `json:"baseModel,omitempty"`
+	State       string      `json:"state,omitempty"`
+	CreateTime  string      `json:"createTime,omitempty"`
+	UpdateTime  string      `json:"updateTime,omitempty"`
+	TuningTask  *TuningTask `json:"tuningTask,omitempty"`
+}
+
+// TuningTask describes the training of a tuned model.
+type TuningTask struct {
+	StartTime       string                 `json:"startTime,omitempty"`
+	CompleteTime    string                 `json:"completeTime,omitempty"`
+	Snapshots       []TuningSnapshot       `json:"snapshots,omitempty"`
+	TrainingData    *TuningDataset         `json:"trainingData,omitempty"`
+	Hyperparameters *TuningHyperparameters `json:"hyperparameters,omitempty"`
+}
+
+// TuningSnapshot records the loss at a training step.
+type TuningSnapshot struct {
+	Step        int     `json:"step"`
+	Epoch       int     `json:"epoch"`
+	MeanLoss    float64 `json:"meanLoss"`
+	ComputeTime string  `json:"computeTime"`
+}
+
+// TuningDataset holds inline training examples.
+type TuningDataset struct {
+	Examples *TuningExamples `json:"examples,omitempty"`
+}
+
+// TuningExamples is a list of training examples.
+type TuningExamples struct {
+	Examples []TuningExample `json:"examples"`
+}
+
+// TuningExample is a single input/output pair.
+type TuningExample struct {
+	TextInput string `json:"textInput"`
+	Output    string `json:"output"`
+}
+
+// TuningHyperparameters are the tunable hyperparameters.
+type TuningHyperparameters struct {
+	EpochCount   int     `json:"epochCount,omitempty"`
+	BatchSize    int     `json:"batchSize,omitempty"`
+	LearningRate float64 `json:"learningRate,omitempty"`
+}
+
+// TunedModelOperation is the long-running operation returned when creating a tuned model.
+type TunedModelOperation struct {
+	Name     string `json:"name"`
+	Metadata struct {
+		TunedModel string `json:"tunedModel"`
+		TotalSteps int    `json:"totalSteps"`
+	} `json:"metadata"`
+}
+
+// TunedModelList is the response from listing tuned models.
+type TunedModelList struct {
+	TunedModels   []TunedModel `json:"tunedModels,omitempty"`
+	NextPageToken string       `json:"nextPageToken,omitempty"`
+}
+
+// CreateFineTuneJob creates a tuned model. Gemini only accepts inline
+// text examples, so TrainingData is required and TrainingFile is not supported.
+// The job ID is the tuned model name ("tunedModels/...").
+func (c *Client) CreateFineTuneJob(ctx context.Context, req *provider.FineTuneRequest) (*provider.FineTuneJob, error) {
+	if len(req.TrainingData) == 0 {
+		return nil, errors.ErrInvalidRequest("training data is required; training files are not supported").WithProvider(types.ProviderGoogle)
+	}
+
+	examples := make([]TuningExample, 0, len(req.TrainingData))
+	for _, ex := range req.TrainingData {
+		examples = append(examples, convertTrainingExample(ex))
+	}
+
+	baseModel := req.Model
+	if !strings.HasPrefix(baseModel, "models/") {
+		baseModel = "models/" + baseModel
+	}
+
+	tuned := TunedModel{
+		DisplayName: req.Suffix,
+		BaseModel:   baseModel,
+		TuningTask: &TuningTask{
+			TrainingData: &TuningDataset{Examples: &TuningExamples{Examples: examples}},
+		},
+	}
+	if req.Epochs > 0 || req.BatchSize > 0 || req.LearningRate > 0 {
+		tuned.TuningTask.Hyperparameters = &TuningHyperparameters{
+			EpochCount:   req.Epochs,
+			BatchSize:    req.BatchSize,
+			LearningRate: req.LearningRate,
+		}
+	}
+
+	body, err := json.Marshal(tuned)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/tunedModels", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var op TunedModelOperation
+	if err := json.NewDecoder(resp.Body).Decode(&op); err != nil {
+		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
+	}
+
+	return &provider.FineTuneJob{
+		ID:        op.Metadata.TunedModel,
+		Provider:  types.ProviderGoogle,
+		Status:    provider.FineTuneStatusPending,
+		Model:     baseModel,
+		CreatedAt: time.Now(),
+		Metadata: map[string]any{
+			"operation":   op.Name,
+			"total_steps": op.Metadata.TotalSteps,
+		},
+	}, nil
+}
+
+// convertTrainingExample flattens a conversation into a text input and the
+// final assistant reply.
+func convertTrainingExample(ex provider.TrainingExample) TuningExample {
+	var input []string
+	var output string
+	for i, msg := range ex.Messages {
+		var text strings.Builder
+		for _, block := range msg.Content {
+			if block.Type == types.ContentTypeText {
+				text.WriteString(block.Text)
+			}
+		}
+		if i == len(ex.Messages)-1 && msg.Role == types.RoleAssistant {
+			output = text.String()
+		} else if text.Len() > 0 {
+			input = append(input, text.String())
+		}
+	}
+	return TuningExample{TextInput: strings.Join(input, "\n"), Output: output}
+}
+
+// GetFineTuneJob retrieves a tuned model.
+func (c *Client) GetFineTuneJob(ctx context.Context, jobID string) (*provider.FineTuneJob, error) {
+	tuned, err := c.getTunedModel(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	return c.convertTunedModel(tuned), nil
+}
+
+// getTunedModel fetches a tuned model by name.
+func (c *Client) getTunedModel(ctx context.Context, name string) (*TunedModel, error) {
+	if !strings.HasPrefix(name, "tunedModels/") {
+		name = "tunedModels/" + name
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/"+name, nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var tuned TunedModel
+	if err := json.NewDecoder(resp.Body).Decode(&tuned); err != nil {
+		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
+	}
+
+	return &tuned, nil
+}
+
+// ListFineTuneJobs lists tuned models. opts.After is a page token.
+func (c *Client) ListFineTuneJobs(ctx context.Context, opts *provider.ListFineTuneOptions) ([]provider.FineTuneJob, error) {
+	params := url.Values{}
+	if opts != nil {
+		if opts.Limit > 0 {
+			params.Set("pageSize", strconv.Itoa(opts.Limit))
+		}
+		if opts.After != "" {
+			params.Set("pageToken", opts.After)
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/tunedModels?"+params.Encode(), nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list TunedModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderGoogle, "failed to decode response").WithCause(err)
+	}
+
+	jobs := make([]provider.FineTuneJob, len(list.TunedModels))
+	for i, tuned := range list.TunedModels {
+		jobs[i] = *c.convertTunedModel(&tuned)
+	}
+
+	return jobs, nil
+}
+
+// CancelFineTuneJob stops tuning by deleting the tuned model; the Gemini API
+// has no separate cancel operation. Only models still being tuned can be
+// cancelled, so a finished model is never deleted.
+func (c *Client) CancelFineTuneJob(ctx context.Context, jobID string) error {
+	tuned, err := c.getTunedModel(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	if tuned.State != "CREATING" {
+		return errors.ErrInvalidRequest(fmt.Sprintf("tuned model %s is %s; only models still being tuned can be cancelled", tuned.Name, tuned.State)).WithProvider(types.ProviderGoogle)
+	}
+
+	name := jobID
+	if !strings.HasPrefix(name, "tunedModels/") {
+		name = "tunedModels/" + name
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "DELETE", c.baseURL+"/"+name, nil)
+	if err != nil {
+		return errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	if err := c.setHeaders(httpReq); err != nil {
+		return err
+	}
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderGoogle)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return c.handleErrorResponse(resp)
+	}
+
+	return nil
+}
+
+// ListFineTuneCheckpoints returns the training snapshots of a tuned model.
+// Snapshots record loss only and cannot be used as models.
+func (c *Client) ListFineTuneCheckpoints(ctx context.Context, jobID string) ([]provider.FineTuneCheckpoint, error) {
+	tuned, err := c.getTunedModel(ctx, jobID)
+	if err != nil {
+		return nil, err
+	}
+	if tuned.TuningTask == nil {
+		return nil, nil
+	}
+
+	checkpoints := make([]provider.FineTuneCheckpoint, len(tuned.TuningTask.Snapshots))
+	for i, snap := range tuned.TuningTask.Snapshots {
+		checkpoints[i] = provider.FineTuneCheckpoint{
+			Step: snap.Step,
+			Metrics: map[string]float64{
+				"epoch":     float64(snap.Epoch),
+				"mean_loss": snap.MeanLoss,
+			},
+		}
+		if t, err := time.Parse(time.RFC3339, snap.ComputeTime); err == nil {
+			checkpoints[i].CreatedAt = t
+		}
+	}
+
+	return checkpoints, nil
+}
+
+// convertTunedModel converts a Gemini tuned model to a provider FineTuneJob.
+func (c *Client) convertTunedModel(tuned *TunedModel) *provider.FineTuneJob {
+	job := &provider.FineTuneJob{
+		ID:       tuned.Name,
+		Provider: types.ProviderGoogle,
+		Model:    tuned.BaseModel,
+		Metadata: map[string]any{
+			"state": tuned.State,
+		},
+	}
+	if tuned.DisplayName != "" {
+		job.Metadata["display_name"] = tuned.DisplayName
+	}
+
+	if t, err := time.Parse(time.RFC3339, tuned.CreateTime); err == nil {
+		job.CreatedAt = t
+	}
+
+	switch tuned.State {
+	case "ACTIVE":
+		job.Status = provider.FineTuneStatusSucceeded
+		job.FineTunedModel = tuned.Name
+	case "FAILED":
+		job.Status = provider.FineTuneStatusFailed
+	case "CREATING":
+		job.Status = provider.FineTuneStatusRunning
+	default:
+		job.Status = provider.FineTuneStatusPending
+	}
+
+	if tuned.TuningTask != nil {
+		if t, err := time.Parse(time.RFC3339, tuned.TuningTask.CompleteTime); err == nil {
+			job.FinishedAt = &t
+		}
+	}
+
+	return job
+}
+
+// Ensure Client implements provider.FineTuneProvider
+var _ provider.FineTuneProvider = (*Client)(nil)
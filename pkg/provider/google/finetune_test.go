@@ -0,0 +1,114 @@
+package google
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// newTuningServer serves the tuned model endpoints with one model per
+// state: tunedModels/active and tunedModels/creating. Deleted models are
+// recorded.
+func newTuningServer(t *testing.T, created *TunedModel, deleted *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "POST" && r.URL.Path == "/tunedModels":
+			json.NewDecoder(r.Body).Decode(created)
+			fmt.Fprint(w, `{"name":"tunedModels/new/operations/op1","metadata":{"tunedModel":"tunedModels/new","totalSteps":30}}`)
+		case r.Method == "GET" && r.URL.Path == "/tunedModels":
+			fmt.Fprint(w, `{"tunedModels":[{"name":"tunedModels/active","baseModel":"models/gemini-1.5-flash-001-tuning","state":"ACTIVE"},{"name":"tunedModels/creating","state":"CREATING"}]}`)
+		case r.Method == "GET" && r.URL.Path == "/tunedModels/active":
+			fmt.Fprint(w, `{"name":"tunedModels/active","displayName":"support","baseModel":"models/gemini-1.5-flash-001-tuning","state":"ACTIVE","createTime":"2025-01-01T00:00:00Z",
+				"tuningTask":{"completeTime":"2025-01-01T01:00:00Z","snapshots":[{"step":10,"epoch":1,"meanLoss":0.25,"computeTime":"2025-01-01T00:30:00Z"}]}}`)
+		case r.Method == "GET" && r.URL.Path == "/tunedModels/creating":
+			fmt.Fprint(w, `{"name":"tunedModels/creating","state":"CREATING"}`)
+		case r.Method == "DELETE":
+			*deleted = append(*deleted, r.URL.Path)
+			fmt.Fprint(w, `{}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestFineTune(t *testing.T) {
+	var created TunedModel
+	var deleted []string
+	srv := newTuningServer(t, &created, &deleted)
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(srv.URL))
+	ctx := context.Background()
+
+	job, err := c.CreateFineTuneJob(ctx, &provider.FineTuneRequest{
+		Model:  "gemini-1.5-flash-001-tuning",
+		Suffix: "support",
+		TrainingData: []provider.TrainingExample{{Messages: []types.Message{
+			types.NewTextMessage(types.RoleSystem, "Be brief."),
+			types.NewTextMessage(types.RoleUser, "hi"),
+			types.NewTextMessage(types.RoleAssistant, "hello"),
+		}}},
+		Epochs: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.ID != "tunedModels/new" || job.Status != provider.FineTuneStatusPending || job.Metadata["total_steps"] != 30 {
+		t.Errorf("job = %+v", job)
+	}
+	examples := created.TuningTask.TrainingData.Examples.Examples
+	if created.BaseModel != "models/gemini-1.5-flash-001-tuning" || created.TuningTask.Hyperparameters.EpochCount != 3 ||
+		len(examples) != 1 || examples[0].TextInput != "Be brief.\nhi" || examples[0].Output != "hello" {
+		t.Errorf("created = %+v", created)
+	}
+
+	job, err = c.GetFineTuneJob(ctx, "active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Status != provider.FineTuneStatusSucceeded || job.FineTunedModel != "tunedModels/active" || job.FinishedAt == nil {
+		t.Errorf("job = %+v", job)
+	}
+
+	jobs, err := c.ListFineTuneJobs(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 2 || jobs[1].Status != provider.FineTuneStatusRunning {
+		t.Errorf("jobs = %+v", jobs)
+	}
+
+	checkpoints, err := c.ListFineTuneCheckpoints(ctx, "tunedModels/active")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].Step != 10 || checkpoints[0].Metrics["mean_loss"] != 0.25 || checkpoints[0].CreatedAt.IsZero() {
+		t.Errorf("checkpoints = %+v", checkpoints)
+	}
+}
+
+func TestCancelFineTuneJob(t *testing.T) {
+	var deleted []string
+	srv := newTuningServer(t, &TunedModel{}, &deleted)
+	c := New(provider.WithAPIKey("key"), provider.WithBaseURL(srv.URL))
+
+	err := c.CancelFineTuneJob(context.Background(), "tunedModels/active")
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeInvalidRequest, "")) {
+		t.Errorf("err = %v, want invalid request for a finished model", err)
+	}
+	if err := c.CancelFineTuneJob(context.Background(), "creating"); err != nil {
+		t.Fatal(err)
+	}
+	if len(deleted) != 1 || deleted[0] != "/tunedModels/creating" {
+		t.Errorf("deleted %v, want only the model being tuned", deleted)
+	}
+}
@@ -2,8 +2,14 @@ package google
 
 import (
 	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/schema"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -36,12 +42,17 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *GenerateCo
 		Temperature: req.Temperature,
 		TopP:        req.TopP,
 		TopK:        req.TopK,
+		Seed:        req.Seed,
 	}
 
 	if req.MaxTokens != nil {
 		genConfig.MaxOutputTokens = req.MaxTokens
 	}
 
+	if req.N > 1 {
+		genConfig.CandidateCount = types.Ptr(req.N)
+	}
+
 	if len(req.StopSequences) > 0 {
 		genConfig.StopSequences = req.StopSequences
 	}
@@ -118,7 +129,9 @@ func (t *Transformer) transformMessages(messages []types.Message) ([]Content, *C
 	var contents []Content
 	var systemInstruction *Content
 
-	for _, msg := range messages {
+	messages = provider.PairToolResults(messages)
+	names := toolCallNames(messages)
+	for i, msg := range messages {
 		// Handle system messages
 		if msg.Role == types.RoleSystem {
 			var parts []Part
@@ -133,9 +146,17 @@ func (t *Transformer) transformMessages(messages []types.Message) ([]Content, *C
 			continue
 		}
 
+		// Gemini expects one function response per call of the previous
+		// turn, so the results of parallel calls share one content.
+		if msg.Role == types.RoleTool && i > 0 && messages[i-1].Role == types.RoleTool && len(contents) > 0 {
+			last := &contents[len(contents)-1]
+			last.Parts = append(last.Parts, t.transformParts(msg.Content, names)...)
+			continue
+		}
+
 		content := Content{
 			Role:  t.mapRole(msg.Role),
-			Parts: t.transformParts(msg.Content),
+			Parts: t.transformParts(msg.Content, names),
 		}
 
 		contents = append(contents, content)
@@ -156,8 +177,9 @@ func (t *Transformer) mapRole(role types.Role) string {
 	}
 }
 
-// transformParts converts unified content blocks to Google parts.
-func (t *Transformer) transformParts(blocks []types.ContentBlock) []Part {
+// transformParts converts unified content blocks to Google parts. names maps
+// tool call IDs to function names for tool results.
+func (t *Transformer) transformParts(blocks []types.ContentBlock, names map[string]string) []Part {
 	var parts []Part
 
 	for _, block := range blocks {
@@ -186,6 +208,7 @@ func (t *Transformer) transformParts(blocks []types.ContentBlock) []Part {
 			args, _ := block.ToolInput.(map[string]any)
 			parts = append(parts, Part{
 				FunctionCall: &FunctionCall{
+					ID:   providerCallID(block.ToolUseID),
 					Name: block.ToolName,
 					Args: args,
 				},
@@ -197,12 +220,27 @@ func (t *Transformer) transformParts(blocks []types.ContentBlock) []Part {
 			if err := json.Unmarshal([]byte(block.Text), &response); err != nil {
 				response = map[string]any{"result": block.Text}
 			}
+			name := block.ToolName
+			if name == "" {
+				name = names[block.ToolResultID]
+			}
+			if name == "" {
+				name = toolCallName(block.ToolResultID)
+			}
 			parts = append(parts, Part{
 				FunctionResponse: &FunctionResponse{
-					Name:     block.ToolName,
+					ID:       providerCallID(block.ToolResultID),
+					Name:     name,
 					Response: response,
 				},
 			})
+
+			// Images in the result follow the function response as parts.
+			for _, rb := range block.ToolResultContent {
+				if rb.Type == types.ContentTypeImage {
+					parts = append(parts, t.transformParts([]types.ContentBlock{rb}, names)...)
+				}
+			}
 		}
 	}
 
@@ -230,10 +268,18 @@ func (t *Transformer) convertGoogleSchema(s *schema.GoogleSchema) *Schema {
 
 	gs := &Schema{
 		Type:        s.Type,
+		Format:      s.Format,
 		Description: s.Description,
 		Enum:        s.Enum,
 		Required:    s.Required,
 		Nullable:    s.Nullable,
+		Minimum:     s.Minimum,
+		Maximum:     s.Maximum,
+		MinItems:    s.MinItems,
+		MaxItems:    s.MaxItems,
+		MinLength:   s.MinLength,
+		MaxLength:   s.MaxLength,
+		Pattern:     s.Pattern,
 	}
 
 	if len(s.Properties) > 0 {
@@ -247,6 +293,10 @@ func (t *Transformer) convertGoogleSchema(s *schema.GoogleSchema) *Schema {
 		gs.Items = t.convertGoogleSchema(s.Items)
 	}
 
+	for _, variant := range s.AnyOf {
+		gs.AnyOf = append(gs.AnyOf, t.convertGoogleSchema(variant))
+	}
+
 	return gs
 }
 
@@ -303,11 +353,34 @@ func (t *Transformer) TransformResponse(resp *GenerateContentResponse) *types.Co
 
 	candidate := t.pickResponseCandidate(resp.Candidates)
 	result := &types.CompletionResponse{
-		Provider:   types.ProviderGoogle,
-		Content:    t.transformResponseContent(candidate.Content),
-		StopReason: t.TransformStopReason(candidate.FinishReason),
-		ToolCalls:  t.extractToolCalls(candidate.Content),
-		CreatedAt:  time.Now(),
+		ID:               resp.ResponseID,
+		Provider:         types.ProviderGoogle,
+		Content:          t.transformResponseContent(candidate.Content),
+		StopReason:       t.TransformStopReason(candidate.FinishReason),
+		ToolCalls:        t.extractToolCalls(candidate.Content),
+		CreatedAt:        time.Now(),
+		ProviderMetadata: types.ProviderMetadata{ModelVersion: resp.ModelVersion},
+	}
+	result.Content = append(result.Content, t.transformGrounding(candidate)...)
+	result.Citations = provider.CollectCitations(result.Content)
+	result.Metadata = t.SafetyMetadata(candidate.SafetyRatings, resp.PromptFeedback)
+
+	for i := range resp.Candidates {
+		c := &resp.Candidates[i]
+		if c == candidate {
+			continue
+		}
+		alt := types.CompletionResponse{
+			ID:         resp.ResponseID,
+			Provider:   types.ProviderGoogle,
+			Content:    append(t.transformResponseContent(c.Content), t.transformGrounding(c)...),
+			StopReason: t.TransformStopReason(c.FinishReason),
+			ToolCalls:  t.extractToolCalls(c.Content),
+			CreatedAt:  result.CreatedAt,
+			Metadata:   t.SafetyMetadata(c.SafetyRatings, nil),
+		}
+		alt.Citations = provider.CollectCitations(alt.Content)
+		result.Alternatives = append(result.Alternatives, alt)
 	}
 
 	if resp.UsageMetadata != nil {
@@ -322,6 +395,96 @@ func (t *Transformer) TransformResponse(resp *GenerateContentResponse) *types.Co
 	return result
 }
 
+// SafetyMetadata returns response metadata holding the candidate's
+// "safety_ratings" ([]SafetyRating) and the "prompt_feedback"
+// (*PromptFeedback), or nil if there are neither.
+func (t *Transformer) SafetyMetadata(ratings []SafetyRating, feedback *PromptFeedback) map[string]any {
+	if len(ratings) == 0 && feedback == nil {
+		return nil
+	}
+	metadata := make(map[string]any)
+	if len(ratings) > 0 {
+		metadata["safety_ratings"] = ratings
+	}
+	if feedback != nil {
+		metadata["prompt_feedback"] = feedback
+	}
+	return metadata
+}
+
+// BlockedError returns a content_blocked error if feedback says the prompt
+// was blocked, or nil. Gemini then returns no candidates.
+func (t *Transformer) BlockedError(name types.Provider, feedback *PromptFeedback) error {
+	if feedback == nil || feedback.BlockReason == "" {
+		return nil
+	}
+	return errors.ErrContentBlocked(name, feedback.BlockReason, flaggedCategories(feedback.SafetyRatings))
+}
+
+// FilterError returns a content_filter error if the response stopped under
+// a content policy (SAFETY, RECITATION, ...), or nil. result is the
+// transformed response, whose text is the partial text.
+func (t *Transformer) FilterError(name types.Provider, resp *GenerateContentResponse, result *types.CompletionResponse) error {
+	if result.StopReason != types.StopReasonContentFilter {
+		return nil
+	}
+	candidate := t.pickResponseCandidate(resp.Candidates)
+	return errors.ErrContentFilter(name, candidate.FinishReason, flaggedCategories(candidate.SafetyRatings), result.Text())
+}
+
+// StatusError maps the errors that Google distinguishes by their status
+// rather than the HTTP code, or returns nil:
+//
+//   - RESOURCE_EXHAUSTED is a rate limit, retryable after the RetryInfo
+//     delay, unless a daily quota ran out, which no retry fixes today.
+//   - PERMISSION_DENIED is an authentication error, not a bad request.
+//   - UNAVAILABLE means the model is overloaded.
+//   - An API_KEY_INVALID reason is an invalid API key, though Google
+//     answers it with a 400.
+func (t *Transformer) StatusError(name types.Provider, apiErr *APIError, statusCode int) *errors.RouterError {
+	var retryAfter time.Duration
+	dailyQuota, invalidKey := false, false
+	for _, d := range apiErr.Details {
+		if delay, err := time.ParseDuration(d.RetryDelay); err == nil {
+			retryAfter = delay
+		}
+		for _, v := range d.Violations {
+			if strings.Contains(v.QuotaID, "PerDay") {
+				dailyQuota = true
+			}
+		}
+		if d.Reason == "API_KEY_INVALID" {
+			invalidKey = true
+		}
+	}
+
+	switch {
+	case apiErr.Status == "RESOURCE_EXHAUSTED" && dailyQuota:
+		return errors.ErrQuotaExceeded(name, apiErr.Message).WithStatusCode(statusCode)
+	case apiErr.Status == "RESOURCE_EXHAUSTED":
+		return errors.ErrRateLimit(name, apiErr.Message).WithStatusCode(statusCode).WithRetryAfter(retryAfter)
+	case apiErr.Status == "PERMISSION_DENIED":
+		return errors.ErrAuthentication(name, apiErr.Message).WithStatusCode(statusCode)
+	case apiErr.Status == "UNAVAILABLE":
+		return errors.ErrOverloaded(name, apiErr.Message).WithStatusCode(statusCode).WithRetryAfter(retryAfter)
+	case invalidKey:
+		return errors.ErrInvalidAPIKey(name).WithStatusCode(statusCode)
+	}
+	return nil
+}
+
+// flaggedCategories returns the categories of ratings that were blocked or
+// rated a medium or high probability of harm.
+func flaggedCategories(ratings []SafetyRating) []string {
+	var categories []string
+	for _, r := range ratings {
+		if r.Blocked || r.Probability == "HIGH" || r.Probability == "MEDIUM" {
+			categories = append(categories, r.Category)
+		}
+	}
+	return categories
+}
+
 func (t *Transformer) pickResponseCandidate(candidates []Candidate) *Candidate {
 	for i := range candidates {
 		c := &candidates[i]
@@ -343,6 +506,7 @@ func (t *Transformer) transformResponseContent(content *Content) []types.Content
 	var thoughtOnly []types.ContentBlock
 	visibleText := false
 
+	calls := 0
 	for _, part := range content.Parts {
 		if part.Text != "" {
 			b := types.ContentBlock{Type: types.ContentTypeText, Text: part.Text}
@@ -357,9 +521,11 @@ func (t *Transformer) transformResponseContent(content *Content) []types.Content
 		if part.FunctionCall != nil {
 			blocks = append(blocks, types.ContentBlock{
 				Type:      types.ContentTypeToolUse,
+				ToolUseID: ToolCallID(part.FunctionCall, calls),
 				ToolName:  part.FunctionCall.Name,
 				ToolInput: part.FunctionCall.Args,
 			})
+			calls++
 		}
 	}
 
@@ -376,6 +542,69 @@ func (t *Transformer) transformResponseContent(content *Content) []types.Content
 	return blocks
 }
 
+// transformGrounding converts grounding metadata to citation blocks: one per
+// supported segment and source, or one per source if no segments are given.
+func (t *Transformer) transformGrounding(candidate *Candidate) []types.ContentBlock {
+	gm := candidate.GroundingMetadata
+	if gm == nil {
+		return nil
+	}
+
+	source := func(i int) *types.Citation {
+		if i < 0 || i >= len(gm.GroundingChunks) {
+			return nil
+		}
+		src := gm.GroundingChunks[i].Web
+		if src == nil {
+			src = gm.GroundingChunks[i].RetrievedContext
+		}
+		if src == nil {
+			return nil
+		}
+		return &types.Citation{Type: "grounding", URL: src.URI, Title: src.Title}
+	}
+
+	var blocks []types.ContentBlock
+	if len(gm.GroundingSupports) == 0 {
+		for i := range gm.GroundingChunks {
+			if c := source(i); c != nil {
+				blocks = append(blocks, types.ContentBlock{Type: types.ContentTypeCitation, Citation: c})
+			}
+		}
+		return blocks
+	}
+
+	// Segment offsets are within a part; make them offsets in the text.
+	var partOffsets []int
+	offset := 0
+	if candidate.Content != nil {
+		for _, part := range candidate.Content.Parts {
+			partOffsets = append(partOffsets, offset)
+			if !part.Thought {
+				offset += len(part.Text)
+			}
+		}
+	}
+	for _, support := range gm.GroundingSupports {
+		seg := support.Segment
+		base := 0
+		if seg.PartIndex < len(partOffsets) {
+			base = partOffsets[seg.PartIndex]
+		}
+		for _, i := range support.GroundingChunkIndices {
+			c := source(i)
+			if c == nil {
+				continue
+			}
+			c.Text = seg.Text
+			c.StartIndex = base + seg.StartIndex
+			c.EndIndex = base + seg.EndIndex
+			blocks = append(blocks, types.ContentBlock{Type: types.ContentTypeCitation, Citation: c})
+		}
+	}
+	return blocks
+}
+
 func completionHasTextBlocks(blocks []types.ContentBlock) bool {
 	for _, b := range blocks {
 		if b.Type == types.ContentTypeText {
@@ -396,6 +625,7 @@ func (t *Transformer) extractToolCalls(content *Content) []types.ToolCall {
 	for _, part := range content.Parts {
 		if part.FunctionCall != nil {
 			calls = append(calls, types.ToolCall{
+				ID:    ToolCallID(part.FunctionCall, len(calls)),
 				Name:  part.FunctionCall.Name,
 				Input: part.FunctionCall.Args,
 			})
@@ -412,9 +642,7 @@ func (t *Transformer) TransformStopReason(reason string) types.StopReason {
 		return types.StopReasonEnd
 	case "MAX_TOKENS":
 		return types.StopReasonMaxTokens
-	case "SAFETY":
-		return types.StopReasonContentFilter
-	case "RECITATION":
+	case "SAFETY", "RECITATION", "BLOCKLIST", "PROHIBITED_CONTENT", "SPII", "IMAGE_SAFETY":
 		return types.StopReasonContentFilter
 	case "OTHER":
 		return types.StopReasonEnd
@@ -422,3 +650,57 @@ func (t *Transformer) TransformStopReason(reason string) types.StopReason {
 		return types.StopReasonEnd
 	}
 }
+
+// syntheticCallPrefix starts the IDs ToolCallID makes up for function calls
+// that have none.
+const syntheticCallPrefix = "call_"
+
+// ToolCallID returns the ID of the index'th function call in a response.
+// Gemini usually returns calls without IDs, so one is synthesized from the
+// call's position, name, and arguments: it is stable for the same response
+// and ends with the function name, which tool results need.
+func ToolCallID(call *FunctionCall, index int) string {
+	if call.ID != "" {
+		return call.ID
+	}
+	h := fnv.New32a()
+	args, _ := json.Marshal(call.Args)
+	fmt.Fprintf(h, "%d\x00%s\x00%s", index, call.Name, args)
+	return fmt.Sprintf("%s%08x_%s", syntheticCallPrefix, h.Sum32(), call.Name)
+}
+
+// toolCallName returns the function name in an ID made by ToolCallID, or ""
+// for other IDs.
+func toolCallName(id string) string {
+	rest, ok := strings.CutPrefix(id, syntheticCallPrefix)
+	if !ok || len(rest) < 10 || rest[8] != '_' {
+		return ""
+	}
+	if _, err := strconv.ParseUint(rest[:8], 16, 32); err != nil {
+		return ""
+	}
+	return rest[9:]
+}
+
+// providerCallID returns the ID to send back to Gemini: IDs it issued are
+// echoed, and those made up by ToolCallID are left out.
+func providerCallID(id string) string {
+	if toolCallName(id) != "" {
+		return ""
+	}
+	return id
+}
+
+// toolCallNames maps the IDs of the tool calls in a conversation to their
+// function names.
+func toolCallNames(messages []types.Message) map[string]string {
+	names := make(map[string]string)
+	for _, msg := range messages {
+		for _, block := range msg.Content {
+			if block.Type == types.ContentTypeToolUse && block.ToolUseID != "" {
+				names[block.ToolUseID] = block.ToolName
+			}
+		}
+	}
+	return names
+}
@@ -51,6 +51,7 @@ func TestTransformRequest_WithParameters(t *testing.T) {
 		TopP:          &topP,
 		TopK:          &topK,
 		StopSequences: []string{"END"},
+		Seed:          types.Ptr(7),
 	}
 
 	result := transformer.TransformRequest(req)
@@ -78,6 +79,10 @@ func TestTransformRequest_WithParameters(t *testing.T) {
 	if len(result.GenerationConfig.StopSequences) != 1 || result.GenerationConfig.StopSequences[0] != "END" {
 		t.Errorf("expected stop sequence 'END', got %v", result.GenerationConfig.StopSequences)
 	}
+
+	if result.GenerationConfig.Seed == nil || *result.GenerationConfig.Seed != 7 {
+		t.Errorf("expected seed 7, got %v", result.GenerationConfig.Seed)
+	}
 }
 
 func TestTransformRequest_SystemMessage(t *testing.T) {
@@ -288,6 +293,39 @@ func TestTransformRequest_ToolResult(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ParallelToolResults(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []types.Message{
+			{
+				Role: types.RoleAssistant,
+				Content: []types.ContentBlock{
+					{Type: types.ContentTypeToolUse, ToolUseID: "call_a", ToolName: "get_weather"},
+					{Type: types.ContentTypeToolUse, ToolUseID: "call_b", ToolName: "get_time"},
+				},
+			},
+			types.NewToolResultMessage("call_b", `{"time": "12:00"}`, false),
+			types.NewToolResultMessage("call_a", `{"temperature": 22}`, false),
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	if len(result.Contents) != 2 {
+		t.Fatalf("expected the results in one content, got %d contents", len(result.Contents))
+	}
+
+	parts := result.Contents[1].Parts
+	if result.Contents[1].Role != "user" || len(parts) != 2 {
+		t.Fatalf("unexpected tool result content: %+v", result.Contents[1])
+	}
+	if parts[0].FunctionResponse.Name != "get_time" || parts[1].FunctionResponse.Name != "get_weather" {
+		t.Errorf("function response names = %q, %q", parts[0].FunctionResponse.Name, parts[1].FunctionResponse.Name)
+	}
+}
+
 func TestTransformRequest_ToolResultNonJSON(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -455,6 +493,37 @@ func TestTransformRequest_JSONSchemaResponseFormat(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_JSONSchemaUnion(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model:    "gemini-2.5-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+		ResponseFormat: &types.ResponseFormat{
+			Type: "json_schema",
+			Schema: &types.JSONSchema{
+				Type: "object",
+				Properties: map[string]types.JSONSchema{
+					"value": {AnyOf: []types.JSONSchema{{Type: "string"}, {Ref: "#/$defs/point"}}},
+				},
+				Defs: map[string]types.JSONSchema{
+					"point": {Type: "object", Properties: map[string]types.JSONSchema{"x": {Type: "number"}}},
+				},
+			},
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	value := result.GenerationConfig.ResponseSchema.Properties["value"]
+	if value == nil || len(value.AnyOf) != 2 {
+		t.Fatalf("expected anyOf with 2 variants, got %+v", value)
+	}
+	if value.AnyOf[1].Type != "OBJECT" || value.AnyOf[1].Properties["x"].Type != "NUMBER" {
+		t.Errorf("expected the inlined point schema, got %+v", value.AnyOf[1])
+	}
+}
+
 func TestTransformRequest_ThinkingDefaultsIncludeThoughts(t *testing.T) {
 	transformer := NewTransformer()
 	req := &types.CompletionRequest{
@@ -620,6 +689,77 @@ func TestTransformResponse(t *testing.T) {
 	}
 }
 
+func TestTransformResponse_Grounding(t *testing.T) {
+	transformer := NewTransformer()
+
+	resp := &GenerateContentResponse{
+		Candidates: []Candidate{
+			{
+				Content: &Content{
+					Role:  "model",
+					Parts: []Part{{Text: "Spain won Euro 2024. "}, {Text: "The final was in Berlin."}},
+				},
+				FinishReason: "STOP",
+				GroundingMetadata: &GroundingMetadata{
+					GroundingChunks: []GroundingChunk{
+						{Web: &GroundingSource{URI: "https://a.example", Title: "a.example"}},
+						{Web: &GroundingSource{URI: "https://b.example", Title: "b.example"}},
+					},
+					GroundingSupports: []GroundingSupport{
+						{Segment: GroundingSegment{PartIndex: 1, StartIndex: 0, EndIndex: 24, Text: "The final was in Berlin."}, GroundingChunkIndices: []int{0, 1}},
+					},
+				},
+			},
+		},
+	}
+
+	result := transformer.TransformResponse(resp)
+
+	if len(result.Citations) != 2 {
+		t.Fatalf("expected 2 citations, got %d", len(result.Citations))
+	}
+	c := result.Citations[1]
+	if c.URL != "https://b.example" || c.Type != "grounding" {
+		t.Errorf("unexpected citation source: %+v", c)
+	}
+	if got := result.Text()[c.StartIndex:c.EndIndex]; got != "The final was in Berlin." || c.Text != got {
+		t.Errorf("expected span of the cited segment, got %q", got)
+	}
+	if last := result.Content[len(result.Content)-1]; last.Type != types.ContentTypeCitation {
+		t.Errorf("expected citation blocks in content, got %q", last.Type)
+	}
+}
+
+func TestTransformResponse_SafetyMetadata(t *testing.T) {
+	transformer := NewTransformer()
+
+	ratings := []SafetyRating{{Category: "HARM_CATEGORY_HARASSMENT", Probability: "LOW"}}
+	resp := &GenerateContentResponse{
+		Candidates: []Candidate{
+			{
+				Content:       &Content{Role: "model", Parts: []Part{{Text: "Hi"}}},
+				FinishReason:  "STOP",
+				SafetyRatings: ratings,
+			},
+		},
+		PromptFeedback: &PromptFeedback{SafetyRatings: ratings},
+	}
+
+	result := transformer.TransformResponse(resp)
+
+	got, ok := result.Metadata["safety_ratings"].([]SafetyRating)
+	if !ok || len(got) != 1 || got[0].Probability != "LOW" {
+		t.Errorf("expected safety ratings in metadata, got %v", result.Metadata)
+	}
+	if _, ok := result.Metadata["prompt_feedback"].(*PromptFeedback); !ok {
+		t.Errorf("expected prompt feedback in metadata, got %v", result.Metadata)
+	}
+
+	if err := transformer.BlockedError(types.ProviderGoogle, resp.PromptFeedback); err != nil {
+		t.Errorf("expected no error without a block reason, got %v", err)
+	}
+}
+
 func TestTransformResponse_WithToolCalls(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -663,6 +803,57 @@ func TestTransformResponse_WithToolCalls(t *testing.T) {
 	}
 }
 
+func TestToolCallIDs_RoundTrip(t *testing.T) {
+	transformer := NewTransformer()
+
+	resp := &GenerateContentResponse{
+		Candidates: []Candidate{{
+			Content: &Content{Role: "model", Parts: []Part{
+				{FunctionCall: &FunctionCall{Name: "get_weather", Args: map[string]any{"location": "Paris"}}},
+				{FunctionCall: &FunctionCall{Name: "get_weather", Args: map[string]any{"location": "Rome"}}},
+				{FunctionCall: &FunctionCall{ID: "fc-1", Name: "get_time"}},
+			}},
+			FinishReason: "STOP",
+		}},
+	}
+	result := transformer.TransformResponse(resp)
+	calls := result.ToolCalls
+	if len(calls) != 3 || calls[0].ID == "" || calls[0].ID == calls[1].ID || calls[2].ID != "fc-1" {
+		t.Fatalf("tool call IDs = %+v", calls)
+	}
+	if again := transformer.TransformResponse(resp).ToolCalls[0].ID; again != calls[0].ID {
+		t.Errorf("ID not stable: %q then %q", calls[0].ID, again)
+	}
+	if result.Content[0].ToolUseID != calls[0].ID {
+		t.Errorf("content block ID = %q, want %q", result.Content[0].ToolUseID, calls[0].ID)
+	}
+
+	// The second call is missing from the conversation, so its name comes
+	// from the synthesized ID alone.
+	req := transformer.TransformRequest(&types.CompletionRequest{
+		Model: "gemini-2.5-flash",
+		Messages: []types.Message{
+			{Role: types.RoleAssistant, Content: []types.ContentBlock{result.Content[0], result.Content[2]}},
+			types.NewToolResultMessage(calls[0].ID, `{"temperature": 22}`, false),
+			types.NewToolResultMessage(calls[1].ID, `{"temperature": 25}`, false),
+			types.NewToolResultMessage(calls[2].ID, `12:00`, false),
+		},
+	})
+
+	if sent := req.Contents[0].Parts; sent[0].FunctionCall.ID != "" || sent[1].FunctionCall.ID != "fc-1" {
+		t.Errorf("function call IDs sent back as %q, %q", sent[0].FunctionCall.ID, sent[1].FunctionCall.ID)
+	}
+	if len(req.Contents) != 2 {
+		t.Fatalf("expected the results in one content, got %d contents", len(req.Contents))
+	}
+	for i, want := range []FunctionResponse{{Name: "get_weather"}, {Name: "get_weather"}, {ID: "fc-1", Name: "get_time"}} {
+		got := req.Contents[1].Parts[i].FunctionResponse
+		if got.ID != want.ID || got.Name != want.Name {
+			t.Errorf("result %d = %q %q, want %q %q", i, got.ID, got.Name, want.ID, want.Name)
+		}
+	}
+}
+
 func TestTransformResponse_Nil(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -721,3 +912,42 @@ func TestMapRole(t *testing.T) {
 		}
 	}
 }
+
+func TestTransformResponse_ProviderMetadata(t *testing.T) {
+	result := NewTransformer().TransformResponse(&GenerateContentResponse{
+		Candidates: []Candidate{{
+			Content:      &Content{Role: "model", Parts: []Part{{Text: "Hi"}}},
+			FinishReason: "STOP",
+		}},
+		ModelVersion: "gemini-2.0-flash-001",
+		ResponseID:   "resp_123",
+	})
+	if result.ID != "resp_123" || result.ProviderMetadata.ModelVersion != "gemini-2.0-flash-001" {
+		t.Errorf("id = %q, provider metadata = %+v", result.ID, result.ProviderMetadata)
+	}
+}
+
+func TestTransformRequestResponse_N(t *testing.T) {
+	transformer := NewTransformer()
+	gReq := transformer.TransformRequest(&types.CompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+		N:        2,
+	})
+	if gReq.GenerationConfig == nil || gReq.GenerationConfig.CandidateCount == nil || *gReq.GenerationConfig.CandidateCount != 2 {
+		t.Errorf("generation config = %+v, want candidateCount 2", gReq.GenerationConfig)
+	}
+
+	result := transformer.TransformResponse(&GenerateContentResponse{
+		Candidates: []Candidate{
+			{Content: &Content{Role: "model", Parts: []Part{{Text: "Hello!"}}}, FinishReason: "STOP"},
+			{Content: &Content{Role: "model", Parts: []Part{{Text: "Hi there"}}}, FinishReason: "MAX_TOKENS"},
+		},
+	})
+	if result.Text() != "Hello!" || len(result.Alternatives) != 1 {
+		t.Fatalf("text = %q, alternatives = %d", result.Text(), len(result.Alternatives))
+	}
+	if alt := result.Alternatives[0]; alt.Text() != "Hi there" || alt.StopReason != types.StopReasonMaxTokens {
+		t.Errorf("alternative = %+v", alt)
+	}
+}
@@ -41,14 +41,18 @@ type FileData struct {
 	FileURI  string `json:"fileUri"`
 }
 
-// FunctionCall is a function call from the model.
+// FunctionCall is a function call from the model. ID is only set by some
+// models and APIs.
 type FunctionCall struct {
+	ID   string         `json:"id,omitempty"`
 	Name string         `json:"name"`
 	Args map[string]any `json:"args"`
 }
 
-// FunctionResponse is a function response from the user.
+// FunctionResponse is a function response from the user. ID echoes the
+// call's ID when it had one.
 type FunctionResponse struct {
+	ID       string         `json:"id,omitempty"`
 	Name     string         `json:"name"`
 	Response map[string]any `json:"response"`
 }
@@ -61,6 +65,7 @@ type GenerationConfig struct {
 	MaxOutputTokens  *int               `json:"maxOutputTokens,omitempty"`
 	StopSequences    []string           `json:"stopSequences,omitempty"`
 	CandidateCount   *int               `json:"candidateCount,omitempty"`
+	Seed             *int               `json:"seed,omitempty"`
 	ResponseMimeType string             `json:"responseMimeType,omitempty"`
 	ResponseSchema   *Schema            `json:"responseSchema,omitempty"`
 	ThinkingConfig   *ThinkingConfigGen `json:"thinkingConfig,omitempty"`
@@ -76,13 +81,22 @@ type ThinkingConfigGen struct {
 
 // Schema is Google's schema format.
 type Schema struct {
-	Type        string             `json:"type"`
+	Type        string             `json:"type,omitempty"`
+	Format      string             `json:"format,omitempty"`
 	Description string             `json:"description,omitempty"`
 	Enum        []string           `json:"enum,omitempty"`
 	Properties  map[string]*Schema `json:"properties,omitempty"`
 	Required    []string           `json:"required,omitempty"`
 	Items       *Schema            `json:"items,omitempty"`
 	Nullable    bool               `json:"nullable,omitempty"`
+	AnyOf       []*Schema          `json:"anyOf,omitempty"`
+	Minimum     *float64           `json:"minimum,omitempty"`
+	Maximum     *float64           `json:"maximum,omitempty"`
+	MinItems    *int               `json:"minItems,omitempty"`
+	MaxItems    *int               `json:"maxItems,omitempty"`
+	MinLength   *int               `json:"minLength,omitempty"`
+	MaxLength   *int               `json:"maxLength,omitempty"`
+	Pattern     string             `json:"pattern,omitempty"`
 }
 
 // SafetySetting configures safety thresholds.
@@ -119,20 +133,58 @@ type GenerateContentResponse struct {
 	Candidates     []Candidate     `json:"candidates"`
 	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
 	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
 }
 
 // Candidate is a response candidate.
 type Candidate struct {
-	Content       *Content       `json:"content"`
-	FinishReason  string         `json:"finishReason"`
-	Index         int            `json:"index"`
-	SafetyRatings []SafetyRating `json:"safetyRatings,omitempty"`
+	Content           *Content           `json:"content"`
+	FinishReason      string             `json:"finishReason"`
+	Index             int                `json:"index"`
+	SafetyRatings     []SafetyRating     `json:"safetyRatings,omitempty"`
+	GroundingMetadata *GroundingMetadata `json:"groundingMetadata,omitempty"`
+}
+
+// GroundingMetadata lists the sources a grounded response (Google Search or
+// retrieval) is based on, and which text segments each supports.
+type GroundingMetadata struct {
+	GroundingChunks   []GroundingChunk   `json:"groundingChunks,omitempty"`
+	GroundingSupports []GroundingSupport `json:"groundingSupports,omitempty"`
+	WebSearchQueries  []string           `json:"webSearchQueries,omitempty"`
+}
+
+// GroundingChunk is a source: a web page or a retrieved document.
+type GroundingChunk struct {
+	Web              *GroundingSource `json:"web,omitempty"`
+	RetrievedContext *GroundingSource `json:"retrievedContext,omitempty"`
+}
+
+// GroundingSource identifies a grounding chunk's source.
+type GroundingSource struct {
+	URI   string `json:"uri,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// GroundingSupport links a segment of the response to grounding chunks.
+type GroundingSupport struct {
+	Segment               GroundingSegment `json:"segment"`
+	GroundingChunkIndices []int            `json:"groundingChunkIndices,omitempty"`
+}
+
+// GroundingSegment is a span of a response part, in bytes.
+type GroundingSegment struct {
+	PartIndex  int    `json:"partIndex,omitempty"`
+	StartIndex int    `json:"startIndex,omitempty"`
+	EndIndex   int    `json:"endIndex,omitempty"`
+	Text       string `json:"text,omitempty"`
 }
 
 // SafetyRating is a safety rating for content.
 type SafetyRating struct {
 	Category    string `json:"category"`
 	Probability string `json:"probability"`
+	Blocked     bool   `json:"blocked,omitempty"`
 }
 
 // PromptFeedback is feedback about the prompt.
@@ -155,6 +207,8 @@ type StreamChunk struct {
 	Candidates     []Candidate     `json:"candidates"`
 	UsageMetadata  *UsageMetadata  `json:"usageMetadata,omitempty"`
 	PromptFeedback *PromptFeedback `json:"promptFeedback,omitempty"`
+	ModelVersion   string          `json:"modelVersion,omitempty"`
+	ResponseID     string          `json:"responseId,omitempty"`
 }
 
 // ErrorResponse is a Google API error response.
@@ -164,9 +218,25 @@ type ErrorResponse struct {
 
 // APIError is a Google API error.
 type APIError struct {
-	Code    int    `json:"code"`
-	Message string `json:"message"`
-	Status  string `json:"status"`
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Status  string        `json:"status"`
+	Details []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail is a typed detail of an API error. Only the fields of the
+// RetryInfo, QuotaFailure, and ErrorInfo types are decoded.
+type ErrorDetail struct {
+	Type       string           `json:"@type"`
+	RetryDelay string           `json:"retryDelay,omitempty"`
+	Violations []QuotaViolation `json:"violations,omitempty"`
+	Reason     string           `json:"reason,omitempty"`
+}
+
+// QuotaViolation names a quota a request exceeded.
+type QuotaViolation struct {
+	QuotaMetric string `json:"quotaMetric,omitempty"`
+	QuotaID     string `json:"quotaId,omitempty"`
 }
 
 // Batch API types
@@ -288,3 +358,20 @@ type UploadedFile struct {
 	CreateTime  string `json:"createTime,omitempty"`
 	URI         string `json:"uri,omitempty"`
 }
+
+// ModelList is the response from listing models.
+type ModelList struct {
+	Models        []Model `json:"models"`
+	NextPageToken string  `json:"nextPageToken,omitempty"`
+}
+
+// Model is a Gemini model object.
+type Model struct {
+	Name                       string   `json:"name"`
+	Version                    string   `json:"version,omitempty"`
+	DisplayName                string   `json:"displayName,omitempty"`
+	Description                string   `json:"description,omitempty"`
+	InputTokenLimit            int      `json:"inputTokenLimit,omitempty"`
+	OutputTokenLimit           int      `json:"outputTokenLimit,omitempty"`
+	SupportedGenerationMethods []string `json:"supportedGenerationMethods,omitempty"`
+}
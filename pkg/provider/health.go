@@ -0,0 +1,45 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Pinger is an optional interface for providers with a cheaper or more
+// reliable health check than Ping's default.
+type Pinger interface {
+	// Ping makes a minimal authenticated call and returns its error.
+	Ping(ctx context.Context) error
+}
+
+// Ping checks that a provider is reachable and accepts its credentials,
+// with a minimal authenticated call. It uses the provider's Pinger if it
+// has one, otherwise lists its models if it is a ModelLister, and otherwise
+// sends a 1-token completion to its first model.
+func Ping(ctx context.Context, p Provider) error {
+	switch p := p.(type) {
+	case Pinger:
+		return p.Ping(ctx)
+	case ModelLister:
+		_, err := p.ListModels(ctx)
+		return err
+	}
+	models := p.Models()
+	if len(models) == 0 {
+		return errors.ErrUnsupportedFeature(p.Name(), "health checks without a model")
+	}
+	return PingCompletion(ctx, p, models[0])
+}
+
+// PingCompletion sends the smallest possible completion to model: one
+// token of output for a one-word prompt.
+func PingCompletion(ctx context.Context, p Provider, model string) error {
+	_, err := p.Complete(ctx, &types.CompletionRequest{
+		Model:     model,
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "ping")},
+		MaxTokens: types.Ptr(1),
+	})
+	return err
+}
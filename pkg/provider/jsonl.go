@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"iter"
+)
+
+// JSONLines returns an iterator over the non-empty lines of a JSONL stream.
+// Lines are read one at a time without a length limit; each yielded slice is
+// only valid until the next iteration. A read error is yielded once and ends
+// the iteration.
+func JSONLines(r io.Reader) iter.Seq2[[]byte, error] {
+	return func(yield func([]byte, error) bool) {
+		br := bufio.NewReader(r)
+		for {
+			line, err := br.ReadSlice('\n')
+			if err == bufio.ErrBufferFull {
+				// Long line: fall back to an allocating read for the rest.
+				line = append([]byte(nil), line...)
+				var rest []byte
+				rest, err = br.ReadBytes('\n')
+				line = append(line, rest...)
+			}
+			if line = bytes.TrimSpace(line); len(line) > 0 {
+				if !yield(line, nil) {
+					return
+				}
+			}
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				yield(nil, err)
+				return
+			}
+		}
+	}
+}
+
+// CollectBatchResults drains a results iterator into a slice, stopping at the
+// first error.
+func CollectBatchResults(seq iter.Seq2[BatchResult, error]) ([]BatchResult, error) {
+	var results []BatchResult
+	for result, err := range seq {
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
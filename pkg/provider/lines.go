@@ -0,0 +1,73 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"sync"
+)
+
+const (
+	// lineBufferSize is the size of pooled line buffers, enough for the
+	// deltas of a typical event stream without growing.
+	lineBufferSize = 64 << 10
+
+	// maxLineSize caps a single event stream line.
+	maxLineSize = 16 << 20
+)
+
+// lineBuffers pools the buffers of LineReaders across streams.
+var lineBuffers = sync.Pool{
+	New: func() any {
+		buf := make([]byte, lineBufferSize)
+		return &buf
+	},
+}
+
+// LineReader reads the lines of a server-sent event stream into a pooled
+// buffer without allocating per line. The buffer returns to the pool when
+// the stream ends or fails; a LineReader abandoned mid-stream leaves it to
+// the garbage collector.
+type LineReader struct {
+	scanner *bufio.Scanner
+	buf     *[]byte
+}
+
+// NewLineReader returns a LineReader for r.
+func NewLineReader(r io.Reader) *LineReader {
+	buf := lineBuffers.Get().(*[]byte)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(*buf, maxLineSize)
+	return &LineReader{scanner: scanner, buf: buf}
+}
+
+// Next returns the next line with surrounding whitespace trimmed. The slice
+// is only valid until the next call. At the end of the stream it returns
+// io.EOF; a line over 16 MiB fails with bufio.ErrTooLong.
+func (l *LineReader) Next() ([]byte, error) {
+	if l.buf == nil {
+		return nil, io.EOF
+	}
+	if l.scanner.Scan() {
+		return bytes.TrimSpace(l.scanner.Bytes()), nil
+	}
+	err := l.scanner.Err()
+	if err == nil {
+		err = io.EOF
+	}
+	l.release()
+	return nil, err
+}
+
+// release returns the buffer to the pool.
+func (l *LineReader) release() {
+	lineBuffers.Put(l.buf)
+	l.buf = nil
+}
+
+// SSEData returns the payload of an SSE "data:" line, and false for any
+// other line.
+func SSEData(line []byte) ([]byte, bool) {
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	return bytes.TrimLeft(data, " "), ok
+}
@@ -0,0 +1,48 @@
+package provider
+
+import (
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestLineReader(t *testing.T) {
+	long := strings.Repeat("x", 3*lineBufferSize)
+	r := NewLineReader(strings.NewReader("event: ping\r\ndata: {}\n\n" + "data: " + long + "\ndata: [DONE]"))
+
+	var lines []string
+	for {
+		line, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines = append(lines, string(line))
+	}
+
+	want := []string{"event: ping", "data: {}", "", "data: " + long, "data: [DONE]"}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i := range want {
+		if lines[i] != want[i] {
+			t.Errorf("line %d = %.20q, want %.20q", i, lines[i], want[i])
+		}
+	}
+	if _, err := r.Next(); err != io.EOF {
+		t.Errorf("Next after the end = %v, want io.EOF", err)
+	}
+}
+
+func TestSSEData(t *testing.T) {
+	for line, want := range map[string]string{"data: {}": "{}", "data:{}": "{}", "data:": ""} {
+		if data, ok := SSEData([]byte(line)); !ok || string(data) != want {
+			t.Errorf("SSEData(%q) = %q, %v", line, data, ok)
+		}
+	}
+	if _, ok := SSEData([]byte("event: ping")); ok {
+		t.Error("SSEData accepted an event line")
+	}
+}
@@ -0,0 +1,49 @@
+package provider
+
+import (
+	"context"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// ModelLister is an optional interface for providers that can list their
+// available models from the provider API.
+type ModelLister interface {
+	// ListModels fetches the models available to the configured credentials.
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// ModelInfo describes a model as reported by a provider. Fields the provider
+// does not report are left zero.
+type ModelInfo struct {
+	// ID is the model name to use in requests.
+	ID string `json:"id"`
+
+	// Provider serving the model.
+	Provider types.Provider `json:"provider"`
+
+	// DisplayName is a human-readable name.
+	DisplayName string `json:"display_name,omitempty"`
+
+	// Description of the model.
+	Description string `json:"description,omitempty"`
+
+	// CreatedAt is when the model was released.
+	CreatedAt time.Time `json:"created_at,omitempty"`
+
+	// ContextWindow is the maximum number of input tokens.
+	ContextWindow int `json:"context_window,omitempty"`
+
+	// MaxOutputTokens is the maximum number of tokens the model can generate.
+	MaxOutputTokens int `json:"max_output_tokens,omitempty"`
+
+	// InputModalities lists accepted input types ("text", "image", ...).
+	InputModalities []string `json:"input_modalities,omitempty"`
+
+	// Deprecated is true if the model is scheduled for retirement.
+	Deprecated bool `json:"deprecated,omitempty"`
+
+	// Metadata contains provider-specific information.
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
@@ -5,7 +5,14 @@ import (
 	"context"
 	"encoding/json"
 	"io"
+	"iter"
+	"mime/multipart"
 	"net/http"
+	"net/textproto"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 
 	"github.com/Chloe199719/agent-router/pkg/errors"
 	"github.com/Chloe199719/agent-router/pkg/provider"
@@ -109,19 +116,30 @@ type FileUploadResponse struct {
 
 // CreateBatch creates a new batch job.
 func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchRequest) (*provider.BatchJob, error) {
-	// Step 1: Create JSONL content for batch input
 	var buffer bytes.Buffer
-	encoder := json.NewEncoder(&buffer)
+	if err := c.WriteBatchInput(&buffer, slices.Values(requests)); err != nil {
+		return nil, err
+	}
+	return c.CreateBatchFromReader(ctx, &buffer, int64(buffer.Len()))
+}
+
+// WriteBatchInput writes requests to w as batch input lines for the chat
+// completions endpoint.
+func (c *Client) WriteBatchInput(w io.Writer, requests iter.Seq[provider.BatchRequest]) error {
+	encoder := json.NewEncoder(w)
 
-	for _, req := range requests {
-		// Transform request to OpenAI format
-		oaiReq := c.transformer.TransformRequest(req.Request)
-		oaiReq.Stream = false
+	for req := range requests {
+		// Transform request to OpenAI format. Batches don't stream, so the
+		// request is transformed as a non-streaming one: a streaming one
+		// would add stream_options and stream audio formats.
+		unary := *req.Request
+		unary.Stream = false
+		oaiReq := c.transformer.TransformRequest(&unary)
 
 		// Convert to generic map for body
 		reqBody, err := json.Marshal(oaiReq)
 		if err != nil {
-			return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+			return errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
 		}
 
 		var body map[string]interface{}
@@ -135,17 +153,23 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 		}
 
 		if err := encoder.Encode(line); err != nil {
-			return nil, errors.ErrInvalidRequest("failed to encode batch line").WithCause(err)
+			return errors.ErrInvalidRequest("failed to encode batch line").WithCause(err)
 		}
 	}
+	return nil
+}
 
-	// Step 2: Upload the file
-	fileID, err := c.uploadBatchFile(ctx, buffer.Bytes())
+// CreateBatchFromReader uploads JSONL batch input, as written by
+// WriteBatchInput, and creates a batch job from it. The input is streamed,
+// so it need not fit in memory.
+func (c *Client) CreateBatchFromReader(ctx context.Context, r io.Reader, size int64) (*provider.BatchJob, error) {
+	// Step 1: Upload the file
+	fileID, err := c.UploadFile(ctx, "batch", "batch_input.jsonl", r, size)
 	if err != nil {
 		return nil, err
 	}
 
-	// Step 3: Create the batch
+	// Step 2: Create the batch
 	createReq := BatchCreateRequest{
 		InputFileID:      fileID,
 		Endpoint:         "/v1/chat/completions",
@@ -164,9 +188,9 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -182,31 +206,50 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 	return c.convertBatchJob(&batch), nil
 }
 
-// uploadBatchFile uploads a file for batch processing.
-func (c *Client) uploadBatchFile(ctx context.Context, content []byte) (string, error) {
-	// Create multipart form
-	var buffer bytes.Buffer
-	boundary := "----GoAgentRouterBoundary"
-	buffer.WriteString("--" + boundary + "\r\n")
-	buffer.WriteString("Content-Disposition: form-data; name=\"purpose\"\r\n\r\n")
-	buffer.WriteString("batch\r\n")
-	buffer.WriteString("--" + boundary + "\r\n")
-	buffer.WriteString("Content-Disposition: form-data; name=\"file\"; filename=\"batch_input.jsonl\"\r\n")
-	buffer.WriteString("Content-Type: application/jsonl\r\n\r\n")
-	buffer.Write(content)
-	buffer.WriteString("\r\n--" + boundary + "--\r\n")
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/files", &buffer)
+// quoteEscaper escapes a form field name or filename for a
+// Content-Disposition header, as mime/multipart does.
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// UploadFile uploads a file for the given purpose ("batch", "fine-tune") and
+// returns its ID. The content is streamed from r, so files of any size are
+// uploaded without being held in memory. If size is the length of the
+// content, it is sent as the Content-Length; pass -1 if it is unknown to
+// upload with chunked transfer encoding.
+func (c *Client) UploadFile(ctx context.Context, purpose, filename string, r io.Reader, size int64) (string, error) {
+	// The multipart framing around the file is built up front, so the file
+	// itself streams between the two.
+	var head bytes.Buffer
+	mw := multipart.NewWriter(&head)
+	if err := mw.WriteField("purpose", purpose); err != nil {
+		return "", errors.ErrInvalidRequest("failed to encode upload").WithCause(err)
+	}
+	h := make(textproto.MIMEHeader)
+	h.Set("Content-Disposition", `form-data; name="file"; filename="`+quoteEscaper.Replace(filename)+`"`)
+	h.Set("Content-Type", "application/jsonl")
+	if _, err := mw.CreatePart(h); err != nil {
+		return "", errors.ErrInvalidRequest("failed to encode upload").WithCause(err)
+	}
+	n := head.Len()
+	mw.Close()
+	framing := head.Bytes()
+	prefix, suffix := framing[:n], framing[n:]
+
+	body := io.MultiReader(bytes.NewReader(prefix), r, bytes.NewReader(suffix))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/files", body)
 	if err != nil {
 		return "", errors.ErrInvalidRequest("failed to create upload request").WithCause(err)
 	}
+	httpReq.ContentLength = -1
+	if size >= 0 {
+		httpReq.ContentLength = int64(len(prefix)) + size + int64(len(suffix))
+	}
 
-	httpReq.Header.Set("Content-Type", "multipart/form-data; boundary="+boundary)
+	httpReq.Header.Set("Content-Type", mw.FormDataContentType())
 	httpReq.Header.Set("Authorization", "Bearer "+c.config.APIKey)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return "", errors.ErrProviderUnavailable(types.ProviderOpenAI, "upload failed").WithCause(err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -231,9 +274,9 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -251,6 +294,16 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 // GetBatchResults retrieves the results of a completed batch job.
 func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provider.BatchResult, error) {
+	seq, err := c.GetBatchResultsIter(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.CollectBatchResults(seq)
+}
+
+// GetBatchResultsIter streams the results of a completed batch job, decoding
+// the output file line by line.
+func (c *Client) GetBatchResultsIter(ctx context.Context, batchID string) (iter.Seq2[provider.BatchResult, error], error) {
 	// First get the batch to get the output file ID
 	job, err := c.GetBatch(ctx, batchID)
 	if err != nil {
@@ -262,53 +315,65 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 		return nil, errors.ErrInvalidRequest("batch has no output file").WithProvider(types.ProviderOpenAI)
 	}
 
-	// Download the output file
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/files/"+outputFileID+"/content", nil)
+	return func(yield func(provider.BatchResult, error) bool) {
+		body, err := c.downloadFile(ctx, outputFileID)
+		if err != nil {
+			yield(provider.BatchResult{}, err)
+			return
+		}
+		defer body.Close()
+
+		for data, err := range provider.JSONLines(body) {
+			if err != nil {
+				yield(provider.BatchResult{}, errors.ErrServerError(types.ProviderOpenAI, "failed to read response").WithCause(err))
+				return
+			}
+
+			var line BatchOutputLine
+			if err := json.Unmarshal(data, &line); err != nil {
+				continue
+			}
+
+			result := provider.BatchResult{
+				CustomID: line.CustomID,
+			}
+
+			if line.Error != nil {
+				result.Error = errors.ErrServerError(types.ProviderOpenAI, line.Error.Message)
+			} else if line.Response != nil {
+				result.Response = c.transformer.TransformResponse(&line.Response.Body)
+				if result.Response == nil {
+					result.Error = errors.ErrEmptyResponse(types.ProviderOpenAI, "batch result has no choices")
+				}
+			}
+
+			if !yield(result, nil) {
+				return
+			}
+		}
+	}, nil
+}
+
+// downloadFile opens the content of an uploaded or generated file.
+func (c *Client) downloadFile(ctx context.Context, fileID string) (io.ReadCloser, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/files/"+fileID+"/content", nil)
 	if err != nil {
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	// Parse JSONL output
-	content, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to read response").WithCause(err)
-	}
-
-	var results []provider.BatchResult
-	decoder := json.NewDecoder(bytes.NewReader(content))
-
-	for decoder.More() {
-		var line BatchOutputLine
-		if err := decoder.Decode(&line); err != nil {
-			continue
-		}
-
-		result := provider.BatchResult{
-			CustomID: line.CustomID,
-		}
-
-		if line.Error != nil {
-			result.Error = errors.ErrServerError(types.ProviderOpenAI, line.Error.Message)
-		} else if line.Response != nil {
-			result.Response = c.transformer.TransformResponse(&line.Response.Body)
-		}
-
-		results = append(results, result)
-	}
-
-	return results, nil
+	return resp.Body, nil
 }
 
 // CancelBatch cancels a batch job.
@@ -320,9 +385,9 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -335,43 +400,51 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 // ListBatches lists all batch jobs.
 func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
-	url := c.baseURL + "/batches"
+	jobs, _, err := c.listBatchesPage(ctx, opts)
+	return jobs, err
+}
+
+// ListAllBatches lists every batch job, following the after cursor.
+func (c *Client) ListAllBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
+	return provider.ListAllBatches(ctx, opts, c.listBatchesPage)
+}
+
+// listBatchesPage fetches one page of batch jobs and the cursor for the next.
+func (c *Client) listBatchesPage(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, string, error) {
+	endpoint := c.baseURL + "/batches"
 	if opts != nil {
-		params := "?"
+		params := url.Values{}
 		if opts.Limit > 0 {
-			params += "limit=" + string(rune(opts.Limit))
+			params.Set("limit", strconv.Itoa(opts.Limit))
 		}
 		if opts.After != "" {
-			if params != "?" {
-				params += "&"
-			}
-			params += "after=" + opts.After
+			params.Set("after", opts.After)
 		}
-		if params != "?" {
-			url += params
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
 		}
 	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		return nil, "", errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
 	c.setHeaders(httpReq)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp)
+		return nil, "", c.handleErrorResponse(resp)
 	}
 
 	var list BatchList
 	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
-		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+		return nil, "", errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
 	}
 
 	jobs := make([]provider.BatchJob, len(list.Data))
@@ -379,7 +452,12 @@ func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOption
 		jobs[i] = *c.convertBatchJob(&batch)
 	}
 
-	return jobs, nil
+	var next string
+	if list.HasMore {
+		next = list.LastID
+	}
+
+	return jobs, next, nil
 }
 
 // convertBatchJob converts OpenAI batch to provider batch job.
@@ -441,3 +519,9 @@ func (c *Client) convertBatchStatus(status string) provider.BatchStatus {
 
 // Ensure Client implements provider.BatchProvider
 var _ provider.BatchProvider = (*Client)(nil)
+
+// Ensure Client implements provider.BatchResultsIterator
+var _ provider.BatchResultsIterator = (*Client)(nil)
+
+// Ensure Client implements provider.BatchLister
+var _ provider.BatchLister = (*Client)(nil)
@@ -0,0 +1,232 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestGetBatchResultsIter(t *testing.T) {
+	long := strings.Repeat("x", 200*1024)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/batches/batch_1":
+			fmt.Fprint(w, `{"id":"batch_1","status":"completed","output_file_id":"file_1"}`)
+		case "/files/file_1/content":
+			for i := range 3 {
+				fmt.Fprintf(w, `{"custom_id":"req-%d","response":{"status_code":200,"body":{"id":"c%d","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}}}`+"\n", i, i)
+			}
+			fmt.Fprint(w, "not json\n\n")
+			fmt.Fprintf(w, `{"custom_id":"req-long","response":{"status_code":200,"body":{"id":"c","model":"gpt-4o-mini","choices":[{"index":0,"message":{"role":"assistant","content":"%s"},"finish_reason":"stop"}]}}}`+"\n", long)
+			fmt.Fprint(w, `{"custom_id":"req-err","error":{"message":"boom"}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+
+	seq, err := c.GetBatchResultsIter(context.Background(), "batch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ids []string
+	for result, err := range seq {
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, result.CustomID)
+		if result.CustomID == "req-long" && len(result.Response.Text()) != len(long) {
+			t.Errorf("expected long line to be decoded in full, got %d bytes", len(result.Response.Text()))
+		}
+		if result.CustomID == "req-err" && result.Error == nil {
+			t.Error("expected error result")
+		}
+	}
+	if got := strings.Join(ids, ","); got != "req-0,req-1,req-2,req-long,req-err" {
+		t.Errorf("unexpected results: %s", got)
+	}
+
+	// Stopping early closes the download.
+	seq, err = c.GetBatchResultsIter(context.Background(), "batch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for result := range seq {
+		if result.CustomID != "req-0" {
+			t.Errorf("expected first result, got %s", result.CustomID)
+		}
+		break
+	}
+}
+
+func TestGetBatchResultsIter_NoOutput(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":"batch_1","status":"in_progress"}`)
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+	if _, err := c.GetBatchResultsIter(context.Background(), "batch_1"); err == nil {
+		t.Error("expected error for batch without output file")
+	}
+}
+
+func TestListAllBatches(t *testing.T) {
+	var queries []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		switch r.URL.Query().Get("after") {
+		case "":
+			fmt.Fprint(w, `{"data":[{"id":"b1","status":"completed"},{"id":"b2","status":"completed"}],"last_id":"b2","has_more":true}`)
+		case "b2":
+			fmt.Fprint(w, `{"data":[{"id":"b3","status":"failed"}],"last_id":"b3","has_more":false}`)
+		default:
+			t.Errorf("unexpected cursor %q", r.URL.Query().Get("after"))
+		}
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+
+	jobs, err := c.ListAllBatches(context.Background(), &provider.ListBatchOptions{Limit: 50})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jobs) != 3 || jobs[2].ID != "b3" {
+		t.Errorf("expected all three batches, got %+v", jobs)
+	}
+	if len(queries) != 2 || queries[0] != "limit=50" || queries[1] != "after=b2&limit=50" {
+		t.Errorf("unexpected queries: %q", queries)
+	}
+}
+
+func TestUploadFile(t *testing.T) {
+	content := strings.Repeat(`{"custom_id":"a"}`+"\n", 1000)
+	filename := `batch "1".jsonl`
+	var lengths []int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lengths = append(lengths, r.ContentLength)
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			t.Error(err)
+			return
+		}
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		data, _ := io.ReadAll(file)
+		if r.FormValue("purpose") != "batch" || header.Filename != filename || string(data) != content {
+			t.Errorf("purpose %q, filename %q, %d bytes", r.FormValue("purpose"), header.Filename, len(data))
+		}
+		fmt.Fprint(w, `{"id":"file_1"}`)
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+	for _, size := range []int64{int64(len(content)), -1} {
+		// A reader without a known length streams the content.
+		r := io.MultiReader(strings.NewReader(content))
+		id, err := c.UploadFile(context.Background(), "batch", filename, r, size)
+		if err != nil || id != "file_1" {
+			t.Fatalf("UploadFile = %q, %v", id, err)
+		}
+	}
+	if len(lengths) != 2 || lengths[0] <= int64(len(content)) || lengths[1] != -1 {
+		t.Errorf("content lengths = %v, want the full body then chunked", lengths)
+	}
+}
+
+func TestWriteBatchInput_StructuredToolsVision(t *testing.T) {
+	req := (&types.CompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.Message{{Role: types.RoleUser, Content: []types.ContentBlock{
+			{Type: types.ContentTypeText, Text: "Describe the image."},
+			{Type: types.ContentTypeImage, ImageURL: "https://example.com/cat.jpg"},
+		}}},
+		Tools:  []types.Tool{{Name: "lookup", Parameters: types.JSONSchema{Type: "object"}}},
+		Audio:  &types.AudioConfig{},
+		Stream: true,
+	}).WithJSONSchema("caption", types.JSONSchema{
+		Type:       "object",
+		Properties: map[string]types.JSONSchema{"caption": {Type: "string"}},
+	})
+
+	c := New(provider.WithAPIKey("test"))
+	var buf bytes.Buffer
+	if err := c.WriteBatchInput(&buf, slices.Values([]provider.BatchRequest{{CustomID: "req-1", Request: req}})); err != nil {
+		t.Fatal(err)
+	}
+
+	var line struct {
+		Body ChatCompletionRequest `json:"body"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatal(err)
+	}
+	body := line.Body
+	if rf := body.ResponseFormat; rf == nil || rf.Type != "json_schema" || rf.JSONSchema == nil || rf.JSONSchema.Name != "caption" {
+		t.Errorf("response format = %+v", rf)
+	}
+	if len(body.Tools) != 1 || body.Tools[0].Function.Name != "lookup" {
+		t.Errorf("tools = %+v", body.Tools)
+	}
+	parts, _ := json.Marshal(body.Messages[0].Content)
+	if !strings.Contains(string(parts), `"image_url":{"url":"https://example.com/cat.jpg"`) {
+		t.Errorf("content = %s, want the image", parts)
+	}
+	// Batches don't stream.
+	if body.Stream || body.StreamOptions != nil || body.Audio == nil || body.Audio.Format != "wav" {
+		t.Errorf("stream = %v, stream options = %+v, audio = %+v", body.Stream, body.StreamOptions, body.Audio)
+	}
+	if req.Stream != true {
+		t.Error("the caller's request was modified")
+	}
+}
+
+func TestGetBatchResults_StructuredAndToolCalls(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/batches/batch_1":
+			fmt.Fprint(w, `{"id":"batch_1","status":"completed","output_file_id":"file_1"}`)
+		case "/files/file_1/content":
+			fmt.Fprintln(w, `{"custom_id":"json","response":{"status_code":200,"body":{"id":"c1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"{\"caption\":\"A cat\"}"},"finish_reason":"stop"}]}}}`)
+			fmt.Fprintln(w, `{"custom_id":"tool","response":{"status_code":200,"body":{"id":"c2","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"cat\"}"}}]},"finish_reason":"tool_calls"}]}}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+	results, err := c.GetBatchResults(context.Background(), "batch_1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 2 {
+		t.Fatalf("got %d results, want 2", len(results))
+	}
+	if text := results[0].Response.Text(); text != `{"caption":"A cat"}` {
+		t.Errorf("json text = %q", text)
+	}
+	resp := results[1].Response
+	if len(resp.ToolCalls) != 1 {
+		t.Fatalf("tool calls = %+v", resp.ToolCalls)
+	}
+	if input, _ := resp.ToolCalls[0].Input.(map[string]any); resp.ToolCalls[0].Name != "lookup" || input["q"] != "cat" || resp.StopReason != types.StopReasonToolUse {
+		t.Errorf("tool call = %+v, stop reason %s", resp.ToolCalls[0], resp.StopReason)
+	}
+}
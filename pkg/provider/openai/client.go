@@ -2,7 +2,6 @@
 package openai
 
 import (
-	"bufio"
 	"bytes"
 	"context"
 	"encoding/json"
@@ -38,16 +37,9 @@ func New(opts ...provider.Option) *Client {
 		baseURL = cfg.BaseURL
 	}
 
-	httpClient := cfg.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: time.Duration(cfg.Timeout) * time.Second,
-		}
-	}
-
 	return &Client{
 		config:      cfg,
-		httpClient:  httpClient,
+		httpClient:  provider.NewHTTPClient(cfg),
 		baseURL:     baseURL,
 		transformer: NewTransformer(),
 	}
@@ -66,7 +58,11 @@ func (c *Client) SupportsFeature(feature types.Feature) bool {
 		types.FeatureTools,
 		types.FeatureVision,
 		types.FeatureBatch,
-		types.FeatureJSON:
+		types.FeatureJSON,
+		types.FeatureEmbeddings,
+		types.FeatureSeed,
+		types.FeatureAudioOutput,
+		types.FeatureCandidates:
 		return true
 	default:
 		return false
@@ -87,6 +83,44 @@ func (c *Client) Models() []string {
 	}
 }
 
+// ListModels fetches the models available to the API key. OpenAI reports
+// only IDs and creation times.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+
+	models := make([]provider.ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		models[i] = provider.ModelInfo{
+			ID:        m.ID,
+			Provider:  types.ProviderOpenAI,
+			CreatedAt: time.Unix(m.Created, 0),
+			Metadata:  map[string]any{"owned_by": m.OwnedBy},
+		}
+	}
+
+	return models, nil
+}
+
 // Complete sends a completion request.
 func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
 	oaiReq := c.transformer.TransformRequest(req)
@@ -103,10 +137,11 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 	}
 
 	c.setHeaders(httpReq)
+	setClientRequestID(httpReq, req.RequestID)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -119,7 +154,23 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
 	}
 
-	return c.transformer.TransformResponse(&oaiResp), nil
+	result := c.transformer.TransformResponse(&oaiResp)
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderOpenAI, "response has no choices")
+	}
+	if result.StopReason == types.StopReasonContentFilter {
+		return nil, errors.ErrContentFilter(types.ProviderOpenAI, oaiResp.Choices[0].FinishReason, nil, result.Text())
+	}
+	if req.Audio != nil {
+		format := audioFormat(req.Audio, false)
+		for i := range result.Content {
+			if result.Content[i].Type == types.ContentTypeAudio {
+				result.Content[i].AudioFormat = format
+			}
+		}
+	}
+	result.ProviderMetadata.RequestID = provider.RequestID(resp.Header)
+	return result, nil
 }
 
 // Stream sends a streaming completion request.
@@ -127,6 +178,9 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 	oaiReq := c.transformer.TransformRequest(req)
 	oaiReq.Stream = true
 	oaiReq.StreamOptions = &StreamOptions{IncludeUsage: true}
+	if oaiReq.Audio != nil {
+		oaiReq.Audio.Format = audioFormat(req.Audio, true)
+	}
 
 	body, err := json.Marshal(oaiReq)
 	if err != nil {
@@ -139,10 +193,11 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 	}
 
 	c.setHeaders(httpReq)
+	setClientRequestID(httpReq, req.RequestID)
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderOpenAI)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderOpenAI, "request failed").WithCause(err)
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -150,7 +205,12 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	return newStreamReader(resp.Body, c.transformer), nil
+	stream := newStreamReader(ctx, resp.Body, c.transformer)
+	stream.meta.RequestID = provider.RequestID(resp.Header)
+	if oaiReq.Audio != nil {
+		stream.audioFormat = oaiReq.Audio.Format
+	}
+	return stream, nil
 }
 
 // setHeaders sets the required headers for OpenAI API requests.
@@ -159,27 +219,43 @@ func (c *Client) setHeaders(req *http.Request) {
 	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
 }
 
+// setClientRequestID sends the router's request ID as X-Client-Request-Id,
+// which OpenAI logs with the request for support lookups.
+func setClientRequestID(req *http.Request, id string) {
+	if id != "" {
+		req.Header.Set("X-Client-Request-Id", id)
+	}
+}
+
 // handleErrorResponse converts an error response to a RouterError.
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
+	rerr := errors.ErrServerError(types.ProviderOpenAI, string(body)).WithStatusCode(resp.StatusCode)
 	var errResp ErrorResponse
 	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-		return c.mapAPIError(errResp.Error, resp.StatusCode)
+		rerr = c.mapAPIError(errResp.Error, resp.StatusCode)
 	}
-
-	return errors.ErrServerError(types.ProviderOpenAI, string(body)).WithStatusCode(resp.StatusCode)
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
 }
 
-// mapAPIError maps OpenAI API error to RouterError.
-func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
+// mapAPIError maps OpenAI API error to RouterError. A 429 is either a rate
+// limit or, with code insufficient_quota, an account out of credits.
+func (c *Client) mapAPIError(apiErr *APIError, statusCode int) *errors.RouterError {
 	switch statusCode {
 	case http.StatusUnauthorized:
 		return errors.ErrInvalidAPIKey(types.ProviderOpenAI).WithStatusCode(statusCode)
+	case http.StatusForbidden:
+		return errors.ErrAuthentication(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusTooManyRequests:
+		if apiErr.Code == "insufficient_quota" || apiErr.Type == "insufficient_quota" {
+			return errors.ErrQuotaExceeded(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
+		}
 		return errors.ErrRateLimit(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusNotFound:
 		return errors.ErrModelNotFound(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusServiceUnavailable:
+		return errors.ErrOverloaded(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
 	case http.StatusBadRequest:
 		if strings.Contains(apiErr.Message, "context_length") {
 			return errors.ErrContextLength(types.ProviderOpenAI, apiErr.Message).WithStatusCode(statusCode)
@@ -190,223 +266,16 @@ func (c *Client) mapAPIError(apiErr *APIError, statusCode int) error {
 	}
 }
 
-// streamReader implements types.StreamReader for OpenAI.
-type streamReader struct {
-	reader      *bufio.Reader
-	body        io.ReadCloser
-	transformer *Transformer
-	response    *types.CompletionResponse
-	done        bool
-
-	// Accumulated state
-	id         string
-	model      string
-	content    strings.Builder
-	toolCalls  map[int]*types.ToolCall  // index -> tool call
-	toolInputs map[int]*strings.Builder // index -> accumulated arguments
-	usage      *types.Usage
-	stopReason types.StopReason
-}
-
-func newStreamReader(body io.ReadCloser, transformer *Transformer) *streamReader {
-	return &streamReader{
-		reader:      bufio.NewReader(body),
-		body:        body,
-		transformer: transformer,
-		toolCalls:   make(map[int]*types.ToolCall),
-		toolInputs:  make(map[int]*strings.Builder),
-	}
-}
-
-// Next returns the next stream event.
-func (s *streamReader) Next() (*types.StreamEvent, error) {
-	if s.done {
-		return nil, nil
-	}
-
-	for {
-		line, err := s.reader.ReadString('\n')
-		if err != nil {
-			if err == io.EOF {
-				s.done = true
-				s.buildResponse()
-				return nil, nil
-			}
-			return nil, err
-		}
-
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-
-		if !strings.HasPrefix(line, "data: ") {
-			continue
-		}
-
-		data := strings.TrimPrefix(line, "data: ")
-		if data == "[DONE]" {
-			s.done = true
-			s.buildResponse()
-			return &types.StreamEvent{
-				Type:       types.StreamEventDone,
-				Usage:      s.usage,
-				StopReason: s.stopReason,
-				ResponseID: s.id,
-			}, nil
-		}
-
-		var chunk StreamChunk
-		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
-			continue
-		}
-
-		event := s.processChunk(&chunk)
-		if event != nil {
-			return event, nil
-		}
-	}
-}
-
-// processChunk processes a stream chunk and returns an event if applicable.
-func (s *streamReader) processChunk(chunk *StreamChunk) *types.StreamEvent {
-	// Store metadata
-	if s.id == "" {
-		s.id = chunk.ID
-	}
-	if s.model == "" {
-		s.model = chunk.Model
-	}
-
-	// Handle usage (comes with final chunk)
-	if chunk.Usage != nil {
-		s.usage = &types.Usage{
-			InputTokens:  chunk.Usage.PromptTokens,
-			OutputTokens: chunk.Usage.CompletionTokens,
-			TotalTokens:  chunk.Usage.TotalTokens,
-		}
-	}
-
-	if len(chunk.Choices) == 0 {
-		return nil
-	}
-
-	choice := chunk.Choices[0]
-	delta := choice.Delta
-
-	// Handle finish reason
-	if choice.FinishReason != "" {
-		s.stopReason = s.transformer.transformStopReason(choice.FinishReason)
-	}
-
-	// Handle content delta
-	if delta.Content != "" {
-		s.content.WriteString(delta.Content)
-		return &types.StreamEvent{
-			Type: types.StreamEventContentDelta,
-			Delta: &types.ContentBlock{
-				Type: types.ContentTypeText,
-				Text: delta.Content,
-			},
-			Index: 0,
-		}
-	}
-
-	// Handle tool calls
-	for _, tc := range delta.ToolCalls {
-		idx := 0
-		if tc.Index != nil {
-			idx = *tc.Index
-		}
-
-		// New tool call
-		if tc.ID != "" {
-			s.toolCalls[idx] = &types.ToolCall{
-				ID:   tc.ID,
-				Name: tc.Function.Name,
-			}
-			s.toolInputs[idx] = &strings.Builder{}
-
-			return &types.StreamEvent{
-				Type: types.StreamEventToolCallStart,
-				ToolCall: &types.ToolCall{
-					ID:   tc.ID,
-					Name: tc.Function.Name,
-				},
-			}
-		}
-
-		// Tool call arguments delta
-		if tc.Function.Arguments != "" {
-			if builder, ok := s.toolInputs[idx]; ok {
-				builder.WriteString(tc.Function.Arguments)
-			}
-
-			return &types.StreamEvent{
-				Type:           types.StreamEventToolCallDelta,
-				ToolInputDelta: tc.Function.Arguments,
-				Index:          idx,
-			}
-		}
-	}
-
-	return nil
-}
-
-// buildResponse builds the final response from accumulated state.
-func (s *streamReader) buildResponse() {
-	var content []types.ContentBlock
-
-	// Add text content
-	if s.content.Len() > 0 {
-		content = append(content, types.ContentBlock{
-			Type: types.ContentTypeText,
-			Text: s.content.String(),
-		})
-	}
-
-	// Finalize tool calls
-	var toolCalls []types.ToolCall
-	for idx, tc := range s.toolCalls {
-		if builder, ok := s.toolInputs[idx]; ok {
-			var input any
-			json.Unmarshal([]byte(builder.String()), &input)
-			tc.Input = input
-		}
-		toolCalls = append(toolCalls, *tc)
-
-		content = append(content, types.ContentBlock{
-			Type:      types.ContentTypeToolUse,
-			ToolUseID: tc.ID,
-			ToolName:  tc.Name,
-			ToolInput: tc.Input,
-		})
-	}
-
-	s.response = &types.CompletionResponse{
-		ID:         s.id,
-		Provider:   types.ProviderOpenAI,
-		Model:      s.model,
-		Content:    content,
-		StopReason: s.stopReason,
-		ToolCalls:  toolCalls,
-		CreatedAt:  time.Now(),
-	}
-
-	if s.usage != nil {
-		s.response.Usage = *s.usage
-	}
-}
-
-// Close closes the stream.
-func (s *streamReader) Close() error {
-	return s.body.Close()
-}
-
-// Response returns the accumulated response.
-func (s *streamReader) Response() *types.CompletionResponse {
-	return s.response
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
 }
 
 // Ensure Client implements provider.Provider
 var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
@@ -0,0 +1,355 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestHandleErrorResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		code       string
+		retryAfter time.Duration
+	}{
+		{"rate limit", 429, `{"error":{"message":"Rate limit reached","type":"requests","code":"rate_limit_exceeded"}}`, errors.ErrCodeRateLimit, 1500 * time.Millisecond},
+		{"insufficient quota", 429, `{"error":{"message":"You exceeded your current quota","type":"insufficient_quota","code":"insufficient_quota"}}`, errors.ErrCodeQuotaExceeded, 1500 * time.Millisecond},
+		{"forbidden", 403, `{"error":{"message":"Country, region, or territory not supported","type":"request_forbidden"}}`, errors.ErrCodeAuthentication, 0},
+		{"overloaded", 503, `{"error":{"message":"The engine is currently overloaded","type":"server_error"}}`, errors.ErrCodeOverloaded, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			if tt.retryAfter > 0 {
+				header.Set("retry-after-ms", "1500")
+			}
+			err := New(provider.WithAPIKey("key")).handleErrorResponse(&http.Response{
+				StatusCode: tt.status,
+				Header:     header,
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			})
+
+			var rerr *errors.RouterError
+			if !stderrors.As(err, &rerr) || rerr.Code != tt.code || rerr.StatusCode != tt.status {
+				t.Fatalf("err = %#v, want code %s", err, tt.code)
+			}
+			if got := errors.RetryAfter(err); got != tt.retryAfter {
+				t.Errorf("retry after = %v, want %v", got, tt.retryAfter)
+			}
+			if tt.code == errors.ErrCodeQuotaExceeded && errors.IsRetryable(err) {
+				t.Error("insufficient quota should not be retryable")
+			}
+		})
+	}
+}
+
+func TestProviderMetadata(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.Header().Set("x-request-id", "req_abc123")
+		if body.Stream {
+			fmt.Fprint(w, `data: {"id":"c1","model":"gpt-4o","system_fingerprint":"fp_44709d6fcb","service_tier":"default","choices":[{"index":0,"delta":{"content":"Hi"},"finish_reason":"stop"}]}`+"\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		fmt.Fprint(w, `{"id":"c1","model":"gpt-4o","system_fingerprint":"fp_44709d6fcb","service_tier":"default","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("key"), provider.WithBaseURL(server.URL))
+	req := &types.CompletionRequest{Model: "gpt-4o", Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")}}
+	want := types.ProviderMetadata{RequestID: "req_abc123", SystemFingerprint: "fp_44709d6fcb", ServiceTier: "default"}
+
+	resp, err := client.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ProviderMetadata != want {
+		t.Errorf("complete metadata = %+v, want %+v", resp.ProviderMetadata, want)
+	}
+
+	stream, err := client.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+	}
+	if got := stream.Response().ProviderMetadata; got != want {
+		t.Errorf("stream metadata = %+v, want %+v", got, want)
+	}
+}
+
+// benchmarkStream is a stream of 1000 text deltas.
+var benchmarkStream = func() string {
+	var b strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&b, "data: {\"id\":\"chatcmpl-1\",\"object\":\"chat.completion.chunk\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"token %d \"}}]}\n\n", i)
+	}
+	b.WriteString("data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\n")
+	b.WriteString("data: [DONE]\n\n")
+	return b.String()
+}()
+
+func BenchmarkStreamReader(b *testing.B) {
+	b.ReportAllocs()
+	b.SetBytes(int64(len(benchmarkStream)))
+	for b.Loop() {
+		stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(benchmarkStream)), NewTransformer())
+		for {
+			event, err := stream.Next()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if event == nil || event.Type == types.StreamEventDone {
+				break
+			}
+		}
+		stream.Close()
+	}
+}
+
+func TestStreamReader_ToolCalls(t *testing.T) {
+	// The first call's ID and arguments share a chunk, and the second call
+	// starts in the same chunk as the last of the first's arguments.
+	body := `data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","tool_calls":[{"index":0,"id":"call_a","type":"function","function":{"name":"get_weather","arguments":"{\"city\":"}}]}}]}
+
+data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"tool_calls":[{"index":0,"function":{"arguments":"\"Paris\"}"}},{"index":1,"id":"call_b","type":"function","function":{"name":"get_time","arguments":"{}"}}]}}]}
+
+data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{},"finish_reason":"tool_calls"}]}
+
+data: [DONE]
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(body)), NewTransformer())
+	defer stream.Close()
+
+	var got []string
+	ends := map[int]*types.ToolCall{}
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventToolCallStart, types.StreamEventToolCallDelta, types.StreamEventToolCallEnd:
+			got = append(got, fmt.Sprintf("%s:%d", event.Type, event.Index))
+			if event.Type == types.StreamEventToolCallEnd {
+				ends[event.Index] = event.ToolCall
+			}
+		}
+	}
+
+	want := []string{
+		"tool_call_start:0", "tool_call_delta:0",
+		"tool_call_delta:0",
+		"tool_call_end:0", "tool_call_start:1", "tool_call_delta:1",
+		"tool_call_end:1",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if input, _ := ends[0].Input.(map[string]any); ends[0].ID != "call_a" || input["city"] != "Paris" {
+		t.Errorf("first call = %+v, want call_a with the parsed city", ends[0])
+	}
+	if ends[1].ID != "call_b" || ends[1].Name != "get_time" {
+		t.Errorf("second call = %+v, want call_b", ends[1])
+	}
+
+	resp := stream.Response()
+	if len(resp.ToolCalls) != 2 || resp.ToolCalls[0].ID != "call_a" || resp.ToolCalls[1].ID != "call_b" {
+		t.Fatalf("tool calls = %+v, want call_a then call_b", resp.ToolCalls)
+	}
+}
+
+func TestStreamReader_Audio(t *testing.T) {
+	// Each chunk's data is encoded on its own.
+	body := `data: {"id":"c1","model":"gpt-4o-audio-preview","choices":[{"index":0,"delta":{"role":"assistant","audio":{"id":"audio_1","transcript":"Hel"}}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"audio":{"data":"AAEC"}}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"audio":{"data":"AwQ=","transcript":"lo"}}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(body)), NewTransformer())
+	stream.audioFormat = "pcm16"
+	defer stream.Close()
+
+	var deltas []types.ContentBlock
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventAudioDelta {
+			deltas = append(deltas, *event.Delta)
+		}
+	}
+
+	if len(deltas) != 3 || deltas[1].AudioBase64 != "AAEC" || deltas[1].AudioID != "audio_1" || deltas[1].AudioFormat != "pcm16" {
+		t.Fatalf("audio deltas = %+v, want three chunks of audio_1", deltas)
+	}
+
+	resp := stream.Response()
+	if len(resp.Content) != 1 || resp.Content[0].AudioBase64 != "AAECAwQ=" || resp.Content[0].AudioFormat != "pcm16" {
+		t.Fatalf("content = %+v, want the joined pcm16 audio", resp.Content)
+	}
+	if resp.Transcript() != "Hello" {
+		t.Errorf("transcript = %q, want Hello", resp.Transcript())
+	}
+}
+
+func TestStreamReader_Refusal(t *testing.T) {
+	body := `data: {"id":"c1","model":"gpt-4o","choices":[{"index":0,"delta":{"role":"assistant","refusal":"I can't "}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"refusal":"help with that."}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(body)), NewTransformer())
+	defer stream.Close()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventContentDelta {
+			t.Errorf("refusal streamed as content: %q", event.Delta.Text)
+		}
+	}
+
+	resp := stream.Response()
+	if resp.StopReason != types.StopReasonRefusal || resp.Refusal != "I can't help with that." {
+		t.Errorf("got stop reason %q, refusal %q", resp.StopReason, resp.Refusal)
+	}
+}
+
+func TestStreamReader_ReasoningWithContent(t *testing.T) {
+	// The last reasoning text arrives in the same chunk as the first content.
+	body := `data: {"id":"c1","model":"o4-mini","choices":[{"index":0,"delta":{"role":"assistant","reasoning_content":"Think"}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"reasoning_content":"ing.","content":"Hel"}}]}
+
+data: {"id":"c1","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}
+
+data: [DONE]
+
+`
+	stream := newStreamReader(context.Background(), io.NopCloser(strings.NewReader(body)), NewTransformer())
+	defer stream.Close()
+
+	var got []string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventThinkingDelta, types.StreamEventContentDelta:
+			got = append(got, fmt.Sprintf("%s:%s", event.Type, event.Delta.Text))
+		}
+	}
+
+	want := []string{
+		"thinking_delta:Think",
+		"thinking_delta:ing.", "content_delta:Hel",
+		"content_delta:lo",
+	}
+	if !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if text := stream.Response().Text(); text != "Hello" {
+		t.Errorf("text = %q, want Hello", text)
+	}
+}
+
+func TestStream_StructuredOutput(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req ChatCompletionRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.Stream || req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.JSONSchema == nil {
+			t.Errorf("request = %+v, want a stream with the response format", req)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, delta := range []string{`{\"name\":`, `\"Ann\",\"age\"`, `:42}`} {
+			fmt.Fprintf(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"%s\"}}]}\n\n", delta)
+		}
+		fmt.Fprint(w, "data: {\"id\":\"c1\",\"choices\":[{\"index\":0,\"delta\":{},\"finish_reason\":\"stop\"}]}\n\ndata: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	person := types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"name": {Type: "string"},
+			"age":  {Type: "integer"},
+		},
+		Required: []string{"name", "age"},
+	}
+	req := (&types.CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Extract: Ann is 42.")},
+	}).WithJSONSchema("person", person)
+
+	client := New(provider.WithAPIKey("sk-test"), provider.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for event, err := stream.Next(); event != nil || err != nil; event, err = stream.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	text := stream.Response().Text()
+	if err := schema.Validate(&person, []byte(text)); err != nil {
+		t.Fatalf("accumulated text %q does not validate: %v", text, err)
+	}
+}
@@ -0,0 +1,84 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Limits of the embeddings endpoint per request.
+const (
+	maxEmbeddingInputs = 2048
+	maxEmbeddingTokens = 300000
+)
+
+// Embed embeds texts with the embeddings endpoint.
+func (c *Client) Embed(ctx context.Context, req *types.EmbeddingRequest) (*types.EmbeddingResponse, error) {
+	body, err := json.Marshal(EmbeddingRequest{
+		Model:      req.Model,
+		Input:      req.Input,
+		Dimensions: req.Dimensions,
+	})
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/embeddings", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var embResp EmbeddingResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embResp); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+	if len(embResp.Data) != len(req.Input) {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "embeddings response does not match the inputs")
+	}
+
+	// Data is not guaranteed to be in input order
+	result := &types.EmbeddingResponse{
+		Provider:   types.ProviderOpenAI,
+		Model:      embResp.Model,
+		Embeddings: make([][]float64, len(req.Input)),
+	}
+	for _, d := range embResp.Data {
+		if d.Index < 0 || d.Index >= len(req.Input) {
+			return nil, errors.ErrServerError(types.ProviderOpenAI, "embedding index out of range")
+		}
+		result.Embeddings[d.Index] = d.Embedding
+	}
+	if embResp.Usage != nil {
+		result.Usage = types.Usage{
+			InputTokens: embResp.Usage.PromptTokens,
+			TotalTokens: embResp.Usage.TotalTokens,
+		}
+	}
+
+	return result, nil
+}
+
+// EmbeddingLimits returns the limits of the embeddings endpoint.
+func (c *Client) EmbeddingLimits() provider.EmbeddingLimits {
+	return provider.EmbeddingLimits{MaxInputs: maxEmbeddingInputs, MaxTokens: maxEmbeddingTokens}
+}
+
+// Ensure Client implements provider.Embedder
+var _ provider.Embedder = (*Client)(nil)
@@ -0,0 +1,324 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Fine-tuning types
+
+// FineTuningJobRequest is the request to create a fine-tuning job.
+type FineTuningJobRequest struct {
+	Model           string                 `json:"model"`
+	TrainingFile    string                 `json:"training_file"`
+	ValidationFile  string                 `json:"validation_file,omitempty"`
+	Suffix          string                 `json:"suffix,omitempty"`
+	Hyperparameters *FineTuningHyperparams `json:"hyperparameters,omitempty"`
+}
+
+// FineTuningHyperparams are the tunable hyperparameters of a job.
+type FineTuningHyperparams struct {
+	NEpochs                int     `json:"n_epochs,omitempty"`
+	BatchSize              int     `json:"batch_size,omitempty"`
+	LearningRateMultiplier float64 `json:"learning_rate_multiplier,omitempty"`
+}
+
+// FineTuningJob is the OpenAI fine-tuning job object.
+type FineTuningJob struct {
+	ID             string           `json:"id"`
+	Object         string           `json:"object"`
+	Model          string           `json:"model"`
+	CreatedAt      int64            `json:"created_at"`
+	FinishedAt     *int64           `json:"finished_at,omitempty"`
+	FineTunedModel string           `json:"fine_tuned_model,omitempty"`
+	Status         string           `json:"status"`
+	TrainingFile   string           `json:"training_file"`
+	ValidationFile string           `json:"validation_file,omitempty"`
+	TrainedTokens  int              `json:"trained_tokens,omitempty"`
+	Error          *FineTuningError `json:"error,omitempty"`
+}
+
+// FineTuningError describes why a job failed.
+type FineTuningError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Param   string `json:"param,omitempty"`
+}
+
+// FineTuningJobList is the response from listing fine-tuning jobs.
+type FineTuningJobList struct {
+	Object  string          `json:"object"`
+	Data    []FineTuningJob `json:"data"`
+	HasMore bool            `json:"has_more"`
+}
+
+// FineTuningCheckpoint is a checkpoint of a fine-tuning job.
+type FineTuningCheckpoint struct {
+	ID                       string             `json:"id"`
+	CreatedAt                int64              `json:"created_at"`
+	FineTunedModelCheckpoint string             `json:"fine_tuned_model_checkpoint"`
+	StepNumber               int                `json:"step_number"`
+	Metrics                  map[string]float64 `json:"metrics"`
+}
+
+// FineTuningCheckpointList is the response from listing checkpoints.
+type FineTuningCheckpointList struct {
+	Data    []FineTuningCheckpoint `json:"data"`
+	HasMore bool                   `json:"has_more"`
+}
+
+// CreateFineTuneJob creates a fine-tuning job. TrainingData, if set, is
+// uploaded as a fine-tune file first.
+func (c *Client) CreateFineTuneJob(ctx context.Context, req *provider.FineTuneRequest) (*provider.FineTuneJob, error) {
+	trainingFile := req.TrainingFile
+	if trainingFile == "" {
+		if len(req.TrainingData) == 0 {
+			return nil, errors.ErrInvalidRequest("training file or training data is required").WithProvider(types.ProviderOpenAI)
+		}
+		fileID, err := c.UploadTrainingFile(ctx, req.TrainingData)
+		if err != nil {
+			return nil, err
+		}
+		trainingFile = fileID
+	}
+
+	jobReq := FineTuningJobRequest{
+		Model:          req.Model,
+		TrainingFile:   trainingFile,
+		ValidationFile: req.ValidationFile,
+		Suffix:         req.Suffix,
+	}
+	if req.Epochs > 0 || req.BatchSize > 0 || req.LearningRate > 0 {
+		jobReq.Hyperparameters = &FineTuningHyperparams{
+			NEpochs:                req.Epochs,
+			BatchSize:              req.BatchSize,
+			LearningRateMultiplier: req.LearningRate,
+		}
+	}
+
+	body, err := json.Marshal(jobReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/fine_tuning/jobs", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	return c.doFineTuningJob(httpReq)
+}
+
+// UploadTrainingFile uploads examples as a JSONL file with the "fine-tune"
+// purpose and returns the file ID.
+func (c *Client) UploadTrainingFile(ctx context.Context, examples []provider.TrainingExample) (string, error) {
+	var buffer bytes.Buffer
+	for _, ex := range examples {
+		line := struct {
+			Messages []ChatMessage `json:"messages"`
+		}{
+			Messages: c.transformer.transformMessages(ex.Messages),
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			return "", errors.ErrInvalidRequest("failed to marshal training example").WithCause(err)
+		}
+		buffer.Write(data)
+		buffer.WriteByte('\n')
+	}
+
+	return c.UploadFile(ctx, "fine-tune", "training.jsonl", &buffer, int64(buffer.Len()))
+}
+
+// GetFineTuneJob retrieves a fine-tuning job.
+func (c *Client) GetFineTuneJob(ctx context.Context, jobID string) (*provider.FineTuneJob, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/fine_tuning/jobs/"+jobID, nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	return c.doFineTuningJob(httpReq)
+}
+
+// ListFineTuneJobs lists fine-tuning jobs.
+func (c *Client) ListFineTuneJobs(ctx context.Context, opts *provider.ListFineTuneOptions) ([]provider.FineTuneJob, error) {
+	endpoint := c.baseURL + "/fine_tuning/jobs"
+	if opts != nil {
+		params := url.Values{}
+		if opts.Limit > 0 {
+			params.Set("limit", strconv.Itoa(opts.Limit))
+		}
+		if opts.After != "" {
+			params.Set("after", opts.After)
+		}
+		if len(params) > 0 {
+			endpoint += "?" + params.Encode()
+		}
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list FineTuningJobList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+
+	jobs := make([]provider.FineTuneJob, len(list.Data))
+	for i, job := range list.Data {
+		jobs[i] = *c.convertFineTuningJob(&job)
+	}
+
+	return jobs, nil
+}
+
+// CancelFineTuneJob cancels a fine-tuning job.
+func (c *Client) CancelFineTuneJob(ctx context.Context, jobID string) error {
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/fine_tuning/jobs/"+jobID+"/cancel", nil)
+	if err != nil {
+		return errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	_, err = c.doFineTuningJob(httpReq)
+	return err
+}
+
+// ListFineTuneCheckpoints lists the checkpoints of a fine-tuning job.
+func (c *Client) ListFineTuneCheckpoints(ctx context.Context, jobID string) ([]provider.FineTuneCheckpoint, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/fine_tuning/jobs/"+jobID+"/checkpoints", nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list FineTuningCheckpointList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+
+	checkpoints := make([]provider.FineTuneCheckpoint, len(list.Data))
+	for i, cp := range list.Data {
+		checkpoints[i] = provider.FineTuneCheckpoint{
+			ID:             cp.ID,
+			Step:           cp.StepNumber,
+			FineTunedModel: cp.FineTunedModelCheckpoint,
+			CreatedAt:      time.Unix(cp.CreatedAt, 0),
+			Metrics:        cp.Metrics,
+		}
+	}
+
+	return checkpoints, nil
+}
+
+// doFineTuningJob sends a request that returns a fine-tuning job object.
+func (c *Client) doFineTuningJob(httpReq *http.Request) (*provider.FineTuneJob, error) {
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var job FineTuningJob
+	if err := json.NewDecoder(resp.Body).Decode(&job); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+
+	return c.convertFineTuningJob(&job), nil
+}
+
+// convertFineTuningJob converts an OpenAI fine-tuning job to a provider FineTuneJob.
+func (c *Client) convertFineTuningJob(job *FineTuningJob) *provider.FineTuneJob {
+	result := &provider.FineTuneJob{
+		ID:             job.ID,
+		Provider:       types.ProviderOpenAI,
+		Status:         c.convertFineTuningStatus(job.Status),
+		Model:          job.Model,
+		FineTunedModel: job.FineTunedModel,
+		CreatedAt:      time.Unix(job.CreatedAt, 0),
+		Metadata: map[string]any{
+			"training_file": job.TrainingFile,
+			"status":        job.Status,
+		},
+	}
+
+	if job.FinishedAt != nil {
+		t := time.Unix(*job.FinishedAt, 0)
+		result.FinishedAt = &t
+	}
+	if job.Error != nil {
+		result.Error = job.Error.Message
+	}
+	if job.TrainedTokens > 0 {
+		result.Metadata["trained_tokens"] = job.TrainedTokens
+	}
+
+	return result
+}
+
+// convertFineTuningStatus converts an OpenAI job status to a provider status.
+func (c *Client) convertFineTuningStatus(status string) provider.FineTuneStatus {
+	switch status {
+	case "validating_files", "queued":
+		return provider.FineTuneStatusPending
+	case "running":
+		return provider.FineTuneStatusRunning
+	case "succeeded":
+		return provider.FineTuneStatusSucceeded
+	case "failed":
+		return provider.FineTuneStatusFailed
+	case "cancelled":
+		return provider.FineTuneStatusCancelled
+	default:
+		return provider.FineTuneStatusPending
+	}
+}
+
+// Ensure Client implements provider.FineTuneProvider
+var _ provider.FineTuneProvider = (*Client)(nil)
+
+// Ensure Client implements provider.TrainingFileUploader
+var _ provider.TrainingFileUploader = (*Client)(nil)
@@ -0,0 +1,68 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCreateFineTuneJob_UploadsTrainingData(t *testing.T) {
+	var upload string
+	var jobReq FineTuningJobRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/files":
+			body, _ := io.ReadAll(r.Body)
+			upload = string(body)
+			fmt.Fprint(w, `{"id":"file_train"}`)
+		case "/fine_tuning/jobs":
+			json.NewDecoder(r.Body).Decode(&jobReq)
+			fmt.Fprint(w, `{"id":"ftjob_1","model":"gpt-4o-mini","created_at":1700000000,"status":"validating_files","training_file":"file_train"}`)
+		case "/fine_tuning/jobs/ftjob_1/checkpoints":
+			fmt.Fprint(w, `{"data":[{"id":"ckpt_1","created_at":1700000100,"fine_tuned_model_checkpoint":"ft:gpt-4o-mini:org::ckpt-step-10","step_number":10,"metrics":{"train_loss":0.5}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	c := New(provider.WithAPIKey("test"), provider.WithBaseURL(srv.URL))
+
+	job, err := c.CreateFineTuneJob(context.Background(), &provider.FineTuneRequest{
+		Model: "gpt-4o-mini",
+		TrainingData: []provider.TrainingExample{{Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "hi"),
+			types.NewTextMessage(types.RoleAssistant, "hello"),
+		}}},
+		Epochs: 3,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(upload, "fine-tune\r\n") || !strings.Contains(upload, `"content":"hello"`) {
+		t.Errorf("expected fine-tune upload with training messages, got %q", upload)
+	}
+	if jobReq.TrainingFile != "file_train" || jobReq.Hyperparameters == nil || jobReq.Hyperparameters.NEpochs != 3 {
+		t.Errorf("unexpected job request: %+v", jobReq)
+	}
+	if job.ID != "ftjob_1" || job.Status != provider.FineTuneStatusPending {
+		t.Errorf("unexpected job: %+v", job)
+	}
+
+	checkpoints, err := c.ListFineTuneCheckpoints(context.Background(), job.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(checkpoints) != 1 || checkpoints[0].Step != 10 || checkpoints[0].Metrics["train_loss"] != 0.5 {
+		t.Errorf("unexpected checkpoints: %+v", checkpoints)
+	}
+}
@@ -0,0 +1,50 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// moderationModel is the model used by Moderate.
+const moderationModel = "omni-moderation-latest"
+
+// Moderate classifies text with the moderations endpoint.
+func (c *Client) Moderate(ctx context.Context, input string) (*ModerationResult, error) {
+	body, err := json.Marshal(ModerationRequest{Model: moderationModel, Input: input})
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/moderations", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var modResp ModerationResponse
+	if err := json.NewDecoder(resp.Body).Decode(&modResp); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "failed to decode response").WithCause(err)
+	}
+	if len(modResp.Results) == 0 {
+		return nil, errors.ErrServerError(types.ProviderOpenAI, "moderation response has no results")
+	}
+
+	return &modResp.Results[0], nil
+}
@@ -0,0 +1,429 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// StreamConfig adapts a StreamReader to an OpenAI-compatible chat
+// completions API, such as DeepSeek's or OpenRouter's.
+type StreamConfig struct {
+	// Provider is the provider named in the response.
+	Provider types.Provider
+
+	// RequestID is the provider's ID of the request, from the response
+	// headers.
+	RequestID string
+
+	// ReasoningField is the delta field carrying reasoning text:
+	// "reasoning_content" or "reasoning". Empty reads both.
+	ReasoningField string
+
+	// StopReason converts a finish reason to unified format.
+	StopReason func(reason string) types.StopReason
+
+	// Chunk, if set, decodes the provider's own fields of a chunk's data.
+	// It returns the chunk's usage, which replaces the OpenAI usage when
+	// not nil, and entries for the response's Metadata.
+	Chunk func(data []byte) (usage *types.Usage, metadata map[string]any)
+}
+
+// StreamReader implements types.StreamReader for OpenAI-compatible chat
+// completion streams. Reasoning deltas are streamed as thinking events and
+// kept as the response's thinking block.
+type StreamReader struct {
+	lines    *provider.LineReader
+	body     *provider.StreamBody
+	config   StreamConfig
+	response *types.CompletionResponse
+	done     bool
+
+	// pending holds events of the last chunk not yet returned by Next.
+	pending []*types.StreamEvent
+
+	// Accumulated state
+	id         string
+	model      string
+	thinking   strings.Builder
+	content    strings.Builder
+	refusal    strings.Builder
+	toolCalls  map[int]*types.ToolCall  // index -> tool call
+	toolInputs map[int]*strings.Builder // index -> accumulated arguments
+	openCalls  []int                    // indexes of tool calls not yet ended, in start order
+	usage      *types.Usage
+
+	// Audio output, decoded chunk by chunk: each chunk is encoded on its
+	// own, so the encoded chunks do not concatenate.
+	audioFormat     string
+	audioID         string
+	audio           []byte
+	audioTranscript strings.Builder
+
+	stopReason types.StopReason
+	meta       types.ProviderMetadata
+	metadata   map[string]any
+}
+
+// NewStreamReader returns a reader of the chat completion stream in body.
+func NewStreamReader(ctx context.Context, body io.ReadCloser, config StreamConfig) *StreamReader {
+	streamBody := provider.NewStreamBody(ctx, body)
+	return &StreamReader{
+		lines:      provider.NewLineReader(streamBody),
+		body:       streamBody,
+		config:     config,
+		toolCalls:  make(map[int]*types.ToolCall),
+		toolInputs: make(map[int]*strings.Builder),
+		meta:       types.ProviderMetadata{RequestID: config.RequestID},
+	}
+}
+
+// newStreamReader returns a reader of an OpenAI stream.
+func newStreamReader(ctx context.Context, body io.ReadCloser, transformer *Transformer) *StreamReader {
+	return NewStreamReader(ctx, body, StreamConfig{
+		Provider:   types.ProviderOpenAI,
+		StopReason: transformer.TransformStopReason,
+	})
+}
+
+// Next returns the next stream event.
+func (s *StreamReader) Next() (*types.StreamEvent, error) {
+	if len(s.pending) > 0 {
+		return s.pop(), nil
+	}
+	if s.done {
+		return nil, nil
+	}
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
+
+	for {
+		line, err := s.lines.Next()
+		if err != nil {
+			if err == io.EOF {
+				s.finish()
+				return s.pop(), nil
+			}
+			return nil, err
+		}
+
+		// Blank lines, and ": keep-alive" comments while the server is
+		// busy, carry no data.
+		data, ok := provider.SSEData(line)
+		if !ok {
+			continue
+		}
+
+		if bytes.Equal(data, doneMarker) {
+			s.finish()
+			s.emit(&types.StreamEvent{
+				Type:       types.StreamEventDone,
+				Usage:      s.usage,
+				StopReason: s.stopReason,
+				ResponseID: s.id,
+			})
+			return s.pop(), nil
+		}
+
+		var chunk StreamChunk
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			continue
+		}
+
+		s.processChunk(&chunk, data)
+		if len(s.pending) > 0 {
+			return s.pop(), nil
+		}
+	}
+}
+
+// doneMarker is the data of the event that ends a stream.
+var doneMarker = []byte("[DONE]")
+
+// emit queues an event for Next.
+func (s *StreamReader) emit(event *types.StreamEvent) {
+	s.pending = append(s.pending, event)
+}
+
+// pop removes and returns the first pending event, or nil if there is none.
+func (s *StreamReader) pop() *types.StreamEvent {
+	if len(s.pending) == 0 {
+		return nil
+	}
+	event := s.pending[0]
+	s.pending = s.pending[1:]
+	return event
+}
+
+// reasoning returns the reasoning text of a delta.
+func (s *StreamReader) reasoning(delta *MessageDelta) string {
+	switch s.config.ReasoningField {
+	case "reasoning_content":
+		return delta.ReasoningContent
+	case "reasoning":
+		return delta.Reasoning
+	default:
+		return delta.ReasoningContent + delta.Reasoning
+	}
+}
+
+// processChunk queues the events of a stream chunk, whose undecoded data
+// is passed to the Chunk hook.
+func (s *StreamReader) processChunk(chunk *StreamChunk, data []byte) {
+	// Store metadata
+	if s.id == "" {
+		s.id = chunk.ID
+	}
+	if s.model == "" {
+		s.model = chunk.Model
+	}
+	if chunk.SystemFingerprint != "" {
+		s.meta.SystemFingerprint = chunk.SystemFingerprint
+	}
+	if chunk.ServiceTier != "" {
+		s.meta.ServiceTier = chunk.ServiceTier
+	}
+
+	// Handle usage (comes with final chunk)
+	if chunk.Usage != nil {
+		usage := transformUsage(chunk.Usage)
+		s.usage = &usage
+	}
+	if s.config.Chunk != nil {
+		usage, metadata := s.config.Chunk(data)
+		if usage != nil {
+			s.usage = usage
+		}
+		if len(metadata) > 0 {
+			if s.metadata == nil {
+				s.metadata = make(map[string]any)
+			}
+			maps.Copy(s.metadata, metadata)
+		}
+	}
+
+	if len(chunk.Choices) == 0 {
+		return
+	}
+
+	choice := chunk.Choices[0]
+	delta := choice.Delta
+
+	// Handle reasoning delta
+	if reasoning := s.reasoning(&delta); reasoning != "" {
+		s.thinking.WriteString(reasoning)
+		s.emit(&types.StreamEvent{
+			Type: types.StreamEventThinkingDelta,
+			Delta: &types.ContentBlock{
+				Type: types.ContentTypeText,
+				Text: reasoning,
+			},
+		})
+	}
+
+	// Handle content delta
+	if delta.Content != "" {
+		s.content.WriteString(delta.Content)
+		s.emit(&types.StreamEvent{
+			Type: types.StreamEventContentDelta,
+			Delta: &types.ContentBlock{
+				Type: types.ContentTypeText,
+				Text: delta.Content,
+			},
+			Index: 0,
+		})
+	}
+
+	// Refusals are not streamed as content; they are in the response.
+	s.refusal.WriteString(delta.Refusal)
+
+	// Handle audio
+	if a := delta.Audio; a != nil {
+		if a.ID != "" {
+			s.audioID = a.ID
+		}
+		if data, err := base64.StdEncoding.DecodeString(a.Data); err == nil {
+			s.audio = append(s.audio, data...)
+		}
+		s.audioTranscript.WriteString(a.Transcript)
+		if a.Data != "" || a.Transcript != "" {
+			s.emit(&types.StreamEvent{
+				Type: types.StreamEventAudioDelta,
+				Delta: &types.ContentBlock{
+					Type:        types.ContentTypeAudio,
+					AudioID:     s.audioID,
+					AudioBase64: a.Data,
+					AudioFormat: s.audioFormat,
+					Text:        a.Transcript,
+				},
+			})
+		}
+	}
+
+	// Handle tool calls
+	for _, tc := range delta.ToolCalls {
+		idx := 0
+		if tc.Index != nil {
+			idx = *tc.Index
+		}
+
+		// New tool call. Calls are streamed one after another, so the
+		// previous ones are complete.
+		if tc.ID != "" {
+			s.endToolCalls(idx)
+			s.toolCalls[idx] = &types.ToolCall{
+				ID:   tc.ID,
+				Name: tc.Function.Name,
+			}
+			s.toolInputs[idx] = &strings.Builder{}
+			s.openCalls = append(s.openCalls, idx)
+
+			s.emit(&types.StreamEvent{
+				Type: types.StreamEventToolCallStart,
+				ToolCall: &types.ToolCall{
+					ID:   tc.ID,
+					Name: tc.Function.Name,
+				},
+				Index: idx,
+			})
+		}
+
+		// Tool call arguments delta
+		if tc.Function.Arguments != "" {
+			if builder, ok := s.toolInputs[idx]; ok {
+				builder.WriteString(tc.Function.Arguments)
+			}
+
+			s.emit(&types.StreamEvent{
+				Type:           types.StreamEventToolCallDelta,
+				ToolInputDelta: tc.Function.Arguments,
+				Index:          idx,
+			})
+		}
+	}
+
+	// Handle finish reason
+	if choice.FinishReason != "" {
+		s.stopReason = s.config.StopReason(choice.FinishReason)
+		s.endToolCalls(-1)
+	}
+}
+
+// endToolCalls parses the input of the open tool calls other than the one at
+// index keep and emits their end events, in the order they started.
+func (s *StreamReader) endToolCalls(keep int) {
+	open := s.openCalls[:0]
+	for _, idx := range s.openCalls {
+		if idx == keep {
+			open = append(open, idx)
+			continue
+		}
+		tc := s.toolCalls[idx]
+		var input any
+		json.Unmarshal([]byte(s.toolInputs[idx].String()), &input)
+		tc.Input = input
+
+		end := *tc
+		s.emit(&types.StreamEvent{
+			Type:     types.StreamEventToolCallEnd,
+			ToolCall: &end,
+			Index:    idx,
+		})
+	}
+	s.openCalls = open
+}
+
+// finish ends the stream: it ends the open tool calls and builds the
+// response.
+func (s *StreamReader) finish() {
+	s.done = true
+	s.endToolCalls(-1)
+	s.buildResponse()
+}
+
+// buildResponse builds the final response from accumulated state.
+func (s *StreamReader) buildResponse() {
+	var content []types.ContentBlock
+
+	// Add reasoning first, as in complete responses
+	if s.thinking.Len() > 0 {
+		content = append(content, types.ContentBlock{
+			Type: types.ContentTypeThinking,
+			Text: s.thinking.String(),
+		})
+	}
+
+	// Add text content
+	if s.content.Len() > 0 {
+		content = append(content, types.ContentBlock{
+			Type: types.ContentTypeText,
+			Text: s.content.String(),
+		})
+	}
+
+	// Add audio
+	if len(s.audio) > 0 || s.audioTranscript.Len() > 0 {
+		content = append(content, types.ContentBlock{
+			Type:        types.ContentTypeAudio,
+			AudioID:     s.audioID,
+			AudioBase64: base64.StdEncoding.EncodeToString(s.audio),
+			AudioFormat: s.audioFormat,
+			Text:        s.audioTranscript.String(),
+		})
+	}
+
+	// Add tool calls in index order
+	var toolCalls []types.ToolCall
+	for _, idx := range slices.Sorted(maps.Keys(s.toolCalls)) {
+		tc := s.toolCalls[idx]
+		toolCalls = append(toolCalls, *tc)
+
+		content = append(content, types.ContentBlock{
+			Type:      types.ContentTypeToolUse,
+			ToolUseID: tc.ID,
+			ToolName:  tc.Name,
+			ToolInput: tc.Input,
+		})
+	}
+
+	s.response = &types.CompletionResponse{
+		ID:               s.id,
+		Provider:         s.config.Provider,
+		Model:            s.model,
+		Content:          content,
+		StopReason:       s.stopReason,
+		ToolCalls:        toolCalls,
+		CreatedAt:        time.Now(),
+		ProviderMetadata: s.meta,
+		Metadata:         s.metadata,
+	}
+
+	setRefusal(s.response, s.refusal.String())
+
+	if s.usage != nil {
+		s.response.Usage = *s.usage
+	}
+}
+
+// Close closes the stream. It is idempotent and safe to call while Next is blocked.
+func (s *StreamReader) Close() error {
+	return s.body.Close()
+}
+
+// Response returns the accumulated response.
+func (s *StreamReader) Response() *types.CompletionResponse {
+	return s.response
+}
+
+// Ensure StreamReader implements types.StreamReader
+var _ types.StreamReader = (*StreamReader)(nil)
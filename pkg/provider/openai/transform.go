@@ -2,8 +2,10 @@ package openai
 
 import (
 	"encoding/json"
+	"slices"
 	"time"
 
+	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/schema"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -27,6 +29,7 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatComple
 		Messages:    t.transformMessages(req.Messages),
 		MaxTokens:   req.MaxTokens,
 		Temperature: req.Temperature,
+		Seed:        req.Seed,
 		TopP:        req.TopP,
 		Stop:        req.StopSequences,
 		Stream:      req.Stream,
@@ -36,6 +39,10 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatComple
 		oaiReq.StreamOptions = &StreamOptions{IncludeUsage: true}
 	}
 
+	if req.N > 1 {
+		oaiReq.N = types.Ptr(req.N)
+	}
+
 	// Transform response format
 	if req.ResponseFormat != nil {
 		oaiReq.ResponseFormat = t.transformResponseFormat(req.ResponseFormat)
@@ -49,6 +56,10 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatComple
 	// Transform tool choice
 	if req.ToolChoice != nil {
 		oaiReq.ToolChoice = t.transformToolChoice(req.ToolChoice)
+		// parallel_tool_calls is only accepted alongside tools.
+		if req.ToolChoice.DisableParallelToolUse && len(oaiReq.Tools) > 0 {
+			oaiReq.ParallelToolCalls = types.Ptr(false)
+		}
 	}
 
 	if len(req.Metadata) > 0 {
@@ -56,24 +67,80 @@ func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatComple
 		for k, v := range req.Metadata {
 			oaiReq.Metadata[k] = v
 		}
+		// The end user is also sent as user, which OpenAI's abuse
+		// monitoring reads.
+		oaiReq.User = req.Metadata["user_id"]
 	}
 
 	if req.Thinking != nil && req.Thinking.Effort != "" {
 		oaiReq.ReasoningEffort = req.Thinking.Effort
 	}
 
+	if req.Prediction != "" {
+		oaiReq.Prediction = &Prediction{Type: "content", Content: req.Prediction}
+	}
+
+	if req.Audio != nil {
+		oaiReq.Modalities = []string{"text", "audio"}
+		oaiReq.Audio = &AudioParams{
+			Voice:  req.Audio.Voice,
+			Format: audioFormat(req.Audio, req.Stream),
+		}
+		if oaiReq.Audio.Voice == "" {
+			oaiReq.Audio.Voice = DefaultVoice
+		}
+	}
+
+	switch req.ServiceTier {
+	case "":
+	case types.ServiceTierStandard:
+		oaiReq.ServiceTier = "default"
+	default:
+		oaiReq.ServiceTier = string(req.ServiceTier)
+	}
+
 	return oaiReq
 }
 
+// DefaultVoice is the voice of audio output when the request names none.
+const DefaultVoice = "alloy"
+
+// audioFormat returns the format of audio output: the configured one, or
+// "wav", or "pcm16" for streams, the only format OpenAI streams.
+func audioFormat(cfg *types.AudioConfig, stream bool) string {
+	switch {
+	case cfg != nil && cfg.Format != "":
+		return cfg.Format
+	case stream:
+		return "pcm16"
+	default:
+		return "wav"
+	}
+}
+
 // transformMessages converts unified messages to OpenAI format.
 func (t *Transformer) transformMessages(messages []types.Message) []ChatMessage {
+	messages = provider.PairToolResults(messages)
 	result := make([]ChatMessage, 0, len(messages))
 
+	// Tool messages only carry text, so images in tool results are sent in a
+	// user message after the run of tool messages.
+	var toolImages []ContentPart
+	flushToolImages := func() {
+		if len(toolImages) > 0 {
+			parts := append([]ContentPart{{Type: "text", Text: "Images returned by the tool calls above:"}}, toolImages...)
+			result = append(result, ChatMessage{Role: string(types.RoleUser), Content: parts})
+			toolImages = nil
+		}
+	}
+
 	for _, msg := range messages {
 		oaiMsg := ChatMessage{
 			Role: string(msg.Role),
 		}
 
+		oaiMsg.Audio, msg.Content = messageAudio(msg)
+
 		// Check if this is a tool result message
 		if msg.Role == types.RoleTool {
 			for _, block := range msg.Content {
@@ -81,10 +148,16 @@ func (t *Transformer) transformMessages(messages []types.Message) []ChatMessage
 					oaiMsg.ToolCallID = block.ToolResultID
 					oaiMsg.Content = block.Text
 					result = append(result, oaiMsg)
+					for _, rb := range block.ToolResultContent {
+						if rb.Type == types.ContentTypeImage {
+							toolImages = append(toolImages, imagePart(rb))
+						}
+					}
 				}
 			}
 			continue
 		}
+		flushToolImages()
 
 		// Check if we need multipart content
 		hasMultipleParts := len(msg.Content) > 1
@@ -137,16 +210,7 @@ func (t *Transformer) transformMessages(messages []types.Message) []ChatMessage
 						Text: block.Text,
 					})
 				case types.ContentTypeImage:
-					url := block.ImageURL
-					if url == "" && block.ImageBase64 != "" {
-						url = "data:" + block.MediaType + ";base64," + block.ImageBase64
-					}
-					parts = append(parts, ContentPart{
-						Type: "image_url",
-						ImageURL: &ImageURL{
-							URL: url,
-						},
-					})
+					parts = append(parts, imagePart(block))
 				}
 			}
 			oaiMsg.Content = parts
@@ -163,10 +227,52 @@ func (t *Transformer) transformMessages(messages []types.Message) []ChatMessage
 
 		result = append(result, oaiMsg)
 	}
+	flushToolImages()
 
 	return result
 }
 
+// messageAudio returns the audio an assistant message refers to and the
+// message's other content. Assistant audio is sent back by ID; audio without
+// one, or outside assistant messages, is sent as its transcript.
+func messageAudio(msg types.Message) (*MessageAudio, []types.ContentBlock) {
+	isAudio := func(b types.ContentBlock) bool { return b.Type == types.ContentTypeAudio }
+	if !slices.ContainsFunc(msg.Content, isAudio) {
+		return nil, msg.Content
+	}
+
+	var audio *MessageAudio
+	content := make([]types.ContentBlock, 0, len(msg.Content))
+	for _, block := range msg.Content {
+		if isAudio(block) {
+			if block.AudioID != "" && msg.Role == types.RoleAssistant && audio == nil {
+				audio = &MessageAudio{ID: block.AudioID}
+				continue
+			}
+			if block.Text == "" {
+				continue
+			}
+			block = types.ContentBlock{Type: types.ContentTypeText, Text: block.Text}
+		}
+		content = append(content, block)
+	}
+	return audio, content
+}
+
+// imagePart converts an image block to an image_url content part.
+func imagePart(block types.ContentBlock) ContentPart {
+	url := block.ImageURL
+	if url == "" && block.ImageBase64 != "" {
+		url = "data:" + block.MediaType + ";base64," + block.ImageBase64
+	}
+	return ContentPart{
+		Type: "image_url",
+		ImageURL: &ImageURL{
+			URL: url,
+		},
+	}
+}
+
 // transformResponseFormat converts unified response format to OpenAI format.
 func (t *Transformer) transformResponseFormat(rf *types.ResponseFormat) *ResponseFormat {
 	oaiRF := t.schemaTranslator.ToOpenAI(rf)
@@ -241,28 +347,79 @@ func (t *Transformer) TransformResponse(resp *ChatCompletionResponse) *types.Com
 		Provider:   types.ProviderOpenAI,
 		Model:      resp.Model,
 		Content:    t.transformContent(choice.Message),
-		StopReason: t.transformStopReason(choice.FinishReason),
+		StopReason: t.TransformStopReason(choice.FinishReason),
 		ToolCalls:  t.extractToolCalls(choice.Message),
 		CreatedAt:  time.Unix(resp.Created, 0),
+		ProviderMetadata: types.ProviderMetadata{
+			SystemFingerprint: resp.SystemFingerprint,
+			ServiceTier:       resp.ServiceTier,
+		},
+	}
+	result.Citations = provider.CollectCitations(result.Content)
+	setRefusal(result, choice.Message.Refusal)
+
+	for _, choice := range resp.Choices[1:] {
+		alt := types.CompletionResponse{
+			ID:         resp.ID,
+			Provider:   types.ProviderOpenAI,
+			Model:      resp.Model,
+			Content:    t.transformContent(choice.Message),
+			StopReason: t.TransformStopReason(choice.FinishReason),
+			ToolCalls:  t.extractToolCalls(choice.Message),
+			CreatedAt:  result.CreatedAt,
+		}
+		alt.Citations = provider.CollectCitations(alt.Content)
+		setRefusal(&alt, choice.Message.Refusal)
+		result.Alternatives = append(result.Alternatives, alt)
 	}
 
 	if resp.Usage != nil {
-		result.Usage = types.Usage{
-			InputTokens:  resp.Usage.PromptTokens,
-			OutputTokens: resp.Usage.CompletionTokens,
-			TotalTokens:  resp.Usage.TotalTokens,
-		}
-		if resp.Usage.PromptTokensDetails != nil {
-			result.Usage.CachedTokens = resp.Usage.PromptTokensDetails.CachedTokens
-		}
-		if resp.Usage.CompletionTokensDetails != nil {
-			result.Usage.ReasoningTokens = resp.Usage.CompletionTokensDetails.ReasoningTokens
-		}
+		result.Usage = transformUsage(resp.Usage)
 	}
 
 	return result
 }
 
+// setRefusal records a refusal on resp. OpenAI reports refusals with a
+// "stop" finish reason.
+func setRefusal(resp *types.CompletionResponse, refusal string) {
+	if refusal != "" {
+		resp.Refusal = refusal
+		resp.StopReason = types.StopReasonRefusal
+	}
+}
+
+// transformUsage converts OpenAI token usage to the unified format.
+func transformUsage(u *Usage) types.Usage {
+	usage := types.Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+	if d := u.CompletionTokensDetails; d != nil {
+		usage.ReasoningTokens = d.ReasoningTokens
+		usage.AcceptedPredictionTokens = d.AcceptedPredictionTokens
+		usage.RejectedPredictionTokens = d.RejectedPredictionTokens
+	}
+	return usage
+}
+
+// byteOffset converts a character index in text to a byte offset, clamped to
+// the text.
+func byteOffset(text string, chars int) int {
+	n := 0
+	for i := range text {
+		if n == chars {
+			return i
+		}
+		n++
+	}
+	return len(text)
+}
+
 // transformContent extracts content blocks from OpenAI message.
 func (t *Transformer) transformContent(msg ChatMessage) []types.ContentBlock {
 	var blocks []types.ContentBlock
@@ -289,6 +446,44 @@ func (t *Transformer) transformContent(msg ChatMessage) []types.ContentBlock {
 		}
 	}
 
+	// Handle audio, whose transcript is the message's text
+	if msg.Audio != nil {
+		blocks = append(blocks, types.ContentBlock{
+			Type:        types.ContentTypeAudio,
+			AudioID:     msg.Audio.ID,
+			AudioBase64: msg.Audio.Data,
+			Text:        msg.Audio.Transcript,
+		})
+	}
+
+	// Handle annotations, which index the text content
+	var text string
+	for _, b := range blocks {
+		if b.Type == types.ContentTypeText {
+			text += b.Text
+		}
+	}
+	for _, a := range msg.Annotations {
+		if a.URLCitation == nil {
+			continue
+		}
+		start, end := byteOffset(text, a.URLCitation.StartIndex), byteOffset(text, a.URLCitation.EndIndex)
+		if end < start {
+			end = start
+		}
+		blocks = append(blocks, types.ContentBlock{
+			Type: types.ContentTypeCitation,
+			Citation: &types.Citation{
+				Type:       a.Type,
+				URL:        a.URLCitation.URL,
+				Title:      a.URLCitation.Title,
+				Text:       text[start:end],
+				StartIndex: start,
+				EndIndex:   end,
+			},
+		})
+	}
+
 	// Handle tool calls
 	for _, tc := range msg.ToolCalls {
 		var input any
@@ -326,8 +521,8 @@ func (t *Transformer) extractToolCalls(msg ChatMessage) []types.ToolCall {
 	return calls
 }
 
-// transformStopReason converts OpenAI finish reason to unified format.
-func (t *Transformer) transformStopReason(reason string) types.StopReason {
+// TransformStopReason converts OpenAI finish reason to unified format.
+func (t *Transformer) TransformStopReason(reason string) types.StopReason {
 	switch reason {
 	case "stop":
 		return types.StopReasonEnd
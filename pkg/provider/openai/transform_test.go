@@ -2,6 +2,7 @@ package openai
 
 import (
 	"encoding/json"
+	"slices"
 	"testing"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
@@ -148,6 +149,47 @@ func TestTransformRequest_ToolResult(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ToolResultContent(t *testing.T) {
+	transformer := NewTransformer()
+
+	image := types.ContentBlock{Type: types.ContentTypeImage, ImageBase64: "iVBORw0KGgo=", MediaType: "image/png"}
+	req := &types.CompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.Message{
+			types.NewToolResultContentMessage("call_1", []types.ContentBlock{
+				{Type: types.ContentTypeText, Text: "Screenshot 1"},
+				image,
+			}, false),
+			types.NewToolResultContentMessage("call_2", []types.ContentBlock{image}, false),
+			types.NewTextMessage(types.RoleUser, "What do you see?"),
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	if len(result.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(result.Messages))
+	}
+	if result.Messages[0].ToolCallID != "call_1" || result.Messages[0].Content != "Screenshot 1" {
+		t.Errorf("unexpected first tool message: %+v", result.Messages[0])
+	}
+	if result.Messages[1].ToolCallID != "call_2" {
+		t.Errorf("unexpected second tool message: %+v", result.Messages[1])
+	}
+
+	images := result.Messages[2]
+	parts, ok := images.Content.([]ContentPart)
+	if images.Role != "user" || !ok || len(parts) != 3 {
+		t.Fatalf("expected a user message with a caption and 2 images, got %+v", images)
+	}
+	if parts[1].ImageURL == nil || parts[1].ImageURL.URL != "data:image/png;base64,iVBORw0KGgo=" {
+		t.Errorf("unexpected image part: %+v", parts[1])
+	}
+	if result.Messages[3].Content != "What do you see?" {
+		t.Errorf("unexpected last message: %+v", result.Messages[3])
+	}
+}
+
 func TestTransformRequest_AssistantWithToolCalls(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -190,6 +232,75 @@ func TestTransformRequest_AssistantWithToolCalls(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_ParallelToolCalls(t *testing.T) {
+	transformer := NewTransformer()
+
+	req := &types.CompletionRequest{
+		Model: "gpt-4o",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Weather in Paris and Rome?"),
+			{
+				Role: types.RoleAssistant,
+				Content: []types.ContentBlock{
+					{Type: types.ContentTypeToolUse, ToolUseID: "call_1", ToolName: "get_weather", ToolInput: map[string]any{"location": "Paris"}},
+					{Type: types.ContentTypeToolUse, ToolUseID: "call_2", ToolName: "get_weather", ToolInput: map[string]any{"location": "Rome"}},
+				},
+			},
+			// Results matched by tool name, in call order
+			{
+				Role: types.RoleTool,
+				Content: []types.ContentBlock{
+					{Type: types.ContentTypeToolResult, ToolName: "get_weather", Text: "22C"},
+					{Type: types.ContentTypeToolResult, ToolName: "get_weather", Text: "25C"},
+				},
+			},
+		},
+	}
+
+	result := transformer.TransformRequest(req)
+
+	if len(result.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(result.Messages))
+	}
+	if calls := result.Messages[1].ToolCalls; len(calls) != 2 || calls[0].ID != "call_1" || calls[1].ID != "call_2" {
+		t.Errorf("unexpected tool calls: %+v", calls)
+	}
+	for i, want := range []struct{ id, text string }{{"call_1", "22C"}, {"call_2", "25C"}} {
+		msg := result.Messages[i+2]
+		if msg.Role != "tool" || msg.ToolCallID != want.id || msg.Content != want.text {
+			t.Errorf("tool message %d = %+v, want %s %q", i, msg, want.id, want.text)
+		}
+	}
+}
+
+func TestTransformRequest_DisableParallelToolUse(t *testing.T) {
+	transformer := NewTransformer()
+	tools := []types.Tool{{Name: "get_weather", Parameters: types.JSONSchema{Type: "object"}}}
+
+	tests := []struct {
+		name  string
+		tools []types.Tool
+		tc    *types.ToolChoice
+		want  *bool
+	}{
+		{"disabled", tools, &types.ToolChoice{Type: types.ToolChoiceAuto, DisableParallelToolUse: true}, types.Ptr(false)},
+		{"default", tools, &types.ToolChoice{Type: types.ToolChoiceAuto}, nil},
+		{"no tools", nil, &types.ToolChoice{Type: types.ToolChoiceNone, DisableParallelToolUse: true}, nil},
+	}
+	for _, tt := range tests {
+		result := transformer.TransformRequest(&types.CompletionRequest{
+			Model:      "gpt-4o",
+			Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "Weather in Paris and Rome?")},
+			Tools:      tt.tools,
+			ToolChoice: tt.tc,
+		})
+		got := result.ParallelToolCalls
+		if (got == nil) != (tt.want == nil) || (got != nil && *got != *tt.want) {
+			t.Errorf("%s: parallel_tool_calls = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
 func TestTransformRequest_MultipartImage(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -368,6 +479,79 @@ func TestTransformRequest_ReasoningEffort(t *testing.T) {
 	}
 }
 
+func TestTransformRequest_Prediction(t *testing.T) {
+	transformer := NewTransformer()
+	req := &types.CompletionRequest{
+		Model:      "gpt-4o",
+		Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "Rename x to count")},
+		Prediction: "func f() { x := 0 }",
+	}
+	result := transformer.TransformRequest(req)
+	want := &Prediction{Type: "content", Content: "func f() { x := 0 }"}
+	if result.Prediction == nil || *result.Prediction != *want {
+		t.Errorf("prediction = %+v, want %+v", result.Prediction, want)
+	}
+}
+
+func TestTransformRequest_Audio(t *testing.T) {
+	transformer := NewTransformer()
+	req := &types.CompletionRequest{
+		Model: "gpt-4o-audio-preview",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Say hello"),
+			{Role: types.RoleAssistant, Content: []types.ContentBlock{
+				{Type: types.ContentTypeAudio, AudioID: "audio_1", Text: "Hello!"},
+			}},
+			{Role: types.RoleAssistant, Content: []types.ContentBlock{
+				{Type: types.ContentTypeAudio, Text: "Expired audio"},
+			}},
+		},
+		Audio: &types.AudioConfig{},
+	}
+
+	result := transformer.TransformRequest(req)
+	if !slices.Equal(result.Modalities, []string{"text", "audio"}) {
+		t.Errorf("modalities = %v, want text and audio", result.Modalities)
+	}
+	if result.Audio == nil || *result.Audio != (AudioParams{Voice: "alloy", Format: "wav"}) {
+		t.Errorf("audio = %+v, want alloy wav", result.Audio)
+	}
+	if a := result.Messages[1].Audio; a == nil || a.ID != "audio_1" || result.Messages[1].Content != "" {
+		t.Errorf("assistant audio = %+v, %v; want a reference to audio_1", a, result.Messages[1].Content)
+	}
+	if result.Messages[2].Audio != nil || result.Messages[2].Content != "Expired audio" {
+		t.Errorf("audio without ID = %+v, want its transcript as text", result.Messages[2])
+	}
+
+	req.Stream = true
+	req.Audio.Voice = "verse"
+	result = transformer.TransformRequest(req)
+	if *result.Audio != (AudioParams{Voice: "verse", Format: "pcm16"}) {
+		t.Errorf("streamed audio = %+v, want verse pcm16", result.Audio)
+	}
+}
+
+func TestTransformRequest_ServiceTier(t *testing.T) {
+	transformer := NewTransformer()
+	tests := map[types.ServiceTier]string{
+		"":                        "",
+		types.ServiceTierAuto:     "auto",
+		types.ServiceTierStandard: "default",
+		types.ServiceTierFlex:     "flex",
+		types.ServiceTierPriority: "priority",
+	}
+	for tier, want := range tests {
+		result := transformer.TransformRequest(&types.CompletionRequest{
+			Model:       "gpt-4o",
+			Messages:    []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+			ServiceTier: tier,
+		})
+		if result.ServiceTier != want {
+			t.Errorf("tier %q: service_tier = %q, want %q", tier, result.ServiceTier, want)
+		}
+	}
+}
+
 func TestTransformRequest_Metadata(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -397,6 +581,14 @@ func TestTransformRequest_Metadata(t *testing.T) {
 	if meta["session"] != "sess-1" || meta["env"] != "test" {
 		t.Errorf("metadata = %v", meta)
 	}
+	if _, ok := decoded["user"]; ok {
+		t.Errorf("user = %v, want none without a user_id", decoded["user"])
+	}
+
+	req.Metadata["user_id"] = "user-42"
+	if result := transformer.TransformRequest(req); result.User != "user-42" || result.Metadata["user_id"] != "user-42" {
+		t.Errorf("user = %q, metadata = %v", result.User, result.Metadata)
+	}
 }
 
 func TestTransformResponse(t *testing.T) {
@@ -454,6 +646,96 @@ func TestTransformResponse(t *testing.T) {
 	}
 }
 
+func TestTransformResponse_PredictionUsage(t *testing.T) {
+	result := NewTransformer().TransformResponse(&ChatCompletionResponse{
+		Choices: []Choice{{Message: ChatMessage{Role: "assistant", Content: "ok"}, FinishReason: "stop"}},
+		Usage: &Usage{
+			PromptTokens:     10,
+			CompletionTokens: 30,
+			TotalTokens:      40,
+			CompletionTokensDetails: &CompletionTokensDetails{
+				AcceptedPredictionTokens: 20,
+				RejectedPredictionTokens: 4,
+			},
+		},
+	})
+	if result.Usage.AcceptedPredictionTokens != 20 || result.Usage.RejectedPredictionTokens != 4 {
+		t.Errorf("usage = %+v", result.Usage)
+	}
+}
+
+func TestTransformResponse_Refusal(t *testing.T) {
+	result := NewTransformer().TransformResponse(&ChatCompletionResponse{
+		Choices: []Choice{{Message: ChatMessage{Role: "assistant", Refusal: "I can't help with that."}, FinishReason: "stop"}},
+	})
+	if result.StopReason != types.StopReasonRefusal || result.Refusal != "I can't help with that." || result.Text() != "" {
+		t.Errorf("got stop reason %q, refusal %q, text %q", result.StopReason, result.Refusal, result.Text())
+	}
+}
+
+func TestTransformResponse_Annotations(t *testing.T) {
+	transformer := NewTransformer()
+
+	resp := &ChatCompletionResponse{
+		ID:    "chatcmpl-123",
+		Model: "gpt-4o-search-preview",
+		Choices: []Choice{
+			{
+				Message: ChatMessage{
+					Role:    "assistant",
+					Content: "Café opens at 9 (source).",
+					Annotations: []Annotation{{
+						Type:        "url_citation",
+						URLCitation: &URLCitation{StartIndex: 16, EndIndex: 24, URL: "https://cafe.example", Title: "Café"},
+					}},
+				},
+				FinishReason: "stop",
+			},
+		},
+	}
+
+	result := transformer.TransformResponse(resp)
+
+	if len(result.Citations) != 1 {
+		t.Fatalf("expected 1 citation, got %d", len(result.Citations))
+	}
+	c := result.Citations[0]
+	if c.URL != "https://cafe.example" || c.Title != "Café" || c.Type != "url_citation" {
+		t.Errorf("unexpected citation: %+v", c)
+	}
+	// Character indices become byte offsets in Text()
+	if got := result.Text()[c.StartIndex:c.EndIndex]; got != "(source)" || c.Text != got {
+		t.Errorf("expected cited span '(source)', got %q", got)
+	}
+}
+
+func TestTransformResponse_Audio(t *testing.T) {
+	transformer := NewTransformer()
+	resp := &ChatCompletionResponse{
+		ID:    "chatcmpl-1",
+		Model: "gpt-4o-audio-preview",
+		Choices: []Choice{{
+			Message: ChatMessage{
+				Role:  "assistant",
+				Audio: &MessageAudio{ID: "audio_1", Data: "AAECAwQ=", Transcript: "Hello!"},
+			},
+			FinishReason: "stop",
+		}},
+	}
+
+	result := transformer.TransformResponse(resp)
+	if len(result.Content) != 1 || result.Content[0].Type != types.ContentTypeAudio || result.Content[0].AudioID != "audio_1" {
+		t.Fatalf("content = %+v, want one audio block", result.Content)
+	}
+	if result.Transcript() != "Hello!" || result.Text() != "" {
+		t.Errorf("transcript = %q, text = %q; want the transcript only", result.Transcript(), result.Text())
+	}
+	audio, err := result.Audio()
+	if err != nil || !slices.Equal(audio, []byte{0, 1, 2, 3, 4}) {
+		t.Errorf("audio = %v, %v; want the decoded data", audio, err)
+	}
+}
+
 func TestTransformResponse_WithToolCalls(t *testing.T) {
 	transformer := NewTransformer()
 
@@ -539,9 +821,36 @@ func TestTransformStopReason(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		result := transformer.transformStopReason(tt.reason)
+		result := transformer.TransformStopReason(tt.reason)
 		if result != tt.expected {
-			t.Errorf("transformStopReason(%q) = %q, expected %q", tt.reason, result, tt.expected)
+			t.Errorf("TransformStopReason(%q) = %q, expected %q", tt.reason, result, tt.expected)
 		}
 	}
 }
+
+func TestTransformRequestResponse_N(t *testing.T) {
+	transformer := NewTransformer()
+	oaiReq := transformer.TransformRequest(&types.CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+		N:        2,
+	})
+	if oaiReq.N == nil || *oaiReq.N != 2 {
+		t.Errorf("n = %v, want 2", oaiReq.N)
+	}
+
+	result := transformer.TransformResponse(&ChatCompletionResponse{
+		ID:    "chatcmpl-123",
+		Model: "gpt-4o",
+		Choices: []Choice{
+			{Index: 0, Message: ChatMessage{Role: "assistant", Content: "Hello!"}, FinishReason: "stop"},
+			{Index: 1, Message: ChatMessage{Role: "assistant", Content: "Hi there"}, FinishReason: "length"},
+		},
+	})
+	if result.Text() != "Hello!" || len(result.Alternatives) != 1 {
+		t.Fatalf("text = %q, alternatives = %d", result.Text(), len(result.Alternatives))
+	}
+	if alt := result.Alternatives[0]; alt.Text() != "Hi there" || alt.StopReason != types.StopReasonMaxTokens || alt.Model != "gpt-4o" {
+		t.Errorf("alternative = %+v", alt)
+	}
+}
@@ -22,6 +22,24 @@ type ChatCompletionRequest struct {
 	Seed              *int              `json:"seed,omitempty"`
 	Metadata          map[string]string `json:"metadata,omitempty"`
 	ReasoningEffort   string            `json:"reasoning_effort,omitempty"`
+	Prediction        *Prediction       `json:"prediction,omitempty"`
+	ServiceTier       string            `json:"service_tier,omitempty"`
+	Modalities        []string          `json:"modalities,omitempty"` // "text", "audio"
+	Audio             *AudioParams      `json:"audio,omitempty"`
+}
+
+// AudioParams configures audio output, requested with the "audio" modality.
+// See https://platform.openai.com/docs/guides/audio
+type AudioParams struct {
+	Voice  string `json:"voice"`
+	Format string `json:"format"` // "wav", "mp3", "flac", "opus", or "pcm16"
+}
+
+// Prediction is a predicted output.
+// See https://platform.openai.com/docs/guides/predicted-outputs
+type Prediction struct {
+	Type    string `json:"type"` // "content"
+	Content string `json:"content"`
 }
 
 // StreamOptions configures streaming behavior.
@@ -31,11 +49,44 @@ type StreamOptions struct {
 
 // ChatMessage is an OpenAI chat message.
 type ChatMessage struct {
-	Role       string     `json:"role"`
-	Content    any        `json:"content"` // string or []ContentPart
-	Name       string     `json:"name,omitempty"`
-	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
-	ToolCallID string     `json:"tool_call_id,omitempty"`
+	Role        string        `json:"role"`
+	Content     any           `json:"content"` // string or []ContentPart
+	Name        string        `json:"name,omitempty"`
+	ToolCalls   []ToolCall    `json:"tool_calls,omitempty"`
+	ToolCallID  string        `json:"tool_call_id,omitempty"`
+	Annotations []Annotation  `json:"annotations,omitempty"` // responses only
+	Audio       *MessageAudio `json:"audio,omitempty"`
+	Refusal     string        `json:"refusal,omitempty"` // responses only
+
+	// ReasoningContent and Reasoning are the reasoning text of responses
+	// from OpenAI-compatible APIs such as DeepSeek and OpenRouter.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
+}
+
+// MessageAudio is the audio of an assistant message. Responses carry the
+// data and transcript; requests refer to earlier audio by ID alone. In
+// streams each delta carries a chunk of the data and of the transcript.
+type MessageAudio struct {
+	ID         string `json:"id,omitempty"`
+	Data       string `json:"data,omitempty"` // base64
+	ExpiresAt  int64  `json:"expires_at,omitempty"`
+	Transcript string `json:"transcript,omitempty"`
+}
+
+// Annotation is a citation in a response message, from web search.
+type Annotation struct {
+	Type        string       `json:"type"` // "url_citation"
+	URLCitation *URLCitation `json:"url_citation,omitempty"`
+}
+
+// URLCitation is a web page cited for the message text between StartIndex
+// and EndIndex, which count characters.
+type URLCitation struct {
+	StartIndex int    `json:"start_index"`
+	EndIndex   int    `json:"end_index"`
+	URL        string `json:"url"`
+	Title      string `json:"title,omitempty"`
 }
 
 // ContentPart is a content part in a message.
@@ -113,6 +164,7 @@ type ChatCompletionResponse struct {
 	Choices           []Choice `json:"choices"`
 	Usage             *Usage   `json:"usage,omitempty"`
 	SystemFingerprint string   `json:"system_fingerprint,omitempty"`
+	ServiceTier       string   `json:"service_tier,omitempty"`
 }
 
 // Choice is a completion choice.
@@ -140,8 +192,10 @@ type PromptTokensDetails struct {
 
 // CompletionTokensDetails contains details about completion tokens.
 type CompletionTokensDetails struct {
-	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
-	AudioTokens     int `json:"audio_tokens,omitempty"`
+	ReasoningTokens          int `json:"reasoning_tokens,omitempty"`
+	AudioTokens              int `json:"audio_tokens,omitempty"`
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens,omitempty"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
 }
 
 // StreamChunk is a streaming response chunk.
@@ -153,6 +207,7 @@ type StreamChunk struct {
 	Choices           []StreamChoice `json:"choices"`
 	Usage             *Usage         `json:"usage,omitempty"`
 	SystemFingerprint string         `json:"system_fingerprint,omitempty"`
+	ServiceTier       string         `json:"service_tier,omitempty"`
 }
 
 // StreamChoice is a streaming choice.
@@ -165,9 +220,17 @@ type StreamChoice struct {
 
 // MessageDelta is the delta in a streaming message.
 type MessageDelta struct {
-	Role      string     `json:"role,omitempty"`
-	Content   string     `json:"content,omitempty"`
-	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
+	Role      string        `json:"role,omitempty"`
+	Content   string        `json:"content,omitempty"`
+	ToolCalls []ToolCall    `json:"tool_calls,omitempty"`
+	Audio     *MessageAudio `json:"audio,omitempty"`
+	Refusal   string        `json:"refusal,omitempty"`
+
+	// ReasoningContent and Reasoning carry reasoning text on
+	// OpenAI-compatible APIs such as DeepSeek and OpenRouter. OpenAI's chat
+	// completions do not stream reasoning.
+	ReasoningContent string `json:"reasoning_content,omitempty"`
+	Reasoning        string `json:"reasoning,omitempty"`
 }
 
 // ErrorResponse is an OpenAI error response.
@@ -182,3 +245,57 @@ type APIError struct {
 	Param   string `json:"param,omitempty"`
 	Code    string `json:"code,omitempty"`
 }
+
+// ModelList is the response from listing models.
+type ModelList struct {
+	Object string  `json:"object"`
+	Data   []Model `json:"data"`
+}
+
+// Model is an OpenAI model object.
+type Model struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	OwnedBy string `json:"owned_by"`
+}
+
+// ModerationRequest is the request body for the moderations endpoint.
+type ModerationRequest struct {
+	Model string `json:"model,omitempty"`
+	Input string `json:"input"`
+}
+
+// ModerationResponse is the response from the moderations endpoint.
+type ModerationResponse struct {
+	ID      string             `json:"id"`
+	Model   string             `json:"model"`
+	Results []ModerationResult `json:"results"`
+}
+
+// ModerationResult is the classification of one input.
+type ModerationResult struct {
+	Flagged        bool               `json:"flagged"`
+	Categories     map[string]bool    `json:"categories"`
+	CategoryScores map[string]float64 `json:"category_scores"`
+}
+
+// EmbeddingRequest is the request body for the embeddings endpoint.
+type EmbeddingRequest struct {
+	Model      string   `json:"model"`
+	Input      []string `json:"input"`
+	Dimensions *int     `json:"dimensions,omitempty"`
+}
+
+// EmbeddingResponse is the response from the embeddings endpoint.
+type EmbeddingResponse struct {
+	Model string      `json:"model"`
+	Data  []Embedding `json:"data"`
+	Usage *Usage      `json:"usage,omitempty"`
+}
+
+// Embedding is the vector of the input at Index.
+type Embedding struct {
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
@@ -0,0 +1,283 @@
+// Package openrouter provides an OpenRouter API client implementation.
+//
+// OpenRouter serves models of many vendors, named "vendor/model", through
+// OpenAI's chat completions wire format, and routes each request to one of
+// the upstream providers hosting the model. Responses name the model and
+// upstream provider that served them and the request's billed cost, which
+// the client returns as the response model, metadata, and Usage.Cost.
+package openrouter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	defaultBaseURL = "https://openrouter.ai/api/v1"
+)
+
+// Client is a OpenRouter API client.
+type Client struct {
+	config      *provider.Config
+	httpClient  *http.Client
+	baseURL     string
+	transformer *Transformer
+}
+
+// New creates a new OpenRouter client.
+func New(opts ...provider.Option) *Client {
+	cfg := provider.DefaultConfig()
+	provider.ApplyOptions(cfg, opts...)
+
+	baseURL := defaultBaseURL
+	if cfg.BaseURL != "" {
+		baseURL = cfg.BaseURL
+	}
+
+	return &Client{
+		config:      cfg,
+		httpClient:  provider.NewHTTPClient(cfg),
+		baseURL:     baseURL,
+		transformer: NewTransformer(),
+	}
+}
+
+// Name returns the provider name.
+func (c *Client) Name() types.Provider {
+	return types.ProviderOpenRouter
+}
+
+// SupportsFeature checks if OpenRouter supports a feature. Support varies by
+// model; OpenRouter only routes to upstream providers that can serve the
+// request's tools, images, and response format.
+func (c *Client) SupportsFeature(feature types.Feature) bool {
+	switch feature {
+	case types.FeatureStreaming,
+		types.FeatureTools,
+		types.FeatureVision,
+		types.FeatureJSON,
+		types.FeatureStructuredOutput,
+		types.FeatureSeed:
+		return true
+	default:
+		return false
+	}
+}
+
+// Models returns commonly used OpenRouter models. "openrouter/auto" lets
+// OpenRouter choose the model; ListModels returns the full list.
+func (c *Client) Models() []string {
+	return []string{
+		"openrouter/auto",
+		"openai/gpt-5",
+		"openai/gpt-4o-mini",
+		"anthropic/claude-sonnet-4.5",
+		"anthropic/claude-haiku-4.5",
+		"google/gemini-2.5-pro",
+		"google/gemini-2.5-flash",
+		"deepseek/deepseek-r1",
+		"meta-llama/llama-3.3-70b-instruct",
+	}
+}
+
+// ListModels fetches the models available to the API key.
+func (c *Client) ListModels(ctx context.Context) ([]provider.ModelInfo, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/models", nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenRouter)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var list ModelList
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenRouter, "failed to decode response").WithCause(err)
+	}
+
+	models := make([]provider.ModelInfo, len(list.Data))
+	for i, m := range list.Data {
+		models[i] = provider.ModelInfo{
+			ID:              m.ID,
+			Provider:        types.ProviderOpenRouter,
+			DisplayName:     m.Name,
+			Description:     m.Description,
+			CreatedAt:       time.Unix(m.Created, 0),
+			ContextWindow:   m.ContextLength,
+			MaxOutputTokens: m.TopProvider.MaxCompletionTokens,
+			InputModalities: m.Architecture.InputModalities,
+			Metadata: map[string]any{
+				"prompt_price":     m.Pricing.Prompt,
+				"completion_price": m.Pricing.Completion,
+			},
+		}
+	}
+
+	return models, nil
+}
+
+// Complete sends a completion request.
+func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	orReq := c.transformer.TransformRequest(req)
+	orReq.Stream = false
+
+	body, err := json.Marshal(orReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderOpenRouter)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	var orResp ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&orResp); err != nil {
+		return nil, errors.ErrServerError(types.ProviderOpenRouter, "failed to decode response").WithCause(err)
+	}
+
+	result := c.transformer.TransformResponse(&orResp)
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderOpenRouter, "response has no choices")
+	}
+	if result.StopReason == types.StopReasonContentFilter {
+		return nil, errors.ErrContentFilter(types.ProviderOpenRouter, orResp.Choices[0].FinishReason, nil, result.Text())
+	}
+	result.ProviderMetadata.RequestID = provider.RequestID(resp.Header)
+	return result, nil
+}
+
+// Stream sends a streaming completion request.
+func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	orReq := c.transformer.TransformRequest(req)
+	orReq.Stream = true
+
+	body, err := json.Marshal(orReq)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to marshal request").WithCause(err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/chat/completions", bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+
+	c.setHeaders(httpReq)
+
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderOpenRouter)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, c.handleErrorResponse(resp)
+	}
+
+	return openai.NewStreamReader(ctx, resp.Body, openai.StreamConfig{
+		Provider:       types.ProviderOpenRouter,
+		RequestID:      provider.RequestID(resp.Header),
+		ReasoningField: "reasoning",
+		StopReason:     c.transformer.TransformStopReason,
+		Chunk:          transformChunk,
+	}), nil
+}
+
+// setHeaders sets the required headers for OpenRouter API requests.
+func (c *Client) setHeaders(req *http.Request) {
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+c.config.APIKey)
+}
+
+// handleErrorResponse converts an error response to a RouterError.
+func (c *Client) handleErrorResponse(resp *http.Response) error {
+	body, _ := io.ReadAll(resp.Body)
+
+	rerr := errors.ErrServerError(types.ProviderOpenRouter, string(body)).WithStatusCode(resp.StatusCode)
+	var errResp ErrorResponse
+	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
+		rerr = c.mapAPIError(errResp.Error, resp.StatusCode)
+	}
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
+}
+
+// mapAPIError maps OpenRouter API error to RouterError. 402 means the
+// account is out of credits; 502 and 503 mean no upstream provider could
+// serve the request.
+func (c *Client) mapAPIError(apiErr *APIError, statusCode int) *errors.RouterError {
+	switch statusCode {
+	case http.StatusUnauthorized:
+		return errors.ErrInvalidAPIKey(types.ProviderOpenRouter).WithStatusCode(statusCode)
+	case http.StatusPaymentRequired:
+		return errors.ErrQuotaExceeded(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusForbidden:
+		// Moderation flagged the input; metadata.reasons lists why
+		var reasons []string
+		if list, ok := apiErr.Metadata["reasons"].([]any); ok {
+			for _, r := range list {
+				if reason, ok := r.(string); ok {
+					reasons = append(reasons, reason)
+				}
+			}
+		}
+		return errors.ErrContentBlocked(types.ProviderOpenRouter, apiErr.Message, reasons).WithStatusCode(statusCode)
+	case http.StatusTooManyRequests:
+		return errors.ErrRateLimit(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusNotFound:
+		return errors.ErrModelNotFound(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusBadGateway, http.StatusServiceUnavailable:
+		return errors.ErrProviderUnavailable(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		if strings.Contains(apiErr.Message, "context length") {
+			return errors.ErrContextLength(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+		}
+		return errors.ErrInvalidRequest(apiErr.Message).WithProvider(types.ProviderOpenRouter).WithStatusCode(statusCode)
+	default:
+		return errors.ErrServerError(types.ProviderOpenRouter, apiErr.Message).WithStatusCode(statusCode)
+	}
+}
+
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
+// Ensure Client implements provider.Provider
+var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.ModelLister
+var _ provider.ModelLister = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
@@ -0,0 +1,143 @@
+package openrouter
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestComplete_RoutingMetadata(t *testing.T) {
+	var sent map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" {
+			t.Errorf("path = %q", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&sent)
+		fmt.Fprint(w, `{
+			"id": "gen-1", "model": "anthropic/claude-sonnet-4.5", "provider": "Amazon Bedrock",
+			"choices": [{"index": 0, "finish_reason": "stop", "message": {
+				"role": "assistant", "reasoning": "Simple sum.", "content": "4"
+			}}],
+			"usage": {"prompt_tokens": 12, "completion_tokens": 8, "total_tokens": 20, "cost": 0.00015,
+				"completion_tokens_details": {"reasoning_tokens": 5}}
+		}`)
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("sk-or-test"), provider.WithBaseURL(server.URL))
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:    "anthropic/claude-sonnet-4.5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "2+2?")},
+		Thinking: &types.ThinkingConfig{Budget: types.Ptr(1024)},
+		Extra: map[string]any{
+			"provider": ProviderPreferences{Order: []string{"anthropic", "amazon-bedrock"}, AllowFallbacks: types.Ptr(false)},
+			"models":   []string{"openai/gpt-4o"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	prefs, _ := sent["provider"].(map[string]any)
+	if order, _ := prefs["order"].([]any); len(order) != 2 || prefs["allow_fallbacks"] != false {
+		t.Errorf("provider preferences sent as %v", sent["provider"])
+	}
+	if models, _ := sent["models"].([]any); len(models) != 1 {
+		t.Errorf("models sent as %v", sent["models"])
+	}
+	if reasoning, _ := sent["reasoning"].(map[string]any); reasoning["max_tokens"] != float64(1024) {
+		t.Errorf("reasoning sent as %v", sent["reasoning"])
+	}
+	if usage, _ := sent["usage"].(map[string]any); usage["include"] != true {
+		t.Errorf("usage sent as %v", sent["usage"])
+	}
+
+	if resp.Provider != types.ProviderOpenRouter || resp.Model != "anthropic/claude-sonnet-4.5" {
+		t.Errorf("provider = %q, model = %q", resp.Provider, resp.Model)
+	}
+	if resp.Metadata[MetadataUpstreamProvider] != "Amazon Bedrock" {
+		t.Errorf("metadata = %v", resp.Metadata)
+	}
+	if resp.Thinking() != "Simple sum." || resp.Text() != "4" {
+		t.Errorf("thinking = %q, text = %q", resp.Thinking(), resp.Text())
+	}
+	if resp.Usage.Cost != 0.00015 || resp.Usage.ReasoningTokens != 5 {
+		t.Errorf("usage = %+v", resp.Usage)
+	}
+}
+
+func TestStream_RoutingMetadata(t *testing.T) {
+	chunks := []string{
+		`{"id":"gen-2","model":"meta-llama/llama-3.3-70b-instruct","provider":"Together","choices":[{"index":0,"delta":{"role":"assistant","content":"Hel"}}]}`,
+		`{"id":"gen-2","model":"meta-llama/llama-3.3-70b-instruct","provider":"Together","choices":[{"index":0,"delta":{"content":"lo"},"finish_reason":"stop"}]}`,
+		`{"id":"gen-2","model":"meta-llama/llama-3.3-70b-instruct","provider":"Together","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":2,"total_tokens":5,"cost":0.00001}}`,
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, ": OPENROUTER PROCESSING\n\n")
+		for _, c := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", c)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("sk-or-test"), provider.WithBaseURL(server.URL))
+	stream, err := client.Stream(context.Background(), &types.CompletionRequest{
+		Model:    "openrouter/auto",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var text strings.Builder
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventContentDelta {
+			text.WriteString(event.Delta.Text)
+		}
+	}
+
+	resp := stream.Response()
+	if text.String() != "Hello" || resp.Model != "meta-llama/llama-3.3-70b-instruct" {
+		t.Errorf("text = %q, model = %q", text.String(), resp.Model)
+	}
+	if resp.Metadata[MetadataUpstreamProvider] != "Together" || resp.Usage.Cost != 0.00001 {
+		t.Errorf("metadata = %v, usage = %+v", resp.Metadata, resp.Usage)
+	}
+}
+
+func TestComplete_ModerationError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprint(w, `{"error": {"code": 403, "message": "Input was flagged", "metadata": {"reasons": ["violence"]}}}`)
+	}))
+	defer server.Close()
+
+	client := New(provider.WithAPIKey("sk-or-test"), provider.WithBaseURL(server.URL))
+	_, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:    "openai/gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	})
+
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeContentBlocked {
+		t.Fatalf("expected content blocked error, got %v", err)
+	}
+}
@@ -0,0 +1,139 @@
+package openrouter
+
+import (
+	"encoding/json"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Metadata keys of responses.
+const (
+	// MetadataUpstreamProvider is the provider that served the request,
+	// such as "Anthropic" or "Together".
+	MetadataUpstreamProvider = "upstream_provider"
+)
+
+// Transformer converts between unified types and OpenRouter's API format,
+// using the OpenAI transformer for the parts the formats share.
+type Transformer struct {
+	openai *openai.Transformer
+}
+
+// NewTransformer creates a new OpenRouter transformer.
+func NewTransformer() *Transformer {
+	return &Transformer{openai: openai.NewTransformer()}
+}
+
+// TransformRequest converts a unified request to OpenRouter format. The
+// "provider" and "models" entries of req.Extra are sent as OpenRouter's
+// provider preferences and fallback models.
+func (t *Transformer) TransformRequest(req *types.CompletionRequest) *ChatCompletionRequest {
+	// Earlier reasoning cannot be sent back in a form every upstream
+	// accepts, so the thinking of earlier turns is dropped.
+	stripped := *req
+	stripped.Messages = provider.StripThinking(req.Messages)
+	oaiReq := t.openai.TransformRequest(&stripped)
+
+	orReq := &ChatCompletionRequest{
+		Model:             oaiReq.Model,
+		Messages:          oaiReq.Messages,
+		MaxTokens:         oaiReq.MaxTokens,
+		Temperature:       oaiReq.Temperature,
+		TopP:              oaiReq.TopP,
+		TopK:              req.TopK,
+		Stop:              oaiReq.Stop,
+		PresencePenalty:   oaiReq.PresencePenalty,
+		FrequencyPenalty:  oaiReq.FrequencyPenalty,
+		ResponseFormat:    oaiReq.ResponseFormat,
+		Tools:             oaiReq.Tools,
+		ToolChoice:        oaiReq.ToolChoice,
+		ParallelToolCalls: oaiReq.ParallelToolCalls,
+		Seed:              oaiReq.Seed,
+		Provider:          req.Extra["provider"],
+		Usage:             &UsageOptions{Include: true},
+	}
+
+	if models, ok := req.Extra["models"].([]string); ok {
+		orReq.Models = models
+	}
+
+	if th := req.Thinking; th != nil {
+		orReq.Reasoning = &Reasoning{Effort: th.Effort, MaxTokens: th.Budget}
+		if th.IncludeThoughts != nil && !*th.IncludeThoughts {
+			orReq.Reasoning.Exclude = true
+		}
+	}
+
+	return orReq
+}
+
+// TransformResponse converts an OpenRouter response to unified format. The
+// reasoning comes first, as a thinking block, and the upstream provider is
+// recorded in the metadata.
+func (t *Transformer) TransformResponse(resp *ChatCompletionResponse) *types.CompletionResponse {
+	if resp == nil {
+		return nil
+	}
+
+	result := t.openai.TransformResponse(&resp.ChatCompletionResponse)
+	if result == nil {
+		return nil
+	}
+	result.Provider = types.ProviderOpenRouter
+
+	if reasoning := resp.Choices[0].Message.Reasoning; reasoning != "" {
+		result.Content = append([]types.ContentBlock{{Type: types.ContentTypeThinking, Text: reasoning}}, result.Content...)
+	}
+	if resp.Provider != "" {
+		result.Metadata = map[string]any{MetadataUpstreamProvider: resp.Provider}
+	}
+	if resp.Usage != nil {
+		result.Usage = transformUsage(resp.Usage)
+	}
+
+	return result
+}
+
+// TransformStopReason converts an OpenRouter finish reason to unified
+// format. OpenRouter normalizes the upstream reasons to OpenAI's.
+func (t *Transformer) TransformStopReason(reason string) types.StopReason {
+	return t.openai.TransformStopReason(reason)
+}
+
+// transformUsage converts OpenRouter usage, including the billed cost.
+func transformUsage(u *Usage) types.Usage {
+	usage := types.Usage{
+		InputTokens:  u.PromptTokens,
+		OutputTokens: u.CompletionTokens,
+		TotalTokens:  u.TotalTokens,
+		Cost:         u.Cost,
+	}
+	if u.PromptTokensDetails != nil {
+		usage.CachedTokens = u.PromptTokensDetails.CachedTokens
+	}
+	if u.CompletionTokensDetails != nil {
+		usage.ReasoningTokens = u.CompletionTokensDetails.ReasoningTokens
+	}
+	return usage
+}
+
+// transformChunk decodes the usage, with its cost, and the upstream provider
+// of a stream chunk.
+func transformChunk(data []byte) (*types.Usage, map[string]any) {
+	var chunk StreamChunk
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil, nil
+	}
+	var usage *types.Usage
+	if chunk.Usage != nil {
+		u := transformUsage(chunk.Usage)
+		usage = &u
+	}
+	var metadata map[string]any
+	if chunk.Provider != "" {
+		metadata = map[string]any{MetadataUpstreamProvider: chunk.Provider}
+	}
+	return usage, metadata
+}
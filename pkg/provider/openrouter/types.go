@@ -0,0 +1,151 @@
+package openrouter
+
+import "github.com/Chloe199719/agent-router/pkg/provider/openai"
+
+// ChatCompletionRequest is an OpenRouter chat completion request. Messages,
+// tools, and response formats use the OpenAI wire format.
+type ChatCompletionRequest struct {
+	Model             string                 `json:"model"`
+	Messages          []openai.ChatMessage   `json:"messages"`
+	MaxTokens         *int                   `json:"max_tokens,omitempty"`
+	Temperature       *float64               `json:"temperature,omitempty"`
+	TopP              *float64               `json:"top_p,omitempty"`
+	TopK              *int                   `json:"top_k,omitempty"`
+	Stream            bool                   `json:"stream,omitempty"`
+	Stop              []string               `json:"stop,omitempty"`
+	PresencePenalty   *float64               `json:"presence_penalty,omitempty"`
+	FrequencyPenalty  *float64               `json:"frequency_penalty,omitempty"`
+	ResponseFormat    *openai.ResponseFormat `json:"response_format,omitempty"`
+	Tools             []openai.Tool          `json:"tools,omitempty"`
+	ToolChoice        any                    `json:"tool_choice,omitempty"`
+	ParallelToolCalls *bool                  `json:"parallel_tool_calls,omitempty"`
+	Seed              *int                   `json:"seed,omitempty"`
+	Reasoning         *Reasoning             `json:"reasoning,omitempty"`
+	Provider          any                    `json:"provider,omitempty"`
+	Models            []string               `json:"models,omitempty"`
+	Usage             *UsageOptions          `json:"usage,omitempty"`
+}
+
+// ProviderPreferences controls which upstream providers OpenRouter routes a
+// request to. Pass it as the "provider" entry of CompletionRequest.Extra.
+type ProviderPreferences struct {
+	// Order lists provider slugs to try first, such as "anthropic" or
+	// "together".
+	Order []string `json:"order,omitempty"`
+
+	// AllowFallbacks allows providers outside Order when those fail.
+	// OpenRouter defaults to true.
+	AllowFallbacks *bool `json:"allow_fallbacks,omitempty"`
+
+	// RequireParameters only routes to providers that support every
+	// parameter of the request.
+	RequireParameters bool `json:"require_parameters,omitempty"`
+
+	// DataCollection is "deny" to skip providers that may store or train
+	// on prompts.
+	DataCollection string `json:"data_collection,omitempty"`
+
+	// Only and Ignore allow or exclude provider slugs.
+	Only   []string `json:"only,omitempty"`
+	Ignore []string `json:"ignore,omitempty"`
+
+	// Quantizations restricts the model quantizations served, such as
+	// "fp8".
+	Quantizations []string `json:"quantizations,omitempty"`
+
+	// Sort orders providers by "price", "throughput", or "latency"
+	// instead of OpenRouter's load balancing.
+	Sort string `json:"sort,omitempty"`
+
+	// MaxPrice caps the price, in USD per million tokens, of the providers
+	// used.
+	MaxPrice *MaxPrice `json:"max_price,omitempty"`
+}
+
+// MaxPrice is a price limit in USD per million tokens.
+type MaxPrice struct {
+	Prompt     *float64 `json:"prompt,omitempty"`
+	Completion *float64 `json:"completion,omitempty"`
+}
+
+// Reasoning configures reasoning tokens. Effort and MaxTokens are exclusive.
+type Reasoning struct {
+	Effort    string `json:"effort,omitempty"`
+	MaxTokens *int   `json:"max_tokens,omitempty"`
+	Exclude   bool   `json:"exclude,omitempty"`
+}
+
+// UsageOptions asks for the request's cost in the usage.
+type UsageOptions struct {
+	Include bool `json:"include"`
+}
+
+// ChatCompletionResponse is an OpenRouter chat completion response. Model
+// is the model that served the request and Provider the upstream provider
+// it ran on.
+type ChatCompletionResponse struct {
+	openai.ChatCompletionResponse
+	Provider string `json:"provider,omitempty"`
+	Usage    *Usage `json:"usage,omitempty"`
+}
+
+// StreamChunk is a streaming chunk. Every chunk names the model and
+// upstream provider.
+type StreamChunk struct {
+	openai.StreamChunk
+	Provider string `json:"provider,omitempty"`
+	Usage    *Usage `json:"usage,omitempty"`
+}
+
+// Usage is token usage with the request's cost in USD.
+type Usage struct {
+	openai.Usage
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// ErrorResponse is an OpenRouter error response.
+type ErrorResponse struct {
+	Error *APIError `json:"error"`
+}
+
+// APIError is an OpenRouter API error. Code is the HTTP status code;
+// Metadata carries details such as the upstream provider's raw error or
+// the moderation reasons of a blocked request.
+type APIError struct {
+	Code     int            `json:"code"`
+	Message  string         `json:"message"`
+	Metadata map[string]any `json:"metadata,omitempty"`
+}
+
+// ModelList is the response of the models endpoint.
+type ModelList struct {
+	Data []Model `json:"data"`
+}
+
+// Model is a model available on OpenRouter.
+type Model struct {
+	ID            string       `json:"id"` // "vendor/model", such as "anthropic/claude-sonnet-4.5"
+	Name          string       `json:"name"`
+	Description   string       `json:"description,omitempty"`
+	Created       int64        `json:"created"`
+	ContextLength int          `json:"context_length"`
+	Architecture  Architecture `json:"architecture"`
+	Pricing       ModelPricing `json:"pricing"`
+	TopProvider   TopProvider  `json:"top_provider"`
+}
+
+// Architecture describes a model's modalities.
+type Architecture struct {
+	InputModalities []string `json:"input_modalities"`
+}
+
+// ModelPricing is a model's price in USD per token, as decimal strings.
+type ModelPricing struct {
+	Prompt     string `json:"prompt"`
+	Completion string `json:"completion"`
+}
+
+// TopProvider describes the limits of the model's primary provider.
+type TopProvider struct {
+	MaxCompletionTokens int `json:"max_completion_tokens"`
+}
@@ -3,6 +3,9 @@ package provider
 
 import (
 	"context"
+	"fmt"
+	"io"
+	"iter"
 	"net/http"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
@@ -46,6 +49,60 @@ type BatchProvider interface {
 	ListBatches(ctx context.Context, opts *ListBatchOptions) ([]BatchJob, error)
 }
 
+// BatchResultsIterator is an optional interface for batch providers that can
+// stream results from the provider's output file instead of loading it into
+// memory. The download starts when iteration begins and is closed when it
+// ends; a download or read failure is yielded as the final error.
+type BatchResultsIterator interface {
+	GetBatchResultsIter(ctx context.Context, batchID string) (iter.Seq2[BatchResult, error], error)
+}
+
+// BatchLister is an optional interface for batch providers that can page
+// through all of their batch jobs.
+type BatchLister interface {
+	// ListAllBatches lists every batch job, following pagination cursors.
+	// opts.Limit, if set, is the page size; opts.After is the starting cursor.
+	ListAllBatches(ctx context.Context, opts *ListBatchOptions) ([]BatchJob, error)
+}
+
+// BatchInputReader is an optional interface for batch providers that can
+// create a batch from input in the provider's own JSONL format, streamed from
+// a reader rather than built from a slice of requests.
+type BatchInputReader interface {
+	// WriteBatchInput writes requests to w as the provider's JSONL batch
+	// input, one line per request.
+	WriteBatchInput(w io.Writer, requests iter.Seq[BatchRequest]) error
+
+	// CreateBatchFromReader creates a batch from JSONL input read from r.
+	// size is the length of the input in bytes, or -1 if it is unknown.
+	CreateBatchFromReader(ctx context.Context, r io.Reader, size int64) (*BatchJob, error)
+}
+
+// BatchPageFunc fetches one page of batch jobs and returns the cursor for the
+// next page, or "" when there are no more.
+type BatchPageFunc func(ctx context.Context, opts *ListBatchOptions) ([]BatchJob, string, error)
+
+// ListAllBatches calls page until the cursor runs out and returns all jobs.
+func ListAllBatches(ctx context.Context, opts *ListBatchOptions, page BatchPageFunc) ([]BatchJob, error) {
+	pageOpts := ListBatchOptions{}
+	if opts != nil {
+		pageOpts = *opts
+	}
+
+	var all []BatchJob
+	for {
+		jobs, next, err := page(ctx, &pageOpts)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, jobs...)
+		if next == "" || next == pageOpts.After {
+			return all, nil
+		}
+		pageOpts.After = next
+	}
+}
+
 // BatchRequest wraps a completion request with a custom ID for batch processing.
 type BatchRequest struct {
 	// CustomID is a developer-provided ID for matching results to requests.
@@ -133,10 +190,20 @@ type Config struct {
 	// BaseURL overrides the default API endpoint.
 	BaseURL string
 
-	// HTTPClient is a custom HTTP client to use.
+	// HTTPClient is a custom HTTP client to use. It takes precedence over
+	// Transport.
 	HTTPClient *http.Client
 
-	// Timeout for requests (in seconds).
+	// Transport tunes the connection pool of the default HTTP client.
+	Transport *TransportConfig
+
+	// RequestCompression is the smallest request body, in bytes, that the
+	// default HTTP client gzips. Zero disables request compression.
+	RequestCompression int
+
+	// Timeout for requests (in seconds). It bounds non-streaming calls only;
+	// streams are bounded by the request context, CompletionRequest.Timeout,
+	// and CompletionRequest.StreamIdleTimeout so long generations are not cut off.
 	Timeout int
 
 	// MaxRetries is the maximum number of retries for failed requests.
@@ -151,14 +218,42 @@ type Config struct {
 	// Location is the Google Cloud region (for Vertex AI), e.g. "us-central1".
 	Location string
 
-	// AccessToken is an OAuth2 access token (alternative to APIKey, for Vertex AI).
+	// AccessToken is an OAuth2 access token (alternative to APIKey, for
+	// Google and Vertex AI).
 	AccessToken string
 
+	// TokenSource supplies OAuth2 access tokens when AccessToken is empty
+	// (for Google and Vertex AI).
+	TokenSource TokenSource
+
+	// APIKeyInQuery makes the Google client send the API key in the "key"
+	// query parameter instead of the x-goog-api-key header.
+	APIKeyInQuery bool
+
+	// Vertex makes the Anthropic client call Claude on Vertex AI instead of
+	// the Anthropic API. Set it with anthropic.WithVertex.
+	Vertex bool
+
+	// Bedrock makes the Anthropic client call Amazon Bedrock in Location.
+	Bedrock bool
+
+	// AWSCredentials sign Bedrock requests when APIKey is empty. Nil reads
+	// them from the AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, and
+	// AWS_SESSION_TOKEN environment variables.
+	AWSCredentials *AWSCredentials
+
 	// BatchBucket is the GCS bucket for Vertex AI batch input/output staging.
 	// Required for Vertex AI batch operations. Example: "my-bucket" or "my-bucket/batch-staging".
 	BatchBucket string
 }
 
+// AWSCredentials are AWS access keys for SigV4 request signing.
+type AWSCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
 // Option is a function that configures a provider.
 type Option func(*Config)
 
@@ -225,6 +320,38 @@ func WithAccessToken(token string) Option {
 	}
 }
 
+// WithTokenSource sets a source of OAuth2 access tokens, such as
+// DefaultCredentials.
+func WithTokenSource(ts TokenSource) Option {
+	return func(c *Config) {
+		c.TokenSource = ts
+	}
+}
+
+// WithAPIKeyInQuery makes the Google client send the API key in the URL
+// query string, for proxies that do not forward the x-goog-api-key header.
+// URLs end up in proxy and server logs, so prefer the default header.
+func WithAPIKeyInQuery() Option {
+	return func(c *Config) {
+		c.APIKeyInQuery = true
+	}
+}
+
+// WithAWSCredentials sets the AWS credentials that sign Bedrock requests.
+func WithAWSCredentials(creds AWSCredentials) Option {
+	return func(c *Config) {
+		c.AWSCredentials = &creds
+	}
+}
+
+// VertexBaseURL returns the Vertex AI endpoint for a region.
+func VertexBaseURL(location string) string {
+	if location == "global" {
+		return "https://aiplatform.googleapis.com/v1"
+	}
+	return fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1", location)
+}
+
 // WithBatchBucket sets the GCS bucket for Vertex AI batch staging.
 func WithBatchBucket(bucket string) Option {
 	return func(c *Config) {
@@ -232,6 +359,18 @@ func WithBatchBucket(bucket string) Option {
 	}
 }
 
+// StreamingClient returns a copy of client without its overall timeout.
+// http.Client.Timeout includes reading the body, which would abort long
+// streams mid-response; streams rely on context deadlines instead.
+func StreamingClient(client *http.Client) *http.Client {
+	if client.Timeout == 0 {
+		return client
+	}
+	c := *client
+	c.Timeout = 0
+	return &c
+}
+
 // DefaultConfig returns a default configuration.
 func DefaultConfig() *Config {
 	return &Config{
@@ -0,0 +1,55 @@
+package provider
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Do sends req with client and converts a transport failure into a
+// RouterError via RequestError.
+func Do(client *http.Client, req *http.Request, name types.Provider) (*http.Response, error) {
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, RequestError(req.Context(), name, time.Since(start), err)
+	}
+	return resp, nil
+}
+
+// requestIDHeaders are the headers providers return their request IDs in.
+var requestIDHeaders = []string{"request-id", "x-request-id", "x-amzn-requestid"}
+
+// RequestID returns the provider's request ID from response headers:
+// request-id (Anthropic), x-request-id (OpenAI and others), or
+// x-amzn-requestid (Bedrock).
+func RequestID(h http.Header) string {
+	for _, name := range requestIDHeaders {
+		if id := h.Get(name); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// RequestError classifies a failed request that ran for elapsed. If ctx's
+// deadline passed or the HTTP client timed out, it is a retryable timeout
+// error; otherwise the provider could not be reached. Credentials in the
+// failed request's URL are scrubbed from the cause.
+func RequestError(ctx context.Context, name types.Provider, elapsed time.Duration, err error) *errors.RouterError {
+	var urlErr *url.Error
+	if stderrors.As(err, &urlErr) {
+		urlErr.URL = errors.Scrub(urlErr.URL)
+	}
+
+	var netErr interface{ Timeout() bool }
+	if stderrors.Is(ctx.Err(), context.DeadlineExceeded) || (stderrors.As(err, &netErr) && netErr.Timeout()) {
+		return errors.ErrTimeoutAfter(name, elapsed).WithCause(err)
+	}
+	return errors.ErrProviderUnavailable(name, "request failed").WithCause(err)
+}
@@ -0,0 +1,53 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestDo_Timeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	_, err := Do(server.Client(), req, types.ProviderOpenAI)
+	assertTimeout(t, err)
+
+	// The HTTP client's own timeout counts too.
+	client := &http.Client{Timeout: 50 * time.Millisecond}
+	req, _ = http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err = Do(client, req, types.ProviderOpenAI)
+	assertTimeout(t, err)
+}
+
+func assertTimeout(t *testing.T, err error) {
+	t.Helper()
+	rerr, ok := err.(*errors.RouterError)
+	if !ok || rerr.Code != errors.ErrCodeTimeout || rerr.Provider != types.ProviderOpenAI {
+		t.Fatalf("expected an openai timeout error, got %v", err)
+	}
+	if elapsed, _ := rerr.Details["elapsed"].(time.Duration); elapsed < 50*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least 50ms", elapsed)
+	}
+}
+
+func TestDo_Unavailable(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	req, _ := http.NewRequest(http.MethodGet, server.URL, nil)
+	_, err := Do(http.DefaultClient, req, types.ProviderOpenAI)
+	if rerr, ok := err.(*errors.RouterError); !ok || rerr.Code != errors.ErrCodeProviderUnavailable {
+		t.Errorf("expected provider_unavailable error, got %v", err)
+	}
+}
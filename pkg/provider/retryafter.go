@@ -0,0 +1,29 @@
+package provider
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryAfter parses how long a provider asked to wait before retrying from
+// the response headers: retry-after-ms (OpenAI, Anthropic) in milliseconds,
+// or Retry-After as seconds or an HTTP date. It returns zero if neither is
+// set or parseable.
+func RetryAfter(h http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(h.Get("retry-after-ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil {
+		return max(time.Duration(secs*float64(time.Second)), 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(time.Until(t), 0)
+	}
+	return 0
+}
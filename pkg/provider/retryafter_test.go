@@ -0,0 +1,34 @@
+package provider
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryAfter(t *testing.T) {
+	date := time.Now().Add(time.Minute).UTC().Format(http.TimeFormat)
+	tests := []struct {
+		name    string
+		headers map[string]string
+		min     time.Duration
+		max     time.Duration
+	}{
+		{"none", nil, 0, 0},
+		{"seconds", map[string]string{"Retry-After": "20"}, 20 * time.Second, 20 * time.Second},
+		{"milliseconds win", map[string]string{"Retry-After": "20", "retry-after-ms": "1500"}, 1500 * time.Millisecond, 1500 * time.Millisecond},
+		{"http date", map[string]string{"Retry-After": date}, 55 * time.Second, time.Minute},
+		{"garbage", map[string]string{"Retry-After": "soon"}, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := http.Header{}
+			for k, v := range tt.headers {
+				h.Set(k, v)
+			}
+			if got := RetryAfter(h); got < tt.min || got > tt.max {
+				t.Errorf("RetryAfter = %v, want between %v and %v", got, tt.min, tt.max)
+			}
+		})
+	}
+}
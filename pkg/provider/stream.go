@@ -0,0 +1,56 @@
+package provider
+
+import (
+	"context"
+	"io"
+	"sync"
+)
+
+// StreamBody wraps a streaming response body so that cancelling the request
+// context immediately unblocks a pending read, regardless of the transport.
+// Close is idempotent and safe to call concurrently with Read.
+type StreamBody struct {
+	ctx  context.Context
+	body io.ReadCloser
+	stop func() bool
+
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// NewStreamBody returns a body that is closed as soon as ctx is done.
+func NewStreamBody(ctx context.Context, body io.ReadCloser) *StreamBody {
+	b := &StreamBody{ctx: ctx, body: body}
+	b.stop = context.AfterFunc(ctx, func() {
+		b.closeBody()
+	})
+	return b
+}
+
+// Read reads from the underlying body. Once the context is done, the
+// context's error is returned instead of the transport's.
+func (b *StreamBody) Read(p []byte) (int, error) {
+	n, err := b.body.Read(p)
+	if err != nil && b.ctx.Err() != nil {
+		return n, b.ctx.Err()
+	}
+	return n, err
+}
+
+// Err returns the context's error, if the stream was cancelled.
+func (b *StreamBody) Err() error {
+	return b.ctx.Err()
+}
+
+// Close closes the underlying body. Only the first call has an effect.
+func (b *StreamBody) Close() error {
+	b.stop()
+	return b.closeBody()
+}
+
+func (b *StreamBody) closeBody() error {
+	b.closeOnce.Do(func() {
+		b.closeErr = b.body.Close()
+	})
+	return b.closeErr
+}
@@ -0,0 +1,19 @@
+package provider
+
+import "github.com/Chloe199719/agent-router/pkg/types"
+
+// StripThinking returns messages without their thinking blocks, for APIs that
+// reject earlier reasoning sent back as history.
+func StripThinking(messages []types.Message) []types.Message {
+	result := make([]types.Message, len(messages))
+	for i, msg := range messages {
+		result[i] = msg
+		result[i].Content = nil
+		for _, block := range msg.Content {
+			if block.Type != types.ContentTypeThinking {
+				result[i].Content = append(result[i].Content, block)
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,16 @@
+package provider
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// TokenCounter is an optional interface for providers with a token counting
+// API.
+type TokenCounter interface {
+	// CountTokens returns the number of input tokens req would use,
+	// counting its messages, system prompt, and tools with the model's own
+	// tokenizer.
+	CountTokens(ctx context.Context, req *types.CompletionRequest) (int, error)
+}
@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"slices"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// PairToolResults returns messages with the ToolResultID and ToolName of each
+// tool result filled in from the tool call it answers, so transformers can
+// use whichever their API matches on. A result may name its call by ID, by
+// tool name, or neither: by name it answers the first unanswered call of
+// that name in the preceding assistant message, so parallel calls of one
+// function pair up in order, and with neither it answers the first
+// unanswered call. messages is not modified.
+func PairToolResults(messages []types.Message) []types.Message {
+	var calls []types.ContentBlock
+	var answered []bool
+	out := slices.Clone(messages)
+
+	for i, msg := range messages {
+		cloned := false
+		if msg.Role == types.RoleAssistant {
+			calls = calls[:0]
+			for _, block := range msg.Content {
+				if block.Type == types.ContentTypeToolUse {
+					calls = append(calls, block)
+				}
+			}
+			answered = make([]bool, len(calls))
+			continue
+		}
+
+		for j, block := range msg.Content {
+			if block.Type != types.ContentTypeToolResult {
+				continue
+			}
+			k := matchToolCall(calls, answered, block)
+			if k < 0 {
+				continue
+			}
+			answered[k] = true
+			if block.ToolResultID == calls[k].ToolUseID && block.ToolName == calls[k].ToolName {
+				continue
+			}
+
+			if !cloned {
+				out[i].Content = slices.Clone(msg.Content)
+				cloned = true
+			}
+			out[i].Content[j].ToolResultID = calls[k].ToolUseID
+			out[i].Content[j].ToolName = calls[k].ToolName
+		}
+	}
+	return out
+}
+
+// matchToolCall returns the index of the call a tool result answers, or -1.
+func matchToolCall(calls []types.ContentBlock, answered []bool, result types.ContentBlock) int {
+	for k, call := range calls {
+		switch {
+		case answered[k]:
+		case result.ToolResultID != "":
+			if call.ToolUseID == result.ToolResultID {
+				return k
+			}
+		case result.ToolName != "":
+			if call.ToolName == result.ToolName {
+				return k
+			}
+		default:
+			return k
+		}
+	}
+	return -1
+}
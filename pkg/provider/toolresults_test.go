@@ -0,0 +1,69 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestPairToolResults(t *testing.T) {
+	call := func(id, name string) types.ContentBlock {
+		return types.ContentBlock{Type: types.ContentTypeToolUse, ToolUseID: id, ToolName: name}
+	}
+	result := func(id, name string) types.ContentBlock {
+		return types.ContentBlock{Type: types.ContentTypeToolResult, ToolResultID: id, ToolName: name}
+	}
+
+	messages := []types.Message{
+		{Role: types.RoleAssistant, Content: []types.ContentBlock{
+			call("call_1", "get_weather"),
+			call("call_2", "get_weather"),
+			call("call_3", "get_time"),
+			call("call_4", "search"),
+		}},
+		{Role: types.RoleTool, Content: []types.ContentBlock{
+			result("", "get_time"),
+			result("call_2", ""),
+			result("", "get_weather"),
+		}},
+		{Role: types.RoleTool, Content: []types.ContentBlock{result("", "")}},
+		{Role: types.RoleAssistant, Content: []types.ContentBlock{call("call_5", "get_weather")}},
+		{Role: types.RoleTool, Content: []types.ContentBlock{result("", "get_weather")}},
+	}
+
+	paired := PairToolResults(messages)
+
+	want := [][]types.ContentBlock{
+		nil,
+		{result("call_3", "get_time"), result("call_2", "get_weather"), result("call_1", "get_weather")},
+		{result("call_4", "search")},
+		nil,
+		{result("call_5", "get_weather")},
+	}
+	for i, blocks := range want {
+		for j, w := range blocks {
+			got := paired[i].Content[j]
+			if got.ToolResultID != w.ToolResultID || got.ToolName != w.ToolName {
+				t.Errorf("message %d result %d = %q %q, want %q %q", i, j, got.ToolResultID, got.ToolName, w.ToolResultID, w.ToolName)
+			}
+		}
+	}
+
+	if messages[1].Content[0].ToolResultID != "" || messages[2].Content[0].ToolName != "" {
+		t.Error("PairToolResults modified its input")
+	}
+}
+
+func TestPairToolResults_UnknownID(t *testing.T) {
+	messages := []types.Message{
+		{Role: types.RoleAssistant, Content: []types.ContentBlock{
+			{Type: types.ContentTypeToolUse, ToolUseID: "call_1", ToolName: "get_weather"},
+		}},
+		types.NewToolResultMessage("call_9", "ok", false),
+	}
+
+	got := PairToolResults(messages)[1].Content[0]
+	if got.ToolResultID != "call_9" || got.ToolName != "" {
+		t.Errorf("result for an unknown call = %q %q, want it unchanged", got.ToolResultID, got.ToolName)
+	}
+}
@@ -0,0 +1,112 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// TransportConfig tunes the connection pool of a provider's HTTP client.
+// Zero fields keep the http.DefaultTransport settings.
+//
+// Without it, provider clients share http.DefaultTransport, which keeps
+// only two idle connections per host: under high concurrency most requests
+// then open a new TLS connection. A provider configured with WithTransport
+// gets a pool of its own, so its traffic cannot starve the others.
+type TransportConfig struct {
+	// MaxIdleConns caps idle connections across all hosts.
+	MaxIdleConns int
+
+	// MaxIdleConnsPerHost caps idle connections kept per host.
+	MaxIdleConnsPerHost int
+
+	// MaxConnsPerHost caps connections per host, including those in use.
+	// Requests over the cap wait for a connection.
+	MaxConnsPerHost int
+
+	// IdleConnTimeout closes connections idle for longer.
+	IdleConnTimeout time.Duration
+
+	// DisableKeepAlives opens a new connection for every request.
+	DisableKeepAlives bool
+
+	// DisableHTTP2 restricts the client to HTTP/1.1, for proxies and
+	// gateways that mishandle HTTP/2.
+	DisableHTTP2 bool
+
+	// Proxy selects the proxy for a request, as http.Transport.Proxy. Nil
+	// uses the HTTP_PROXY, HTTPS_PROXY, and NO_PROXY environment variables;
+	// use http.ProxyURL for a fixed proxy.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// WithTransport tunes the connection pool of the provider's HTTP client. It
+// has no effect with WithHTTPClient, whose transport is used as is.
+func WithTransport(t TransportConfig) Option {
+	return func(c *Config) {
+		c.Transport = &t
+	}
+}
+
+// NewTransport returns a clone of http.DefaultTransport with the config
+// applied.
+func (t TransportConfig) NewTransport() *http.Transport {
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	if t.MaxIdleConns > 0 {
+		tr.MaxIdleConns = t.MaxIdleConns
+	}
+	if t.MaxIdleConnsPerHost > 0 {
+		tr.MaxIdleConnsPerHost = t.MaxIdleConnsPerHost
+	}
+	if t.MaxConnsPerHost > 0 {
+		tr.MaxConnsPerHost = t.MaxConnsPerHost
+	}
+	if t.IdleConnTimeout > 0 {
+		tr.IdleConnTimeout = t.IdleConnTimeout
+	}
+	tr.DisableKeepAlives = t.DisableKeepAlives
+	if t.DisableHTTP2 {
+		tr.ForceAttemptHTTP2 = false
+		tr.Protocols = new(http.Protocols)
+		tr.Protocols.SetHTTP1(true)
+	}
+	if t.Proxy != nil {
+		tr.Proxy = t.Proxy
+	}
+	return tr
+}
+
+// NewHTTPClient returns the HTTP client a provider client should use:
+// cfg.HTTPClient if set, otherwise a client with cfg's timeout and, if
+// cfg.Transport is set, a tuned transport. The default client accepts and
+// decodes compressed responses in every encoding registered with
+// RegisterContentDecoder and compresses requests per cfg.RequestCompression.
+// The returned client is safe for concurrent use.
+func NewHTTPClient(cfg *Config) *http.Client {
+	if cfg.HTTPClient != nil {
+		return cfg.HTTPClient
+	}
+	var base http.RoundTripper
+	if cfg.Transport != nil {
+		base = cfg.Transport.NewTransport()
+	}
+	return &http.Client{
+		Timeout:   time.Duration(cfg.Timeout) * time.Second,
+		Transport: &compressionTransport{base: base, minSize: cfg.RequestCompression},
+	}
+}
+
+// IdleCloser is an optional interface for providers that pool connections.
+// CloseIdleConnections closes the pooled connections not carrying a request;
+// the router calls it on shutdown.
+type IdleCloser interface {
+	CloseIdleConnections()
+}
+
+// CloseIdleConnections closes the idle connections of rt, if it pools any.
+// Transports that wrap another should forward the call with it.
+func CloseIdleConnections(rt http.RoundTripper) {
+	if c, ok := rt.(interface{ CloseIdleConnections() }); ok {
+		c.CloseIdleConnections()
+	}
+}
@@ -0,0 +1,59 @@
+package provider
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestNewHTTPClient_Transport(t *testing.T) {
+	proxyURL, _ := url.Parse("http://proxy.internal:3128")
+	cfg := DefaultConfig()
+	ApplyOptions(cfg, WithTransport(TransportConfig{
+		MaxIdleConnsPerHost: 64,
+		MaxConnsPerHost:     128,
+		IdleConnTimeout:     30 * time.Second,
+		DisableHTTP2:        true,
+		Proxy:               http.ProxyURL(proxyURL),
+	}))
+
+	client := NewHTTPClient(cfg)
+	if client.Timeout != 120*time.Second {
+		t.Errorf("timeout = %v", client.Timeout)
+	}
+	ct, ok := client.Transport.(*compressionTransport)
+	if !ok {
+		t.Fatalf("transport = %T", client.Transport)
+	}
+	tr, ok := ct.base.(*http.Transport)
+	if !ok {
+		t.Fatalf("base transport = %T", ct.base)
+	}
+	if tr.MaxIdleConnsPerHost != 64 || tr.MaxConnsPerHost != 128 || tr.IdleConnTimeout != 30*time.Second {
+		t.Errorf("pool = %d idle, %d max, %v timeout", tr.MaxIdleConnsPerHost, tr.MaxConnsPerHost, tr.IdleConnTimeout)
+	}
+	if tr.MaxIdleConns != http.DefaultTransport.(*http.Transport).MaxIdleConns {
+		t.Errorf("unset MaxIdleConns = %d, want the default", tr.MaxIdleConns)
+	}
+	if tr.Protocols == nil || tr.Protocols.HTTP2() || !tr.Protocols.HTTP1() {
+		t.Errorf("protocols = %v, want HTTP/1.1 only", tr.Protocols)
+	}
+	got, _ := tr.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: "api.openai.com"}})
+	if got == nil || got.Host != "proxy.internal:3128" {
+		t.Errorf("proxy = %v", got)
+	}
+}
+
+func TestNewHTTPClient_Precedence(t *testing.T) {
+	custom := &http.Client{}
+	cfg := DefaultConfig()
+	ApplyOptions(cfg, WithTransport(TransportConfig{MaxIdleConnsPerHost: 64}), WithHTTPClient(custom))
+	if NewHTTPClient(cfg) != custom {
+		t.Error("WithHTTPClient should take precedence over WithTransport")
+	}
+
+	if ct, ok := NewHTTPClient(DefaultConfig()).Transport.(*compressionTransport); !ok || ct.base != nil {
+		t.Errorf("default transport should wrap http.DefaultTransport")
+	}
+}
@@ -4,10 +4,13 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"fmt"
 	"io"
+	"iter"
 	"net/http"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -70,7 +73,7 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 	outputURIPrefix := fmt.Sprintf("gs://%s/%s%s/output/", bucket, prefix, batchID)
 
 	if err := c.uploadToGCS(ctx, bucket, inputPath, buf.Bytes()); err != nil {
-		return nil, errors.ErrServerError(types.ProviderVertex, "failed to upload batch input to GCS").WithCause(err)
+		return nil, gcsError("failed to upload batch input to GCS", err)
 	}
 
 	// Create batch prediction job
@@ -104,11 +107,13 @@ func (c *Client) CreateBatch(ctx context.Context, requests []provider.BatchReque
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -133,7 +138,7 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 	}
 
 	url := fmt.Sprintf("%s/%s", c.baseURL, batchName)
-	if c.config.AccessToken == "" && c.config.APIKey != "" {
+	if c.usesAPIKey() {
 		url += "?key=" + c.config.APIKey
 	}
 
@@ -142,11 +147,13 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -164,6 +171,16 @@ func (c *Client) GetBatch(ctx context.Context, batchID string) (*provider.BatchJ
 
 // GetBatchResults retrieves the results of a completed batch prediction job.
 func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provider.BatchResult, error) {
+	seq, err := c.GetBatchResultsIter(ctx, batchID)
+	if err != nil {
+		return nil, err
+	}
+	return provider.CollectBatchResults(seq)
+}
+
+// GetBatchResultsIter streams the results of a completed batch prediction job,
+// decoding the GCS output file line by line.
+func (c *Client) GetBatchResultsIter(ctx context.Context, batchID string) (iter.Seq2[provider.BatchResult, error], error) {
 	job, err := c.GetBatch(ctx, batchID)
 	if err != nil {
 		return nil, err
@@ -181,11 +198,37 @@ func (c *Client) GetBatchResults(ctx context.Context, batchID string) ([]provide
 
 	// Download and parse results from GCS.
 	// custom_id is extracted from the echoed request labels in each output line.
-	return c.downloadBatchResults(ctx, outputDir)
+	return func(yield func(provider.BatchResult, error) bool) {
+		body, err := c.downloadBatchResults(ctx, outputDir)
+		if err != nil {
+			yield(provider.BatchResult{}, err)
+			return
+		}
+		defer body.Close()
+
+		// Parse JSONL output - each line contains a prediction result with the
+		// original request echoed back. We extract custom_id from the request's
+		// labels field where we embedded it during CreateBatch.
+		for data, err := range provider.JSONLines(body) {
+			if err != nil {
+				yield(provider.BatchResult{}, errors.ErrServerError(types.ProviderVertex, "failed to read batch results from GCS").WithCause(err))
+				return
+			}
+
+			var line VertexBatchOutputLine
+			if err := json.Unmarshal(data, &line); err != nil {
+				continue
+			}
+
+			if !yield(c.convertBatchOutputLine(&line), nil) {
+				return
+			}
+		}
+	}, nil
 }
 
-// downloadBatchResults downloads and parses JSONL results from a GCS output directory.
-func (c *Client) downloadBatchResults(ctx context.Context, gcsOutputDir string) ([]provider.BatchResult, error) {
+// downloadBatchResults opens the JSONL results file in a GCS output directory.
+func (c *Client) downloadBatchResults(ctx context.Context, gcsOutputDir string) (io.ReadCloser, error) {
 	// Vertex AI writes output files with dynamic names like "prediction-model-<timestamp>"
 	// so we need to list the output directory to find the actual result file.
 	bucket, prefix := parseGCSURI(strings.TrimSuffix(gcsOutputDir, "/") + "/")
@@ -196,53 +239,45 @@ func (c *Client) downloadBatchResults(ctx context.Context, gcsOutputDir string)
 	// List objects in the output directory to find the result file
 	objectPath, err := c.findBatchOutputFile(ctx, bucket, prefix)
 	if err != nil {
-		return nil, errors.ErrServerError(types.ProviderVertex, "failed to find batch output file in GCS").WithCause(err)
+		return nil, gcsError("failed to find batch output file in GCS", err)
 	}
 
-	content, err := c.downloadFromGCS(ctx, bucket, objectPath)
+	body, err := c.downloadFromGCS(ctx, bucket, objectPath)
 	if err != nil {
-		return nil, errors.ErrServerError(types.ProviderVertex, "failed to download batch results from GCS").WithCause(err)
+		return nil, gcsError("failed to download batch results from GCS", err)
 	}
 
-	// Parse JSONL output - each line contains a prediction result with the
-	// original request echoed back. We extract custom_id from the request's
-	// labels field where we embedded it during CreateBatch.
-	var results []provider.BatchResult
-	decoder := json.NewDecoder(bytes.NewReader(content))
-
-	for decoder.More() {
-		var line VertexBatchOutputLine
-		if err := decoder.Decode(&line); err != nil {
-			continue
-		}
+	return body, nil
+}
 
-		result := provider.BatchResult{}
+// convertBatchOutputLine converts a prediction output line to a provider batch result.
+func (c *Client) convertBatchOutputLine(line *VertexBatchOutputLine) provider.BatchResult {
+	result := provider.BatchResult{}
 
-		if line.Request != nil && len(line.Request.Labels) > 0 {
-			result.RequestLabels = make(map[string]string, len(line.Request.Labels))
-			for k, v := range line.Request.Labels {
-				result.RequestLabels[k] = v
-			}
-			if customID, ok := line.Request.Labels["custom_id"]; ok {
-				result.CustomID = customID
-			}
+	if line.Request != nil && len(line.Request.Labels) > 0 {
+		result.RequestLabels = make(map[string]string, len(line.Request.Labels))
+		for k, v := range line.Request.Labels {
+			result.RequestLabels[k] = v
 		}
-
-		if line.Response != nil {
-			result.Response = c.transformer.TransformResponse(line.Response)
-			if result.Response != nil {
-				result.Response.Provider = types.ProviderVertex
-			}
+		if customID, ok := line.Request.Labels["custom_id"]; ok {
+			result.CustomID = customID
 		}
+	}
 
-		if line.Status != "" {
-			result.Error = errors.ErrServerError(types.ProviderVertex, line.Status)
+	if line.Response != nil {
+		result.Response = c.transformer.TransformResponse(line.Response)
+		if result.Response != nil {
+			result.Response.Provider = types.ProviderVertex
 		}
+	}
 
-		results = append(results, result)
+	if line.Status != "" {
+		result.Error = errors.ErrServerError(types.ProviderVertex, line.Status)
+	} else if result.Response == nil {
+		result.Error = errors.ErrEmptyResponse(types.ProviderVertex, "batch result has no candidates")
 	}
 
-	return results, nil
+	return result
 }
 
 // findBatchOutputFile lists objects in a GCS directory and returns the path of the prediction output file.
@@ -255,13 +290,13 @@ func (c *Client) findBatchOutputFile(ctx context.Context, bucket, prefix string)
 		return "", fmt.Errorf("create list request: %w", err)
 	}
 
-	if c.config.AccessToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	if err := c.authorize(httpReq); err != nil {
+		return "", err
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return "", fmt.Errorf("list request: %w", err)
+		return "", err
 	}
 	defer resp.Body.Close()
 
@@ -307,7 +342,7 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 	}
 
 	url := fmt.Sprintf("%s/%s:cancel", c.baseURL, batchName)
-	if c.config.AccessToken == "" && c.config.APIKey != "" {
+	if c.usesAPIKey() {
 		url += "?key=" + c.config.APIKey
 	}
 
@@ -316,11 +351,13 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 		return errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -333,45 +370,58 @@ func (c *Client) CancelBatch(ctx context.Context, batchID string) error {
 
 // ListBatches lists batch prediction jobs.
 func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
-	url := fmt.Sprintf("%s/projects/%s/locations/%s/batchPredictionJobs",
+	jobs, _, err := c.listBatchesPage(ctx, opts)
+	return jobs, err
+}
+
+// ListAllBatches lists every batch prediction job, following page tokens.
+func (c *Client) ListAllBatches(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, error) {
+	return provider.ListAllBatches(ctx, opts, c.listBatchesPage)
+}
+
+// listBatchesPage fetches one page of batch prediction jobs and the next page token.
+func (c *Client) listBatchesPage(ctx context.Context, opts *provider.ListBatchOptions) ([]provider.BatchJob, string, error) {
+	endpoint := fmt.Sprintf("%s/projects/%s/locations/%s/batchPredictionJobs",
 		c.baseURL, c.projectID, c.location)
 
-	separator := "?"
-	if c.config.AccessToken == "" && c.config.APIKey != "" {
-		url += "?key=" + c.config.APIKey
-		separator = "&"
+	params := url.Values{}
+	if c.usesAPIKey() {
+		params.Set("key", c.config.APIKey)
 	}
-
 	if opts != nil {
 		if opts.Limit > 0 {
-			url += fmt.Sprintf("%spageSize=%d", separator, opts.Limit)
-			separator = "&"
+			params.Set("pageSize", strconv.Itoa(opts.Limit))
 		}
 		if opts.After != "" {
-			url += separator + "pageToken=" + opts.After
+			params.Set("pageToken", opts.After)
 		}
 	}
+	if len(params) > 0 {
+		endpoint += "?" + params.Encode()
+	}
 
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	httpReq, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
 	if err != nil {
-		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+		return nil, "", errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, "", err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return nil, "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, c.handleErrorResponse(resp)
+		return nil, "", c.handleErrorResponse(resp)
 	}
 
 	var listResp VertexBatchPredictionJobList
 	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
-		return nil, errors.ErrServerError(types.ProviderVertex, "failed to decode response").WithCause(err)
+		return nil, "", errors.ErrServerError(types.ProviderVertex, "failed to decode response").WithCause(err)
 	}
 
 	jobs := make([]provider.BatchJob, len(listResp.BatchPredictionJobs))
@@ -379,7 +429,7 @@ func (c *Client) ListBatches(ctx context.Context, opts *provider.ListBatchOption
 		jobs[i] = *c.convertVertexBatchJob(&job, "")
 	}
 
-	return jobs, nil
+	return jobs, listResp.NextPageToken, nil
 }
 
 // batchJobsURL returns the URL for the batchPredictionJobs endpoint.
@@ -387,13 +437,24 @@ func (c *Client) batchJobsURL() string {
 	url := fmt.Sprintf("%s/projects/%s/locations/%s/batchPredictionJobs",
 		c.baseURL, c.projectID, c.location)
 
-	if c.config.AccessToken == "" && c.config.APIKey != "" {
+	if c.usesAPIKey() {
 		url += "?key=" + c.config.APIKey
 	}
 
 	return url
 }
 
+// gcsError wraps a failed Cloud Storage call as a server error. Errors that
+// are already RouterErrors, such as transport timeouts classified by
+// provider.Do, are returned as is.
+func gcsError(message string, err error) error {
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) {
+		return rerr
+	}
+	return errors.ErrServerError(types.ProviderVertex, message).WithCause(err)
+}
+
 // uploadToGCS uploads data to a GCS bucket using the JSON API.
 func (c *Client) uploadToGCS(ctx context.Context, bucket, objectPath string, data []byte) error {
 	url := fmt.Sprintf("https://storage.googleapis.com/upload/storage/v1/b/%s/o?uploadType=media&name=%s",
@@ -405,13 +466,13 @@ func (c *Client) uploadToGCS(ctx context.Context, bucket, objectPath string, dat
 	}
 
 	httpReq.Header.Set("Content-Type", "application/jsonl")
-	if c.config.AccessToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	if err := c.authorize(httpReq); err != nil {
+		return err
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return fmt.Errorf("upload request: %w", err)
+		return err
 	}
 	defer resp.Body.Close()
 
@@ -423,8 +484,8 @@ func (c *Client) uploadToGCS(ctx context.Context, bucket, objectPath string, dat
 	return nil
 }
 
-// downloadFromGCS downloads an object from GCS using the JSON API.
-func (c *Client) downloadFromGCS(ctx context.Context, bucket, objectPath string) ([]byte, error) {
+// downloadFromGCS opens an object in GCS for download using the JSON API.
+func (c *Client) downloadFromGCS(ctx context.Context, bucket, objectPath string) (io.ReadCloser, error) {
 	// The GCS JSON API requires the object path to be URL-encoded
 	encodedPath := url.PathEscape(objectPath)
 	downloadURL := fmt.Sprintf("https://storage.googleapis.com/storage/v1/b/%s/o/%s?alt=media",
@@ -435,22 +496,22 @@ func (c *Client) downloadFromGCS(ctx context.Context, bucket, objectPath string)
 		return nil, fmt.Errorf("create download request: %w", err)
 	}
 
-	if c.config.AccessToken != "" {
-		httpReq.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+	if err := c.authorize(httpReq); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, fmt.Errorf("download request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
 		body, _ := io.ReadAll(resp.Body)
 		return nil, fmt.Errorf("GCS download failed with status %d: %s", resp.StatusCode, string(body))
 	}
 
-	return io.ReadAll(resp.Body)
+	return resp.Body, nil
 }
 
 // convertVertexBatchJob converts a Vertex AI batch prediction job to a provider BatchJob.
@@ -548,3 +609,9 @@ func parseGCSURI(uri string) (bucket, objectPath string) {
 
 // Ensure Client implements provider.BatchProvider
 var _ provider.BatchProvider = (*Client)(nil)
+
+// Ensure Client implements provider.BatchResultsIterator
+var _ provider.BatchResultsIterator = (*Client)(nil)
+
+// Ensure Client implements provider.BatchLister
+var _ provider.BatchLister = (*Client)(nil)
@@ -41,8 +41,9 @@ type Client struct {
 // New creates a new Vertex AI client.
 //
 // The projectID and location are required. Authentication is provided via
-// provider.WithAccessToken() (OAuth2 Bearer token) or provider.WithAPIKey()
-// (API key). At least one authentication method must be provided.
+// provider.WithAccessToken() or provider.WithTokenSource() (OAuth2 Bearer
+// token) or provider.WithAPIKey() (API key). Without any of them, the client
+// authenticates with Application Default Credentials.
 func New(projectID, location string, opts ...provider.Option) *Client {
 	cfg := provider.DefaultConfig()
 	provider.ApplyOptions(cfg, opts...)
@@ -56,23 +57,18 @@ func New(projectID, location string, opts ...provider.Option) *Client {
 
 	baseURL := cfg.BaseURL
 	if baseURL == "" {
-		if location == "global" {
-			baseURL = "https://aiplatform.googleapis.com/v1"
-		} else {
-			baseURL = fmt.Sprintf("https://%s-aiplatform.googleapis.com/v1", location)
-		}
+		baseURL = provider.VertexBaseURL(location)
 	}
 
-	httpClient := cfg.HTTPClient
-	if httpClient == nil {
-		httpClient = &http.Client{
-			Timeout: time.Duration(cfg.Timeout) * time.Second,
-		}
+	// Token requests bypass httpClient so they are not seen by transports
+	// that record traffic.
+	if cfg.AccessToken == "" && cfg.TokenSource == nil && cfg.APIKey == "" {
+		cfg.TokenSource = provider.DefaultCredentials(nil)
 	}
 
 	return &Client{
 		config:      cfg,
-		httpClient:  httpClient,
+		httpClient:  provider.NewHTTPClient(cfg),
 		projectID:   projectID,
 		location:    location,
 		baseURL:     baseURL,
@@ -93,7 +89,9 @@ func (c *Client) SupportsFeature(feature types.Feature) bool {
 		types.FeatureTools,
 		types.FeatureVision,
 		types.FeatureJSON,
-		types.FeatureBatch:
+		types.FeatureBatch,
+		types.FeatureSeed,
+		types.FeatureCandidates:
 		return true
 	default:
 		return false
@@ -128,11 +126,13 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(c.httpClient, httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -145,10 +145,22 @@ func (c *Client) Complete(ctx context.Context, req *types.CompletionRequest) (*t
 		return nil, errors.ErrServerError(types.ProviderVertex, "failed to decode response").WithCause(err)
 	}
 
+	if err := c.transformer.BlockedError(types.ProviderVertex, gResp.PromptFeedback); err != nil && len(gResp.Candidates) == 0 {
+		return nil, err
+	}
+
 	result := c.transformer.TransformResponse(&gResp)
-	if result != nil {
-		result.Provider = types.ProviderVertex
-		result.Model = req.Model
+	if result == nil {
+		return nil, errors.ErrEmptyResponse(types.ProviderVertex, "response has no candidates")
+	}
+	if err := c.transformer.FilterError(types.ProviderVertex, &gResp, result); err != nil {
+		return nil, err
+	}
+	result.Provider = types.ProviderVertex
+	result.Model = req.Model
+	for i := range result.Alternatives {
+		result.Alternatives[i].Provider = types.ProviderVertex
+		result.Alternatives[i].Model = req.Model
 	}
 	return result, nil
 }
@@ -169,11 +181,13 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
 	}
 
-	c.setHeaders(httpReq)
+	if err := c.setHeaders(httpReq); err != nil {
+		return nil, err
+	}
 
-	resp, err := c.httpClient.Do(httpReq)
+	resp, err := provider.Do(provider.StreamingClient(c.httpClient), httpReq, types.ProviderVertex)
 	if err != nil {
-		return nil, errors.ErrProviderUnavailable(types.ProviderVertex, "request failed").WithCause(err)
+		return nil, err
 	}
 
 	if resp.StatusCode != http.StatusOK {
@@ -181,7 +195,7 @@ func (c *Client) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, c.handleErrorResponse(resp)
 	}
 
-	return newStreamReader(resp.Body, c.transformer, req.Model), nil
+	return newStreamReader(ctx, resp.Body, c.transformer, req.Model), nil
 }
 
 // buildURL builds the Vertex AI API URL for a given model and action.
@@ -190,37 +204,60 @@ func (c *Client) buildURL(model, action string) string {
 		c.baseURL, c.projectID, c.location, model, action)
 
 	// If using API key auth (no access token), append key as query parameter
-	if c.config.AccessToken == "" && c.config.APIKey != "" {
+	if c.usesAPIKey() {
 		url += "?key=" + c.config.APIKey
 	}
 
 	return url
 }
 
+// usesAPIKey reports whether requests authenticate with the API key, sent in
+// the URL, rather than an OAuth2 Bearer token.
+func (c *Client) usesAPIKey() bool {
+	return c.config.AccessToken == "" && c.config.TokenSource == nil && c.config.APIKey != ""
+}
+
 // setHeaders sets the required headers for Vertex AI API requests.
-func (c *Client) setHeaders(req *http.Request) {
+func (c *Client) setHeaders(req *http.Request) error {
 	req.Header.Set("Content-Type", "application/json")
+	return c.authorize(req)
+}
 
-	// Prefer access token (OAuth2 Bearer), fall back to API key (handled in URL)
-	if c.config.AccessToken != "" {
-		req.Header.Set("Authorization", "Bearer "+c.config.AccessToken)
+// authorize adds the OAuth2 Bearer token, from the access token or the token
+// source, to a request. API keys are sent in the URL instead.
+func (c *Client) authorize(req *http.Request) error {
+	token := c.config.AccessToken
+	if token == "" && c.config.TokenSource != nil {
+		t, err := c.config.TokenSource.Token(req.Context())
+		if err != nil {
+			return errors.ErrAuthentication(types.ProviderVertex, "failed to get access token").WithCause(err)
+		}
+		token = t
 	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return nil
 }
 
 // handleErrorResponse converts an error response to a RouterError.
 func (c *Client) handleErrorResponse(resp *http.Response) error {
 	body, _ := io.ReadAll(resp.Body)
 
+	rerr := errors.ErrServerError(types.ProviderVertex, string(body)).WithStatusCode(resp.StatusCode)
 	var errResp googleProvider.ErrorResponse
 	if err := json.Unmarshal(body, &errResp); err == nil && errResp.Error != nil {
-		return c.mapAPIError(errResp.Error, resp.StatusCode)
+		rerr = c.mapAPIError(errResp.Error, resp.StatusCode)
 	}
-
-	return errors.ErrServerError(types.ProviderVertex, string(body)).WithStatusCode(resp.StatusCode)
+	return rerr.WithRetryAfter(provider.RetryAfter(resp.Header))
 }
 
 // mapAPIError maps Vertex AI API error to RouterError.
-func (c *Client) mapAPIError(apiErr *googleProvider.APIError, statusCode int) error {
+func (c *Client) mapAPIError(apiErr *googleProvider.APIError, statusCode int) *errors.RouterError {
+	if rerr := c.transformer.StatusError(types.ProviderVertex, apiErr, statusCode); rerr != nil {
+		return rerr
+	}
+
 	switch statusCode {
 	case http.StatusUnauthorized:
 		return errors.ErrInvalidAPIKey(types.ProviderVertex).WithStatusCode(statusCode)
@@ -258,13 +295,16 @@ func contains(s string, substrs ...string) bool {
 // Vertex AI uses the same JSON array streaming format as the Google Gemini API.
 type streamReader struct {
 	decoder      *json.Decoder
-	body         io.ReadCloser
+	body         *provider.StreamBody
 	transformer  *googleProvider.Transformer
 	model        string
 	response     *types.CompletionResponse
 	done         bool
 	arrayStarted bool
 
+	// pending holds events of the last chunk not yet returned by Next.
+	pending []*types.StreamEvent
+
 	// Accumulated state
 	content    []types.ContentBlock
 	thoughtBuf []types.ContentBlock
@@ -272,12 +312,17 @@ type streamReader struct {
 	usage      *types.Usage
 	stopReason types.StopReason
 	started    bool
+	safety     []googleProvider.SafetyRating
+	id         string
+	meta       types.ProviderMetadata
+	feedback   *googleProvider.PromptFeedback
 }
 
-func newStreamReader(body io.ReadCloser, transformer *googleProvider.Transformer, model string) *streamReader {
+func newStreamReader(ctx context.Context, body io.ReadCloser, transformer *googleProvider.Transformer, model string) *streamReader {
+	streamBody := provider.NewStreamBody(ctx, body)
 	return &streamReader{
-		decoder:     json.NewDecoder(body),
-		body:        body,
+		decoder:     json.NewDecoder(streamBody),
+		body:        streamBody,
 		transformer: transformer,
 		model:       model,
 	}
@@ -285,9 +330,15 @@ func newStreamReader(body io.ReadCloser, transformer *googleProvider.Transformer
 
 // Next returns the next stream event.
 func (s *streamReader) Next() (*types.StreamEvent, error) {
+	if len(s.pending) > 0 {
+		return s.pop(), nil
+	}
 	if s.done {
 		return nil, nil
 	}
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
 
 	// Send start event first
 	if !s.started {
@@ -328,12 +379,17 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 			continue
 		}
 
-		event := s.processChunk(&chunk)
-		if event != nil {
-			return event, nil
+		s.processChunk(&chunk)
+		if len(s.pending) > 0 {
+			return s.pop(), nil
 		}
 	}
 
+	// Stop on cancellation rather than reporting a truncated stream as finished
+	if err := s.body.Err(); err != nil {
+		return nil, err
+	}
+
 	// Array finished
 	s.done = true
 	s.buildResponse()
@@ -344,13 +400,45 @@ func (s *streamReader) Next() (*types.StreamEvent, error) {
 	}, nil
 }
 
-// processChunk processes a stream chunk and returns an event if applicable.
-func (s *streamReader) processChunk(chunk *googleProvider.StreamChunk) *types.StreamEvent {
-	if len(chunk.Candidates) == 0 {
+// emit queues an event for Next.
+func (s *streamReader) emit(event *types.StreamEvent) {
+	s.pending = append(s.pending, event)
+}
+
+// pop removes and returns the first pending event, or nil if there is none.
+func (s *streamReader) pop() *types.StreamEvent {
+	if len(s.pending) == 0 {
 		return nil
 	}
+	event := s.pending[0]
+	s.pending = s.pending[1:]
+	return event
+}
+
+// processChunk queues the events of a stream chunk.
+func (s *streamReader) processChunk(chunk *googleProvider.StreamChunk) {
+	if chunk.ResponseID != "" {
+		s.id = chunk.ResponseID
+	}
+	if chunk.ModelVersion != "" {
+		s.meta.ModelVersion = chunk.ModelVersion
+	}
+	if chunk.PromptFeedback != nil {
+		s.feedback = chunk.PromptFeedback
+	}
+	if len(chunk.Candidates) == 0 {
+		if err := s.transformer.BlockedError(types.ProviderVertex, chunk.PromptFeedback); err != nil {
+			s.done = true
+			s.buildResponse()
+			s.emit(&types.StreamEvent{Type: types.StreamEventError, Error: err})
+		}
+		return
+	}
 
 	candidate := chunk.Candidates[0]
+	if len(candidate.SafetyRatings) > 0 {
+		s.safety = candidate.SafetyRatings
+	}
 
 	// Handle finish reason
 	if candidate.FinishReason != "" {
@@ -367,7 +455,7 @@ func (s *streamReader) processChunk(chunk *googleProvider.StreamChunk) *types.St
 	}
 
 	if candidate.Content == nil {
-		return nil
+		return
 	}
 
 	// Process parts
@@ -375,7 +463,14 @@ func (s *streamReader) processChunk(chunk *googleProvider.StreamChunk) *types.St
 		if part.Text != "" {
 			if part.Thought {
 				s.appendThoughtText(part.Text)
-				return nil
+				s.emit(&types.StreamEvent{
+					Type: types.StreamEventThinkingDelta,
+					Delta: &types.ContentBlock{
+						Type: types.ContentTypeText,
+						Text: part.Text,
+					},
+				})
+				continue
 			}
 			s.thoughtBuf = nil
 			if len(s.content) == 0 || s.content[len(s.content)-1].Type != types.ContentTypeText {
@@ -387,35 +482,47 @@ func (s *streamReader) processChunk(chunk *googleProvider.StreamChunk) *types.St
 				s.content[len(s.content)-1].Text += part.Text
 			}
 
-			return &types.StreamEvent{
+			s.emit(&types.StreamEvent{
 				Type: types.StreamEventContentDelta,
 				Delta: &types.ContentBlock{
 					Type: types.ContentTypeText,
 					Text: part.Text,
 				},
-			}
+			})
+			continue
 		}
 
 		if part.FunctionCall != nil {
 			tc := types.ToolCall{
+				ID:    googleProvider.ToolCallID(part.FunctionCall, len(s.toolCalls)),
 				Name:  part.FunctionCall.Name,
 				Input: part.FunctionCall.Args,
 			}
 			s.toolCalls = append(s.toolCalls, tc)
 			s.content = append(s.content, types.ContentBlock{
 				Type:      types.ContentTypeToolUse,
+				ToolUseID: tc.ID,
 				ToolName:  part.FunctionCall.Name,
 				ToolInput: part.FunctionCall.Args,
 			})
 
-			return &types.StreamEvent{
+			// Calls arrive whole, so each ends as soon as it starts. The
+			// start carries the input too, for consumers that only read
+			// starts.
+			index := len(s.toolCalls) - 1
+			start, end := tc, tc
+			s.emit(&types.StreamEvent{
 				Type:     types.StreamEventToolCallStart,
-				ToolCall: &tc,
-			}
+				ToolCall: &start,
+				Index:    index,
+			})
+			s.emit(&types.StreamEvent{
+				Type:     types.StreamEventToolCallEnd,
+				ToolCall: &end,
+				Index:    index,
+			})
 		}
 	}
-
-	return nil
 }
 
 func (s *streamReader) appendThoughtText(text string) {
@@ -449,20 +556,23 @@ func (s *streamReader) buildResponse() {
 	}
 
 	s.response = &types.CompletionResponse{
-		Provider:   types.ProviderVertex,
-		Model:      s.model,
-		Content:    content,
-		StopReason: s.stopReason,
-		ToolCalls:  s.toolCalls,
-		CreatedAt:  time.Now(),
+		ID:               s.id,
+		Provider:         types.ProviderVertex,
+		Model:            s.model,
+		Content:          content,
+		StopReason:       s.stopReason,
+		ToolCalls:        s.toolCalls,
+		CreatedAt:        time.Now(),
+		ProviderMetadata: s.meta,
 	}
 
 	if s.usage != nil {
 		s.response.Usage = *s.usage
 	}
+	s.response.Metadata = s.transformer.SafetyMetadata(s.safety, s.feedback)
 }
 
-// Close closes the stream.
+// Close closes the stream. It is idempotent and safe to call while Next is blocked.
 func (s *streamReader) Close() error {
 	return s.body.Close()
 }
@@ -472,5 +582,13 @@ func (s *streamReader) Response() *types.CompletionResponse {
 	return s.response
 }
 
+// CloseIdleConnections closes the client's idle HTTP connections.
+func (c *Client) CloseIdleConnections() {
+	c.httpClient.CloseIdleConnections()
+}
+
 // Ensure Client implements provider.Provider
 var _ provider.Provider = (*Client)(nil)
+
+// Ensure Client implements provider.IdleCloser
+var _ provider.IdleCloser = (*Client)(nil)
@@ -3,14 +3,17 @@ package vertex
 import (
 	"context"
 	"encoding/json"
+	stderrors "errors"
 	"io"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	googleProvider "github.com/Chloe199719/agent-router/pkg/provider/google"
 
+	"github.com/Chloe199719/agent-router/pkg/errors"
 	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -194,6 +197,56 @@ func TestSetHeaders_APIKey(t *testing.T) {
 	}
 }
 
+type staticToken string
+
+func (s staticToken) Token(context.Context) (string, error) { return string(s), nil }
+
+func TestSetHeaders_TokenSource(t *testing.T) {
+	client := New("proj", "loc", provider.WithAPIKey("my-api-key"), provider.WithTokenSource(staticToken("ya29.token")))
+
+	req, _ := http.NewRequest("POST", "https://example.com", nil)
+	if err := client.setHeaders(req); err != nil {
+		t.Fatal(err)
+	}
+	if req.Header.Get("Authorization") != "Bearer ya29.token" {
+		t.Errorf("expected Authorization 'Bearer ya29.token', got %q", req.Header.Get("Authorization"))
+	}
+	if url := client.buildURL("gemini-2.0-flash", "generateContent"); strings.Contains(url, "key=") {
+		t.Errorf("expected no API key in URL with a token source, got %s", url)
+	}
+}
+
+func TestComplete_DefaultCredentials(t *testing.T) {
+	metadata := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"access_token":"ya29.adc","expires_in":3600}`))
+	}))
+	defer metadata.Close()
+	t.Setenv("GOOGLE_APPLICATION_CREDENTIALS", "")
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("GCE_METADATA_HOST", strings.TrimPrefix(metadata.URL, "http://"))
+
+	var auth, query string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, query = r.Header.Get("Authorization"), r.URL.RawQuery
+		json.NewEncoder(w).Encode(googleProvider.GenerateContentResponse{
+			Candidates: []googleProvider.Candidate{{Content: &googleProvider.Content{Role: "model", Parts: []googleProvider.Part{{Text: "Hi"}}}, FinishReason: "STOP"}},
+		})
+	}))
+	defer server.Close()
+
+	client := New("my-project", "us-central1", provider.WithBaseURL(server.URL))
+	_, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer ya29.adc" || query != "" {
+		t.Errorf("Authorization = %q, query = %q, want the ADC token and no key", auth, query)
+	}
+}
+
 func TestComplete_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Verify the URL pattern
@@ -318,6 +371,72 @@ func TestComplete_ErrorResponse(t *testing.T) {
 	}
 }
 
+func TestComplete_PromptBlocked(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(googleProvider.GenerateContentResponse{
+			PromptFeedback: &googleProvider.PromptFeedback{
+				BlockReason: "SAFETY",
+				SafetyRatings: []googleProvider.SafetyRating{
+					{Category: "HARM_CATEGORY_DANGEROUS_CONTENT", Probability: "HIGH", Blocked: true},
+					{Category: "HARM_CATEGORY_HARASSMENT", Probability: "NEGLIGIBLE"},
+				},
+			},
+		})
+	}))
+	defer server.Close()
+
+	client := New("test-project", "us-central1",
+		provider.WithAccessToken("test-token"),
+		provider.WithBaseURL(server.URL),
+	)
+
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderVertex,
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Hello"),
+		},
+	})
+
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeContentBlocked {
+		t.Fatalf("expected content_blocked error, got resp %v, err %v", resp, err)
+	}
+	if rerr.Provider != types.ProviderVertex || rerr.Details["block_reason"] != "SAFETY" {
+		t.Errorf("unexpected error: %+v", rerr)
+	}
+	if categories, _ := rerr.Details["categories"].([]string); len(categories) != 1 || categories[0] != "HARM_CATEGORY_DANGEROUS_CONTENT" {
+		t.Errorf("expected blocked category, got %v", rerr.Details["categories"])
+	}
+}
+
+func TestComplete_NoCandidates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"candidates":[]}`))
+	}))
+	defer server.Close()
+
+	client := New("test-project", "us-central1",
+		provider.WithAccessToken("test-token"),
+		provider.WithBaseURL(server.URL),
+	)
+
+	resp, err := client.Complete(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderVertex,
+		Model:    "gemini-2.0-flash",
+		Messages: []types.Message{
+			types.NewTextMessage(types.RoleUser, "Hello"),
+		},
+	})
+
+	var rerr *errors.RouterError
+	if resp != nil || !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeEmptyResponse {
+		t.Fatalf("expected empty_response error, got resp %v, err %v", resp, err)
+	}
+}
+
 func TestComplete_RateLimitError(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusTooManyRequests)
@@ -1221,3 +1340,43 @@ func TestDownloadBatchResults_EchoedLabelsIncludeMetadata(t *testing.T) {
 		}
 	}
 }
+
+func TestGetBatchResults_GCSDownloadTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/batchPredictionJobs/") {
+			_ = json.NewEncoder(w).Encode(VertexBatchPredictionJob{
+				Name:       "projects/proj/locations/loc/batchPredictionJobs/123",
+				State:      "JOB_STATE_SUCCEEDED",
+				OutputInfo: &VertexBatchOutputInfo{GcsOutputDirectory: "gs://my-bucket/out/"},
+			})
+			return
+		}
+		if r.URL.Query().Get("alt") != "media" {
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"items": []map[string]string{{"name": "out/prediction-model-001"}},
+			})
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	client := New("proj", "loc",
+		provider.WithAccessToken("tok"),
+		provider.WithBaseURL(server.URL),
+	)
+	client.httpClient = &http.Client{
+		Transport: &rewriteTransport{
+			targetURL: server.URL[len("http://"):],
+			transport: http.DefaultTransport,
+		},
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetBatchResults(ctx, "projects/proj/locations/loc/batchPredictionJobs/123")
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeTimeout, "")) {
+		t.Fatalf("err = %v, want timeout", err)
+	}
+}
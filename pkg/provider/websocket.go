@@ -0,0 +1,311 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WebSocket opcodes (RFC 6455 section 5.2).
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// maxMessageSize caps a single WebSocket message, as maxLineSize caps an
+// event stream line.
+const maxMessageSize = 16 << 20
+
+// wsGUID is the key suffix of the opening handshake.
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// WebSocket is a WebSocket connection (RFC 6455) for the JSON messages of
+// the providers' realtime and streaming APIs. It answers pings and close
+// frames itself. One goroutine may read while others write; writes are
+// serialized.
+type WebSocket struct {
+	rwc    io.ReadWriteCloser
+	br     *bufio.Reader
+	client bool // client frames are masked
+
+	writeMu   sync.Mutex
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// DialWebSocket opens a WebSocket to rawURL, an http, https, ws, or wss URL,
+// with the given extra headers. The handshake goes through client, so its
+// transport, proxy, and TLS settings apply; its timeout does not, as it
+// would end the connection. name attributes handshake failures: a rejected
+// handshake is returned as a RouterError like a failed HTTP request.
+func DialWebSocket(ctx context.Context, client *http.Client, rawURL string, header http.Header, name types.Provider) (*WebSocket, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	switch {
+	case strings.HasPrefix(rawURL, "wss://"):
+		rawURL = "https://" + strings.TrimPrefix(rawURL, "wss://")
+	case strings.HasPrefix(rawURL, "ws://"):
+		rawURL = "http://" + strings.TrimPrefix(rawURL, "ws://")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", rawURL, nil)
+	if err != nil {
+		return nil, errors.ErrInvalidRequest("failed to create request").WithCause(err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	var nonce [16]byte
+	rand.Read(nonce[:])
+	key := base64.StdEncoding.EncodeToString(nonce[:])
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", key)
+
+	resp, err := Do(StreamingClient(client), req, name)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		defer resp.Body.Close()
+		return nil, handshakeError(resp, name)
+	}
+	rwc, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok || resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		resp.Body.Close()
+		return nil, errors.ErrServerError(name, "invalid websocket handshake response")
+	}
+
+	return &WebSocket{rwc: rwc, br: bufio.NewReader(rwc), client: true}, nil
+}
+
+// handshakeError converts a rejected handshake to a RouterError.
+func handshakeError(resp *http.Response, name types.Provider) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	message := strings.TrimSpace(string(body))
+	if message == "" {
+		message = resp.Status
+	}
+
+	var rerr *errors.RouterError
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		rerr = errors.ErrInvalidAPIKey(name)
+	case http.StatusForbidden:
+		rerr = errors.ErrAuthentication(name, message)
+	case http.StatusNotFound:
+		rerr = errors.ErrModelNotFound(name, message)
+	case http.StatusTooManyRequests:
+		rerr = errors.ErrRateLimit(name, message)
+	case http.StatusServiceUnavailable:
+		rerr = errors.ErrOverloaded(name, message)
+	case http.StatusBadRequest:
+		rerr = errors.ErrInvalidRequest(message).WithProvider(name)
+	default:
+		rerr = errors.ErrServerError(name, message)
+	}
+	return rerr.WithStatusCode(resp.StatusCode).WithRetryAfter(RetryAfter(resp.Header))
+}
+
+// UpgradeWebSocket accepts a WebSocket handshake on the server side, for
+// proxies and test servers speaking a provider's WebSocket protocol.
+func UpgradeWebSocket(w http.ResponseWriter, r *http.Request) (*WebSocket, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a websocket handshake", http.StatusBadRequest)
+		return nil, stderrors.New("not a websocket handshake")
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, stderrors.New("response writer cannot be hijacked")
+	}
+	conn, brw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Fprintf(brw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", acceptKey(key))
+	if err := brw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &WebSocket{rwc: conn, br: brw.Reader}, nil
+}
+
+// acceptKey returns the Sec-WebSocket-Accept value for a handshake key.
+func acceptKey(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// ReadMessage returns the payload of the next text or binary message. It
+// returns io.EOF when the peer closes the connection.
+func (ws *WebSocket) ReadMessage() ([]byte, error) {
+	var message []byte
+	for {
+		fin, opcode, payload, err := ws.readFrame()
+		if err != nil {
+			return nil, err
+		}
+
+		switch opcode {
+		case wsPing:
+			if err := ws.writeFrame(wsPong, payload); err != nil {
+				return nil, err
+			}
+		case wsPong:
+		case wsClose:
+			ws.writeFrame(wsClose, payload)
+			ws.rwc.Close()
+			return nil, io.EOF
+		case wsText, wsBinary, wsContinuation:
+			if len(message)+len(payload) > maxMessageSize {
+				return nil, fmt.Errorf("websocket message exceeds %d bytes", maxMessageSize)
+			}
+			message = append(message, payload...)
+			if fin {
+				return message, nil
+			}
+		default:
+			return nil, fmt.Errorf("unknown websocket opcode %#x", opcode)
+		}
+	}
+}
+
+// readFrame reads one frame, unmasking its payload.
+func (ws *WebSocket) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err := io.ReadFull(ws.br, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin = head[0]&0x80 != 0
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(ws.br, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket frame exceeds %d bytes", maxMessageSize)
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(ws.br, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload = make([]byte, size)
+	if _, err := io.ReadFull(ws.br, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends data as a text message. If ctx ends mid-write the
+// connection is closed, since a partial frame cannot be recovered.
+func (ws *WebSocket) WriteMessage(ctx context.Context, data []byte) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { ws.rwc.Close() })
+	defer stop()
+	return ws.writeFrame(wsText, data)
+}
+
+// writeFrame sends a single frame, masking it on the client side.
+func (ws *WebSocket) writeFrame(opcode byte, payload []byte) error {
+	frame := make([]byte, 0, 14+len(payload))
+	frame = append(frame, 0x80|opcode)
+
+	maskBit := byte(0)
+	if ws.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, maskBit|126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(n))
+	default:
+		frame = append(frame, maskBit|127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(n))
+	}
+
+	if ws.client {
+		var mask [4]byte
+		rand.Read(mask[:])
+		frame = append(frame, mask[:]...)
+		start := len(frame)
+		frame = append(frame, payload...)
+		for i := range payload {
+			frame[start+i] ^= mask[i%4]
+		}
+	} else {
+		frame = append(frame, payload...)
+	}
+
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+	_, err := ws.rwc.Write(frame)
+	return err
+}
+
+// closeTimeout bounds sending the close frame to an unresponsive peer.
+const closeTimeout = time.Second
+
+// Close sends a normal closure and closes the connection. It is idempotent
+// and safe to call while ReadMessage is blocked.
+func (ws *WebSocket) Close() error {
+	ws.closeOnce.Do(func() {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			ws.writeFrame(wsClose, []byte{0x03, 0xE8}) // 1000, normal closure
+		}()
+		select {
+		case <-done:
+		case <-time.After(closeTimeout):
+		}
+		ws.closeErr = ws.rwc.Close()
+	})
+	return ws.closeErr
+}
@@ -0,0 +1,98 @@
+package provider
+
+import (
+	"context"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestWebSocket_Echo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			t.Errorf("authorization = %q", r.Header.Get("Authorization"))
+		}
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer ws.Close()
+		// Ping first: the client must answer it while reading.
+		ws.writeFrame(wsPing, []byte("hi"))
+		for {
+			msg, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			ws.WriteMessage(context.Background(), msg)
+		}
+	}))
+	defer server.Close()
+
+	header := http.Header{"Authorization": {"Bearer key"}}
+	ws, err := DialWebSocket(context.Background(), server.Client(), "ws"+strings.TrimPrefix(server.URL, "http"), header, types.ProviderOpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	// Short, 16-bit, and 64-bit payload lengths.
+	for _, size := range []int{5, 300, 70000} {
+		want := strings.Repeat("x", size)
+		if err := ws.WriteMessage(context.Background(), []byte(want)); err != nil {
+			t.Fatal(err)
+		}
+		got, err := ws.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != want {
+			t.Fatalf("echo of %d bytes = %d bytes", size, len(got))
+		}
+	}
+}
+
+func TestWebSocket_PeerClose(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ws, err := UpgradeWebSocket(w, r)
+		if err != nil {
+			return
+		}
+		ws.WriteMessage(context.Background(), []byte("bye"))
+		ws.Close()
+	}))
+	defer server.Close()
+
+	ws, err := DialWebSocket(context.Background(), server.Client(), server.URL, nil, types.ProviderOpenAI)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if msg, err := ws.ReadMessage(); err != nil || string(msg) != "bye" {
+		t.Fatalf("message = %q, %v", msg, err)
+	}
+	if _, err := ws.ReadMessage(); err != io.EOF {
+		t.Fatalf("after close: err = %v, want io.EOF", err)
+	}
+}
+
+func TestDialWebSocket_Rejected(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "bad key", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	_, err := DialWebSocket(context.Background(), server.Client(), server.URL, nil, types.ProviderOpenAI)
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidAPIKey || rerr.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("err = %v, want an invalid API key error", err)
+	}
+}
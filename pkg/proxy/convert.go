@@ -0,0 +1,402 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// ChatCompletionRequest is the inbound OpenAI chat completions request body.
+// Fields that OpenAI accepts in more than one shape (stop, tool_choice) are kept raw.
+type ChatCompletionRequest struct {
+	Model               string                 `json:"model"`
+	Messages            []openai.ChatMessage   `json:"messages"`
+	MaxTokens           *int                   `json:"max_tokens,omitempty"`
+	MaxCompletionTokens *int                   `json:"max_completion_tokens,omitempty"`
+	Temperature         *float64               `json:"temperature,omitempty"`
+	TopP                *float64               `json:"top_p,omitempty"`
+	Seed                *int                   `json:"seed,omitempty"`
+	Stop                json.RawMessage        `json:"stop,omitempty"`
+	Stream              bool                   `json:"stream,omitempty"`
+	StreamOptions       *openai.StreamOptions  `json:"stream_options,omitempty"`
+	Tools               []openai.Tool          `json:"tools,omitempty"`
+	ToolChoice          json.RawMessage        `json:"tool_choice,omitempty"`
+	ParallelToolCalls   *bool                  `json:"parallel_tool_calls,omitempty"`
+	ResponseFormat      *openai.ResponseFormat `json:"response_format,omitempty"`
+	Metadata            map[string]string      `json:"metadata,omitempty"`
+	ReasoningEffort     string                 `json:"reasoning_effort,omitempty"`
+	ServiceTier         string                 `json:"service_tier,omitempty"`
+	Modalities          []string               `json:"modalities,omitempty"`
+	Audio               *openai.AudioParams    `json:"audio,omitempty"`
+	User                string                 `json:"user,omitempty"`
+}
+
+// ToUnified converts an OpenAI chat completions request into a unified request.
+// Provider and Model are left for the caller to fill in after model resolution.
+func ToUnified(req *ChatCompletionRequest) (*types.CompletionRequest, error) {
+	out := &types.CompletionRequest{
+		Temperature: req.Temperature,
+		TopP:        req.TopP,
+		Seed:        req.Seed,
+		Stream:      req.Stream,
+	}
+
+	out.MaxTokens = req.MaxCompletionTokens
+	if out.MaxTokens == nil {
+		out.MaxTokens = req.MaxTokens
+	}
+
+	messages, err := toUnifiedMessages(req.Messages)
+	if err != nil {
+		return nil, err
+	}
+	out.Messages = messages
+
+	stop, err := parseStop(req.Stop)
+	if err != nil {
+		return nil, err
+	}
+	out.StopSequences = stop
+
+	for _, tool := range req.Tools {
+		var params types.JSONSchema
+		if tool.Function.Parameters != nil {
+			data, _ := json.Marshal(tool.Function.Parameters)
+			if err := json.Unmarshal(data, &params); err != nil {
+				return nil, errors.ErrInvalidRequest(fmt.Sprintf("invalid parameters for tool %q", tool.Function.Name)).WithCause(err)
+			}
+		}
+		out.Tools = append(out.Tools, types.Tool{
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  params,
+		})
+	}
+
+	tc, err := parseToolChoice(req.ToolChoice)
+	if err != nil {
+		return nil, err
+	}
+	if req.ParallelToolCalls != nil && !*req.ParallelToolCalls {
+		if tc == nil {
+			tc = &types.ToolChoice{Type: types.ToolChoiceAuto}
+		}
+		tc.DisableParallelToolUse = true
+	}
+	out.ToolChoice = tc
+
+	if rf := req.ResponseFormat; rf != nil {
+		switch rf.Type {
+		case "json_object":
+			out.ResponseFormat = &types.ResponseFormat{Type: "json"}
+		case "json_schema":
+			if rf.JSONSchema == nil {
+				return nil, errors.ErrInvalidRequest("response_format json_schema requires a json_schema object")
+			}
+			var schema types.JSONSchema
+			data, _ := json.Marshal(rf.JSONSchema.Schema)
+			if err := json.Unmarshal(data, &schema); err != nil {
+				return nil, errors.ErrInvalidRequest("invalid response_format schema").WithCause(err)
+			}
+			strict := rf.JSONSchema.Strict
+			out.ResponseFormat = &types.ResponseFormat{
+				Type:        "json_schema",
+				Name:        rf.JSONSchema.Name,
+				Description: rf.JSONSchema.Description,
+				Schema:      &schema,
+				Strict:      &strict,
+			}
+		}
+	}
+
+	if len(req.Metadata) > 0 || req.User != "" {
+		out.Metadata = make(map[string]string, len(req.Metadata)+1)
+		for k, v := range req.Metadata {
+			out.Metadata[k] = v
+		}
+		if req.User != "" {
+			out.Metadata["user_id"] = req.User
+		}
+	}
+
+	if req.ReasoningEffort != "" {
+		out.Thinking = &types.ThinkingConfig{Effort: req.ReasoningEffort}
+	}
+
+	switch req.ServiceTier {
+	case "":
+	case "default":
+		out.ServiceTier = types.ServiceTierStandard
+	default:
+		out.ServiceTier = types.ServiceTier(req.ServiceTier)
+	}
+
+	if slices.Contains(req.Modalities, "audio") {
+		out.Audio = &types.AudioConfig{}
+		if req.Audio != nil {
+			out.Audio.Voice = req.Audio.Voice
+			out.Audio.Format = req.Audio.Format
+		}
+	}
+
+	return out, nil
+}
+
+// toUnifiedMessages converts OpenAI chat messages to unified messages.
+func toUnifiedMessages(messages []openai.ChatMessage) ([]types.Message, error) {
+	// Tool result messages only carry the call ID; Gemini needs the function name too.
+	toolNames := make(map[string]string)
+
+	var out []types.Message
+	for _, msg := range messages {
+		switch msg.Role {
+		case "system", "developer":
+			out = append(out, types.NewTextMessage(types.RoleSystem, contentText(msg.Content)))
+
+		case "tool":
+			result := types.NewToolResultMessage(msg.ToolCallID, contentText(msg.Content), false)
+			result.Content[0].ToolName = toolNames[msg.ToolCallID]
+			out = append(out, result)
+
+		case "assistant":
+			m := types.Message{Role: types.RoleAssistant}
+			if text := contentText(msg.Content); text != "" {
+				m.Content = append(m.Content, types.ContentBlock{Type: types.ContentTypeText, Text: text})
+			}
+			if msg.Audio != nil && msg.Audio.ID != "" {
+				m.Content = append(m.Content, types.ContentBlock{Type: types.ContentTypeAudio, AudioID: msg.Audio.ID})
+			}
+			for _, tc := range msg.ToolCalls {
+				var input any
+				if tc.Function.Arguments != "" {
+					if err := json.Unmarshal([]byte(tc.Function.Arguments), &input); err != nil {
+						return nil, errors.ErrInvalidRequest(fmt.Sprintf("invalid arguments for tool call %q", tc.ID)).WithCause(err)
+					}
+				}
+				toolNames[tc.ID] = tc.Function.Name
+				m.Content = append(m.Content, types.ContentBlock{
+					Type:      types.ContentTypeToolUse,
+					ToolUseID: tc.ID,
+					ToolName:  tc.Function.Name,
+					ToolInput: input,
+				})
+			}
+			out = append(out, m)
+
+		case "user":
+			blocks, err := contentBlocks(msg.Content)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, types.Message{Role: types.RoleUser, Content: blocks})
+
+		default:
+			return nil, errors.ErrInvalidRequest(fmt.Sprintf("unsupported message role: %s", msg.Role))
+		}
+	}
+
+	return out, nil
+}
+
+// contentText flattens OpenAI message content (string or parts) to text.
+func contentText(content any) string {
+	switch c := content.(type) {
+	case string:
+		return c
+	case []any:
+		var sb strings.Builder
+		for _, part := range c {
+			if p, ok := part.(map[string]any); ok && p["type"] == "text" {
+				text, _ := p["text"].(string)
+				sb.WriteString(text)
+			}
+		}
+		return sb.String()
+	default:
+		return ""
+	}
+}
+
+// contentBlocks converts OpenAI user content (string or parts) to unified content blocks.
+func contentBlocks(content any) ([]types.ContentBlock, error) {
+	switch c := content.(type) {
+	case string:
+		return []types.ContentBlock{{Type: types.ContentTypeText, Text: c}}, nil
+	case []any:
+		var blocks []types.ContentBlock
+		for _, part := range c {
+			p, ok := part.(map[string]any)
+			if !ok {
+				continue
+			}
+			switch p["type"] {
+			case "text":
+				text, _ := p["text"].(string)
+				blocks = append(blocks, types.ContentBlock{Type: types.ContentTypeText, Text: text})
+			case "image_url":
+				img, _ := p["image_url"].(map[string]any)
+				url, _ := img["url"].(string)
+				if url == "" {
+					return nil, errors.ErrInvalidRequest("image_url part requires a url")
+				}
+				blocks = append(blocks, imageBlock(url))
+			}
+		}
+		return blocks, nil
+	default:
+		return nil, nil
+	}
+}
+
+// imageBlock converts an image URL (http(s) or data URI) to a unified image block.
+func imageBlock(url string) types.ContentBlock {
+	// data:<media type>;base64,<data>
+	if rest, ok := strings.CutPrefix(url, "data:"); ok {
+		if meta, data, ok := strings.Cut(rest, ","); ok && strings.HasSuffix(meta, ";base64") {
+			return types.ContentBlock{
+				Type:        types.ContentTypeImage,
+				ImageBase64: data,
+				MediaType:   strings.TrimSuffix(meta, ";base64"),
+			}
+		}
+	}
+	return types.ContentBlock{Type: types.ContentTypeImage, ImageURL: url}
+}
+
+// parseStop accepts either a single stop string or an array of strings.
+func parseStop(raw json.RawMessage) ([]string, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var single string
+	if err := json.Unmarshal(raw, &single); err == nil {
+		return []string{single}, nil
+	}
+	var many []string
+	if err := json.Unmarshal(raw, &many); err != nil {
+		return nil, errors.ErrInvalidRequest("stop must be a string or an array of strings").WithCause(err)
+	}
+	return many, nil
+}
+
+// parseToolChoice accepts "auto" | "none" | "required" or {"type":"function","function":{"name":...}}.
+func parseToolChoice(raw json.RawMessage) (*types.ToolChoice, error) {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil, nil
+	}
+	var mode string
+	if err := json.Unmarshal(raw, &mode); err == nil {
+		switch mode {
+		case "auto":
+			return &types.ToolChoice{Type: types.ToolChoiceAuto}, nil
+		case "none":
+			return &types.ToolChoice{Type: types.ToolChoiceNone}, nil
+		case "required":
+			return &types.ToolChoice{Type: types.ToolChoiceRequired}, nil
+		default:
+			return nil, errors.ErrInvalidRequest(fmt.Sprintf("unsupported tool_choice: %s", mode))
+		}
+	}
+	var obj openai.ToolChoiceObject
+	if err := json.Unmarshal(raw, &obj); err != nil || obj.Function == nil {
+		return nil, errors.ErrInvalidRequest("invalid tool_choice")
+	}
+	return &types.ToolChoice{Type: types.ToolChoiceTool, Name: obj.Function.Name}, nil
+}
+
+// FromUnified converts a unified response into an OpenAI chat completion response.
+// model is echoed back as the client requested it.
+func FromUnified(resp *types.CompletionResponse, model string) *openai.ChatCompletionResponse {
+	msg := openai.ChatMessage{Role: "assistant", Content: resp.Text(), ReasoningContent: resp.Thinking(), Refusal: resp.Refusal}
+	for _, block := range resp.Content {
+		if block.Type == types.ContentTypeAudio {
+			msg.Audio = &openai.MessageAudio{ID: block.AudioID, Data: block.AudioBase64, Transcript: block.Text}
+			break
+		}
+	}
+	for _, tc := range resp.ToolCalls {
+		msg.ToolCalls = append(msg.ToolCalls, openai.ToolCall{
+			ID:   tc.ID,
+			Type: "function",
+			Function: openai.FunctionCall{
+				Name:      tc.Name,
+				Arguments: toolArguments(tc.Input),
+			},
+		})
+	}
+
+	created := resp.CreatedAt
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	return &openai.ChatCompletionResponse{
+		ID:      responseID(resp.ID),
+		Object:  "chat.completion",
+		Created: created.Unix(),
+		Model:   model,
+		Choices: []openai.Choice{{
+			Index:        0,
+			Message:      msg,
+			FinishReason: finishReason(resp.StopReason),
+		}},
+		Usage:             usage(&resp.Usage),
+		SystemFingerprint: resp.ProviderMetadata.SystemFingerprint,
+		ServiceTier:       resp.ProviderMetadata.ServiceTier,
+	}
+}
+
+// toolArguments serializes tool input as the JSON string OpenAI clients expect.
+func toolArguments(input any) string {
+	if input == nil {
+		return "{}"
+	}
+	data, err := json.Marshal(input)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// finishReason maps a unified stop reason to an OpenAI finish_reason.
+func finishReason(reason types.StopReason) string {
+	switch reason {
+	case types.StopReasonMaxTokens:
+		return "length"
+	case types.StopReasonToolUse:
+		return "tool_calls"
+	case types.StopReasonContentFilter:
+		return "content_filter"
+	default:
+		return "stop"
+	}
+}
+
+func usage(u *types.Usage) *openai.Usage {
+	if u == nil {
+		return nil
+	}
+	total := u.TotalTokens
+	if total == 0 {
+		total = u.InputTokens + u.OutputTokens
+	}
+	return &openai.Usage{
+		PromptTokens:     u.InputTokens,
+		CompletionTokens: u.OutputTokens,
+		TotalTokens:      total,
+	}
+}
+
+// responseID returns id, or a generated chatcmpl-style ID for providers that don't return one.
+func responseID(id string) string {
+	if id != "" {
+		return id
+	}
+	return fmt.Sprintf("chatcmpl-%d", time.Now().UnixNano())
+}
@@ -0,0 +1,440 @@
+// Package proxy exposes a router as an OpenAI-compatible HTTP API.
+//
+// Existing OpenAI SDK clients can point their base URL at the handler and reach
+// any configured provider by model name:
+//
+//	r, _ := router.New(router.WithOpenAI(key), router.WithAnthropic(key))
+//	http.ListenAndServe(":8080", proxy.NewHandler(r))
+//
+// Supported endpoints:
+//   - POST /v1/chat/completions (including stream=true and tools)
+//   - GET  /v1/models
+package proxy
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Handler serves the OpenAI-compatible API on top of a router.
+type Handler struct {
+	router    *router.Router
+	mux       *http.ServeMux
+	authToken string
+}
+
+// Option configures the handler.
+type Option func(*Handler)
+
+// WithAuthToken requires clients to send "Authorization: Bearer <token>".
+// Without it the handler accepts any (or no) API key.
+func WithAuthToken(token string) Option {
+	return func(h *Handler) {
+		h.authToken = token
+	}
+}
+
+// NewHandler creates a new OpenAI-compatible handler for the router.
+func NewHandler(r *router.Router, opts ...Option) *Handler {
+	h := &Handler{
+		router: r,
+		mux:    http.NewServeMux(),
+	}
+
+	for _, opt := range opts {
+		opt(h)
+	}
+
+	h.mux.HandleFunc("POST /v1/chat/completions", h.handleChatCompletions)
+	h.mux.HandleFunc("GET /v1/models", h.handleModels)
+
+	return h
+}
+
+// ServeHTTP implements http.Handler.
+func (h *Handler) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if h.authToken != "" && subtle.ConstantTimeCompare([]byte(req.Header.Get("Authorization")), []byte("Bearer "+h.authToken)) != 1 {
+		writeError(w, errors.NewError(errors.ErrCodeInvalidAPIKey, "invalid or missing API key").WithStatusCode(http.StatusUnauthorized))
+		return
+	}
+	h.mux.ServeHTTP(w, req)
+}
+
+// handleChatCompletions serves POST /v1/chat/completions.
+func (h *Handler) handleChatCompletions(w http.ResponseWriter, req *http.Request) {
+	var chatReq ChatCompletionRequest
+	if err := json.NewDecoder(req.Body).Decode(&chatReq); err != nil {
+		writeError(w, errors.ErrInvalidRequest("invalid request body").WithCause(err))
+		return
+	}
+
+	providerName, model, err := ResolveProvider(chatReq.Model, h.router.Providers())
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	unified, err := ToUnified(&chatReq)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	unified.Provider = providerName
+	unified.Model = model
+
+	// Accept the client's request ID, or generate one, and echo it back so
+	// the client can correlate the response with router and provider logs.
+	unified.RequestID = req.Header.Get("X-Request-Id")
+	if unified.RequestID == "" {
+		unified.RequestID = router.NewRequestID()
+	}
+	w.Header().Set("X-Request-Id", unified.RequestID)
+
+	// reasoning_effort only has a meaning on OpenAI; drop it elsewhere rather than fail validation.
+	if providerName != types.ProviderOpenAI {
+		unified.Thinking = nil
+	}
+
+	if chatReq.Stream {
+		includeUsage := chatReq.StreamOptions != nil && chatReq.StreamOptions.IncludeUsage
+		h.stream(w, req, unified, chatReq.Model, includeUsage)
+		return
+	}
+
+	resp, err := h.router.Complete(req.Context(), unified)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, FromUnified(resp, chatReq.Model))
+}
+
+// stream serves a chat completion as OpenAI server-sent events.
+func (h *Handler) stream(w http.ResponseWriter, req *http.Request, unified *types.CompletionRequest, model string, includeUsage bool) {
+	stream, err := h.router.Stream(req.Context(), unified)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	defer stream.Close()
+
+	flusher, _ := w.(http.Flusher)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	enc := newChunkEncoder(model)
+	send := func(chunk *openai.StreamChunk) {
+		data, _ := json.Marshal(chunk)
+		fmt.Fprintf(w, "data: %s\n\n", data)
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			writeStreamError(w, err)
+			break
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventError {
+			writeStreamError(w, event.Error)
+			break
+		}
+		for _, chunk := range enc.encode(event, includeUsage) {
+			send(chunk)
+		}
+		if event.Type == types.StreamEventDone {
+			break
+		}
+	}
+
+	fmt.Fprint(w, "data: [DONE]\n\n")
+	if flusher != nil {
+		flusher.Flush()
+	}
+}
+
+// chunkEncoder converts unified stream events into OpenAI chat.completion.chunk objects.
+type chunkEncoder struct {
+	id        string
+	model     string
+	created   int64
+	toolCalls int // number of tool calls started so far
+}
+
+func newChunkEncoder(model string) *chunkEncoder {
+	return &chunkEncoder{
+		id:      responseID(""),
+		model:   model,
+		created: time.Now().Unix(),
+	}
+}
+
+// encode returns the chunks to emit for a single unified event.
+func (e *chunkEncoder) encode(event *types.StreamEvent, includeUsage bool) []*openai.StreamChunk {
+	switch event.Type {
+	case types.StreamEventStart:
+		if event.ResponseID != "" {
+			e.id = event.ResponseID
+		}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{Role: "assistant"}, "")}
+
+	case types.StreamEventContentDelta:
+		if event.Delta == nil || event.Delta.Text == "" {
+			return nil
+		}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{Content: event.Delta.Text}, "")}
+
+	case types.StreamEventThinkingDelta:
+		if event.Delta == nil || event.Delta.Text == "" {
+			return nil
+		}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{ReasoningContent: event.Delta.Text}, "")}
+
+	case types.StreamEventAudioDelta:
+		if event.Delta == nil {
+			return nil
+		}
+		audio := &openai.MessageAudio{ID: event.Delta.AudioID, Data: event.Delta.AudioBase64, Transcript: event.Delta.Text}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{Audio: audio}, "")}
+
+	case types.StreamEventToolCallStart:
+		if event.ToolCall == nil {
+			return nil
+		}
+		idx := e.toolCalls
+		e.toolCalls++
+		tc := openai.ToolCall{
+			ID:       event.ToolCall.ID,
+			Type:     "function",
+			Function: openai.FunctionCall{Name: event.ToolCall.Name},
+			Index:    &idx,
+		}
+		if tc.ID == "" {
+			tc.ID = fmt.Sprintf("call_%d", idx)
+		}
+		// Providers that emit complete calls (Gemini) have the input up front.
+		if event.ToolCall.Input != nil {
+			tc.Function.Arguments = toolArguments(event.ToolCall.Input)
+		}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{ToolCalls: []openai.ToolCall{tc}}, "")}
+
+	case types.StreamEventToolCallDelta:
+		if event.ToolInputDelta == "" || e.toolCalls == 0 {
+			return nil
+		}
+		// Argument deltas always follow their call's start, so they belong to the latest call.
+		idx := e.toolCalls - 1
+		tc := openai.ToolCall{
+			Function: openai.FunctionCall{Arguments: event.ToolInputDelta},
+			Index:    &idx,
+		}
+		return []*openai.StreamChunk{e.chunk(openai.MessageDelta{ToolCalls: []openai.ToolCall{tc}}, "")}
+
+	case types.StreamEventDone:
+		chunks := []*openai.StreamChunk{e.chunk(openai.MessageDelta{}, finishReason(event.StopReason))}
+		if includeUsage && event.Usage != nil {
+			chunks = append(chunks, &openai.StreamChunk{
+				ID:      e.id,
+				Object:  "chat.completion.chunk",
+				Created: e.created,
+				Model:   e.model,
+				Choices: []openai.StreamChoice{},
+				Usage:   usage(event.Usage),
+			})
+		}
+		return chunks
+	}
+
+	return nil
+}
+
+func (e *chunkEncoder) chunk(delta openai.MessageDelta, finish string) *openai.StreamChunk {
+	return &openai.StreamChunk{
+		ID:      e.id,
+		Object:  "chat.completion.chunk",
+		Created: e.created,
+		Model:   e.model,
+		Choices: []openai.StreamChoice{{
+			Index:        0,
+			Delta:        delta,
+			FinishReason: finish,
+		}},
+	}
+}
+
+// handleModels serves GET /v1/models with "provider/model" IDs for every configured provider.
+func (h *Handler) handleModels(w http.ResponseWriter, req *http.Request) {
+	type model struct {
+		ID      string `json:"id"`
+		Object  string `json:"object"`
+		Created int64  `json:"created"`
+		OwnedBy string `json:"owned_by"`
+	}
+
+	providers := h.router.Providers()
+	sort.Slice(providers, func(i, j int) bool { return providers[i] < providers[j] })
+
+	data := []model{}
+	for _, p := range providers {
+		models, err := h.router.Models(p)
+		if err != nil {
+			continue
+		}
+		for _, m := range models {
+			data = append(data, model{ID: string(p) + "/" + m, Object: "model", OwnedBy: string(p)})
+		}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{"object": "list", "data": data})
+}
+
+// ResolveProvider picks the provider that should serve model.
+//
+// An explicit "provider/model" prefix (e.g. "anthropic/claude-sonnet-4-20250514") always wins.
+// Otherwise the model family is matched against the configured providers; Gemini models prefer
+// Google and fall back to Vertex. Any other "vendor/model" name goes to OpenRouter, which names
+// models that way. When exactly one provider is configured it serves every model.
+// The returned model has any provider prefix stripped.
+func ResolveProvider(model string, configured []types.Provider) (types.Provider, string, error) {
+	if model == "" {
+		return "", "", errors.ErrInvalidRequest("model is required")
+	}
+
+	has := func(p types.Provider) bool {
+		for _, c := range configured {
+			if c == p {
+				return true
+			}
+		}
+		return false
+	}
+
+	if prefix, rest, ok := strings.Cut(model, "/"); ok && has(types.Provider(prefix)) {
+		// OpenRouter's own models, such as "openrouter/auto", keep their prefix.
+		if types.Provider(prefix) == types.ProviderOpenRouter && !strings.Contains(rest, "/") {
+			return types.ProviderOpenRouter, model, nil
+		}
+		return types.Provider(prefix), rest, nil
+	}
+
+	m := strings.ToLower(model)
+	var candidates []types.Provider
+	switch {
+	case strings.HasPrefix(m, "gpt-"), strings.HasPrefix(m, "chatgpt-"),
+		strings.HasPrefix(m, "o1"), strings.HasPrefix(m, "o3"), strings.HasPrefix(m, "o4"):
+		candidates = []types.Provider{types.ProviderOpenAI}
+	case strings.HasPrefix(m, "claude"):
+		candidates = []types.Provider{types.ProviderAnthropic}
+	case strings.HasPrefix(m, "gemini"), strings.HasPrefix(m, "gemma"):
+		candidates = []types.Provider{types.ProviderGoogle, types.ProviderVertex}
+	case strings.HasPrefix(m, "deepseek"):
+		candidates = []types.Provider{types.ProviderDeepSeek}
+	case strings.HasPrefix(m, "command"):
+		candidates = []types.Provider{types.ProviderCohere}
+	case strings.Contains(m, "/"):
+		candidates = []types.Provider{types.ProviderOpenRouter}
+	}
+
+	for _, p := range candidates {
+		if has(p) {
+			return p, model, nil
+		}
+	}
+
+	if len(configured) == 1 {
+		return configured[0], model, nil
+	}
+
+	return "", "", errors.NewError(errors.ErrCodeModelNotFound, fmt.Sprintf("no configured provider serves model: %s", model)).WithStatusCode(http.StatusNotFound)
+}
+
+// errorBody is the OpenAI error envelope.
+type errorBody struct {
+	Error openai.APIError `json:"error"`
+}
+
+// writeError writes err in OpenAI's error format with a matching HTTP status.
+func writeError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	apiErr := openai.APIError{Message: err.Error(), Type: "server_error"}
+
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) {
+		apiErr.Message = rerr.Message
+		apiErr.Code = rerr.Code
+		status = statusForError(rerr)
+		if status < 500 {
+			apiErr.Type = "invalid_request_error"
+		}
+		if d := errors.RetryAfter(rerr); d > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+		}
+	}
+
+	writeJSON(w, status, errorBody{Error: apiErr})
+}
+
+// writeStreamError reports a mid-stream failure as a final data event.
+func writeStreamError(w http.ResponseWriter, err error) {
+	apiErr := openai.APIError{Message: err.Error(), Type: "server_error"}
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) {
+		apiErr.Code = rerr.Code
+	}
+	data, _ := json.Marshal(errorBody{Error: apiErr})
+	fmt.Fprintf(w, "data: %s\n\n", data)
+}
+
+// statusForError picks the HTTP status for a router error.
+func statusForError(e *errors.RouterError) int {
+	if e.StatusCode != 0 {
+		return e.StatusCode
+	}
+	switch e.Code {
+	case errors.ErrCodeInvalidRequest, errors.ErrCodeUnsupportedFeature, errors.ErrCodeContextLength:
+		return http.StatusBadRequest
+	case errors.ErrCodeAuthentication, errors.ErrCodeInvalidAPIKey:
+		return http.StatusUnauthorized
+	case errors.ErrCodeModelNotFound:
+		return http.StatusNotFound
+	case errors.ErrCodeRateLimit:
+		return http.StatusTooManyRequests
+	case errors.ErrCodeQuotaExceeded:
+		return http.StatusPaymentRequired
+	case errors.ErrCodeOverloaded:
+		return http.StatusServiceUnavailable
+	case errors.ErrCodeTimeout:
+		return http.StatusGatewayTimeout
+	case errors.ErrCodeProviderUnavailable:
+		return http.StatusBadGateway
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
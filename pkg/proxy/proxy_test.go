@@ -0,0 +1,353 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestResolveProvider(t *testing.T) {
+	all := []types.Provider{types.ProviderOpenAI, types.ProviderAnthropic, types.ProviderVertex}
+
+	tests := []struct {
+		model        string
+		configured   []types.Provider
+		wantProvider types.Provider
+		wantModel    string
+		wantErr      bool
+	}{
+		{"gpt-4o-mini", all, types.ProviderOpenAI, "gpt-4o-mini", false},
+		{"o3-mini", all, types.ProviderOpenAI, "o3-mini", false},
+		{"claude-haiku-4-5", all, types.ProviderAnthropic, "claude-haiku-4-5", false},
+		{"gemini-2.0-flash", all, types.ProviderVertex, "gemini-2.0-flash", false},
+		{"anthropic/claude-haiku-4-5", all, types.ProviderAnthropic, "claude-haiku-4-5", false},
+		{"meta-llama/llama-3.3-70b-instruct", []types.Provider{types.ProviderOpenAI, types.ProviderOpenRouter}, types.ProviderOpenRouter, "meta-llama/llama-3.3-70b-instruct", false},
+		{"openrouter/anthropic/claude-sonnet-4.5", []types.Provider{types.ProviderAnthropic, types.ProviderOpenRouter}, types.ProviderOpenRouter, "anthropic/claude-sonnet-4.5", false},
+		{"openrouter/auto", []types.Provider{types.ProviderOpenAI, types.ProviderOpenRouter}, types.ProviderOpenRouter, "openrouter/auto", false},
+		{"my-finetune", []types.Provider{types.ProviderOpenAI}, types.ProviderOpenAI, "my-finetune", false},
+		{"my-finetune", all, "", "", true},
+		{"", all, "", "", true},
+	}
+
+	for _, tt := range tests {
+		p, m, err := ResolveProvider(tt.model, tt.configured)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ResolveProvider(%q): expected error", tt.model)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ResolveProvider(%q): unexpected error: %v", tt.model, err)
+			continue
+		}
+		if p != tt.wantProvider || m != tt.wantModel {
+			t.Errorf("ResolveProvider(%q) = (%s, %s), want (%s, %s)", tt.model, p, m, tt.wantProvider, tt.wantModel)
+		}
+	}
+}
+
+func TestToUnified_MessagesAndTools(t *testing.T) {
+	body := `{
+		"model": "gpt-4o",
+		"messages": [
+			{"role": "system", "content": "Be brief."},
+			{"role": "user", "content": [
+				{"type": "text", "text": "What is this?"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,AAAA"}}
+			]},
+			{"role": "assistant", "content": null, "tool_calls": [
+				{"id": "call_1", "type": "function", "function": {"name": "get_weather", "arguments": "{\"location\":\"Paris\"}"}}
+			]},
+			{"role": "tool", "tool_call_id": "call_1", "content": "{\"temp\":20}"}
+		],
+		"stop": "END",
+		"max_tokens": 100,
+		"tools": [{"type": "function", "function": {"name": "get_weather", "parameters": {"type": "object", "properties": {"location": {"type": "string"}}}}}],
+		"tool_choice": {"type": "function", "function": {"name": "get_weather"}},
+		"parallel_tool_calls": false
+	}`
+
+	var req ChatCompletionRequest
+	if err := json.Unmarshal([]byte(body), &req); err != nil {
+		t.Fatal(err)
+	}
+
+	out, err := ToUnified(&req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(out.Messages) != 4 {
+		t.Fatalf("expected 4 messages, got %d", len(out.Messages))
+	}
+	if out.Messages[0].Role != types.RoleSystem || out.Messages[0].Content[0].Text != "Be brief." {
+		t.Errorf("unexpected system message: %+v", out.Messages[0])
+	}
+
+	img := out.Messages[1].Content[1]
+	if img.Type != types.ContentTypeImage || img.ImageBase64 != "AAAA" || img.MediaType != "image/png" {
+		t.Errorf("expected data URI converted to base64 image, got %+v", img)
+	}
+
+	call := out.Messages[2].Content[0]
+	if call.Type != types.ContentTypeToolUse || call.ToolName != "get_weather" {
+		t.Errorf("unexpected tool use block: %+v", call)
+	}
+	if input, _ := call.ToolInput.(map[string]any); input["location"] != "Paris" {
+		t.Errorf("expected parsed tool input, got %v", call.ToolInput)
+	}
+
+	result := out.Messages[3].Content[0]
+	if result.ToolResultID != "call_1" || result.ToolName != "get_weather" {
+		t.Errorf("expected tool result linked by ID and name, got %+v", result)
+	}
+
+	if len(out.StopSequences) != 1 || out.StopSequences[0] != "END" {
+		t.Errorf("expected single stop sequence, got %v", out.StopSequences)
+	}
+	if out.MaxTokens == nil || *out.MaxTokens != 100 {
+		t.Errorf("expected max tokens 100, got %v", out.MaxTokens)
+	}
+	if len(out.Tools) != 1 || out.Tools[0].Parameters.Properties["location"].Type != "string" {
+		t.Errorf("unexpected tools: %+v", out.Tools)
+	}
+	if out.ToolChoice == nil || out.ToolChoice.Type != types.ToolChoiceTool || out.ToolChoice.Name != "get_weather" || !out.ToolChoice.DisableParallelToolUse {
+		t.Errorf("unexpected tool choice: %+v", out.ToolChoice)
+	}
+}
+
+// newAnthropicBackend returns a fake Anthropic messages endpoint.
+func newAnthropicBackend(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\",\"model\":\"claude-haiku-4-5\"}}\n\n")
+			io.WriteString(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":0,\"content_block\":{\"type\":\"text\",\"text\":\"\"}}\n\n")
+			io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":0,\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\n\n")
+			io.WriteString(w, "event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":0}\n\n")
+			io.WriteString(w, "event: content_block_start\ndata: {\"type\":\"content_block_start\",\"index\":1,\"content_block\":{\"type\":\"tool_use\",\"id\":\"toolu_1\",\"name\":\"get_weather\"}}\n\n")
+			io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"{\\\"location\\\":\"}}\n\n")
+			io.WriteString(w, "event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"index\":1,\"delta\":{\"type\":\"input_json_delta\",\"partial_json\":\"\\\"Paris\\\"}\"}}\n\n")
+			io.WriteString(w, "event: content_block_stop\ndata: {\"type\":\"content_block_stop\",\"index\":1}\n\n")
+			io.WriteString(w, "event: message_delta\ndata: {\"type\":\"message_delta\",\"delta\":{\"stop_reason\":\"tool_use\"},\"usage\":{\"output_tokens\":7}}\n\n")
+			io.WriteString(w, "event: message_stop\ndata: {\"type\":\"message_stop\"}\n\n")
+			return
+		}
+
+		json.NewEncoder(w).Encode(map[string]any{
+			"id":          "msg_1",
+			"type":        "message",
+			"role":        "assistant",
+			"model":       "claude-haiku-4-5",
+			"stop_reason": "end_turn",
+			"content":     []map[string]any{{"type": "text", "text": "Hello from Claude"}},
+			"usage":       map[string]any{"input_tokens": 5, "output_tokens": 3},
+		})
+	}))
+}
+
+func newTestProxy(t *testing.T, backendURL string, opts ...Option) *httptest.Server {
+	t.Helper()
+	r, err := router.New(router.WithAnthropic("test-key", provider.WithBaseURL(backendURL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return httptest.NewServer(NewHandler(r, opts...))
+}
+
+func TestHandler_ChatCompletion(t *testing.T) {
+	backend := newAnthropicBackend(t)
+	defer backend.Close()
+	srv := newTestProxy(t, backend.URL)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"claude-haiku-4-5","messages":[{"role":"user","content":"Hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		t.Fatalf("expected 200, got %d: %s", resp.StatusCode, body)
+	}
+
+	var out openai.ChatCompletionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Model != "claude-haiku-4-5" {
+		t.Errorf("expected requested model echoed, got %q", out.Model)
+	}
+	if len(out.Choices) != 1 || out.Choices[0].Message.Content != "Hello from Claude" {
+		t.Errorf("unexpected choices: %+v", out.Choices)
+	}
+	if out.Choices[0].FinishReason != "stop" {
+		t.Errorf("expected finish_reason stop, got %q", out.Choices[0].FinishReason)
+	}
+	if out.Usage == nil || out.Usage.TotalTokens != 8 {
+		t.Errorf("unexpected usage: %+v", out.Usage)
+	}
+}
+
+func TestHandler_RequestID(t *testing.T) {
+	backend := newAnthropicBackend(t)
+	defer backend.Close()
+	srv := newTestProxy(t, backend.URL)
+	defer srv.Close()
+
+	body := `{"model":"claude-haiku-4-5","messages":[{"role":"user","content":"Hi"}]}`
+	req, _ := http.NewRequest("POST", srv.URL+"/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("X-Request-Id", "trace-123")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-Id"); got != "trace-123" {
+		t.Errorf("X-Request-Id = %q, want the client's ID echoed", got)
+	}
+
+	resp, err = http.Post(srv.URL+"/v1/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if got := resp.Header.Get("X-Request-Id"); !strings.HasPrefix(got, "req_") {
+		t.Errorf("X-Request-Id = %q, want a generated ID", got)
+	}
+}
+
+func TestHandler_ChatCompletionStream(t *testing.T) {
+	backend := newAnthropicBackend(t)
+	defer backend.Close()
+	srv := newTestProxy(t, backend.URL)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"claude-haiku-4-5","stream":true,"stream_options":{"include_usage":true},"messages":[{"role":"user","content":"Hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	var chunks []openai.StreamChunk
+	sawDone := false
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		data, ok := strings.CutPrefix(scanner.Text(), "data: ")
+		if !ok {
+			continue
+		}
+		if data == "[DONE]" {
+			sawDone = true
+			continue
+		}
+		var chunk openai.StreamChunk
+		if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+			t.Fatalf("invalid chunk %q: %v", data, err)
+		}
+		chunks = append(chunks, chunk)
+	}
+
+	if !sawDone {
+		t.Error("expected [DONE] terminator")
+	}
+
+	var text, args, finish, toolName string
+	var usage *openai.Usage
+	for _, c := range chunks {
+		if c.Usage != nil {
+			usage = c.Usage
+		}
+		for _, ch := range c.Choices {
+			text += ch.Delta.Content
+			for _, tc := range ch.Delta.ToolCalls {
+				if tc.Index == nil || *tc.Index != 0 {
+					t.Errorf("expected tool call index 0, got %v", tc.Index)
+				}
+				if tc.Function.Name != "" {
+					toolName = tc.Function.Name
+				}
+				args += tc.Function.Arguments
+			}
+			if ch.FinishReason != "" {
+				finish = ch.FinishReason
+			}
+		}
+	}
+
+	if text != "Hi" {
+		t.Errorf("expected text 'Hi', got %q", text)
+	}
+	if toolName != "get_weather" || args != `{"location":"Paris"}` {
+		t.Errorf("unexpected tool call: name=%q args=%q", toolName, args)
+	}
+	if finish != "tool_calls" {
+		t.Errorf("expected finish_reason tool_calls, got %q", finish)
+	}
+	if usage == nil || usage.CompletionTokens != 7 {
+		t.Errorf("expected usage chunk, got %+v", usage)
+	}
+}
+
+func TestHandler_AuthToken(t *testing.T) {
+	backend := newAnthropicBackend(t)
+	defer backend.Close()
+	srv := newTestProxy(t, backend.URL, WithAuthToken("secret"))
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/v1/models")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	req, _ := http.NewRequest("GET", srv.URL+"/v1/models", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 with token, got %d", resp.StatusCode)
+	}
+}
+
+func TestHandler_UnknownModel(t *testing.T) {
+	backend := newAnthropicBackend(t)
+	defer backend.Close()
+	srv := newTestProxy(t, backend.URL)
+	defer srv.Close()
+
+	resp, err := http.Post(srv.URL+"/v1/chat/completions", "application/json",
+		strings.NewReader(`{"model":"openai/gpt-4o","messages":[{"role":"user","content":"Hi"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	// Only Anthropic is configured, so it serves every model name, prefix included.
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected single provider to serve the request, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,268 @@
+// Package rag provides building blocks for retrieval-augmented generation
+// that work with any vector store or search index.
+//
+// A Retriever finds chunks for a query; Build numbers them as sources and
+// formats them into a context block within a token budget; Apply adds the
+// block to a request; and Citations turns the answer's [n] tags back into
+// citations of the chunks.
+//
+//	chunks, err := retriever.Retrieve(ctx, question, 8)
+//	c := rag.Build(chunks, rag.Options{MaxTokens: 4000})
+//	req := c.Apply(&types.CompletionRequest{...})
+//	resp, err := r.Complete(ctx, req)
+//	citations := c.Citations(resp.Text())
+package rag
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// defaultInstructions precede the sources in the context block.
+const defaultInstructions = "Answer using the numbered sources below. Cite the sources that support each statement with their numbers in brackets, like [1] or [2][3]. If the sources do not contain the answer, say so."
+
+// Chunk is a piece of retrieved text.
+type Chunk struct {
+	// ID identifies the chunk in the store.
+	ID string `json:"id,omitempty"`
+
+	// Text is the content given to the model.
+	Text string `json:"text"`
+
+	// Title and URL describe where the chunk comes from. They are shown to
+	// the model and copied to citations.
+	Title string `json:"title,omitempty"`
+	URL   string `json:"url,omitempty"`
+
+	// Score is the retrieval score, higher is more relevant.
+	Score float64 `json:"score,omitempty"`
+
+	// Metadata holds store-specific fields. It is not shown to the model.
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// Retriever finds the chunks most relevant to a query, most relevant first.
+type Retriever interface {
+	Retrieve(ctx context.Context, query string, k int) ([]Chunk, error)
+}
+
+// RetrieverFunc adapts a function to the Retriever interface.
+type RetrieverFunc func(ctx context.Context, query string, k int) ([]Chunk, error)
+
+// Retrieve calls f(ctx, query, k).
+func (f RetrieverFunc) Retrieve(ctx context.Context, query string, k int) ([]Chunk, error) {
+	return f(ctx, query, k)
+}
+
+// Placement is where Apply puts the context block.
+type Placement string
+
+const (
+	// PlaceSystem adds the block as a system message before the
+	// conversation.
+	PlaceSystem Placement = "system"
+
+	// PlaceUser adds the block to the start of the last user message, for
+	// models that follow system prompts loosely.
+	PlaceUser Placement = "user"
+)
+
+// Options configure Build.
+type Options struct {
+	// MaxTokens is the budget for the context block, counting four
+	// characters per token. Chunks are added in order until the next one
+	// would exceed it; zero means no limit.
+	MaxTokens int
+
+	// Instructions precede the sources. Empty uses instructions to answer
+	// from the sources and cite them as [n].
+	Instructions string
+
+	// Placement is where Apply puts the block. Empty means PlaceSystem.
+	Placement Placement
+}
+
+// Context is a formatted context block.
+type Context struct {
+	// Text is the block: the instructions followed by the numbered sources.
+	Text string
+
+	// Chunks are the chunks in the block; Chunks[i] is source [i+1].
+	Chunks []Chunk
+
+	// Dropped is the number of chunks left out to stay within the budget.
+	Dropped int
+
+	placement Placement
+}
+
+// Build numbers the chunks as sources and formats them into a context
+// block within opts.MaxTokens. A chunk that does not fit is skipped, so a
+// smaller one after it may still be included.
+func Build(chunks []Chunk, opts Options) *Context {
+	instructions := opts.Instructions
+	if instructions == "" {
+		instructions = defaultInstructions
+	}
+
+	var b strings.Builder
+	b.WriteString(instructions)
+	b.WriteString("\n\n")
+
+	c := &Context{placement: opts.Placement}
+	for _, chunk := range chunks {
+		source := formatSource(len(c.Chunks)+1, chunk)
+		if opts.MaxTokens > 0 && EstimateTokens(b.String()+source) > opts.MaxTokens {
+			c.Dropped++
+			continue
+		}
+		b.WriteString(source)
+		c.Chunks = append(c.Chunks, chunk)
+	}
+
+	c.Text = strings.TrimRight(b.String(), "\n")
+	return c
+}
+
+// formatSource formats a chunk as source n.
+func formatSource(n int, chunk Chunk) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "[%d]", n)
+	if chunk.Title != "" {
+		fmt.Fprintf(&b, " %s", chunk.Title)
+	}
+	if chunk.URL != "" {
+		fmt.Fprintf(&b, " (%s)", chunk.URL)
+	}
+	fmt.Fprintf(&b, "\n%s\n\n", strings.TrimSpace(chunk.Text))
+	return b.String()
+}
+
+// EstimateTokens estimates the tokens of text at four characters per token.
+func EstimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// Apply returns a copy of req with the context block added per the
+// placement. req is not modified.
+func (c *Context) Apply(req *types.CompletionRequest) *types.CompletionRequest {
+	clone := *req
+	clone.Messages = make([]types.Message, 0, len(req.Messages)+1)
+
+	if c.placement == PlaceUser {
+		last := -1
+		for i, msg := range req.Messages {
+			if msg.Role == types.RoleUser {
+				last = i
+			}
+		}
+		if last >= 0 {
+			clone.Messages = append(clone.Messages, req.Messages...)
+			msg := clone.Messages[last]
+			msg.Content = append([]types.ContentBlock{{Type: types.ContentTypeText, Text: c.Text + "\n\n"}}, msg.Content...)
+			clone.Messages[last] = msg
+			return &clone
+		}
+	}
+
+	clone.Messages = append(clone.Messages, types.NewTextMessage(types.RoleSystem, c.Text))
+	clone.Messages = append(clone.Messages, req.Messages...)
+	return &clone
+}
+
+var (
+	// citationTag matches a source tag such as [2].
+	citationTag = regexp.MustCompile(`\[(\d+)\]`)
+
+	// trailingTag matches a source tag at the end of text.
+	trailingTag = regexp.MustCompile(`\[\d+\]$`)
+)
+
+// Citations returns a citation for each [n] tag in text that names a source
+// of the block, in order of appearance. A citation's DocumentIndex is the
+// chunk's index in Chunks, and its Text, StartIndex, and EndIndex are the
+// sentence before the tag.
+func (c *Context) Citations(text string) []types.Citation {
+	var citations []types.Citation
+	for _, m := range citationTag.FindAllStringSubmatchIndex(text, -1) {
+		n, err := strconv.Atoi(text[m[2]:m[3]])
+		if err != nil || n < 1 || n > len(c.Chunks) {
+			continue
+		}
+		chunk := c.Chunks[n-1]
+		start, end := supportedSpan(text, m[0])
+		citations = append(citations, types.Citation{
+			Type:          "document",
+			DocumentIndex: n - 1,
+			Title:         chunk.Title,
+			URL:           chunk.URL,
+			CitedText:     chunk.Text,
+			Text:          text[start:end],
+			StartIndex:    start,
+			EndIndex:      end,
+		})
+	}
+	return citations
+}
+
+// supportedSpan returns the byte offsets of the sentence before the tag at
+// offset tag. Adjacent tags, as in "[2][3]", support the same sentence.
+func supportedSpan(text string, tag int) (int, int) {
+	end := tag
+	for {
+		trimmed := strings.TrimRight(text[:end], " ")
+		loc := trailingTag.FindStringIndex(trimmed)
+		if loc == nil {
+			end = len(trimmed)
+			break
+		}
+		end = loc[0]
+	}
+
+	// The sentence's own full stop may come before the tag.
+	start := strings.LastIndexAny(text[:max(end-1, 0)], ".!?\n") + 1
+	for start < end && (text[start] == ' ' || text[start] == '\n') {
+		start++
+	}
+	return start, end
+}
+
+// Augment retrieves k chunks for the text of the last user message and
+// returns the request with them applied, and the context for resolving
+// citations in the answer.
+func Augment(ctx context.Context, retriever Retriever, req *types.CompletionRequest, k int, opts Options) (*types.CompletionRequest, *Context, error) {
+	var query string
+	for i := len(req.Messages) - 1; i >= 0; i-- {
+		if req.Messages[i].Role == types.RoleUser {
+			query = messageText(req.Messages[i])
+			break
+		}
+	}
+	if query == "" {
+		return nil, nil, fmt.Errorf("rag: request has no user message to retrieve for")
+	}
+
+	chunks, err := retriever.Retrieve(ctx, query, k)
+	if err != nil {
+		return nil, nil, fmt.Errorf("rag: retrieving: %w", err)
+	}
+
+	c := Build(chunks, opts)
+	return c.Apply(req), c, nil
+}
+
+// messageText returns the text blocks of msg.
+func messageText(msg types.Message) string {
+	var b strings.Builder
+	for _, block := range msg.Content {
+		if block.Type == types.ContentTypeText {
+			b.WriteString(block.Text)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,87 @@
+package rag
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+var testChunks = []Chunk{
+	{ID: "a", Title: "Penguins", URL: "https://example.com/penguins", Text: "Emperor penguins are the tallest penguins."},
+	{ID: "b", Title: "Habitats", Text: strings.Repeat("Antarctica is cold. ", 100)},
+	{ID: "c", Title: "Diet", Text: "Penguins eat krill and fish."},
+}
+
+func TestBuild_TokenBudget(t *testing.T) {
+	c := Build(testChunks, Options{MaxTokens: 120})
+
+	if len(c.Chunks) != 2 || c.Chunks[1].ID != "c" || c.Dropped != 1 {
+		t.Fatalf("chunks = %+v, dropped = %d", c.Chunks, c.Dropped)
+	}
+	if EstimateTokens(c.Text) > 120 {
+		t.Errorf("context of %d tokens exceeds the budget", EstimateTokens(c.Text))
+	}
+	if !strings.Contains(c.Text, "[1] Penguins (https://example.com/penguins)\nEmperor") || !strings.Contains(c.Text, "[2] Diet\nPenguins eat") {
+		t.Errorf("unexpected context:\n%s", c.Text)
+	}
+}
+
+func TestApply(t *testing.T) {
+	req := &types.CompletionRequest{
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "How tall are emperor penguins?")},
+	}
+
+	system := Build(testChunks[:1], Options{}).Apply(req)
+	if len(system.Messages) != 2 || system.Messages[0].Role != types.RoleSystem || len(req.Messages) != 1 {
+		t.Errorf("system placement: %+v", system.Messages)
+	}
+
+	user := Build(testChunks[:1], Options{Placement: PlaceUser}).Apply(req)
+	if len(user.Messages) != 1 || len(user.Messages[0].Content) != 2 || len(req.Messages[0].Content) != 1 {
+		t.Errorf("user placement: %+v", user.Messages)
+	}
+	if !strings.HasPrefix(user.Messages[0].Content[0].Text, defaultInstructions) {
+		t.Errorf("user placement content: %+v", user.Messages[0].Content)
+	}
+}
+
+func TestCitations(t *testing.T) {
+	c := Build(testChunks, Options{})
+	answer := "Emperor penguins are the tallest [1]. They eat krill [3][2]. See [9]."
+
+	citations := c.Citations(answer)
+	if len(citations) != 3 {
+		t.Fatalf("expected 3 citations, got %+v", citations)
+	}
+	first, second, third := citations[0], citations[1], citations[2]
+	if first.DocumentIndex != 0 || first.URL != "https://example.com/penguins" || first.Text != "Emperor penguins are the tallest" {
+		t.Errorf("first citation: %+v", first)
+	}
+	if second.DocumentIndex != 2 || third.DocumentIndex != 1 || second.Text != "They eat krill" || third.Text != "They eat krill" {
+		t.Errorf("adjacent citations: %+v, %+v", second, third)
+	}
+	if answer[second.StartIndex:second.EndIndex] != second.Text {
+		t.Errorf("offsets %d-%d do not match %q", second.StartIndex, second.EndIndex, second.Text)
+	}
+}
+
+func TestAugment(t *testing.T) {
+	var query string
+	retriever := RetrieverFunc(func(ctx context.Context, q string, k int) ([]Chunk, error) {
+		query = q
+		return testChunks[:k], nil
+	})
+
+	req, c, err := Augment(context.Background(), retriever, &types.CompletionRequest{
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "What do penguins eat?")},
+	}, 1, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if query != "What do penguins eat?" || len(c.Chunks) != 1 || len(req.Messages) != 2 {
+		t.Errorf("query = %q, chunks = %d, messages = %d", query, len(c.Chunks), len(req.Messages))
+	}
+}
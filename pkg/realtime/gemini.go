@@ -0,0 +1,315 @@
+package realtime
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/provider/google"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	geminiBaseURL = "https://generativelanguage.googleapis.com"
+	geminiPath    = "/ws/google.ai.generativelanguage.v1beta.GenerativeService.BidiGenerateContent"
+
+	// geminiInputMIMEType is the format of the user's audio.
+	geminiInputMIMEType = "audio/pcm;rate=16000"
+)
+
+// geminiSession is a session with the Gemini Live API.
+// See https://ai.google.dev/gemini-api/docs/live
+type geminiSession struct {
+	conn
+	manualTurns bool
+
+	// speaking reports whether a manual turn's activityStart has been
+	// sent. Only the audio sender uses it.
+	speaking bool
+}
+
+func dialGemini(ctx context.Context, cfg Config, pcfg *provider.Config) (*geminiSession, error) {
+	baseURL := geminiBaseURL
+	if pcfg.BaseURL != "" {
+		baseURL = strings.TrimSuffix(pcfg.BaseURL, "/")
+	}
+	rawURL := baseURL + geminiPath
+	header := http.Header{}
+
+	token := pcfg.AccessToken
+	if token == "" && pcfg.TokenSource != nil && pcfg.APIKey == "" {
+		t, err := pcfg.TokenSource.Token(ctx)
+		if err != nil {
+			return nil, errors.ErrAuthentication(types.ProviderGoogle, "failed to get access token").WithCause(err)
+		}
+		token = t
+	}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	} else {
+		rawURL += "?key=" + url.QueryEscape(pcfg.APIKey)
+	}
+
+	ws, err := provider.DialWebSocket(ctx, provider.NewHTTPClient(pcfg), rawURL, header, types.ProviderGoogle)
+	if err != nil {
+		return nil, err
+	}
+	s := &geminiSession{conn: conn{ws: ws, name: types.ProviderGoogle}, manualTurns: cfg.ManualTurns}
+
+	if err := s.setup(ctx, cfg); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// setup sends the session config and waits for the server to accept it,
+// which it must before any other message.
+func (s *geminiSession) setup(ctx context.Context, cfg Config) error {
+	model := cfg.Model
+	if !strings.HasPrefix(model, "models/") {
+		model = "models/" + model
+	}
+	setup := &geminiSetup{
+		Model:            model,
+		GenerationConfig: &geminiGenerationConfig{ResponseModalities: []string{"AUDIO"}},
+	}
+	if cfg.TextOnly {
+		setup.GenerationConfig.ResponseModalities = []string{"TEXT"}
+	} else {
+		setup.OutputAudioTranscription = &struct{}{}
+	}
+	if cfg.Voice != "" {
+		setup.GenerationConfig.SpeechConfig = &geminiSpeechConfig{}
+		setup.GenerationConfig.SpeechConfig.VoiceConfig.PrebuiltVoiceConfig.VoiceName = cfg.Voice
+	}
+	if cfg.Instructions != "" {
+		setup.SystemInstruction = &google.Content{Parts: []google.Part{{Text: cfg.Instructions}}}
+	}
+	if tool := schema.NewTranslator().ToolsToGoogle(cfg.Tools); tool != nil {
+		setup.Tools = []*schema.GoogleTool{tool}
+	}
+	if cfg.ManualTurns {
+		setup.RealtimeInputConfig = &geminiRealtimeInputConfig{}
+		setup.RealtimeInputConfig.AutomaticActivityDetection.Disabled = true
+	}
+	if cfg.TranscribeInput {
+		setup.InputAudioTranscription = &struct{}{}
+	}
+
+	if err := s.send(ctx, geminiClientMessage{Setup: setup}); err != nil {
+		return err
+	}
+
+	for {
+		data, err := s.ws.ReadMessage()
+		if err != nil {
+			return errors.ErrProviderUnavailable(types.ProviderGoogle, "realtime session setup failed").WithCause(err)
+		}
+		var msg geminiServerMessage
+		if json.Unmarshal(data, &msg) == nil && msg.SetupComplete != nil {
+			return nil
+		}
+	}
+}
+
+// geminiClientMessage is a message to the Live API. Exactly one field is
+// set.
+type geminiClientMessage struct {
+	Setup         *geminiSetup         `json:"setup,omitempty"`
+	ClientContent *geminiClientContent `json:"clientContent,omitempty"`
+	RealtimeInput *geminiRealtimeInput `json:"realtimeInput,omitempty"`
+	ToolResponse  *geminiToolResponse  `json:"toolResponse,omitempty"`
+}
+
+type geminiSetup struct {
+	Model                    string                     `json:"model"`
+	GenerationConfig         *geminiGenerationConfig    `json:"generationConfig,omitempty"`
+	SystemInstruction        *google.Content            `json:"systemInstruction,omitempty"`
+	Tools                    []*schema.GoogleTool       `json:"tools,omitempty"`
+	RealtimeInputConfig      *geminiRealtimeInputConfig `json:"realtimeInputConfig,omitempty"`
+	InputAudioTranscription  *struct{}                  `json:"inputAudioTranscription,omitempty"`
+	OutputAudioTranscription *struct{}                  `json:"outputAudioTranscription,omitempty"`
+}
+
+type geminiGenerationConfig struct {
+	ResponseModalities []string            `json:"responseModalities"`
+	SpeechConfig       *geminiSpeechConfig `json:"speechConfig,omitempty"`
+}
+
+type geminiSpeechConfig struct {
+	VoiceConfig struct {
+		PrebuiltVoiceConfig struct {
+			VoiceName string `json:"voiceName"`
+		} `json:"prebuiltVoiceConfig"`
+	} `json:"voiceConfig"`
+}
+
+type geminiRealtimeInputConfig struct {
+	AutomaticActivityDetection struct {
+		Disabled bool `json:"disabled"`
+	} `json:"automaticActivityDetection"`
+}
+
+type geminiClientContent struct {
+	Turns        []google.Content `json:"turns"`
+	TurnComplete bool             `json:"turnComplete"`
+}
+
+type geminiRealtimeInput struct {
+	Audio          *google.InlineData `json:"audio,omitempty"`
+	AudioStreamEnd bool               `json:"audioStreamEnd,omitempty"`
+	ActivityStart  *struct{}          `json:"activityStart,omitempty"`
+	ActivityEnd    *struct{}          `json:"activityEnd,omitempty"`
+}
+
+type geminiToolResponse struct {
+	FunctionResponses []google.FunctionResponse `json:"functionResponses"`
+}
+
+// geminiServerMessage is a message from the Live API.
+type geminiServerMessage struct {
+	SetupComplete *struct{}            `json:"setupComplete,omitempty"`
+	ServerContent *geminiServerContent `json:"serverContent,omitempty"`
+	ToolCall      *struct {
+		FunctionCalls []google.FunctionCall `json:"functionCalls"`
+	} `json:"toolCall,omitempty"`
+	UsageMetadata *geminiUsage     `json:"usageMetadata,omitempty"`
+	Error         *google.APIError `json:"error,omitempty"`
+}
+
+type geminiServerContent struct {
+	ModelTurn           *google.Content      `json:"modelTurn,omitempty"`
+	TurnComplete        bool                 `json:"turnComplete,omitempty"`
+	Interrupted         bool                 `json:"interrupted,omitempty"`
+	InputTranscription  *geminiTranscription `json:"inputTranscription,omitempty"`
+	OutputTranscription *geminiTranscription `json:"outputTranscription,omitempty"`
+}
+
+type geminiTranscription struct {
+	Text string `json:"text"`
+}
+
+type geminiUsage struct {
+	PromptTokenCount   int `json:"promptTokenCount"`
+	ResponseTokenCount int `json:"responseTokenCount"`
+	TotalTokenCount    int `json:"totalTokenCount"`
+}
+
+func (s *geminiSession) SendText(ctx context.Context, text string) error {
+	return s.send(ctx, geminiClientMessage{ClientContent: &geminiClientContent{
+		Turns:        []google.Content{{Role: "user", Parts: []google.Part{{Text: text}}}},
+		TurnComplete: true,
+	}})
+}
+
+func (s *geminiSession) SendAudio(ctx context.Context, pcm []byte) error {
+	// Without voice activity detection the turn is marked explicitly.
+	if s.manualTurns && !s.speaking {
+		if err := s.send(ctx, geminiClientMessage{RealtimeInput: &geminiRealtimeInput{ActivityStart: &struct{}{}}}); err != nil {
+			return err
+		}
+		s.speaking = true
+	}
+	return s.send(ctx, geminiClientMessage{RealtimeInput: &geminiRealtimeInput{Audio: &google.InlineData{
+		MimeType: geminiInputMIMEType,
+		Data:     base64.StdEncoding.EncodeToString(pcm),
+	}}})
+}
+
+func (s *geminiSession) EndAudio(ctx context.Context) error {
+	if s.manualTurns {
+		if !s.speaking {
+			return nil
+		}
+		s.speaking = false
+		return s.send(ctx, geminiClientMessage{RealtimeInput: &geminiRealtimeInput{ActivityEnd: &struct{}{}}})
+	}
+	return s.send(ctx, geminiClientMessage{RealtimeInput: &geminiRealtimeInput{AudioStreamEnd: true}})
+}
+
+func (s *geminiSession) SendToolResult(ctx context.Context, call types.ToolCall, result string) error {
+	// Like the Gemini client, send JSON object results as they are.
+	var response map[string]any
+	if err := json.Unmarshal([]byte(result), &response); err != nil {
+		response = map[string]any{"result": result}
+	}
+	return s.send(ctx, geminiClientMessage{ToolResponse: &geminiToolResponse{
+		FunctionResponses: []google.FunctionResponse{{ID: call.ID, Name: call.Name, Response: response}},
+	}})
+}
+
+func (s *geminiSession) Next() (*Event, error) {
+	return s.next(s.decode)
+}
+
+// decode converts a server message to session events.
+func (s *geminiSession) decode(data []byte) []*Event {
+	var msg geminiServerMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+
+	var events []*Event
+	if c := msg.ServerContent; c != nil {
+		if c.Interrupted {
+			events = append(events, &Event{Type: EventInterrupted})
+		}
+		if c.InputTranscription != nil && c.InputTranscription.Text != "" {
+			events = append(events, &Event{Type: EventInputTranscript, Text: c.InputTranscription.Text})
+		}
+		if c.ModelTurn != nil {
+			for _, part := range c.ModelTurn.Parts {
+				switch {
+				case part.InlineData != nil:
+					audio, err := base64.StdEncoding.DecodeString(part.InlineData.Data)
+					if err == nil {
+						events = append(events, &Event{Type: EventAudio, Audio: audio})
+					}
+				case part.Text != "" && !part.Thought:
+					events = append(events, &Event{Type: EventText, Text: part.Text})
+				}
+			}
+		}
+		if c.OutputTranscription != nil && c.OutputTranscription.Text != "" {
+			events = append(events, &Event{Type: EventTranscript, Text: c.OutputTranscription.Text})
+		}
+		if c.TurnComplete {
+			event := &Event{Type: EventTurnDone}
+			if u := msg.UsageMetadata; u != nil {
+				event.Usage = &types.Usage{
+					InputTokens:  u.PromptTokenCount,
+					OutputTokens: u.ResponseTokenCount,
+					TotalTokens:  u.TotalTokenCount,
+				}
+			}
+			events = append(events, event)
+		}
+	}
+
+	if msg.ToolCall != nil {
+		for _, fc := range msg.ToolCall.FunctionCalls {
+			var input any = fc.Args
+			if fc.Args == nil {
+				input = map[string]any{}
+			}
+			events = append(events, &Event{Type: EventToolCall, ToolCall: &types.ToolCall{
+				ID:    fc.ID,
+				Name:  fc.Name,
+				Input: input,
+			}})
+		}
+	}
+
+	if msg.Error != nil {
+		err := errors.ErrServerError(types.ProviderGoogle, msg.Error.Message).WithStatusCode(msg.Error.Code)
+		events = append(events, &Event{Type: EventError, Error: err})
+	}
+	return events
+}
@@ -0,0 +1,261 @@
+package realtime
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+const (
+	openaiBaseURL = "https://api.openai.com/v1"
+
+	// openaiTranscriptionModel transcribes the user's audio.
+	openaiTranscriptionModel = "whisper-1"
+)
+
+// openaiSession is a session with the OpenAI Realtime API.
+// See https://platform.openai.com/docs/guides/realtime
+type openaiSession struct {
+	conn
+}
+
+func dialOpenAI(ctx context.Context, cfg Config, pcfg *provider.Config) (*openaiSession, error) {
+	baseURL := openaiBaseURL
+	if pcfg.BaseURL != "" {
+		baseURL = strings.TrimSuffix(pcfg.BaseURL, "/")
+	}
+	header := http.Header{}
+	header.Set("Authorization", "Bearer "+pcfg.APIKey)
+	header.Set("OpenAI-Beta", "realtime=v1")
+
+	ws, err := provider.DialWebSocket(ctx, provider.NewHTTPClient(pcfg), baseURL+"/realtime?model="+url.QueryEscape(cfg.Model), header, types.ProviderOpenAI)
+	if err != nil {
+		return nil, err
+	}
+	s := &openaiSession{conn: conn{ws: ws, name: types.ProviderOpenAI}}
+
+	if err := s.send(ctx, openaiEvent{Type: "session.update", Session: openaiSessionConfig(cfg)}); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// openaiSessionConfig converts a session config to OpenAI's.
+func openaiSessionConfig(cfg Config) *openaiSessionParams {
+	params := &openaiSessionParams{
+		Modalities:        []string{"text", "audio"},
+		Instructions:      cfg.Instructions,
+		Voice:             cfg.Voice,
+		InputAudioFormat:  "pcm16",
+		OutputAudioFormat: "pcm16",
+	}
+	if cfg.TextOnly {
+		params.Modalities = []string{"text"}
+	}
+	if !cfg.ManualTurns {
+		params.TurnDetection = &openaiTurnDetection{Type: "server_vad"}
+	}
+	if cfg.TranscribeInput {
+		params.InputAudioTranscription = &openaiTranscription{Model: openaiTranscriptionModel}
+	}
+	for _, tool := range schema.NewTranslator().ToolsToOpenAI(cfg.Tools) {
+		params.Tools = append(params.Tools, openaiTool{
+			Type:        "function",
+			Name:        tool.Function.Name,
+			Description: tool.Function.Description,
+			Parameters:  tool.Function.Parameters,
+		})
+	}
+	return params
+}
+
+// openaiEvent is a client or server event of the Realtime API. Only the
+// fields of the event's type are set.
+type openaiEvent struct {
+	Type    string               `json:"type"`
+	Session *openaiSessionParams `json:"session,omitempty"`
+	Item    *openaiItem          `json:"item,omitempty"`
+	Audio   string               `json:"audio,omitempty"`
+
+	// Server events
+	Delta      string          `json:"delta,omitempty"`
+	Transcript string          `json:"transcript,omitempty"`
+	CallID     string          `json:"call_id,omitempty"`
+	Name       string          `json:"name,omitempty"`
+	Arguments  string          `json:"arguments,omitempty"`
+	Response   *openaiResponse `json:"response,omitempty"`
+	Error      *openaiError    `json:"error,omitempty"`
+}
+
+type openaiSessionParams struct {
+	Modalities              []string             `json:"modalities"`
+	Instructions            string               `json:"instructions,omitempty"`
+	Voice                   string               `json:"voice,omitempty"`
+	InputAudioFormat        string               `json:"input_audio_format"`
+	OutputAudioFormat       string               `json:"output_audio_format"`
+	InputAudioTranscription *openaiTranscription `json:"input_audio_transcription,omitempty"`
+	TurnDetection           *openaiTurnDetection `json:"turn_detection"` // null turns it off
+	Tools                   []openaiTool         `json:"tools,omitempty"`
+}
+
+type openaiTranscription struct {
+	Model string `json:"model"`
+}
+
+type openaiTurnDetection struct {
+	Type string `json:"type"`
+}
+
+type openaiTool struct {
+	Type        string         `json:"type"`
+	Name        string         `json:"name"`
+	Description string         `json:"description,omitempty"`
+	Parameters  map[string]any `json:"parameters"`
+}
+
+// openaiItem is a conversation item: a message or a function call output.
+type openaiItem struct {
+	Type    string              `json:"type"`
+	Role    string              `json:"role,omitempty"`
+	Content []openaiContentPart `json:"content,omitempty"`
+	CallID  string              `json:"call_id,omitempty"`
+	Output  string              `json:"output,omitempty"`
+}
+
+type openaiContentPart struct {
+	Type string `json:"type"`
+	Text string `json:"text,omitempty"`
+}
+
+type openaiResponse struct {
+	Status string       `json:"status"`
+	Usage  *openaiUsage `json:"usage,omitempty"`
+}
+
+type openaiUsage struct {
+	InputTokens       int `json:"input_tokens"`
+	OutputTokens      int `json:"output_tokens"`
+	TotalTokens       int `json:"total_tokens"`
+	InputTokenDetails *struct {
+		CachedTokens int `json:"cached_tokens"`
+	} `json:"input_token_details,omitempty"`
+}
+
+type openaiError struct {
+	Type    string `json:"type"`
+	Code    string `json:"code,omitempty"`
+	Message string `json:"message"`
+}
+
+func (s *openaiSession) SendText(ctx context.Context, text string) error {
+	item := &openaiItem{
+		Type:    "message",
+		Role:    "user",
+		Content: []openaiContentPart{{Type: "input_text", Text: text}},
+	}
+	if err := s.send(ctx, openaiEvent{Type: "conversation.item.create", Item: item}); err != nil {
+		return err
+	}
+	return s.send(ctx, openaiEvent{Type: "response.create"})
+}
+
+func (s *openaiSession) SendAudio(ctx context.Context, pcm []byte) error {
+	return s.send(ctx, openaiEvent{Type: "input_audio_buffer.append", Audio: base64.StdEncoding.EncodeToString(pcm)})
+}
+
+func (s *openaiSession) EndAudio(ctx context.Context) error {
+	if err := s.send(ctx, openaiEvent{Type: "input_audio_buffer.commit"}); err != nil {
+		return err
+	}
+	return s.send(ctx, openaiEvent{Type: "response.create"})
+}
+
+func (s *openaiSession) SendToolResult(ctx context.Context, call types.ToolCall, result string) error {
+	item := &openaiItem{Type: "function_call_output", CallID: call.ID, Output: result}
+	if err := s.send(ctx, openaiEvent{Type: "conversation.item.create", Item: item}); err != nil {
+		return err
+	}
+	return s.send(ctx, openaiEvent{Type: "response.create"})
+}
+
+func (s *openaiSession) Next() (*Event, error) {
+	return s.next(s.decode)
+}
+
+// decode converts a server event to session events. Events without a
+// counterpart, such as session.created, are dropped. Both the beta and the
+// GA names of the response events are accepted.
+func (s *openaiSession) decode(data []byte) []*Event {
+	var e openaiEvent
+	if err := json.Unmarshal(data, &e); err != nil {
+		return nil
+	}
+
+	switch e.Type {
+	case "response.text.delta", "response.output_text.delta":
+		return []*Event{{Type: EventText, Text: e.Delta}}
+
+	case "response.audio.delta", "response.output_audio.delta":
+		audio, err := base64.StdEncoding.DecodeString(e.Delta)
+		if err != nil {
+			return nil
+		}
+		return []*Event{{Type: EventAudio, Audio: audio}}
+
+	case "response.audio_transcript.delta", "response.output_audio_transcript.delta":
+		return []*Event{{Type: EventTranscript, Text: e.Delta}}
+
+	case "conversation.item.input_audio_transcription.completed":
+		return []*Event{{Type: EventInputTranscript, Text: e.Transcript}}
+
+	case "response.function_call_arguments.done":
+		return []*Event{{Type: EventToolCall, ToolCall: &types.ToolCall{
+			ID:    e.CallID,
+			Name:  e.Name,
+			Input: toolInput(e.Arguments),
+		}}}
+
+	case "input_audio_buffer.speech_started":
+		return []*Event{{Type: EventInterrupted}}
+
+	case "response.done":
+		event := &Event{Type: EventTurnDone}
+		if e.Response != nil && e.Response.Usage != nil {
+			u := e.Response.Usage
+			event.Usage = &types.Usage{
+				InputTokens:  u.InputTokens,
+				OutputTokens: u.OutputTokens,
+				TotalTokens:  u.TotalTokens,
+			}
+			if u.InputTokenDetails != nil {
+				event.Usage.CachedTokens = u.InputTokenDetails.CachedTokens
+			}
+		}
+		return []*Event{event}
+
+	case "error":
+		if e.Error == nil {
+			return nil
+		}
+		var err *errors.RouterError
+		if e.Error.Type == "server_error" {
+			err = errors.ErrServerError(types.ProviderOpenAI, e.Error.Message)
+		} else {
+			err = errors.ErrInvalidRequest(e.Error.Message).WithProvider(types.ProviderOpenAI)
+		}
+		if e.Error.Code != "" {
+			err = err.WithDetails(map[string]any{"code": e.Error.Code})
+		}
+		return []*Event{{Type: EventError, Error: err}}
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+// Package realtime holds live, bidirectional sessions with speech models:
+// OpenAI's Realtime API and Gemini Live, both over WebSocket. The caller
+// streams the user's audio or text in while the model's audio, transcripts,
+// text, and tool calls stream out, so either side can speak at any time.
+//
+// Audio is 16-bit little-endian mono PCM in both directions. The model
+// speaks at OutputSampleRate; the input rate depends on the provider (see
+// InputSampleRate).
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sync/atomic"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// OutputSampleRate is the sample rate of the model's audio, in Hz.
+const OutputSampleRate = 24000
+
+// InputSampleRate returns the sample rate, in Hz, of the audio a provider
+// expects from SendAudio: 24 kHz for OpenAI and 16 kHz for Gemini.
+func InputSampleRate(p types.Provider) int {
+	if p == types.ProviderGoogle {
+		return 16000
+	}
+	return 24000
+}
+
+// Config configures a session.
+type Config struct {
+	// Model is the realtime model, e.g. "gpt-4o-realtime-preview" or
+	// "gemini-2.0-flash-live-001".
+	Model string
+
+	// Instructions is the system prompt.
+	Instructions string
+
+	// Voice is the provider's voice name, e.g. "alloy" (OpenAI) or "Puck"
+	// (Gemini). Empty uses the provider default.
+	Voice string
+
+	// TextOnly makes the model answer in text instead of speech.
+	TextOnly bool
+
+	// Tools the model may call. Answer calls with Session.SendToolResult.
+	Tools []types.Tool
+
+	// ManualTurns turns off the provider's voice activity detection: the
+	// user's turn ends when EndAudio is called rather than when they stop
+	// speaking.
+	ManualTurns bool
+
+	// TranscribeInput sends EventInputTranscript events with the text of
+	// the user's audio.
+	TranscribeInput bool
+}
+
+// Session is a live conversation with a realtime model. Next is called
+// from one goroutine while others send; Close ends the session and unblocks
+// Next.
+type Session interface {
+	// SendText sends a user message and asks for a response.
+	SendText(ctx context.Context, text string) error
+
+	// SendAudio streams a chunk of the user's speech.
+	SendAudio(ctx context.Context, pcm []byte) error
+
+	// EndAudio ends the user's spoken turn, which the model then answers.
+	// With voice activity detection the provider notices the end of speech
+	// itself, and EndAudio is only needed to cut a turn short.
+	EndAudio(ctx context.Context) error
+
+	// SendToolResult answers a tool call from an EventToolCall event and
+	// lets the model continue.
+	SendToolResult(ctx context.Context, call types.ToolCall, result string) error
+
+	// Next returns the next event. It returns nil, nil once the session
+	// is closed.
+	Next() (*Event, error)
+
+	// Close ends the session. It is idempotent.
+	Close() error
+}
+
+// Dial opens a session with a provider's realtime API: types.ProviderOpenAI
+// or types.ProviderGoogle. opts configure credentials, base URL, and HTTP
+// client as for the provider's client.
+func Dial(ctx context.Context, p types.Provider, cfg Config, opts ...provider.Option) (Session, error) {
+	pcfg := provider.DefaultConfig()
+	provider.ApplyOptions(pcfg, opts...)
+
+	switch p {
+	case types.ProviderOpenAI:
+		return dialOpenAI(ctx, cfg, pcfg)
+	case types.ProviderGoogle:
+		return dialGemini(ctx, cfg, pcfg)
+	default:
+		return nil, errors.ErrUnsupportedFeature(p, "realtime sessions")
+	}
+}
+
+// EventType is the type of a session event.
+type EventType string
+
+const (
+	EventText            EventType = "text"             // Text of the response, with TextOnly
+	EventAudio           EventType = "audio"            // Chunk of the response's speech
+	EventTranscript      EventType = "transcript"       // Transcript of the response's speech
+	EventInputTranscript EventType = "input_transcript" // Transcript of the user's speech, with TranscribeInput
+	EventToolCall        EventType = "tool_call"        // The model called a tool
+	EventInterrupted     EventType = "interrupted"      // The user spoke over the response; stop playing it
+	EventTurnDone        EventType = "turn_done"        // The response is complete
+	EventError           EventType = "error"            // The provider reported an error; the session goes on
+)
+
+// Event is an event of a session.
+type Event struct {
+	Type EventType `json:"type"`
+
+	// Text is the text or transcript chunk of text, transcript, and
+	// input_transcript events.
+	Text string `json:"text,omitempty"`
+
+	// Audio is the PCM chunk of audio events.
+	Audio []byte `json:"audio,omitempty"`
+
+	// ToolCall is the call of tool_call events, with its parsed input.
+	ToolCall *types.ToolCall `json:"tool_call,omitempty"`
+
+	// Usage is the token usage of turn_done events, where reported.
+	Usage *types.Usage `json:"usage,omitempty"`
+
+	// Error is the error of error events.
+	Error error `json:"error,omitempty"`
+}
+
+// conn is the connection shared by the provider sessions: it sends JSON
+// messages and queues the events decoded from received ones.
+type conn struct {
+	ws     *provider.WebSocket
+	name   types.Provider
+	closed atomic.Bool
+
+	// pending holds decoded events not yet returned by Next.
+	pending []*Event
+}
+
+// send writes v as a JSON message.
+func (c *conn) send(ctx context.Context, v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return errors.ErrInvalidRequest("failed to marshal message").WithCause(err)
+	}
+	if err := c.ws.WriteMessage(ctx, data); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return errors.ErrProviderUnavailable(c.name, "realtime connection lost").WithCause(err)
+	}
+	return nil
+}
+
+// next returns the next event, reading messages and decoding them into
+// events until there is one.
+func (c *conn) next(decode func(data []byte) []*Event) (*Event, error) {
+	for {
+		if len(c.pending) > 0 {
+			event := c.pending[0]
+			c.pending = c.pending[1:]
+			return event, nil
+		}
+
+		data, err := c.ws.ReadMessage()
+		if err != nil {
+			if err == io.EOF || c.closed.Load() {
+				return nil, nil
+			}
+			return nil, errors.ErrProviderUnavailable(c.name, "realtime connection lost").WithCause(err)
+		}
+
+		c.pending = append(c.pending, decode(data)...)
+	}
+}
+
+// Close closes the connection.
+func (c *conn) Close() error {
+	if c.closed.Swap(true) {
+		return nil
+	}
+	return c.ws.Close()
+}
+
+// toolInput parses the JSON arguments of a tool call.
+func toolInput(arguments string) any {
+	var input any
+	json.Unmarshal([]byte(arguments), &input)
+	return input
+}
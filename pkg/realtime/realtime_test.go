@@ -0,0 +1,247 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// fakeServer accepts one WebSocket session, records the messages it
+// receives, and sends scripted replies after the message with each index.
+func fakeServer(t *testing.T, check func(r *http.Request), replies map[int][]string) (*httptest.Server, <-chan []map[string]any) {
+	t.Helper()
+	received := make(chan []map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		check(r)
+		ws, err := provider.UpgradeWebSocket(w, r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		defer ws.Close()
+
+		var messages []map[string]any
+		defer func() { received <- messages }()
+		for i := 0; ; i++ {
+			data, err := ws.ReadMessage()
+			if err != nil {
+				return
+			}
+			var msg map[string]any
+			json.Unmarshal(data, &msg)
+			messages = append(messages, msg)
+			for _, reply := range replies[i] {
+				ws.WriteMessage(context.Background(), []byte(reply))
+			}
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+// collect reads events until the first turn_done event.
+func collect(t *testing.T, s Session) []*Event {
+	t.Helper()
+	var events []*Event
+	for {
+		event, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			t.Fatal("session ended before the turn was done")
+		}
+		events = append(events, event)
+		if event.Type == EventTurnDone {
+			return events
+		}
+	}
+}
+
+func eventTypes(events []*Event) []EventType {
+	var types []EventType
+	for _, e := range events {
+		types = append(types, e.Type)
+	}
+	return types
+}
+
+func TestOpenAISession(t *testing.T) {
+	server, received := fakeServer(t, func(r *http.Request) {
+		if r.URL.Path != "/realtime" || r.URL.Query().Get("model") != "gpt-4o-realtime-preview" {
+			t.Errorf("url = %s", r.URL)
+		}
+		if r.Header.Get("Authorization") != "Bearer sk-test" || r.Header.Get("OpenAI-Beta") != "realtime=v1" {
+			t.Errorf("headers = %v", r.Header)
+		}
+	}, map[int][]string{
+		// After response.create for the text.
+		2: {
+			`{"type":"session.created"}`,
+			`{"type":"response.audio_transcript.delta","delta":"Let me check."}`,
+			`{"type":"response.audio.delta","delta":"AAEC"}`,
+			`{"type":"response.function_call_arguments.done","call_id":"call_1","name":"get_weather","arguments":"{\"city\":\"Paris\"}"}`,
+			`{"type":"response.done","response":{"status":"completed","usage":{"input_tokens":10,"output_tokens":5,"total_tokens":15}}}`,
+		},
+		// After response.create for the tool result.
+		4: {
+			`{"type":"error","error":{"type":"invalid_request_error","code":"bad","message":"oops"}}`,
+			`{"type":"response.text.delta","delta":"Sunny."}`,
+			`{"type":"response.done","response":{"status":"completed"}}`,
+		},
+	})
+
+	ctx := context.Background()
+	s, err := Dial(ctx, types.ProviderOpenAI, Config{
+		Model:        "gpt-4o-realtime-preview",
+		Instructions: "Be brief.",
+		Tools:        []types.Tool{{Name: "get_weather", Parameters: types.JSONSchema{Type: "object"}}},
+		ManualTurns:  true,
+	}, provider.WithAPIKey("sk-test"), provider.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SendText(ctx, "Weather in Paris?"); err != nil {
+		t.Fatal(err)
+	}
+	events := collect(t, s)
+	want := []EventType{EventTranscript, EventAudio, EventToolCall, EventTurnDone}
+	if got := eventTypes(events); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if !slices.Equal(events[1].Audio, []byte{0, 1, 2}) {
+		t.Errorf("audio = %v", events[1].Audio)
+	}
+	call := events[2].ToolCall
+	if input, _ := call.Input.(map[string]any); call.ID != "call_1" || input["city"] != "Paris" {
+		t.Errorf("tool call = %+v", call)
+	}
+	if u := events[3].Usage; u == nil || u.InputTokens != 10 || u.TotalTokens != 15 {
+		t.Errorf("usage = %+v", u)
+	}
+
+	if err := s.SendToolResult(ctx, *call, `{"sky":"sunny"}`); err != nil {
+		t.Fatal(err)
+	}
+	events = collect(t, s)
+	var rerr *errors.RouterError
+	if !stderrors.As(events[0].Error, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		t.Errorf("error event = %+v", events[0])
+	}
+	if events[1].Type != EventText || events[1].Text != "Sunny." {
+		t.Errorf("text event = %+v", events[1])
+	}
+	s.Close()
+
+	messages := <-received
+	var got []string
+	for _, m := range messages {
+		got = append(got, m["type"].(string))
+	}
+	wantTypes := []string{"session.update", "conversation.item.create", "response.create", "conversation.item.create", "response.create"}
+	if !slices.Equal(got, wantTypes) {
+		t.Fatalf("sent = %v, want %v", got, wantTypes)
+	}
+	session := messages[0]["session"].(map[string]any)
+	if session["instructions"] != "Be brief." || session["turn_detection"] != nil || len(session["tools"].([]any)) != 1 {
+		t.Errorf("session = %v", session)
+	}
+	if item := messages[3]["item"].(map[string]any); item["call_id"] != "call_1" || item["output"] != `{"sky":"sunny"}` {
+		t.Errorf("tool result item = %v", item)
+	}
+}
+
+func TestGeminiSession(t *testing.T) {
+	server, received := fakeServer(t, func(r *http.Request) {
+		if r.URL.Query().Get("key") != "g-key" {
+			t.Errorf("key = %q", r.URL.Query().Get("key"))
+		}
+	}, map[int][]string{
+		0: {`{"setupComplete":{}}`},
+		// After activityStart and the audio.
+		2: {
+			`{"serverContent":{"modelTurn":{"parts":[{"inlineData":{"mimeType":"audio/pcm;rate=24000","data":"AAEC"}}]}}}`,
+			`{"serverContent":{"outputTranscription":{"text":"Hi"}}}`,
+			`{"toolCall":{"functionCalls":[{"id":"fc_1","name":"lookup","args":{"q":"x"}}]}}`,
+		},
+		// After activityEnd.
+		3: {
+			`{"serverContent":{"interrupted":true}}`,
+			`{"serverContent":{"turnComplete":true},"usageMetadata":{"promptTokenCount":7,"responseTokenCount":3,"totalTokenCount":10}}`,
+		},
+	})
+
+	ctx := context.Background()
+	s, err := Dial(ctx, types.ProviderGoogle, Config{
+		Model:       "gemini-2.0-flash-live-001",
+		Voice:       "Puck",
+		ManualTurns: true,
+	}, provider.WithAPIKey("g-key"), provider.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.SendAudio(ctx, []byte{1, 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.EndAudio(ctx); err != nil {
+		t.Fatal(err)
+	}
+	events := collect(t, s)
+	want := []EventType{EventAudio, EventTranscript, EventToolCall, EventInterrupted, EventTurnDone}
+	if got := eventTypes(events); !slices.Equal(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if u := events[4].Usage; u == nil || u.OutputTokens != 3 {
+		t.Errorf("usage = %+v", u)
+	}
+	if err := s.SendToolResult(ctx, *events[2].ToolCall, "found"); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	if event, err := s.Next(); event != nil || err != nil {
+		t.Errorf("after close: %v, %v", event, err)
+	}
+
+	messages := <-received
+	setup := messages[0]["setup"].(map[string]any)
+	if setup["model"] != "models/gemini-2.0-flash-live-001" || setup["realtimeInputConfig"] == nil || setup["outputAudioTranscription"] == nil {
+		t.Errorf("setup = %v", setup)
+	}
+	var sent []string
+	for _, m := range messages[1:] {
+		for k, v := range m {
+			if input, ok := v.(map[string]any); ok && k == "realtimeInput" {
+				for field := range input {
+					sent = append(sent, field)
+				}
+			} else {
+				sent = append(sent, k)
+			}
+		}
+	}
+	if want := []string{"activityStart", "audio", "activityEnd", "toolResponse"}; !slices.Equal(sent, want) {
+		t.Errorf("sent = %v, want %v", sent, want)
+	}
+	response := messages[4]["toolResponse"].(map[string]any)["functionResponses"].([]any)[0].(map[string]any)
+	if response["id"] != "fc_1" || response["response"].(map[string]any)["result"] != "found" {
+		t.Errorf("tool response = %v", response)
+	}
+}
+
+func TestDial_UnsupportedProvider(t *testing.T) {
+	_, err := Dial(context.Background(), types.ProviderAnthropic, Config{Model: "claude"})
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeUnsupportedFeature {
+		t.Fatalf("err = %v, want unsupported feature", err)
+	}
+}
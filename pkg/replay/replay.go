@@ -0,0 +1,427 @@
+// Package replay re-runs provider traffic captured with router.WithRawCapture,
+// for regression-testing transformer changes.
+//
+// Record traffic once, storing the unified response the transformers produced
+// for each completion exchange:
+//
+//	f, _ := os.Create("testdata/traffic.jsonl")
+//	rec := replay.NewRecorder(f)
+//	r, _ := router.New(router.WithAnthropic(key), router.WithRawCapture(rec.Record))
+//
+// Later, run the captured responses through the current transformers and diff
+// the results, or resend the captured requests to the provider with Live:
+//
+//	f, _ = os.Open("testdata/traffic.jsonl")
+//	records, _ := replay.Load(f)
+//	for _, res := range replay.Replay(ctx, records) {
+//		if res.Err != nil || len(res.Diff) > 0 {
+//			t.Errorf("%s: %v %v", res.Record.Exchange.URL, res.Err, res.Diff)
+//		}
+//	}
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/provider/anthropic"
+	"github.com/Chloe199719/agent-router/pkg/provider/cohere"
+	"github.com/Chloe199719/agent-router/pkg/provider/deepseek"
+	"github.com/Chloe199719/agent-router/pkg/provider/google"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/provider/openrouter"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// ErrNotCompletion is returned by Transform for exchanges that are not
+// successful, non-streaming completion calls.
+var ErrNotCompletion = errors.New("replay: not a completion exchange")
+
+// ignoredFields differ between otherwise identical responses and are skipped
+// by Diff.
+var ignoredFields = []string{"id", "created_at", "raw"}
+
+// Record is a captured exchange with the unified response the transformers
+// produced for it when it was recorded.
+type Record struct {
+	Exchange router.RawExchange        `json:"exchange"`
+	Response *types.CompletionResponse `json:"response,omitempty"`
+}
+
+// Recorder writes captured exchanges as JSON lines of Records. Its Record
+// method is a router.RawSink.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes ex, with its unified response if it is a completion exchange.
+func (r *Recorder) Record(ex router.RawExchange) {
+	rec := Record{Exchange: ex}
+	rec.Response, _ = Transform(ex)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(rec)
+	}
+}
+
+// Err returns the first write error.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Load reads JSON lines of Records written by a Recorder.
+func Load(r io.Reader) ([]Record, error) {
+	var records []Record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("replay: line %d: %w", line, err)
+		}
+		records = append(records, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("replay: %w", err)
+	}
+	return records, nil
+}
+
+// Transform runs a captured completion response through the provider's
+// current transformer.
+func Transform(ex router.RawExchange) (*types.CompletionResponse, error) {
+	if ex.StatusCode != http.StatusOK || !isCompletion(ex) {
+		return nil, ErrNotCompletion
+	}
+
+	var result *types.CompletionResponse
+	switch ex.Provider {
+	case types.ProviderOpenAI:
+		var resp openai.ChatCompletionResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		result = openai.NewTransformer().TransformResponse(&resp)
+	case types.ProviderDeepSeek:
+		var resp deepseek.ChatCompletionResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		result = deepseek.NewTransformer().TransformResponse(&resp)
+	case types.ProviderOpenRouter:
+		var resp openrouter.ChatCompletionResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		result = openrouter.NewTransformer().TransformResponse(&resp)
+	case types.ProviderCohere:
+		var resp cohere.ChatResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		// Without the request's documents, document citations have no index or title.
+		result = cohere.NewTransformer().TransformResponse(&resp, nil)
+	case types.ProviderAnthropic:
+		var resp anthropic.MessagesResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		result = anthropic.NewTransformer().TransformResponse(&resp)
+	case types.ProviderGoogle, types.ProviderVertex:
+		var resp google.GenerateContentResponse
+		if err := json.Unmarshal(ex.Response, &resp); err != nil {
+			return nil, fmt.Errorf("replay: decoding response: %w", err)
+		}
+		result = google.NewTransformer().TransformResponse(&resp)
+		if result != nil {
+			// The model is in the URL, as the clients set it.
+			result.Provider = ex.Provider
+			result.Model = urlModel(ex.URL)
+		}
+	default:
+		return nil, fmt.Errorf("replay: unsupported provider %q", ex.Provider)
+	}
+	if result == nil {
+		return nil, fmt.Errorf("replay: empty %s response", ex.Provider)
+	}
+	return result, nil
+}
+
+// isCompletion reports whether ex is a non-streaming completion call.
+func isCompletion(ex router.RawExchange) bool {
+	u, err := url.Parse(ex.URL)
+	if err != nil {
+		return false
+	}
+	switch ex.Provider {
+	case types.ProviderOpenAI, types.ProviderDeepSeek, types.ProviderOpenRouter:
+		return strings.HasSuffix(u.Path, "/chat/completions") && !streams(ex.Request)
+	case types.ProviderAnthropic:
+		return strings.HasSuffix(u.Path, "/messages") && !streams(ex.Request)
+	case types.ProviderCohere:
+		return strings.HasSuffix(u.Path, "/v2/chat") && !streams(ex.Request)
+	case types.ProviderGoogle, types.ProviderVertex:
+		return strings.HasSuffix(u.Path, ":generateContent")
+	}
+	return false
+}
+
+func streams(body json.RawMessage) bool {
+	var req struct {
+		Stream bool `json:"stream"`
+	}
+	json.Unmarshal(body, &req)
+	return req.Stream
+}
+
+// urlModel returns the model named in a Gemini or Vertex AI URL.
+func urlModel(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	_, model, ok := strings.Cut(u.Path, "/models/")
+	if !ok {
+		return ""
+	}
+	model, _, _ = strings.Cut(model, ":")
+	return model
+}
+
+// Option configures Resend and Replay.
+type Option func(*config)
+
+type config struct {
+	apiKey  string
+	baseURL string
+	client  *http.Client
+	live    bool
+}
+
+// Live resends captured requests to the provider with apiKey in place of the
+// redacted credentials, instead of re-transforming the captured responses.
+func Live(apiKey string) Option {
+	return func(c *config) {
+		c.live = true
+		c.apiKey = apiKey
+	}
+}
+
+// WithBaseURL sends requests to baseURL instead of the captured scheme and
+// host, e.g. a proxy or test server.
+func WithBaseURL(baseURL string) Option {
+	return func(c *config) {
+		c.baseURL = baseURL
+	}
+}
+
+// WithHTTPClient sets the client used to resend requests. Defaults to
+// http.DefaultClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *config) {
+		c.client = client
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{client: http.DefaultClient}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// Resend sends a captured request again and returns the new exchange. The
+// redacted credentials are filled in with the Live API key.
+func Resend(ctx context.Context, ex router.RawExchange, opts ...Option) (router.RawExchange, error) {
+	cfg := newConfig(opts)
+
+	u, err := url.Parse(ex.URL)
+	if err != nil {
+		return ex, fmt.Errorf("replay: %w", err)
+	}
+	if cfg.baseURL != "" {
+		base, err := url.Parse(cfg.baseURL)
+		if err != nil {
+			return ex, fmt.Errorf("replay: %w", err)
+		}
+		u.Scheme, u.Host = base.Scheme, base.Host
+	}
+	q := u.Query()
+	for key, values := range q {
+		if len(values) == 1 && values[0] == router.Redacted {
+			q.Set(key, cfg.apiKey)
+		}
+	}
+	u.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, ex.Method, u.String(), bytes.NewReader(ex.Request))
+	if err != nil {
+		return ex, fmt.Errorf("replay: %w", err)
+	}
+	for key, values := range ex.Header {
+		for _, v := range values {
+			if v == router.Redacted {
+				v = cfg.apiKey
+				if http.CanonicalHeaderKey(key) == "Authorization" {
+					v = "Bearer " + cfg.apiKey
+				}
+			}
+			req.Header.Add(key, v)
+		}
+	}
+
+	resp, err := cfg.client.Do(req)
+	if err != nil {
+		return ex, fmt.Errorf("replay: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return ex, fmt.Errorf("replay: %w", err)
+	}
+
+	out := ex
+	out.StatusCode = resp.StatusCode
+	out.Response = body
+	out.Error = ""
+	return out, nil
+}
+
+// Result is the outcome of replaying one Record.
+type Result struct {
+	Record Record
+	Got    *types.CompletionResponse
+	Diff   []string
+	Err    error
+}
+
+// Replay re-transforms each recorded completion exchange, or resends it with
+// Live, and diffs the unified response against the recorded one. Records
+// that are not completion exchanges are skipped.
+func Replay(ctx context.Context, records []Record, opts ...Option) []Result {
+	cfg := newConfig(opts)
+
+	var results []Result
+	for _, rec := range records {
+		if !isCompletion(rec.Exchange) {
+			continue
+		}
+		res := Result{Record: rec}
+
+		ex := rec.Exchange
+		if cfg.live {
+			ex, res.Err = Resend(ctx, ex, opts...)
+		}
+		if res.Err == nil {
+			res.Got, res.Err = Transform(ex)
+		}
+		if res.Err == nil && rec.Response != nil {
+			res.Diff = Diff(rec.Response, res.Got)
+		}
+		results = append(results, res)
+	}
+	return results
+}
+
+// Diff compares two unified responses and describes each difference as
+// "path: want != got". IDs, timestamps, and raw bodies are ignored.
+func Diff(want, got *types.CompletionResponse) []string {
+	var diffs []string
+	diffValues("", toValue(want), toValue(got), &diffs)
+	return diffs
+}
+
+func toValue(resp *types.CompletionResponse) any {
+	var v map[string]any
+	if resp != nil {
+		data, _ := json.Marshal(resp)
+		json.Unmarshal(data, &v)
+		for _, field := range ignoredFields {
+			delete(v, field)
+		}
+	}
+	return v
+}
+
+func diffValues(path string, want, got any, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]any:
+		if g, ok := got.(map[string]any); ok {
+			keys := make(map[string]bool, len(w)+len(g))
+			for k := range w {
+				keys[k] = true
+			}
+			for k := range g {
+				keys[k] = true
+			}
+			sorted := make([]string, 0, len(keys))
+			for k := range keys {
+				sorted = append(sorted, k)
+			}
+			sort.Strings(sorted)
+			for _, k := range sorted {
+				diffValues(joinPath(path, k), w[k], g[k], diffs)
+			}
+			return
+		}
+	case []any:
+		if g, ok := got.([]any); ok {
+			for i := 0; i < max(len(w), len(g)); i++ {
+				var wi, gi any
+				if i < len(w) {
+					wi = w[i]
+				}
+				if i < len(g) {
+					gi = g[i]
+				}
+				diffValues(fmt.Sprintf("%s[%d]", path, i), wi, gi, diffs)
+			}
+			return
+		}
+	}
+	if !reflect.DeepEqual(want, got) {
+		*diffs = append(*diffs, fmt.Sprintf("%s: %s != %s", path, jsonString(want), jsonString(got)))
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonString(v any) string {
+	if v == nil {
+		return "<missing>"
+	}
+	data, _ := json.Marshal(v)
+	return string(data)
+}
@@ -0,0 +1,107 @@
+package replay
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func openAIExchange(text string) router.RawExchange {
+	return router.RawExchange{
+		Provider:   types.ProviderOpenAI,
+		Method:     http.MethodPost,
+		URL:        "https://api.openai.com/v1/chat/completions",
+		Header:     http.Header{"Authorization": {router.Redacted}, "Content-Type": {"application/json"}},
+		Request:    json.RawMessage(`{"model":"gpt-4o","messages":[{"role":"user","content":"Hello"}]}`),
+		StatusCode: http.StatusOK,
+		Response:   json.RawMessage(`{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"` + text + `"},"finish_reason":"stop"}]}`),
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	rec.Record(openAIExchange("Hi"))
+	rec.Record(router.RawExchange{Provider: types.ProviderOpenAI, Method: http.MethodGet, URL: "https://api.openai.com/v1/models", StatusCode: http.StatusOK})
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("got %d records, want 2", len(records))
+	}
+	if records[0].Response == nil || records[0].Response.Text() != "Hi" {
+		t.Errorf("response = %+v, want transformed completion", records[0].Response)
+	}
+	if records[1].Response != nil {
+		t.Error("expected no response for a non-completion exchange")
+	}
+
+	results := Replay(context.Background(), records)
+	if len(results) != 1 || results[0].Err != nil || len(results[0].Diff) != 0 {
+		t.Errorf("results = %+v, want one clean result", results)
+	}
+}
+
+func TestTransform_Google(t *testing.T) {
+	ex := router.RawExchange{
+		Provider:   types.ProviderVertex,
+		URL:        "https://us-central1-aiplatform.googleapis.com/v1/projects/p/locations/us-central1/publishers/google/models/gemini-2.0-flash:generateContent",
+		StatusCode: http.StatusOK,
+		Response:   json.RawMessage(`{"candidates":[{"content":{"role":"model","parts":[{"text":"Hi"}]},"finishReason":"STOP"}]}`),
+	}
+	resp, err := Transform(ex)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != types.ProviderVertex || resp.Model != "gemini-2.0-flash" || resp.Text() != "Hi" {
+		t.Errorf("response = %+v", resp)
+	}
+
+	ex.URL = strings.Replace(ex.URL, ":generateContent", ":streamGenerateContent", 1)
+	if _, err := Transform(ex); err != ErrNotCompletion {
+		t.Errorf("err = %v, want ErrNotCompletion for a stream", err)
+	}
+}
+
+func TestDiff(t *testing.T) {
+	want, _ := Transform(openAIExchange("Hi"))
+	got, _ := Transform(openAIExchange("Hello"))
+	got.ID = "other"
+
+	diffs := Diff(want, got)
+	if len(diffs) != 1 || diffs[0] != `content[0].text: "Hi" != "Hello"` {
+		t.Errorf("diffs = %v", diffs)
+	}
+}
+
+func TestReplay_Live(t *testing.T) {
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write(openAIExchange("Hello").Response)
+	}))
+	defer server.Close()
+
+	want, _ := Transform(openAIExchange("Hi"))
+	results := Replay(context.Background(), []Record{{Exchange: openAIExchange("Hi"), Response: want}},
+		Live("sk-test"), WithBaseURL(server.URL))
+
+	if auth != "Bearer sk-test" {
+		t.Errorf("Authorization = %q, want the live key", auth)
+	}
+	if len(results) != 1 || results[0].Err != nil || len(results[0].Diff) != 1 {
+		t.Fatalf("results = %+v, want one text difference", results)
+	}
+}
@@ -0,0 +1,130 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// CompletePartial turns a truncated JSON document, such as the text of a
+// structured output stream so far, into the most complete valid JSON it
+// allows. Unterminated strings are closed, open objects and arrays are
+// closed, and a trailing comma, a key without a value, or an unfinished
+// literal are dropped. Text before the first object or array is ignored. ok
+// is false if no value has started yet.
+func CompletePartial(text string) (completed string, ok bool) {
+	start := strings.IndexAny(text, "{[")
+	if start < 0 {
+		return "", false
+	}
+	text = text[start:]
+
+	var (
+		stack     []byte // closers of the open containers
+		safe      = -1   // text[:safe] + safeStack closers is valid JSON
+		safeStack []byte
+		expectKey bool
+		inString  bool
+		isKey     bool
+		strStart  int
+	)
+	markSafe := func(end int) {
+		safe = end
+		safeStack = append(safeStack[:0], stack...)
+	}
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch c {
+			case '\\':
+				i++
+			case '"':
+				inString = false
+				if !isKey {
+					markSafe(i + 1)
+				}
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString, isKey, strStart = true, expectKey, i
+			expectKey = false
+		case '{', '[':
+			closer := byte('}')
+			if c == '[' {
+				closer = ']'
+			}
+			stack = append(stack, closer)
+			expectKey = c == '{'
+			markSafe(i + 1)
+		case '}', ']':
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				return finishPartial(text[:i+1], nil) // ignore text after the value
+			}
+			expectKey = false
+			markSafe(i + 1)
+		case ',':
+			expectKey = len(stack) > 0 && stack[len(stack)-1] == '}'
+		case ':':
+			expectKey = false
+		default:
+			if isSpace(c) {
+				continue
+			}
+			// A number or literal: safe once terminated, or if it is
+			// already valid at the end of the text.
+			j := i
+			for j < len(text) && !isSpace(text[j]) && !strings.ContainsRune(",:]}", rune(text[j])) {
+				j++
+			}
+			if j < len(text) || json.Valid([]byte(text[i:j])) {
+				markSafe(j)
+			}
+			i = j - 1
+		}
+	}
+
+	if inString && !isKey {
+		return finishPartial(text[:strStart]+closePartialString(text[strStart:]), stack)
+	}
+	if safe < 0 {
+		return "", false
+	}
+	return finishPartial(text[:safe], safeStack)
+}
+
+// finishPartial appends the closers of the open containers, innermost first.
+func finishPartial(prefix string, stack []byte) (string, bool) {
+	var b strings.Builder
+	b.WriteString(prefix)
+	for i := len(stack) - 1; i >= 0; i-- {
+		b.WriteByte(stack[i])
+	}
+	out := b.String()
+	return out, json.Valid([]byte(out))
+}
+
+// closePartialString terminates an unterminated string literal, dropping an
+// incomplete escape sequence at its end.
+func closePartialString(s string) string {
+	if i := strings.LastIndex(s, `\u`); i >= 0 && len(s)-i < 6 && escaped(s, i) {
+		s = s[:i]
+	}
+	if strings.HasSuffix(s, `\`) && escaped(s, len(s)-1) {
+		s = s[:len(s)-1]
+	}
+	return s + `"`
+}
+
+// escaped reports whether the backslash at s[i] starts an escape sequence,
+// that is, whether it is preceded by an even number of backslashes.
+func escaped(s string, i int) bool {
+	n := 0
+	for j := i - 1; j >= 0 && s[j] == '\\'; j-- {
+		n++
+	}
+	return n%2 == 0
+}
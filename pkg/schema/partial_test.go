@@ -0,0 +1,37 @@
+package schema
+
+import "testing"
+
+func TestCompletePartial(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{``, ``, false},
+		{`Sure, here`, ``, false},
+		{`{`, `{}`, true},
+		{`{"na`, `{}`, true},
+		{`{"name"`, `{}`, true},
+		{`{"name": `, `{}`, true},
+		{`{"name": "An`, `{"name": "An"}`, true},
+		{`{"name": "Ann", `, `{"name": "Ann"}`, true},
+		{`{"name": "Ann", "tags": ["a", "b`, `{"name": "Ann", "tags": ["a", "b"]}`, true},
+		{`{"age": 4`, `{"age": 4}`, true},
+		{`{"age": 4.`, `{}`, true},
+		{`{"ok": tr`, `{}`, true},
+		{`{"ok": true, "n": nu`, `{"ok": true}`, true},
+		{`[{"a": 1}, {"b": [1, 2,`, `[{"a": 1}, {"b": [1, 2]}]`, true},
+		{`{"s": "line\`, `{"s": "line"}`, true},
+		{`{"s": "caf\u00`, `{"s": "caf"}`, true},
+		{`{"s": "a\\`, `{"s": "a\\"}`, true},
+		{"```json\n{\"a\": {\"b\": \"c", `{"a": {"b": "c"}}`, true},
+		{"{\"a\": 1}\n```", `{"a": 1}`, true},
+	}
+	for _, tt := range tests {
+		got, ok := CompletePartial(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("CompletePartial(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
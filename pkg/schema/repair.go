@@ -0,0 +1,164 @@
+package schema
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// Repair fixes common defects in model-generated JSON: a markdown code
+// fence or prose around the value, trailing commas, unquoted object keys,
+// and single-quoted strings. Valid JSON is returned unchanged (apart from
+// surrounding whitespace); the result of repairing invalid JSON may still be
+// invalid, so callers should check it.
+func Repair(text string) string {
+	text = strings.TrimSpace(text)
+	if json.Valid([]byte(text)) {
+		return text
+	}
+
+	text = stripFence(text)
+	if json.Valid([]byte(text)) {
+		return text
+	}
+
+	// Drop prose before and after the outermost object or array.
+	if start := strings.IndexAny(text, "{["); start >= 0 {
+		closer := byte('}')
+		if text[start] == '[' {
+			closer = ']'
+		}
+		if end := strings.LastIndexByte(text, closer); end > start {
+			text = text[start : end+1]
+		}
+	}
+	return repairTokens(text)
+}
+
+// stripFence returns the contents of the first markdown code fence in text,
+// or text if it has none.
+func stripFence(text string) string {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return text
+	}
+	rest := text[start+3:]
+	if i := strings.IndexByte(rest, '\n'); i >= 0 {
+		rest = rest[i+1:] // skip the language tag
+	}
+	if end := strings.Index(rest, "```"); end >= 0 {
+		rest = rest[:end]
+	}
+	return strings.TrimSpace(rest)
+}
+
+// repairTokens rewrites single-quoted strings, unquoted keys, and trailing
+// commas in a single pass.
+func repairTokens(text string) string {
+	var b strings.Builder
+	b.Grow(len(text) + 16)
+	last := byte(0) // last significant byte written outside strings
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case c == '"':
+			end := stringEnd(text, i, '"')
+			b.WriteString(text[i:end])
+			i = end - 1
+			last = '"'
+
+		case c == '\'':
+			end := stringEnd(text, i, '\'')
+			inner := text[i+1 : end]
+			if end < len(text) || strings.HasSuffix(inner, "'") {
+				inner = inner[:len(inner)-1]
+			}
+			inner = strings.ReplaceAll(inner, `\'`, `'`)
+			inner = escapeQuotes(inner)
+			b.WriteString(`"` + inner + `"`)
+			i = end - 1
+			last = '"'
+
+		case c == ',':
+			j := skipSpace(text, i+1)
+			if j < len(text) && (text[j] == '}' || text[j] == ']') {
+				continue // trailing comma
+			}
+			b.WriteByte(c)
+			last = c
+
+		case (last == '{' || last == ',') && isIdentStart(c):
+			j := i
+			for j < len(text) && isIdentPart(text[j]) {
+				j++
+			}
+			if k := skipSpace(text, j); k < len(text) && text[k] == ':' {
+				b.WriteString(`"` + text[i:j] + `"`)
+			} else {
+				b.WriteString(text[i:j])
+			}
+			i = j - 1
+			last = 'a'
+
+		default:
+			b.WriteByte(c)
+			if !isSpace(c) {
+				last = c
+			}
+		}
+	}
+	return b.String()
+}
+
+// stringEnd returns the index just past the string literal starting at
+// text[start], or len(text) if it is unterminated.
+func stringEnd(text string, start int, quote byte) int {
+	for i := start + 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			i++
+		case quote:
+			return i + 1
+		}
+	}
+	return len(text)
+}
+
+// escapeQuotes escapes unescaped double quotes.
+func escapeQuotes(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			b.WriteByte('\\')
+			if i+1 < len(s) {
+				i++
+				b.WriteByte(s[i])
+			}
+		case '"':
+			b.WriteString(`\"`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func skipSpace(text string, i int) int {
+	for i < len(text) && isSpace(text[i]) {
+		i++
+	}
+	return i
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '$' || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || ('0' <= c && c <= '9')
+}
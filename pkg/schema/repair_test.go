@@ -0,0 +1,28 @@
+package schema
+
+import "testing"
+
+func TestRepair(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"valid", ` {"a": 1} `, `{"a": 1}`},
+		{"fence", "```json\n{\"a\": 1}\n```", `{"a": 1}`},
+		{"prose", "Here is the result:\n```\n[1, 2]\n```\nLet me know!", `[1, 2]`},
+		{"unfenced prose", `Sure! {"a": 1} Hope that helps.`, `{"a": 1}`},
+		{"trailing commas", `{"a": [1, 2,], "b": 3,}`, `{"a": [1, 2], "b": 3}`},
+		{"unquoted keys", `{name: "Ann", $id: 1, nested: {ok: true}}`, `{"name": "Ann", "$id": 1, "nested": {"ok": true}}`},
+		{"single quotes", `{'name': 'Ann "the" O\'Neil'}`, `{"name": "Ann \"the\" O'Neil"}`},
+		{"strings untouched", `{"a": "x, }", "b": "{c: 1,}",}`, `{"a": "x, }", "b": "{c: 1,}"}`},
+		{"literals", `[true, false, null,]`, `[true, false, null]`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Repair(tt.in); got != tt.want {
+				t.Errorf("Repair(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
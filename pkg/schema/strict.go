@@ -0,0 +1,229 @@
+package schema
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// openAIStrictUnsupported lists keywords OpenAI strict mode rejects. They
+// are removed and described instead.
+var openAIStrictUnsupported = []string{
+	"minLength", "maxLength", "minProperties", "maxProperties", "uniqueItems",
+	"contains", "patternProperties", "propertyNames", "unevaluatedProperties",
+	"default", "not", "if", "then", "else",
+}
+
+// openAIStrictFormats lists the string formats OpenAI strict mode accepts.
+var openAIStrictFormats = []string{
+	"date-time", "time", "date", "duration", "email", "hostname", "ipv4", "ipv6", "uuid",
+}
+
+// NormalizeOpenAIStrict rewrites a schema to satisfy OpenAI strict mode:
+//   - every object lists all its properties as required and sets
+//     additionalProperties to false;
+//   - properties that were optional become nullable, via a type union or an
+//     anyOf with null, so the model can still omit a value;
+//   - oneOf becomes anyOf and allOf is merged into its parent;
+//   - unsupported keywords and formats are removed and described in the
+//     schema's description.
+//
+// The input is not modified.
+func (t *Translator) NormalizeOpenAIStrict(s *types.JSONSchema) map[string]any {
+	if s == nil {
+		return nil
+	}
+	schema := s.ToMap()
+	defs, _ := schema["$defs"].(map[string]any)
+	normalizeStrict(schema, defs)
+	if defs != nil {
+		for _, name := range sortedKeys(defs) {
+			if def, ok := defs[name].(map[string]any); ok {
+				normalizeStrict(def, defs)
+			}
+		}
+	}
+	return schema
+}
+
+// normalizeStrict normalizes one schema map in place, recursing into its
+// children. defs resolves $ref in allOf.
+func normalizeStrict(m map[string]any, defs map[string]any) {
+	if parts, ok := m["allOf"].([]any); ok {
+		delete(m, "allOf")
+		for _, part := range parts {
+			if pm, ok := part.(map[string]any); ok {
+				mergeSchemaMap(m, resolveRef(pm, defs))
+			}
+		}
+	}
+	if variants, ok := m["oneOf"]; ok {
+		delete(m, "oneOf")
+		if existing, ok := m["anyOf"].([]any); ok {
+			variants = append(existing, variants.([]any)...)
+		}
+		m["anyOf"] = variants
+	}
+
+	var notes []string
+	for _, key := range openAIStrictUnsupported {
+		if v, ok := m[key]; ok {
+			notes = append(notes, fmt.Sprintf("%s: %s", key, toString(v)))
+			delete(m, key)
+		}
+	}
+	if format, ok := m["format"].(string); ok && !slices.Contains(openAIStrictFormats, format) {
+		notes = append(notes, "format: "+format)
+		delete(m, "format")
+	}
+	if len(notes) > 0 {
+		desc, _ := m["description"].(string)
+		m["description"] = withConstraints(desc, notes)
+	}
+
+	if props, ok := m["properties"].(map[string]any); ok {
+		required := map[string]bool{}
+		if list, ok := m["required"].([]any); ok {
+			for _, name := range list {
+				if s, ok := name.(string); ok {
+					required[s] = true
+				}
+			}
+		}
+		names := sortedKeys(props)
+		all := make([]any, len(names))
+		for i, name := range names {
+			all[i] = name
+			prop, ok := props[name].(map[string]any)
+			if !ok {
+				continue
+			}
+			normalizeStrict(prop, defs)
+			if !required[name] {
+				props[name] = nullable(prop)
+			}
+		}
+		m["required"] = all
+	}
+	if isObject(m) {
+		m["additionalProperties"] = false
+	}
+
+	if items, ok := m["items"].(map[string]any); ok {
+		normalizeStrict(items, defs)
+	}
+	if variants, ok := m["anyOf"].([]any); ok {
+		for _, v := range variants {
+			if vm, ok := v.(map[string]any); ok {
+				normalizeStrict(vm, defs)
+			}
+		}
+	}
+}
+
+// nullable returns a schema that also accepts null.
+func nullable(m map[string]any) map[string]any {
+	switch typ := m["type"].(type) {
+	case string:
+		if typ != "null" {
+			m["type"] = []any{typ, "null"}
+		}
+		if enum, ok := m["enum"].([]any); ok && !slices.Contains(enum, nil) {
+			m["enum"] = append(enum, nil)
+		}
+		return m
+	case []any:
+		if !slices.Contains(typ, any("null")) {
+			m["type"] = append(typ, "null")
+		}
+		return m
+	}
+
+	if variants, ok := m["anyOf"].([]any); ok {
+		for _, v := range variants {
+			if vm, ok := v.(map[string]any); ok && vm["type"] == "null" {
+				return m
+			}
+		}
+		m["anyOf"] = append(variants, map[string]any{"type": "null"})
+		return m
+	}
+
+	// A $ref or untyped schema: wrap it, keeping the description outside.
+	wrapped := map[string]any{"anyOf": []any{m, map[string]any{"type": "null"}}}
+	if desc, ok := m["description"]; ok {
+		wrapped["description"] = desc
+		delete(m, "description")
+	}
+	return wrapped
+}
+
+// resolveRef returns the definition a "#/$defs/..." reference points to, or
+// m itself.
+func resolveRef(m map[string]any, defs map[string]any) map[string]any {
+	ref, ok := m["$ref"].(string)
+	if !ok || len(ref) <= len("#/$defs/") {
+		return m
+	}
+	if def, ok := defs[ref[len("#/$defs/"):]].(map[string]any); ok {
+		return def
+	}
+	return m
+}
+
+// mergeSchemaMap merges part into m for allOf: properties and required
+// fields are unioned, and other keywords are copied when m lacks them.
+func mergeSchemaMap(m, part map[string]any) {
+	for key, v := range part {
+		switch key {
+		case "properties":
+			props, _ := m["properties"].(map[string]any)
+			if props == nil {
+				props = map[string]any{}
+				m["properties"] = props
+			}
+			if partProps, ok := v.(map[string]any); ok {
+				for name, prop := range partProps {
+					if _, exists := props[name]; !exists {
+						props[name] = prop
+					}
+				}
+			}
+		case "required":
+			existing, _ := m["required"].([]any)
+			if list, ok := v.([]any); ok {
+				for _, name := range list {
+					if !slices.Contains(existing, name) {
+						existing = append(existing, name)
+					}
+				}
+			}
+			m["required"] = existing
+		default:
+			if _, exists := m[key]; !exists {
+				m[key] = v
+			}
+		}
+	}
+}
+
+func isObject(m map[string]any) bool {
+	switch typ := m["type"].(type) {
+	case string:
+		return typ == "object"
+	case []any:
+		return slices.Contains(typ, any("object"))
+	}
+	return false
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
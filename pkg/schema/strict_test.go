@@ -0,0 +1,129 @@
+package schema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestNormalizeOpenAIStrict(t *testing.T) {
+	translator := NewTranslator()
+
+	s := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"name":  {Type: "string", MinLength: types.Ptr(1)},
+			"unit":  {Type: "string", Enum: []any{"c", "f"}, Default: "c"},
+			"when":  {Type: "string", Format: "date-time"},
+			"site":  {Type: "string", Format: "uri", Description: "Home page"},
+			"owner": {Ref: "#/$defs/person"},
+			"shape": {OneOf: []types.JSONSchema{{Type: "string"}, {Type: "integer"}}},
+			"extra": {AllOf: []types.JSONSchema{
+				{Ref: "#/$defs/person"},
+				{Type: "object", Properties: map[string]types.JSONSchema{"age": {Type: "integer"}}},
+			}},
+		},
+		Required: []string{"name", "when"},
+		Defs: map[string]types.JSONSchema{
+			"person": {Type: "object", Properties: map[string]types.JSONSchema{"id": {Type: "string"}}, Required: []string{"id"}},
+		},
+	}
+
+	got := translator.NormalizeOpenAIStrict(s)
+
+	wantRequired := []any{"extra", "name", "owner", "shape", "site", "unit", "when"}
+	if !reflect.DeepEqual(got["required"], wantRequired) {
+		t.Errorf("required = %v, want %v", got["required"], wantRequired)
+	}
+	if got["additionalProperties"] != false {
+		t.Error("expected additionalProperties false on the root")
+	}
+
+	props := got["properties"].(map[string]any)
+	name := props["name"].(map[string]any)
+	if name["type"] != "string" || name["minLength"] != nil || name["description"] != "(minLength: 1)" {
+		t.Errorf("name = %v, want required string with minLength described", name)
+	}
+	unit := props["unit"].(map[string]any)
+	if !reflect.DeepEqual(unit["type"], []any{"string", "null"}) || !reflect.DeepEqual(unit["enum"], []any{"c", "f", nil}) {
+		t.Errorf("unit = %v, want nullable enum", unit)
+	}
+	if unit["default"] != nil || unit["description"] != "(default: c)" {
+		t.Errorf("unit = %v, want default described", unit)
+	}
+	if when := props["when"].(map[string]any); when["format"] != "date-time" {
+		t.Errorf("when = %v, want supported format kept", when)
+	}
+	if site := props["site"].(map[string]any); site["format"] != nil || site["description"] != "Home page (format: uri)" {
+		t.Errorf("site = %v, want unsupported format described", site)
+	}
+
+	owner := props["owner"].(map[string]any)
+	wantOwner := map[string]any{"anyOf": []any{map[string]any{"$ref": "#/$defs/person"}, map[string]any{"type": "null"}}}
+	if !reflect.DeepEqual(owner, wantOwner) {
+		t.Errorf("owner = %v, want %v", owner, wantOwner)
+	}
+	shape := props["shape"].(map[string]any)
+	if shape["oneOf"] != nil || len(shape["anyOf"].([]any)) != 3 {
+		t.Errorf("shape = %v, want anyOf with a null variant", shape)
+	}
+
+	extra := props["extra"].(map[string]any)
+	if extra["allOf"] != nil || !reflect.DeepEqual(extra["required"], []any{"age", "id"}) {
+		t.Errorf("extra = %v, want allOf merged with all properties required", extra)
+	}
+	age := extra["properties"].(map[string]any)["age"].(map[string]any)
+	if !reflect.DeepEqual(age["type"], []any{"integer", "null"}) {
+		t.Errorf("extra.age = %v, want nullable", age)
+	}
+
+	person := got["$defs"].(map[string]any)["person"].(map[string]any)
+	if person["additionalProperties"] != false || !reflect.DeepEqual(person["required"], []any{"id"}) {
+		t.Errorf("person = %v, want normalized definition", person)
+	}
+
+	// The input is untouched.
+	if s.Properties["name"].MinLength == nil || len(s.Properties["extra"].AllOf) != 2 {
+		t.Error("input schema was modified")
+	}
+}
+
+func TestToolsToOpenAIStrict_Normalizes(t *testing.T) {
+	translator := NewTranslator()
+
+	tools := translator.ToolsToOpenAIStrict([]types.Tool{{
+		Name: "search",
+		Parameters: types.JSONSchema{
+			Type: "object",
+			Properties: map[string]types.JSONSchema{
+				"query": {Type: "string"},
+				"limit": {Type: "integer", Default: 10},
+			},
+			Required: []string{"query"},
+		},
+	}})
+
+	data, _ := json.Marshal(tools[0].Function.Parameters)
+	if !strings.Contains(string(data), `"required":["limit","query"]`) {
+		t.Errorf("parameters = %s, want every property required", data)
+	}
+	if !strings.Contains(string(data), `"type":["integer","null"]`) {
+		t.Errorf("parameters = %s, want optional limit nullable", data)
+	}
+
+	// Non-strict response formats keep the schema as written.
+	rf := translator.ToOpenAI(&types.ResponseFormat{
+		Type:   "json_schema",
+		Strict: types.Ptr(false),
+		Schema: &types.JSONSchema{
+			Type:       "object",
+			Properties: map[string]types.JSONSchema{"query": {Type: "string"}},
+		},
+	})
+	if rf.JSONSchema.Schema["required"] != nil {
+		t.Errorf("non-strict schema = %v, want required left alone", rf.JSONSchema.Schema)
+	}
+}
@@ -3,6 +3,10 @@ package schema
 
 import (
 	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
@@ -41,11 +45,11 @@ func (t *Translator) ToOpenAI(rf *types.ResponseFormat) *OpenAIResponseFormat {
 	case "json":
 		return &OpenAIResponseFormat{Type: "json_object"}
 	case "json_schema":
-		schema := t.prepareOpenAISchema(rf.Schema)
 		strict := true
 		if rf.Strict != nil {
 			strict = *rf.Strict
 		}
+		schema := t.prepareOpenAISchema(rf.Schema, strict)
 		return &OpenAIResponseFormat{
 			Type: "json_schema",
 			JSONSchema: &OpenAIJSONSchema{
@@ -60,11 +64,15 @@ func (t *Translator) ToOpenAI(rf *types.ResponseFormat) *OpenAIResponseFormat {
 	}
 }
 
-// prepareOpenAISchema adds required OpenAI constraints.
-func (t *Translator) prepareOpenAISchema(s *types.JSONSchema) map[string]any {
+// prepareOpenAISchema adds required OpenAI constraints. Strict schemas are
+// normalized with NormalizeOpenAIStrict.
+func (t *Translator) prepareOpenAISchema(s *types.JSONSchema, strict bool) map[string]any {
 	if s == nil {
 		return nil
 	}
+	if strict {
+		return t.NormalizeOpenAIStrict(s)
+	}
 
 	// Convert to map and add OpenAI-specific requirements
 	schema := s.ToMap()
@@ -161,11 +169,12 @@ func (t *Translator) ToolsToOpenAI(tools []types.Tool) []OpenAITool {
 }
 
 // ToolsToOpenAIStrict converts unified tools to OpenAI format with strict mode.
-// In strict mode, ALL properties must be listed in the required array.
+// In strict mode, ALL properties must be listed in the required array; the
+// parameters are normalized with NormalizeOpenAIStrict to make it so.
 func (t *Translator) ToolsToOpenAIStrict(tools []types.Tool) []OpenAITool {
 	result := make([]OpenAITool, len(tools))
 	for i, tool := range tools {
-		params := t.prepareOpenAISchema(&tool.Parameters)
+		params := t.prepareOpenAISchema(&tool.Parameters, true)
 		result[i] = OpenAITool{
 			Type: "function",
 			Function: OpenAIFunctionTool{
@@ -199,8 +208,8 @@ func (t *Translator) ToAnthropic(rf *types.ResponseFormat) *AnthropicOutputConfi
 	}
 
 	if rf.Type == "json" {
-		// Anthropic doesn't have a simple JSON mode like OpenAI
-		// We'd need to handle this differently, perhaps with system prompt
+		// Anthropic doesn't have a simple JSON mode like OpenAI; the
+		// Anthropic transformer emulates it with the system prompt
 		return nil
 	}
 
@@ -208,6 +217,7 @@ func (t *Translator) ToAnthropic(rf *types.ResponseFormat) *AnthropicOutputConfi
 		schema := rf.Schema.ToMap()
 		// Anthropic requires additionalProperties: false on all objects
 		t.addAdditionalPropertiesFalse(schema)
+		t.describeAnthropicConstraints(schema)
 		return &AnthropicOutputConfig{
 			Format: &AnthropicFormat{
 				Type:   "json_schema",
@@ -219,6 +229,75 @@ func (t *Translator) ToAnthropic(rf *types.ResponseFormat) *AnthropicOutputConfi
 	return nil
 }
 
+// anthropicUnsupported lists the validation keywords Anthropic structured
+// outputs reject. Tool input schemas accept them.
+var anthropicUnsupported = []string{
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "maxItems", "minProperties", "maxProperties",
+}
+
+// describeAnthropicConstraints moves the keywords Anthropic structured
+// outputs do not support into each schema's description, so the model still
+// sees them. minItems is kept when it is 0 or 1, the values Anthropic allows.
+func (t *Translator) describeAnthropicConstraints(schema map[string]any) {
+	walkSchemaMap(schema, func(m map[string]any) {
+		var notes []string
+		for _, key := range anthropicUnsupported {
+			if v, ok := m[key]; ok {
+				notes = append(notes, fmt.Sprintf("%s: %s", key, toString(v)))
+				delete(m, key)
+			}
+		}
+		if v, ok := m["minItems"].(float64); ok && v > 1 {
+			notes = append(notes, fmt.Sprintf("minItems: %s", toString(v)))
+			delete(m, "minItems")
+		}
+		if len(notes) > 0 {
+			desc, _ := m["description"].(string)
+			m["description"] = withConstraints(desc, notes)
+		}
+	})
+}
+
+// walkSchemaMap calls fn on a schema map and every schema nested in it.
+func walkSchemaMap(schema map[string]any, fn func(map[string]any)) {
+	if schema == nil {
+		return
+	}
+	fn(schema)
+	for _, key := range []string{"properties", "$defs"} {
+		if children, ok := schema[key].(map[string]any); ok {
+			for _, child := range children {
+				if m, ok := child.(map[string]any); ok {
+					walkSchemaMap(m, fn)
+				}
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		walkSchemaMap(items, fn)
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		if arr, ok := schema[key].([]any); ok {
+			for _, item := range arr {
+				if m, ok := item.(map[string]any); ok {
+					walkSchemaMap(m, fn)
+				}
+			}
+		}
+	}
+}
+
+// withConstraints appends constraints a provider cannot enforce to a
+// description, e.g. "Age in years (minimum: 0, maximum: 150)".
+func withConstraints(desc string, notes []string) string {
+	note := "(" + strings.Join(notes, ", ") + ")"
+	if desc == "" {
+		return note
+	}
+	return desc + " " + note
+}
+
 // AnthropicTool is Anthropic's tool format.
 type AnthropicTool struct {
 	Name        string         `json:"name"`
@@ -253,14 +332,31 @@ type GoogleGenerationConfig struct {
 }
 
 // GoogleSchema is Google's schema format (differs from standard JSON Schema).
+// It has no references, so $ref is inlined; unions use anyOf.
 type GoogleSchema struct {
-	Type        string                   `json:"type"`
+	Type        string                   `json:"type,omitempty"`
+	Format      string                   `json:"format,omitempty"`
 	Description string                   `json:"description,omitempty"`
 	Enum        []string                 `json:"enum,omitempty"`
 	Properties  map[string]*GoogleSchema `json:"properties,omitempty"`
 	Required    []string                 `json:"required,omitempty"`
 	Items       *GoogleSchema            `json:"items,omitempty"`
 	Nullable    bool                     `json:"nullable,omitempty"`
+	AnyOf       []*GoogleSchema          `json:"anyOf,omitempty"`
+	Minimum     *float64                 `json:"minimum,omitempty"`
+	Maximum     *float64                 `json:"maximum,omitempty"`
+	MinItems    *int                     `json:"minItems,omitempty"`
+	MaxItems    *int                     `json:"maxItems,omitempty"`
+	MinLength   *int                     `json:"minLength,omitempty"`
+	MaxLength   *int                     `json:"maxLength,omitempty"`
+	Pattern     string                   `json:"pattern,omitempty"`
+}
+
+// googleFormats lists the formats Google accepts for each type.
+var googleFormats = map[string][]string{
+	"STRING":  {"enum", "date-time"},
+	"INTEGER": {"int32", "int64"},
+	"NUMBER":  {"float", "double"},
 }
 
 // ToGoogle converts unified schema to Google format.
@@ -290,37 +386,167 @@ func (t *Translator) convertToGoogleSchema(s *types.JSONSchema) *GoogleSchema {
 	if s == nil {
 		return nil
 	}
+	return t.toGoogleSchema(s, s, nil)
+}
+
+// toGoogleSchema converts s, inlining references to root's $defs. expanding
+// holds the definitions being inlined; a recursive reference is cut off as
+// an untyped object, since Google schemas cannot express recursion.
+func (t *Translator) toGoogleSchema(root, s *types.JSONSchema, expanding []string) *GoogleSchema {
+	if s.Ref != "" {
+		name, _ := strings.CutPrefix(s.Ref, "#/$defs/")
+		def, ok := root.Defs[name]
+		if !ok || slices.Contains(expanding, name) {
+			desc := s.Description
+			if desc == "" {
+				desc = "A " + name + " value."
+			}
+			return &GoogleSchema{Type: "OBJECT", Description: desc}
+		}
+		gs := t.toGoogleSchema(root, &def, append(expanding, name))
+		if s.Description != "" {
+			gs.Description = s.Description
+		}
+		return gs
+	}
+
+	// allOf is merged into a single schema.
+	if len(s.AllOf) > 0 {
+		merged := mergeAllOf(root, s)
+		return t.toGoogleSchema(root, &merged, expanding)
+	}
 
 	gs := &GoogleSchema{
-		Type:        t.mapTypeToGoogle(s.Type),
 		Description: s.Description,
 		Required:    s.Required,
 	}
 
+	// Unions become anyOf, except a union with null, which becomes nullable.
+	// Google has no oneOf; it is relaxed to anyOf.
+	if variants := append(slices.Clone(s.AnyOf), s.OneOf...); len(variants) > 0 {
+		var nonNull []types.JSONSchema
+		for _, v := range variants {
+			if v.Type == "null" {
+				gs.Nullable = true
+			} else {
+				nonNull = append(nonNull, v)
+			}
+		}
+		if len(nonNull) == 1 {
+			inner := t.toGoogleSchema(root, &nonNull[0], expanding)
+			inner.Nullable = inner.Nullable || gs.Nullable
+			if gs.Description != "" {
+				inner.Description = gs.Description
+			}
+			return inner
+		}
+		for i := range nonNull {
+			gs.AnyOf = append(gs.AnyOf, t.toGoogleSchema(root, &nonNull[i], expanding))
+		}
+		return gs
+	}
+
+	switch {
+	case s.Type != "":
+		gs.Type = t.mapTypeToGoogle(s.Type)
+	case len(s.Properties) > 0:
+		gs.Type = "OBJECT"
+	case s.Items != nil:
+		gs.Type = "ARRAY"
+	default:
+		gs.Type = t.mapTypeToGoogle("")
+	}
+
 	// Convert enum (Google only supports string enums)
 	if len(s.Enum) > 0 {
 		gs.Enum = make([]string, len(s.Enum))
 		for i, v := range s.Enum {
 			gs.Enum[i] = toString(v)
 		}
+	} else if s.Const != nil {
+		gs.Enum = []string{toString(s.Const)}
+	}
+
+	// Validation keywords Google supports are carried over; the rest are
+	// described so the model still sees them.
+	gs.Minimum, gs.Maximum = s.Minimum, s.Maximum
+	gs.MinItems, gs.MaxItems = s.MinItems, s.MaxItems
+	gs.MinLength, gs.MaxLength = s.MinLength, s.MaxLength
+	gs.Pattern = s.Pattern
+	if s.Format != "" {
+		if slices.Contains(googleFormats[gs.Type], s.Format) {
+			gs.Format = s.Format
+		} else {
+			gs.Description = withConstraints(gs.Description, []string{"format: " + s.Format})
+		}
 	}
 
 	// Convert properties
 	if len(s.Properties) > 0 {
 		gs.Properties = make(map[string]*GoogleSchema)
 		for name, prop := range s.Properties {
-			gs.Properties[name] = t.convertToGoogleSchema(&prop)
+			gs.Properties[name] = t.toGoogleSchema(root, &prop, expanding)
 		}
 	}
 
 	// Convert items (arrays)
 	if s.Items != nil {
-		gs.Items = t.convertToGoogleSchema(s.Items)
+		gs.Items = t.toGoogleSchema(root, s.Items, expanding)
 	}
 
 	return gs
 }
 
+// mergeAllOf combines s and its allOf schemas, with references resolved,
+// into one schema: properties and required fields are unioned and the first
+// type and description win.
+func mergeAllOf(root, s *types.JSONSchema) types.JSONSchema {
+	merged := *s
+	merged.AllOf = nil
+	merged.Properties = maps.Clone(s.Properties)
+	merged.Required = slices.Clone(s.Required)
+
+	for _, part := range s.AllOf {
+		for depth := 0; part.Ref != "" && depth < 32; depth++ {
+			name, _ := strings.CutPrefix(part.Ref, "#/$defs/")
+			def, ok := root.Defs[name]
+			if !ok {
+				break
+			}
+			part = def
+		}
+		if len(part.AllOf) > 0 {
+			part = mergeAllOf(root, &part)
+		}
+		if merged.Type == "" {
+			merged.Type = part.Type
+		}
+		if merged.Description == "" {
+			merged.Description = part.Description
+		}
+		for name, prop := range part.Properties {
+			if merged.Properties == nil {
+				merged.Properties = make(map[string]types.JSONSchema)
+			}
+			if _, ok := merged.Properties[name]; !ok {
+				merged.Properties[name] = prop
+			}
+		}
+		for _, name := range part.Required {
+			if !slices.Contains(merged.Required, name) {
+				merged.Required = append(merged.Required, name)
+			}
+		}
+		if merged.Items == nil {
+			merged.Items = part.Items
+		}
+		if len(merged.Enum) == 0 {
+			merged.Enum = part.Enum
+		}
+	}
+	return merged
+}
+
 // mapTypeToGoogle maps JSON Schema types to Google types.
 func (t *Translator) mapTypeToGoogle(jsonType string) string {
 	switch jsonType {
@@ -2,6 +2,7 @@ package schema
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 
 	"github.com/Chloe199719/agent-router/pkg/types"
@@ -384,6 +385,137 @@ func TestToGoogle_JSONSchemaFormat(t *testing.T) {
 	}
 }
 
+func TestToGoogle_RefsAndUnions(t *testing.T) {
+	translator := NewTranslator()
+
+	s := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"home":     {Ref: "#/$defs/address", Description: "Home address"},
+			"nickname": {AnyOf: []types.JSONSchema{{Type: "string"}, {Type: "null"}}},
+			"contact": {OneOf: []types.JSONSchema{
+				{Type: "string"},
+				{Ref: "#/$defs/address"},
+			}},
+			"employee": {AllOf: []types.JSONSchema{
+				{Ref: "#/$defs/address"},
+				{Properties: map[string]types.JSONSchema{"badge": {Type: "integer"}}, Required: []string{"badge"}},
+			}},
+			"manager": {Ref: "#/$defs/person"},
+		},
+		Defs: map[string]types.JSONSchema{
+			"address": {
+				Type:       "object",
+				Properties: map[string]types.JSONSchema{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+			"person": {
+				Type:       "object",
+				Properties: map[string]types.JSONSchema{"boss": {Ref: "#/$defs/person"}},
+			},
+		},
+	}
+
+	result := translator.ToGoogle(&types.ResponseFormat{Type: "json_schema", Schema: s}).ResponseSchema
+	props := result.Properties
+
+	if home := props["home"]; home.Type != "OBJECT" || home.Description != "Home address" || home.Properties["city"].Type != "STRING" {
+		t.Errorf("home = %+v, want the inlined address", home)
+	}
+	if nick := props["nickname"]; nick.Type != "STRING" || !nick.Nullable || nick.AnyOf != nil {
+		t.Errorf("nickname = %+v, want a nullable string", nick)
+	}
+	if contact := props["contact"]; contact.Type != "" || len(contact.AnyOf) != 2 || contact.AnyOf[1].Properties["city"] == nil {
+		t.Errorf("contact = %+v, want anyOf string and address", contact)
+	}
+	if emp := props["employee"]; emp.Type != "OBJECT" || len(emp.Properties) != 2 || len(emp.Required) != 2 {
+		t.Errorf("employee = %+v, want merged properties", emp)
+	}
+	boss := props["manager"].Properties["boss"]
+	if boss == nil || boss.Type != "OBJECT" || boss.Properties != nil {
+		t.Errorf("boss = %+v, want the recursion cut off", boss)
+	}
+
+	data, _ := json.Marshal(result)
+	if strings.Contains(string(data), "$ref") || strings.Contains(string(data), "oneOf") {
+		t.Errorf("unsupported keywords left in %s", data)
+	}
+}
+
+func TestToAnthropic_Constraints(t *testing.T) {
+	translator := NewTranslator()
+
+	s := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"age":  {Type: "integer", Description: "Age in years", Minimum: types.Ptr(0.0), Maximum: types.Ptr(150.0)},
+			"code": {Type: "string", Pattern: "^[A-Z]{3}$", MaxLength: types.Ptr(3)},
+			"tags": {Type: "array", Items: &types.JSONSchema{Type: "string"}, MinItems: types.Ptr(1)},
+			"pair": {Type: "array", Items: &types.JSONSchema{Type: "number"}, MinItems: types.Ptr(2)},
+		},
+	}
+
+	props := translator.ToAnthropic(&types.ResponseFormat{Type: "json_schema", Schema: s}).Format.Schema["properties"].(map[string]any)
+
+	age := props["age"].(map[string]any)
+	if _, ok := age["minimum"]; ok {
+		t.Error("expected minimum to be removed")
+	}
+	if age["description"] != "Age in years (minimum: 0, maximum: 150)" {
+		t.Errorf("age description = %q", age["description"])
+	}
+	code := props["code"].(map[string]any)
+	if code["pattern"] != "^[A-Z]{3}$" || code["description"] != "(maxLength: 3)" {
+		t.Errorf("code = %v, want pattern kept and maxLength described", code)
+	}
+	if tags := props["tags"].(map[string]any); tags["minItems"] != float64(1) {
+		t.Errorf("tags = %v, want minItems 1 kept", tags)
+	}
+	if pair := props["pair"].(map[string]any); pair["minItems"] != nil || pair["description"] != "(minItems: 2)" {
+		t.Errorf("pair = %v, want minItems 2 described", pair)
+	}
+
+	// Tool input schemas support every keyword.
+	tools := translator.ToolsToAnthropic([]types.Tool{{Name: "f", Parameters: *s}})
+	toolAge := tools[0].InputSchema["properties"].(map[string]any)["age"].(map[string]any)
+	if toolAge["minimum"] != float64(0) {
+		t.Errorf("tool age = %v, want minimum kept", toolAge)
+	}
+}
+
+func TestToGoogle_Constraints(t *testing.T) {
+	translator := NewTranslator()
+
+	s := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"age":   {Type: "integer", Minimum: types.Ptr(0.0), Maximum: types.Ptr(150.0), Format: "int32"},
+			"code":  {Type: "string", Pattern: "^[A-Z]{3}$", MinLength: types.Ptr(3), MaxLength: types.Ptr(3)},
+			"email": {Type: "string", Description: "Contact", Format: "email"},
+			"tags":  {Type: "array", Items: &types.JSONSchema{Type: "string"}, MinItems: types.Ptr(1), MaxItems: types.Ptr(5)},
+			"kind":  {Type: "string", Const: "person"},
+		},
+	}
+
+	props := translator.ToGoogle(&types.ResponseFormat{Type: "json_schema", Schema: s}).ResponseSchema.Properties
+
+	if age := props["age"]; *age.Minimum != 0 || *age.Maximum != 150 || age.Format != "int32" {
+		t.Errorf("age = %+v", age)
+	}
+	if code := props["code"]; code.Pattern != "^[A-Z]{3}$" || *code.MinLength != 3 || *code.MaxLength != 3 {
+		t.Errorf("code = %+v", code)
+	}
+	if email := props["email"]; email.Format != "" || email.Description != "Contact (format: email)" {
+		t.Errorf("email = %+v, want the unsupported format described", email)
+	}
+	if tags := props["tags"]; *tags.MinItems != 1 || *tags.MaxItems != 5 {
+		t.Errorf("tags = %+v", tags)
+	}
+	if kind := props["kind"]; len(kind.Enum) != 1 || kind.Enum[0] != "person" {
+		t.Errorf("kind = %+v, want const as a single-value enum", kind)
+	}
+}
+
 func TestMapTypeToGoogle(t *testing.T) {
 	translator := NewTranslator()
 
@@ -0,0 +1,216 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Validate checks a JSON document against a schema. It supports the subset
+// of JSON Schema that JSONSchema models, resolving $ref against the root
+// schema's $defs; format is not checked. The error names the first invalid
+// location, such as "$.items[2].name".
+func Validate(s *types.JSONSchema, data []byte) error {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %w", err)
+	}
+	return (&validator{root: s}).validate(s, v, "$")
+}
+
+type validator struct {
+	root *types.JSONSchema
+}
+
+func (vr *validator) validate(s *types.JSONSchema, v any, path string) error {
+	if s.Ref != "" {
+		name, ok := strings.CutPrefix(s.Ref, "#/$defs/")
+		def, found := vr.root.Defs[name]
+		if !ok || !found {
+			return fmt.Errorf("%s: unresolved $ref %q", path, s.Ref)
+		}
+		return vr.validate(&def, v, path)
+	}
+
+	if s.Type != "" && !hasType(s.Type, v) {
+		return fmt.Errorf("%s: expected %s, got %s", path, s.Type, typeName(v))
+	}
+	if s.Const != nil && !equalJSON(s.Const, v) {
+		return fmt.Errorf("%s: expected %v", path, s.Const)
+	}
+	if len(s.Enum) > 0 && !containsJSON(s.Enum, v) {
+		return fmt.Errorf("%s: %v is not one of %v", path, v, s.Enum)
+	}
+
+	switch v := v.(type) {
+	case map[string]any:
+		if err := vr.validateObject(s, v, path); err != nil {
+			return err
+		}
+	case []any:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			return fmt.Errorf("%s: expected at least %d items, got %d", path, *s.MinItems, len(v))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			return fmt.Errorf("%s: expected at most %d items, got %d", path, *s.MaxItems, len(v))
+		}
+		if s.Items != nil {
+			for i, item := range v {
+				if err := vr.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i)); err != nil {
+					return err
+				}
+			}
+		}
+	case string:
+		n := utf8.RuneCountInString(v)
+		if s.MinLength != nil && n < *s.MinLength {
+			return fmt.Errorf("%s: expected at least %d characters, got %d", path, *s.MinLength, n)
+		}
+		if s.MaxLength != nil && n > *s.MaxLength {
+			return fmt.Errorf("%s: expected at most %d characters, got %d", path, *s.MaxLength, n)
+		}
+		if s.Pattern != "" {
+			re, err := regexp.Compile(s.Pattern)
+			if err != nil {
+				return fmt.Errorf("%s: invalid pattern %q: %w", path, s.Pattern, err)
+			}
+			if !re.MatchString(v) {
+				return fmt.Errorf("%s: %q does not match %q", path, v, s.Pattern)
+			}
+		}
+	case float64:
+		if s.Minimum != nil && v < *s.Minimum {
+			return fmt.Errorf("%s: %v is less than %v", path, v, *s.Minimum)
+		}
+		if s.Maximum != nil && v > *s.Maximum {
+			return fmt.Errorf("%s: %v is greater than %v", path, v, *s.Maximum)
+		}
+	}
+
+	for i := range s.AllOf {
+		if err := vr.validate(&s.AllOf[i], v, path); err != nil {
+			return err
+		}
+	}
+	if len(s.AnyOf) > 0 && vr.matches(s.AnyOf, v, path) == 0 {
+		return fmt.Errorf("%s: does not match any allowed schema", path)
+	}
+	if len(s.OneOf) > 0 {
+		if n := vr.matches(s.OneOf, v, path); n != 1 {
+			return fmt.Errorf("%s: matches %d schemas, expected exactly one", path, n)
+		}
+	}
+	return nil
+}
+
+func (vr *validator) validateObject(s *types.JSONSchema, v map[string]any, path string) error {
+	for _, name := range s.Required {
+		if _, ok := v[name]; !ok {
+			return fmt.Errorf("%s: missing required property %q", path, name)
+		}
+	}
+
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		prop, ok := s.Properties[k]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				return fmt.Errorf("%s: unexpected property %q", path, k)
+			}
+			continue
+		}
+		if err := vr.validate(&prop, v[k], path+"."+k); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matches counts the schemas v is valid against.
+func (vr *validator) matches(schemas []types.JSONSchema, v any, path string) int {
+	n := 0
+	for i := range schemas {
+		if vr.validate(&schemas[i], v, path) == nil {
+			n++
+		}
+	}
+	return n
+}
+
+func hasType(t string, v any) bool {
+	switch t {
+	case "object":
+		_, ok := v.(map[string]any)
+		return ok
+	case "array":
+		_, ok := v.([]any)
+		return ok
+	case "string":
+		_, ok := v.(string)
+		return ok
+	case "number":
+		_, ok := v.(float64)
+		return ok
+	case "integer":
+		f, ok := v.(float64)
+		return ok && f == math.Trunc(f)
+	case "boolean":
+		_, ok := v.(bool)
+		return ok
+	case "null":
+		return v == nil
+	}
+	return true
+}
+
+func typeName(v any) string {
+	switch v.(type) {
+	case map[string]any:
+		return "object"
+	case []any:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case nil:
+		return "null"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// equalJSON compares values as they would appear in JSON, so the schema's
+// Go values (e.g. int) match decoded ones (float64).
+func equalJSON(a, b any) bool {
+	ja, err1 := json.Marshal(a)
+	jb, err2 := json.Marshal(b)
+	if err1 != nil || err2 != nil {
+		return reflect.DeepEqual(a, b)
+	}
+	var na, nb any
+	json.Unmarshal(ja, &na)
+	json.Unmarshal(jb, &nb)
+	return reflect.DeepEqual(na, nb)
+}
+
+func containsJSON(values []any, v any) bool {
+	for _, want := range values {
+		if equalJSON(want, v) {
+			return true
+		}
+	}
+	return false
+}
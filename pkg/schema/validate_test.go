@@ -0,0 +1,81 @@
+package schema
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestValidate(t *testing.T) {
+	s := &types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"name":    {Type: "string", MinLength: types.Ptr(1)},
+			"age":     {Type: "integer", Minimum: types.Ptr(0.0)},
+			"role":    {Type: "string", Enum: []any{"admin", "user"}},
+			"tags":    {Type: "array", Items: &types.JSONSchema{Type: "string"}, MaxItems: types.Ptr(2)},
+			"address": {Ref: "#/$defs/address"},
+		},
+		Required:             []string{"name", "age"},
+		AdditionalProperties: types.Ptr(false),
+		Defs: map[string]types.JSONSchema{
+			"address": {
+				Type:       "object",
+				Properties: map[string]types.JSONSchema{"city": {Type: "string"}},
+				Required:   []string{"city"},
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		data    string
+		wantErr string
+	}{
+		{"valid", `{"name":"Ann","age":42,"role":"admin","tags":["a"],"address":{"city":"Oslo"}}`, ""},
+		{"invalid JSON", `{"name":`, "invalid JSON"},
+		{"missing required", `{"name":"Ann"}`, `$: missing required property "age"`},
+		{"wrong type", `{"name":"Ann","age":"42"}`, "$.age: expected integer, got string"},
+		{"not an integer", `{"name":"Ann","age":4.5}`, "$.age: expected integer, got number"},
+		{"below minimum", `{"name":"Ann","age":-1}`, "$.age: -1 is less than 0"},
+		{"too short", `{"name":"","age":1}`, "$.name: expected at least 1 characters"},
+		{"not in enum", `{"name":"Ann","age":1,"role":"root"}`, "$.role: root is not one of"},
+		{"too many items", `{"name":"Ann","age":1,"tags":["a","b","c"]}`, "$.tags: expected at most 2 items"},
+		{"bad item", `{"name":"Ann","age":1,"tags":[1]}`, "$.tags[0]: expected string"},
+		{"extra property", `{"name":"Ann","age":1,"email":"a@b.c"}`, `$: unexpected property "email"`},
+		{"ref", `{"name":"Ann","age":1,"address":{}}`, `$.address: missing required property "city"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(s, []byte(tt.data))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidate_Composition(t *testing.T) {
+	s := &types.JSONSchema{AnyOf: []types.JSONSchema{{Type: "string"}, {Type: "null"}}}
+	if err := Validate(s, []byte(`null`)); err != nil {
+		t.Errorf("null: %v", err)
+	}
+	if err := Validate(s, []byte(`1`)); err == nil {
+		t.Error("expected 1 to fail anyOf")
+	}
+
+	s = &types.JSONSchema{OneOf: []types.JSONSchema{{Type: "number"}, {Type: "integer"}}}
+	if err := Validate(s, []byte(`1`)); err == nil {
+		t.Error("expected 1 to match both oneOf schemas")
+	}
+	if err := Validate(s, []byte(`1.5`)); err != nil {
+		t.Errorf("1.5: %v", err)
+	}
+}
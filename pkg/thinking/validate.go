@@ -5,6 +5,8 @@
 //   - Anthropic: https://docs.anthropic.com/en/docs/about-claude/models/extended-thinking-models
 //   - Google:    https://ai.google.dev/gemini-api/docs/thinking ("Supported models, tools, and capabilities")
 //   - OpenAI:    https://platform.openai.com/docs/guides/reasoning
+//   - DeepSeek:  https://api-docs.deepseek.com/guides/reasoning_model
+//   - OpenRouter: https://openrouter.ai/docs/use-cases/reasoning-tokens
 package thinking
 
 import (
@@ -31,6 +33,12 @@ func ModelSupportsThinking(provider types.Provider, model string) bool {
 		return anthropicModelSupportsThinking(m)
 	case types.ProviderGoogle, types.ProviderVertex:
 		return googleModelSupportsThinking(m)
+	case types.ProviderDeepSeek:
+		return strings.Contains(m, "deepseek-reasoner")
+	case types.ProviderOpenRouter:
+		// OpenRouter accepts reasoning settings for every model and ignores
+		// them where the upstream model cannot reason.
+		return true
 	default:
 		return false
 	}
@@ -117,6 +125,10 @@ func ValidateThinking(provider types.Provider, model string, thinking *types.Thi
 		return validateAnthropic(thinking, maxTokens)
 	case types.ProviderGoogle, types.ProviderVertex:
 		return validateGoogle(provider, model, thinking)
+	case types.ProviderDeepSeek:
+		return validateDeepSeek(thinking)
+	case types.ProviderOpenRouter:
+		return validateOpenRouter(thinking)
 	default:
 		return errors.ErrInvalidRequest(fmt.Sprintf("thinking is not supported for provider %s", provider)).WithProvider(provider)
 	}
@@ -127,6 +139,25 @@ func validateOpenAI(thinking *types.ThinkingConfig) error {
 	return nil
 }
 
+// DeepSeek: deepseek-reasoner always reasons and takes no reasoning settings.
+func validateDeepSeek(thinking *types.ThinkingConfig) error {
+	if thinking.Budget != nil || strings.TrimSpace(thinking.Effort) != "" || strings.TrimSpace(thinking.Level) != "" {
+		return errors.ErrInvalidRequest("thinking: DeepSeek reasoning cannot be configured; use deepseek-reasoner without budget, effort, or level").WithProvider(types.ProviderDeepSeek)
+	}
+	return nil
+}
+
+// OpenRouter: reasoning takes either an effort or a max_tokens budget.
+func validateOpenRouter(thinking *types.ThinkingConfig) error {
+	if thinking.Budget != nil && strings.TrimSpace(thinking.Effort) != "" {
+		return errors.ErrInvalidRequest(`thinking: for OpenRouter set either "effort" or "budget", not both`).WithProvider(types.ProviderOpenRouter)
+	}
+	if strings.TrimSpace(thinking.Level) != "" {
+		return errors.ErrInvalidRequest(`thinking: OpenRouter does not take "level"; use "effort" or "budget"`).WithProvider(types.ProviderOpenRouter)
+	}
+	return nil
+}
+
 func validateAnthropic(thinking *types.ThinkingConfig, maxTokens *int) error {
 	adaptive := strings.EqualFold(thinking.Type, "adaptive")
 	enabledExplicit := strings.EqualFold(thinking.Type, "enabled")
@@ -46,6 +46,9 @@ func TestModelSupportsThinking(t *testing.T) {
 		{types.ProviderGoogle, "gemini-2.0-flash", false},
 		{types.ProviderVertex, "gemini-2.5-pro", true},
 		{types.ProviderVertex, "gemini-3-pro-preview", true},
+		// DeepSeek — reasoning model guide
+		{types.ProviderDeepSeek, "deepseek-reasoner", true},
+		{types.ProviderDeepSeek, "deepseek-chat", false},
 	}
 	for _, tt := range tests {
 		if got := ModelSupportsThinking(tt.provider, tt.model); got != tt.want {
@@ -67,6 +70,24 @@ func TestValidateThinking_OpenAI_EmptyOK(t *testing.T) {
 	}
 }
 
+func TestValidateThinking_DeepSeek(t *testing.T) {
+	if err := ValidateThinking(types.ProviderDeepSeek, "deepseek-reasoner", &types.ThinkingConfig{}, nil); err != nil {
+		t.Fatalf("deepseek empty thinking: %v", err)
+	}
+	if err := ValidateThinking(types.ProviderDeepSeek, "deepseek-reasoner", &types.ThinkingConfig{Effort: "high"}, nil); err == nil {
+		t.Fatal("expected error for deepseek reasoning effort")
+	}
+}
+
+func TestValidateThinking_OpenRouter(t *testing.T) {
+	if err := ValidateThinking(types.ProviderOpenRouter, "anthropic/claude-sonnet-4.5", &types.ThinkingConfig{Budget: types.Ptr(2048)}, nil); err != nil {
+		t.Fatalf("openrouter budget: %v", err)
+	}
+	if err := ValidateThinking(types.ProviderOpenRouter, "openai/o3", &types.ThinkingConfig{Effort: "high", Budget: types.Ptr(2048)}, nil); err == nil {
+		t.Fatal("expected error for openrouter effort with budget")
+	}
+}
+
 func TestValidateThinking_OpenAI_ModelUnsupported(t *testing.T) {
 	th := &types.ThinkingConfig{Effort: "low"}
 	err := ValidateThinking(types.ProviderOpenAI, "gpt-4o", th, nil)
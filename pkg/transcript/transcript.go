@@ -0,0 +1,131 @@
+// Package transcript records the router's completions and exports them as
+// fine-tuning and evaluation datasets.
+//
+// Record every call as JSON lines:
+//
+//	f, _ := os.Create("transcripts.jsonl")
+//	rec := transcript.NewRecorder(f)
+//	r, _ := router.New(router.WithOpenAI(key), router.WithCompletionLog(rec.Record))
+//
+// Each line is a router.Completion: the request, the response with its tool
+// calls and usage, the cost, and the timing. Load reads them back, and
+// Examples and WriteOpenAI turn the successful ones into training data:
+//
+//	f, _ = os.Open("transcripts.jsonl")
+//	completions, _ := transcript.Load(f)
+//	id, err := r.FineTune().UploadTrainingFile(ctx, types.ProviderOpenAI, transcript.Examples(completions))
+//
+// To keep transcripts elsewhere, such as in a database, pass
+// router.WithCompletionLog a sink of your own.
+package transcript
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/finetune"
+	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Recorder writes completions as JSON lines. Its Record method is a
+// router.CompletionSink.
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder creates a recorder writing to w.
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Record writes c.
+func (r *Recorder) Record(c router.Completion) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = r.enc.Encode(c)
+	}
+}
+
+// Err returns the first write error.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Load reads JSON lines of completions written by a Recorder.
+func Load(r io.Reader) ([]router.Completion, error) {
+	var completions []router.Completion
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var c router.Completion
+		if err := json.Unmarshal(scanner.Bytes(), &c); err != nil {
+			return nil, fmt.Errorf("transcript: line %d: %w", line, err)
+		}
+		completions = append(completions, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("transcript: %w", err)
+	}
+	return completions, nil
+}
+
+// Conversation returns the messages of c's request followed by the
+// response as an assistant message, or nil if the call failed.
+func Conversation(c router.Completion) []types.Message {
+	if c.Request == nil || c.Response == nil || c.Error != "" {
+		return nil
+	}
+	messages := append([]types.Message(nil), c.Request.Messages...)
+	return append(messages, types.Message{Role: types.RoleAssistant, Content: c.Response.Content})
+}
+
+// Examples returns the conversations of the successful completions as
+// fine-tuning examples, ending with the model's response.
+func Examples(completions []router.Completion) []finetune.Example {
+	var examples []finetune.Example
+	for _, c := range completions {
+		if messages := Conversation(c); messages != nil {
+			examples = append(examples, finetune.Example{Messages: messages})
+		}
+	}
+	return examples
+}
+
+// openAIExample is a line of an OpenAI chat fine-tuning file.
+type openAIExample struct {
+	Messages []openai.ChatMessage `json:"messages"`
+	Tools    []openai.Tool        `json:"tools,omitempty"`
+}
+
+// WriteOpenAI writes the successful completions as an OpenAI chat
+// fine-tuning file, one conversation per line with the request's tools. The
+// format is also read by most open-source fine-tuning tools.
+func WriteOpenAI(w io.Writer, completions []router.Completion) error {
+	transformer := openai.NewTransformer()
+	enc := json.NewEncoder(w)
+	for _, c := range completions {
+		messages := Conversation(c)
+		if messages == nil {
+			continue
+		}
+		req := transformer.TransformRequest(&types.CompletionRequest{Messages: messages, Tools: c.Request.Tools})
+		if err := enc.Encode(openAIExample{Messages: req.Messages, Tools: req.Tools}); err != nil {
+			return fmt.Errorf("transcript: %w", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package transcript
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	router "github.com/Chloe199719/agent-router"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+var weatherTool = types.Tool{
+	Name:       "get_weather",
+	Parameters: types.JSONSchema{Type: "object", Properties: map[string]types.JSONSchema{"city": {Type: "string"}}},
+}
+
+func completions() []router.Completion {
+	return []router.Completion{
+		{
+			RequestID: "req_1",
+			Request: &types.CompletionRequest{
+				Model: "gpt-4o",
+				Messages: []types.Message{
+					types.NewTextMessage(types.RoleSystem, "Be brief."),
+					types.NewTextMessage(types.RoleUser, "Weather in Paris?"),
+				},
+				Tools: []types.Tool{weatherTool},
+			},
+			Response: &types.CompletionResponse{
+				Provider: types.ProviderOpenAI,
+				Model:    "gpt-4o",
+				Content: []types.ContentBlock{{
+					Type: types.ContentTypeToolUse, ToolUseID: "call_1", ToolName: "get_weather", ToolInput: map[string]any{"city": "Paris"},
+				}},
+				ToolCalls: []types.ToolCall{{ID: "call_1", Name: "get_weather", Input: map[string]any{"city": "Paris"}}},
+				Usage:     types.Usage{InputTokens: 20, OutputTokens: 10, TotalTokens: 30},
+			},
+			Cost:     0.0002,
+			Start:    time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC),
+			Duration: 800 * time.Millisecond,
+		},
+		{
+			RequestID: "req_2",
+			Request:   &types.CompletionRequest{Model: "gpt-4o", Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")}},
+			Error:     "rate limited",
+		},
+	}
+}
+
+func TestRecorderRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	rec := NewRecorder(&buf)
+	for _, c := range completions() {
+		rec.Record(c)
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := Load(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded) != 2 {
+		t.Fatalf("loaded %d completions, want 2", len(loaded))
+	}
+	first := loaded[0]
+	if first.RequestID != "req_1" || first.Duration != 800*time.Millisecond || first.Cost != 0.0002 || first.Response.Usage.TotalTokens != 30 {
+		t.Errorf("first = %+v", first)
+	}
+	if len(first.Response.ToolCalls) != 1 || first.Response.ToolCalls[0].ID != "call_1" {
+		t.Errorf("tool calls = %+v", first.Response.ToolCalls)
+	}
+	if loaded[1].Error != "rate limited" || loaded[1].Response != nil {
+		t.Errorf("second = %+v", loaded[1])
+	}
+
+	if _, err := Load(strings.NewReader("{}\nnot json\n")); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("err = %v, want the bad line", err)
+	}
+}
+
+func TestExamples(t *testing.T) {
+	examples := Examples(completions())
+	if len(examples) != 1 {
+		t.Fatalf("got %d examples, want only the successful completion", len(examples))
+	}
+	messages := examples[0].Messages
+	if len(messages) != 3 || messages[2].Role != types.RoleAssistant || messages[2].Content[0].ToolUseID != "call_1" {
+		t.Errorf("messages = %+v, want the request followed by the response", messages)
+	}
+}
+
+func TestWriteOpenAI(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteOpenAI(&buf, completions()); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("got %d lines, want 1:\n%s", len(lines), buf.String())
+	}
+
+	var example struct {
+		Messages []struct {
+			Role      string `json:"role"`
+			ToolCalls []struct {
+				ID       string `json:"id"`
+				Function struct {
+					Name      string `json:"name"`
+					Arguments string `json:"arguments"`
+				} `json:"function"`
+			} `json:"tool_calls"`
+		} `json:"messages"`
+		Tools []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+	}
+	if err := json.Unmarshal([]byte(lines[0]), &example); err != nil {
+		t.Fatal(err)
+	}
+	roles := []string{}
+	for _, m := range example.Messages {
+		roles = append(roles, m.Role)
+	}
+	if strings.Join(roles, ",") != "system,user,assistant" {
+		t.Errorf("roles = %v", roles)
+	}
+	call := example.Messages[2].ToolCalls
+	if len(call) != 1 || call[0].ID != "call_1" || call[0].Function.Arguments != `{"city":"Paris"}` {
+		t.Errorf("tool calls = %+v", call)
+	}
+	if len(example.Tools) != 1 || example.Tools[0].Function.Name != "get_weather" {
+		t.Errorf("tools = %+v", example.Tools)
+	}
+}
@@ -7,10 +7,13 @@ import "encoding/json"
 type Provider string
 
 const (
-	ProviderOpenAI    Provider = "openai"
-	ProviderAnthropic Provider = "anthropic"
-	ProviderGoogle    Provider = "google"
-	ProviderVertex    Provider = "vertex"
+	ProviderOpenAI     Provider = "openai"
+	ProviderAnthropic  Provider = "anthropic"
+	ProviderGoogle     Provider = "google"
+	ProviderVertex     Provider = "vertex"
+	ProviderDeepSeek   Provider = "deepseek"
+	ProviderCohere     Provider = "cohere"
+	ProviderOpenRouter Provider = "openrouter"
 )
 
 // Role represents message roles in a conversation.
@@ -31,13 +34,16 @@ const (
 	ContentTypeImage      ContentType = "image"
 	ContentTypeToolUse    ContentType = "tool_use"
 	ContentTypeToolResult ContentType = "tool_result"
+	ContentTypeCitation   ContentType = "citation"
+	ContentTypeThinking   ContentType = "thinking"
+	ContentTypeAudio      ContentType = "audio"
 )
 
 // ContentBlock represents a piece of content (text, image, tool use, etc.).
 type ContentBlock struct {
 	Type ContentType `json:"type"`
 
-	// For text content
+	// For text and thinking content
 	Text string `json:"text,omitempty"`
 
 	// For image content
@@ -53,6 +59,65 @@ type ContentBlock struct {
 	// For tool result (user providing tool output)
 	ToolResultID string `json:"tool_result_id,omitempty"`
 	IsError      bool   `json:"is_error,omitempty"`
+
+	// Structured tool result content (text and image blocks). Text holds the
+	// joined text for providers that only accept text results.
+	ToolResultContent []ContentBlock `json:"tool_result_content,omitempty"`
+
+	// For audio content (in responses to requests for audio output). Text
+	// holds the transcript. AudioID refers to the audio kept by the
+	// provider, so a later turn can include it without resending the data
+	// (OpenAI).
+	AudioID     string `json:"audio_id,omitempty"`
+	AudioBase64 string `json:"audio_base64,omitempty"`
+	AudioFormat string `json:"audio_format,omitempty"` // e.g., "wav", "mp3", "pcm16"
+
+	// For citation content (in responses, after the text it supports)
+	Citation *Citation `json:"citation,omitempty"`
+
+	// Prompt caching breakpoint. Anthropic caches the prompt up to and
+	// including a system block that sets it; other providers cache
+	// automatically and ignore it.
+	CacheControl *CacheControl `json:"cache_control,omitempty"`
+}
+
+// Citation is a source the model cited for part of its response: an
+// Anthropic citation, an OpenAI annotation, or a Gemini grounding chunk.
+type Citation struct {
+	// Provider's citation type, e.g. "char_location", "url_citation",
+	// "web_search_result_location", or "grounding".
+	Type string `json:"type,omitempty"`
+
+	// Source
+	URL           string `json:"url,omitempty"`
+	Title         string `json:"title,omitempty"`
+	DocumentIndex int    `json:"document_index,omitempty"` // request document, for document citations
+	CitedText     string `json:"cited_text,omitempty"`     // text quoted from the source
+
+	// Response text the citation supports, and its byte offsets in the
+	// response's Text(). Offsets are zero when the provider gives no span.
+	Text       string `json:"text,omitempty"`
+	StartIndex int    `json:"start_index,omitempty"`
+	EndIndex   int    `json:"end_index,omitempty"`
+}
+
+// Document is a source the model can ground its answer in and cite.
+// Citations of it have type "document" and its index in DocumentIndex.
+type Document struct {
+	// ID identifies the document in citations. Empty uses "doc_" and the
+	// document's index.
+	ID string `json:"id,omitempty"`
+
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+
+	// Fields are further fields the model may use, such as a URL or date.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// CacheControl marks a prompt caching breakpoint.
+type CacheControl struct {
+	TTL string `json:"ttl,omitempty"` // "5m" or "1h"; provider default if empty
 }
 
 // Message represents a conversation message.
@@ -71,6 +136,17 @@ func NewTextMessage(role Role, text string) Message {
 	}
 }
 
+// NewCachedSystemMessage creates a system message that is cached by
+// providers with explicit prompt caching.
+func NewCachedSystemMessage(text string) Message {
+	return Message{
+		Role: RoleSystem,
+		Content: []ContentBlock{
+			{Type: ContentTypeText, Text: text, CacheControl: &CacheControl{}},
+		},
+	}
+}
+
 // NewToolResultMessage creates a tool result message.
 func NewToolResultMessage(toolUseID string, result string, isError bool) Message {
 	return Message{
@@ -86,6 +162,23 @@ func NewToolResultMessage(toolUseID string, result string, isError bool) Message
 	}
 }
 
+// NewToolResultContentMessage creates a tool result message with structured
+// content, such as a screenshot with a caption.
+func NewToolResultContentMessage(toolUseID string, content []ContentBlock, isError bool) Message {
+	var text string
+	for _, block := range content {
+		if block.Type == ContentTypeText {
+			if text != "" {
+				text += "\n"
+			}
+			text += block.Text
+		}
+	}
+	msg := NewToolResultMessage(toolUseID, text, isError)
+	msg.Content[0].ToolResultContent = content
+	return msg
+}
+
 // Tool represents a function/tool that the model can use.
 type Tool struct {
 	Name        string     `json:"name"`
@@ -127,14 +220,126 @@ type JSONSchema struct {
 	Defs                 map[string]JSONSchema `json:"$defs,omitempty"`
 }
 
-// ToMap converts JSONSchema to a map for JSON marshaling.
+// ToMap converts JSONSchema to a map for JSON marshaling. The map is built
+// directly rather than through a JSON round trip, as ToMap runs for every
+// tool of every request, but holds the same values a round trip would:
+// numbers are float64, lists are []any, and nested schemas are maps. The
+// map is new on every call, so callers may modify it.
 func (s JSONSchema) ToMap() map[string]any {
-	data, _ := json.Marshal(s)
-	var m map[string]any
-	json.Unmarshal(data, &m)
+	m := make(map[string]any, 4)
+	if s.Type != "" {
+		m["type"] = s.Type
+	}
+	if s.Description != "" {
+		m["description"] = s.Description
+	}
+	if len(s.Properties) > 0 {
+		props := make(map[string]any, len(s.Properties))
+		for name, prop := range s.Properties {
+			props[name] = prop.ToMap()
+		}
+		m["properties"] = props
+	}
+	if s.Items != nil {
+		m["items"] = s.Items.ToMap()
+	}
+	if len(s.Required) > 0 {
+		required := make([]any, len(s.Required))
+		for i, name := range s.Required {
+			required[i] = name
+		}
+		m["required"] = required
+	}
+	if len(s.Enum) > 0 {
+		m["enum"] = jsonValue(s.Enum)
+	}
+	if s.Const != nil {
+		m["const"] = jsonValue(s.Const)
+	}
+	if s.AdditionalProperties != nil {
+		m["additionalProperties"] = *s.AdditionalProperties
+	}
+	setNumber(m, "minItems", s.MinItems)
+	setNumber(m, "maxItems", s.MaxItems)
+	setNumber(m, "minimum", s.Minimum)
+	setNumber(m, "maximum", s.Maximum)
+	setNumber(m, "minLength", s.MinLength)
+	setNumber(m, "maxLength", s.MaxLength)
+	if s.Pattern != "" {
+		m["pattern"] = s.Pattern
+	}
+	if s.Format != "" {
+		m["format"] = s.Format
+	}
+	if s.Default != nil {
+		m["default"] = jsonValue(s.Default)
+	}
+	setSchemas(m, "anyOf", s.AnyOf)
+	setSchemas(m, "oneOf", s.OneOf)
+	setSchemas(m, "allOf", s.AllOf)
+	if s.Ref != "" {
+		m["$ref"] = s.Ref
+	}
+	if len(s.Defs) > 0 {
+		defs := make(map[string]any, len(s.Defs))
+		for name, def := range s.Defs {
+			defs[name] = def.ToMap()
+		}
+		m["$defs"] = defs
+	}
 	return m
 }
 
+// setNumber sets m[key] to *n as a float64 if n is set.
+func setNumber[T int | float64](m map[string]any, key string, n *T) {
+	if n != nil {
+		m[key] = float64(*n)
+	}
+}
+
+// setSchemas sets m[key] to the maps of schemas if there are any.
+func setSchemas(m map[string]any, key string, schemas []JSONSchema) {
+	if len(schemas) == 0 {
+		return
+	}
+	list := make([]any, len(schemas))
+	for i, schema := range schemas {
+		list[i] = schema.ToMap()
+	}
+	m[key] = list
+}
+
+// jsonValue returns v as it decodes from JSON. Strings, booleans, float64s,
+// and nil are returned as is; lists and objects of them are copied; other
+// values take a JSON round trip.
+func jsonValue(v any) any {
+	switch v := v.(type) {
+	case nil, string, bool, float64:
+		return v
+	case int:
+		return float64(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = jsonValue(item)
+		}
+		return list
+	case map[string]any:
+		obj := make(map[string]any, len(v))
+		for k, item := range v {
+			obj[k] = jsonValue(item)
+		}
+		return obj
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil
+	}
+	var decoded any
+	json.Unmarshal(data, &decoded)
+	return decoded
+}
+
 // StopReason represents why generation stopped.
 type StopReason string
 
@@ -144,6 +349,11 @@ const (
 	StopReasonToolUse       StopReason = "tool_use"
 	StopReasonStopSequence  StopReason = "stop_sequence"
 	StopReasonContentFilter StopReason = "content_filter"
+
+	// StopReasonRefusal means the model declined to answer: an OpenAI
+	// refusal or an Anthropic refusal stop. CompletionResponse.Refusal
+	// holds the model's explanation when the provider gives one.
+	StopReasonRefusal StopReason = "refusal"
 )
 
 // Usage represents token usage information.
@@ -155,6 +365,30 @@ type Usage struct {
 	// Provider-specific details (optional)
 	CachedTokens    int `json:"cached_tokens,omitempty"`
 	ReasoningTokens int `json:"reasoning_tokens,omitempty"`
+
+	// AcceptedPredictionTokens and RejectedPredictionTokens count the tokens
+	// of CompletionRequest.Prediction that appeared in the output and that
+	// did not. Rejected tokens are still billed as output.
+	AcceptedPredictionTokens int `json:"accepted_prediction_tokens,omitempty"`
+	RejectedPredictionTokens int `json:"rejected_prediction_tokens,omitempty"`
+
+	// Cost is the price of the request in USD as billed by the provider,
+	// for providers that report it (OpenRouter). Cost accounting uses it in
+	// place of catalog list prices.
+	Cost float64 `json:"cost,omitempty"`
+}
+
+// Add adds the token counts and cost of other to u, for totalling the
+// usage of several requests.
+func (u *Usage) Add(other Usage) {
+	u.InputTokens += other.InputTokens
+	u.OutputTokens += other.OutputTokens
+	u.TotalTokens += other.TotalTokens
+	u.CachedTokens += other.CachedTokens
+	u.ReasoningTokens += other.ReasoningTokens
+	u.AcceptedPredictionTokens += other.AcceptedPredictionTokens
+	u.RejectedPredictionTokens += other.RejectedPredictionTokens
+	u.Cost += other.Cost
 }
 
 // Feature represents provider capabilities.
@@ -167,4 +401,12 @@ const (
 	FeatureVision           Feature = "vision"
 	FeatureBatch            Feature = "batch"
 	FeatureJSON             Feature = "json_mode"
+	FeatureMCP              Feature = "mcp"            // Provider calls remote MCP servers itself
+	FeatureDocuments        Feature = "documents"      // Grounding documents (CompletionRequest.Documents)
+	FeatureEmbeddings       Feature = "embeddings"     // Embedding texts (provider.Embedder)
+	FeatureTokenCounting    Feature = "token_counting" // Provider-accurate token counts (provider.TokenCounter)
+	FeatureSeed             Feature = "seed"           // Seeded sampling (CompletionRequest.Seed)
+	FeatureAudioOutput      Feature = "audio_output"   // Spoken responses (CompletionRequest.Audio)
+	FeatureCandidates       Feature = "candidates"     // Several responses per call (CompletionRequest.N)
+	FeaturePrefill          Feature = "prefill"        // Continuing a trailing assistant message
 )
@@ -0,0 +1,79 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// roundTrip converts s to a map through JSON, as ToMap once did.
+func roundTrip(s JSONSchema) map[string]any {
+	data, _ := json.Marshal(s)
+	var m map[string]any
+	json.Unmarshal(data, &m)
+	return m
+}
+
+// testSchema uses every JSONSchema field.
+var testSchema = func() JSONSchema {
+	no := false
+	one, ten := 1, 10
+	low, high := 0.5, 99.5
+	return JSONSchema{
+		Type:        "object",
+		Description: "An order",
+		Properties: map[string]JSONSchema{
+			"id":     {Type: "string", Pattern: "^[a-z]+$", Format: "uuid", MinLength: &one, MaxLength: &ten},
+			"status": {Type: "string", Enum: []any{"open", "closed", 3, nil}, Default: "open"},
+			"total":  {Type: "number", Minimum: &low, Maximum: &high, Const: 42},
+			"lines": {Type: "array", MinItems: &one, MaxItems: &ten, Items: &JSONSchema{
+				AnyOf: []JSONSchema{{Ref: "#/$defs/line"}, {Type: "null"}},
+			}},
+			"meta":  {Type: "object", Default: map[string]any{"tags": []any{"a", 1}}, Const: struct{ A int }{1}},
+			"empty": {Properties: map[string]JSONSchema{}, Required: []string{}},
+		},
+		Required:             []string{"id", "status"},
+		AdditionalProperties: &no,
+		OneOf:                []JSONSchema{{Required: []string{"id"}}},
+		AllOf:                []JSONSchema{{Type: "object"}},
+		Defs: map[string]JSONSchema{
+			"line": {Type: "object", Properties: map[string]JSONSchema{"sku": {Type: "string"}}},
+		},
+	}
+}()
+
+func TestJSONSchemaToMap_MatchesJSON(t *testing.T) {
+	got, want := testSchema.ToMap(), roundTrip(testSchema)
+	if !reflect.DeepEqual(got, want) {
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		t.Errorf("ToMap() =\n%s\nwant\n%s", gotJSON, wantJSON)
+	}
+
+	if m := (JSONSchema{}).ToMap(); len(m) != 0 {
+		t.Errorf("empty schema = %v", m)
+	}
+}
+
+func BenchmarkJSONSchemaToMap(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		testSchema.ToMap()
+	}
+}
+
+func BenchmarkJSONSchemaRoundTrip(b *testing.B) {
+	b.ReportAllocs()
+	for b.Loop() {
+		roundTrip(testSchema)
+	}
+}
+
+func TestUsageAdd(t *testing.T) {
+	total := Usage{InputTokens: 1, OutputTokens: 2, TotalTokens: 3, Cost: 0.5}
+	total.Add(Usage{InputTokens: 1, OutputTokens: 1, TotalTokens: 2, CachedTokens: 1, ReasoningTokens: 1, AcceptedPredictionTokens: 2, RejectedPredictionTokens: 3, Cost: 0.25})
+	want := Usage{InputTokens: 2, OutputTokens: 3, TotalTokens: 5, CachedTokens: 1, ReasoningTokens: 1, AcceptedPredictionTokens: 2, RejectedPredictionTokens: 3, Cost: 0.75}
+	if total != want {
+		t.Errorf("total = %+v, want %+v", total, want)
+	}
+}
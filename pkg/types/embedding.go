@@ -0,0 +1,31 @@
+package types
+
+// EmbeddingRequest is a request to embed texts as vectors.
+type EmbeddingRequest struct {
+	// Provider to use
+	Provider Provider `json:"provider"`
+
+	// Model to use (e.g. "text-embedding-3-small")
+	Model string `json:"model"`
+
+	// Input holds the texts to embed.
+	Input []string `json:"input"`
+
+	// Dimensions shortens the vectors, for models that support it.
+	Dimensions *int `json:"dimensions,omitempty"`
+}
+
+// EmbeddingResponse holds the vectors of an embedding request.
+type EmbeddingResponse struct {
+	// Provider that generated the embeddings
+	Provider Provider `json:"provider"`
+
+	// Model that generated the embeddings
+	Model string `json:"model"`
+
+	// Embeddings holds one vector per input, in input order.
+	Embeddings [][]float64 `json:"embeddings"`
+
+	// Token usage information
+	Usage Usage `json:"usage"`
+}
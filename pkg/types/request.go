@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 // CompletionRequest is the unified request format for all providers.
 type CompletionRequest struct {
 	// Provider to use for this request
@@ -18,6 +20,36 @@ type CompletionRequest struct {
 	TopK          *int     `json:"top_k,omitempty"` // Anthropic/Google only
 	StopSequences []string `json:"stop_sequences,omitempty"`
 
+	// Seed makes sampling repeatable on providers with FeatureSeed: the
+	// same request with the same seed returns the same output as far as
+	// the provider can guarantee it.
+	Seed *int `json:"seed,omitempty"`
+
+	// N asks providers with FeatureCandidates for N responses to the
+	// prompt in one call, sampled independently; the first is the response
+	// and the others are in CompletionResponse.Alternatives. Zero or one
+	// asks for one. Stream does not support it.
+	N int `json:"n,omitempty"`
+
+	// Reproducible asks for output that is as repeatable as the provider
+	// allows: the router sends temperature 0 unless Temperature is set, and
+	// a seed (router.DefaultSeed unless Seed is set) to providers with
+	// FeatureSeed. Providers without it are sent the request unseeded, with
+	// a logged warning. The response's ProviderMetadata records the seed
+	// and, where reported, the backend that served it.
+	Reproducible bool `json:"reproducible,omitempty"`
+
+	// IncludeStopSequence appends the matched stop sequence to the response
+	// text of Complete calls. Providers leave it out of the text; it can only
+	// be restored when the provider reports it (see
+	// CompletionResponse.StopSequence).
+	IncludeStopSequence bool `json:"include_stop_sequence,omitempty"`
+
+	// IncludeTokenBreakdown sets CompletionResponse.TokenBreakdown, which
+	// splits the input tokens between the system prompt, the conversation,
+	// the tools, and the documents.
+	IncludeTokenBreakdown bool `json:"include_token_breakdown,omitempty"`
+
 	// Structured output configuration
 	ResponseFormat *ResponseFormat `json:"response_format,omitempty"`
 
@@ -25,15 +57,66 @@ type CompletionRequest struct {
 	Tools      []Tool      `json:"tools,omitempty"`
 	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
 
+	// Documents are sources the model grounds its answer in, for
+	// retrieval-augmented generation. Providers with FeatureDocuments cite
+	// them in the response's citations.
+	Documents []Document `json:"documents,omitempty"`
+
+	// MCPServers are remote MCP servers whose tools the model may call.
+	// Providers with FeatureMCP call them directly; for other providers the
+	// router lists the servers' tools and executes the calls itself.
+	MCPServers []MCPServer `json:"mcp_servers,omitempty"`
+
+	// Prediction is content the response is expected to largely repeat,
+	// such as a file being rewritten with small edits. OpenAI uses it as a
+	// predicted output to generate the unchanged parts faster; other
+	// providers ignore it. Usage reports how many predicted tokens were
+	// accepted and rejected.
+	Prediction string `json:"prediction,omitempty"`
+
+	// ServiceTier selects the provider's processing tier, trading price
+	// against latency and availability. Empty leaves it to the provider's
+	// default. The tier that served the request is reported in
+	// CompletionResponse.ProviderMetadata.ServiceTier.
+	ServiceTier ServiceTier `json:"service_tier,omitempty"`
+
+	// Audio asks for a spoken response as well as text, from models with
+	// audio output such as OpenAI's gpt-4o-audio-preview. The response
+	// holds an audio block with the audio and its transcript; streams send
+	// it in audio delta events.
+	Audio *AudioConfig `json:"audio,omitempty"`
+
 	// Streaming
 	Stream bool `json:"stream,omitempty"`
 
+	// Timeout bounds the whole call, overriding the client-wide timeout when shorter.
+	// For streams it covers the time until the last event. Zero means no per-request limit.
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// StreamIdleTimeout aborts a stream with a timeout error when no event arrives
+	// for this long. Zero uses the router default (see router.WithStreamIdleTimeout).
+	StreamIdleTimeout time.Duration `json:"stream_idle_timeout,omitempty"`
+
 	// Metadata is optional string key-value data sent to providers that support it:
-	// Vertex AI Gemini as request labels; OpenAI as chat completion metadata;
-	// Anthropic only forwards the "user_id" key to metadata.user_id.
+	// Vertex AI Gemini as request labels; OpenAI as chat completion metadata,
+	// with the "user_id" key also sent as user; Anthropic only forwards the
+	// "user_id" key to metadata.user_id.
 	// The Google Generative Language API (AI Studio) does not accept labels; Metadata is ignored there.
+	// The router's completion log records it, and router.WithUsageTags
+	// aggregates usage by its values.
 	Metadata map[string]string `json:"metadata,omitempty"`
 
+	// TenantID identifies the customer a request is made for. The router
+	// aggregates usage, applies rate limits, and selects API keys per tenant
+	// (see router.WithTenant); it is not sent to providers.
+	TenantID string `json:"tenant_id,omitempty"`
+
+	// RequestID correlates the request across systems. The router generates
+	// one if it is empty, returns it on the response and on errors, and sends
+	// it to providers that accept a client request ID (OpenAI's
+	// X-Client-Request-Id header).
+	RequestID string `json:"request_id,omitempty"`
+
 	// Thinking requests extended reasoning where the provider and model support it.
 	// See ThinkingConfig for which fields apply to each provider; the router validates
 	// model support and required field combinations before calling the provider.
@@ -43,6 +126,34 @@ type CompletionRequest struct {
 	Extra map[string]any `json:"extra,omitempty"`
 }
 
+// AudioConfig configures the audio of a spoken response.
+type AudioConfig struct {
+	// Voice is the provider's voice name, e.g. "alloy". Empty uses the
+	// provider default.
+	Voice string `json:"voice,omitempty"`
+
+	// Format is the audio encoding, e.g. "wav", "mp3", "flac", "opus", or
+	// "pcm16". Empty uses "wav", or "pcm16" for streams, the only format
+	// OpenAI streams.
+	Format string `json:"format,omitempty"`
+}
+
+// MCPServer is a remote MCP server reachable over streamable HTTP.
+type MCPServer struct {
+	// Name identifies the server in tool calls and errors.
+	Name string `json:"name"`
+
+	// URL of the server's MCP endpoint.
+	URL string `json:"url"`
+
+	// AuthorizationToken is sent as a bearer token, if set.
+	AuthorizationToken string `json:"authorization_token,omitempty"`
+
+	// AllowedTools restricts which of the server's tools the model may use.
+	// Empty allows all of them.
+	AllowedTools []string `json:"allowed_tools,omitempty"`
+}
+
 // ThinkingConfig is a unified thinking / reasoning request.
 // Fields are mapped per provider as follows:
 //   - Budget: Anthropic messages API thinking.budget_tokens (type "enabled"); Gemini 2.5+ thinkingBudget.
@@ -82,6 +193,26 @@ type ResponseFormat struct {
 	Strict *bool `json:"strict,omitempty"`
 }
 
+// ServiceTier is a processing tier. Providers map the tiers as follows:
+//   - OpenAI: service_tier "auto", "default", "flex", or "priority".
+//   - Anthropic: service_tier "auto" for ServiceTierAuto and
+//     ServiceTierPriority, which uses Priority Tier capacity when the
+//     organization has it, and "standard_only" for ServiceTierStandard.
+//     Anthropic has no flex tier; ServiceTierFlex is not sent. Claude on
+//     Vertex AI and Bedrock ignores the tier.
+//   - Google and others: ignored.
+//
+// For the half-price asynchronous tier, submit the requests through the
+// batch API (see router.Router.Batch) instead.
+type ServiceTier string
+
+const (
+	ServiceTierAuto     ServiceTier = "auto"     // Provider's choice, using priority capacity where available
+	ServiceTierStandard ServiceTier = "standard" // Standard capacity only
+	ServiceTierFlex     ServiceTier = "flex"     // Cheaper, slower, and may be unavailable (OpenAI)
+	ServiceTierPriority ServiceTier = "priority" // Faster and more reliable at a premium
+)
+
 // ToolChoiceType represents how the model should use tools.
 type ToolChoiceType string
 
@@ -100,7 +231,11 @@ type ToolChoice struct {
 	// Name of specific tool (when Type is "tool")
 	Name string `json:"name,omitempty"`
 
-	// DisableParallelToolUse prevents multiple tool calls in one response (Anthropic)
+	// DisableParallelToolUse limits the model to one tool call per response,
+	// so tools are called one after another. It is sent as Anthropic's
+	// disable_parallel_tool_use and as OpenAI's and OpenRouter's
+	// parallel_tool_calls: false. Google, Cohere, and DeepSeek have no such
+	// control and may still return several calls in one response.
 	DisableParallelToolUse bool `json:"disable_parallel_tool_use,omitempty"`
 }
 
@@ -1,12 +1,22 @@
 package types
 
-import "time"
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+)
 
 // CompletionResponse is the unified response format from all providers.
 type CompletionResponse struct {
 	// Unique identifier for this completion
 	ID string `json:"id"`
 
+	// RequestID is the router's ID for the request; see
+	// CompletionRequest.RequestID. The provider's own request ID is in
+	// ProviderMetadata.
+	RequestID string `json:"request_id,omitempty"`
+
 	// Provider that generated this response
 	Provider Provider `json:"provider"`
 
@@ -19,17 +29,141 @@ type CompletionResponse struct {
 	// Why generation stopped
 	StopReason StopReason `json:"stop_reason"`
 
+	// Refusal is the model's explanation for declining to answer, from
+	// OpenAI's refusal field. Branch on StopReasonRefusal rather than on
+	// this field: Anthropic refusals come without an explanation.
+	Refusal string `json:"refusal,omitempty"`
+
+	// StopSequence is the stop sequence that ended generation, for providers
+	// that report it (Anthropic). OpenAI and Google do not say which matched.
+	StopSequence string `json:"stop_sequence,omitempty"`
+
 	// Token usage information
 	Usage Usage `json:"usage"`
 
+	// Alternatives are the other responses of a request with N > 1, each
+	// with its own content, tool calls, and stop reason. Usage covers them
+	// all and is only set on the response itself.
+	Alternatives []CompletionResponse `json:"alternatives,omitempty"`
+
 	// Tool calls made by the model (convenience accessor, also in Content)
 	ToolCalls []ToolCall `json:"tool_calls,omitempty"`
 
+	// Citations of sources (convenience accessor, also in Content)
+	Citations []Citation `json:"citations,omitempty"`
+
 	// Timestamp when response was created
 	CreatedAt time.Time `json:"created_at,omitempty"`
 
 	// Provider-specific metadata
 	Metadata map[string]any `json:"metadata,omitempty"`
+
+	// ProviderMetadata identifies the response on the provider's side
+	ProviderMetadata ProviderMetadata `json:"provider_metadata,omitzero"`
+
+	// Raw provider response body, set when the router captures raw traffic
+	Raw json.RawMessage `json:"raw,omitempty"`
+
+	// StreamStats describes how a streamed response arrived, set on the
+	// router's Stream responses
+	StreamStats *StreamStats `json:"stream_stats,omitempty"`
+
+	// TokenBreakdown splits the input tokens by part of the prompt, set
+	// when the request asks for it
+	TokenBreakdown *TokenBreakdown `json:"token_breakdown,omitempty"`
+
+	// Compression reports what prompt compression saved, set when the
+	// router compresses prompts
+	Compression *CompressionStats `json:"compression,omitempty"`
+}
+
+// CompressionStats reports what prompt compression saved on a request. Tokens
+// are estimated from the text of the messages at four characters per token.
+type CompressionStats struct {
+	// OriginalTokens is the tokens of the messages before compression.
+	OriginalTokens int `json:"original_tokens"`
+
+	// CompressedTokens is the tokens of the messages that were sent.
+	CompressedTokens int `json:"compressed_tokens"`
+
+	// SavedTokens is OriginalTokens less CompressedTokens.
+	SavedTokens int `json:"saved_tokens"`
+
+	// Stages holds the tokens saved by each compression stage, by name.
+	Stages map[string]int `json:"stages,omitempty"`
+
+	// Usage is what the compression stages' own requests used, such as an
+	// LLM rewriting long passages. It is not included in the response's
+	// Usage.
+	Usage Usage `json:"usage,omitzero"`
+}
+
+// TokenBreakdown splits a request's input tokens by part of the prompt, to
+// show what fills the context window. The parts are estimated from their
+// size and, when the provider reports the exact total, scaled to sum to it.
+type TokenBreakdown struct {
+	// Total is the input tokens of the whole request.
+	Total int `json:"total"`
+
+	// System is the tokens of the system messages.
+	System int `json:"system"`
+
+	// History is the tokens of the other messages.
+	History int `json:"history"`
+
+	// Tools is the tokens of the tool definitions.
+	Tools int `json:"tools"`
+
+	// Documents is the tokens of the grounding documents.
+	Documents int `json:"documents"`
+
+	// Messages holds the tokens of each request message, in order.
+	Messages []int `json:"messages"`
+
+	// Estimated reports whether Total is an estimate rather than the
+	// provider's count.
+	Estimated bool `json:"estimated"`
+}
+
+// ProviderMetadata identifies a response on the provider's side, for
+// debugging and audits without capturing raw traffic. Fields the provider
+// does not report are empty.
+type ProviderMetadata struct {
+	// RequestID is the provider's ID for the HTTP request, from the
+	// request-id (Anthropic) or x-request-id (OpenAI and others) header.
+	// Quote it in support requests.
+	RequestID string `json:"request_id,omitempty"`
+
+	// SystemFingerprint identifies the backend configuration that served
+	// the request (OpenAI, DeepSeek). A change explains a change in
+	// outputs for the same seed.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// ModelVersion is the exact model version that served the request
+	// (Gemini).
+	ModelVersion string `json:"model_version,omitempty"`
+
+	// ServiceTier is the tier the request was processed in (OpenAI,
+	// Anthropic).
+	ServiceTier string `json:"service_tier,omitempty"`
+
+	// Seed is the sampling seed the router sent for a reproducible
+	// request, to repeat it with.
+	Seed *int `json:"seed,omitempty"`
+}
+
+// StreamStats are timings of a streamed response.
+type StreamStats struct {
+	// TimeToFirstToken is the time from sending the request to the first
+	// content, thinking, audio, or tool call delta.
+	TimeToFirstToken time.Duration `json:"time_to_first_token"`
+
+	// Duration is the time from sending the request to the end of the stream.
+	Duration time.Duration `json:"duration"`
+
+	// Deltas is the number of content, thinking, audio, and tool call delta
+	// events.
+	Deltas int `json:"deltas"`
 }
 
 // Text returns the concatenated text content from the response.
@@ -43,6 +177,47 @@ func (r *CompletionResponse) Text() string {
 	return text
 }
 
+// Thinking returns the concatenated text of thinking blocks: the model's
+// reasoning, where the provider returns it.
+func (r *CompletionResponse) Thinking() string {
+	var text string
+	for _, block := range r.Content {
+		if block.Type == ContentTypeThinking {
+			text += block.Text
+		}
+	}
+	return text
+}
+
+// Audio returns the decoded audio of the response's audio blocks, or nil if
+// it has none.
+func (r *CompletionResponse) Audio() ([]byte, error) {
+	var audio []byte
+	for _, block := range r.Content {
+		if block.Type != ContentTypeAudio || block.AudioBase64 == "" {
+			continue
+		}
+		data, err := base64.StdEncoding.DecodeString(block.AudioBase64)
+		if err != nil {
+			return nil, err
+		}
+		audio = append(audio, data...)
+	}
+	return audio, nil
+}
+
+// Transcript returns the concatenated transcripts of the response's audio
+// blocks.
+func (r *CompletionResponse) Transcript() string {
+	var text string
+	for _, block := range r.Content {
+		if block.Type == ContentTypeAudio {
+			text += block.Text
+		}
+	}
+	return text
+}
+
 // HasToolCalls returns true if the response contains tool calls.
 func (r *CompletionResponse) HasToolCalls() bool {
 	return len(r.ToolCalls) > 0
@@ -54,6 +229,8 @@ type StreamEventType string
 const (
 	StreamEventStart         StreamEventType = "start"           // Stream started
 	StreamEventContentDelta  StreamEventType = "content_delta"   // Text content chunk
+	StreamEventThinkingDelta StreamEventType = "thinking_delta"  // Reasoning text chunk, not part of the answer
+	StreamEventAudioDelta    StreamEventType = "audio_delta"     // Audio chunk and/or its transcript chunk
 	StreamEventToolCallStart StreamEventType = "tool_call_start" // Tool call started
 	StreamEventToolCallDelta StreamEventType = "tool_call_delta" // Tool call input chunk
 	StreamEventToolCallEnd   StreamEventType = "tool_call_end"   // Tool call finished
@@ -66,7 +243,7 @@ type StreamEvent struct {
 	// Type of this event
 	Type StreamEventType `json:"type"`
 
-	// Content delta (for content_delta events)
+	// Content delta (for content_delta, thinking_delta, and audio_delta events)
 	Delta *ContentBlock `json:"delta,omitempty"`
 
 	// Index of the content block being updated
@@ -107,3 +284,9 @@ type StreamReader interface {
 	// Returns nil if called before the stream is complete.
 	Response() *CompletionResponse
 }
+
+// Completer sends completion requests. *router.Router and the provider
+// clients implement it.
+type Completer interface {
+	Complete(ctx context.Context, req *CompletionRequest) (*CompletionResponse, error)
+}
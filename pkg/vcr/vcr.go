@@ -0,0 +1,321 @@
+// Package vcr provides an HTTP transport that records provider traffic to
+// cassette files and replays it later, so tests that talk to real APIs can run
+// deterministically without credentials.
+//
+// Example usage:
+//
+//	rec, err := vcr.New("testdata/cassettes/openai_basic.json", vcr.ModeFromEnv())
+//	if err != nil {
+//		t.Fatal(err)
+//	}
+//	defer rec.Stop()
+//
+//	r, _ := router.New(router.WithOpenAI(key, provider.WithHTTPClient(rec.Client())))
+//
+// Credentials are scrubbed from recorded requests (Authorization, x-api-key,
+// x-goog-api-key, api-key headers and the "key" query parameter), so cassettes
+// are safe to commit.
+package vcr
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Mode controls whether a Recorder talks to the network.
+type Mode string
+
+const (
+	// ModeReplay serves responses from the cassette and fails on unmatched requests.
+	ModeReplay Mode = "replay"
+
+	// ModeRecord sends requests to the network and overwrites the cassette.
+	ModeRecord Mode = "record"
+
+	// ModeAuto replays if the cassette exists and records otherwise.
+	ModeAuto Mode = "auto"
+
+	// ModePassthrough sends requests to the network without recording.
+	ModePassthrough Mode = "passthrough"
+)
+
+// EnvMode is the environment variable read by ModeFromEnv.
+const EnvMode = "AGENT_ROUTER_VCR"
+
+// Redacted replaces scrubbed credential values in cassettes.
+const Redacted = "[REDACTED]"
+
+// ErrCassetteNotFound is returned by New in ModeReplay when the cassette file is missing.
+var ErrCassetteNotFound = errors.New("vcr: cassette not found")
+
+// sensitiveHeaders are removed from recorded requests.
+var sensitiveHeaders = []string{"Authorization", "X-Api-Key", "X-Goog-Api-Key", "Api-Key"}
+
+// sensitiveQuery are query parameters scrubbed from recorded URLs.
+var sensitiveQuery = []string{"key", "api_key"}
+
+// ModeFromEnv returns the mode named by AGENT_ROUTER_VCR, defaulting to
+// ModePassthrough when the variable is unset or unrecognised.
+func ModeFromEnv() Mode {
+	switch m := Mode(strings.ToLower(os.Getenv(EnvMode))); m {
+	case ModeReplay, ModeRecord, ModeAuto:
+		return m
+	default:
+		return ModePassthrough
+	}
+}
+
+// Cassette is the on-disk format of a recording.
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Interaction is a single recorded request/response pair.
+type Interaction struct {
+	Request  RecordedRequest  `json:"request"`
+	Response RecordedResponse `json:"response"`
+}
+
+// RecordedRequest is a scrubbed HTTP request.
+type RecordedRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers,omitempty"`
+	Body    string      `json:"body,omitempty"`
+}
+
+// RecordedResponse is a captured HTTP response.
+type RecordedResponse struct {
+	StatusCode int         `json:"status_code"`
+	Headers    http.Header `json:"headers,omitempty"`
+	Body       string      `json:"body"`
+}
+
+// Recorder is an http.RoundTripper that records or replays interactions.
+// It is safe for concurrent use.
+type Recorder struct {
+	path      string
+	mode      Mode
+	transport http.RoundTripper
+
+	mu       sync.Mutex
+	cassette Cassette
+	used     []bool
+}
+
+// Option configures a Recorder.
+type Option func(*Recorder)
+
+// WithTransport sets the transport used to reach the network when recording.
+// Defaults to http.DefaultTransport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// New creates a recorder backed by the cassette at path.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if r.mode == ModeAuto {
+		if _, err := os.Stat(path); err == nil {
+			r.mode = ModeReplay
+		} else {
+			r.mode = ModeRecord
+		}
+	}
+
+	if r.mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s", ErrCassetteNotFound, path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("vcr: failed to read cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("vcr: failed to parse cassette %s: %w", path, err)
+		}
+		r.used = make([]bool, len(r.cassette.Interactions))
+	}
+
+	return r, nil
+}
+
+// Mode returns the effective mode (ModeAuto is resolved at construction).
+func (r *Recorder) Mode() Mode {
+	return r.mode
+}
+
+// Client returns an HTTP client that uses the recorder as its transport.
+func (r *Recorder) Client() *http.Client {
+	return &http.Client{Transport: r}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	switch r.mode {
+	case ModeReplay:
+		return r.replay(req)
+	case ModeRecord:
+		return r.record(req)
+	default:
+		return r.transport.RoundTrip(req)
+	}
+}
+
+// Stop writes the cassette to disk when recording. It is a no-op in other modes.
+func (r *Recorder) Stop() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return fmt.Errorf("vcr: failed to encode cassette: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return fmt.Errorf("vcr: failed to create cassette directory: %w", err)
+	}
+	if err := os.WriteFile(r.path, data, 0o644); err != nil {
+		return fmt.Errorf("vcr: failed to write cassette: %w", err)
+	}
+	return nil
+}
+
+// record forwards the request and captures the exchange.
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	recorded, err := captureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("vcr: failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request: recorded,
+		Response: RecordedResponse{
+			StatusCode: resp.StatusCode,
+			Headers:    resp.Header.Clone(),
+			Body:       string(body),
+		},
+	})
+	r.mu.Unlock()
+
+	return resp, nil
+}
+
+// replay serves the first unused interaction matching the request.
+func (r *Recorder) replay(req *http.Request) (*http.Response, error) {
+	recorded, err := captureRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, in := range r.cassette.Interactions {
+		if r.used[i] || !matches(in.Request, recorded) {
+			continue
+		}
+		r.used[i] = true
+
+		header := in.Response.Headers.Clone()
+		if header == nil {
+			header = make(http.Header)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", in.Response.StatusCode, http.StatusText(in.Response.StatusCode)),
+			StatusCode:    in.Response.StatusCode,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(strings.NewReader(in.Response.Body)),
+			ContentLength: int64(len(in.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+
+	return nil, fmt.Errorf("vcr: no recorded interaction for %s %s in %s", recorded.Method, recorded.URL, r.path)
+}
+
+// matches reports whether a recorded request corresponds to an incoming one.
+func matches(recorded, incoming RecordedRequest) bool {
+	return recorded.Method == incoming.Method &&
+		recorded.URL == incoming.URL &&
+		recorded.Body == incoming.Body
+}
+
+// captureRequest snapshots and scrubs a request, restoring its body for sending.
+func captureRequest(req *http.Request) (RecordedRequest, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return RecordedRequest{}, fmt.Errorf("vcr: failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	headers := req.Header.Clone()
+	for _, h := range sensitiveHeaders {
+		if headers.Get(h) != "" {
+			headers.Set(h, Redacted)
+		}
+	}
+
+	return RecordedRequest{
+		Method:  req.Method,
+		URL:     scrubURL(req.URL),
+		Headers: headers,
+		Body:    string(body),
+	}, nil
+}
+
+// scrubURL returns the URL with credential query parameters redacted.
+func scrubURL(u *url.URL) string {
+	scrubbed := *u
+	q := scrubbed.Query()
+	for _, k := range sensitiveQuery {
+		if q.Has(k) {
+			q.Set(k, Redacted)
+		}
+	}
+	scrubbed.RawQuery = q.Encode()
+	return scrubbed.String()
+}
@@ -0,0 +1,105 @@
+package vcr
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRecorder_RecordThenReplay(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"echo":` + string(body) + `}`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassettes", "basic.json")
+
+	rec, err := New(path, ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := http.NewRequest("POST", server.URL+"/v1/chat?key=secret-key", strings.NewReader(`{"n":1}`))
+	req.Header.Set("Authorization", "Bearer sk-secret")
+	req.Header.Set("x-api-key", "sk-ant-secret")
+	resp, err := rec.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != `{"echo":{"n":1}}` {
+		t.Fatalf("unexpected recorded body: %s", got)
+	}
+	if err := rec.Stop(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"sk-secret", "sk-ant-secret", "secret-key"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("cassette leaked %q", secret)
+		}
+	}
+
+	// Replay with different credentials; the server must not be hit.
+	rec, err = New(path, ModeAuto)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rec.Mode() != ModeReplay {
+		t.Fatalf("expected auto mode to resolve to replay, got %s", rec.Mode())
+	}
+	req, _ = http.NewRequest("POST", server.URL+"/v1/chat?key=other-key", strings.NewReader(`{"n":1}`))
+	req.Header.Set("Authorization", "Bearer other")
+	resp, err = rec.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || string(got) != `{"echo":{"n":1}}` {
+		t.Errorf("unexpected replayed response: %d %s", resp.StatusCode, got)
+	}
+	if resp.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("expected replayed headers, got %v", resp.Header)
+	}
+	if calls != 1 {
+		t.Errorf("expected 1 network call, got %d", calls)
+	}
+
+	// The interaction has been consumed; a repeat request has no match.
+	req, _ = http.NewRequest("POST", server.URL+"/v1/chat?key=other-key", strings.NewReader(`{"n":1}`))
+	if _, err := rec.Client().Do(req); err == nil {
+		t.Error("expected error for exhausted interaction")
+	}
+}
+
+func TestRecorder_ReplayMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.json"), ModeReplay)
+	if !errors.Is(err, ErrCassetteNotFound) {
+		t.Errorf("expected ErrCassetteNotFound, got %v", err)
+	}
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(EnvMode, "REPLAY")
+	if m := ModeFromEnv(); m != ModeReplay {
+		t.Errorf("expected replay, got %s", m)
+	}
+	t.Setenv(EnvMode, "")
+	if m := ModeFromEnv(); m != ModePassthrough {
+		t.Errorf("expected passthrough, got %s", m)
+	}
+}
@@ -0,0 +1,66 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// errRaceLost cancels the requests of a Race that another target won.
+var errRaceLost = stderrors.New("another target won the race")
+
+// Race sends req to every target at once and returns the first successful
+// response, cancelling the others. It trades cost for latency: every target
+// is billed for what it generated before it was cancelled. req.Provider and
+// req.Model are replaced by each target's; the response reports which won.
+//
+//	resp, err := r.Race(ctx, req,
+//		router.ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4.1-mini"},
+//		router.ModelTarget{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"},
+//	)
+//
+// Each request goes through Complete, so guards, budgets, and fallbacks
+// apply. The cancelled requests are not counted as errors in Metrics. If
+// every target fails, Race returns their errors joined, in target order.
+func (r *Router) Race(ctx context.Context, req *types.CompletionRequest, targets ...ModelTarget) (*types.CompletionResponse, error) {
+	if len(targets) == 0 {
+		return nil, errors.ErrInvalidRequest("Race needs at least one target")
+	}
+
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(errRaceLost)
+
+	type result struct {
+		i    int
+		resp *types.CompletionResponse
+		err  error
+	}
+	results := make(chan result, len(targets))
+	for i, target := range targets {
+		raced := *req
+		raced.Provider = target.Provider
+		raced.Model = target.Model
+		go func() {
+			resp, err := r.Complete(ctx, &raced)
+			results <- result{i, resp, err}
+		}()
+	}
+
+	errs := make([]error, len(targets))
+	for range targets {
+		res := <-results
+		if res.err == nil {
+			return res.resp, nil
+		}
+		errs[res.i] = res.err
+	}
+	return nil, stderrors.Join(errs...)
+}
+
+// lostRace reports whether ctx was cancelled because another target of a
+// Race won.
+func lostRace(ctx context.Context) bool {
+	return stderrors.Is(context.Cause(ctx), errRaceLost)
+}
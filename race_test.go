@@ -0,0 +1,94 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestRace(t *testing.T) {
+	started, cancelled := make(chan struct{}), make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.Copy(io.Discard, r.Body)
+		close(started)
+		<-r.Context().Done()
+		close(cancelled)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-started
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"fast"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer fast.Close()
+
+	r, err := New(
+		WithOpenAI("key", provider.WithBaseURL(slow.URL)),
+		WithAnthropic("key", provider.WithBaseURL(fast.URL)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	}
+
+	resp, err := r.Race(context.Background(), req,
+		ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o"},
+		ModelTarget{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Provider != types.ProviderAnthropic || resp.Text() != "fast" {
+		t.Errorf("got %s %q, want the anthropic response", resp.Provider, resp.Text())
+	}
+	<-cancelled
+
+	// Wait for the losing request to return before checking its metrics.
+	if err := r.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := r.Metrics().Stats(types.ProviderOpenAI, "gpt-4o"); stats.Errors != 0 {
+		t.Errorf("cancelled request recorded %d errors, want 0", stats.Errors)
+	}
+}
+
+func TestRace_AllFail(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(w, `{"error":{"message":"bad request","type":"invalid_request_error"}}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	}
+
+	_, err = r.Race(context.Background(), req,
+		ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o"},
+		ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o-mini"},
+	)
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		t.Fatalf("err = %v, want an invalid request error", err)
+	}
+	if n := len(err.(interface{ Unwrap() []error }).Unwrap()); n != 2 {
+		t.Errorf("joined %d errors, want 2", n)
+	}
+
+	if _, err := r.Race(context.Background(), req); err == nil {
+		t.Error("Race with no targets succeeded")
+	}
+}
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"log"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// DefaultSeed is the seed sent with reproducible requests that do not set
+// one.
+const DefaultSeed = 0
+
+// applyReproducible returns a reproducible req with temperature 0 and a
+// seed filled in, or req itself if it is not reproducible. Providers without
+// seeded sampling are sent no seed, and a warning is logged unless the
+// unsupported feature policy is PolicyIgnore.
+func (r *Router) applyReproducible(p provider.Provider, req *types.CompletionRequest) *types.CompletionRequest {
+	if !req.Reproducible {
+		return req
+	}
+
+	clone := *req
+	if clone.Temperature == nil {
+		clone.Temperature = types.Ptr(0.0)
+	}
+	switch {
+	case !p.SupportsFeature(types.FeatureSeed):
+		clone.Seed = nil
+		if r.config.OnUnsupportedFeature != PolicyIgnore {
+			log.Printf("agent-router: warning: %s does not support seeded sampling; outputs of %s may vary between identical requests", p.Name(), req.Model)
+		}
+	case clone.Seed == nil:
+		clone.Seed = types.Ptr(DefaultSeed)
+	}
+	return &clone
+}
+
+// recordSeed records the seed of a reproducible request on its response.
+func recordSeed(req *types.CompletionRequest, resp *types.CompletionResponse) {
+	if req.Reproducible && req.Seed != nil && resp != nil {
+		resp.ProviderMetadata.Seed = types.Ptr(*req.Seed)
+	}
+}
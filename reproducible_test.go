@@ -0,0 +1,106 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestReproducible(t *testing.T) {
+	var sent struct {
+		Temperature *float64 `json:"temperature"`
+		Seed        *int     `json:"seed"`
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"c1","model":"gpt-4o","system_fingerprint":"fp_abc","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider:     types.ProviderOpenAI,
+		Model:        "gpt-4o",
+		Messages:     []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+		Reproducible: true,
+	}
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent.Temperature == nil || *sent.Temperature != 0 || sent.Seed == nil || *sent.Seed != DefaultSeed {
+		t.Errorf("sent temperature %v and seed %v, want 0 and the default seed", sent.Temperature, sent.Seed)
+	}
+	meta := resp.ProviderMetadata
+	if meta.Seed == nil || *meta.Seed != DefaultSeed || meta.SystemFingerprint != "fp_abc" {
+		t.Errorf("metadata = %+v, want the seed and system fingerprint", meta)
+	}
+	if req.Seed != nil || req.Temperature != nil {
+		t.Error("the caller's request was modified")
+	}
+
+	req.Seed = types.Ptr(7)
+	req.Temperature = types.Ptr(0.3)
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if *sent.Temperature != 0.3 || *sent.Seed != 7 {
+		t.Errorf("sent temperature %v and seed %v, want the request's", *sent.Temperature, *sent.Seed)
+	}
+}
+
+func TestReproducible_Unseeded(t *testing.T) {
+	var sent map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&sent)
+		w.Write([]byte(`{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"Hi"}],"stop_reason":"end_turn","usage":{"input_tokens":3,"output_tokens":1}}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)), WithUnsupportedFeaturePolicy(PolicyIgnore))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider:     types.ProviderAnthropic,
+		Model:        "claude-haiku-4-5",
+		Messages:     []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+		Reproducible: true,
+	}
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatalf("reproducible request without seed support failed: %v", err)
+	}
+	if sent["temperature"] != 0.0 {
+		t.Errorf("temperature = %v, want 0", sent["temperature"])
+	}
+	if resp.ProviderMetadata.Seed != nil {
+		t.Errorf("seed = %d, want none for an unseeded provider", *resp.ProviderMetadata.Seed)
+	}
+}
+
+func TestSeed_Unsupported(t *testing.T) {
+	r, err := New(WithAnthropic("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.Complete(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+		Seed:     types.Ptr(1),
+	})
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeUnsupportedFeature, "")) {
+		t.Errorf("err = %v, want unsupported feature", err)
+	}
+}
@@ -0,0 +1,63 @@
+package router
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	stderrors "errors"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// NewRequestID returns a random request ID of the form "req_" followed by 24
+// hex digits, as the router generates for requests without one.
+func NewRequestID() string {
+	var b [12]byte
+	rand.Read(b[:])
+	return "req_" + hex.EncodeToString(b[:])
+}
+
+// withRequestID returns req, or a copy of it with a generated RequestID if it
+// has none. req is not modified.
+func withRequestID(req *types.CompletionRequest) *types.CompletionRequest {
+	if req == nil || req.RequestID != "" {
+		return req
+	}
+	clone := *req
+	clone.RequestID = NewRequestID()
+	return &clone
+}
+
+// tagRequestID records id on err if it is a RouterError without one.
+func tagRequestID(err error, id string) error {
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) && errors.RequestID(rerr) == "" {
+		rerr.WithRequestID(id)
+	}
+	return err
+}
+
+// requestIDStream sets the request ID on the stream's response and errors.
+type requestIDStream struct {
+	types.StreamReader
+	id string
+}
+
+func (s *requestIDStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	if err != nil {
+		return event, tagRequestID(err, s.id)
+	}
+	if event != nil && event.Error != nil {
+		tagRequestID(event.Error, s.id)
+	}
+	return event, nil
+}
+
+func (s *requestIDStream) Response() *types.CompletionResponse {
+	resp := s.StreamReader.Response()
+	if resp != nil {
+		resp.RequestID = s.id
+	}
+	return resp
+}
@@ -0,0 +1,103 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestComplete_RequestID(t *testing.T) {
+	var sent []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, r.Header.Get("X-Client-Request-Id"))
+		if len(sent) == 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			fmt.Fprint(w, `{"error":{"message":"boom","type":"server_error"}}`)
+			return
+		}
+		fmt.Fprint(w, `{"id":"chatcmpl-1","model":"gpt-4o","choices":[{"index":0,"message":{"role":"assistant","content":"Hi"},"finish_reason":"stop"}]}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	}
+
+	resp, err := r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(resp.RequestID, "req_") || sent[0] != resp.RequestID || req.RequestID != "" {
+		t.Errorf("generated ID %q, sent %q, caller's request %q", resp.RequestID, sent[0], req.RequestID)
+	}
+
+	req.RequestID = "trace-123"
+	resp, err = r.Complete(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.RequestID != "trace-123" || sent[1] != "trace-123" {
+		t.Errorf("caller's ID: response %q, sent %q", resp.RequestID, sent[1])
+	}
+
+	_, err = r.Complete(context.Background(), req)
+	if got := errors.RequestID(err); got != "trace-123" {
+		t.Errorf("error request ID = %q (%v)", got, err)
+	}
+}
+
+func TestStream_RequestID(t *testing.T) {
+	var sent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = r.Header.Get("X-Client-Request-Id")
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, "data: {\"id\":\"chatcmpl-1\",\"model\":\"gpt-4o\",\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hi\"},\"finish_reason\":\"stop\"}]}\n\n")
+		io.WriteString(w, "data: [DONE]\n\n")
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{
+		Provider:  types.ProviderOpenAI,
+		Model:     "gpt-4o",
+		Messages:  []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+		RequestID: "trace-456",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil || event.Type == types.StreamEventDone {
+			break
+		}
+	}
+
+	if sent != "trace-456" {
+		t.Errorf("sent X-Client-Request-Id %q", sent)
+	}
+	if resp := stream.Response(); resp == nil || resp.RequestID != "trace-456" {
+		t.Errorf("response = %+v", resp)
+	}
+}
@@ -26,25 +26,63 @@ package router
 import (
 	"context"
 	"fmt"
+	"log"
+	"sync"
+	"time"
 
 	"github.com/Chloe199719/agent-router/pkg/batch"
+	"github.com/Chloe199719/agent-router/pkg/compress"
 	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/finetune"
+	"github.com/Chloe199719/agent-router/pkg/guardrails"
+	"github.com/Chloe199719/agent-router/pkg/models"
 	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/provider/anthropic"
+	"github.com/Chloe199719/agent-router/pkg/provider/cohere"
+	"github.com/Chloe199719/agent-router/pkg/provider/deepseek"
 	"github.com/Chloe199719/agent-router/pkg/provider/google"
 	"github.com/Chloe199719/agent-router/pkg/provider/openai"
+	"github.com/Chloe199719/agent-router/pkg/provider/openrouter"
 	"github.com/Chloe199719/agent-router/pkg/provider/vertex"
 	"github.com/Chloe199719/agent-router/pkg/thinking"
 	"github.com/Chloe199719/agent-router/pkg/types"
 )
 
 // Router provides a unified interface for multiple LLM providers.
+//
+// A Router is safe for concurrent use by multiple goroutines; share one per
+// process rather than creating one per request, so provider connections are
+// reused. Providers can be added, removed, or re-keyed at runtime with
+// AddProvider, RemoveProvider, and UpdateAPIKey; requests already in flight
+// keep using the client they started with. Options that configure the
+// router itself, rather than a provider, must be passed to New.
+//
+// Each provider's connection pool is tuned with provider.WithTransport.
 type Router struct {
+	mu        sync.RWMutex
 	providers map[types.Provider]provider.Provider
+	factories map[types.Provider]*providerFactory
 	batch     *batch.Manager
+	finetune  *finetune.Manager
+	models    *modelCache
+	metrics   *Metrics
+	aliases   map[string]*modelAlias
+	budget    *budget
+	tenants   *tenants
+	guards    *guardrails.Pipeline
+	compress  *compress.Pipeline
+	coalescer *coalescer
+	inflight  inflight
 	config    *Config
 }
 
+// providerFactory remembers how a built-in provider was constructed so its
+// client can be rebuilt with new credentials.
+type providerFactory struct {
+	build func(opts ...provider.Option) provider.Provider
+	opts  []provider.Option
+}
+
 // Config configures the router.
 type Config struct {
 	// OnUnsupportedFeature controls behavior when a provider doesn't support a feature.
@@ -52,6 +90,39 @@ type Config struct {
 
 	// Debug enables debug logging.
 	Debug bool
+
+	// StreamRetry resumes streams that fail mid-response. Nil disables it.
+	StreamRetry *StreamRetryPolicy
+
+	// StreamIdleTimeout aborts streams that produce no event for this long.
+	// Zero disables it.
+	StreamIdleTimeout time.Duration
+
+	// ModelCacheTTL is how long ListModels results are cached. Zero disables caching.
+	ModelCacheTTL time.Duration
+
+	// RepairJSON repairs almost-valid JSON in structured output responses.
+	RepairJSON bool
+
+	// ImagePreflight checks image inputs before requests are sent. Nil
+	// disables it.
+	ImagePreflight *ImagePreflight
+
+	// RawCapture receives the providers' HTTP exchanges. Nil disables it.
+	RawCapture RawSink
+
+	// CompletionLog receives finished Complete and Stream calls. Nil
+	// disables it.
+	CompletionLog CompletionSink
+
+	// DefaultMaxTokens is the MaxTokens sent for requests that leave it
+	// unset, unless the models catalog has a default for the model. Zero
+	// leaves the choice to each provider.
+	DefaultMaxTokens int
+
+	// PingModels are the models Ping sends a 1-token completion to, by
+	// provider. Providers without one get their default check.
+	PingModels map[types.Provider]string
 }
 
 // UnsupportedFeaturePolicy controls how unsupported features are handled.
@@ -66,6 +137,13 @@ const (
 
 	// PolicyIgnore silently ignores unsupported features.
 	PolicyIgnore UnsupportedFeaturePolicy = "ignore"
+
+	// PolicyFallback emulates unsupported features where the router can and
+	// otherwise behaves like PolicyError. Complete emulates json_schema
+	// output with a forced tool call or a JSON instruction, validating the
+	// result locally, including when a provider rejects native json_schema
+	// output for a model.
+	PolicyFallback UnsupportedFeaturePolicy = "fallback"
 )
 
 // Option configures the router.
@@ -75,9 +153,16 @@ type Option func(*Router)
 func New(opts ...Option) (*Router, error) {
 	r := &Router{
 		providers: make(map[types.Provider]provider.Provider),
+		factories: make(map[types.Provider]*providerFactory),
 		batch:     batch.NewManager(),
+		finetune:  finetune.NewManager(),
+		models:    newModelCache(),
+		metrics:   newMetrics(),
+		aliases:   make(map[string]*modelAlias),
+		tenants:   newTenants(),
 		config: &Config{
 			OnUnsupportedFeature: PolicyError,
+			ModelCacheTTL:        defaultModelCacheTTL,
 		},
 	}
 
@@ -96,9 +181,9 @@ func New(opts ...Option) (*Router, error) {
 func WithOpenAI(apiKey string, opts ...provider.Option) Option {
 	return func(r *Router) {
 		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
-		client := openai.New(allOpts...)
-		r.providers[types.ProviderOpenAI] = client
-		r.batch.RegisterProvider(client)
+		r.register(types.ProviderOpenAI, func(opts ...provider.Option) provider.Provider {
+			return openai.New(opts...)
+		}, allOpts)
 	}
 }
 
@@ -106,9 +191,9 @@ func WithOpenAI(apiKey string, opts ...provider.Option) Option {
 func WithAnthropic(apiKey string, opts ...provider.Option) Option {
 	return func(r *Router) {
 		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
-		client := anthropic.New(allOpts...)
-		r.providers[types.ProviderAnthropic] = client
-		r.batch.RegisterProvider(client)
+		r.register(types.ProviderAnthropic, func(opts ...provider.Option) provider.Provider {
+			return anthropic.New(opts...)
+		}, allOpts)
 	}
 }
 
@@ -116,26 +201,67 @@ func WithAnthropic(apiKey string, opts ...provider.Option) Option {
 func WithGoogle(apiKey string, opts ...provider.Option) Option {
 	return func(r *Router) {
 		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
-		client := google.New(allOpts...)
-		r.providers[types.ProviderGoogle] = client
-		r.batch.RegisterProvider(client)
+		r.register(types.ProviderGoogle, func(opts ...provider.Option) provider.Provider {
+			return google.New(opts...)
+		}, allOpts)
+	}
+}
+
+// WithDeepSeek adds DeepSeek as a provider.
+func WithDeepSeek(apiKey string, opts ...provider.Option) Option {
+	return func(r *Router) {
+		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
+		r.register(types.ProviderDeepSeek, func(opts ...provider.Option) provider.Provider {
+			return deepseek.New(opts...)
+		}, allOpts)
+	}
+}
+
+// WithCohere adds Cohere as a provider.
+func WithCohere(apiKey string, opts ...provider.Option) Option {
+	return func(r *Router) {
+		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
+		r.register(types.ProviderCohere, func(opts ...provider.Option) provider.Provider {
+			return cohere.New(opts...)
+		}, allOpts)
+	}
+}
+
+// WithOpenRouter adds OpenRouter as a provider. Models are named
+// "vendor/model"; responses report the upstream provider that served them in
+// their metadata and the billed cost in Usage.Cost.
+func WithOpenRouter(apiKey string, opts ...provider.Option) Option {
+	return func(r *Router) {
+		allOpts := append([]provider.Option{provider.WithAPIKey(apiKey)}, opts...)
+		r.register(types.ProviderOpenRouter, func(opts ...provider.Option) provider.Provider {
+			return openrouter.New(opts...)
+		}, allOpts)
 	}
 }
 
 // WithVertex adds Google Vertex AI as a provider.
 //
 // The projectID and location are required. Authentication can be provided via
-// provider.WithAccessToken() (OAuth2 Bearer token) or provider.WithAPIKey()
-// (API key) in the opts. Example:
+// provider.WithAccessToken() or provider.WithTokenSource() (OAuth2 Bearer
+// token) or provider.WithAPIKey() (API key) in the opts; without any of them,
+// Application Default Credentials are used. Example:
 //
 //	router.WithVertex("my-project", "us-central1",
 //	    provider.WithAccessToken(os.Getenv("VERTEX_ACCESS_TOKEN")),
 //	)
 func WithVertex(projectID, location string, opts ...provider.Option) Option {
 	return func(r *Router) {
-		client := vertex.New(projectID, location, opts...)
-		r.providers[types.ProviderVertex] = client
-		r.batch.RegisterProvider(client)
+		r.register(types.ProviderVertex, func(opts ...provider.Option) provider.Provider {
+			return vertex.New(projectID, location, opts...)
+		}, opts)
+	}
+}
+
+// WithBatchStore persists submitted batches so they can be tracked and
+// retried after a restart. See batch.NewFileStore.
+func WithBatchStore(store batch.Store) Option {
+	return func(r *Router) {
+		batch.WithStore(store)(r.batch)
 	}
 }
 
@@ -146,6 +272,15 @@ func WithUnsupportedFeaturePolicy(policy UnsupportedFeaturePolicy) Option {
 	}
 }
 
+// WithGuardrails runs a guardrails pipeline on every Complete and Stream
+// call: request guards before routing, response guards on the result, and
+// stream guards on each text delta.
+func WithGuardrails(p *guardrails.Pipeline) Option {
+	return func(r *Router) {
+		r.guards = p
+	}
+}
+
 // WithDebug enables debug logging.
 func WithDebug(debug bool) Option {
 	return func(r *Router) {
@@ -153,24 +288,268 @@ func WithDebug(debug bool) Option {
 	}
 }
 
-// Complete sends a completion request to the specified provider.
+// register builds a provider client and installs it, replacing any existing
+// client for the same provider.
+func (r *Router) register(name types.Provider, build func(opts ...provider.Option) provider.Provider, opts []provider.Option) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.config.RawCapture != nil {
+		build = r.capturing(name, build)
+	}
+	r.factories[name] = &providerFactory{build: build, opts: opts}
+	r.installLocked(name, build(opts...))
+}
+
+// installLocked stores a provider client and keeps the batch and fine-tuning
+// managers in sync. The caller must hold r.mu.
+func (r *Router) installLocked(name types.Provider, p provider.Provider) {
+	r.providers[name] = p
+	r.models.invalidate(name)
+	r.tenants.invalidate(name)
+	if bp, ok := p.(provider.BatchProvider); ok {
+		r.batch.RegisterProvider(bp)
+	} else {
+		r.batch.UnregisterProvider(name)
+		r.batch.RegisterLocalProvider(p)
+	}
+	if fp, ok := p.(provider.FineTuneProvider); ok {
+		r.finetune.RegisterProvider(fp)
+	} else {
+		r.finetune.UnregisterProvider(name)
+	}
+}
+
+// AddProvider adds a provider to a running router, replacing the existing
+// client if the provider is already configured. It accepts the provider
+// options of New, such as WithOpenAI; other options are not safe to apply
+// while requests are in flight:
+//
+//	r.AddProvider(router.WithAnthropic(os.Getenv("ANTHROPIC_API_KEY")))
+func (r *Router) AddProvider(opt Option) {
+	opt(r)
+}
+
+// RemoveProvider removes a configured provider. Subsequent requests for it
+// fail with a provider_unavailable error.
+func (r *Router) RemoveProvider(name types.Provider) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, ok := r.providers[name]; !ok {
+		return errors.ErrProviderUnavailable(name, "provider not configured")
+	}
+
+	delete(r.providers, name)
+	delete(r.factories, name)
+	r.batch.UnregisterProvider(name)
+	r.finetune.UnregisterProvider(name)
+	r.models.invalidate(name)
+	r.tenants.invalidate(name)
+	return nil
+}
+
+// UpdateAPIKey rebuilds a provider's client with a new API key, keeping all
+// other options it was configured with. Use it to rotate keys without
+// restarting the service.
+func (r *Router) UpdateAPIKey(name types.Provider, apiKey string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	f, ok := r.factories[name]
+	if !ok {
+		return errors.ErrProviderUnavailable(name, "provider not configured")
+	}
+
+	opts := make([]provider.Option, 0, len(f.opts)+1)
+	opts = append(opts, f.opts...)
+	opts = append(opts, provider.WithAPIKey(apiKey))
+	f.opts = opts
+
+	r.installLocked(name, f.build(opts...))
+	return nil
+}
+
+// Complete sends a completion request to the specified provider. If
+// req.Provider is empty and req.Model names a model alias, the alias's
+// strategy picks the provider and model.
 func (r *Router) Complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
-	p, err := r.getProvider(req.Provider)
+	if err := r.inflight.enter(); err != nil {
+		return nil, err
+	}
+	defer r.inflight.leave()
+
+	req = withRequestID(req)
+	start := time.Now()
+	var resp *types.CompletionResponse
+	var err error
+	if r.coalescer != nil {
+		resp, err = r.coalescer.do(ctx, req, r.complete)
+	} else {
+		resp, err = r.complete(ctx, req)
+	}
+	if err != nil {
+		err = tagRequestID(err, req.RequestID)
+		if r.config.CompletionLog != nil {
+			r.logCompletion(req, nil, err, false, start)
+		}
+		return nil, err
+	}
+	resp.RequestID = req.RequestID
+	if r.config.CompletionLog != nil {
+		r.logCompletion(req, resp, nil, false, start)
+	}
+	return resp, nil
+}
+
+// complete implements Complete for a request with an ID.
+func (r *Router) complete(ctx context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	req, err := r.guards.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	req, compression, err := r.compress.Compress(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
+	req, res, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := r.providerFor(req)
+	if err != nil {
+		r.budget.settle(res, nil, err)
+		return nil, err
+	}
+
+	req = r.applyDefaultMaxTokens(p, req)
+	req = r.applyReproducible(p, req)
+
 	// Check feature support
 	if err := r.checkFeatureSupport(p, req); err != nil {
+		r.budget.settle(res, nil, err)
 		return nil, err
 	}
 
-	return p.Complete(ctx, req)
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	req, err = r.preflightImages(ctx, p.Name(), req)
+	if err != nil {
+		r.budget.settle(res, nil, err)
+		return nil, err
+	}
+
+	var call *rawCall
+	if r.config.RawCapture != nil {
+		ctx, call = withRawCall(ctx)
+	}
+
+	start := time.Now()
+	var resp *types.CompletionResponse
+	fallback := r.config.OnUnsupportedFeature == PolicyFallback
+	switch {
+	case len(req.MCPServers) > 0 && !p.SupportsFeature(types.FeatureMCP):
+		resp, err = r.completeWithMCP(ctx, p, req)
+	case fallback && unsupportedStructuredOutput(p, req) != nil:
+		resp, err = completeStructured(ctx, p, req)
+	default:
+		resp, err = p.Complete(ctx, req)
+		if err != nil && fallback && rejectsStructuredOutput(req, err) {
+			resp, err = completeStructured(ctx, p, req)
+		}
+	}
+	if err == nil && resp == nil {
+		err = errors.ErrEmptyResponse(p.Name(), "provider returned no response")
+	}
+	if !lostRace(ctx) {
+		r.metrics.Record(p.Name(), req.Model, time.Since(start), err)
+	}
+	r.budget.settle(res, resp, err)
+	if err != nil {
+		r.tenants.record(req, p.Name(), nil, err)
+		return nil, timeoutError(ctx, p.Name(), err)
+	}
+	r.tenants.record(req, p.Name(), &resp.Usage, nil)
+	r.metrics.RecordPrediction(p.Name(), req.Model, resp.Usage)
+	finishResponse(req, resp)
+	resp.Compression = compression
+	if call != nil {
+		resp.Raw = call.response()
+	}
+	normalizeStopSequence(req, resp)
+
+	if r.config.RepairJSON {
+		if err := repairJSON(p.Name(), req, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.guards.Response(ctx, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
 // Stream sends a streaming completion request to the specified provider.
+// Model aliases are resolved as in Complete.
 func (r *Router) Stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
-	p, err := r.getProvider(req.Provider)
+	if err := r.inflight.enter(); err != nil {
+		return nil, err
+	}
+
+	req = withRequestID(req)
+	start := time.Now()
+	stream, err := r.stream(ctx, req)
+	if err != nil {
+		r.inflight.leave()
+		err = tagRequestID(err, req.RequestID)
+		if r.config.CompletionLog != nil {
+			r.logCompletion(req, nil, err, true, start)
+		}
+		return nil, err
+	}
+	stream = &requestIDStream{StreamReader: stream, id: req.RequestID}
+	if r.config.CompletionLog != nil {
+		stream = &loggedStream{StreamReader: stream, router: r, req: req, start: start}
+	}
+	return &inflightStream{StreamReader: stream, inflight: &r.inflight}, nil
+}
+
+// stream implements Stream for a request with an ID.
+func (r *Router) stream(ctx context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	req, err := r.guards.Request(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	req, compression, err := r.compress.Compress(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	req, res, err := r.route(req)
+	if err != nil {
+		return nil, err
+	}
+	stream, err := r.streamRouted(ctx, req, res, compression)
+	if err != nil {
+		r.budget.settle(res, nil, err)
+		return nil, err
+	}
+	return stream, nil
+}
+
+// streamRouted opens a stream for req, routed with budget reservation res.
+// The caller settles res if it fails; otherwise the stream does.
+func (r *Router) streamRouted(ctx context.Context, req *types.CompletionRequest, res *reservation, compression *types.CompressionStats) (types.StreamReader, error) {
+	p, err := r.providerFor(req)
 	if err != nil {
 		return nil, err
 	}
@@ -180,12 +559,81 @@ func (r *Router) Stream(ctx context.Context, req *types.CompletionRequest) (type
 		return nil, errors.ErrUnsupportedFeature(req.Provider, types.FeatureStreaming)
 	}
 
+	// MCP tools are only executed locally for Complete.
+	if len(req.MCPServers) > 0 && !p.SupportsFeature(types.FeatureMCP) {
+		if err := r.handleUnsupportedFeature(errors.ErrUnsupportedFeature(req.Provider, types.FeatureMCP)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Streams carry one response.
+	if req.N > 1 {
+		return nil, errors.ErrInvalidRequest("N > 1 is only supported by Complete").WithProvider(req.Provider)
+	}
+
+	// Structured output is only emulated for Complete.
+	if r.config.OnUnsupportedFeature == PolicyFallback {
+		if err := unsupportedStructuredOutput(p, req); err != nil {
+			return nil, err
+		}
+	}
+
+	req = r.applyDefaultMaxTokens(p, req)
+	req = r.applyReproducible(p, req)
+
 	// Check other feature support
 	if err := r.checkFeatureSupport(p, req); err != nil {
 		return nil, err
 	}
 
-	return p.Stream(ctx, req)
+	idle := r.config.StreamIdleTimeout
+	if req.StreamIdleTimeout > 0 {
+		idle = req.StreamIdleTimeout
+	}
+
+	var cancel context.CancelFunc
+	if req.Timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+
+	req, err = r.preflightImages(ctx, p.Name(), req)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	start := time.Now()
+	stream, err := p.Stream(ctx, req)
+	if err != nil {
+		err = timeoutError(ctx, p.Name(), err)
+		r.tenants.record(req, p.Name(), nil, err)
+		cancel()
+		return nil, err
+	}
+
+	if r.config.StreamRetry != nil && r.config.StreamRetry.MaxRetries > 0 {
+		stream = newResumingStream(ctx, p, req, stream, *r.config.StreamRetry)
+	}
+	if res != nil {
+		stream = &budgetStream{StreamReader: stream, budget: r.budget, res: res}
+	}
+	if r.tenants.tracks(req) {
+		stream = &tenantStream{StreamReader: stream, tenants: r.tenants, req: req, provider: p.Name()}
+	}
+	stream = newStatsStream(ctx, stream, r.metrics, p.Name(), req.Model, start)
+	if req.Reproducible || req.IncludeTokenBreakdown {
+		stream = &finishingStream{StreamReader: stream, req: req}
+	}
+	if r.config.RepairJSON {
+		stream = &repairingStream{StreamReader: stream, name: p.Name(), req: req}
+	}
+	if compression != nil {
+		stream = &compressedStream{StreamReader: stream, stats: compression}
+	}
+	stream = r.guards.Stream(ctx, stream)
+	return newTimeoutStream(ctx, cancel, stream, p.Name(), idle), nil
 }
 
 // Batch returns the batch manager for batch processing operations.
@@ -193,6 +641,11 @@ func (r *Router) Batch() *batch.Manager {
 	return r.batch
 }
 
+// FineTune returns the fine-tuning manager.
+func (r *Router) FineTune() *finetune.Manager {
+	return r.finetune
+}
+
 // Provider returns the provider implementation for direct access.
 func (r *Router) Provider(name types.Provider) (provider.Provider, error) {
 	return r.getProvider(name)
@@ -200,6 +653,9 @@ func (r *Router) Provider(name types.Provider) (provider.Provider, error) {
 
 // Providers returns all configured providers.
 func (r *Router) Providers() []types.Provider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
 	providers := make([]types.Provider, 0, len(r.providers))
 	for name := range r.providers {
 		providers = append(providers, name)
@@ -227,66 +683,122 @@ func (r *Router) Models(providerName types.Provider) ([]string, error) {
 
 // getProvider returns the provider for the given name.
 func (r *Router) getProvider(name types.Provider) (provider.Provider, error) {
+	r.mu.RLock()
 	p, ok := r.providers[name]
+	r.mu.RUnlock()
 	if !ok {
 		return nil, errors.ErrProviderUnavailable(name, "provider not configured")
 	}
 	return p, nil
 }
 
-// checkFeatureSupport checks if the provider supports the features required by the request.
+// checkFeatureSupport checks if the provider, and the model per the models
+// catalog, support the features required by the request, and that MaxTokens
+// is within the model's output limit.
 func (r *Router) checkFeatureSupport(p provider.Provider, req *types.CompletionRequest) error {
-	// Check structured output support
-	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json_schema" {
-		if !p.SupportsFeature(types.FeatureStructuredOutput) {
-			return r.handleUnsupportedFeature(p.Name(), types.FeatureStructuredOutput)
+	info, known := models.Lookup(p.Name(), req.Model)
+
+	for _, feature := range requiredFeatures(req) {
+		if feature == types.FeatureStructuredOutput && r.config.OnUnsupportedFeature == PolicyFallback {
+			continue // emulated by Complete, rejected by Stream
+		}
+		if !p.SupportsFeature(feature) {
+			if err := r.handleUnsupportedFeature(errors.ErrUnsupportedFeature(p.Name(), feature)); err != nil {
+				return err
+			}
+			continue
 		}
+		if known && !info.Supports(feature) {
+			if err := r.handleUnsupportedFeature(errors.ErrModelUnsupportedFeature(p.Name(), req.Model, feature)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if limit := info.OutputLimit(); known && req.MaxTokens != nil && limit > 0 && *req.MaxTokens > limit {
+		return errors.ErrInvalidRequest(fmt.Sprintf("max_tokens %d exceeds the %d output token limit of %s", *req.MaxTokens, limit, req.Model)).WithProvider(p.Name())
+	}
+
+	if err := thinking.ValidateThinking(p.Name(), req.Model, req.Thinking, req.MaxTokens); err != nil {
+		return err
 	}
 
-	// Check JSON mode support
-	if req.ResponseFormat != nil && req.ResponseFormat.Type == "json" {
-		if !p.SupportsFeature(types.FeatureJSON) {
-			return r.handleUnsupportedFeature(p.Name(), types.FeatureJSON)
+	return nil
+}
+
+// requiredFeatures returns the features a request depends on.
+func requiredFeatures(req *types.CompletionRequest) []types.Feature {
+	var features []types.Feature
+
+	if req.ResponseFormat != nil {
+		switch req.ResponseFormat.Type {
+		case "json_schema":
+			features = append(features, types.FeatureStructuredOutput)
+		case "json":
+			features = append(features, types.FeatureJSON)
 		}
 	}
 
-	// Check tools support
 	if len(req.Tools) > 0 {
-		if !p.SupportsFeature(types.FeatureTools) {
-			return r.handleUnsupportedFeature(p.Name(), types.FeatureTools)
-		}
+		features = append(features, types.FeatureTools)
+	}
+
+	if len(req.Documents) > 0 {
+		features = append(features, types.FeatureDocuments)
+	}
+
+	if req.Seed != nil {
+		features = append(features, types.FeatureSeed)
+	}
+
+	if req.Audio != nil {
+		features = append(features, types.FeatureAudioOutput)
+	}
+
+	if req.N > 1 {
+		features = append(features, types.FeatureCandidates)
 	}
 
-	// Check vision support (detect images in messages)
+	// Detect images in messages
 	for _, msg := range req.Messages {
 		for _, block := range msg.Content {
 			if block.Type == types.ContentTypeImage {
-				if !p.SupportsFeature(types.FeatureVision) {
-					return r.handleUnsupportedFeature(p.Name(), types.FeatureVision)
-				}
-				break
+				return append(features, types.FeatureVision)
 			}
 		}
 	}
 
-	if err := thinking.ValidateThinking(p.Name(), req.Model, req.Thinking, req.MaxTokens); err != nil {
-		return err
-	}
-
-	return nil
+	return features
 }
 
-// handleUnsupportedFeature handles an unsupported feature based on policy.
-func (r *Router) handleUnsupportedFeature(providerName types.Provider, feature types.Feature) error {
+// handleUnsupportedFeature applies the unsupported feature policy to err.
+func (r *Router) handleUnsupportedFeature(err *errors.RouterError) error {
 	switch r.config.OnUnsupportedFeature {
-	case PolicyError:
-		return errors.ErrUnsupportedFeature(providerName, feature)
 	case PolicyWarn:
-		// TODO: Add logging
+		log.Printf("agent-router: warning: %s", err.Message)
 		return nil
 	case PolicyIgnore:
 		return nil
 	default:
-		return errors.ErrUnsupportedFeature(providerName, feature)
+		return err
 	}
 }
+
+// finishResponse adds what the request asked for to a provider's response:
+// the seed of a reproducible request and the token breakdown.
+func finishResponse(req *types.CompletionRequest, resp *types.CompletionResponse) {
+	recordSeed(req, resp)
+	withTokenBreakdown(req, resp)
+}
+
+// finishingStream applies finishResponse to the stream's response.
+type finishingStream struct {
+	types.StreamReader
+	req *types.CompletionRequest
+}
+
+func (s *finishingStream) Response() *types.CompletionResponse {
+	resp := s.StreamReader.Response()
+	finishResponse(s.req, resp)
+	return resp
+}
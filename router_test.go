@@ -0,0 +1,140 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// scriptedStream replays a fixed list of events, then fails with err (or ends).
+type scriptedStream struct {
+	events []*types.StreamEvent
+	err    error
+	resp   *types.CompletionResponse
+}
+
+func (s *scriptedStream) Next() (*types.StreamEvent, error) {
+	if len(s.events) == 0 {
+		return nil, s.err
+	}
+	e := s.events[0]
+	s.events = s.events[1:]
+	return e, nil
+}
+
+func (s *scriptedStream) Close() error                        { return nil }
+func (s *scriptedStream) Response() *types.CompletionResponse { return s.resp }
+
+// fakeProvider returns the next scripted stream on each Stream call. The
+// first calls fail with the non-nil entries of errs.
+type fakeProvider struct {
+	streams   []*scriptedStream
+	errs      []error
+	noPrefill bool
+	requests  []*types.CompletionRequest
+}
+
+func (f *fakeProvider) Name() types.Provider { return types.ProviderAnthropic }
+func (f *fakeProvider) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	return &types.CompletionResponse{Provider: f.Name(), Model: req.Model}, nil
+}
+func (f *fakeProvider) Stream(_ context.Context, req *types.CompletionRequest) (types.StreamReader, error) {
+	f.requests = append(f.requests, req)
+	if len(f.errs) > 0 {
+		err := f.errs[0]
+		f.errs = f.errs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	s := f.streams[0]
+	f.streams = f.streams[1:]
+	return s, nil
+}
+func (f *fakeProvider) SupportsFeature(feature types.Feature) bool {
+	return feature != types.FeaturePrefill || !f.noPrefill
+}
+func (f *fakeProvider) Models() []string { return nil }
+
+func textDelta(text string) *types.StreamEvent {
+	return &types.StreamEvent{Type: types.StreamEventContentDelta, Delta: &types.ContentBlock{Type: types.ContentTypeText, Text: text}}
+}
+
+// newTestRouter returns a router with p registered under its name and opts
+// applied.
+func newTestRouter(t *testing.T, p provider.Provider, opts ...Option) *Router {
+	t.Helper()
+	r, err := New(append(opts, func(r *Router) {
+		r.register(p.Name(), func(...provider.Option) provider.Provider { return p }, nil)
+	})...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func TestProviderLifecycle(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("x-api-key"))
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("openai-key"), WithTenant("acme", TenantConfig{APIKeys: map[types.Provider]string{types.ProviderAnthropic: "acme-key"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	request := func(tenant string) error {
+		_, err := r.Complete(context.Background(), &types.CompletionRequest{
+			Provider: types.ProviderAnthropic,
+			Model:    "claude-haiku-4-5",
+			TenantID: tenant,
+			Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+		})
+		return err
+	}
+	unavailable := errors.NewError(errors.ErrCodeProviderUnavailable, "")
+
+	if err := request(""); !stderrors.Is(err, unavailable) {
+		t.Fatalf("before AddProvider: err = %v, want provider_unavailable", err)
+	}
+
+	r.AddProvider(WithAnthropic("old-key", provider.WithBaseURL(srv.URL)))
+	if err := r.UpdateAPIKey(types.ProviderAnthropic, "new-key"); err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"", "acme"} {
+		if err := request(tenant); err != nil {
+			t.Fatalf("tenant %q: %v", tenant, err)
+		}
+	}
+	if want := []string{"new-key", "acme-key"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+
+	if err := r.RemoveProvider(types.ProviderAnthropic); err != nil {
+		t.Fatal(err)
+	}
+	for _, tenant := range []string{"", "acme"} {
+		if err := request(tenant); !stderrors.Is(err, unavailable) {
+			t.Errorf("tenant %q after RemoveProvider: err = %v, want provider_unavailable", tenant, err)
+		}
+	}
+	if len(keys) != 2 {
+		t.Errorf("requests reached the removed provider: %v", keys)
+	}
+	if err := r.RemoveProvider(types.ProviderAnthropic); !stderrors.Is(err, unavailable) {
+		t.Errorf("second RemoveProvider: err = %v", err)
+	}
+	if err := r.UpdateAPIKey(types.ProviderAnthropic, "key"); !stderrors.Is(err, unavailable) {
+		t.Errorf("UpdateAPIKey on removed provider: err = %v", err)
+	}
+}
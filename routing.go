@@ -0,0 +1,232 @@
+package router
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// SelectOption constrains the provider and model chosen by CompleteAny.
+type SelectOption func(*selection)
+
+type selection struct {
+	features []types.Feature
+	class    models.Class
+	strategy Strategy
+}
+
+// Require restricts selection to providers and models that support all of
+// the given features. Features the request itself uses (tools, images,
+// response formats) are always required.
+func Require(features ...types.Feature) SelectOption {
+	return func(s *selection) {
+		s.features = append(s.features, features...)
+	}
+}
+
+// WithModelClass restricts selection to models of the given class.
+func WithModelClass(class models.Class) SelectOption {
+	return func(s *selection) {
+		s.class = class
+	}
+}
+
+// WithStrategy sets the strategy that ranks the candidates. The default is
+// RouteCheapest.
+func WithStrategy(strategy Strategy) SelectOption {
+	return func(s *selection) {
+		s.strategy = strategy
+	}
+}
+
+// Candidate is a provider and model that can serve a request.
+type Candidate struct {
+	Provider types.Provider
+	Model    string
+
+	// Info is the model's catalog entry, or zero if it is not cataloged.
+	Info models.Info
+}
+
+// Candidates returns the configured providers and catalog models that satisfy
+// the request's features and the selection options, in the order the
+// strategy prefers them. Only models with a class in the models catalog are
+// considered.
+func (r *Router) Candidates(req *types.CompletionRequest, opts ...SelectOption) []Candidate {
+	sel := newSelection(req, opts)
+
+	var candidates []Candidate
+	for _, name := range r.Providers() {
+		if !r.providerSupports(name, sel.features) {
+			continue
+		}
+
+		catalogName := name
+		if name == types.ProviderVertex {
+			catalogName = types.ProviderGoogle
+		}
+		for _, info := range models.All(catalogName) {
+			if info.Class == "" || info.Deprecated || (sel.class != "" && info.Class != sel.class) {
+				continue
+			}
+			if !modelSupports(info, sel.features, req) {
+				continue
+			}
+			candidates = append(candidates, Candidate{Provider: name, Model: info.ID, Info: info})
+		}
+	}
+
+	return rank(sel.strategy, candidates, r.metrics)
+}
+
+// CompleteAny sends the request to the first of Candidates: by default the
+// cheapest configured provider and model that satisfy the request and the
+// selection options, instead of the caller choosing one. req.Provider and
+// req.Model are ignored; the response reports which were used.
+//
+//	resp, err := r.CompleteAny(ctx, req, router.Require(types.FeatureTools, types.FeatureVision))
+func (r *Router) CompleteAny(ctx context.Context, req *types.CompletionRequest, opts ...SelectOption) (*types.CompletionResponse, error) {
+	candidates := r.Candidates(req, opts...)
+	if len(candidates) == 0 {
+		sel := newSelection(req, opts)
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("no configured provider has a %s model supporting %v", classOrAny(sel.class), sel.features))
+	}
+
+	routed := *req
+	routed.Provider = candidates[0].Provider
+	routed.Model = candidates[0].Model
+	return r.Complete(ctx, &routed)
+}
+
+// ModelTarget is a provider and model that a model alias can route to.
+type ModelTarget struct {
+	Provider types.Provider
+	Model    string
+}
+
+type modelAlias struct {
+	strategy Strategy
+	targets  []ModelTarget
+}
+
+// WithModelAlias defines a model name that routes to one of several targets,
+// chosen per request by the strategy from the targets that are configured and
+// support the request. Use it by setting Model to the alias and leaving
+// Provider empty:
+//
+//	router.WithModelAlias("chat", router.RouteFastestP95,
+//		router.ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4.1-mini"},
+//		router.ModelTarget{Provider: types.ProviderGoogle, Model: "gemini-2.5-flash"},
+//	)
+func WithModelAlias(alias string, strategy Strategy, targets ...ModelTarget) Option {
+	return func(r *Router) {
+		if strategy == nil {
+			strategy = RouteCheapest
+		}
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.aliases[alias] = &modelAlias{strategy: strategy, targets: targets}
+	}
+}
+
+// route applies the tenant's rate limit, resolves a model alias, and admits
+// the request against the budget.
+func (r *Router) route(req *types.CompletionRequest) (*types.CompletionRequest, *reservation, error) {
+	if err := r.tenants.allow(req.TenantID); err != nil {
+		return nil, nil, err
+	}
+	routed, err := r.resolveAlias(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	return r.budget.admit(r, req, routed)
+}
+
+// isAlias reports whether name is a model alias.
+func (r *Router) isAlias(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.aliases[name]
+	return ok
+}
+
+// resolveAlias returns a copy of req routed to a target of its model alias,
+// or req itself if it names a provider or the model is not an alias.
+func (r *Router) resolveAlias(req *types.CompletionRequest) (*types.CompletionRequest, error) {
+	if req.Provider != "" {
+		return req, nil
+	}
+	r.mu.RLock()
+	alias, ok := r.aliases[req.Model]
+	r.mu.RUnlock()
+	if !ok {
+		return req, nil
+	}
+
+	features := requiredFeatures(req)
+	var candidates []Candidate
+	for _, t := range alias.targets {
+		if !r.providerSupports(t.Provider, features) {
+			continue
+		}
+		info, cataloged := models.Lookup(t.Provider, t.Model)
+		if cataloged && !modelSupports(info, features, req) {
+			continue
+		}
+		candidates = append(candidates, Candidate{Provider: t.Provider, Model: t.Model, Info: info})
+	}
+	if len(candidates) == 0 {
+		return nil, errors.ErrInvalidRequest(fmt.Sprintf("no target of model alias %q is configured and supports the request", req.Model))
+	}
+
+	best := rank(alias.strategy, candidates, r.metrics)[0]
+	routed := *req
+	routed.Provider = best.Provider
+	routed.Model = best.Model
+	return &routed, nil
+}
+
+// newSelection applies the options on top of the features the request uses.
+func newSelection(req *types.CompletionRequest, opts []SelectOption) *selection {
+	sel := &selection{features: requiredFeatures(req), strategy: RouteCheapest}
+	for _, opt := range opts {
+		opt(sel)
+	}
+	return sel
+}
+
+func classOrAny(class models.Class) string {
+	if class == "" {
+		return "classified"
+	}
+	return string(class)
+}
+
+// providerSupports reports whether a provider is configured and supports
+// every feature.
+func (r *Router) providerSupports(name types.Provider, features []types.Feature) bool {
+	p, err := r.getProvider(name)
+	return err == nil && supportsAll(features, p.SupportsFeature)
+}
+
+// modelSupports reports whether a cataloged model supports every feature and
+// the request's max tokens.
+func modelSupports(info models.Info, features []types.Feature, req *types.CompletionRequest) bool {
+	if req.MaxTokens != nil && info.OutputLimit() > 0 && *req.MaxTokens > info.OutputLimit() {
+		return false
+	}
+	return supportsAll(features, info.Supports)
+}
+
+// supportsAll reports whether supports returns true for every feature.
+func supportsAll(features []types.Feature, supports func(types.Feature) bool) bool {
+	for _, f := range features {
+		if !supports(f) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCandidates(t *testing.T) {
+	r, err := New(WithOpenAI("key"), WithAnthropic("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")}}
+
+	tests := []struct {
+		name     string
+		opts     []SelectOption
+		provider types.Provider
+		model    string
+	}{
+		{"cheapest", nil, types.ProviderOpenAI, "gpt-5-nano"},
+		{"class", []SelectOption{WithModelClass(models.ClassPowerful)}, types.ProviderOpenAI, "o3"},
+		{"balanced with vision", []SelectOption{Require(types.FeatureVision), WithModelClass(models.ClassBalanced)}, types.ProviderOpenAI, "o4-mini"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := r.Candidates(req, tt.opts...)
+			if len(candidates) == 0 {
+				t.Fatal("no candidates")
+			}
+			if c := candidates[0]; c.Provider != tt.provider || c.Model != tt.model {
+				t.Errorf("selected %s/%s, want %s/%s", c.Provider, c.Model, tt.provider, tt.model)
+			}
+		})
+	}
+}
+
+func TestCompleteAny(t *testing.T) {
+	var model string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model string `json:"model"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		model = body.Model
+		fmt.Fprintf(w, `{"id":"msg_1","type":"message","role":"assistant","model":%q,"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":1,"output_tokens":1}}`, body.Model)
+	}))
+	defer srv.Close()
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	}
+
+	resp, err := r.CompleteAny(context.Background(), req, Require(types.FeatureTools, types.FeatureVision), WithModelClass(models.ClassBalanced))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if model != "claude-sonnet-4-5" || resp.Provider != types.ProviderAnthropic {
+		t.Errorf("routed to %s/%s, want anthropic/claude-sonnet-4-5", resp.Provider, model)
+	}
+	if req.Provider != types.ProviderOpenAI || req.Model != "" {
+		t.Error("CompleteAny modified the caller's request")
+	}
+
+	_, err = r.CompleteAny(context.Background(), req, WithModelClass("huge"))
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		t.Errorf("err = %v, want invalid request", err)
+	}
+}
+
+// nilProvider returns neither a response nor an error.
+type nilProvider struct{ fakeProvider }
+
+func (nilProvider) Complete(context.Context, *types.CompletionRequest) (*types.CompletionResponse, error) {
+	return nil, nil
+}
+
+func TestComplete_EmptyResponse(t *testing.T) {
+	r := newTestRouter(t, &nilProvider{})
+
+	resp, err := r.Complete(context.Background(), &types.CompletionRequest{Provider: types.ProviderAnthropic, Model: "m"})
+	var rerr *errors.RouterError
+	if resp != nil || !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeEmptyResponse {
+		t.Fatalf("got resp %v, err %v, want empty_response error", resp, err)
+	}
+	if !errors.IsRetryable(err) {
+		t.Error("expected empty response to be retryable")
+	}
+}
@@ -0,0 +1,125 @@
+package router
+
+import (
+	"context"
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// inflight tracks the calls in progress so Shutdown can wait for them.
+type inflight struct {
+	mu      sync.Mutex
+	closed  bool
+	pending sync.WaitGroup
+}
+
+// enter registers a call, or fails once the router is shutting down.
+func (f *inflight) enter() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.closed {
+		return errShutdown()
+	}
+	f.pending.Add(1)
+	return nil
+}
+
+// leave marks a call registered with enter as done.
+func (f *inflight) leave() {
+	f.pending.Done()
+}
+
+// close stops new calls and returns a channel closed once the calls in
+// progress are done.
+func (f *inflight) close() <-chan struct{} {
+	f.mu.Lock()
+	f.closed = true
+	f.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		f.pending.Wait()
+		close(done)
+	}()
+	return done
+}
+
+// errShutdown is the error of calls made after Shutdown. A server can map
+// it to 503 so clients try another instance.
+func errShutdown() error {
+	return errors.ErrProviderUnavailable("", "router is shutting down")
+}
+
+// Shutdown shuts the router down gracefully, for servers that embed it. New
+// Complete, Stream, Embed, and EmbedBatch calls fail with a
+// provider_unavailable error, and so do new batches and batch polls. Shutdown
+// then waits for the calls in progress, including streams until they end or
+// are closed, and for the batch manager (see batch.Manager.Shutdown). Finally
+// it closes the providers' idle connections.
+//
+// If ctx ends first, Shutdown returns its error; like http.Server.Shutdown,
+// it does not cancel the calls still in progress. The router cannot be
+// restarted.
+func (r *Router) Shutdown(ctx context.Context) error {
+	var err error
+	select {
+	case <-r.inflight.close():
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	if batchErr := r.batch.Shutdown(ctx); err == nil {
+		err = batchErr
+	}
+	r.closeIdleConnections()
+	return err
+}
+
+// closeIdleConnections closes the idle connections of every provider client,
+// including the tenants' own.
+func (r *Router) closeIdleConnections() {
+	var clients []provider.Provider
+	r.mu.RLock()
+	for _, p := range r.providers {
+		clients = append(clients, p)
+	}
+	r.mu.RUnlock()
+
+	r.tenants.mu.Lock()
+	for _, t := range r.tenants.configs {
+		for _, p := range t.clients {
+			clients = append(clients, p)
+		}
+	}
+	r.tenants.mu.Unlock()
+
+	for _, p := range clients {
+		if c, ok := p.(provider.IdleCloser); ok {
+			c.CloseIdleConnections()
+		}
+	}
+}
+
+// inflightStream leaves the router's in-flight calls when the stream ends
+// or is closed.
+type inflightStream struct {
+	types.StreamReader
+	inflight *inflight
+	once     sync.Once
+}
+
+func (s *inflightStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	if err != nil || event == nil {
+		s.once.Do(s.inflight.leave)
+	}
+	return event, err
+}
+
+func (s *inflightStream) Close() error {
+	err := s.StreamReader.Close()
+	s.once.Do(s.inflight.leave)
+	return err
+}
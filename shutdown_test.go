@@ -0,0 +1,51 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestShutdown(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{{events: []*types.StreamEvent{textDelta("hi")}}}}
+	r := newTestRouter(t, fake)
+	req := &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-sonnet-4-20250514",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hi")},
+	}
+
+	stream, err := r.Stream(context.Background(), req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The open stream holds Shutdown until the deadline.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := r.Shutdown(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("shutdown err = %v, want deadline exceeded", err)
+	}
+
+	_, err = r.Complete(context.Background(), req)
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeProviderUnavailable {
+		t.Fatalf("complete err = %v, want provider unavailable", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- r.Shutdown(context.Background()) }()
+	select {
+	case <-done:
+		t.Fatal("shutdown returned before the stream was closed")
+	case <-time.After(10 * time.Millisecond):
+	}
+	stream.Close()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
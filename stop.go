@@ -0,0 +1,36 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// normalizeStopSequence makes the reported stop sequence's presence in the
+// response text consistent: appended with req.IncludeStopSequence and
+// stripped otherwise. Responses without a reported sequence are unchanged.
+func normalizeStopSequence(req *types.CompletionRequest, resp *types.CompletionResponse) {
+	seq := resp.StopSequence
+	if seq == "" {
+		return
+	}
+
+	last := -1
+	for i, block := range resp.Content {
+		if block.Type == types.ContentTypeText {
+			last = i
+		}
+	}
+
+	if req.IncludeStopSequence {
+		if last < 0 {
+			resp.Content = append(resp.Content, types.ContentBlock{Type: types.ContentTypeText, Text: seq})
+		} else if !strings.HasSuffix(resp.Content[last].Text, seq) {
+			resp.Content[last].Text += seq
+		}
+		return
+	}
+	if last >= 0 {
+		resp.Content[last].Text = strings.TrimSuffix(resp.Content[last].Text, seq)
+	}
+}
@@ -0,0 +1,36 @@
+package router
+
+import (
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestNormalizeStopSequence(t *testing.T) {
+	tests := []struct {
+		name    string
+		include bool
+		text    string
+		seq     string
+		want    string
+	}{
+		{"stripped by default", false, "Answer: 42", "END", "Answer: 42"},
+		{"echoed by provider", false, "Answer: 42END", "END", "Answer: 42"},
+		{"included", true, "Answer: 42", "END", "Answer: 42END"},
+		{"included once", true, "Answer: 42END", "END", "Answer: 42END"},
+		{"no reported sequence", true, "Answer: 42", "", "Answer: 42"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &types.CompletionResponse{
+				Content:      []types.ContentBlock{{Type: types.ContentTypeText, Text: tt.text}},
+				StopReason:   types.StopReasonStopSequence,
+				StopSequence: tt.seq,
+			}
+			normalizeStopSequence(&types.CompletionRequest{IncludeStopSequence: tt.include}, resp)
+			if got := resp.Text(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"slices"
+	"sort"
+	"sync/atomic"
+
+	"github.com/Chloe199719/agent-router/pkg/models"
+)
+
+// Strategy ranks the candidates for a request, most preferred first. The
+// candidates arrive sorted cheapest first; Rank may reorder the slice in
+// place and returns it.
+type Strategy interface {
+	Rank(candidates []Candidate, metrics *Metrics) []Candidate
+}
+
+// StrategyFunc adapts a function to the Strategy interface.
+type StrategyFunc func(candidates []Candidate, metrics *Metrics) []Candidate
+
+// Rank calls f(candidates, metrics).
+func (f StrategyFunc) Rank(candidates []Candidate, metrics *Metrics) []Candidate {
+	return f(candidates, metrics)
+}
+
+var (
+	// RouteCheapest prefers the lowest list price in the models catalog.
+	RouteCheapest Strategy = StrategyFunc(func(candidates []Candidate, _ *Metrics) []Candidate {
+		return candidates
+	})
+
+	// RouteFastestP95 prefers the lowest p95 latency recorded in the router's
+	// Metrics. Candidates without samples come first, cheapest first, so each
+	// gets measured.
+	RouteFastestP95 Strategy = StrategyFunc(rankFastestP95)
+
+	// RouteRoundRobin spreads requests evenly across the candidates. Its
+	// rotation is shared by every router and alias that uses it.
+	RouteRoundRobin Strategy = &roundRobin{}
+)
+
+func rankFastestP95(candidates []Candidate, metrics *Metrics) []Candidate {
+	p95 := make(map[Candidate]float64, len(candidates))
+	for _, c := range candidates {
+		if stats, ok := metrics.Stats(c.Provider, c.Model); ok {
+			p95[c] = float64(stats.P95)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		pi, iok := p95[candidates[i]]
+		pj, jok := p95[candidates[j]]
+		if iok != jok {
+			return !iok
+		}
+		return pi < pj
+	})
+	return candidates
+}
+
+type roundRobin struct {
+	next atomic.Uint64
+}
+
+func (rr *roundRobin) Rank(candidates []Candidate, _ *Metrics) []Candidate {
+	if len(candidates) == 0 {
+		return candidates
+	}
+	n := int((rr.next.Add(1) - 1) % uint64(len(candidates)))
+	return append(slices.Clone(candidates[n:]), candidates[:n]...)
+}
+
+// rank sorts candidates cheapest first, then applies the strategy.
+func rank(strategy Strategy, candidates []Candidate, metrics *Metrics) []Candidate {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if pa, pb := price(a.Info), price(b.Info); pa != pb {
+			return pa < pb
+		}
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		return a.Model < b.Model
+	})
+	return strategy.Rank(candidates, metrics)
+}
+
+// price is the blended list price used to rank candidates.
+func price(info models.Info) float64 {
+	return info.Pricing.Input + info.Pricing.Output
+}
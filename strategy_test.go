@@ -0,0 +1,153 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestMetrics_Stats(t *testing.T) {
+	m := newMetrics()
+	for i := 1; i <= 200; i++ {
+		m.Record(types.ProviderOpenAI, "gpt-4o", time.Duration(i)*time.Millisecond, nil)
+	}
+	m.Record(types.ProviderOpenAI, "gpt-4o", 0, stderrors.New("boom"))
+
+	stats, ok := m.Stats(types.ProviderOpenAI, "gpt-4o")
+	if !ok {
+		t.Fatal("no stats")
+	}
+	if stats.Requests != 201 || stats.Errors != 1 {
+		t.Errorf("requests = %d, errors = %d", stats.Requests, stats.Errors)
+	}
+	// Only the last 128 latencies (73ms..200ms) are kept.
+	if stats.P50 != 136*time.Millisecond || stats.P95 != 194*time.Millisecond {
+		t.Errorf("p50 = %v, p95 = %v", stats.P50, stats.P95)
+	}
+
+	if _, ok := m.Stats(types.ProviderOpenAI, "gpt-4o-mini"); ok {
+		t.Error("stats for unrecorded model")
+	}
+}
+
+func TestMetrics_Prediction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"id":"c1","model":"gpt-4o","choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}],"usage":{"prompt_tokens":10,"completion_tokens":30,"total_tokens":40,"completion_tokens_details":{"accepted_prediction_tokens":20,"rejected_prediction_tokens":4}}}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithOpenAI("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &types.CompletionRequest{
+		Provider:   types.ProviderOpenAI,
+		Model:      "gpt-4o",
+		Messages:   []types.Message{types.NewTextMessage(types.RoleUser, "Rename x")},
+		Prediction: "x := 0",
+	}
+	for range 2 {
+		if _, err := r.Complete(context.Background(), req); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	stats, _ := r.Metrics().Stats(types.ProviderOpenAI, "gpt-4o")
+	if stats.AcceptedPredictionTokens != 40 || stats.RejectedPredictionTokens != 8 {
+		t.Errorf("prediction stats = %d accepted, %d rejected", stats.AcceptedPredictionTokens, stats.RejectedPredictionTokens)
+	}
+}
+
+func TestStream_Stats(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{{
+		events: []*types.StreamEvent{{Type: types.StreamEventStart}, textDelta("Hel"), textDelta("lo"), {Type: types.StreamEventDone}},
+		resp:   &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: "Hello"}}},
+	}}}
+	r := newTestRouter(t, fake)
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-sonnet-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+	if _, err := readText(t, stream); err != nil {
+		t.Fatal(err)
+	}
+
+	got := stream.Response().StreamStats
+	if got == nil || got.Deltas != 2 || got.TimeToFirstToken <= 0 || got.Duration < got.TimeToFirstToken {
+		t.Errorf("stream stats = %+v", got)
+	}
+	stats, _ := r.Metrics().Stats(types.ProviderAnthropic, "claude-sonnet-4-5")
+	if stats.Streams != 1 || stats.StreamErrors != 0 || stats.TTFTP50 != got.TimeToFirstToken {
+		t.Errorf("metrics = %+v", stats)
+	}
+}
+
+func TestStrategies(t *testing.T) {
+	candidates := func() []Candidate {
+		return []Candidate{
+			{Provider: types.ProviderOpenAI, Model: "a"},
+			{Provider: types.ProviderOpenAI, Model: "b"},
+			{Provider: types.ProviderOpenAI, Model: "c"},
+		}
+	}
+
+	m := newMetrics()
+	m.Record(types.ProviderOpenAI, "a", 300*time.Millisecond, nil)
+	m.Record(types.ProviderOpenAI, "b", 100*time.Millisecond, nil)
+	if got := RouteFastestP95.Rank(candidates(), m); got[0].Model != "c" || got[1].Model != "b" || got[2].Model != "a" {
+		t.Errorf("fastest = %v, want unmeasured c first, then b, a", got)
+	}
+
+	rr := &roundRobin{}
+	var picks []string
+	for range 4 {
+		picks = append(picks, rr.Rank(candidates(), m)[0].Model)
+	}
+	if want := []string{"a", "b", "c", "a"}; !slices.Equal(picks, want) {
+		t.Errorf("round robin picks = %v, want %v", picks, want)
+	}
+}
+
+func TestModelAlias(t *testing.T) {
+	fake := &fakeProvider{}
+	r := newTestRouter(t, fake, WithModelAlias("chat", RouteFastestP95,
+		ModelTarget{Provider: types.ProviderOpenAI, Model: "gpt-4o"},
+		ModelTarget{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"},
+		ModelTarget{Provider: types.ProviderAnthropic, Model: "claude-sonnet-4-5"},
+	))
+	r.Metrics().Record(types.ProviderAnthropic, "claude-haiku-4-5", time.Second, nil)
+	r.Metrics().Record(types.ProviderAnthropic, "claude-sonnet-4-5", 200*time.Millisecond, nil)
+
+	req := &types.CompletionRequest{Model: "chat", Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")}}
+	routed, err := r.resolveAlias(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// OpenAI is not configured, so the faster Anthropic model wins.
+	if routed.Provider != types.ProviderAnthropic || routed.Model != "claude-sonnet-4-5" {
+		t.Errorf("routed to %s/%s", routed.Provider, routed.Model)
+	}
+	if req.Provider != "" || req.Model != "chat" {
+		t.Error("resolveAlias modified the caller's request")
+	}
+
+	if _, err := r.Complete(context.Background(), req); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := r.Metrics().Stats(types.ProviderAnthropic, "claude-sonnet-4-5"); stats.Requests != 2 {
+		t.Errorf("requests = %d, want the aliased call recorded", stats.Requests)
+	}
+}
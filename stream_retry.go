@@ -0,0 +1,213 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"strings"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// StreamRetryPolicy controls how streams that fail mid-response are resumed.
+//
+// When a stream breaks with a retryable error, the router re-issues the request
+// with the text received so far appended as a trailing assistant message and
+// keeps emitting deltas from the new stream as if nothing happened. This
+// needs a provider with FeaturePrefill (Anthropic), which continues the
+// message where it stopped. Other providers would repeat or restart the
+// text, so their streams are only retried if they fail before any text.
+//
+// Streams that have already started a tool call are not resumed, since a
+// partial tool call cannot be continued reliably.
+type StreamRetryPolicy struct {
+	// MaxRetries is the maximum number of times a single stream is resumed.
+	MaxRetries int
+
+	// Backoff is the delay before each resumption attempt.
+	Backoff time.Duration
+
+	// Retryable decides whether an error should trigger a resumption.
+	// Defaults to errors.IsRetryable plus transport errors (dropped connections).
+	Retryable func(err error) bool
+}
+
+// WithStreamRetry enables resuming streams that fail mid-response.
+func WithStreamRetry(policy StreamRetryPolicy) Option {
+	return func(r *Router) {
+		r.config.StreamRetry = &policy
+	}
+}
+
+// isRetryableStreamError is the default StreamRetryPolicy.Retryable.
+func isRetryableStreamError(err error) bool {
+	if err == nil || stderrors.Is(err, context.Canceled) || stderrors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) {
+		return errors.IsRetryable(rerr)
+	}
+	// Errors that are not RouterErrors come from reading the HTTP body,
+	// i.e. the connection dropped.
+	return true
+}
+
+// resumingStream wraps a provider stream and transparently resumes it after
+// retryable mid-stream failures.
+type resumingStream struct {
+	ctx    context.Context
+	p      provider.Provider
+	req    *types.CompletionRequest
+	policy StreamRetryPolicy
+
+	current types.StreamReader
+	retries int
+	prefill bool  // the provider continues a trailing assistant message
+	failed  error // set when resuming failed; returned by every later Next
+
+	// text is everything emitted so far; resumed is set once a retry happened.
+	text       strings.Builder
+	resumed    bool
+	inToolCall bool
+	started    bool
+	done       bool
+
+	// trimLeading is set when trailing whitespace was stripped from the
+	// continuation prefix, so the duplicate at the start of the resumed
+	// output can be dropped.
+	trimLeading bool
+}
+
+func newResumingStream(ctx context.Context, p provider.Provider, req *types.CompletionRequest, stream types.StreamReader, policy StreamRetryPolicy) *resumingStream {
+	if policy.Retryable == nil {
+		policy.Retryable = isRetryableStreamError
+	}
+	return &resumingStream{
+		ctx:     ctx,
+		p:       p,
+		req:     req,
+		policy:  policy,
+		current: stream,
+		prefill: p.SupportsFeature(types.FeaturePrefill),
+	}
+}
+
+// Next returns the next event, resuming the stream if it fails.
+func (s *resumingStream) Next() (*types.StreamEvent, error) {
+	if s.failed != nil {
+		return nil, s.failed
+	}
+	for {
+		event, err := s.current.Next()
+
+		failure := err
+		if failure == nil && event != nil && event.Type == types.StreamEventError {
+			failure = event.Error
+		}
+		if failure != nil {
+			if s.done || s.inToolCall || (!s.prefill && s.text.Len() > 0) || s.ctx.Err() != nil ||
+				s.retries >= s.policy.MaxRetries || !s.policy.Retryable(failure) {
+				return event, err
+			}
+			if rerr := s.resume(); rerr != nil {
+				s.failed = failure
+				return nil, failure
+			}
+			continue
+		}
+
+		if event == nil {
+			s.done = true
+			return nil, nil
+		}
+
+		switch event.Type {
+		case types.StreamEventStart:
+			if s.started {
+				continue
+			}
+			s.started = true
+		case types.StreamEventContentDelta:
+			if event.Delta != nil && event.Delta.Type == types.ContentTypeText {
+				if s.trimLeading {
+					event.Delta.Text = strings.TrimLeft(event.Delta.Text, " \t\n")
+					if event.Delta.Text == "" {
+						continue
+					}
+					s.trimLeading = false
+				}
+				s.text.WriteString(event.Delta.Text)
+			}
+		case types.StreamEventToolCallStart:
+			s.inToolCall = true
+		case types.StreamEventDone:
+			s.done = true
+		}
+
+		return event, nil
+	}
+}
+
+// resume closes the failed stream and opens a continuation. Opening it is
+// retried while the failures are retryable and retries remain.
+func (s *resumingStream) resume() error {
+	s.current.Close()
+
+	req := *s.req
+	req.Messages = append([]types.Message(nil), s.req.Messages...)
+
+	// Providers reject a trailing assistant message ending in whitespace.
+	prefix := strings.TrimRight(s.text.String(), " \t\n")
+	s.trimLeading = prefix != s.text.String()
+	if prefix != "" {
+		req.Messages = append(req.Messages, types.NewTextMessage(types.RoleAssistant, prefix))
+	}
+
+	for {
+		s.retries++
+		if s.policy.Backoff > 0 {
+			select {
+			case <-s.ctx.Done():
+				return s.ctx.Err()
+			case <-time.After(s.policy.Backoff):
+			}
+		}
+
+		stream, err := s.p.Stream(s.ctx, &req)
+		if err == nil {
+			s.current = stream
+			s.resumed = true
+			return nil
+		}
+		if s.ctx.Err() != nil || s.retries >= s.policy.MaxRetries || !s.policy.Retryable(err) {
+			return err
+		}
+	}
+}
+
+// Close closes the underlying stream.
+func (s *resumingStream) Close() error {
+	return s.current.Close()
+}
+
+// Response returns the accumulated response, including text from streams
+// that failed before the last resumption.
+func (s *resumingStream) Response() *types.CompletionResponse {
+	resp := s.current.Response()
+	if resp == nil || !s.resumed {
+		return resp
+	}
+
+	merged := *resp
+	merged.Content = make([]types.ContentBlock, 0, len(resp.Content)+1)
+	merged.Content = append(merged.Content, types.ContentBlock{Type: types.ContentTypeText, Text: s.text.String()})
+	for _, block := range resp.Content {
+		if block.Type != types.ContentTypeText {
+			merged.Content = append(merged.Content, block)
+		}
+	}
+	return &merged
+}
@@ -0,0 +1,181 @@
+package router
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestStreamRetry_ResumesAfterDisconnect(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{
+		{
+			events: []*types.StreamEvent{{Type: types.StreamEventStart}, textDelta("Hello, "), textDelta("wor")},
+			err:    io.ErrUnexpectedEOF,
+		},
+		{
+			events: []*types.StreamEvent{{Type: types.StreamEventStart}, textDelta("ld!"), {Type: types.StreamEventDone, StopReason: types.StopReasonEnd}},
+			resp: &types.CompletionResponse{
+				Content:    []types.ContentBlock{{Type: types.ContentTypeText, Text: "ld!"}},
+				StopReason: types.StopReasonEnd,
+			},
+		},
+	}}
+	r := newTestRouter(t, fake, WithStreamRetry(StreamRetryPolicy{MaxRetries: 1}))
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Greet the world")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	var text string
+	starts := 0
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if event == nil {
+			break
+		}
+		switch event.Type {
+		case types.StreamEventStart:
+			starts++
+		case types.StreamEventContentDelta:
+			text += event.Delta.Text
+		}
+	}
+
+	if text != "Hello, world!" {
+		t.Errorf("expected seamless text, got %q", text)
+	}
+	if starts != 1 {
+		t.Errorf("expected a single start event, got %d", starts)
+	}
+
+	if len(fake.requests) != 2 {
+		t.Fatalf("expected 2 requests, got %d", len(fake.requests))
+	}
+	resumed := fake.requests[1].Messages
+	last := resumed[len(resumed)-1]
+	if last.Role != types.RoleAssistant || last.Content[0].Text != "Hello, wor" {
+		t.Errorf("expected continuation prefix as assistant message, got %+v", last)
+	}
+	if len(fake.requests[0].Messages) != 1 {
+		t.Error("original request messages must not be modified")
+	}
+
+	if got := stream.Response().Text(); got != "Hello, world!" {
+		t.Errorf("expected merged response text, got %q", got)
+	}
+}
+
+func TestStreamRetry_DoesNotResumeToolCalls(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{
+		{
+			events: []*types.StreamEvent{{Type: types.StreamEventToolCallStart, ToolCall: &types.ToolCall{ID: "t1", Name: "f"}}},
+			err:    io.ErrUnexpectedEOF,
+		},
+	}}
+	r := newTestRouter(t, fake, WithStreamRetry(StreamRetryPolicy{MaxRetries: 3}))
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream.Next()
+	if _, err := stream.Next(); err != io.ErrUnexpectedEOF {
+		t.Errorf("expected original error, got %v", err)
+	}
+	if len(fake.requests) != 1 {
+		t.Errorf("expected no resumption, got %d requests", len(fake.requests))
+	}
+}
+
+func TestStreamRetry_NonRetryableError(t *testing.T) {
+	authErr := errors.ErrAuthentication(types.ProviderAnthropic, "bad key")
+	fake := &fakeProvider{streams: []*scriptedStream{{err: authErr}}}
+	r := newTestRouter(t, fake, WithStreamRetry(StreamRetryPolicy{MaxRetries: 3}))
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := stream.Next(); err != authErr {
+		t.Errorf("expected auth error, got %v", err)
+	}
+}
+
+func TestStreamRetry_RetriesFailedResume(t *testing.T) {
+	overloaded := errors.ErrOverloaded(types.ProviderAnthropic, "overloaded")
+	fake := &fakeProvider{
+		errs: []error{nil, overloaded},
+		streams: []*scriptedStream{
+			{events: []*types.StreamEvent{textDelta("Hel")}, err: io.ErrUnexpectedEOF},
+			{events: []*types.StreamEvent{textDelta("lo"), {Type: types.StreamEventDone}}},
+		},
+	}
+	r := newTestRouter(t, fake, WithStreamRetry(StreamRetryPolicy{MaxRetries: 2}))
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var text string
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event == nil {
+			break
+		}
+		if event.Type == types.StreamEventContentDelta {
+			text += event.Delta.Text
+		}
+	}
+	if text != "Hello" || len(fake.requests) != 3 {
+		t.Errorf("text = %q after %d requests, want Hello after 3", text, len(fake.requests))
+	}
+}
+
+func TestStreamRetry_WithoutPrefill(t *testing.T) {
+	newStream := func(fake *fakeProvider) types.StreamReader {
+		r := newTestRouter(t, fake, WithStreamRetry(StreamRetryPolicy{MaxRetries: 2}))
+		stream, err := r.Stream(context.Background(), &types.CompletionRequest{Provider: types.ProviderAnthropic, Model: "claude-haiku-4-5"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return stream
+	}
+
+	// Text already sent would be repeated, so the stream fails.
+	fake := &fakeProvider{noPrefill: true, streams: []*scriptedStream{
+		{events: []*types.StreamEvent{textDelta("Hel")}, err: io.ErrUnexpectedEOF},
+	}}
+	stream := newStream(fake)
+	stream.Next()
+	if _, err := stream.Next(); err != io.ErrUnexpectedEOF || len(fake.requests) != 1 {
+		t.Errorf("err = %v after %d requests, want the original error without a retry", err, len(fake.requests))
+	}
+
+	// Before any text, the request is simply sent again.
+	fake = &fakeProvider{noPrefill: true, streams: []*scriptedStream{
+		{events: []*types.StreamEvent{{Type: types.StreamEventStart}}, err: io.ErrUnexpectedEOF},
+		{events: []*types.StreamEvent{textDelta("Hello")}},
+	}}
+	stream = newStream(fake)
+	stream.Next()
+	event, err := stream.Next()
+	if err != nil || event.Delta.Text != "Hello" || len(fake.requests[1].Messages) != 0 {
+		t.Errorf("event = %+v, err = %v, want the retried stream's text", event, err)
+	}
+}
@@ -0,0 +1,238 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"strings"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/schema"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// structuredOutputTool is the tool the model calls with its result when
+// json_schema output is emulated with tool calling.
+const structuredOutputTool = "emit_result"
+
+// unsupportedStructuredOutput returns the unsupported feature error for a
+// json_schema request the provider or model cannot serve natively, or nil.
+func unsupportedStructuredOutput(p provider.Provider, req *types.CompletionRequest) *errors.RouterError {
+	rf := req.ResponseFormat
+	if rf == nil || rf.Type != "json_schema" || rf.Schema == nil {
+		return nil
+	}
+	if !p.SupportsFeature(types.FeatureStructuredOutput) {
+		return errors.ErrUnsupportedFeature(p.Name(), types.FeatureStructuredOutput)
+	}
+	if info, ok := models.Lookup(p.Name(), req.Model); ok && !info.StructuredOutput {
+		return errors.ErrModelUnsupportedFeature(p.Name(), req.Model, types.FeatureStructuredOutput)
+	}
+	return nil
+}
+
+// rejectsStructuredOutput reports whether err is a provider rejecting a
+// request's native json_schema output, as models that predate structured
+// outputs do.
+func rejectsStructuredOutput(req *types.CompletionRequest, err error) bool {
+	if req.ResponseFormat == nil || req.ResponseFormat.Type != "json_schema" || req.ResponseFormat.Schema == nil {
+		return false
+	}
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeInvalidRequest {
+		return false
+	}
+	msg := strings.ToLower(rerr.Message)
+	for _, hint := range []string{"output format", "output_config", "response_format", "json_schema"} {
+		if strings.Contains(msg, hint) {
+			return true
+		}
+	}
+	return false
+}
+
+// completeStructured emulates json_schema output. Models that can call tools
+// are made to call an emit_result tool whose parameters are the schema;
+// others are instructed to reply with JSON. Either way the result is
+// validated against the schema and returned as the response text, as native
+// structured output would be.
+func completeStructured(ctx context.Context, p provider.Provider, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	rf := req.ResponseFormat
+	emulated := *req
+	emulated.ResponseFormat = nil
+
+	useTool := p.SupportsFeature(types.FeatureTools)
+	if info, ok := models.Lookup(p.Name(), req.Model); ok && !info.Tools {
+		useTool = false
+	}
+
+	if useTool {
+		desc := "Return the final result. Call this exactly once with the complete result."
+		if rf.Description != "" {
+			desc += " The result is " + rf.Description
+		}
+		emulated.Tools = append(append([]types.Tool(nil), req.Tools...), types.Tool{
+			Name:        structuredOutputTool,
+			Description: desc,
+			Parameters:  *rf.Schema,
+		})
+		if len(req.Tools) == 0 {
+			emulated.ToolChoice = &types.ToolChoice{Type: types.ToolChoiceTool, Name: structuredOutputTool}
+		} else {
+			// The model may still need the caller's tools first.
+			emulated.ToolChoice = &types.ToolChoice{Type: types.ToolChoiceRequired}
+		}
+	} else {
+		schemaJSON, err := json.Marshal(rf.Schema)
+		if err != nil {
+			return nil, errors.ErrInvalidRequest("invalid response schema").WithCause(err)
+		}
+		instruction := "Respond only with a JSON value that conforms to this JSON Schema, with no other text:\n" + string(schemaJSON)
+		emulated.Messages = append([]types.Message{types.NewTextMessage(types.RoleSystem, instruction)}, req.Messages...)
+	}
+
+	resp, err := p.Complete(ctx, &emulated)
+	if err != nil {
+		return nil, err
+	}
+
+	var output string
+	if useTool {
+		call := findToolCall(resp.ToolCalls, structuredOutputTool)
+		if call == nil {
+			if resp.HasToolCalls() {
+				// The model called one of the caller's tools.
+				return resp, nil
+			}
+			return nil, errors.ErrServerError(p.Name(), "structured output fallback: model did not call "+structuredOutputTool)
+		}
+		if s, ok := call.Input.(string); ok {
+			output = schema.Repair(s)
+		} else {
+			data, err := json.Marshal(call.Input)
+			if err != nil {
+				return nil, errors.ErrServerError(p.Name(), "structured output fallback: invalid tool input").WithCause(err)
+			}
+			output = string(data)
+		}
+	} else {
+		output = schema.Repair(resp.Text())
+	}
+
+	if err := schema.Validate(rf.Schema, []byte(output)); err != nil {
+		return nil, errors.ErrServerError(p.Name(), fmt.Sprintf("structured output fallback: response does not match schema: %v", err)).WithCause(err)
+	}
+
+	resp.Content = []types.ContentBlock{{Type: types.ContentTypeText, Text: output}}
+	resp.ToolCalls = nil
+	if resp.StopReason == types.StopReasonToolUse {
+		resp.StopReason = types.StopReasonEnd
+	}
+	return resp, nil
+}
+
+func findToolCall(calls []types.ToolCall, name string) *types.ToolCall {
+	for i := range calls {
+		if calls[i].Name == name {
+			return &calls[i]
+		}
+	}
+	return nil
+}
+
+// WithJSONRepair repairs almost-valid JSON in responses to requests for json
+// or json_schema output: it strips markdown fences and surrounding prose, and
+// fixes trailing commas, unquoted keys, and single-quoted strings. The
+// repaired text is checked against the schema, if any; a response that
+// cannot be repaired fails with a retryable server_error instead of being
+// returned as invalid JSON. For streams, the accumulated response is repaired
+// when the stream is done.
+func WithJSONRepair() Option {
+	return func(r *Router) {
+		r.config.RepairJSON = true
+	}
+}
+
+// repairJSON applies WithJSONRepair to a response.
+func repairJSON(name types.Provider, req *types.CompletionRequest, resp *types.CompletionResponse) error {
+	rf := req.ResponseFormat
+	if rf == nil || (rf.Type != "json" && rf.Type != "json_schema") || resp.HasToolCalls() {
+		return nil
+	}
+
+	text := resp.Text()
+	check := func(text string) error {
+		if rf.Type == "json_schema" && rf.Schema != nil {
+			return schema.Validate(rf.Schema, []byte(text))
+		}
+		if !json.Valid([]byte(text)) {
+			return stderrors.New("invalid JSON")
+		}
+		return nil
+	}
+	if check(text) == nil {
+		return nil
+	}
+
+	repaired := schema.Repair(text)
+	if err := check(repaired); err != nil {
+		return errors.ErrServerError(name, fmt.Sprintf("structured output could not be repaired: %v", err)).WithCause(err)
+	}
+
+	// Replace the text blocks with the repaired text.
+	content := []types.ContentBlock{{Type: types.ContentTypeText, Text: repaired}}
+	for _, block := range resp.Content {
+		if block.Type != types.ContentTypeText {
+			content = append(content, block)
+		}
+	}
+	resp.Content = content
+	return nil
+}
+
+// repairingStream applies WithJSONRepair to a stream's accumulated response.
+// The response is checked when the stream is done, and one that cannot be
+// repaired fails the stream instead of being returned as invalid JSON. The
+// text deltas already read are not changed.
+type repairingStream struct {
+	types.StreamReader
+	name types.Provider
+	req  *types.CompletionRequest
+
+	checked bool
+	err     error
+}
+
+func (s *repairingStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	if err != nil {
+		return event, err
+	}
+	if event == nil || event.Type == types.StreamEventDone {
+		s.Response()
+		if s.err != nil {
+			return nil, s.err
+		}
+	}
+	return event, nil
+}
+
+// Response returns the accumulated response with its JSON repaired, or nil
+// if it could not be.
+func (s *repairingStream) Response() *types.CompletionResponse {
+	resp := s.StreamReader.Response()
+	if resp == nil {
+		return nil
+	}
+	if !s.checked {
+		s.checked = true
+		s.err = repairJSON(s.name, s.req, resp)
+	}
+	if s.err != nil {
+		return nil
+	}
+	return resp
+}
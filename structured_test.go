@@ -0,0 +1,206 @@
+package router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// legacyModelProvider rejects native json_schema output, like models that
+// predate structured outputs, and answers emulated requests with output.
+type legacyModelProvider struct {
+	fakeProvider
+	tools    bool
+	output   string
+	requests []*types.CompletionRequest
+}
+
+func (f *legacyModelProvider) SupportsFeature(feature types.Feature) bool {
+	return feature != types.FeatureTools || f.tools
+}
+func (f *legacyModelProvider) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	f.requests = append(f.requests, req)
+	if req.ResponseFormat != nil {
+		return nil, errors.ErrInvalidRequest("'claude-legacy' does not support output format").WithProvider(f.Name())
+	}
+	if f.tools {
+		call := types.ToolCall{ID: "toolu_1", Name: req.ToolChoice.Name, Input: f.output}
+		return &types.CompletionResponse{ToolCalls: []types.ToolCall{call}, StopReason: types.StopReasonToolUse}, nil
+	}
+	return &types.CompletionResponse{
+		Content:    []types.ContentBlock{{Type: types.ContentTypeText, Text: "```json\n" + f.output + "\n```"}},
+		StopReason: types.StopReasonEnd,
+	}, nil
+}
+
+func personRequest() *types.CompletionRequest {
+	return (&types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-legacy",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Extract: John Smith is 42 years old.")},
+	}).WithJSONSchema("person_info", types.JSONSchema{
+		Type: "object",
+		Properties: map[string]types.JSONSchema{
+			"name": {Type: "string"},
+			"age":  {Type: "integer"},
+		},
+		Required: []string{"name", "age"},
+	})
+}
+
+func TestStructuredFallback_ToolCall(t *testing.T) {
+	fake := &legacyModelProvider{tools: true, output: `{"name":"John Smith","age":42}`}
+	r := newTestRouter(t, fake, WithUnsupportedFeaturePolicy(PolicyFallback))
+
+	resp, err := r.Complete(context.Background(), personRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != fake.output || resp.HasToolCalls() || resp.StopReason != types.StopReasonEnd {
+		t.Errorf("response = %q, tool calls %v, stop %s", resp.Text(), resp.ToolCalls, resp.StopReason)
+	}
+
+	emulated := fake.requests[1]
+	if emulated.ResponseFormat != nil || len(emulated.Tools) != 1 || emulated.Tools[0].Name != structuredOutputTool {
+		t.Errorf("emulated request has format %v and tools %v", emulated.ResponseFormat, emulated.Tools)
+	}
+	if emulated.ToolChoice == nil || emulated.ToolChoice.Type != types.ToolChoiceTool {
+		t.Errorf("tool choice = %+v, want forced %s", emulated.ToolChoice, structuredOutputTool)
+	}
+}
+
+func TestStructuredFallback_Prompt(t *testing.T) {
+	fake := &legacyModelProvider{output: `{"name":"John Smith","age":42}`}
+	r := newTestRouter(t, fake, WithUnsupportedFeaturePolicy(PolicyFallback))
+
+	resp, err := r.Complete(context.Background(), personRequest())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text() != fake.output {
+		t.Errorf("text = %q, want the JSON without its code fence", resp.Text())
+	}
+	if msgs := fake.requests[1].Messages; len(msgs) != 2 || msgs[0].Role != types.RoleSystem {
+		t.Errorf("expected a JSON instruction system message, got %+v", msgs)
+	}
+}
+
+func TestStructuredFallback_InvalidOutput(t *testing.T) {
+	fake := &legacyModelProvider{tools: true, output: `{"name":"John Smith"}`}
+	r := newTestRouter(t, fake, WithUnsupportedFeaturePolicy(PolicyFallback))
+
+	_, err := r.Complete(context.Background(), personRequest())
+	if err == nil || !errors.IsRetryable(err) {
+		t.Errorf("err = %v, want a retryable schema mismatch", err)
+	}
+}
+
+func TestStructuredFallback_PolicyError(t *testing.T) {
+	fake := &legacyModelProvider{tools: true}
+	r := newTestRouter(t, fake, WithUnsupportedFeaturePolicy(PolicyError))
+
+	if _, err := r.Complete(context.Background(), personRequest()); err == nil {
+		t.Fatal("expected the provider's rejection")
+	}
+	if len(fake.requests) != 1 {
+		t.Errorf("sent %d requests, want no fallback", len(fake.requests))
+	}
+}
+
+// textProvider answers every request with fixed text.
+type textProvider struct {
+	fakeProvider
+	text string
+}
+
+func (f *textProvider) Complete(_ context.Context, req *types.CompletionRequest) (*types.CompletionResponse, error) {
+	return &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: f.text}}}, nil
+}
+
+func TestJSONRepair(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"valid", `{"name":"Ann","age":42}`, `{"name":"Ann","age":42}`, false},
+		{"fenced", "```json\n{\"name\": \"Ann\", \"age\": 42,}\n```", `{"name": "Ann", "age": 42}`, false},
+		{"unrepairable", `{"name": "Ann"}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &textProvider{text: tt.text}
+			r := newTestRouter(t, fake, WithJSONRepair())
+			resp, err := r.Complete(context.Background(), personRequest())
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got %q", resp.Text())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text() != tt.want {
+				t.Errorf("text = %q, want %q", resp.Text(), tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONRepair_Stream(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{"valid", `{"name":"Ann","age":42}`, `{"name":"Ann","age":42}`, false},
+		{"fenced", "```json\n{\"name\": \"Ann\", \"age\": 42,}\n```", `{"name": "Ann", "age": 42}`, false},
+		{"unrepairable", `{"name": "Ann"}`, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeProvider{streams: []*scriptedStream{{
+				events: []*types.StreamEvent{textDelta(tt.text), {Type: types.StreamEventDone, StopReason: types.StopReasonEnd}},
+				resp:   &types.CompletionResponse{Content: []types.ContentBlock{{Type: types.ContentTypeText, Text: tt.text}}},
+			}}}
+			r := newTestRouter(t, fake, WithJSONRepair())
+
+			req := personRequest()
+			req.Stream = true
+			stream, err := r.Stream(context.Background(), req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer stream.Close()
+
+			var streamErr error
+			for {
+				event, err := stream.Next()
+				if err != nil {
+					streamErr = err
+					break
+				}
+				if event == nil {
+					break
+				}
+			}
+			if tt.wantErr {
+				if streamErr == nil {
+					t.Errorf("expected an error, got %q", stream.Response().Text())
+				}
+				return
+			}
+			if streamErr != nil {
+				t.Fatal(streamErr)
+			}
+			if got := stream.Response().Text(); got != tt.want {
+				t.Errorf("text = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package router
+
+import (
+	"sync"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// Tee splits a stream into n streams that each receive every event, so one
+// model stream can feed several consumers, such as a UI, a transcript logger,
+// and a guardrail scanner. The branches may be read at different speeds and
+// from different goroutines; events not yet read by a branch are buffered
+// for it. Events are shared between branches and must not be modified.
+//
+// Closing a branch stops buffering for it; the upstream stream is closed
+// when every branch is closed. Response returns the upstream response.
+func Tee(stream types.StreamReader, n int) []types.StreamReader {
+	t := &tee{upstream: stream, queues: make([][]*types.StreamEvent, n), closed: make([]bool, n), open: n}
+	branches := make([]types.StreamReader, n)
+	for i := range branches {
+		branches[i] = &teeBranch{tee: t, index: i}
+	}
+	return branches
+}
+
+// tee reads the upstream stream on behalf of whichever branch needs the
+// next event and queues it for the others.
+type tee struct {
+	upstream types.StreamReader
+	readMu   sync.Mutex // serializes upstream reads
+
+	mu     sync.Mutex
+	queues [][]*types.StreamEvent
+	closed []bool
+	open   int
+	done   bool
+	err    error
+}
+
+type teeBranch struct {
+	tee   *tee
+	index int
+}
+
+func (b *teeBranch) Next() (*types.StreamEvent, error) {
+	t := b.tee
+	for {
+		if event, ok, err := t.pop(b.index); ok {
+			return event, err
+		}
+
+		t.readMu.Lock()
+		// Another branch may have read while this one waited.
+		t.mu.Lock()
+		ready := len(t.queues[b.index]) > 0 || t.done
+		t.mu.Unlock()
+		if !ready {
+			event, err := t.upstream.Next()
+			t.mu.Lock()
+			if event == nil || err != nil {
+				t.done, t.err = true, err
+			} else {
+				for i := range t.queues {
+					if !t.closed[i] {
+						t.queues[i] = append(t.queues[i], event)
+					}
+				}
+			}
+			t.mu.Unlock()
+		}
+		t.readMu.Unlock()
+	}
+}
+
+// pop returns the branch's next queued event, or the end of the stream once
+// its queue is drained. ok is false if the branch must wait for upstream.
+func (t *tee) pop(i int) (event *types.StreamEvent, ok bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.closed[i] {
+		return nil, true, nil
+	}
+	if q := t.queues[i]; len(q) > 0 {
+		t.queues[i] = q[1:]
+		return q[0], true, nil
+	}
+	if t.done {
+		return nil, true, t.err
+	}
+	return nil, false, nil
+}
+
+func (b *teeBranch) Close() error {
+	t := b.tee
+	t.mu.Lock()
+	if t.closed[b.index] {
+		t.mu.Unlock()
+		return nil
+	}
+	t.closed[b.index] = true
+	t.queues[b.index] = nil
+	t.open--
+	last := t.open == 0
+	t.mu.Unlock()
+
+	if last {
+		return t.upstream.Close()
+	}
+	return nil
+}
+
+func (b *teeBranch) Response() *types.CompletionResponse {
+	return b.tee.upstream.Response()
+}
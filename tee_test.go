@@ -0,0 +1,94 @@
+package router
+
+import (
+	stderrors "errors"
+	"sync"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// closeCountingStream counts Close calls on a scripted stream.
+type closeCountingStream struct {
+	scriptedStream
+	closes int
+}
+
+func (s *closeCountingStream) Close() error {
+	s.closes++
+	return nil
+}
+
+func readText(t *testing.T, stream types.StreamReader) (string, error) {
+	t.Helper()
+	var text string
+	for {
+		event, err := stream.Next()
+		if err != nil || event == nil {
+			return text, err
+		}
+		if event.Delta != nil {
+			text += event.Delta.Text
+		}
+	}
+}
+
+func TestTee(t *testing.T) {
+	upstream := &closeCountingStream{scriptedStream: scriptedStream{
+		events: []*types.StreamEvent{textDelta("Hello"), textDelta(", "), textDelta("world")},
+	}}
+	branches := Tee(upstream, 3)
+
+	var wg sync.WaitGroup
+	texts := make([]string, len(branches))
+	for i, branch := range branches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			texts[i], _ = readText(t, branch)
+		}()
+	}
+	wg.Wait()
+
+	for i, text := range texts {
+		if text != "Hello, world" {
+			t.Errorf("branch %d read %q", i, text)
+		}
+	}
+
+	for _, branch := range branches[:2] {
+		branch.Close()
+	}
+	if upstream.closes != 0 {
+		t.Error("upstream closed while a branch is open")
+	}
+	branches[2].Close()
+	branches[2].Close()
+	if upstream.closes != 1 {
+		t.Errorf("upstream closed %d times, want 1", upstream.closes)
+	}
+}
+
+func TestTee_Error(t *testing.T) {
+	failure := stderrors.New("connection reset")
+	branches := Tee(&scriptedStream{events: []*types.StreamEvent{textDelta("Hi")}, err: failure}, 2)
+
+	for i, branch := range branches {
+		text, err := readText(t, branch)
+		if text != "Hi" || err != failure {
+			t.Errorf("branch %d = %q, %v; want the event then the error", i, text, err)
+		}
+	}
+}
+
+func TestTee_ClosedBranchStopsBuffering(t *testing.T) {
+	branches := Tee(&scriptedStream{events: []*types.StreamEvent{textDelta("a"), textDelta("b")}}, 2)
+	branches[1].Close()
+
+	if text, err := readText(t, branches[0]); text != "ab" || err != nil {
+		t.Errorf("open branch = %q, %v", text, err)
+	}
+	if event, err := branches[1].Next(); event != nil || err != nil {
+		t.Errorf("closed branch returned %v, %v", event, err)
+	}
+}
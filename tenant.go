@@ -0,0 +1,279 @@
+package router
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/models"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// TenantConfig configures a tenant of the router.
+type TenantConfig struct {
+	// RequestsPerMinute limits the tenant's request rate, allowing bursts of
+	// up to that many requests. Zero is unlimited.
+	RequestsPerMinute int
+
+	// APIKeys overrides provider API keys for the tenant's requests. Other
+	// provider options are shared with the router's client; providers without
+	// an entry use the router's key.
+	APIKeys map[types.Provider]string
+}
+
+// TenantUsage is the usage aggregated for a tenant.
+type TenantUsage struct {
+	Requests     int64
+	Errors       int64
+	InputTokens  int64
+	OutputTokens int64
+
+	// Cost is the list price in USD of the tenant's cataloged requests.
+	Cost float64
+}
+
+// WithTenant configures a tenant, identified by CompletionRequest.TenantID.
+// Usage is aggregated for every tenant ID, configured or not; requests for
+// unconfigured tenants are not rate limited and use the router's keys.
+func WithTenant(id string, cfg TenantConfig) Option {
+	return func(r *Router) {
+		r.tenants.configure(id, cfg)
+	}
+}
+
+// TenantUsage returns the usage aggregated for a tenant.
+func (r *Router) TenantUsage(id string) TenantUsage {
+	r.tenants.mu.Lock()
+	defer r.tenants.mu.Unlock()
+	if u := r.tenants.usage[id]; u != nil {
+		return *u
+	}
+	return TenantUsage{}
+}
+
+// WithUsageTags aggregates usage by the values of the given
+// CompletionRequest.Metadata keys, for attribution to features, teams, or
+// end users. Requests without a key are not counted for it.
+func WithUsageTags(keys ...string) Option {
+	return func(r *Router) {
+		r.tenants.tagKeys = append(r.tenants.tagKeys, keys...)
+	}
+}
+
+// TagUsage returns the usage aggregated for the requests whose metadata key
+// has the given value. The key must be one passed to WithUsageTags.
+func (r *Router) TagUsage(key, value string) TenantUsage {
+	r.tenants.mu.Lock()
+	defer r.tenants.mu.Unlock()
+	if u := r.tenants.tagUsage[usageTag{key, value}]; u != nil {
+		return *u
+	}
+	return TenantUsage{}
+}
+
+type tenants struct {
+	mu      sync.Mutex
+	configs map[string]*tenant
+	usage   map[string]*TenantUsage
+
+	tagKeys  []string
+	tagUsage map[usageTag]*TenantUsage
+}
+
+// usageTag is a metadata key and value that usage is aggregated by.
+type usageTag struct {
+	key, value string
+}
+
+type tenant struct {
+	cfg     TenantConfig
+	tokens  float64
+	updated time.Time
+	clients map[types.Provider]provider.Provider
+}
+
+func newTenants() *tenants {
+	return &tenants{
+		configs:  make(map[string]*tenant),
+		usage:    make(map[string]*TenantUsage),
+		tagUsage: make(map[usageTag]*TenantUsage),
+	}
+}
+
+func (ts *tenants) configure(id string, cfg TenantConfig) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	ts.configs[id] = &tenant{
+		cfg:     cfg,
+		tokens:  float64(cfg.RequestsPerMinute),
+		updated: time.Now(),
+		clients: make(map[types.Provider]provider.Provider),
+	}
+}
+
+// allow takes a token from the tenant's rate limit bucket.
+func (ts *tenants) allow(id string) error {
+	if id == "" {
+		return nil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	t := ts.configs[id]
+	if t == nil || t.cfg.RequestsPerMinute <= 0 {
+		return nil
+	}
+
+	limit := float64(t.cfg.RequestsPerMinute)
+	now := time.Now()
+	t.tokens = min(limit, t.tokens+now.Sub(t.updated).Minutes()*limit)
+	t.updated = now
+	if t.tokens < 1 {
+		return errors.ErrRateLimit("", fmt.Sprintf("tenant %q exceeded %d requests per minute", id, t.cfg.RequestsPerMinute))
+	}
+	t.tokens--
+	return nil
+}
+
+// client returns the tenant's client for a provider, building it from the
+// router's factory on first use. It returns nil if the tenant has no key for
+// the provider.
+func (ts *tenants) client(id string, name types.Provider, f *providerFactory) provider.Provider {
+	if id == "" || f == nil {
+		return nil
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	t := ts.configs[id]
+	if t == nil {
+		return nil
+	}
+	key, ok := t.cfg.APIKeys[name]
+	if !ok {
+		return nil
+	}
+	if p, ok := t.clients[name]; ok {
+		return p
+	}
+
+	opts := make([]provider.Option, 0, len(f.opts)+1)
+	opts = append(opts, f.opts...)
+	opts = append(opts, provider.WithAPIKey(key))
+	p := f.build(opts...)
+	t.clients[name] = p
+	return p
+}
+
+// invalidate drops the tenants' clients for a provider so they are rebuilt
+// with its new options.
+func (ts *tenants) invalidate(name types.Provider) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+	for _, t := range ts.configs {
+		delete(t.clients, name)
+	}
+}
+
+// tracks reports whether a request's usage is aggregated, for its tenant or
+// its usage tags.
+func (ts *tenants) tracks(req *types.CompletionRequest) bool {
+	if req.TenantID != "" {
+		return true
+	}
+	for _, key := range ts.tagKeys {
+		if _, ok := req.Metadata[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// record adds a request outcome to the usage of the request's tenant and
+// usage tags.
+func (ts *tenants) record(req *types.CompletionRequest, providerName types.Provider, usage *types.Usage, err error) {
+	if !ts.tracks(req) {
+		return
+	}
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if req.TenantID != "" {
+		u := ts.usage[req.TenantID]
+		if u == nil {
+			u = &TenantUsage{}
+			ts.usage[req.TenantID] = u
+		}
+		u.add(providerName, req.Model, usage, err)
+	}
+	for _, key := range ts.tagKeys {
+		value, ok := req.Metadata[key]
+		if !ok {
+			continue
+		}
+		tag := usageTag{key, value}
+		u := ts.tagUsage[tag]
+		if u == nil {
+			u = &TenantUsage{}
+			ts.tagUsage[tag] = u
+		}
+		u.add(providerName, req.Model, usage, err)
+	}
+}
+
+// add adds a request outcome to u.
+func (u *TenantUsage) add(providerName types.Provider, model string, usage *types.Usage, err error) {
+	u.Requests++
+	if err != nil {
+		u.Errors++
+		return
+	}
+	if usage == nil {
+		return
+	}
+	u.InputTokens += int64(usage.InputTokens)
+	u.OutputTokens += int64(usage.OutputTokens)
+	u.Cost += models.Cost(providerName, model, *usage)
+}
+
+// providerFor returns the client for a request: the tenant's own client if it
+// has a key for the provider, otherwise the router's.
+func (r *Router) providerFor(req *types.CompletionRequest) (provider.Provider, error) {
+	if req.TenantID != "" {
+		r.mu.RLock()
+		f := r.factories[req.Provider]
+		_, configured := r.providers[req.Provider]
+		r.mu.RUnlock()
+		if configured {
+			if p := r.tenants.client(req.TenantID, req.Provider, f); p != nil {
+				return p, nil
+			}
+		}
+	}
+	return r.getProvider(req.Provider)
+}
+
+// tenantStream records a stream's usage for its tenant and usage tags when
+// the stream finishes.
+type tenantStream struct {
+	types.StreamReader
+	tenants  *tenants
+	req      *types.CompletionRequest
+	provider types.Provider
+	once     sync.Once
+}
+
+func (s *tenantStream) Next() (*types.StreamEvent, error) {
+	event, err := s.StreamReader.Next()
+	switch {
+	case err != nil:
+		s.once.Do(func() { s.tenants.record(s.req, s.provider, nil, err) })
+	case event == nil:
+		s.once.Do(func() { s.tenants.record(s.req, s.provider, nil, nil) })
+	case event.Type == types.StreamEventDone:
+		s.once.Do(func() { s.tenants.record(s.req, s.provider, event.Usage, nil) })
+	}
+	return event, err
+}
@@ -0,0 +1,113 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestTenants(t *testing.T) {
+	var keys []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("x-api-key"))
+		fmt.Fprint(w, `{"id":"msg_1","type":"message","role":"assistant","model":"claude-haiku-4-5","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn","usage":{"input_tokens":100,"output_tokens":10}}`)
+	}))
+	defer srv.Close()
+
+	r, err := New(
+		WithAnthropic("router-key", provider.WithBaseURL(srv.URL)),
+		WithTenant("acme", TenantConfig{RequestsPerMinute: 2, APIKeys: map[types.Provider]string{types.ProviderAnthropic: "acme-key"}}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	request := func(tenant string) error {
+		_, err := r.Complete(context.Background(), &types.CompletionRequest{
+			Provider: types.ProviderAnthropic,
+			Model:    "claude-haiku-4-5",
+			TenantID: tenant,
+			Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+		})
+		return err
+	}
+
+	for _, tenant := range []string{"acme", "acme", "other", ""} {
+		if err := request(tenant); err != nil {
+			t.Fatalf("tenant %q: %v", tenant, err)
+		}
+	}
+	if want := []string{"acme-key", "acme-key", "router-key", "router-key"}; fmt.Sprint(keys) != fmt.Sprint(want) {
+		t.Errorf("keys = %v, want %v", keys, want)
+	}
+
+	err = request("acme")
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeRateLimit {
+		t.Errorf("err = %v, want rate limit", err)
+	}
+
+	usage := r.TenantUsage("acme")
+	if usage.Requests != 2 || usage.InputTokens != 200 || usage.OutputTokens != 20 || usage.Cost == 0 {
+		t.Errorf("usage = %+v", usage)
+	}
+	if other := r.TenantUsage("other"); other.Requests != 1 {
+		t.Errorf("other usage = %+v", other)
+	}
+}
+
+func TestUsageTags(t *testing.T) {
+	fake := &fakeProvider{streams: []*scriptedStream{{
+		events: []*types.StreamEvent{textDelta("ok"), {Type: types.StreamEventDone, Usage: &types.Usage{InputTokens: 5, OutputTokens: 2}}},
+	}}}
+	r := newTestRouter(t, fake, WithUsageTags("feature", "user_id"))
+
+	request := func(metadata map[string]string) *types.CompletionRequest {
+		return &types.CompletionRequest{
+			Provider: types.ProviderAnthropic,
+			Model:    "claude-haiku-4-5",
+			Messages: []types.Message{types.NewTextMessage(types.RoleUser, "hi")},
+			Metadata: metadata,
+		}
+	}
+	for _, metadata := range []map[string]string{
+		{"feature": "search", "user_id": "u1"},
+		{"feature": "chat"},
+		{"team": "infra"},
+	} {
+		if _, err := r.Complete(context.Background(), request(metadata)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream, err := r.Stream(context.Background(), request(map[string]string{"feature": "search"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for event, err := stream.Next(); event != nil || err != nil; event, err = stream.Next() {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream.Close()
+
+	if u := r.TagUsage("feature", "search"); u.Requests != 2 || u.InputTokens != 5 || u.OutputTokens != 2 {
+		t.Errorf("search usage = %+v", u)
+	}
+	if u := r.TagUsage("feature", "chat"); u.Requests != 1 {
+		t.Errorf("chat usage = %+v", u)
+	}
+	if u := r.TagUsage("user_id", "u1"); u.Requests != 1 {
+		t.Errorf("user usage = %+v", u)
+	}
+	// Only the configured keys are aggregated.
+	if u := r.TagUsage("team", "infra"); u.Requests != 0 {
+		t.Errorf("untagged usage = %+v", u)
+	}
+}
@@ -9,6 +9,7 @@
 //   - GOOGLE_API_KEY (optional)
 //   - OPENAI_THINKING_MODEL — model for reasoning/thinking tests (default: gpt-5-mini)
 //   - VERTEX_BATCH_WAIT_RESULTS=1 — run slow Vertex batch GetResults / RequestLabels checks (optional)
+//   - AGENT_ROUTER_VCR=record|replay|auto — record to / replay from testdata/cassettes (optional)
 //
 //go:build integration
 
@@ -19,6 +20,7 @@ import (
 	"encoding/json"
 	"errors"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -30,6 +32,7 @@ import (
 	routererrors "github.com/Chloe199719/agent-router/pkg/errors"
 	"github.com/Chloe199719/agent-router/pkg/provider"
 	"github.com/Chloe199719/agent-router/pkg/types"
+	"github.com/Chloe199719/agent-router/pkg/vcr"
 )
 
 func init() {
@@ -52,20 +55,38 @@ const (
 	openAIThinkingModelDefault = "gpt-5-mini"
 )
 
-// getRouter creates a router with available providers
+// getRouter creates a router with available providers.
+//
+// When AGENT_ROUTER_VCR is set, provider traffic goes through a vcr.Recorder
+// using testdata/cassettes/<TestName>.json. In replay mode, providers without
+// keys are configured with a placeholder so recorded tests run without credentials.
 func getRouter(t *testing.T) *router.Router {
 	t.Helper()
-
 	var opts []router.Option
 
-	if key := os.Getenv("OPENAI_API_KEY"); key != "" {
-		opts = append(opts, router.WithOpenAI(key))
+	rec := newRecorder(t)
+	apiKey := func(env string) string {
+		if key := os.Getenv(env); key != "" {
+			return key
+		}
+		if rec != nil && rec.Mode() == vcr.ModeReplay {
+			return "vcr-replay"
+		}
+		return ""
 	}
-	if key := os.Getenv("ANTHROPIC_API_KEY"); key != "" {
-		opts = append(opts, router.WithAnthropic(key))
+	var httpOpts []provider.Option
+	if rec != nil {
+		httpOpts = append(httpOpts, provider.WithHTTPClient(rec.Client()))
 	}
-	if key := os.Getenv("GOOGLE_API_KEY"); key != "" {
-		opts = append(opts, router.WithGoogle(key))
+
+	if key := apiKey("OPENAI_API_KEY"); key != "" {
+		opts = append(opts, router.WithOpenAI(key, httpOpts...))
+	}
+	if key := apiKey("ANTHROPIC_API_KEY"); key != "" {
+		opts = append(opts, router.WithAnthropic(key, httpOpts...))
+	}
+	if key := apiKey("GOOGLE_API_KEY"); key != "" {
+		opts = append(opts, router.WithGoogle(key, httpOpts...))
 	}
 
 	// Vertex AI requires project ID, location, and an access token
@@ -74,7 +95,7 @@ func getRouter(t *testing.T) *router.Router {
 		if location == "" {
 			location = "global"
 		}
-		var vertexOpts []provider.Option
+		vertexOpts := append([]provider.Option{}, httpOpts...)
 		if token := os.Getenv("VERTEX_ACCESS_TOKEN"); token != "" {
 			vertexOpts = append(vertexOpts, provider.WithAccessToken(token))
 		}
@@ -96,6 +117,32 @@ func getRouter(t *testing.T) *router.Router {
 	return r
 }
 
+// newRecorder returns the test's VCR recorder, or nil when AGENT_ROUTER_VCR is unset.
+// Tests without a recorded cassette fail in replay mode.
+func newRecorder(t *testing.T) *vcr.Recorder {
+	t.Helper()
+	mode := vcr.ModeFromEnv()
+	if mode == vcr.ModePassthrough {
+		return nil
+	}
+
+	path := filepath.Join("testdata", "cassettes", strings.ReplaceAll(t.Name(), "/", "_")+".json")
+	rec, err := vcr.New(path, mode)
+	if errors.Is(err, vcr.ErrCassetteNotFound) {
+		t.Fatalf("No cassette recorded for %s; record it with make test-record", t.Name())
+	}
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	t.Cleanup(func() {
+		if err := rec.Stop(); err != nil {
+			t.Errorf("Failed to save cassette: %v", err)
+		}
+	})
+	return rec
+}
+
 func hasProvider(r *router.Router, p types.Provider) bool {
 	for _, provider := range r.Providers() {
 		if provider == p {
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// WithStreamIdleTimeout sets the default idle timeout for streams: a stream
+// that produces no event for this long is aborted with a timeout error.
+// CompletionRequest.StreamIdleTimeout overrides it per request.
+func WithStreamIdleTimeout(d time.Duration) Option {
+	return func(r *Router) {
+		r.config.StreamIdleTimeout = d
+	}
+}
+
+// timeoutError converts a failure caused by an expired deadline into a
+// timeout error; other errors, including timeout errors the provider
+// already classified, are returned unchanged.
+func timeoutError(ctx context.Context, providerName types.Provider, err error) error {
+	if err == nil || !stderrors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+	var rerr *errors.RouterError
+	if stderrors.As(err, &rerr) && rerr.Code == errors.ErrCodeTimeout {
+		return err
+	}
+	return errors.ErrTimeout(providerName).WithCause(err)
+}
+
+// timeoutStream enforces CompletionRequest.Timeout and the stream idle timeout.
+// Both work by cancelling the request context, which unblocks the provider's
+// body read.
+type timeoutStream struct {
+	types.StreamReader
+	ctx      context.Context
+	cancel   context.CancelFunc
+	provider types.Provider
+	idle     time.Duration
+
+	idleFired atomic.Bool
+	closeOnce sync.Once
+}
+
+func newTimeoutStream(ctx context.Context, cancel context.CancelFunc, stream types.StreamReader, providerName types.Provider, idle time.Duration) *timeoutStream {
+	return &timeoutStream{
+		StreamReader: stream,
+		ctx:          ctx,
+		cancel:       cancel,
+		provider:     providerName,
+		idle:         idle,
+	}
+}
+
+// Next returns the next event, failing with a timeout error if the deadline
+// passes or the stream stays idle too long.
+func (s *timeoutStream) Next() (*types.StreamEvent, error) {
+	if s.idle > 0 {
+		timer := time.AfterFunc(s.idle, func() {
+			s.idleFired.Store(true)
+			s.cancel()
+		})
+		defer timer.Stop()
+	}
+
+	event, err := s.StreamReader.Next()
+
+	failure := err
+	if failure == nil && event != nil && event.Type == types.StreamEventError {
+		failure = event.Error
+	}
+	if failure != nil {
+		if s.idleFired.Load() {
+			return nil, errors.NewError(errors.ErrCodeTimeout, "stream idle timeout exceeded").
+				WithProvider(s.provider).WithCause(failure)
+		}
+		if timeoutErr := timeoutError(s.ctx, s.provider, failure); timeoutErr != failure {
+			return nil, timeoutErr
+		}
+	}
+	return event, err
+}
+
+// Close closes the stream and releases the request context.
+func (s *timeoutStream) Close() error {
+	var err error
+	s.closeOnce.Do(func() {
+		err = s.StreamReader.Close()
+		s.cancel()
+	})
+	return err
+}
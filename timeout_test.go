@@ -0,0 +1,132 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// newStallingServer stalls until the client goes away. Streaming requests get
+// the start of an Anthropic stream first.
+func newStallingServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+
+		if body.Stream {
+			w.Header().Set("Content-Type", "text/event-stream")
+			io.WriteString(w, "event: message_start\ndata: {\"type\":\"message_start\",\"message\":{\"id\":\"msg_1\"}}\n\n")
+			w.(http.Flusher).Flush()
+		}
+		<-r.Context().Done()
+	}))
+}
+
+func TestStream_IdleTimeout(t *testing.T) {
+	server := newStallingServer(t)
+	defer server.Close()
+
+	r, err := New(WithAnthropic("test-key", provider.WithBaseURL(server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{
+		Provider:          types.ProviderAnthropic,
+		Model:             "claude-haiku-4-5",
+		StreamIdleTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	if event, err := stream.Next(); err != nil || event.Type != types.StreamEventStart {
+		t.Fatalf("expected start event, got %v, %v", event, err)
+	}
+
+	start := time.Now()
+	_, err = stream.Next()
+	if err == nil {
+		t.Fatal("expected idle timeout error")
+	}
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeTimeout {
+		t.Errorf("expected timeout error, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("idle timeout took too long: %v", elapsed)
+	}
+}
+
+func TestStream_RequestTimeout(t *testing.T) {
+	server := newStallingServer(t)
+	defer server.Close()
+
+	r, err := New(WithAnthropic("test-key", provider.WithBaseURL(server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream, err := r.Stream(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Timeout:  50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Close()
+
+	for {
+		event, err := stream.Next()
+		if err != nil {
+			var rerr *errors.RouterError
+			if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeTimeout {
+				t.Errorf("expected timeout error, got %v", err)
+			}
+			return
+		}
+		if event == nil {
+			t.Fatal("expected stream to fail with timeout")
+		}
+	}
+}
+
+func TestComplete_RequestTimeout(t *testing.T) {
+	server := newStallingServer(t)
+	defer server.Close()
+
+	r, err := New(WithAnthropic("test-key", provider.WithBaseURL(server.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = r.Complete(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Timeout:  50 * time.Millisecond,
+	})
+	var rerr *errors.RouterError
+	if !stderrors.As(err, &rerr) || rerr.Code != errors.ErrCodeTimeout {
+		t.Fatalf("expected timeout error, got %v", err)
+	}
+	if elapsed, ok := rerr.Details["elapsed"].(time.Duration); !ok || elapsed < 50*time.Millisecond {
+		t.Errorf("elapsed = %v, want at least the 50ms timeout", rerr.Details["elapsed"])
+	}
+	if rerr.Provider != types.ProviderAnthropic || !errors.IsRetryable(err) {
+		t.Errorf("expected a retryable anthropic timeout, got %v", err)
+	}
+}
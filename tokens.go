@@ -0,0 +1,44 @@
+package router
+
+import (
+	"context"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+// CountTokens returns the number of input tokens req would use with its
+// provider and model, as counted by the provider's token counting API. It
+// does not run the model. Model aliases are resolved as by Complete, and a
+// tenant's own key is used if it has one.
+//
+// Providers without such an API return an ErrCodeUnsupportedFeature error;
+// rag.EstimateTokens gives a rough count for any text.
+func (r *Router) CountTokens(ctx context.Context, req *types.CompletionRequest) (int, error) {
+	req, err := r.resolveAlias(req)
+	if err != nil {
+		return 0, err
+	}
+
+	p, err := r.providerFor(req)
+	if err != nil {
+		return 0, err
+	}
+	counter, ok := p.(provider.TokenCounter)
+	if !ok || !p.SupportsFeature(types.FeatureTokenCounting) {
+		return 0, errors.ErrUnsupportedFeature(req.Provider, types.FeatureTokenCounting)
+	}
+
+	if req.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.Timeout)
+		defer cancel()
+	}
+
+	n, err := counter.CountTokens(ctx, req)
+	if err != nil {
+		return 0, timeoutError(ctx, req.Provider, err)
+	}
+	return n, nil
+}
@@ -0,0 +1,54 @@
+package router
+
+import (
+	"context"
+	stderrors "errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Chloe199719/agent-router/pkg/errors"
+	"github.com/Chloe199719/agent-router/pkg/provider"
+	"github.com/Chloe199719/agent-router/pkg/types"
+)
+
+func TestCountTokens(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/messages/count_tokens" {
+			t.Errorf("path = %s", r.URL.Path)
+		}
+		w.Write([]byte(`{"input_tokens":17}`))
+	}))
+	defer srv.Close()
+
+	r, err := New(WithAnthropic("key", provider.WithBaseURL(srv.URL)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	n, err := r.CountTokens(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderAnthropic,
+		Model:    "claude-haiku-4-5",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 17 {
+		t.Errorf("tokens = %d, want 17", n)
+	}
+}
+
+func TestCountTokens_Unsupported(t *testing.T) {
+	r, err := New(WithOpenAI("key"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = r.CountTokens(context.Background(), &types.CompletionRequest{
+		Provider: types.ProviderOpenAI,
+		Model:    "gpt-4o",
+		Messages: []types.Message{types.NewTextMessage(types.RoleUser, "Hello")},
+	})
+	if !stderrors.Is(err, errors.NewError(errors.ErrCodeUnsupportedFeature, "")) {
+		t.Errorf("err = %v, want unsupported feature", err)
+	}
+}